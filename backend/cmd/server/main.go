@@ -4,9 +4,13 @@ import (
 	"context"
 	"log/slog"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/cors"
+	"github.com/gofiber/fiber/v3/middleware/etag"
 	"github.com/unforced/parachute-backend/internal/acp"
 	"github.com/unforced/parachute-backend/internal/api/handlers"
 	"github.com/unforced/parachute-backend/internal/domain/conversation"
@@ -102,12 +106,60 @@ func main() {
 	spaceRepo := sqlite.NewSpaceRepository(db.DB)
 	conversationRepo := sqlite.NewConversationRepository(db.DB)
 	registryRepo := sqlite.NewRegistryRepository(db.DB)
+	idempotencyStore := sqlite.NewIdempotencyStore(db.DB)
 
 	// Initialize services
 	registryService := registry.NewService(registryRepo, parachuteRoot)
 	spaceService := space.NewService(spaceRepo, parachuteRoot)
+	spaceLimits := space.DefaultSpaceLimits()
+	if maxSpaces := os.Getenv("MAX_SPACES_PER_USER"); maxSpaces != "" {
+		if limit, err := strconv.Atoi(maxSpaces); err == nil {
+			spaceLimits.MaxSpacesPerUser = limit
+		} else {
+			slog.Warn("Ignoring invalid MAX_SPACES_PER_USER", "value", maxSpaces)
+		}
+	}
+	if countArchived := os.Getenv("COUNT_ARCHIVED_SPACES"); countArchived != "" {
+		if count, err := strconv.ParseBool(countArchived); err == nil {
+			spaceLimits.CountArchived = count
+		} else {
+			slog.Warn("Ignoring invalid COUNT_ARCHIVED_SPACES", "value", countArchived)
+		}
+	}
+	spaceService.SetSpaceLimits(spaceLimits)
 	conversationService := conversation.NewService(conversationRepo)
 	spaceDBService := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceDBService.SetSpaceRepository(spaceRepo)
+	spaceService.SetDatabaseService(spaceDBService)
+
+	// Stop runaway clients from hammering one space.sqlite;
+	// SPACE_WRITE_RATE=0 turns the limit off
+	writeLimit := space.DefaultWriteRateLimit
+	if rate := os.Getenv("SPACE_WRITE_RATE"); rate != "" {
+		if n, err := strconv.ParseFloat(rate, 64); err == nil && n >= 0 {
+			writeLimit.PerSecond = n
+		} else {
+			slog.Warn("Ignoring invalid SPACE_WRITE_RATE", "value", rate)
+		}
+	}
+	if burst := os.Getenv("SPACE_WRITE_BURST"); burst != "" {
+		if n, err := strconv.Atoi(burst); err == nil && n > 0 {
+			writeLimit.Burst = n
+		} else {
+			slog.Warn("Ignoring invalid SPACE_WRITE_BURST", "value", burst)
+		}
+	}
+	spaceDBService.SetWriteRateLimit(writeLimit)
+
+	// Refuse to load capture files larger than this into memory;
+	// MAX_CAPTURE_SIZE=0 turns the limit off
+	if maxSize := os.Getenv("MAX_CAPTURE_SIZE"); maxSize != "" {
+		if n, err := strconv.ParseInt(maxSize, 10, 64); err == nil && n >= 0 {
+			spaceDBService.SetMaxCaptureSize(n)
+		} else {
+			slog.Warn("Ignoring invalid MAX_CAPTURE_SIZE", "value", maxSize)
+		}
+	}
 
 	// Log registry initialization
 	slog.Info("Registry service initialized",
@@ -120,8 +172,54 @@ func main() {
 		slog.Warn("Failed to migrate spaces", "error", err)
 	}
 
+	// Forget unlinked notes once they've been in the trash past the retention
+	// period, checking daily from a day after startup so the first run stays
+	// clear of startup migrations; TRASH_RETENTION_DAYS=0 disables the sweep
+	trashRetention := space.DefaultTrashRetention
+	if days := os.Getenv("TRASH_RETENTION_DAYS"); days != "" {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			trashRetention = time.Duration(n) * 24 * time.Hour
+		} else {
+			slog.Warn("Ignoring invalid TRASH_RETENTION_DAYS", "value", days)
+		}
+	}
+	if trashRetention > 0 {
+		go func() {
+			for {
+				time.Sleep(24 * time.Hour)
+				purged, err := spaceDBService.PurgeExpiredDeleted(parachuteRoot, trashRetention)
+				if err != nil {
+					slog.Warn("Failed to purge trash", "error", err)
+				}
+				if purged > 0 {
+					slog.Info("Purged trash", "notes", purged)
+				}
+			}
+		}()
+	}
+
+	// Unlink notes whose expiry has passed, checking hourly. Listings hide
+	// them as soon as they expire; the sweep only clears them out.
+	go func() {
+		for ; ; time.Sleep(time.Hour) {
+			unlinked, err := spaceDBService.SweepExpiredNotes(parachuteRoot)
+			if err != nil {
+				slog.Warn("Failed to unlink expired notes", "error", err)
+			}
+			if unlinked > 0 {
+				slog.Info("Unlinked expired notes", "notes", unlinked)
+			}
+		}
+	}()
+
 	// Initialize context service for CLAUDE.md variable resolution
 	contextService := space.NewContextService(spaceDBService)
+	if allowed := os.Getenv("CONTEXT_VARIABLES"); allowed != "" {
+		if err := contextService.SetAllowedVariables(strings.Split(allowed, ",")); err != nil {
+			slog.Error("Invalid CONTEXT_VARIABLES", "value", allowed, "error", err)
+			os.Exit(1)
+		}
+	}
 
 	// Initialize file service
 	slog.Info("Initializing file service", "root", parachuteRoot)
@@ -137,7 +235,26 @@ func main() {
 	spaceHandler := handlers.NewSpaceHandler(spaceService)
 	fileHandler := handlers.NewFileHandler(fileService)
 	spaceNotesHandler := handlers.NewSpaceNotesHandler(spaceService, spaceDBService)
+	spaceContextHandler := handlers.NewSpaceContextHandler(spaceService, spaceDBService, contextService)
+	spaceSettingsHandler := handlers.NewSpaceSettingsHandler(spaceService, spaceDBService)
+	spaceSavedSearchHandler := handlers.NewSpaceSavedSearchHandler(spaceService, spaceDBService)
+	spaceCollectionHandler := handlers.NewSpaceCollectionHandler(spaceService, spaceDBService)
 	swaggerHandler := handlers.NewSwaggerHandler()
+	adminHandler := handlers.NewAdminHandler(spaceService, spaceDBService, trashRetention)
+	idempotent := handlers.Idempotency(idempotencyStore, handlers.DefaultIdempotencyTTL)
+
+	// Compress large note listings and content; ETags are computed on the
+	// uncompressed body and made encoding-aware by the compression middleware
+	compressionMinSize := handlers.DefaultCompressionMinSize
+	if minSize := os.Getenv("RESPONSE_COMPRESSION_MIN_SIZE"); minSize != "" {
+		if size, err := strconv.Atoi(minSize); err == nil {
+			compressionMinSize = size
+		} else {
+			slog.Warn("Ignoring invalid RESPONSE_COMPRESSION_MIN_SIZE", "value", minSize)
+		}
+	}
+	compressed := handlers.Compression(compressionMinSize)
+	etagged := etag.New()
 
 	// Initialize WebSocket handler if ACP is available
 	var wsHandler *handlers.WebSocketHandler
@@ -156,8 +273,9 @@ func main() {
 
 	// Middleware
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: []string{"*"},
-		AllowHeaders: []string{"Origin", "Content-Type", "Accept"},
+		AllowOrigins:  []string{"*"},
+		AllowHeaders:  []string{"Origin", "Content-Type", "Accept", handlers.IdempotencyKeyHeader},
+		ExposeHeaders: []string{handlers.IdempotentReplayedHeader, handlers.ContextVersionHeader},
 	}))
 
 	app.Use(func(c fiber.Ctx) error {
@@ -200,30 +318,125 @@ func main() {
 
 	// Capture operations
 	registry.Get("/captures", registryHandler.ListCaptures)
-	registry.Post("/captures", registryHandler.AddCapture)
+	registry.Post("/captures", registryHandler.AddCapture, idempotent)
 	registry.Get("/captures/:id", registryHandler.GetCapture)
 
 	// Settings operations
 	registry.Get("/settings", registryHandler.GetSettings)
 	registry.Put("/settings/:key", registryHandler.SetSetting)
 
+	// Admin routes
+	admin := api.Group("/admin")
+	admin.Post("/purge-trash", adminHandler.PurgeTrash)
+	admin.Get("/vault-report", adminHandler.VaultReport)
+
+	// Vault-wide tag routes
+	api.Get("/tags/vault", spaceHandler.GetVaultTagStats)
+	api.Get("/tags/suggest", spaceHandler.SuggestTags)
+
+	// Data export routes
+	api.Get("/export/all", spaceHandler.ExportAllUserData)
+
+	// SPACE.md template variables supported by this server
+	api.Get("/context/variables", spaceContextHandler.ListSupportedVariables)
+
 	// Space routes (legacy, still used by frontend)
 	spaces := api.Group("/spaces")
 	spaces.Get("/", spaceHandler.List)
 	spaces.Post("/", spaceHandler.Create)
+	spaces.Get("/recent", spaceHandler.ListRecent)
+	spaces.Get("/stale-backups", spaceHandler.ListStaleBackups)
 	spaces.Get("/:id", spaceHandler.Get)
 	spaces.Put("/:id", spaceHandler.Update)
+	spaces.Post("/:id/rename", spaceHandler.Rename)
 	spaces.Delete("/:id", spaceHandler.Delete)
+	spaces.Post("/:id/files/move", spaceHandler.MoveFile)
+	spaces.Post("/:id/files/copy", spaceHandler.CopyFile)
 
 	// Space notes routes
-	spaces.Get("/:id/notes", spaceNotesHandler.GetNotes)
-	spaces.Post("/:id/notes", spaceNotesHandler.LinkNote)
+	spaces.Get("/:id/notes", spaceNotesHandler.GetNotes, compressed, etagged)
+	spaces.Get("/:id/notes/grouped", spaceNotesHandler.GetNotesGroupedByTag, compressed, etagged)
+	spaces.Get("/:id/notes/histogram", spaceNotesHandler.GetNoteHistogram)
+	spaces.Get("/:id/notes/untagged", spaceNotesHandler.GetUntaggedNotes)
+	spaces.Get("/:id/notes/duplicates", spaceNotesHandler.FindNearDuplicates)
+	spaces.Get("/:id/tags/tree", spaceNotesHandler.GetTagTree)
+	spaces.Get("/:id/tags/:tag/timeline", spaceNotesHandler.GetTagTimeline)
+	spaces.Get("/:id/analytics/references", spaceNotesHandler.GetReferenceAnalytics)
+	spaces.Get("/:id/diff", spaceNotesHandler.DiffSpaces)
+	spaces.Get("/:id/featured-notes", spaceNotesHandler.GetFeaturedNotes)
+	spaces.Put("/:id/featured-notes", spaceNotesHandler.SetFeaturedNotes)
+	spaces.Post("/:id/notes", spaceNotesHandler.LinkNote, idempotent)
+	spaces.Post("/:id/captures", spaceNotesHandler.CreateCapture, idempotent)
+	spaces.Post("/:id/notes/batch-get", spaceNotesHandler.BatchGetNotes, compressed)
+	spaces.Post("/:id/notes/from-captures", spaceNotesHandler.LinkFromCaptures)
+	spaces.Post("/:id/notes/bulk-tags", spaceNotesHandler.BulkUpdateTags)
+	spaces.Post("/:id/notes/bulk-unlink", spaceNotesHandler.BulkUnlink)
+	spaces.Post("/:id/notes/decay", spaceNotesHandler.DecayRelevance)
 	spaces.Put("/:id/notes/:capture_id", spaceNotesHandler.UpdateNoteContext)
 	spaces.Delete("/:id/notes/:capture_id", spaceNotesHandler.UnlinkNote)
-	spaces.Get("/:id/notes/:capture_id/content", spaceNotesHandler.GetNoteContent)
+	spaces.Put("/:id/notes/:capture_id/status", spaceNotesHandler.SetNoteStatus)
+	spaces.Put("/:id/notes/:capture_id/due", spaceNotesHandler.SetNoteDue)
+	spaces.Put("/:id/notes/:capture_id/expiry", spaceNotesHandler.SetNoteExpiry)
+	spaces.Get("/:id/notes/:capture_id/thread", spaceNotesHandler.GetNoteThread)
+	spaces.Post("/:id/notes/:capture_id/move", spaceNotesHandler.MoveNote)
+	spaces.Put("/:id/notes/:capture_id/priority", spaceNotesHandler.SetNotePriority)
+	spaces.Put("/:id/notes/:capture_id/reminder", spaceNotesHandler.SetReminder)
+	spaces.Delete("/:id/notes/:capture_id/reminder", spaceNotesHandler.DismissReminder)
+	spaces.Get("/:id/notes/:capture_id/content", spaceNotesHandler.GetNoteContent, compressed, etagged)
+	spaces.Get("/:id/notes/:capture_id/structure", spaceNotesHandler.GetNoteStructure)
+	spaces.Get("/:id/notes/:capture_id/chunks", spaceNotesHandler.GetNoteChunks)
+	spaces.Get("/:id/notes/:capture_id/explain", spaceNotesHandler.ExplainLink)
+	spaces.Get("/:id/notes/:capture_id/attachments", spaceNotesHandler.GetNoteAttachments)
+	spaces.Get("/:id/export/markdown", spaceNotesHandler.ExportNotesMarkdown, compressed)
 	spaces.Get("/:id/database/stats", spaceNotesHandler.GetDatabaseStats)
+	spaces.Get("/:id/database/health", spaceNotesHandler.GetDatabaseHealth)
+	spaces.Get("/:id/database/status", spaceNotesHandler.GetDatabaseStatus)
+	spaces.Post("/:id/database/recompute", spaceNotesHandler.RecomputeDatabase)
+	spaces.Post("/:id/database/reset", spaceNotesHandler.ResetDatabase)
+	spaces.Post("/:id/reindex", spaceNotesHandler.StartReindex)
+	spaces.Get("/:id/reindex/:job_id", spaceNotesHandler.GetReindexStatus)
+	spaces.Delete("/:id/reindex/:job_id", spaceNotesHandler.CancelReindex)
+	spaces.Post("/:id/manifest", spaceNotesHandler.ExportManifest)
 	spaces.Get("/:id/database/tables/:table_name", spaceNotesHandler.GetTableData)
 
+	// Space context routes
+	spaces.Get("/:id/context/estimate", spaceContextHandler.EstimateTokens)
+	spaces.Get("/:id/context/version", spaceContextHandler.GetContextVersion)
+	spaces.Get("/:id/context/variables", spaceContextHandler.PreviewVariables)
+	spaces.Post("/:id/context/preview", spaceContextHandler.PreviewContext)
+	spaces.Get("/:id/context/history", spaceContextHandler.GetContextHistory)
+	spaces.Post("/:id/context/freeze", spaceContextHandler.FreezeContext)
+	spaces.Delete("/:id/context/freeze", spaceContextHandler.UnfreezeContext)
+	spaces.Get("/:id/notes/:capture_id/suggest-context", spaceContextHandler.SuggestContext)
+
+	// Space settings routes
+	spaces.Get("/:id/settings", spaceSettingsHandler.GetSettings)
+	spaces.Get("/:id/settings/bulk", spaceSettingsHandler.ExportSettings)
+	spaces.Put("/:id/settings/bulk", spaceSettingsHandler.ImportSettings) // Before :key so "bulk" is not taken as a key
+	spaces.Put("/:id/settings/:key", spaceSettingsHandler.SetSetting)
+	spaces.Get("/:id/export/template", spaceSettingsHandler.ExportTemplate)
+	spaces.Get("/:id/pinned-context", spaceSettingsHandler.GetPinnedContext)
+	spaces.Put("/:id/pinned-context", spaceSettingsHandler.SetPinnedContext)
+
+	// Space saved search routes
+	spaces.Get("/:id/saved-searches", spaceSavedSearchHandler.ListSavedSearches)
+	spaces.Get("/:id/saved-searches/:name", spaceSavedSearchHandler.GetSavedSearch)
+	spaces.Put("/:id/saved-searches/:name", spaceSavedSearchHandler.SaveSearch)
+	spaces.Delete("/:id/saved-searches/:name", spaceSavedSearchHandler.DeleteSavedSearch)
+	spaces.Get("/:id/saved-searches/:name/notes", spaceSavedSearchHandler.RunSavedSearch)
+	spaces.Get("/:id/saved-searches/:name/export", spaceSavedSearchHandler.ExportSavedSearch)
+
+	// Space collection routes
+	spaces.Get("/:id/collections", spaceCollectionHandler.ListCollections)
+	spaces.Post("/:id/collections", spaceCollectionHandler.CreateCollection)
+	spaces.Get("/:id/collections/:collection_id", spaceCollectionHandler.GetCollection)
+	spaces.Put("/:id/collections/:collection_id", spaceCollectionHandler.RenameCollection)
+	spaces.Delete("/:id/collections/:collection_id", spaceCollectionHandler.DeleteCollection)
+	spaces.Get("/:id/collections/:collection_id/notes", spaceCollectionHandler.GetCollectionNotes)
+	spaces.Post("/:id/collections/:collection_id/notes", spaceCollectionHandler.AddToCollection)
+	spaces.Put("/:id/collections/:collection_id/notes", spaceCollectionHandler.ReorderCollection)
+	spaces.Delete("/:id/collections/:collection_id/notes/:capture_id", spaceCollectionHandler.RemoveFromCollection)
+
 	// Conversation routes
 	conversations := api.Group("/conversations")
 	conversations.Get("/", func(c fiber.Ctx) error {
@@ -312,12 +525,15 @@ func main() {
 
 	// File/Capture routes
 	captures := api.Group("/captures")
-	captures.Post("/upload", fileHandler.UploadCapture)
+	captures.Post("/upload", fileHandler.UploadCapture, idempotent)
 	captures.Get("/", fileHandler.ListCaptures)
+	captures.Get("/search", spaceHandler.SearchCaptures) // Before /:filename, which would match it
+	captures.Get("/unlinked", spaceHandler.ListUnlinkedCaptures)
 	captures.Get("/:filename", fileHandler.DownloadCapture)
 	captures.Post("/:filename/transcript", fileHandler.UploadTranscript)
 	captures.Get("/:filename/transcript", fileHandler.DownloadTranscript)
 	captures.Delete("/:filename", fileHandler.DeleteCapture)
+	captures.Post("/:capture_id/rename", spaceHandler.RenameCapture)
 
 	// File browser routes
 	files := api.Group("/files")
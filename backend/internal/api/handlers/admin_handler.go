@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+// AdminHandler handles HTTP requests for vault maintenance
+type AdminHandler struct {
+	spaceService   *space.Service
+	spaceDBService *space.SpaceDatabaseService
+	trashRetention time.Duration
+}
+
+// NewAdminHandler creates a new admin handler. trashRetention is used by
+// PurgeTrash when the request doesn't give a number of days; zero or less
+// means space.DefaultTrashRetention.
+func NewAdminHandler(spaceService *space.Service, spaceDBService *space.SpaceDatabaseService, trashRetention time.Duration) *AdminHandler {
+	if trashRetention <= 0 {
+		trashRetention = space.DefaultTrashRetention
+	}
+	return &AdminHandler{
+		spaceService:   spaceService,
+		spaceDBService: spaceDBService,
+		trashRetention: trashRetention,
+	}
+}
+
+// PurgeTrash handles POST /api/admin/purge-trash
+func (h *AdminHandler) PurgeTrash(c fiber.Ctx) error {
+	retention := h.trashRetention
+	if daysStr := c.Query("days"); daysStr != "" {
+		days, err := strconv.Atoi(daysStr)
+		if err != nil || days < 0 {
+			return HandleError(c, domain.NewValidationError("days", "must be a non-negative integer"))
+		}
+		retention = time.Duration(days) * 24 * time.Hour
+	}
+
+	purged, err := h.spaceDBService.PurgeExpiredDeleted(h.spaceDBService.VaultRoot(), retention)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"purged":         purged,
+		"retention_days": int(retention / (24 * time.Hour)),
+	})
+}
+
+// VaultReport handles GET /api/admin/vault-report
+func (h *AdminHandler) VaultReport(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 60*time.Second)
+	defer cancel()
+
+	// TODO: Get user ID from auth context
+	userID := "default"
+
+	report, err := h.spaceService.ValidateVault(ctx, userID)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(report)
+}
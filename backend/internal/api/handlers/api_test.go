@@ -33,6 +33,7 @@ func setupTestApp(t *testing.T) (*fiber.App, *sqlite.Database) {
 	spaceService := space.NewService(spaceRepo, "/tmp/parachute-test")
 	conversationService := conversation.NewService(conversationRepo)
 	spaceDBService := space.NewSpaceDatabaseService("/tmp/parachute-test")
+	spaceService.SetDatabaseService(spaceDBService)
 	contextService := space.NewContextService(spaceDBService)
 
 	// Initialize handlers
@@ -49,8 +50,10 @@ func setupTestApp(t *testing.T) (*fiber.App, *sqlite.Database) {
 	spaces := api.Group("/spaces")
 	spaces.Get("/", spaceHandler.List)
 	spaces.Post("/", spaceHandler.Create)
+	spaces.Get("/recent", spaceHandler.ListRecent)
 	spaces.Get("/:id", spaceHandler.Get)
 	spaces.Put("/:id", spaceHandler.Update)
+	spaces.Post("/:id/rename", spaceHandler.Rename)
 	spaces.Delete("/:id", spaceHandler.Delete)
 
 	// Conversation routes
@@ -152,6 +155,28 @@ func TestSpaceAPI(t *testing.T) {
 		assert.Greater(t, len(spaces), 0)
 	})
 
+	t.Run("ListRecentSpaces", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/spaces/recent?limit=5", nil)
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result map[string]interface{}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		require.NoError(t, err)
+
+		spaces, ok := result["spaces"].([]interface{})
+		require.True(t, ok, "Expected 'spaces' key with array value")
+		require.NotEmpty(t, spaces)
+
+		first := spaces[0].(map[string]interface{})
+		assert.Equal(t, createdSpaceID, first["space"].(map[string]interface{})["id"])
+		assert.Equal(t, "created", first["activity_source"])
+	})
+
 	t.Run("GetSpace", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/api/spaces/"+createdSpaceID, nil)
 
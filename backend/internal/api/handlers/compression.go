@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// DefaultCompressionMinSize is the smallest response body, in bytes, worth compressing
+const DefaultCompressionMinSize = 1024
+
+// Supported content encodings, in order of preference
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+)
+
+// Compression returns middleware that gzip- or deflate-compresses 200 responses
+// of at least minSize bytes when the client's Accept-Encoding allows it. A
+// negative minSize disables compression.
+//
+// ETags are made encoding-aware by suffixing the encoding ("abc" becomes
+// "abc-gzip"), so caches never confuse the compressed and identity bodies.
+// The suffix is stripped from If-None-Match before inner handlers (such as the
+// etag middleware) see it and restored on 304 responses, so conditional
+// requests keep working. Register it outside the etag middleware.
+func Compression(minSize int) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if minSize < 0 {
+			return c.Next()
+		}
+
+		encoding := negotiateEncoding(c.Get(fiber.HeaderAcceptEncoding))
+
+		// The client echoes back the ETag of the representation it holds
+		clientETag := strings.Clone(c.Get(fiber.HeaderIfNoneMatch))
+		clientEncoding := ""
+		if clientETag != "" {
+			stripped, enc := stripETagEncoding(clientETag)
+			if enc != "" {
+				clientEncoding = enc
+				c.Request().Header.Set(fiber.HeaderIfNoneMatch, stripped)
+			}
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		c.Vary(fiber.HeaderAcceptEncoding)
+
+		resp := c.Response()
+		switch resp.StatusCode() {
+		case fiber.StatusNotModified:
+			if clientEncoding == "" {
+				return nil
+			}
+			if len(resp.Header.Peek(fiber.HeaderETag)) > 0 {
+				tagETag(c, clientEncoding)
+			} else if !strings.Contains(clientETag, ",") {
+				// The etag middleware omits the ETag on 304; echo the one that matched
+				resp.Header.Set(fiber.HeaderETag, strings.TrimSpace(clientETag))
+			}
+			return nil
+		case fiber.StatusOK:
+		default:
+			return nil
+		}
+
+		body := resp.Body()
+		if encoding == "" || len(body) < minSize || len(resp.Header.Peek(fiber.HeaderContentEncoding)) > 0 {
+			return nil
+		}
+
+		compressed, err := compressBody(body, encoding)
+		if err != nil {
+			// Fall back to the uncompressed body
+			return nil
+		}
+
+		resp.SetBodyRaw(compressed)
+		resp.Header.Set(fiber.HeaderContentEncoding, encoding)
+		tagETag(c, encoding)
+
+		return nil
+	}
+}
+
+// negotiateEncoding picks gzip or deflate from an Accept-Encoding header,
+// honoring q=0 exclusions. It returns "" if neither is acceptable.
+func negotiateEncoding(acceptEncoding string) string {
+	accepted := map[string]bool{}
+	wildcard := false
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.TrimSpace(key) == "q" {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		if name == "*" {
+			wildcard = q > 0
+			continue
+		}
+		accepted[name] = q > 0
+	}
+
+	for _, enc := range []string{encodingGzip, encodingDeflate} {
+		if ok, listed := accepted[enc]; ok || (!listed && wildcard) {
+			return enc
+		}
+	}
+	return ""
+}
+
+// compressBody compresses body with the given encoding
+func compressBody(body []byte, encoding string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	var w interface {
+		Write([]byte) (int, error)
+		Close() error
+	}
+	var err error
+	if encoding == encodingGzip {
+		w = gzip.NewWriter(&buf)
+	} else {
+		w, err = flate.NewWriter(&buf, flate.DefaultCompression)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// tagETag appends the encoding to the response ETag, if there is one
+func tagETag(c fiber.Ctx, encoding string) {
+	etag := string(c.Response().Header.Peek(fiber.HeaderETag))
+	if !strings.HasSuffix(etag, `"`) || strings.HasSuffix(etag, "-"+encoding+`"`) {
+		return
+	}
+	c.Response().Header.Set(fiber.HeaderETag, etag[:len(etag)-1]+"-"+encoding+`"`)
+}
+
+// stripETagEncoding removes encoding suffixes added by tagETag from each ETag
+// in an If-None-Match header. It returns the rewritten header and the encoding
+// found ("" if none).
+func stripETagEncoding(ifNoneMatch string) (string, string) {
+	found := ""
+	tags := strings.Split(ifNoneMatch, ",")
+	for i, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		for _, enc := range []string{encodingGzip, encodingDeflate} {
+			suffix := "-" + enc + `"`
+			if strings.HasSuffix(tag, suffix) {
+				tag = tag[:len(tag)-len(suffix)] + `"`
+				found = enc
+				break
+			}
+		}
+		tags[i] = tag
+	}
+	return strings.Join(tags, ", "), found
+}
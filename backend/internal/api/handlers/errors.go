@@ -2,9 +2,12 @@ package handlers
 
 import (
 	"errors"
+	"math"
+	"strconv"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
 )
 
 // HandleError maps domain errors to appropriate HTTP responses
@@ -17,6 +20,13 @@ func HandleError(c fiber.Ctx, err error) error {
 		})
 	}
 
+	var goneErr *domain.GoneError
+	if errors.As(err, &goneErr) {
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{
+			"error": goneErr.Error(),
+		})
+	}
+
 	var validationErr *domain.ValidationError
 	if errors.As(err, &validationErr) {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -46,8 +56,40 @@ func HandleError(c fiber.Ctx, err error) error {
 		})
 	}
 
+	if errors.Is(err, space.ErrRateLimited) {
+		return tooManyRequests(c, err)
+	}
+
+	if errors.Is(err, space.ErrCaptureTooLarge) {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
 	// Default to internal server error
 	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 		"error": "Internal server error",
 	})
 }
+
+// tooManyRequests responds 429 to a write rejected by a space's rate limit,
+// telling the client when to retry
+func tooManyRequests(c fiber.Ctx, err error) error {
+	var limitErr *space.RateLimitError
+	if errors.As(err, &limitErr) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(limitErr.RetryAfter.Seconds()))))
+	}
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+// invalidJSONBody responds 400 to a request body that could not be decoded,
+// keeping the decoder's message in detail so it is distinguishable from a
+// well-formed body that fails validation
+func invalidJSONBody(c fiber.Ctx, err error) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"error":  "invalid JSON body",
+		"detail": err.Error(),
+	})
+}
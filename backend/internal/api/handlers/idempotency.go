@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/unforced/parachute-backend/internal/domain/idempotency"
+)
+
+// IdempotencyKeyHeader is the request header clients set to make a POST safe to retry
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is set on responses replayed from the idempotency store
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// DefaultIdempotencyTTL is how long processed keys are remembered
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// Idempotency returns middleware that replays the stored response when a request
+// carrying an Idempotency-Key header has already been processed within ttl.
+// Requests without the header pass through untouched. Keys are scoped to the
+// method and path, and a key that is still being processed gets 409 Conflict.
+// Server errors (5xx) are not stored so the client can retry them.
+func Idempotency(store idempotency.Store, ttl time.Duration) fiber.Handler {
+	var mu sync.Mutex
+	inFlight := make(map[string]bool)
+
+	return func(c fiber.Ctx) error {
+		key := c.Get(IdempotencyKeyHeader)
+		if key == "" {
+			return c.Next()
+		}
+
+		method := c.Method()
+		path := c.Path()
+		now := time.Now()
+
+		record, err := store.Get(c.Context(), key, method, path, now.Add(-ttl))
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		if record != nil {
+			c.Set(IdempotentReplayedHeader, "true")
+			if record.ContentType != "" {
+				c.Set(fiber.HeaderContentType, record.ContentType)
+			}
+			return c.Status(record.StatusCode).Send(record.Body)
+		}
+
+		lockKey := method + " " + path + " " + key
+		mu.Lock()
+		if inFlight[lockKey] {
+			mu.Unlock()
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "a request with this idempotency key is already in progress",
+			})
+		}
+		inFlight[lockKey] = true
+		mu.Unlock()
+
+		defer func() {
+			mu.Lock()
+			delete(inFlight, lockKey)
+			mu.Unlock()
+		}()
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			return nil
+		}
+
+		record = &idempotency.Record{
+			Key:         key,
+			Method:      method,
+			Path:        path,
+			StatusCode:  status,
+			ContentType: string(c.Response().Header.ContentType()),
+			Body:        append([]byte(nil), c.Response().Body()...),
+			CreatedAt:   now,
+		}
+
+		if err := store.Save(c.Context(), record); err != nil {
+			slog.Error("Failed to save idempotency key", "error", err, "key", key, "path", path)
+			return nil
+		}
+
+		if err := store.DeleteExpired(c.Context(), now.Add(-ttl)); err != nil {
+			slog.Warn("Failed to delete expired idempotency keys", "error", err)
+		}
+
+		return nil
+	}
+}
@@ -429,16 +429,13 @@ func (h *MessageHandler) buildPromptWithContext(
 ) string {
 	prompt := ""
 
-	// Include SPACE.md context if it exists
-	spaceMD, err := h.spaceService.ReadSpaceMD(spaceObj)
-	if err == nil && spaceMD != "" {
-		// Resolve dynamic variables in SPACE.md
-		resolvedSpaceMD, err := h.contextService.ResolveVariables(spaceMD, spaceObj.Path)
-		if err != nil {
-			log.Printf("⚠️  Failed to resolve SPACE.md variables: %v", err)
-			resolvedSpaceMD = spaceMD // Fallback to unresolved
-		}
-
+	// Include SPACE.md context (with dynamic variables resolved) if it exists
+	resolvedSpaceMD, err := h.contextService.RenderSpaceMD(spaceObj)
+	if err != nil {
+		log.Printf("⚠️  Failed to resolve SPACE.md variables: %v", err)
+		resolvedSpaceMD, _ = h.spaceService.ReadSpaceMD(spaceObj) // Fallback to unresolved
+	}
+	if resolvedSpaceMD != "" {
 		prompt += "# Context from SPACE.md\n\n"
 		prompt += resolvedSpaceMD
 		prompt += "\n\n---\n\n"
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+// applyRelativeLinkDates narrows filters by the relative link-date query
+// parameters, for clients that don't want to build RFC3339 timestamps:
+//
+//	linked_within=7d      linked in the last 7 days (same as linked_after=7d)
+//	linked_after=EXPR     linked at or after EXPR
+//	linked_before=EXPR    linked at or before EXPR
+//
+// EXPR is a count of hours, days, weeks or months back from now (12h, 7d,
+// 2w, 3m) or one of today, yesterday and this_week (midnight at the start of
+// the day or the Monday, server local time). Where these overlap with
+// start_date/end_date the narrower bound wins. An invalid expression is a
+// 400.
+func applyRelativeLinkDates(c fiber.Ctx, filters *space.NoteFilters, now time.Time) error {
+	for _, param := range []string{"linked_within", "linked_after"} {
+		expr := c.Query(param)
+		if expr == "" {
+			continue
+		}
+		start, err := parseRelativeTime(expr, now)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid %s: %v", param, err))
+		}
+		if filters.StartDate == nil || start.After(*filters.StartDate) {
+			filters.StartDate = &start
+		}
+	}
+
+	if expr := c.Query("linked_before"); expr != "" {
+		end, err := parseRelativeTime(expr, now)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid linked_before: %v", err))
+		}
+		if filters.EndDate == nil || end.Before(*filters.EndDate) {
+			filters.EndDate = &end
+		}
+	}
+
+	return nil
+}
+
+// parseRelativeTime resolves a relative expression (see
+// applyRelativeLinkDates) against now
+func parseRelativeTime(expr string, now time.Time) (time.Time, error) {
+	expr = strings.ToLower(strings.TrimSpace(expr))
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch expr {
+	case "today":
+		return midnight, nil
+	case "yesterday":
+		return midnight.AddDate(0, 0, -1), nil
+	case "this_week":
+		// Weeks start on Monday
+		return midnight.AddDate(0, 0, -((int(now.Weekday()) + 6) % 7)), nil
+	}
+
+	if len(expr) < 2 {
+		return time.Time{}, fmt.Errorf("%q is not a relative time (e.g. 7d, 12h, 2w, 3m, today)", expr)
+	}
+	n, err := strconv.Atoi(expr[:len(expr)-1])
+	if err != nil || n < 0 {
+		return time.Time{}, fmt.Errorf("%q is not a relative time (e.g. 7d, 12h, 2w, 3m, today)", expr)
+	}
+
+	switch expr[len(expr)-1] {
+	case 'h':
+		return now.Add(-time.Duration(n) * time.Hour), nil
+	case 'd':
+		return now.AddDate(0, 0, -n), nil
+	case 'w':
+		return now.AddDate(0, 0, -7*n), nil
+	case 'm':
+		return now.AddDate(0, -n, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a relative time (e.g. 7d, 12h, 2w, 3m, today)", expr)
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+// SpaceCollectionHandler handles HTTP requests for per-space note collections
+type SpaceCollectionHandler struct {
+	spaceService   *space.Service
+	spaceDBService *space.SpaceDatabaseService
+}
+
+// NewSpaceCollectionHandler creates a new collection handler
+func NewSpaceCollectionHandler(spaceService *space.Service, spaceDBService *space.SpaceDatabaseService) *SpaceCollectionHandler {
+	return &SpaceCollectionHandler{
+		spaceService:   spaceService,
+		spaceDBService: spaceDBService,
+	}
+}
+
+// CollectionRequest represents a request to create or rename a collection
+type CollectionRequest struct {
+	Name string `json:"name"`
+}
+
+// CollectionNotesRequest represents a request to add or reorder a collection's notes
+type CollectionNotesRequest struct {
+	CaptureIDs []string `json:"capture_ids"`
+}
+
+// ListCollections handles GET /api/spaces/:id/collections
+func (h *SpaceCollectionHandler) ListCollections(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	collections, err := h.spaceDBService.ListCollections(spaceObj.Path)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"collections": collections,
+	})
+}
+
+// CreateCollection handles POST /api/spaces/:id/collections
+func (h *SpaceCollectionHandler) CreateCollection(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	var req CollectionRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return invalidJSONBody(c, err)
+	}
+
+	// Ensure space.sqlite exists
+	if err := h.spaceDBService.InitializeSpaceDatabase(spaceObj.ID, spaceObj.Path); err != nil {
+		return HandleError(c, err)
+	}
+
+	collection, err := h.spaceDBService.CreateCollection(spaceObj.Path, req.Name)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(collection)
+}
+
+// GetCollection handles GET /api/spaces/:id/collections/:collection_id
+func (h *SpaceCollectionHandler) GetCollection(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	collection, err := h.spaceDBService.GetCollection(spaceObj.Path, c.Params("collection_id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(collection)
+}
+
+// RenameCollection handles PUT /api/spaces/:id/collections/:collection_id
+func (h *SpaceCollectionHandler) RenameCollection(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	var req CollectionRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return invalidJSONBody(c, err)
+	}
+
+	collection, err := h.spaceDBService.RenameCollection(spaceObj.Path, c.Params("collection_id"), req.Name)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(collection)
+}
+
+// DeleteCollection handles DELETE /api/spaces/:id/collections/:collection_id
+func (h *SpaceCollectionHandler) DeleteCollection(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	collectionID := c.Params("collection_id")
+	if err := h.spaceDBService.DeleteCollection(spaceObj.Path, collectionID); err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "collection deleted successfully",
+		"id":      collectionID,
+	})
+}
+
+// GetCollectionNotes handles GET /api/spaces/:id/collections/:collection_id/notes
+func (h *SpaceCollectionHandler) GetCollectionNotes(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	notes, err := h.spaceDBService.GetCollectionNotes(spaceObj.Path, c.Params("collection_id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(GetNotesResponse{
+		Notes: notes,
+		Total: len(notes),
+	})
+}
+
+// AddToCollection handles POST /api/spaces/:id/collections/:collection_id/notes
+func (h *SpaceCollectionHandler) AddToCollection(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	var req CollectionNotesRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return invalidJSONBody(c, err)
+	}
+
+	collection, err := h.spaceDBService.AddToCollection(spaceObj.Path, c.Params("collection_id"), req.CaptureIDs)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(collection)
+}
+
+// ReorderCollection handles PUT /api/spaces/:id/collections/:collection_id/notes
+func (h *SpaceCollectionHandler) ReorderCollection(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	var req CollectionNotesRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return invalidJSONBody(c, err)
+	}
+
+	collection, err := h.spaceDBService.ReorderCollection(spaceObj.Path, c.Params("collection_id"), req.CaptureIDs)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(collection)
+}
+
+// RemoveFromCollection handles DELETE /api/spaces/:id/collections/:collection_id/notes/:capture_id
+func (h *SpaceCollectionHandler) RemoveFromCollection(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	collection, err := h.spaceDBService.RemoveFromCollection(spaceObj.Path, c.Params("collection_id"), c.Params("capture_id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(collection)
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+// ContextVersionHeader carries a space's context version so clients can tell
+// whether their cached rendered context is stale
+const ContextVersionHeader = "X-Context-Version"
+
+// SpaceContextHandler handles HTTP requests about a space's rendered SPACE.md context
+type SpaceContextHandler struct {
+	spaceService   *space.Service
+	spaceDBService *space.SpaceDatabaseService
+	contextService *space.ContextService
+}
+
+// NewSpaceContextHandler creates a new space context handler
+func NewSpaceContextHandler(spaceService *space.Service, spaceDBService *space.SpaceDatabaseService, contextService *space.ContextService) *SpaceContextHandler {
+	return &SpaceContextHandler{
+		spaceService:   spaceService,
+		spaceDBService: spaceDBService,
+		contextService: contextService,
+	}
+}
+
+// EstimateTokens handles GET /api/spaces/:id/context/estimate?model=...
+func (h *SpaceContextHandler) EstimateTokens(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	estimate, err := h.contextService.EstimateContextTokens(spaceObj, c.Query("model"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(estimate)
+}
+
+// PreviewVariables handles GET /api/spaces/:id/context/variables
+func (h *SpaceContextHandler) PreviewVariables(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	variables, err := h.contextService.PreviewSpaceVariables(spaceObj)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	notices, err := h.contextService.ContextNotices(spaceObj)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"variables": variables,
+		"notices":   notices,
+	})
+}
+
+// PreviewContextRequest represents draft SPACE.md text to render
+type PreviewContextRequest struct {
+	Template *string `json:"template"`
+}
+
+// PreviewContext handles POST /api/spaces/:id/context/preview
+// The draft is resolved against the space's current data, ignoring any
+// freeze. Nothing is saved and SPACE.md is left as it is.
+func (h *SpaceContextHandler) PreviewContext(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	var req PreviewContextRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return invalidJSONBody(c, err)
+	}
+	if req.Template == nil {
+		return HandleError(c, domain.NewValidationError("template", "is required"))
+	}
+
+	output, err := h.contextService.ResolveVariables(*req.Template, spaceObj.Path)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"output": output,
+	})
+}
+
+// ListSupportedVariables handles GET /api/context/variables
+func (h *SpaceContextHandler) ListSupportedVariables(c fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"variables": h.contextService.ListSupportedVariables(),
+	})
+}
+
+// GetContextVersion handles GET /api/spaces/:id/context/version
+func (h *SpaceContextHandler) GetContextVersion(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	version, err := h.contextService.ContextVersion(spaceObj)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	c.Set(ContextVersionHeader, strconv.FormatInt(version, 10))
+	return c.JSON(fiber.Map{
+		"context_version": version,
+	})
+}
+
+// GetContextHistory handles GET /api/spaces/:id/context/history
+func (h *SpaceContextHandler) GetContextHistory(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	history, err := h.spaceDBService.GetContextHistory(spaceObj.Path, limit)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"history": history,
+		"total":   len(history),
+	})
+}
+
+// SuggestContext handles GET /api/spaces/:id/notes/:capture_id/suggest-context
+// The note_path query parameter locates captures not yet linked to the space.
+// The suggestion is returned only; nothing is saved.
+func (h *SpaceContextHandler) SuggestContext(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 30*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	captureID := c.Params("capture_id")
+	suggestion, err := h.contextService.SuggestContext(ctx, spaceObj, captureID, c.Query("note_path"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"capture_id": captureID,
+		"suggestion": suggestion,
+	})
+}
+
+// FreezeContext handles POST /api/spaces/:id/context/freeze
+// The space's rendered SPACE.md is pinned until the freeze is removed.
+func (h *SpaceContextHandler) FreezeContext(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	if err := h.contextService.FreezeContext(spaceObj.Path); err != nil {
+		return HandleError(c, err)
+	}
+
+	frozen, err := h.spaceDBService.GetFrozenContext(spaceObj.Path)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(frozen)
+}
+
+// UnfreezeContext handles DELETE /api/spaces/:id/context/freeze
+func (h *SpaceContextHandler) UnfreezeContext(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	if err := h.contextService.UnfreezeContext(spaceObj.Path); err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
@@ -2,9 +2,14 @@ package handlers
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v3"
+	"github.com/unforced/parachute-backend/internal/domain"
 	"github.com/unforced/parachute-backend/internal/domain/space"
 )
 
@@ -41,6 +46,200 @@ func (h *SpaceHandler) List(c fiber.Ctx) error {
 	})
 }
 
+// ListRecent handles GET /api/spaces/recent
+func (h *SpaceHandler) ListRecent(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	// TODO: Get user ID from auth context
+	userID := "default"
+
+	limit := space.DefaultRecentSpacesLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
+	}
+
+	summaries, err := h.service.ListRecentSpaces(ctx, userID, limit)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"spaces": summaries,
+	})
+}
+
+// ListStaleBackups handles GET /api/spaces/stale-backups?days=N
+func (h *SpaceHandler) ListStaleBackups(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+	defer cancel()
+
+	// TODO: Get user ID from auth context
+	userID := "default"
+
+	days := space.DefaultStaleBackupDays
+	if daysStr := c.Query("days"); daysStr != "" {
+		parsed, err := strconv.Atoi(daysStr)
+		if err != nil || parsed <= 0 {
+			return HandleError(c, domain.NewValidationError("days", "must be a positive integer"))
+		}
+		days = parsed
+	}
+
+	stale, err := h.service.ListStaleBackups(ctx, userID, days, time.Now())
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"days":   days,
+		"spaces": stale,
+	})
+}
+
+// GetVaultTagStats handles GET /api/tags/vault
+func (h *SpaceHandler) GetVaultTagStats(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 30*time.Second)
+	defer cancel()
+
+	// TODO: Get user ID from auth context
+	userID := "default"
+
+	stats, err := h.service.GetVaultTagStats(ctx, userID)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(stats)
+}
+
+// SuggestTags handles GET /api/tags/suggest?q=...&limit=N
+func (h *SpaceHandler) SuggestTags(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 30*time.Second)
+	defer cancel()
+
+	// TODO: Get user ID from auth context
+	userID := "default"
+
+	limit := space.DefaultTagSuggestionLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 || parsed > space.MaxTagSuggestionLimit {
+			return HandleError(c, domain.NewValidationError("limit", fmt.Sprintf("must be between 1 and %d", space.MaxTagSuggestionLimit)))
+		}
+		limit = parsed
+	}
+
+	suggestions, err := h.service.SuggestTagsVaultWide(ctx, userID, c.Query("q"), limit)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"suggestions": suggestions,
+	})
+}
+
+// RenameCaptureRequest is the request body for POST /api/captures/:capture_id/rename
+type RenameCaptureRequest struct {
+	OldPath string `json:"old_path"`
+	NewPath string `json:"new_path"`
+}
+
+// RenameCapture handles POST /api/captures/:capture_id/rename
+func (h *SpaceHandler) RenameCapture(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 30*time.Second)
+	defer cancel()
+
+	// TODO: Get user ID from auth context
+	userID := "default"
+
+	var req RenameCaptureRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	updated, err := h.service.RenameCaptureEverywhere(ctx, userID, c.Params("capture_id"), req.OldPath, req.NewPath)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"spaces_updated": updated,
+	})
+}
+
+// SearchCaptures handles GET /api/captures/search?q=...&limit=...
+func (h *SpaceHandler) SearchCaptures(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 30*time.Second)
+	defer cancel()
+
+	// TODO: Get user ID from auth context
+	userID := "default"
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	results, truncated, err := h.service.SearchVaultCaptures(ctx, userID, c.Query("q"), limit)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"results":   results,
+		"total":     len(results),
+		"truncated": truncated,
+	})
+}
+
+// ListUnlinkedCaptures handles GET /api/captures/unlinked
+func (h *SpaceHandler) ListUnlinkedCaptures(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 30*time.Second)
+	defer cancel()
+
+	// TODO: Get user ID from auth context
+	userID := "default"
+
+	captures, err := h.service.FindUnlinkedCaptures(ctx, userID)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"captures": captures,
+		"total":    len(captures),
+	})
+}
+
+// ExportAllUserData handles GET /api/export/all
+// The zip is streamed as it is built; an error partway through is logged and
+// leaves the download truncated, since the status has already been sent.
+func (h *SpaceHandler) ExportAllUserData(c fiber.Ctx) error {
+	// TODO: Get user ID from auth context
+	userID := "default"
+
+	// The body is written after this handler returns, so the export cannot
+	// use the request's context
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+
+	pr, pw := io.Pipe()
+	go func() {
+		defer cancel()
+		err := h.service.ExportAllUserData(ctx, userID, pw)
+		if err != nil {
+			slog.Error("Failed to export user data", "error", err, "user_id", userID)
+		}
+		pw.CloseWithError(err)
+	}()
+
+	filename := fmt.Sprintf("parachute-export-%s.zip", time.Now().Format("2006-01-02"))
+	c.Set("Content-Type", "application/zip")
+	c.Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	return c.SendStream(pr)
+}
+
 // Get handles GET /api/spaces/:id
 func (h *SpaceHandler) Get(c fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
@@ -105,6 +304,79 @@ func (h *SpaceHandler) Update(c fiber.Ctx) error {
 	return c.JSON(updatedSpace)
 }
 
+// RenameSpaceRequest is the request body for POST /api/spaces/:id/rename
+type RenameSpaceRequest struct {
+	Name string `json:"name"`
+}
+
+// Rename handles POST /api/spaces/:id/rename
+func (h *SpaceHandler) Rename(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+	defer cancel()
+
+	id := c.Params("id")
+
+	var req RenameSpaceRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	renamed, err := h.service.Rename(ctx, id, req.Name)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(renamed)
+}
+
+// TransferFileRequest represents a request to move or copy a file into another space
+type TransferFileRequest struct {
+	ToSpaceID string `json:"to_space_id"`
+	Path      string `json:"path"` // Relative to the source space's files/ directory
+}
+
+// MoveFile handles POST /api/spaces/:id/files/move
+func (h *SpaceHandler) MoveFile(c fiber.Ctx) error {
+	return h.transferFile(c, h.service.MoveFile)
+}
+
+// CopyFile handles POST /api/spaces/:id/files/copy
+func (h *SpaceHandler) CopyFile(c fiber.Ctx) error {
+	return h.transferFile(c, h.service.CopyFile)
+}
+
+// transferFile runs a move or copy and reports the file's new location
+func (h *SpaceHandler) transferFile(c fiber.Ctx, transfer func(ctx context.Context, fromSpaceID, toSpaceID, relPath string) (string, error)) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 30*time.Second)
+	defer cancel()
+
+	id := c.Params("id")
+
+	var req TransferFileRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if req.ToSpaceID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "to_space_id is required",
+		})
+	}
+
+	newPath, err := transfer(ctx, id, req.ToSpaceID, req.Path)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"space_id": req.ToSpaceID,
+		"path":     newPath,
+	})
+}
+
 // Delete handles DELETE /api/spaces/:id
 func (h *SpaceHandler) Delete(c fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
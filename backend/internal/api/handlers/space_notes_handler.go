@@ -1,13 +1,20 @@
 package handlers
 
 import (
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"log"
-	"os"
+	"net/url"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gofiber/fiber/v3"
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/file"
 	"github.com/unforced/parachute-backend/internal/domain/space"
 )
 
@@ -27,16 +34,100 @@ func NewSpaceNotesHandler(spaceService *space.Service, spaceDBService *space.Spa
 
 // LinkNoteRequest represents a request to link a note to a space
 type LinkNoteRequest struct {
-	CaptureID string   `json:"capture_id"`
-	NotePath  string   `json:"note_path"`
-	Context   string   `json:"context"`
-	Tags      []string `json:"tags"`
+	CaptureID              string                  `json:"capture_id"`
+	NotePath               string                  `json:"note_path"`
+	Context                string                  `json:"context"`
+	ContextStructured      space.StructuredContext `json:"context_structured,omitempty"`
+	Tags                   []string                `json:"tags"`
+	InheritFrontmatterTags *bool                   `json:"inherit_frontmatter_tags,omitempty"` // Nil uses the server default
+	CapturedAt             *time.Time              `json:"captured_at,omitempty"`              // Overrides the capture time parsed from the filename
+	ParentCaptureID        string                  `json:"parent_capture_id,omitempty"`        // Links the note as a reply to another linked note
+	SourceURL              string                  `json:"source_url,omitempty"`               // Web page the note was clipped from
+}
+
+// CreateCaptureRequest represents a request to create a capture and link it to a space
+type CreateCaptureRequest struct {
+	Content        string   `json:"content"`
+	Context        string   `json:"context"`
+	Tags           []string `json:"tags"`
+	ReuseIdentical bool     `json:"reuse_identical"` // Link an existing capture with the same content instead of writing a new one
+}
+
+// BatchGetNotesRequest represents the request body for fetching several notes
+type BatchGetNotesRequest struct {
+	CaptureIDs []string `json:"capture_ids"`
+}
+
+// maxBatchGetNotes caps the number of capture IDs per batch-get request
+const maxBatchGetNotes = 500
+
+// LinkFromCapturesRequest represents the request body for importing captures into a space
+type LinkFromCapturesRequest struct {
+	Captures    []space.CaptureRef `json:"captures"`
+	DefaultTags []string           `json:"default_tags"`
+}
+
+// maxLinkFromCaptures caps the number of captures per import request
+const maxLinkFromCaptures = 500
+
+// Chunk sizes for GET /notes/:capture_id/chunks, in bytes
+const (
+	defaultChunkSize    = 1000
+	defaultChunkOverlap = 100
+	maxChunkSize        = 100000
+)
+
+// BulkTagsRequest represents a request to change the tags of every note matching filters
+type BulkTagsRequest struct {
+	Filters space.NoteFilters `json:"filters"`
+	Add     []string          `json:"add"`
+	Remove  []string          `json:"remove"`
+}
+
+// BulkUnlinkRequest represents a request to unlink every note matching filters
+type BulkUnlinkRequest struct {
+	Filters space.NoteFilters `json:"filters"`
+	All     bool              `json:"all"` // Required to unlink every note when filters select nothing
 }
 
 // UpdateNoteContextRequest represents a request to update note context
 type UpdateNoteContextRequest struct {
-	Context *string   `json:"context,omitempty"`
-	Tags    *[]string `json:"tags,omitempty"`
+	Context           *string                 `json:"context,omitempty"`
+	ContextStructured space.StructuredContext `json:"context_structured,omitempty"`
+	Tags              *[]string               `json:"tags,omitempty"`
+	ParentCaptureID   *string                 `json:"parent_capture_id,omitempty"` // Empty makes the note a thread root
+	SourceURL         *string                 `json:"source_url,omitempty"`        // Empty clears the note's source URL
+}
+
+// SetNoteStatusRequest represents a request to change a note's workflow status
+type SetNoteStatusRequest struct {
+	Status string `json:"status"`
+}
+
+// SetNoteDueRequest represents a request to set or clear (null) a note's due date
+type SetNoteDueRequest struct {
+	DueAt *time.Time `json:"due_at"`
+}
+
+// SetNoteExpiryRequest represents a request to set or clear (null) when a note expires
+type SetNoteExpiryRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// MoveNoteRequest represents a request to move a note to another space
+type MoveNoteRequest struct {
+	ToSpaceID string `json:"to_space_id"`
+}
+
+// SetNotePriorityRequest represents a request to set a note's priority
+type SetNotePriorityRequest struct {
+	Priority *int `json:"priority"`
+}
+
+// SetReminderRequest represents a request to set a note's reminder
+type SetReminderRequest struct {
+	Text string    `json:"text"`
+	At   time.Time `json:"at"`
 }
 
 // GetNotesResponse wraps the list of notes
@@ -59,8 +150,97 @@ func (h *SpaceNotesHandler) GetNotes(c fiber.Ctx) error {
 	}
 
 	// Parse query parameters for filtering
+	filters := parseNoteFilters(c, 50)
+	if err := applyRelativeLinkDates(c, &filters, time.Now()); err != nil {
+		return err
+	}
+
+	// Get notes from space database
+	notes, err := h.spaceDBService.GetRelevantNotes(spaceObj.Path, filters)
+	if err != nil {
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to get notes: %v", err))
+	}
+
+	// Titles need each capture file read, so they're opt-in
+	if includes(c, "title") {
+		h.spaceDBService.AttachTitles(spaceObj.Path, notes)
+	}
+
+	if version, err := h.spaceDBService.GetContextVersion(spaceObj.Path); err == nil {
+		c.Set(ContextVersionHeader, strconv.FormatInt(version, 10))
+	}
+
+	return c.JSON(GetNotesResponse{
+		Notes: notes,
+		Total: len(notes),
+	})
+}
+
+// GetUntaggedNotesResponse lists a page of untagged notes
+type GetUntaggedNotesResponse struct {
+	GetNotesResponse
+	UntaggedCount int `json:"untagged_count"` // All untagged notes, ignoring limit and offset
+}
+
+// GetUntaggedNotes handles GET /api/spaces/:id/notes/untagged, for triaging
+// notes linked without tags (e.g. with bulk-tags). Accepts the same filters
+// as GetNotes.
+func (h *SpaceNotesHandler) GetUntaggedNotes(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	if spaceID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id is required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	filters := parseNoteFilters(c, 50)
+	if err := applyRelativeLinkDates(c, &filters, time.Now()); err != nil {
+		return err
+	}
+	hasTags := false
+	filters.HasTags = &hasTags
+
+	notes, err := h.spaceDBService.GetRelevantNotes(spaceObj.Path, filters)
+	if err != nil {
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to get untagged notes: %v", err))
+	}
+
+	count, err := h.spaceDBService.CountUntaggedNotes(spaceObj.Path)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to count untagged notes: %v", err))
+	}
+
+	if includes(c, "title") {
+		h.spaceDBService.AttachTitles(spaceObj.Path, notes)
+	}
+
+	return c.JSON(GetUntaggedNotesResponse{
+		GetNotesResponse: GetNotesResponse{Notes: notes, Total: len(notes)},
+		UntaggedCount:    count,
+	})
+}
+
+// parseNoteFilters builds NoteFilters from the common note query parameters:
+// tags (comma-separated), status, start_date/end_date and due_before (RFC3339),
+// batch_id, context_q (text in the space context), sort, has_tags,
+// include_expired, limit, offset and exists (capture file present on disk).
+// defaultLimit applies when no limit is given (0 means no limit). Relative
+// link dates are applied separately by applyRelativeLinkDates.
+func parseNoteFilters(c fiber.Ctx, defaultLimit int) space.NoteFilters {
 	filters := space.NoteFilters{
-		Limit:  50, // Default limit
+		Limit:  defaultLimit,
 		Offset: 0,
 		Tags:   []string{},
 	}
@@ -71,6 +251,8 @@ func (h *SpaceNotesHandler) GetNotes(c fiber.Ctx) error {
 		filters.Tags = splitAndTrim(tagsParam, ",")
 	}
 
+	filters.Status = c.Query("status")
+
 	// Parse date filters
 	if startDateStr := c.Query("start_date"); startDateStr != "" {
 		if startDate, err := time.Parse(time.RFC3339, startDateStr); err == nil {
@@ -84,6 +266,16 @@ func (h *SpaceNotesHandler) GetNotes(c fiber.Ctx) error {
 		}
 	}
 
+	if dueBeforeStr := c.Query("due_before"); dueBeforeStr != "" {
+		if dueBefore, err := time.Parse(time.RFC3339, dueBeforeStr); err == nil {
+			filters.DueBefore = &dueBefore
+		}
+	}
+
+	filters.BatchID = c.Query("batch_id")
+	filters.ContextContains = c.Query("context_q")
+	filters.Sort = c.Query("sort")
+
 	// Parse limit and offset
 	if limitStr := c.Query("limit"); limitStr != "" {
 		if limit, err := parseInt(limitStr); err == nil && limit > 0 {
@@ -97,20 +289,76 @@ func (h *SpaceNotesHandler) GetNotes(c fiber.Ctx) error {
 		}
 	}
 
-	// Get notes from space database
-	notes, err := h.spaceDBService.GetRelevantNotes(spaceObj.Path, filters)
+	// Parse capture file existence filter
+	if hasTagsStr := c.Query("has_tags"); hasTagsStr != "" {
+		if hasTags, err := strconv.ParseBool(hasTagsStr); err == nil {
+			filters.HasTags = &hasTags
+		}
+	}
+
+	if hasSourceStr := c.Query("has_source"); hasSourceStr != "" {
+		if hasSource, err := strconv.ParseBool(hasSourceStr); err == nil {
+			filters.HasSource = &hasSource
+		}
+	}
+
+	filters.HasMetadataKey = c.Query("has_meta")
+	filters.MissingMetadataKey = c.Query("missing_meta")
+
+	filters.IncludeExpired, _ = strconv.ParseBool(c.Query("include_expired"))
+
+	if existsStr := c.Query("exists"); existsStr != "" {
+		if exists, err := strconv.ParseBool(existsStr); err == nil {
+			filters.ExistsOnDisk = &exists
+		}
+	}
+
+	return filters
+}
+
+// GetNotesGroupedByTag handles GET /api/spaces/:id/notes/grouped
+func (h *SpaceNotesHandler) GetNotesGroupedByTag(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	if spaceID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id is required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to get notes: %v", err))
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
 	}
 
-	return c.JSON(GetNotesResponse{
-		Notes: notes,
-		Total: len(notes),
+	// Group the whole filtered set unless the client limits it
+	filters := parseNoteFilters(c, 0)
+	if err := applyRelativeLinkDates(c, &filters, time.Now()); err != nil {
+		return err
+	}
+
+	bucketLimit := 20 // Default per-bucket cap
+	if bucketLimitStr := c.Query("bucket_limit"); bucketLimitStr != "" {
+		if limit, err := parseInt(bucketLimitStr); err == nil && limit > 0 {
+			bucketLimit = limit
+		}
+	}
+
+	groups, err := h.spaceDBService.GetNotesGroupedByTag(spaceObj.Path, filters, bucketLimit)
+	if err != nil {
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to group notes: %v", err))
+	}
+
+	return c.JSON(fiber.Map{
+		"groups":       groups,
+		"bucket_limit": bucketLimit,
 	})
 }
 
-// LinkNote handles POST /api/spaces/:id/notes
-func (h *SpaceNotesHandler) LinkNote(c fiber.Ctx) error {
+// GetNoteHistogram handles GET /api/spaces/:id/notes/histogram
+func (h *SpaceNotesHandler) GetNoteHistogram(c fiber.Ctx) error {
 	spaceID := c.Params("id")
 	if spaceID == "" {
 		return fiber.NewError(fiber.StatusBadRequest, "space_id is required")
@@ -122,44 +370,119 @@ func (h *SpaceNotesHandler) LinkNote(c fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusNotFound, "space not found")
 	}
 
-	// Parse request body
-	var req LinkNoteRequest
-	if err := c.Bind().JSON(&req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	bucket := c.Query("bucket", space.HistogramBucketDay)
+	field := c.Query("field", space.HistogramFieldLinkedAt)
+
+	buckets, err := h.spaceDBService.GetNoteHistogram(spaceObj.Path, bucket, field)
+	if err != nil {
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			return fiber.NewError(fiber.StatusBadRequest, validationErr.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to build histogram: %v", err))
 	}
 
-	// Validate required fields
-	if req.CaptureID == "" {
-		return fiber.NewError(fiber.StatusBadRequest, "capture_id is required")
+	return c.JSON(fiber.Map{
+		"bucket":  bucket,
+		"field":   field,
+		"buckets": buckets,
+	})
+}
+
+// GetTagTimeline handles GET /api/spaces/:id/tags/:tag/timeline
+// Query parameter bucket (day, week or month; default month) sets the bucket size.
+func (h *SpaceNotesHandler) GetTagTimeline(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	if spaceID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id is required")
 	}
-	if req.NotePath == "" {
-		return fiber.NewError(fiber.StatusBadRequest, "note_path is required")
+
+	// Tags may contain "/" (see GetTagTree), so the parameter arrives escaped
+	tag := c.Params("tag")
+	if unescaped, err := url.PathUnescape(tag); err == nil {
+		tag = unescaped
 	}
 
-	// Ensure space.sqlite exists
-	if err := h.spaceDBService.InitializeSpaceDatabase(spaceID, spaceObj.Path); err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to initialize space database: %v", err))
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
 	}
 
-	// Link the note
-	if err := h.spaceDBService.LinkNote(spaceID, spaceObj.Path, req.CaptureID, req.NotePath, req.Context, req.Tags); err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to link note: %v", err))
+	bucket := c.Query("bucket", space.HistogramBucketMonth)
+
+	buckets, err := h.spaceDBService.GetTagTimeline(spaceObj.Path, tag, bucket)
+	if err != nil {
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			return fiber.NewError(fiber.StatusBadRequest, validationErr.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to build tag timeline: %v", err))
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"message":    "note linked successfully",
-		"space_id":   spaceID,
-		"capture_id": req.CaptureID,
+	return c.JSON(fiber.Map{
+		"tag":     tag,
+		"bucket":  bucket,
+		"buckets": buckets,
 	})
 }
 
-// UpdateNoteContext handles PUT /api/spaces/:id/notes/:capture_id
-func (h *SpaceNotesHandler) UpdateNoteContext(c fiber.Ctx) error {
+// GetReferenceAnalytics handles GET /api/spaces/:id/analytics/references
+// Query parameters: bucket (day, week or month; default day) and from/to, each
+// RFC3339 or a relative time such as 7d (see parseRelativeTime). The window
+// defaults to the 30 days up to now.
+func (h *SpaceNotesHandler) GetReferenceAnalytics(c fiber.Ctx) error {
 	spaceID := c.Params("id")
-	captureID := c.Params("capture_id")
+	if spaceID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id is required")
+	}
 
-	if spaceID == "" || captureID == "" {
-		return fiber.NewError(fiber.StatusBadRequest, "space_id and capture_id are required")
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	now := time.Now()
+	to := now
+	if expr := c.Query("to"); expr != "" {
+		if to, err = parseAnalyticsTime(expr, now); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid to: %v", err))
+		}
+	}
+	from := to.AddDate(0, 0, -30)
+	if expr := c.Query("from"); expr != "" {
+		if from, err = parseAnalyticsTime(expr, now); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid from: %v", err))
+		}
+	}
+
+	series, err := h.spaceDBService.GetReferenceAnalytics(spaceObj.Path, c.Query("bucket", space.HistogramBucketDay), from, to)
+	if err != nil {
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			return fiber.NewError(fiber.StatusBadRequest, validationErr.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to build reference analytics: %v", err))
+	}
+
+	return c.JSON(series)
+}
+
+// parseAnalyticsTime parses an RFC3339 timestamp or a relative time
+func parseAnalyticsTime(expr string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, expr); err == nil {
+		return t, nil
+	}
+	return parseRelativeTime(expr, now)
+}
+
+// FindNearDuplicates handles GET /api/spaces/:id/notes/duplicates
+// Query parameter threshold (0 to 1, default 0.8) sets the minimum similarity.
+func (h *SpaceNotesHandler) FindNearDuplicates(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	if spaceID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id is required")
 	}
 
 	// Get space to get its path
@@ -168,34 +491,1231 @@ func (h *SpaceNotesHandler) UpdateNoteContext(c fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusNotFound, "space not found")
 	}
 
-	// Parse request body
-	var req UpdateNoteContextRequest
+	threshold := space.DefaultDuplicateThreshold
+	if thresholdStr := c.Query("threshold"); thresholdStr != "" {
+		threshold, err = strconv.ParseFloat(thresholdStr, 64)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "threshold must be a number")
+		}
+	}
+
+	groups, err := h.spaceDBService.FindNearDuplicates(spaceObj.Path, threshold)
+	if err != nil {
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			return fiber.NewError(fiber.StatusBadRequest, validationErr.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to find duplicates: %v", err))
+	}
+
+	return c.JSON(fiber.Map{
+		"threshold": threshold,
+		"groups":    groups,
+	})
+}
+
+// SetFeaturedNotesRequest represents a request to replace a space's featured notes
+type SetFeaturedNotesRequest struct {
+	CaptureIDs []string `json:"capture_ids"`
+}
+
+// GetFeaturedNotes handles GET /api/spaces/:id/featured-notes
+func (h *SpaceNotesHandler) GetFeaturedNotes(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	if spaceID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id is required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	notes, err := h.spaceDBService.GetFeaturedNotes(spaceObj.Path)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to get featured notes: %v", err))
+	}
+
+	return c.JSON(GetNotesResponse{
+		Notes: notes,
+		Total: len(notes),
+	})
+}
+
+// SetFeaturedNotes handles PUT /api/spaces/:id/featured-notes
+func (h *SpaceNotesHandler) SetFeaturedNotes(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	if spaceID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id is required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	var req SetFeaturedNotesRequest
 	if err := c.Bind().JSON(&req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+		return invalidJSONBody(c, err)
+	}
+
+	if err := h.spaceDBService.SetFeaturedNotes(spaceObj.Path, req.CaptureIDs); err != nil {
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, space.ErrRateLimited) {
+			return tooManyRequests(c, err)
+		}
+		if errors.Is(err, space.ErrSpaceReadOnly) {
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
+		if errors.Is(err, space.ErrSpaceBusy) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to set featured notes: %v", err))
+	}
+
+	return h.GetFeaturedNotes(c)
+}
+
+// GetTagTree handles GET /api/spaces/:id/tags/tree
+func (h *SpaceNotesHandler) GetTagTree(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	if spaceID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id is required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	tree, err := h.spaceDBService.GetTagTree(spaceObj.Path)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to build tag tree: %v", err))
+	}
+
+	return c.JSON(tree)
+}
+
+// DiffSpaces handles GET /api/spaces/:id/diff?other=<space_id>
+func (h *SpaceNotesHandler) DiffSpaces(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	if spaceID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id is required")
+	}
+
+	otherID := c.Query("other")
+	if otherID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "other is required")
+	}
+
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	otherObj, err := h.spaceService.GetByID(c.Context(), otherID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "other space not found")
+	}
+
+	diff, err := h.spaceDBService.DiffSpaces(spaceObj.Path, otherObj.Path)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to diff spaces: %v", err))
+	}
+
+	return c.JSON(diff)
+}
+
+// BatchGetNotes handles POST /api/spaces/:id/notes/batch-get
+func (h *SpaceNotesHandler) BatchGetNotes(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	if spaceID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id is required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	var req BatchGetNotesRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return invalidJSONBody(c, err)
+	}
+
+	if len(req.CaptureIDs) == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "capture_ids is required")
+	}
+	if len(req.CaptureIDs) > maxBatchGetNotes {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("at most %d capture_ids per request", maxBatchGetNotes))
+	}
+
+	notes, err := h.spaceDBService.GetNotesByIDs(spaceObj.Path, req.CaptureIDs)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to get notes: %v", err))
+	}
+
+	if includes(c, "title") {
+		h.spaceDBService.AttachTitles(spaceObj.Path, notes)
+	}
+
+	return c.JSON(GetNotesResponse{
+		Notes: notes,
+		Total: len(notes),
+	})
+}
+
+// BulkUpdateTags handles POST /api/spaces/:id/notes/bulk-tags
+func (h *SpaceNotesHandler) BulkUpdateTags(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	if spaceID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id is required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	var req BulkTagsRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return invalidJSONBody(c, err)
+	}
+
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		return err
+	}
+
+	result, err := h.spaceDBService.BulkUpdateTags(spaceObj.Path, req.Filters, req.Add, req.Remove, dryRun)
+	if err != nil {
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, space.ErrRateLimited) {
+			return tooManyRequests(c, err)
+		}
+		if errors.Is(err, space.ErrSpaceReadOnly) {
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
+		if errors.Is(err, space.ErrSpaceBusy) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to update tags: %v", err))
+	}
+
+	return c.JSON(fiber.Map{
+		"updated":     result.Count,
+		"capture_ids": result.CaptureIDs,
+		"dry_run":     result.DryRun,
+	})
+}
+
+// BulkUnlink handles POST /api/spaces/:id/notes/bulk-unlink
+// With ?dry_run=true the matching notes are listed but not unlinked.
+func (h *SpaceNotesHandler) BulkUnlink(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	if spaceID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id is required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	var req BulkUnlinkRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return invalidJSONBody(c, err)
+	}
+
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		return err
+	}
+
+	result, err := h.spaceDBService.BulkUnlink(spaceObj.Path, req.Filters, req.All, dryRun)
+	if err != nil {
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, space.ErrRateLimited) {
+			return tooManyRequests(c, err)
+		}
+		if errors.Is(err, space.ErrSpaceReadOnly) {
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
+		if errors.Is(err, space.ErrSpaceBusy) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to unlink notes: %v", err))
+	}
+
+	return c.JSON(fiber.Map{
+		"unlinked":    result.Count,
+		"capture_ids": result.CaptureIDs,
+		"dry_run":     result.DryRun,
+	})
+}
+
+// DecayRelevance handles POST /api/spaces/:id/notes/decay?half_life_days=N
+func (h *SpaceNotesHandler) DecayRelevance(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	if spaceID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id is required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	halfLife := space.DefaultRelevanceHalfLife
+	if daysStr := c.Query("half_life_days"); daysStr != "" {
+		days, err := strconv.ParseFloat(daysStr, 64)
+		if err != nil || days <= 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "half_life_days must be a positive number")
+		}
+		halfLife = time.Duration(days * float64(24*time.Hour))
+	}
+
+	updated, err := h.spaceDBService.ApplyRelevanceDecay(spaceObj.Path, halfLife)
+	if err != nil {
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, space.ErrRateLimited) {
+			return tooManyRequests(c, err)
+		}
+		if errors.Is(err, space.ErrSpaceReadOnly) {
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
+		if errors.Is(err, space.ErrSpaceBusy) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to decay relevance: %v", err))
+	}
+
+	return c.JSON(fiber.Map{
+		"updated":        updated,
+		"half_life_days": halfLife.Hours() / 24,
+	})
+}
+
+// LinkFromCaptures handles POST /api/spaces/:id/notes/from-captures
+func (h *SpaceNotesHandler) LinkFromCaptures(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	if spaceID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id is required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	var req LinkFromCapturesRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return invalidJSONBody(c, err)
+	}
+
+	if len(req.Captures) == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "captures is required")
+	}
+	if len(req.Captures) > maxLinkFromCaptures {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("at most %d captures per request", maxLinkFromCaptures))
+	}
+
+	// Ensure space.sqlite exists
+	if err := h.spaceDBService.InitializeSpaceDatabase(spaceID, spaceObj.Path); err != nil {
+		if errors.Is(err, space.ErrSpaceBusy) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to initialize space database: %v", err))
+	}
+
+	result, err := h.spaceDBService.LinkNotesFromCaptures(spaceID, spaceObj.Path, req.Captures, req.DefaultTags)
+	if err != nil {
+		if errors.Is(err, space.ErrRateLimited) {
+			return tooManyRequests(c, err)
+		}
+		if errors.Is(err, space.ErrSpaceReadOnly) {
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
+		if errors.Is(err, space.ErrSpaceBusy) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to import captures: %v", err))
+	}
+
+	return c.JSON(result)
+}
+
+// LinkNote handles POST /api/spaces/:id/notes
+func (h *SpaceNotesHandler) LinkNote(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	if spaceID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id is required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	// Parse request body
+	var req LinkNoteRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return invalidJSONBody(c, err)
+	}
+
+	// Validate required fields
+	if req.CaptureID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "capture_id is required")
+	}
+	if req.NotePath == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "note_path is required")
+	}
+	if req.ContextStructured != nil {
+		if err := req.ContextStructured.Validate(); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+	}
+
+	// Ensure space.sqlite exists
+	if err := h.spaceDBService.InitializeSpaceDatabase(spaceID, spaceObj.Path); err != nil {
+		if errors.Is(err, space.ErrSpaceBusy) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to initialize space database: %v", err))
+	}
+
+	// Link the note
+	opts := space.LinkOptions{
+		InheritFrontmatterTags: req.InheritFrontmatterTags,
+		CapturedAt:             req.CapturedAt,
+		ParentCaptureID:        req.ParentCaptureID,
+		SourceURL:              req.SourceURL,
+	}
+	if err := h.spaceDBService.LinkNoteWithOptions(spaceID, spaceObj.Path, req.CaptureID, req.NotePath, req.Context, req.Tags, opts); err != nil {
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, space.ErrRateLimited) {
+			return tooManyRequests(c, err)
+		}
+		if errors.Is(err, space.ErrSpaceReadOnly) {
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
+		if errors.Is(err, space.ErrSpaceBusy) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to link note: %v", err))
+	}
+
+	if req.ContextStructured != nil {
+		if err := h.spaceDBService.SetStructuredContext(spaceObj.Path, req.CaptureID, req.ContextStructured); err != nil {
+			if errors.Is(err, space.ErrRateLimited) {
+				return tooManyRequests(c, err)
+			}
+			if errors.Is(err, space.ErrSpaceReadOnly) {
+				return fiber.NewError(fiber.StatusForbidden, err.Error())
+			}
+			return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to set structured context: %v", err))
+		}
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":    "note linked successfully",
+		"space_id":   spaceID,
+		"capture_id": req.CaptureID,
+	})
+}
+
+// CreateCapture handles POST /api/spaces/:id/captures
+// Responds 201 when a new capture was written and 200 when reuse_identical
+// matched an existing one.
+func (h *SpaceNotesHandler) CreateCapture(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	if spaceID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id is required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	var req CreateCaptureRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return invalidJSONBody(c, err)
+	}
+
+	// Ensure space.sqlite exists
+	if err := h.spaceDBService.InitializeSpaceDatabase(spaceID, spaceObj.Path); err != nil {
+		if errors.Is(err, space.ErrSpaceBusy) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to initialize space database: %v", err))
+	}
+
+	result, err := h.spaceDBService.CreateAndLinkCapture(spaceID, spaceObj.Path, space.CreateCaptureParams{
+		Content:        req.Content,
+		Context:        req.Context,
+		Tags:           req.Tags,
+		ReuseIdentical: req.ReuseIdentical,
+	})
+	if err != nil {
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, space.ErrRateLimited) {
+			return tooManyRequests(c, err)
+		}
+		if errors.Is(err, space.ErrSpaceReadOnly) {
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
+		if errors.Is(err, space.ErrSpaceBusy) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to create capture: %v", err))
+	}
+
+	status := fiber.StatusOK
+	if result.Created {
+		status = fiber.StatusCreated
+	}
+	return c.Status(status).JSON(result)
+}
+
+// UpdateNoteContext handles PUT /api/spaces/:id/notes/:capture_id
+func (h *SpaceNotesHandler) UpdateNoteContext(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	captureID := c.Params("capture_id")
+
+	if spaceID == "" || captureID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id and capture_id are required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	// Parse request body
+	var req UpdateNoteContextRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return invalidJSONBody(c, err)
+	}
+
+	// Validate at least one field is provided
+	if req.Context == nil && req.Tags == nil && req.ContextStructured == nil && req.ParentCaptureID == nil && req.SourceURL == nil {
+		return fiber.NewError(fiber.StatusBadRequest, "at least one of context, context_structured, tags, parent_capture_id or source_url must be provided")
+	}
+	if req.ContextStructured != nil {
+		if err := req.ContextStructured.Validate(); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+	}
+	if req.SourceURL != nil && *req.SourceURL != "" {
+		if err := space.ValidateSourceURL(*req.SourceURL); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+	}
+
+	// Update note context
+	if err := h.spaceDBService.UpdateNoteContext(spaceObj.Path, captureID, req.Context, req.Tags); err != nil {
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, space.ErrRateLimited) {
+			return tooManyRequests(c, err)
+		}
+		if errors.Is(err, space.ErrSpaceReadOnly) {
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
+		if errors.Is(err, space.ErrSpaceBusy) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		if err.Error() == "note not found in space" {
+			return fiber.NewError(fiber.StatusNotFound, "note not found in space")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to update note context: %v", err))
+	}
+
+	if req.ContextStructured != nil {
+		if err := h.spaceDBService.SetStructuredContext(spaceObj.Path, captureID, req.ContextStructured); err != nil {
+			if errors.Is(err, space.ErrRateLimited) {
+				return tooManyRequests(c, err)
+			}
+			if errors.Is(err, space.ErrSpaceReadOnly) {
+				return fiber.NewError(fiber.StatusForbidden, err.Error())
+			}
+			if err.Error() == "note not found in space" {
+				return fiber.NewError(fiber.StatusNotFound, "note not found in space")
+			}
+			return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to update structured context: %v", err))
+		}
+	}
+
+	if req.ParentCaptureID != nil {
+		if err := h.spaceDBService.SetNoteParent(spaceObj.Path, captureID, *req.ParentCaptureID); err != nil {
+			var validationErr *domain.ValidationError
+			if errors.As(err, &validationErr) {
+				return fiber.NewError(fiber.StatusBadRequest, err.Error())
+			}
+			if errors.Is(err, space.ErrRateLimited) {
+				return tooManyRequests(c, err)
+			}
+			if errors.Is(err, space.ErrSpaceReadOnly) {
+				return fiber.NewError(fiber.StatusForbidden, err.Error())
+			}
+			if err.Error() == "note not found in space" {
+				return fiber.NewError(fiber.StatusNotFound, "note not found in space")
+			}
+			return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to set note parent: %v", err))
+		}
+	}
+
+	if req.SourceURL != nil {
+		if err := h.spaceDBService.SetNoteSourceURL(spaceObj.Path, captureID, *req.SourceURL); err != nil {
+			var validationErr *domain.ValidationError
+			if errors.As(err, &validationErr) {
+				return fiber.NewError(fiber.StatusBadRequest, err.Error())
+			}
+			if errors.Is(err, space.ErrRateLimited) {
+				return tooManyRequests(c, err)
+			}
+			if errors.Is(err, space.ErrSpaceReadOnly) {
+				return fiber.NewError(fiber.StatusForbidden, err.Error())
+			}
+			if err.Error() == "note not found in space" {
+				return fiber.NewError(fiber.StatusNotFound, "note not found in space")
+			}
+			return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to set note source URL: %v", err))
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "note context updated successfully",
+		"space_id":   spaceID,
+		"capture_id": captureID,
+	})
+}
+
+// SetNoteStatus handles PUT /api/spaces/:id/notes/:capture_id/status
+func (h *SpaceNotesHandler) SetNoteStatus(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	captureID := c.Params("capture_id")
+
+	if spaceID == "" || captureID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id and capture_id are required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	var req SetNoteStatusRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return invalidJSONBody(c, err)
+	}
+
+	if err := h.spaceDBService.SetNoteStatus(spaceObj.Path, captureID, req.Status); err != nil {
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, space.ErrRateLimited) {
+			return tooManyRequests(c, err)
+		}
+		if errors.Is(err, space.ErrSpaceReadOnly) {
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
+		if errors.Is(err, space.ErrSpaceBusy) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		if err.Error() == "note not found in space" {
+			return fiber.NewError(fiber.StatusNotFound, "note not found in space")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to set note status: %v", err))
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "note status updated successfully",
+		"space_id":   spaceID,
+		"capture_id": captureID,
+		"status":     req.Status,
+	})
+}
+
+// SetNoteDue handles PUT /api/spaces/:id/notes/:capture_id/due
+func (h *SpaceNotesHandler) SetNoteDue(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	captureID := c.Params("capture_id")
+
+	if spaceID == "" || captureID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id and capture_id are required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	var req SetNoteDueRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return invalidJSONBody(c, fmt.Errorf("%w (due_at must be RFC3339 or null)", err))
+	}
+
+	if err := h.spaceDBService.SetNoteDueDate(spaceObj.Path, captureID, req.DueAt); err != nil {
+		if errors.Is(err, space.ErrRateLimited) {
+			return tooManyRequests(c, err)
+		}
+		if errors.Is(err, space.ErrSpaceReadOnly) {
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
+		if errors.Is(err, space.ErrSpaceBusy) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		if err.Error() == "note not found in space" {
+			return fiber.NewError(fiber.StatusNotFound, "note not found in space")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to set note due date: %v", err))
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "note due date updated successfully",
+		"space_id":   spaceID,
+		"capture_id": captureID,
+		"due_at":     req.DueAt,
+	})
+}
+
+// GetNoteThread handles GET /api/spaces/:id/notes/:capture_id/thread
+// The note comes first, then its replies level by level, oldest first within a level.
+func (h *SpaceNotesHandler) GetNoteThread(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	captureID := c.Params("capture_id")
+
+	if spaceID == "" || captureID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id and capture_id are required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	notes, err := h.spaceDBService.GetThread(spaceObj.Path, captureID)
+	if err != nil {
+		var goneErr *domain.GoneError
+		if errors.As(err, &goneErr) {
+			return fiber.NewError(fiber.StatusGone, "note was unlinked from space")
+		}
+		if err.Error() == "note not found in space" {
+			return fiber.NewError(fiber.StatusNotFound, "note not found in space")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to get thread: %v", err))
+	}
+
+	return c.JSON(fiber.Map{
+		"capture_id": captureID,
+		"notes":      notes,
+		"count":      len(notes),
+	})
+}
+
+// SetNoteExpiry handles PUT /api/spaces/:id/notes/:capture_id/expiry
+func (h *SpaceNotesHandler) SetNoteExpiry(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	captureID := c.Params("capture_id")
+
+	if spaceID == "" || captureID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id and capture_id are required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	var req SetNoteExpiryRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return invalidJSONBody(c, fmt.Errorf("%w (expires_at must be RFC3339 or null)", err))
+	}
+
+	if err := h.spaceDBService.SetNoteExpiry(spaceObj.Path, captureID, req.ExpiresAt); err != nil {
+		if errors.Is(err, space.ErrRateLimited) {
+			return tooManyRequests(c, err)
+		}
+		if errors.Is(err, space.ErrSpaceReadOnly) {
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
+		if errors.Is(err, space.ErrSpaceBusy) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		if err.Error() == "note not found in space" {
+			return fiber.NewError(fiber.StatusNotFound, "note not found in space")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to set note expiry: %v", err))
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "note expiry updated successfully",
+		"space_id":   spaceID,
+		"capture_id": captureID,
+		"expires_at": req.ExpiresAt,
+	})
+}
+
+// MoveNote handles POST /api/spaces/:id/notes/:capture_id/move
+func (h *SpaceNotesHandler) MoveNote(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	captureID := c.Params("capture_id")
+
+	if spaceID == "" || captureID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id and capture_id are required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	var req MoveNoteRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return invalidJSONBody(c, err)
+	}
+
+	if req.ToSpaceID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "to_space_id is required")
+	}
+
+	target, err := h.spaceService.GetByID(c.Context(), req.ToSpaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "target space not found")
+	}
+
+	if err := h.spaceDBService.MoveNoteToSpace(spaceObj.Path, target.ID, target.Path, captureID); err != nil {
+		var moveErr *space.MoveNoteError
+		if errors.As(err, &moveErr) {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": moveErr.Error(),
+				"state": moveErr.State,
+			})
+		}
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		var conflictErr *domain.ConflictError
+		if errors.As(err, &conflictErr) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		var goneErr *domain.GoneError
+		if errors.As(err, &goneErr) {
+			return fiber.NewError(fiber.StatusGone, "note was unlinked from space")
+		}
+		if errors.Is(err, space.ErrRateLimited) {
+			return tooManyRequests(c, err)
+		}
+		if errors.Is(err, space.ErrSpaceReadOnly) {
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
+		if errors.Is(err, space.ErrSpaceBusy) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		if err.Error() == "note not found in space" {
+			return fiber.NewError(fiber.StatusNotFound, "note not found in space")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to move note: %v", err))
+	}
+
+	return c.JSON(fiber.Map{
+		"message":       "note moved successfully",
+		"capture_id":    captureID,
+		"from_space_id": spaceID,
+		"to_space_id":   target.ID,
+	})
+}
+
+// SetNotePriority handles PUT /api/spaces/:id/notes/:capture_id/priority
+func (h *SpaceNotesHandler) SetNotePriority(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	captureID := c.Params("capture_id")
+
+	if spaceID == "" || captureID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id and capture_id are required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	var req SetNotePriorityRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return invalidJSONBody(c, err)
+	}
+
+	if req.Priority == nil {
+		return fiber.NewError(fiber.StatusBadRequest, "priority is required")
+	}
+
+	if err := h.spaceDBService.SetNotePriority(spaceObj.Path, captureID, *req.Priority); err != nil {
+		if errors.Is(err, space.ErrRateLimited) {
+			return tooManyRequests(c, err)
+		}
+		if errors.Is(err, space.ErrSpaceReadOnly) {
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
+		if errors.Is(err, space.ErrSpaceBusy) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		if err.Error() == "note not found in space" {
+			return fiber.NewError(fiber.StatusNotFound, "note not found in space")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to set note priority: %v", err))
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "note priority updated successfully",
+		"space_id":   spaceID,
+		"capture_id": captureID,
+		"priority":   *req.Priority,
+	})
+}
+
+// SetReminder handles PUT /api/spaces/:id/notes/:capture_id/reminder
+func (h *SpaceNotesHandler) SetReminder(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	captureID := c.Params("capture_id")
+
+	if spaceID == "" || captureID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id and capture_id are required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	var req SetReminderRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return invalidJSONBody(c, fmt.Errorf("%w (at must be RFC3339)", err))
+	}
+
+	if err := h.spaceDBService.SetReminder(spaceObj.Path, captureID, req.Text, req.At); err != nil {
+		return reminderError(c, err, "failed to set reminder")
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "reminder set successfully",
+		"space_id":   spaceID,
+		"capture_id": captureID,
+		"reminder":   space.NoteReminder{Text: strings.TrimSpace(req.Text), At: req.At},
+	})
+}
+
+// DismissReminder handles DELETE /api/spaces/:id/notes/:capture_id/reminder
+func (h *SpaceNotesHandler) DismissReminder(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	captureID := c.Params("capture_id")
+
+	if spaceID == "" || captureID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id and capture_id are required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	if err := h.spaceDBService.DismissReminder(spaceObj.Path, captureID); err != nil {
+		return reminderError(c, err, "failed to dismiss reminder")
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "reminder dismissed successfully",
+		"space_id":   spaceID,
+		"capture_id": captureID,
+	})
+}
+
+// reminderError maps an error from a reminder update to a response
+func reminderError(c fiber.Ctx, err error, message string) error {
+	var validationErr *domain.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, space.ErrRateLimited):
+		return tooManyRequests(c, err)
+	case errors.Is(err, space.ErrSpaceReadOnly):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case err.Error() == "note not found in space":
+		return fiber.NewError(fiber.StatusNotFound, "note not found in space")
+	}
+	return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("%s: %v", message, err))
+}
+
+// UnlinkNote handles DELETE /api/spaces/:id/notes/:capture_id
+func (h *SpaceNotesHandler) UnlinkNote(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	captureID := c.Params("capture_id")
+
+	if spaceID == "" || captureID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id and capture_id are required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	// Unlink the note
+	if err := h.spaceDBService.UnlinkNote(spaceObj.Path, captureID); err != nil {
+		if errors.Is(err, space.ErrRateLimited) {
+			return tooManyRequests(c, err)
+		}
+		if errors.Is(err, space.ErrSpaceReadOnly) {
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
+		if errors.Is(err, space.ErrSpaceBusy) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		if err.Error() == "note not found in space" {
+			return fiber.NewError(fiber.StatusNotFound, "note not found in space")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to unlink note: %v", err))
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "note unlinked successfully",
+		"space_id":   spaceID,
+		"capture_id": captureID,
+	})
+}
+
+// GetNoteContent handles GET /api/spaces/:id/notes/:capture_id/content
+func (h *SpaceNotesHandler) GetNoteContent(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	captureID := c.Params("capture_id")
+
+	if spaceID == "" || captureID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id and capture_id are required")
+	}
+
+	render := c.Query("render")
+	if render != "" && render != "html" {
+		return fiber.NewError(fiber.StatusBadRequest, "render must be html")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	// Get note metadata from space database
+	note, err := h.spaceDBService.GetNoteByID(spaceObj.Path, captureID)
+	if err != nil {
+		var goneErr *domain.GoneError
+		if errors.As(err, &goneErr) {
+			return fiber.NewError(fiber.StatusGone, "note was unlinked from space")
+		}
+		if err.Error() == "note not found in space" {
+			return fiber.NewError(fiber.StatusNotFound, "note not found in space")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to get note: %v", err))
+	}
+
+	// Read note content from file system
+	// note.NotePath is vault-relative (e.g., "captures/2025-10-26_00-00-17.md");
+	// resolution honors the space's captures_dir setting
+	notePath, err := h.spaceDBService.ResolveNoteFile(spaceObj.Path, note.NotePath)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	log.Printf("🔍 Reading note content:")
+	log.Printf("  - Space path: %s", spaceObj.Path)
+	log.Printf("  - Note relative path: %s", note.NotePath)
+	log.Printf("  - Full note path: %s", notePath)
+
+	content, err := h.spaceDBService.ReadCapture(notePath)
+	if err != nil {
+		log.Printf("❌ Failed to read note file: %v", err)
+		if errors.Is(err, space.ErrCaptureTooLarge) {
+			return fiber.NewError(fiber.StatusRequestEntityTooLarge, err.Error())
+		}
+		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("note file not found: %s", notePath))
+	}
+
+	log.Printf("✅ Successfully read note content (%d bytes)", len(content))
+
+	// Track that this note was referenced
+	_ = h.spaceDBService.TrackNoteReference(spaceObj.Path, captureID) // Don't fail if tracking fails
+
+	// Binary or mis-encoded files would be mangled into U+FFFD by the JSON
+	// encoder, so they're returned base64-encoded with a warning instead
+	encoding := "utf-8"
+	text := string(content)
+	var warning string
+	if !utf8.Valid(content) {
+		encoding = "base64"
+		text = base64.StdEncoding.EncodeToString(content)
+		warning = "capture content is not valid UTF-8; content is base64-encoded"
+	}
+
+	// Return both content and space-specific metadata
+	title := ""
+	if encoding == "utf-8" {
+		title = file.ResolveTitle(text)
+	}
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(note.NotePath), ".md")
+	}
+
+	response := fiber.Map{
+		"capture_id":         note.CaptureID,
+		"note_path":          note.NotePath,
+		"title":              title,
+		"content":            text,
+		"encoding":           encoding,
+		"space_context":      note.Context,
+		"context_structured": note.ContextStructured,
+		"tags":               note.Tags,
+		"status":             note.Status,
+		"due_at":             note.DueAt,
+		"linked_at":          note.LinkedAt,
+		"last_referenced":    note.LastReferenced,
+	}
+	if warning != "" {
+		response["warning"] = warning
+	}
+
+	// Sanitized HTML for clients without a markdown renderer; binary content has none
+	if render == "html" && encoding == "utf-8" {
+		html, err := file.RenderHTML(text)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		response["html"] = html
+	}
+	return c.JSON(response)
+}
+
+// ExplainLink handles GET /api/spaces/:id/notes/:capture_id/explain
+func (h *SpaceNotesHandler) ExplainLink(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	captureID := c.Params("capture_id")
+
+	if spaceID == "" || captureID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id and capture_id are required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	explanation, err := h.spaceDBService.ExplainLink(spaceObj.Path, captureID)
+	if err != nil {
+		var goneErr *domain.GoneError
+		if errors.As(err, &goneErr) {
+			return fiber.NewError(fiber.StatusGone, "note was unlinked from space")
+		}
+		if err.Error() == "note not found in space" {
+			return fiber.NewError(fiber.StatusNotFound, "note not found in space")
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to explain link: %v", err))
+	}
+
+	return c.JSON(explanation)
+}
+
+// GetNoteAttachments handles GET /api/spaces/:id/notes/:capture_id/attachments
+func (h *SpaceNotesHandler) GetNoteAttachments(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	captureID := c.Params("capture_id")
+
+	if spaceID == "" || captureID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id and capture_id are required")
 	}
 
-	// Validate at least one field is provided
-	if req.Context == nil && req.Tags == nil {
-		return fiber.NewError(fiber.StatusBadRequest, "at least one of context or tags must be provided")
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
 	}
 
-	// Update note context
-	if err := h.spaceDBService.UpdateNoteContext(spaceObj.Path, captureID, req.Context, req.Tags); err != nil {
+	attachments, err := h.spaceDBService.GetNoteAttachments(spaceObj.Path, captureID)
+	if err != nil {
+		var goneErr *domain.GoneError
+		if errors.As(err, &goneErr) {
+			return fiber.NewError(fiber.StatusGone, "note was unlinked from space")
+		}
+		var notFoundErr *domain.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return fiber.NewError(fiber.StatusNotFound, notFoundErr.Error())
+		}
 		if err.Error() == "note not found in space" {
 			return fiber.NewError(fiber.StatusNotFound, "note not found in space")
 		}
-		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to update note context: %v", err))
+		if errors.Is(err, space.ErrCaptureTooLarge) {
+			return fiber.NewError(fiber.StatusRequestEntityTooLarge, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to get attachments: %v", err))
+	}
+
+	missing := 0
+	for _, attachment := range attachments {
+		if !attachment.Exists {
+			missing++
+		}
 	}
 
 	return c.JSON(fiber.Map{
-		"message":    "note context updated successfully",
-		"space_id":   spaceID,
-		"capture_id": captureID,
+		"attachments": attachments,
+		"total":       len(attachments),
+		"missing":     missing,
 	})
 }
 
-// UnlinkNote handles DELETE /api/spaces/:id/notes/:capture_id
-func (h *SpaceNotesHandler) UnlinkNote(c fiber.Ctx) error {
+// GetNoteStructure handles GET /api/spaces/:id/notes/:capture_id/structure
+func (h *SpaceNotesHandler) GetNoteStructure(c fiber.Ctx) error {
 	spaceID := c.Params("id")
 	captureID := c.Params("capture_id")
 
@@ -209,23 +1729,42 @@ func (h *SpaceNotesHandler) UnlinkNote(c fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusNotFound, "space not found")
 	}
 
-	// Unlink the note
-	if err := h.spaceDBService.UnlinkNote(spaceObj.Path, captureID); err != nil {
+	note, err := h.spaceDBService.GetNoteByID(spaceObj.Path, captureID)
+	if err != nil {
+		var goneErr *domain.GoneError
+		if errors.As(err, &goneErr) {
+			return fiber.NewError(fiber.StatusGone, "note was unlinked from space")
+		}
 		if err.Error() == "note not found in space" {
 			return fiber.NewError(fiber.StatusNotFound, "note not found in space")
 		}
-		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to unlink note: %v", err))
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to get note: %v", err))
+	}
+
+	notePath, err := h.spaceDBService.ResolveNoteFile(spaceObj.Path, note.NotePath)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	content, err := h.spaceDBService.ReadCapture(notePath)
+	if err != nil {
+		if errors.Is(err, space.ErrCaptureTooLarge) {
+			return fiber.NewError(fiber.StatusRequestEntityTooLarge, err.Error())
+		}
+		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("note file not found: %s", note.NotePath))
 	}
 
 	return c.JSON(fiber.Map{
-		"message":    "note unlinked successfully",
-		"space_id":   spaceID,
-		"capture_id": captureID,
+		"capture_id": note.CaptureID,
+		"note_path":  note.NotePath,
+		"structure":  file.ParseCaptureDetailed(string(content)),
 	})
 }
 
-// GetNoteContent handles GET /api/spaces/:id/notes/:capture_id/content
-func (h *SpaceNotesHandler) GetNoteContent(c fiber.Ctx) error {
+// GetNoteChunks handles GET /api/spaces/:id/notes/:capture_id/chunks
+// Query params: size (max bytes per chunk, default 1000) and overlap (bytes
+// shared with the previous chunk, default 100, at most half of size)
+func (h *SpaceNotesHandler) GetNoteChunks(c fiber.Ctx) error {
 	spaceID := c.Params("id")
 	captureID := c.Params("capture_id")
 
@@ -233,58 +1772,93 @@ func (h *SpaceNotesHandler) GetNoteContent(c fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "space_id and capture_id are required")
 	}
 
+	size := defaultChunkSize
+	if sizeStr := c.Query("size"); sizeStr != "" {
+		parsed, err := parseInt(sizeStr)
+		if err != nil || parsed <= 0 || parsed > maxChunkSize {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("size must be between 1 and %d", maxChunkSize))
+		}
+		size = parsed
+	}
+
+	overlap := defaultChunkOverlap
+	if overlapStr := c.Query("overlap"); overlapStr != "" {
+		parsed, err := parseInt(overlapStr)
+		if err != nil || parsed < 0 || parsed > size/2 {
+			return fiber.NewError(fiber.StatusBadRequest, "overlap must be between 0 and half of size")
+		}
+		overlap = parsed
+	} else if overlap > size/2 {
+		overlap = size / 2
+	}
+
 	// Get space to get its path
 	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
 	if err != nil {
 		return fiber.NewError(fiber.StatusNotFound, "space not found")
 	}
 
-	// Get note metadata from space database
 	note, err := h.spaceDBService.GetNoteByID(spaceObj.Path, captureID)
 	if err != nil {
+		var goneErr *domain.GoneError
+		if errors.As(err, &goneErr) {
+			return fiber.NewError(fiber.StatusGone, "note was unlinked from space")
+		}
 		if err.Error() == "note not found in space" {
 			return fiber.NewError(fiber.StatusNotFound, "note not found in space")
 		}
 		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to get note: %v", err))
 	}
 
-	// Read note content from file system
-	// note.NotePath is relative (e.g., "captures/2025-10-26_00-00-17.md")
-	// We need to construct the full path from parachute root
-	parachuteRoot := filepath.Dir(filepath.Dir(spaceObj.Path)) // Go up from spaces/space-name to ~/Parachute
-	notePath := filepath.Join(parachuteRoot, note.NotePath)
-
-	log.Printf("🔍 Reading note content:")
-	log.Printf("  - Space path: %s", spaceObj.Path)
-	log.Printf("  - Parachute root: %s", parachuteRoot)
-	log.Printf("  - Note relative path: %s", note.NotePath)
-	log.Printf("  - Full note path: %s", notePath)
-
-	content, err := os.ReadFile(notePath)
+	notePath, err := h.spaceDBService.ResolveNoteFile(spaceObj.Path, note.NotePath)
 	if err != nil {
-		log.Printf("❌ Failed to read note file: %v", err)
-		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("note file not found: %s", notePath))
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	log.Printf("✅ Successfully read note content (%d bytes)", len(content))
+	content, err := h.spaceDBService.ReadCapture(notePath)
+	if err != nil {
+		if errors.Is(err, space.ErrCaptureTooLarge) {
+			return fiber.NewError(fiber.StatusRequestEntityTooLarge, err.Error())
+		}
+		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("note file not found: %s", note.NotePath))
+	}
 
-	// Track that this note was referenced
-	_ = h.spaceDBService.TrackNoteReference(spaceObj.Path, captureID) // Don't fail if tracking fails
+	chunks := file.ChunkCapture(string(content), size, overlap)
 
-	// Return both content and space-specific metadata
 	return c.JSON(fiber.Map{
-		"capture_id":      note.CaptureID,
-		"note_path":       note.NotePath,
-		"content":         string(content),
-		"space_context":   note.Context,
-		"tags":            note.Tags,
-		"linked_at":       note.LinkedAt,
-		"last_referenced": note.LastReferenced,
+		"capture_id": note.CaptureID,
+		"size":       size,
+		"overlap":    overlap,
+		"total":      len(chunks),
+		"chunks":     chunks,
 	})
 }
 
 // Helper functions
 
+// parseDryRun reads the dry_run query parameter of a bulk operation
+func parseDryRun(c fiber.Ctx) (bool, error) {
+	dryRunStr := c.Query("dry_run")
+	if dryRunStr == "" {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(dryRunStr)
+	if err != nil {
+		return false, fiber.NewError(fiber.StatusBadRequest, "dry_run must be true or false")
+	}
+	return dryRun, nil
+}
+
+// includes reports whether the comma-separated include query parameter lists field
+func includes(c fiber.Ctx, field string) bool {
+	for _, f := range splitAndTrim(c.Query("include"), ",") {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
 func splitAndTrim(s, sep string) []string {
 	if s == "" {
 		return []string{}
@@ -342,6 +1916,52 @@ func parseInt(s string) (int, error) {
 	return result, nil
 }
 
+// ExportNotesMarkdown handles GET /api/spaces/:id/export/markdown
+// It accepts the same filters as GetNotes but applies no default limit, and
+// format=table for a table of notes instead of their full content.
+func (h *SpaceNotesHandler) ExportNotesMarkdown(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	if spaceID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id is required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	filters := parseNoteFilters(c, 0)
+	if err := applyRelativeLinkDates(c, &filters, time.Now()); err != nil {
+		return err
+	}
+
+	var doc string
+	switch c.Query("format", space.ExportFormatBullets) {
+	case space.ExportFormatBullets:
+		doc, err = h.spaceDBService.ExportNotesMarkdown(spaceObj.Path, filters)
+	case space.ExportFormatTable:
+		doc, err = h.spaceDBService.ExportNotesTable(spaceObj.Path, filters)
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, "format must be bullets or table")
+	}
+	if err != nil {
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to export notes: %v", err))
+	}
+
+	// The export succeeded whether or not it could be recorded
+	_ = h.spaceDBService.RecordExport(spaceID, spaceObj.Path, time.Now())
+
+	filename := filepath.Base(spaceObj.Path) + ".md"
+	c.Set("Content-Type", "text/markdown; charset=utf-8")
+	c.Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	return c.SendString(fmt.Sprintf("# %s\n\n%s\n", spaceObj.Name, doc))
+}
+
 // GetDatabaseStats handles GET /api/spaces/:id/database/stats
 func (h *SpaceNotesHandler) GetDatabaseStats(c fiber.Ctx) error {
 	spaceID := c.Params("id")
@@ -367,9 +1987,268 @@ func (h *SpaceNotesHandler) GetDatabaseStats(c fiber.Ctx) error {
 		})
 	}
 
+	// Content stats read every linked capture file, so they are opt-in
+	if include, _ := strconv.ParseBool(c.Query("include_content_stats")); include {
+		contentStats, err := h.spaceDBService.GetContentStats(spaceObj.Path)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": fmt.Sprintf("Failed to get content stats: %v", err),
+			})
+		}
+		stats.ContentStats = contentStats
+	}
+
 	return c.JSON(stats)
 }
 
+// GetDatabaseHealth handles GET /api/spaces/:id/database/health
+func (h *SpaceNotesHandler) GetDatabaseHealth(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	if spaceID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "space_id is required",
+		})
+	}
+
+	// Get space
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Space not found",
+		})
+	}
+
+	health, err := h.spaceDBService.GetDatabaseHealth(spaceObj.Path)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to check database health: %v", err),
+		})
+	}
+
+	return c.JSON(health)
+}
+
+// GetDatabaseStatus handles GET /api/spaces/:id/database/status
+func (h *SpaceNotesHandler) GetDatabaseStatus(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	if spaceID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "space_id is required",
+		})
+	}
+
+	// Get space
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Space not found",
+		})
+	}
+
+	status, err := h.spaceDBService.GetDatabaseStatus(spaceObj.Path)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to get database status: %v", err),
+		})
+	}
+
+	return c.JSON(status)
+}
+
+// ExportManifest handles POST /api/spaces/:id/manifest, regenerating the
+// space's notes.json whether or not the notes_manifest setting is on
+func (h *SpaceNotesHandler) ExportManifest(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	if spaceID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id is required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	if err := h.spaceDBService.ExportManifest(spaceObj.Path); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to export manifest: %v", err))
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "manifest exported",
+		"file":    space.NotesManifestFile,
+	})
+}
+
+// RecomputeDatabase handles POST /api/spaces/:id/database/recompute
+func (h *SpaceNotesHandler) RecomputeDatabase(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	if spaceID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "space_id is required",
+		})
+	}
+
+	// Get space
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Space not found",
+		})
+	}
+
+	report, err := h.spaceDBService.RecomputeDenormalized(spaceObj.Path)
+	if err != nil {
+		if errors.Is(err, space.ErrRateLimited) {
+			return tooManyRequests(c, err)
+		}
+		if errors.Is(err, space.ErrSpaceReadOnly) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, space.ErrSpaceBusy) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to recompute database: %v", err),
+		})
+	}
+
+	return c.JSON(report)
+}
+
+// ResetDatabase handles POST /api/spaces/:id/database/reset
+// The old space.sqlite is kept as a backup in the space directory.
+func (h *SpaceNotesHandler) ResetDatabase(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	if spaceID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "space_id is required",
+		})
+	}
+
+	// Get space
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Space not found",
+		})
+	}
+
+	backupPath, err := h.spaceDBService.ResetSpaceDatabase(spaceObj.Path)
+	if err != nil {
+		if errors.Is(err, space.ErrRateLimited) {
+			return tooManyRequests(c, err)
+		}
+		if errors.Is(err, space.ErrSpaceReadOnly) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, space.ErrSpaceBusy) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if err.Error() == "space database not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to reset database: %v", err),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "space database reset",
+		"backup":  filepath.Base(backupPath),
+	})
+}
+
+// StartReindex handles POST /api/spaces/:id/reindex
+// The reindex runs in the background; poll GET /api/spaces/:id/reindex/:job_id for progress.
+func (h *SpaceNotesHandler) StartReindex(c fiber.Ctx) error {
+	spaceID := c.Params("id")
+	if spaceID == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "space_id is required")
+	}
+
+	// Get space to get its path
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	jobID, err := h.spaceDBService.StartReindex(spaceObj.Path)
+	if err != nil {
+		if errors.Is(err, space.ErrRateLimited) {
+			return tooManyRequests(c, err)
+		}
+		if errors.Is(err, space.ErrSpaceReadOnly) {
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
+		if errors.Is(err, space.ErrSpaceBusy) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, fmt.Sprintf("failed to start reindex: %v", err))
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"job_id": jobID,
+	})
+}
+
+// GetReindexStatus handles GET /api/spaces/:id/reindex/:job_id
+func (h *SpaceNotesHandler) GetReindexStatus(c fiber.Ctx) error {
+	spacePath, jobID, err := h.reindexJobID(c)
+	if err != nil {
+		return err
+	}
+
+	status, err := h.spaceDBService.GetReindexStatus(spacePath, jobID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	return c.JSON(status)
+}
+
+// CancelReindex handles DELETE /api/spaces/:id/reindex/:job_id
+// The job stops after the note it is processing; cancelling a finished job is a no-op.
+func (h *SpaceNotesHandler) CancelReindex(c fiber.Ctx) error {
+	spacePath, jobID, err := h.reindexJobID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.spaceDBService.CancelReindex(spacePath, jobID); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	status, _ := h.spaceDBService.GetReindexStatus(spacePath, jobID)
+	return c.JSON(status)
+}
+
+// reindexJobID validates the space and job ID parameters of a reindex route,
+// returning the space's path with the job ID
+func (h *SpaceNotesHandler) reindexJobID(c fiber.Ctx) (string, string, error) {
+	spaceID := c.Params("id")
+	jobID := c.Params("job_id")
+	if spaceID == "" || jobID == "" {
+		return "", "", fiber.NewError(fiber.StatusBadRequest, "space_id and job_id are required")
+	}
+
+	spaceObj, err := h.spaceService.GetByID(c.Context(), spaceID)
+	if err != nil {
+		return "", "", fiber.NewError(fiber.StatusNotFound, "space not found")
+	}
+
+	return spaceObj.Path, jobID, nil
+}
+
 // GetTableData handles GET /api/spaces/:id/database/tables/:table_name
 func (h *SpaceNotesHandler) GetTableData(c fiber.Ctx) error {
 	spaceID := c.Params("id")
@@ -396,8 +2275,19 @@ func (h *SpaceNotesHandler) GetTableData(c fiber.Ctx) error {
 	}
 
 	// Query table
-	result, err := h.spaceDBService.QueryTable(spaceObj.Path, tableName)
+	filter := space.TableFilter{
+		Search: c.Query("search"),
+		Column: c.Query("column"),
+		Value:  c.Query("value"),
+	}
+	result, err := h.spaceDBService.QueryTableFiltered(spaceObj.Path, tableName, filter)
 	if err != nil {
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": fmt.Sprintf("Failed to query table: %v", err),
 		})
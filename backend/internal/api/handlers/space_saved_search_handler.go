@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+// SpaceSavedSearchHandler handles HTTP requests for per-space saved searches
+type SpaceSavedSearchHandler struct {
+	spaceService   *space.Service
+	spaceDBService *space.SpaceDatabaseService
+}
+
+// NewSpaceSavedSearchHandler creates a new saved search handler
+func NewSpaceSavedSearchHandler(spaceService *space.Service, spaceDBService *space.SpaceDatabaseService) *SpaceSavedSearchHandler {
+	return &SpaceSavedSearchHandler{
+		spaceService:   spaceService,
+		spaceDBService: spaceDBService,
+	}
+}
+
+// SaveSearchRequest represents a request to create or replace a saved search
+type SaveSearchRequest struct {
+	Filters space.NoteFilters `json:"filters"`
+}
+
+// searchName returns the URL-decoded :name parameter
+func searchName(c fiber.Ctx) string {
+	name := c.Params("name")
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		return unescaped
+	}
+	return name
+}
+
+// ListSavedSearches handles GET /api/spaces/:id/saved-searches
+func (h *SpaceSavedSearchHandler) ListSavedSearches(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	searches, err := h.spaceDBService.ListSavedSearches(spaceObj.Path)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"saved_searches": searches,
+	})
+}
+
+// GetSavedSearch handles GET /api/spaces/:id/saved-searches/:name
+func (h *SpaceSavedSearchHandler) GetSavedSearch(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	search, err := h.spaceDBService.GetSavedSearch(spaceObj.Path, searchName(c))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(search)
+}
+
+// SaveSearch handles PUT /api/spaces/:id/saved-searches/:name
+func (h *SpaceSavedSearchHandler) SaveSearch(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	var req SaveSearchRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return invalidJSONBody(c, err)
+	}
+
+	// Ensure space.sqlite exists
+	if err := h.spaceDBService.InitializeSpaceDatabase(spaceObj.ID, spaceObj.Path); err != nil {
+		return HandleError(c, err)
+	}
+
+	search, err := h.spaceDBService.SaveSearch(spaceObj.Path, searchName(c), req.Filters)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(search)
+}
+
+// DeleteSavedSearch handles DELETE /api/spaces/:id/saved-searches/:name
+func (h *SpaceSavedSearchHandler) DeleteSavedSearch(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	name := searchName(c)
+	if err := h.spaceDBService.DeleteSavedSearch(spaceObj.Path, name); err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "saved search deleted successfully",
+		"name":    name,
+	})
+}
+
+// RunSavedSearch handles GET /api/spaces/:id/saved-searches/:name/notes
+func (h *SpaceSavedSearchHandler) RunSavedSearch(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	notes, err := h.spaceDBService.RunSavedSearch(spaceObj.Path, searchName(c))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(GetNotesResponse{
+		Notes: notes,
+		Total: len(notes),
+	})
+}
+
+// SavedSearchExport is the JSON export of a saved search's notes
+type SavedSearchExport struct {
+	Name       string               `json:"name"`
+	Filters    space.NoteFilters    `json:"filters"`
+	ExportedAt time.Time            `json:"exported_at"`
+	Notes      []space.RelevantNote `json:"notes"`
+	Total      int                  `json:"total"`
+}
+
+// ExportSavedSearch handles GET /api/spaces/:id/saved-searches/:name/export
+// format is markdown (the default; same as bullets in the notes export),
+// table or json. The response is sent as a file download.
+func (h *SpaceSavedSearchHandler) ExportSavedSearch(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	search, err := h.spaceDBService.GetSavedSearch(spaceObj.Path, searchName(c))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	format := c.Query("format", "markdown")
+	filename := exportFilename(search.Name)
+
+	var doc string
+	switch format {
+	case "markdown", space.ExportFormatBullets:
+		doc, err = h.spaceDBService.ExportNotesMarkdown(spaceObj.Path, search.Filters)
+	case space.ExportFormatTable:
+		doc, err = h.spaceDBService.ExportNotesTable(spaceObj.Path, search.Filters)
+	case "json":
+		notes, err := h.spaceDBService.GetRelevantNotes(spaceObj.Path, search.Filters)
+		if err != nil {
+			return HandleError(c, err)
+		}
+		c.Set("Content-Disposition", "attachment; filename=\""+filename+".json\"")
+		return c.JSON(SavedSearchExport{
+			Name:       search.Name,
+			Filters:    search.Filters,
+			ExportedAt: time.Now(),
+			Notes:      notes,
+			Total:      len(notes),
+		})
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "format must be markdown, table or json",
+		})
+	}
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	c.Set("Content-Type", "text/markdown; charset=utf-8")
+	c.Set("Content-Disposition", "attachment; filename=\""+filename+".md\"")
+	return c.SendString(fmt.Sprintf("# %s: %s\n\n%s\n", spaceObj.Name, search.Name, doc))
+}
+
+// exportFilename turns a saved search name into a safe download filename
+// (without extension), replacing anything but letters, digits, "-" and "_"
+func exportFilename(name string) string {
+	filename := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, name)
+	filename = strings.Trim(filename, "-")
+	if filename == "" {
+		return "saved-search"
+	}
+	return filename
+}
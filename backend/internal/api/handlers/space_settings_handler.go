@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+// SpaceSettingsHandler handles HTTP requests for per-space settings
+type SpaceSettingsHandler struct {
+	spaceService   *space.Service
+	spaceDBService *space.SpaceDatabaseService
+}
+
+// NewSpaceSettingsHandler creates a new space settings handler
+func NewSpaceSettingsHandler(spaceService *space.Service, spaceDBService *space.SpaceDatabaseService) *SpaceSettingsHandler {
+	return &SpaceSettingsHandler{
+		spaceService:   spaceService,
+		spaceDBService: spaceDBService,
+	}
+}
+
+// SetSpaceSettingRequest represents a request to change a space setting
+type SetSpaceSettingRequest struct {
+	Value string `json:"value"` // Empty resets the setting to its default
+}
+
+// ImportSpaceSettingsRequest represents a request to apply several settings at once
+type ImportSpaceSettingsRequest struct {
+	Settings map[string]string `json:"settings"` // Settings left out keep their values
+}
+
+// SetPinnedContextRequest represents a request to change a space's pinned context
+type SetPinnedContextRequest struct {
+	PinnedContext string `json:"pinned_context"`     // Empty removes it
+	Position      string `json:"position,omitempty"` // "top" or "bottom"; unchanged when omitted
+}
+
+// GetSettings handles GET /api/spaces/:id/settings
+func (h *SpaceSettingsHandler) GetSettings(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	settings, err := h.spaceDBService.GetSettings(spaceObj.Path)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"settings": settings,
+	})
+}
+
+// SetSetting handles PUT /api/spaces/:id/settings/:key
+func (h *SpaceSettingsHandler) SetSetting(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	var req SetSpaceSettingRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return invalidJSONBody(c, err)
+	}
+
+	// Ensure space.sqlite exists
+	if err := h.spaceDBService.InitializeSpaceDatabase(spaceObj.ID, spaceObj.Path); err != nil {
+		return HandleError(c, err)
+	}
+
+	key := c.Params("key")
+	if err := h.spaceDBService.SetSetting(spaceObj.Path, key, req.Value); err != nil {
+		return HandleError(c, err)
+	}
+
+	value, err := h.spaceDBService.GetSetting(spaceObj.Path, key)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"key":   key,
+		"value": value,
+	})
+}
+
+// ExportSettings handles GET /api/spaces/:id/settings/bulk
+// The result can be PUT to the same path on another space.
+func (h *SpaceSettingsHandler) ExportSettings(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	settings, err := h.spaceDBService.ExportSettings(spaceObj.Path)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"settings": settings,
+	})
+}
+
+// ImportSettings handles PUT /api/spaces/:id/settings/bulk
+func (h *SpaceSettingsHandler) ImportSettings(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	var req ImportSpaceSettingsRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return invalidJSONBody(c, err)
+	}
+
+	// Ensure space.sqlite exists
+	if err := h.spaceDBService.InitializeSpaceDatabase(spaceObj.ID, spaceObj.Path); err != nil {
+		return HandleError(c, err)
+	}
+
+	if err := h.spaceDBService.ImportSettings(spaceObj.Path, req.Settings); err != nil {
+		return HandleError(c, err)
+	}
+
+	settings, err := h.spaceDBService.GetSettings(spaceObj.Path)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"settings": settings,
+	})
+}
+
+// ExportTemplate handles GET /api/spaces/:id/export/template
+// The result can be passed as "template" when creating a space.
+func (h *SpaceSettingsHandler) ExportTemplate(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	template, err := h.spaceDBService.ExportAsTemplate(spaceObj.Path)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(template)
+}
+
+// GetPinnedContext handles GET /api/spaces/:id/pinned-context
+func (h *SpaceSettingsHandler) GetPinnedContext(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return h.pinnedContextResponse(c, spaceObj.Path)
+}
+
+// SetPinnedContext handles PUT /api/spaces/:id/pinned-context
+func (h *SpaceSettingsHandler) SetPinnedContext(c fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	spaceObj, err := h.spaceService.GetByID(ctx, c.Params("id"))
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	var req SetPinnedContextRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return invalidJSONBody(c, err)
+	}
+
+	// Ensure space.sqlite exists
+	if err := h.spaceDBService.InitializeSpaceDatabase(spaceObj.ID, spaceObj.Path); err != nil {
+		return HandleError(c, err)
+	}
+
+	settings := map[string]string{space.SettingPinnedContext: req.PinnedContext}
+	if req.Position != "" {
+		settings[space.SettingPinnedContextPosition] = req.Position
+	}
+	if err := h.spaceDBService.ImportSettings(spaceObj.Path, settings); err != nil {
+		return HandleError(c, err)
+	}
+
+	return h.pinnedContextResponse(c, spaceObj.Path)
+}
+
+// pinnedContextResponse responds with a space's pinned context and its position
+func (h *SpaceSettingsHandler) pinnedContextResponse(c fiber.Ctx, spacePath string) error {
+	pinned, err := h.spaceDBService.GetSetting(spacePath, space.SettingPinnedContext)
+	if err != nil {
+		return HandleError(c, err)
+	}
+	position, err := h.spaceDBService.GetSetting(spacePath, space.SettingPinnedContextPosition)
+	if err != nil {
+		return HandleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"pinned_context": pinned,
+		"position":       position,
+	})
+}
@@ -17,6 +17,21 @@ func NewNotFoundError(resource, id string) *NotFoundError {
 	return &NotFoundError{Resource: resource, ID: id}
 }
 
+// GoneError represents a resource that existed but has since been removed
+type GoneError struct {
+	Resource string
+	ID       string
+}
+
+func (e *GoneError) Error() string {
+	return fmt.Sprintf("%s no longer exists: %s", e.Resource, e.ID)
+}
+
+// NewGoneError creates a new GoneError
+func NewGoneError(resource, id string) *GoneError {
+	return &GoneError{Resource: resource, ID: id}
+}
+
 // ValidationError represents a validation error
 type ValidationError struct {
 	Field   string
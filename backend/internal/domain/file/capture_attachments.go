@@ -0,0 +1,64 @@
+package file
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Attachment is a local file a capture references with a markdown image or link
+type Attachment struct {
+	Path  string `json:"path"`  // As written in the capture, with %-escapes decoded
+	Alt   string `json:"alt"`   // Image alt text or link text
+	Image bool   `json:"image"` // ![alt](path) rather than [text](path)
+}
+
+// attachmentPattern matches ![alt](path) and [text](path), with the path
+// optionally in <angle brackets> and followed by a "title"
+var attachmentPattern = regexp.MustCompile(`(!?)\[([^\[\]]*)\]\(\s*(<[^<>]+>|[^()\s]+)(?:\s+"[^"]*")?\s*\)`)
+
+// schemePattern matches a URL scheme such as https: or mailto:
+var schemePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*:`)
+
+// ExtractAttachments returns the local files referenced by markdown images
+// and links in content, in order of first appearance. Links to URLs (anything
+// with a scheme) and to #anchors are not attachments; references inside
+// fenced code blocks are ignored. A #fragment on a path is dropped.
+func ExtractAttachments(content string) []Attachment {
+	attachments := []Attachment{}
+	seen := make(map[string]bool)
+	inFence := false
+
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		for _, m := range attachmentPattern.FindAllStringSubmatch(line, -1) {
+			path := strings.TrimSuffix(strings.TrimPrefix(m[3], "<"), ">")
+			if path == "" || strings.HasPrefix(path, "#") || schemePattern.MatchString(path) {
+				continue
+			}
+			path, _, _ = strings.Cut(path, "#")
+			if unescaped, err := url.PathUnescape(path); err == nil {
+				path = unescaped
+			}
+			if path == "" || seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			attachments = append(attachments, Attachment{
+				Path:  path,
+				Alt:   strings.TrimSpace(m[2]),
+				Image: m[1] == "!",
+			})
+		}
+	}
+
+	return attachments
+}
@@ -0,0 +1,35 @@
+package file
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractAttachments(t *testing.T) {
+	content := strings.Join([]string{
+		"# Garden walk",
+		"![Raised beds](images/beds.jpg \"Spring\") and the [seed list](../docs/seed%20list.pdf#page=2).",
+		"See [the forum](https://example.com/forum), [above](#garden-walk) and [mail](mailto:maria@example.com).",
+		"![](<images/with space.png>) then ![Raised beds again](images/beds.jpg)",
+		"```",
+		"![not an attachment](images/code.png)",
+		"```",
+	}, "\n")
+
+	want := []Attachment{
+		{Path: "images/beds.jpg", Alt: "Raised beds", Image: true},
+		{Path: "../docs/seed list.pdf", Alt: "seed list", Image: false},
+		{Path: "images/with space.png", Alt: "", Image: true},
+	}
+
+	if got := ExtractAttachments(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
+
+func TestExtractAttachmentsNone(t *testing.T) {
+	if got := ExtractAttachments("Just text with [[a wikilink]]"); len(got) != 0 {
+		t.Errorf("Expected no attachments, got %+v", got)
+	}
+}
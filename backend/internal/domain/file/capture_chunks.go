@@ -0,0 +1,157 @@
+package file
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Chunk is a contiguous slice of a capture produced by ChunkCapture
+type Chunk struct {
+	Text  string `json:"text"`
+	Start int    `json:"start"` // Byte offset of the chunk in the full content
+	End   int    `json:"end"`   // Byte offset just past the chunk
+}
+
+// Boundary levels, from weakest to strongest place to cut
+const (
+	boundaryWord = iota + 1
+	boundarySentence
+	boundaryParagraph
+)
+
+// chunkBoundary is a position where a chunk may start: the first
+// non-whitespace byte after a run of whitespace
+type chunkBoundary struct {
+	pos   int
+	level int
+}
+
+// ChunkCapture splits content into chunks of at most maxChars bytes, each
+// sharing up to overlap bytes with the one before it. Cuts fall on the
+// strongest boundary in the back half of the window: a paragraph break, then
+// a sentence end or line break, then a word break; only text with none of
+// these is cut mid-word (never mid-character). Overlaps start at a sentence
+// or word where possible. Chunks cover the whole content in order, and the
+// result depends only on the arguments.
+func ChunkCapture(content string, maxChars int, overlap int) []Chunk {
+	chunks := []Chunk{}
+	if content == "" || maxChars <= 0 {
+		return chunks
+	}
+	if overlap < 0 {
+		overlap = 0
+	}
+	if overlap > maxChars/2 {
+		overlap = maxChars / 2
+	}
+
+	boundaries := findChunkBoundaries(content)
+
+	start := 0
+	for {
+		if len(content)-start <= maxChars {
+			return append(chunks, Chunk{Text: content[start:], Start: start, End: len(content)})
+		}
+
+		end := chunkEnd(content, boundaries, start, maxChars)
+		chunks = append(chunks, Chunk{Text: content[start:end], Start: start, End: end})
+
+		next := overlapStart(content, boundaries, end, overlap)
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+}
+
+// chunkEnd picks where the chunk starting at start ends
+func chunkEnd(content string, boundaries []chunkBoundary, start, maxChars int) int {
+	limit := start + maxChars
+	floor := start + maxChars/2
+
+	// Boundaries at or before limit, searched from the back
+	last := sort.Search(len(boundaries), func(i int) bool { return boundaries[i].pos > limit }) - 1
+	for level := boundaryParagraph; level >= boundaryWord; level-- {
+		for i := last; i >= 0 && boundaries[i].pos > floor; i-- {
+			if boundaries[i].level >= level {
+				return boundaries[i].pos
+			}
+		}
+	}
+
+	// No boundary in the back half: cut at the limit, backing off to a character start
+	end := limit
+	for end > start && !utf8.RuneStart(content[end]) {
+		end--
+	}
+	if end == start {
+		_, size := utf8.DecodeRuneInString(content[start:])
+		end = start + size
+	}
+	return end
+}
+
+// overlapStart picks where the chunk after one ending at end starts, reaching
+// back at most overlap bytes
+func overlapStart(content string, boundaries []chunkBoundary, end, overlap int) int {
+	if overlap == 0 {
+		return end
+	}
+	from := end - overlap
+
+	first := sort.Search(len(boundaries), func(i int) bool { return boundaries[i].pos >= from })
+	for _, level := range []int{boundarySentence, boundaryWord} {
+		for i := first; i < len(boundaries) && boundaries[i].pos < end; i++ {
+			if boundaries[i].level >= level {
+				return boundaries[i].pos
+			}
+		}
+	}
+
+	for from < end && !utf8.RuneStart(content[from]) {
+		from++
+	}
+	return from
+}
+
+// findChunkBoundaries returns every position in content that follows a run
+// of whitespace, in order, graded by the run and the text before it
+func findChunkBoundaries(content string) []chunkBoundary {
+	var boundaries []chunkBoundary
+
+	for pos := 1; pos < len(content); pos++ {
+		if isChunkSpace(content[pos]) || !isChunkSpace(content[pos-1]) {
+			continue
+		}
+
+		runStart := pos - 1
+		for runStart > 0 && isChunkSpace(content[runStart-1]) {
+			runStart--
+		}
+		newlines := strings.Count(content[runStart:pos], "\n")
+
+		level := boundaryWord
+		switch {
+		case newlines >= 2:
+			level = boundaryParagraph
+		case newlines == 1 || endsSentence(content[:runStart]):
+			level = boundarySentence
+		}
+		boundaries = append(boundaries, chunkBoundary{pos: pos, level: level})
+	}
+
+	return boundaries
+}
+
+// endsSentence reports whether text ends with sentence punctuation, allowing
+// closing quotes and brackets after it
+func endsSentence(text string) bool {
+	text = strings.TrimRight(text, `"')]”’`)
+	return strings.HasSuffix(text, ".") || strings.HasSuffix(text, "!") ||
+		strings.HasSuffix(text, "?") || strings.HasSuffix(text, "…")
+}
+
+func isChunkSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
@@ -0,0 +1,112 @@
+package file
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestChunkCapture(t *testing.T) {
+	var paragraphs []string
+	for p := 0; p < 8; p++ {
+		var sentences []string
+		for s := 0; s < 6; s++ {
+			sentences = append(sentences, fmt.Sprintf("Paragraph %d sentence %d talks about the garden beds.", p, s))
+		}
+		paragraphs = append(paragraphs, strings.Join(sentences, " "))
+	}
+	content := strings.Join(paragraphs, "\n\n")
+
+	const size, overlap = 300, 60
+	chunks := ChunkCapture(content, size, overlap)
+	if len(chunks) < 2 {
+		t.Fatalf("Expected several chunks, got %d", len(chunks))
+	}
+
+	t.Run("CoversContent", func(t *testing.T) {
+		if chunks[0].Start != 0 || chunks[len(chunks)-1].End != len(content) {
+			t.Errorf("Expected chunks to span the content, got %d..%d", chunks[0].Start, chunks[len(chunks)-1].End)
+		}
+		var rebuilt strings.Builder
+		covered := 0
+		for _, chunk := range chunks {
+			if chunk.Text != content[chunk.Start:chunk.End] {
+				t.Fatalf("Chunk text does not match its offsets: %+v", chunk)
+			}
+			if chunk.Start > covered {
+				t.Fatalf("Gap before chunk at %d (covered to %d)", chunk.Start, covered)
+			}
+			rebuilt.WriteString(content[covered:chunk.End])
+			covered = chunk.End
+		}
+		if rebuilt.String() != content {
+			t.Error("Expected chunks to rebuild the content")
+		}
+	})
+
+	t.Run("RespectsMaxSize", func(t *testing.T) {
+		for i, chunk := range chunks {
+			if len(chunk.Text) > size {
+				t.Errorf("Chunk %d is %d bytes, over %d", i, len(chunk.Text), size)
+			}
+		}
+	})
+
+	t.Run("Overlaps", func(t *testing.T) {
+		for i := 1; i < len(chunks); i++ {
+			shared := chunks[i-1].End - chunks[i].Start
+			if shared <= 0 || shared > overlap {
+				t.Errorf("Chunk %d shares %d bytes with the previous one, want 1..%d", i, shared, overlap)
+			}
+			if chunks[i].Start <= chunks[i-1].Start {
+				t.Errorf("Chunk %d does not advance", i)
+			}
+		}
+	})
+
+	t.Run("CutsOnSentences", func(t *testing.T) {
+		for i, chunk := range chunks[:len(chunks)-1] {
+			if !endsSentence(strings.TrimRight(chunk.Text, " \n")) {
+				t.Errorf("Chunk %d ends mid-sentence: %q", i, chunk.Text[len(chunk.Text)-20:])
+			}
+		}
+		for i, chunk := range chunks[1:] {
+			if !strings.HasPrefix(chunk.Text, "Paragraph ") {
+				t.Errorf("Chunk %d starts mid-sentence: %q", i+1, chunk.Text[:20])
+			}
+		}
+	})
+
+	t.Run("Deterministic", func(t *testing.T) {
+		if again := ChunkCapture(content, size, overlap); !reflect.DeepEqual(again, chunks) {
+			t.Error("Expected the same chunks for the same input")
+		}
+	})
+
+	t.Run("ShortContentIsOneChunk", func(t *testing.T) {
+		got := ChunkCapture("Just a line.", size, overlap)
+		if len(got) != 1 || got[0].Text != "Just a line." {
+			t.Errorf("Expected a single chunk, got %+v", got)
+		}
+		if got := ChunkCapture("", size, overlap); len(got) != 0 {
+			t.Errorf("Expected no chunks for empty content, got %+v", got)
+		}
+	})
+
+	t.Run("UnbrokenTextSplitsOnCharacters", func(t *testing.T) {
+		unbroken := strings.Repeat("é", 100)
+		got := ChunkCapture(unbroken, 25, 0)
+		covered := 0
+		for _, chunk := range got {
+			if !utf8.ValidString(chunk.Text) || len(chunk.Text) > 25 || chunk.Start != covered {
+				t.Fatalf("Bad chunk %+v", chunk)
+			}
+			covered = chunk.End
+		}
+		if covered != len(unbroken) {
+			t.Errorf("Expected chunks to reach the end, got %d of %d", covered, len(unbroken))
+		}
+	})
+}
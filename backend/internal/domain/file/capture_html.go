@@ -0,0 +1,30 @@
+package file
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var (
+	// markdownRenderer converts capture markdown (with GitHub extensions such
+	// as tables and task lists) to HTML. Raw HTML in the markdown is omitted.
+	markdownRenderer = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+	// htmlPolicy strips anything unsafe from rendered HTML, since capture
+	// content is untrusted
+	htmlPolicy = bluemonday.UGCPolicy()
+)
+
+// RenderHTML renders a capture's markdown body, without its frontmatter, as
+// sanitized HTML safe to embed in a web page.
+func RenderHTML(content string) (string, error) {
+	var buf bytes.Buffer
+	if err := markdownRenderer.Convert([]byte(ParseCaptureDetailed(content).Body), &buf); err != nil {
+		return "", fmt.Errorf("failed to render markdown: %w", err)
+	}
+	return htmlPolicy.Sanitize(buf.String()), nil
+}
@@ -0,0 +1,37 @@
+package file
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderHTML(t *testing.T) {
+	t.Run("HeadingAndList", func(t *testing.T) {
+		html, err := RenderHTML("---\ntitle: Garden\n---\n# Garden walk\n\n- compost\n- soil\n")
+		if err != nil {
+			t.Fatalf("Failed to render: %v", err)
+		}
+
+		want := "<h1>Garden walk</h1>\n<ul>\n<li>compost</li>\n<li>soil</li>\n</ul>\n"
+		if html != want {
+			t.Errorf("Expected %q, got %q", want, html)
+		}
+	})
+
+	t.Run("StripsUnsafeHTML", func(t *testing.T) {
+		content := "Hello <script>alert(1)</script>\n\n<img src=x onerror=alert(1)>\n\n[link](javascript:alert(1))\n"
+		html, err := RenderHTML(content)
+		if err != nil {
+			t.Fatalf("Failed to render: %v", err)
+		}
+
+		for _, unsafe := range []string{"<script", "alert(1)</script>", "onerror", "javascript:"} {
+			if strings.Contains(html, unsafe) {
+				t.Errorf("Expected %q to be stripped, got %q", unsafe, html)
+			}
+		}
+		if !strings.Contains(html, "Hello") {
+			t.Errorf("Expected the text to be kept, got %q", html)
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package file
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CaptureStructure is a capture markdown file broken into its parts
+type CaptureStructure struct {
+	Frontmatter map[string]string `json:"frontmatter"`
+	Headings    []CaptureHeading  `json:"headings"`
+	Tags        []string          `json:"tags"`      // #tags in the body, in order of first appearance
+	Wikilinks   []string          `json:"wikilinks"` // [[wikilink]] targets, in order of first appearance
+	Body        string            `json:"body"`      // Content after the frontmatter
+}
+
+// CaptureHeading is a markdown heading within a capture
+type CaptureHeading struct {
+	Level  int    `json:"level"`
+	Text   string `json:"text"`
+	Offset int    `json:"offset"` // Byte offset of the heading line in the full content
+}
+
+var (
+	headingPattern  = regexp.MustCompile(`^(#{1,6})[ \t]+(.*?)[ \t#]*$`)
+	tagPattern      = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&#/])#([\p{L}\p{N}_][\p{L}\p{N}_/-]*)`)
+	wikilinkPattern = regexp.MustCompile(`\[\[([^\[\]|#]+)(?:[#|][^\[\]]*)?\]\]`)
+	numericPattern  = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// SplitFrontmatter finds a leading "---" frontmatter block in content. It
+// returns the block's lines, without the delimiters or line endings, and the
+// byte offset where the body starts; ok is false if content has none. LF and
+// CRLF line endings are both accepted, the block may be empty, and the
+// closing "---" may end the file without a newline.
+func SplitFrontmatter(content string) (lines []string, bodyStart int, ok bool) {
+	var pos int
+	switch {
+	case strings.HasPrefix(content, "---\n"):
+		pos = len("---\n")
+	case strings.HasPrefix(content, "---\r\n"):
+		pos = len("---\r\n")
+	default:
+		return nil, 0, false
+	}
+
+	for pos < len(content) {
+		line, next := content[pos:], len(content)
+		if end := strings.IndexByte(line, '\n'); end != -1 {
+			line, next = line[:end], pos+end+1
+		}
+		line = strings.TrimSuffix(line, "\r")
+
+		if line == "---" {
+			return lines, next, true
+		}
+		lines = append(lines, line)
+		pos = next
+	}
+	return nil, 0, false
+}
+
+// parseFrontmatter parses a leading "---" frontmatter block of "key: value"
+// lines. It returns nil and 0 if the text has no (well-formed) frontmatter;
+// otherwise the values and the byte offset where the body starts.
+func parseFrontmatter(text string) (map[string]string, int) {
+	lines, bodyStart, ok := SplitFrontmatter(text)
+	if !ok {
+		return nil, 0
+	}
+
+	frontmatter := make(map[string]string)
+	for _, line := range lines {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(key) == "" {
+			continue
+		}
+		frontmatter[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	return frontmatter, bodyStart
+}
+
+// FrontmatterTags returns the tags listed under a top-level "tags" key in the
+// content's frontmatter, whether inline ("tags: [a, b]" or "tags: a, b") or as
+// a block list of "- a" lines. Quotes and a leading # are stripped and
+// duplicates dropped.
+func FrontmatterTags(content string) []string {
+	lines, _, ok := SplitFrontmatter(content)
+	if !ok {
+		return []string{}
+	}
+
+	var items []string
+	for i, line := range lines {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimRight(key, " \t") != "tags" {
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+		if value != "" {
+			value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+			items = strings.Split(value, ",")
+			break
+		}
+		for _, item := range lines[i+1:] {
+			item = strings.TrimSpace(item)
+			if !strings.HasPrefix(item, "-") {
+				break
+			}
+			items = append(items, strings.TrimPrefix(item, "-"))
+		}
+		break
+	}
+
+	tags := []string{}
+	seen := make(map[string]bool)
+	for _, item := range items {
+		tag := strings.TrimPrefix(strings.Trim(strings.TrimSpace(item), `"'`), "#")
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// ParseCaptureDetailed parses capture markdown into frontmatter, headings,
+// #tags and [[wikilinks]]. Headings and tags inside fenced code blocks are
+// ignored; a purely numeric "#123" is not treated as a tag.
+func ParseCaptureDetailed(content string) CaptureStructure {
+	frontmatter, bodyStart := parseFrontmatter(content)
+	if frontmatter == nil {
+		frontmatter = map[string]string{}
+	}
+
+	structure := CaptureStructure{
+		Frontmatter: frontmatter,
+		Headings:    []CaptureHeading{},
+		Tags:        []string{},
+		Wikilinks:   []string{},
+		Body:        content[bodyStart:],
+	}
+
+	seenTags := make(map[string]bool)
+	seenLinks := make(map[string]bool)
+	inFence := false
+
+	offset := bodyStart
+	for _, line := range strings.SplitAfter(content[bodyStart:], "\n") {
+		lineOffset := offset
+		offset += len(line)
+		line = strings.TrimRight(line, "\r\n")
+
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		if m := headingPattern.FindStringSubmatch(line); m != nil {
+			structure.Headings = append(structure.Headings, CaptureHeading{
+				Level:  len(m[1]),
+				Text:   m[2],
+				Offset: lineOffset,
+			})
+		}
+
+		for _, m := range tagPattern.FindAllStringSubmatch(line, -1) {
+			tag := m[1]
+			if numericPattern.MatchString(tag) || seenTags[tag] {
+				continue
+			}
+			seenTags[tag] = true
+			structure.Tags = append(structure.Tags, tag)
+		}
+
+		for _, m := range wikilinkPattern.FindAllStringSubmatch(line, -1) {
+			target := strings.TrimSpace(m[1])
+			if target == "" || seenLinks[target] {
+				continue
+			}
+			seenLinks[target] = true
+			structure.Wikilinks = append(structure.Wikilinks, target)
+		}
+	}
+
+	return structure
+}
@@ -0,0 +1,141 @@
+package file
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCaptureDetailed(t *testing.T) {
+	content := strings.Join([]string{
+		"---",
+		"title: Garden walk",
+		"source: phone",
+		"---",
+		"# Garden walk",
+		"",
+		"Talked about #compost and #soil-health with [[Maria]].",
+		"",
+		"## Next steps",
+		"Order seeds, see [[Seed Catalog|catalog]] and [[Maria]] again. #compost",
+		"",
+		"```",
+		"# not a heading #not-a-tag",
+		"```",
+		"",
+		"### Issue #42",
+		"Links to [[Plans#Spring]].",
+		"",
+	}, "\n")
+
+	structure := ParseCaptureDetailed(content)
+
+	wantFrontmatter := map[string]string{"title": "Garden walk", "source": "phone"}
+	if !reflect.DeepEqual(structure.Frontmatter, wantFrontmatter) {
+		t.Errorf("Expected frontmatter %v, got %v", wantFrontmatter, structure.Frontmatter)
+	}
+
+	if !strings.HasPrefix(structure.Body, "# Garden walk\n") {
+		t.Errorf("Expected body to start after frontmatter, got %q", structure.Body[:20])
+	}
+
+	wantHeadings := []struct {
+		level int
+		text  string
+	}{
+		{1, "Garden walk"},
+		{2, "Next steps"},
+		{3, "Issue #42"},
+	}
+	if len(structure.Headings) != len(wantHeadings) {
+		t.Fatalf("Expected %d headings, got %+v", len(wantHeadings), structure.Headings)
+	}
+	for i, want := range wantHeadings {
+		heading := structure.Headings[i]
+		if heading.Level != want.level || heading.Text != want.text {
+			t.Errorf("Heading %d: expected level %d %q, got level %d %q", i, want.level, want.text, heading.Level, heading.Text)
+		}
+		if !strings.HasPrefix(content[heading.Offset:], strings.Repeat("#", want.level)+" "+want.text) {
+			t.Errorf("Heading %d: offset %d does not point at the heading line", i, heading.Offset)
+		}
+	}
+
+	wantTags := []string{"compost", "soil-health"}
+	if !reflect.DeepEqual(structure.Tags, wantTags) {
+		t.Errorf("Expected tags %v, got %v", wantTags, structure.Tags)
+	}
+
+	wantLinks := []string{"Maria", "Seed Catalog", "Plans"}
+	if !reflect.DeepEqual(structure.Wikilinks, wantLinks) {
+		t.Errorf("Expected wikilinks %v, got %v", wantLinks, structure.Wikilinks)
+	}
+}
+
+func TestParseCaptureDetailedWithoutFrontmatter(t *testing.T) {
+	structure := ParseCaptureDetailed("Just a line with #idea\n")
+
+	if len(structure.Frontmatter) != 0 {
+		t.Errorf("Expected no frontmatter, got %v", structure.Frontmatter)
+	}
+	if structure.Body != "Just a line with #idea\n" {
+		t.Errorf("Expected whole content as body, got %q", structure.Body)
+	}
+	if len(structure.Headings) != 0 {
+		t.Errorf("Expected no headings, got %v", structure.Headings)
+	}
+	if !reflect.DeepEqual(structure.Tags, []string{"idea"}) {
+		t.Errorf("Expected [idea], got %v", structure.Tags)
+	}
+}
+
+func TestFrontmatterTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"FlowList", "---\ntags: [garden, \"soil\", '#compost']\n---\nBody", []string{"garden", "soil", "compost"}},
+		{"CommaList", "---\ntitle: Walk\ntags: garden, soil\n---\n", []string{"garden", "soil"}},
+		{"BlockList", "---\ntags:\n  - garden\n  - soil\n  - garden\nsource: phone\n---\n", []string{"garden", "soil"}},
+		{"IgnoresNestedKeys", "---\nmeta:\n  tags: [hidden]\n---\n", []string{}},
+		{"NoFrontmatter", "tags: [garden]\n", []string{}},
+		{"CRLF", "---\r\ntags: [garden, soil]\r\n---\r\nBody\r\n", []string{"garden", "soil"}},
+		{"ClosingAtEOF", "---\ntags: garden\n---", []string{"garden"}},
+		{"EmptyBlock", "---\n---\ntags: [garden]\n", []string{}},
+		{"Unclosed", "---\ntags: [garden]\n", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FrontmatterTags(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSplitFrontmatter(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		lines     []string
+		bodyStart int
+		ok        bool
+	}{
+		{"LF", "---\ntitle: Walk\n---\nBody", []string{"title: Walk"}, 20, true},
+		{"CRLF", "---\r\ntitle: Walk\r\n---\r\nBody", []string{"title: Walk"}, 23, true},
+		{"Empty", "---\n---\nBody", nil, 8, true},
+		{"ClosingAtEOF", "---\ntitle: Walk\n---", []string{"title: Walk"}, 19, true},
+		{"Unclosed", "---\ntitle: Walk\n", nil, 0, false},
+		{"NotAtStart", "\n---\ntitle: Walk\n---\n", nil, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, bodyStart, ok := SplitFrontmatter(tt.content)
+			if !reflect.DeepEqual(lines, tt.lines) || bodyStart != tt.bodyStart || ok != tt.ok {
+				t.Errorf("Expected %q, %d, %v; got %q, %d, %v", tt.lines, tt.bodyStart, tt.ok, lines, bodyStart, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package file
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// maxDerivedTitleLength caps a title taken from a capture's first line, in runes
+const maxDerivedTitleLength = 80
+
+// ResolveTitle derives a display title from capture markdown: the frontmatter
+// title, else the first H1 heading, else the first non-empty line (truncated).
+// It returns "" for a capture with no text, leaving the caller to fall back to
+// the filename.
+func ResolveTitle(content string) string {
+	structure := ParseCaptureDetailed(content)
+
+	if title := strings.Trim(structure.Frontmatter["title"], `"'`); strings.TrimSpace(title) != "" {
+		return strings.TrimSpace(title)
+	}
+
+	for _, heading := range structure.Headings {
+		if heading.Level == 1 && strings.TrimSpace(heading.Text) != "" {
+			return strings.TrimSpace(heading.Text)
+		}
+	}
+
+	inFence := false
+	for _, line := range strings.Split(structure.Body, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		// A lower-level heading still makes a reasonable title without its #s
+		line = strings.TrimSpace(strings.TrimLeft(line, "#"))
+		if line != "" {
+			return truncateTitle(line)
+		}
+	}
+
+	return ""
+}
+
+// truncateTitle shortens a line to maxDerivedTitleLength runes
+func truncateTitle(line string) string {
+	if utf8.RuneCountInString(line) <= maxDerivedTitleLength {
+		return line
+	}
+	return strings.TrimSpace(string([]rune(line)[:maxDerivedTitleLength])) + "…"
+}
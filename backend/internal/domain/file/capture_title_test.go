@@ -0,0 +1,60 @@
+package file
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveTitle(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "Frontmatter",
+			content: "---\ntitle: \"Garden walk\"\n---\n# Heading\n\nBody",
+			want:    "Garden walk",
+		},
+		{
+			name:    "FirstH1",
+			content: "---\nsource: phone\n---\n## Notes\n# Garden walk\n\nBody",
+			want:    "Garden walk",
+		},
+		{
+			name:    "FirstLine",
+			content: "\n\nTalked about compost today.\nSecond line",
+			want:    "Talked about compost today.",
+		},
+		{
+			name:    "SkipsCodeBlocks",
+			content: "```\n# not a title\n```\nAfter the code",
+			want:    "After the code",
+		},
+		{
+			name:    "LowerHeadingAsLine",
+			content: "### Next steps\n",
+			want:    "Next steps",
+		},
+		{
+			name:    "Empty",
+			content: "---\ntitle:\n---\n\n",
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResolveTitle(tt.content); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("TruncatesLongLine", func(t *testing.T) {
+		got := ResolveTitle(strings.Repeat("a", 200))
+		if !strings.HasSuffix(got, "…") || len([]rune(got)) != maxDerivedTitleLength+1 {
+			t.Errorf("Expected a truncated title, got %q", got)
+		}
+	})
+}
@@ -592,30 +592,9 @@ func (s *Service) extractTitleFromMarkdown(audioFilename string) string {
 		return ""
 	}
 
-	// Parse frontmatter (between --- markers)
-	text := string(content)
-	if !strings.HasPrefix(text, "---\n") {
-		return ""
-	}
-
-	// Find the end of frontmatter
-	endIndex := strings.Index(text[4:], "\n---\n")
-	if endIndex == -1 {
-		return ""
-	}
-
-	frontmatter := text[4 : endIndex+4]
-
-	// Look for title: line
-	lines := strings.Split(frontmatter, "\n")
-	for _, line := range lines {
-		if strings.HasPrefix(line, "title:") {
-			title := strings.TrimSpace(strings.TrimPrefix(line, "title:"))
-			return title
-		}
-	}
-
-	return ""
+	// Parse frontmatter (between --- markers) and look for the title
+	frontmatter, _ := parseFrontmatter(string(content))
+	return frontmatter["title"]
 }
 
 // extractTranscriptFromMarkdown extracts the transcript content from markdown file
@@ -0,0 +1,26 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+// Record is the stored response for a request that was processed under an idempotency key
+type Record struct {
+	Key         string    `json:"key"`
+	Method      string    `json:"method"`
+	Path        string    `json:"path"`
+	StatusCode  int       `json:"status_code"`
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"body"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Store defines the interface for persisting processed idempotency keys
+type Store interface {
+	// Get returns the record for key/method/path created at or after since,
+	// or nil if there is none
+	Get(ctx context.Context, key, method, path string, since time.Time) (*Record, error)
+	Save(ctx context.Context, record *Record) error
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
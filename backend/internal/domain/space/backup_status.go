@@ -0,0 +1,140 @@
+package space
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// DefaultStaleBackupDays is the window ListStaleBackups uses when none is given
+const DefaultStaleBackupDays = 30
+
+// lastExportedAtKey is the space_metadata key holding when the space was last
+// exported, as Unix seconds
+const lastExportedAtKey = "last_exported_at"
+
+// BackupStatus reports when a space was last exported
+type BackupStatus struct {
+	LastExportedAt  *time.Time `json:"last_exported_at"`  // Nil if the space was never exported
+	DaysSinceBackup *int       `json:"days_since_backup"` // Whole days since the last export; nil if never
+}
+
+// StaleBackup is a space not exported within ListStaleBackups' window
+type StaleBackup struct {
+	Space *Space `json:"space"`
+	BackupStatus
+}
+
+// RecordExport notes that the space was exported at the given time, creating
+// space.sqlite if needed. Exports of read-only spaces are recorded too.
+func (s *SpaceDatabaseService) RecordExport(spaceID, spacePath string, at time.Time) error {
+	if err := s.InitializeSpaceDatabase(spaceID, spacePath); err != nil {
+		return err
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`
+		INSERT INTO space_metadata (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`, lastExportedAtKey, strconv.FormatInt(at.Unix(), 10))
+	if err != nil {
+		return fmt.Errorf("failed to record export: %w", err)
+	}
+	return nil
+}
+
+// GetBackupStatus returns when the space was last exported, with the days
+// since counted up to now. A space without a database was never exported.
+func (s *SpaceDatabaseService) GetBackupStatus(spacePath string, now time.Time) (BackupStatus, error) {
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return BackupStatus{}, nil
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return BackupStatus{}, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	var value string
+	err = db.QueryRow("SELECT value FROM space_metadata WHERE key = ?", lastExportedAtKey).Scan(&value)
+	if err == sql.ErrNoRows {
+		return BackupStatus{}, nil
+	}
+	if err != nil {
+		return BackupStatus{}, fmt.Errorf("failed to read last export: %w", err)
+	}
+	return parseBackupStatus(value, now), nil
+}
+
+// parseBackupStatus builds a BackupStatus from a stored last_exported_at
+// value. An unparseable value counts as never exported.
+func parseBackupStatus(value string, now time.Time) BackupStatus {
+	unix, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return BackupStatus{}
+	}
+
+	exportedAt := time.Unix(unix, 0)
+	days := int(now.Sub(exportedAt).Hours() / 24)
+	if days < 0 {
+		days = 0
+	}
+	return BackupStatus{LastExportedAt: &exportedAt, DaysSinceBackup: &days}
+}
+
+// ListStaleBackups returns the user's spaces not exported in the last days
+// days: never exported first, then by oldest export. A space whose database
+// cannot be read is listed as never exported.
+func (s *Service) ListStaleBackups(ctx context.Context, userID string, days int, now time.Time) ([]StaleBackup, error) {
+	if days <= 0 {
+		return nil, domain.NewValidationError("days", "must be a positive integer")
+	}
+	if s.dbService == nil {
+		return nil, fmt.Errorf("space database service not configured")
+	}
+
+	spaces, err := s.repo.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	window := time.Duration(days) * 24 * time.Hour
+	stale := []StaleBackup{}
+	for _, sp := range spaces {
+		status, err := s.dbService.GetBackupStatus(sp.Path, now)
+		if err != nil {
+			status = BackupStatus{}
+		}
+		if status.LastExportedAt != nil && now.Sub(*status.LastExportedAt) < window {
+			continue
+		}
+		stale = append(stale, StaleBackup{Space: sp, BackupStatus: status})
+	}
+
+	sort.SliceStable(stale, func(i, j int) bool {
+		a, b := stale[i].LastExportedAt, stale[j].LastExportedAt
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		return a.Before(*b)
+	})
+
+	return stale, nil
+}
@@ -0,0 +1,109 @@
+package space_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+	sqliteStorage "github.com/unforced/parachute-backend/internal/storage/sqlite"
+)
+
+func TestBackupStatus(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	db, err := sqliteStorage.NewDatabase(filepath.Join(parachuteRoot, "parachute.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	service := space.NewService(sqliteStorage.NewSpaceRepository(db.DB), parachuteRoot)
+	service.SetDatabaseService(dbService)
+
+	spaces := make(map[string]*space.Space)
+	for _, name := range []string{"Garden", "Kitchen", "Attic"} {
+		sp, err := service.Create(ctx, "default", space.CreateSpaceParams{Name: name})
+		if err != nil {
+			t.Fatalf("Failed to create space %s: %v", name, err)
+		}
+		spaces[name] = sp
+	}
+
+	now := time.Now()
+	if err := dbService.RecordExport(spaces["Garden"].ID, spaces["Garden"].Path, now.AddDate(0, 0, -2)); err != nil {
+		t.Fatalf("Failed to record export: %v", err)
+	}
+	if err := dbService.RecordExport(spaces["Kitchen"].ID, spaces["Kitchen"].Path, now.AddDate(0, 0, -45)); err != nil {
+		t.Fatalf("Failed to record export: %v", err)
+	}
+
+	t.Run("Status", func(t *testing.T) {
+		status, err := dbService.GetBackupStatus(spaces["Kitchen"].Path, now)
+		if err != nil {
+			t.Fatalf("Failed to get backup status: %v", err)
+		}
+		if status.LastExportedAt == nil || status.DaysSinceBackup == nil || *status.DaysSinceBackup != 45 {
+			t.Errorf("Expected an export 45 days ago, got %+v", status)
+		}
+
+		status, err = dbService.GetBackupStatus(spaces["Attic"].Path, now)
+		if err != nil {
+			t.Fatalf("Failed to get backup status: %v", err)
+		}
+		if status.LastExportedAt != nil || status.DaysSinceBackup != nil {
+			t.Errorf("Expected a space never exported, got %+v", status)
+		}
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		stats, err := dbService.GetDatabaseStats(spaces["Garden"].Path)
+		if err != nil {
+			t.Fatalf("Failed to get stats: %v", err)
+		}
+		if stats.DaysSinceBackup == nil || *stats.DaysSinceBackup != 2 {
+			t.Errorf("Expected 2 days since backup in stats, got %+v", stats.BackupStatus)
+		}
+	})
+
+	t.Run("StaleBackups", func(t *testing.T) {
+		stale, err := service.ListStaleBackups(ctx, "default", 30, now)
+		if err != nil {
+			t.Fatalf("Failed to list stale backups: %v", err)
+		}
+		if len(stale) != 2 || stale[0].Space.Name != "Attic" || stale[1].Space.Name != "Kitchen" {
+			t.Fatalf("Expected Attic (never) then Kitchen (45 days), got %+v", stale)
+		}
+
+		stale, _ = service.ListStaleBackups(ctx, "default", 1, now)
+		if len(stale) != 3 {
+			t.Errorf("Expected every space with a 1 day window, got %d", len(stale))
+		}
+
+		var validationErr *domain.ValidationError
+		if _, err := service.ListStaleBackups(ctx, "default", 0, now); !errors.As(err, &validationErr) {
+			t.Errorf("Expected a validation error for 0 days, got %v", err)
+		}
+	})
+
+	t.Run("ExportRecords", func(t *testing.T) {
+		if err := service.ExportAllUserData(ctx, "default", io.Discard); err != nil {
+			t.Fatalf("Failed to export: %v", err)
+		}
+
+		stale, err := service.ListStaleBackups(ctx, "default", 1, time.Now())
+		if err != nil {
+			t.Fatalf("Failed to list stale backups: %v", err)
+		}
+		if len(stale) != 0 {
+			t.Errorf("Expected no stale spaces after exporting everything, got %+v", stale)
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package space
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// BulkResult is the outcome of a bulk operation: the notes it changed, or
+// with DryRun the notes it would change (nothing is written)
+type BulkResult struct {
+	CaptureIDs []string `json:"capture_ids"`
+	Count      int      `json:"count"`
+	DryRun     bool     `json:"dry_run"`
+}
+
+// newBulkResult builds a BulkResult for the given capture IDs
+func newBulkResult(captureIDs []string, dryRun bool) BulkResult {
+	if captureIDs == nil {
+		captureIDs = []string{}
+	}
+	return BulkResult{CaptureIDs: captureIDs, Count: len(captureIDs), DryRun: dryRun}
+}
+
+// BulkAddTags adds tags to every note matching filters and reports the notes
+// that changed. Tags a note already has are not duplicated.
+func (s *SpaceDatabaseService) BulkAddTags(spacePath string, filters NoteFilters, tags []string, dryRun bool) (BulkResult, error) {
+	return s.BulkUpdateTags(spacePath, filters, tags, nil, dryRun)
+}
+
+// BulkRemoveTags removes tags from every note matching filters and reports
+// the notes that changed
+func (s *SpaceDatabaseService) BulkRemoveTags(spacePath string, filters NoteFilters, tags []string, dryRun bool) (BulkResult, error) {
+	return s.BulkUpdateTags(spacePath, filters, nil, tags, dryRun)
+}
+
+// BulkUpdateTags adds and removes tags on every note matching filters (the
+// same filters GetRelevantNotes takes, so a listing previews exactly the notes
+// affected). A tag in both lists ends up removed. All changes are applied in
+// one transaction; the result lists the notes whose tags changed. With dryRun
+// the notes that would change are worked out the same way but nothing is
+// written, and the space's write limits don't apply.
+func (s *SpaceDatabaseService) BulkUpdateTags(spacePath string, filters NoteFilters, add, remove []string, dryRun bool) (BulkResult, error) {
+	if !dryRun {
+		if err := s.checkWritable(spacePath); err != nil {
+			return BulkResult{}, err
+		}
+	}
+
+	add = mergeTags(add)
+	remove = mergeTags(remove)
+	if len(add) == 0 && len(remove) == 0 {
+		return BulkResult{}, domain.NewValidationError("tags", "nothing to add or remove")
+	}
+	if err := validateTags(add); err != nil {
+		return BulkResult{}, err
+	}
+
+	notes, err := s.GetRelevantNotes(spacePath, filters)
+	if err != nil {
+		return BulkResult{}, err
+	}
+	if len(notes) == 0 {
+		return newBulkResult(nil, dryRun), nil
+	}
+
+	removeSet := make(map[string]bool, len(remove))
+	for _, tag := range remove {
+		removeSet[tag] = true
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return BulkResult{}, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return BulkResult{}, fmt.Errorf("failed to begin bulk tag update: %w", err)
+	}
+	defer tx.Rollback()
+
+	var changed []string
+	for _, note := range notes {
+		// Re-read inside the transaction so concurrent edits aren't overwritten
+		var tagsJSON sql.NullString
+		err := tx.QueryRow("SELECT tags FROM relevant_notes WHERE capture_id = ?", note.CaptureID).Scan(&tagsJSON)
+		if err == sql.ErrNoRows {
+			continue // Unlinked since the query above
+		}
+		if err != nil {
+			return BulkResult{}, fmt.Errorf("failed to read tags: %w", err)
+		}
+
+		var current []string
+		if tagsJSON.Valid && tagsJSON.String != "" {
+			json.Unmarshal([]byte(tagsJSON.String), &current)
+		}
+
+		updated := []string{}
+		for _, tag := range mergeTags(current, add) {
+			if !removeSet[tag] {
+				updated = append(updated, tag)
+			}
+		}
+		if equalTags(current, updated) {
+			continue
+		}
+		if dryRun {
+			changed = append(changed, note.CaptureID)
+			continue
+		}
+
+		updatedJSON, err := json.Marshal(updated)
+		if err != nil {
+			return BulkResult{}, fmt.Errorf("failed to marshal tags: %w", err)
+		}
+		if _, err := tx.Exec("UPDATE relevant_notes SET tags = ? WHERE capture_id = ?", string(updatedJSON), note.CaptureID); err != nil {
+			return BulkResult{}, fmt.Errorf("failed to update tags: %w", err)
+		}
+		changed = append(changed, note.CaptureID)
+	}
+
+	if dryRun {
+		return newBulkResult(changed, true), nil
+	}
+
+	if len(changed) > 0 {
+		if err := bumpContextVersion(tx); err != nil {
+			return BulkResult{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return BulkResult{}, fmt.Errorf("failed to commit bulk tag update: %w", err)
+	}
+
+	s.syncFrontmatter(spacePath, changed...)
+	if len(changed) > 0 {
+		s.syncManifest(spacePath)
+	}
+	return newBulkResult(changed, false), nil
+}
+
+// equalTags reports whether two tag lists are identical, including order
+func equalTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
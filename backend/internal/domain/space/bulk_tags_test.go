@@ -0,0 +1,118 @@
+package space_test
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestBulkTags(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	// Notes linked 1, 3, 5 and 10 days ago
+	notes := []struct {
+		file string
+		tags []string
+		age  int
+	}{
+		{"a.md", []string{"garden"}, 1},
+		{"b.md", []string{"garden", "reviewed"}, 3},
+		{"c.md", []string{}, 5},
+		{"d.md", []string{"garden"}, 10},
+	}
+	ids := make(map[string]string)
+
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to open space database: %v", err)
+	}
+	for _, n := range notes {
+		captureID, notePath := createNamedCapture(t, parachuteRoot, n.file, n.file)
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "", n.tags); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		linkedAt := time.Now().AddDate(0, 0, -n.age).Unix()
+		if _, err := db.Exec("UPDATE relevant_notes SET linked_at = ? WHERE capture_id = ?", linkedAt, captureID); err != nil {
+			t.Fatalf("Failed to set linked_at: %v", err)
+		}
+		ids[n.file] = captureID
+	}
+	db.Close()
+
+	tagsOf := func(file string) []string {
+		note, err := service.GetNoteByID(spacePath, ids[file])
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		return note.Tags
+	}
+
+	t.Run("AddWithinDateRange", func(t *testing.T) {
+		start := time.Now().AddDate(0, 0, -6)
+		end := time.Now().AddDate(0, 0, -2)
+
+		result, err := service.BulkAddTags(spacePath, space.NoteFilters{StartDate: &start, EndDate: &end}, []string{"reviewed"}, false)
+		if err != nil {
+			t.Fatalf("Failed to add tags: %v", err)
+		}
+		// b.md already had the tag, so only c.md changed
+		if result.Count != 1 || result.CaptureIDs[0] != ids["c.md"] {
+			t.Errorf("Expected only c.md changed, got %+v", result)
+		}
+
+		want := map[string][]string{
+			"a.md": {"garden"},
+			"b.md": {"garden", "reviewed"},
+			"c.md": {"reviewed"},
+			"d.md": {"garden"},
+		}
+		for file, tags := range want {
+			if got := tagsOf(file); !reflect.DeepEqual(got, tags) {
+				t.Errorf("%s: expected tags %v, got %v", file, tags, got)
+			}
+		}
+	})
+
+	t.Run("DryRunChangesNothing", func(t *testing.T) {
+		result, err := service.BulkRemoveTags(spacePath, space.NoteFilters{}, []string{"garden"}, true)
+		if err != nil {
+			t.Fatalf("Failed to preview tag removal: %v", err)
+		}
+		if !result.DryRun || result.Count != 3 {
+			t.Errorf("Expected the 3 garden notes as a dry run, got %+v", result)
+		}
+		if got := tagsOf("a.md"); !reflect.DeepEqual(got, []string{"garden"}) {
+			t.Errorf("Expected tags unchanged by a dry run, got %v", got)
+		}
+	})
+
+	t.Run("RemoveByTag", func(t *testing.T) {
+		result, err := service.BulkRemoveTags(spacePath, space.NoteFilters{Tags: []string{"garden"}}, []string{"garden"}, false)
+		if err != nil {
+			t.Fatalf("Failed to remove tags: %v", err)
+		}
+		if result.Count != 3 || result.DryRun {
+			t.Errorf("Expected 3 notes changed, got %+v", result)
+		}
+		if got := tagsOf("b.md"); !reflect.DeepEqual(got, []string{"reviewed"}) {
+			t.Errorf("Expected only reviewed to remain, got %v", got)
+		}
+	})
+
+	t.Run("NothingToDo", func(t *testing.T) {
+		var validationErr *domain.ValidationError
+		if _, err := service.BulkUpdateTags(spacePath, space.NoteFilters{}, nil, []string{""}, false); !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error, got %v", err)
+		}
+	})
+}
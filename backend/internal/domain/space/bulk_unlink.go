@@ -0,0 +1,142 @@
+package space
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// BulkUnlink unlinks every note matching filters (the same filters
+// GetRelevantNotes takes) in one transaction and reports the notes removed.
+// Each leaves a tombstone as UnlinkNote does. Filters that select nothing
+// in particular are rejected unless all is set, so a missing filter can't
+// empty the space by accident. With dryRun nothing is removed and the result
+// lists the notes that would be, so a client can confirm before running it
+// for real; the space's write limits don't apply.
+func (s *SpaceDatabaseService) BulkUnlink(spacePath string, filters NoteFilters, all, dryRun bool) (BulkResult, error) {
+	if !all && !filters.selective() {
+		return BulkResult{}, domain.NewValidationError("filters", "at least one filter is required; set all to unlink every note")
+	}
+
+	if dryRun {
+		notes, err := s.GetRelevantNotes(spacePath, filters)
+		if err != nil {
+			return BulkResult{}, err
+		}
+		return newBulkResult(captureIDsOf(notes), true), nil
+	}
+
+	if err := s.checkWritable(spacePath); err != nil {
+		return BulkResult{}, err
+	}
+
+	var result BulkResult
+	err := s.withBusyRetry(func() error {
+		var err error
+		result, err = s.bulkUnlink(spacePath, filters)
+		return err
+	})
+	return result, err
+}
+
+// bulkUnlink implements BulkUnlink without retries
+func (s *SpaceDatabaseService) bulkUnlink(spacePath string, filters NoteFilters) (BulkResult, error) {
+	notes, err := s.GetRelevantNotes(spacePath, filters)
+	if err != nil {
+		return BulkResult{}, err
+	}
+	if len(notes) == 0 {
+		return newBulkResult(nil, false), nil
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return BulkResult{}, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return BulkResult{}, fmt.Errorf("failed to begin bulk unlink: %w", err)
+	}
+	defer tx.Rollback()
+
+	removed, err := unlinkInTx(tx, captureIDsOf(notes))
+	if err != nil {
+		return BulkResult{}, err
+	}
+
+	if len(removed) > 0 {
+		if err := bumpContextVersion(tx); err != nil {
+			return BulkResult{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return BulkResult{}, fmt.Errorf("failed to commit bulk unlink: %w", err)
+	}
+
+	if len(removed) > 0 {
+		s.syncManifest(spacePath)
+	}
+	return newBulkResult(removed, false), nil
+}
+
+// selective reports whether f narrows which notes match. Sorting, paging and
+// IncludeExpired don't count, as on their own they still match every note.
+func (f NoteFilters) selective() bool {
+	return len(f.Tags) > 0 || f.Status != "" || f.StartDate != nil || f.EndDate != nil ||
+		f.DueBefore != nil || f.BatchID != "" || f.HasTags != nil || f.HasSource != nil ||
+		f.HasMetadataKey != "" || f.MissingMetadataKey != "" || f.ContextContains != "" ||
+		f.ExistsOnDisk != nil
+}
+
+// unlinkInTx unlinks the given notes, leaving a tombstone for each as
+// UnlinkNote does, and returns the capture IDs that were actually removed
+func unlinkInTx(tx *sql.Tx, captureIDs []string) ([]string, error) {
+	now := time.Now().Unix()
+	var removed []string
+	for _, captureID := range captureIDs {
+		_, err := tx.Exec(`
+			INSERT INTO deleted_notes (capture_id, note_path, deleted_at)
+			SELECT capture_id, note_path, ? FROM relevant_notes WHERE capture_id = ?
+			ON CONFLICT(capture_id) DO UPDATE SET
+				note_path = excluded.note_path,
+				deleted_at = excluded.deleted_at
+		`, now, captureID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record unlinked note: %w", err)
+		}
+
+		result, err := tx.Exec("DELETE FROM relevant_notes WHERE capture_id = ?", captureID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unlink note: %w", err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			continue // Unlinked since it was selected
+		}
+
+		if _, err := tx.Exec("DELETE FROM featured_notes WHERE capture_id = ?", captureID); err != nil {
+			return nil, fmt.Errorf("failed to unfeature note: %w", err)
+		}
+		if _, err := tx.Exec("DELETE FROM collection_items WHERE capture_id = ?", captureID); err != nil {
+			return nil, fmt.Errorf("failed to remove note from collections: %w", err)
+		}
+		removed = append(removed, captureID)
+	}
+	return removed, nil
+}
+
+// captureIDsOf returns the capture IDs of notes, in order
+func captureIDsOf(notes []RelevantNote) []string {
+	ids := make([]string, len(notes))
+	for i, note := range notes {
+		ids[i] = note.CaptureID
+	}
+	return ids
+}
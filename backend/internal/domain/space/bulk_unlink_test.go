@@ -0,0 +1,105 @@
+package space_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestBulkUnlink(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	links := map[string][]string{
+		"old-draft": {"draft"},
+		"new-draft": {"draft", "garden"},
+		"keeper":    {"garden"},
+	}
+	for captureID, tags := range links {
+		if err := service.LinkNote(spaceID, spacePath, captureID, "captures/"+captureID+".md", "", tags); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+	if err := service.SetFeaturedNotes(spacePath, []string{"old-draft", "keeper"}); err != nil {
+		t.Fatalf("Failed to feature notes: %v", err)
+	}
+
+	filters := space.NoteFilters{Tags: []string{"draft"}}
+
+	preview, err := service.BulkUnlink(spacePath, filters, false, true)
+	if err != nil {
+		t.Fatalf("Failed to preview unlink: %v", err)
+	}
+
+	t.Run("DryRunKeepsNotes", func(t *testing.T) {
+		if !preview.DryRun || preview.Count != 2 {
+			t.Errorf("Expected a dry run matching 2 notes, got %+v", preview)
+		}
+		notes, _ := service.GetRelevantNotes(spacePath, space.NoteFilters{})
+		if len(notes) != 3 {
+			t.Errorf("Expected all notes still linked, got %d", len(notes))
+		}
+	})
+
+	t.Run("RealRunRemovesPreviewedSet", func(t *testing.T) {
+		result, err := service.BulkUnlink(spacePath, filters, false, false)
+		if err != nil {
+			t.Fatalf("Failed to unlink: %v", err)
+		}
+		if result.DryRun || !reflect.DeepEqual(result.CaptureIDs, preview.CaptureIDs) {
+			t.Errorf("Expected the previewed notes %v, got %+v", preview.CaptureIDs, result)
+		}
+
+		notes, _ := service.GetRelevantNotes(spacePath, space.NoteFilters{})
+		if len(notes) != 1 || notes[0].CaptureID != "keeper" {
+			t.Errorf("Expected only keeper left, got %+v", notes)
+		}
+
+		// Unlinked notes leave tombstones and drop out of the featured list
+		var goneErr *domain.GoneError
+		if _, err := service.GetNoteByID(spacePath, "old-draft"); !errors.As(err, &goneErr) {
+			t.Errorf("Expected old-draft to be gone, got %v", err)
+		}
+		featured, _ := service.GetFeaturedNotes(spacePath)
+		if len(featured) != 1 || featured[0].CaptureID != "keeper" {
+			t.Errorf("Expected only keeper featured, got %+v", featured)
+		}
+	})
+
+	t.Run("RequiresFilterOrAll", func(t *testing.T) {
+		var validationErr *domain.ValidationError
+		for _, dryRun := range []bool{true, false} {
+			if _, err := service.BulkUnlink(spacePath, space.NoteFilters{Limit: 10}, false, dryRun); !errors.As(err, &validationErr) {
+				t.Errorf("Expected unfiltered unlink (dry run %v) to be rejected, got %v", dryRun, err)
+			}
+		}
+		notes, _ := service.GetRelevantNotes(spacePath, space.NoteFilters{})
+		if len(notes) != 1 {
+			t.Errorf("Expected keeper to stay linked, got %d notes", len(notes))
+		}
+
+		result, err := service.BulkUnlink(spacePath, space.NoteFilters{}, true, true)
+		if err != nil {
+			t.Fatalf("Failed to preview unlinking all: %v", err)
+		}
+		if result.Count != 1 {
+			t.Errorf("Expected all to match every note, got %+v", result)
+		}
+	})
+
+	t.Run("NothingMatches", func(t *testing.T) {
+		result, err := service.BulkUnlink(spacePath, filters, false, false)
+		if err != nil {
+			t.Fatalf("Failed to unlink: %v", err)
+		}
+		if result.Count != 0 || result.CaptureIDs == nil {
+			t.Errorf("Expected an empty result, got %+v", result)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package space
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// ErrDatabaseBusy is returned (wrapping the last SQLite error) when a write
+// still finds the space database busy or locked after every retry
+var ErrDatabaseBusy = errors.New("space database is busy")
+
+// RetryPolicy controls how writes retry when the space database is busy. The
+// delay doubles after each attempt, up to MaxDelay (if set).
+type RetryPolicy struct {
+	MaxAttempts  int // Total attempts, including the first; values below 1 mean 1
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+}
+
+// DefaultRetryPolicy waits about 1.25 seconds in total before giving up
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:  6,
+	InitialDelay: 50 * time.Millisecond,
+	MaxDelay:     500 * time.Millisecond,
+}
+
+// SetRetryPolicy replaces the retry policy used by LinkNote, UpdateNoteContext,
+// UnlinkNote and TrackNoteReference (the default is DefaultRetryPolicy)
+func (s *SpaceDatabaseService) SetRetryPolicy(policy RetryPolicy) {
+	s.retry = policy
+}
+
+// withBusyRetry runs op, running it again with exponential backoff while it
+// fails because the database is busy or locked. Other errors return at once.
+func (s *SpaceDatabaseService) withBusyRetry(op func() error) error {
+	attempts := max(s.retry.MaxAttempts, 1)
+	delay := s.retry.InitialDelay
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = op()
+		if err == nil || !isBusyError(err) {
+			return err
+		}
+		if attempt >= attempts {
+			return fmt.Errorf("%w after %d attempts: %w", ErrDatabaseBusy, attempts, err)
+		}
+
+		time.Sleep(delay)
+		delay *= 2
+		if s.retry.MaxDelay > 0 {
+			delay = min(delay, s.retry.MaxDelay)
+		}
+	}
+}
+
+// isBusyError reports whether err comes from SQLite finding the database busy
+// or a table locked
+func isBusyError(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	code := sqliteErr.Code() & 0xff // Primary result code, without extended bits
+	return code == sqlite3.SQLITE_BUSY || code == sqlite3.SQLITE_LOCKED
+}
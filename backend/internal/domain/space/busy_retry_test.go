@@ -0,0 +1,90 @@
+package space_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+// lockDatabase holds an exclusive lock on a space database until the returned
+// function is called
+func lockDatabase(t *testing.T, spacePath string) func() {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to open space database: %v", err)
+	}
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get connection: %v", err)
+	}
+	if _, err := conn.ExecContext(context.Background(), "BEGIN EXCLUSIVE"); err != nil {
+		t.Fatalf("Failed to lock database: %v", err)
+	}
+
+	return func() {
+		conn.ExecContext(context.Background(), "COMMIT")
+		conn.Close()
+		db.Close()
+	}
+}
+
+func TestBusyRetry(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	t.Run("SucceedsOnceLockReleases", func(t *testing.T) {
+		service.SetRetryPolicy(space.RetryPolicy{MaxAttempts: 20, InitialDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond})
+		captureID, notePath := createNamedCapture(t, parachuteRoot, "retry.md", "Note")
+
+		unlock := lockDatabase(t, spacePath)
+		time.AfterFunc(100*time.Millisecond, unlock)
+
+		start := time.Now()
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "Retried", nil); err != nil {
+			t.Fatalf("Expected link to succeed after the lock was released: %v", err)
+		}
+		if time.Since(start) < 100*time.Millisecond {
+			t.Error("Expected link to wait for the lock")
+		}
+		if note, err := service.GetNoteByID(spacePath, captureID); err != nil || note.Context != "Retried" {
+			t.Errorf("Expected linked note, got %+v, %v", note, err)
+		}
+	})
+
+	t.Run("FailsAfterMaxAttempts", func(t *testing.T) {
+		service.SetRetryPolicy(space.RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond})
+		captureID, notePath := createNamedCapture(t, parachuteRoot, "busy.md", "Note")
+
+		unlock := lockDatabase(t, spacePath)
+		defer unlock()
+
+		err := service.LinkNote(spaceID, spacePath, captureID, notePath, "", nil)
+		if !errors.Is(err, space.ErrDatabaseBusy) {
+			t.Errorf("Expected ErrDatabaseBusy, got %v", err)
+		}
+	})
+
+	t.Run("OtherErrorsNotRetried", func(t *testing.T) {
+		service.SetRetryPolicy(space.RetryPolicy{MaxAttempts: 3, InitialDelay: time.Second})
+
+		start := time.Now()
+		context := "Missing"
+		err := service.UpdateNoteContext(spacePath, "not-linked", &context, nil)
+		if err == nil || errors.Is(err, space.ErrDatabaseBusy) {
+			t.Errorf("Expected a not-found error, got %v", err)
+		}
+		if time.Since(start) > 500*time.Millisecond {
+			t.Error("Expected no retry delay for a non-busy error")
+		}
+	})
+}
@@ -0,0 +1,256 @@
+package space
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// CreateCaptureParams describes a markdown capture to create and link
+type CreateCaptureParams struct {
+	Content string
+	Context string
+	Tags    []string
+	// ReuseIdentical links an existing capture with exactly the same content
+	// instead of writing a new file, so a retried create does not duplicate it
+	ReuseIdentical bool
+}
+
+// CaptureCreation reports the capture CreateAndLinkCapture linked
+type CaptureCreation struct {
+	CaptureID string `json:"capture_id"`
+	NotePath  string `json:"note_path"`
+	Created   bool   `json:"created"` // False when an identical existing capture was reused
+}
+
+// captureHashEntry is the content hash of one capture as of its mod time and size
+type captureHashEntry struct {
+	modTime time.Time
+	size    int64
+	hash    string
+}
+
+// captureHashIndex holds the content hash of each markdown capture, keyed by
+// vault-relative path. Files are only rehashed when they change on disk.
+type captureHashIndex struct {
+	mu      sync.Mutex
+	entries map[string]captureHashEntry
+}
+
+// CreateAndLinkCapture writes content to a new markdown file in the space's
+// captures directory (see CapturesDir), named after the current time, and
+// links it to the space with the given context and tags. With ReuseIdentical
+// set, a capture in that directory whose content is byte-for-byte identical
+// is linked instead (or left as is if the space already links it) and
+// Created is false.
+func (s *SpaceDatabaseService) CreateAndLinkCapture(spaceID, spacePath string, params CreateCaptureParams) (CaptureCreation, error) {
+	if strings.TrimSpace(params.Content) == "" {
+		return CaptureCreation{}, domain.NewValidationError("content", "is required")
+	}
+	if err := s.checkWritable(spacePath); err != nil {
+		return CaptureCreation{}, err
+	}
+
+	capturesDir, err := s.CapturesDir(spacePath)
+	if err != nil {
+		capturesDir = DefaultCapturesDir
+	}
+
+	result, err := s.findOrWriteCapture(capturesDir, []byte(params.Content), params.ReuseIdentical)
+	if err != nil {
+		return CaptureCreation{}, err
+	}
+	if !result.Created {
+		if _, err := s.GetNoteByID(spacePath, result.CaptureID); err == nil {
+			return result, nil
+		}
+	}
+
+	// The write token was taken above, so link without taking another
+	err = s.withBusyRetry(func() error {
+		return s.linkNote(spaceID, spacePath, result.CaptureID, result.NotePath, params.Context, params.Tags, "", LinkOptions{})
+	})
+	if err != nil {
+		return CaptureCreation{}, err
+	}
+	return result, nil
+}
+
+// findOrWriteCapture returns a capture in capturesDir with exactly content
+// when reuse is set and one exists, or else writes a new one. Only lookups
+// for reuse hold the hash index lock, and only until the file is found or
+// written, so that two identical creates can't both write.
+func (s *SpaceDatabaseService) findOrWriteCapture(capturesDir string, content []byte, reuse bool) (CaptureCreation, error) {
+	if !reuse {
+		notePath, err := s.writeNewCapture(capturesDir, content)
+		if err != nil {
+			return CaptureCreation{}, err
+		}
+		return CaptureCreation{CaptureID: captureIDFromPath(notePath), NotePath: notePath, Created: true}, nil
+	}
+
+	s.captureHashes.mu.Lock()
+	defer s.captureHashes.mu.Unlock()
+
+	hash := contentHash(content)
+	notePath, err := s.findCaptureByHash(capturesDir, hash)
+	if err != nil {
+		return CaptureCreation{}, err
+	}
+	if notePath != "" {
+		return CaptureCreation{CaptureID: captureIDFromPath(notePath), NotePath: notePath}, nil
+	}
+
+	notePath, err = s.writeNewCapture(capturesDir, content)
+	if err != nil {
+		return CaptureCreation{}, err
+	}
+	if info, err := os.Stat(filepath.Join(s.parachuteRoot, filepath.FromSlash(notePath))); err == nil {
+		s.captureHashes.entries[notePath] = captureHashEntry{modTime: info.ModTime(), size: info.Size(), hash: hash}
+	}
+	return CaptureCreation{CaptureID: captureIDFromPath(notePath), NotePath: notePath, Created: true}, nil
+}
+
+// writeNewCapture creates <capturesDir>/<timestamp>.md, adding a -2, -3, ...
+// suffix if a capture was already written in the same second. capturesDir
+// is vault-relative.
+func (s *SpaceDatabaseService) writeNewCapture(capturesDir string, content []byte) (string, error) {
+	dir := filepath.Join(s.parachuteRoot, filepath.FromSlash(capturesDir))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create captures directory: %w", err)
+	}
+
+	base := time.Now().Format("2006-01-02_15-04-05")
+	for i := 1; ; i++ {
+		name := base + ".md"
+		if i > 1 {
+			name = fmt.Sprintf("%s-%d.md", base, i)
+		}
+
+		f, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to create capture: %w", err)
+		}
+		if _, err := f.Write(content); err != nil {
+			f.Close()
+			return "", fmt.Errorf("failed to write capture: %w", err)
+		}
+		if err := f.Close(); err != nil {
+			return "", fmt.Errorf("failed to write capture: %w", err)
+		}
+		return capturesDir + "/" + name, nil
+	}
+}
+
+// findCaptureByHash refreshes the content-hash index for capturesDir and
+// returns the path of a capture there with the given hash, or "" if there is
+// none. Where several captures share content, the first by path wins.
+// Callers hold captureHashes.mu.
+func (s *SpaceDatabaseService) findCaptureByHash(capturesDir, hash string) (string, error) {
+	if err := s.refreshCaptureHashes(capturesDir); err != nil {
+		return "", err
+	}
+
+	prefix := capturesDir + "/"
+	match := ""
+	for notePath, entry := range s.captureHashes.entries {
+		if entry.hash == hash && strings.HasPrefix(notePath, prefix) && (match == "" || notePath < match) {
+			match = notePath
+		}
+	}
+	return match, nil
+}
+
+// refreshCaptureHashes brings the index in line with the markdown files under
+// the vault-relative capturesDir, hashing new and changed files and dropping
+// removed ones; entries for other directories are kept. Hidden files and
+// folders are skipped. Callers hold captureHashes.mu.
+func (s *SpaceDatabaseService) refreshCaptureHashes(capturesDir string) error {
+	root := filepath.Join(s.parachuteRoot, filepath.FromSlash(capturesDir))
+	prefix := capturesDir + "/"
+	found := make(map[string]captureHashEntry)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".md") {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.parachuteRoot, path)
+		if err != nil {
+			return err
+		}
+		notePath := filepath.ToSlash(rel)
+
+		if entry, ok := s.captureHashes.entries[notePath]; ok && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+			found[notePath] = entry
+			return nil
+		}
+
+		content, err := s.ReadCapture(path)
+		if errors.Is(err, ErrCaptureTooLarge) {
+			// Too large to hash, so it can never be detected as a duplicate
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		found[notePath] = captureHashEntry{modTime: info.ModTime(), size: info.Size(), hash: contentHash(content)}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to index captures: %w", err)
+	}
+
+	if s.captureHashes.entries == nil {
+		s.captureHashes.entries = make(map[string]captureHashEntry)
+	}
+	for notePath := range s.captureHashes.entries {
+		if strings.HasPrefix(notePath, prefix) {
+			delete(s.captureHashes.entries, notePath)
+		}
+	}
+	for notePath, entry := range found {
+		s.captureHashes.entries[notePath] = entry
+	}
+	return nil
+}
+
+// contentHash returns the hex SHA-256 of content
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// captureIDFromPath derives a capture ID from a note path: its filename
+// without the .md extension
+func captureIDFromPath(notePath string) string {
+	return strings.TrimSuffix(filepath.Base(notePath), filepath.Ext(notePath))
+}
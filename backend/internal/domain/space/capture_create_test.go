@@ -0,0 +1,112 @@
+package space_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestCreateAndLinkCaptureReusesIdentical(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	_, existingPath := createNamedCapture(t, parachuteRoot, "2025-01-02_03-04-05.md", "Water the tomatoes.\n")
+
+	params := space.CreateCaptureParams{Content: "Water the tomatoes.\n", Context: "Chores", ReuseIdentical: true}
+	reused, err := service.CreateAndLinkCapture(spaceID, spacePath, params)
+	if err != nil {
+		t.Fatalf("Failed to create capture: %v", err)
+	}
+	if reused.Created || reused.NotePath != filepath.ToSlash(existingPath) || reused.CaptureID != "2025-01-02_03-04-05" {
+		t.Errorf("Expected the existing capture to be reused, got %+v", reused)
+	}
+	if _, err := service.GetNoteByID(spacePath, reused.CaptureID); err != nil {
+		t.Errorf("Expected the reused capture to be linked: %v", err)
+	}
+
+	again, err := service.CreateAndLinkCapture(spaceID, spacePath, params)
+	if err != nil {
+		t.Fatalf("Failed to create capture again: %v", err)
+	}
+	if again != reused {
+		t.Errorf("Expected a retry to reuse %+v, got %+v", reused, again)
+	}
+
+	t.Run("DifferentContent", func(t *testing.T) {
+		created, err := service.CreateAndLinkCapture(spaceID, spacePath, space.CreateCaptureParams{Content: "Water the beans.\n", ReuseIdentical: true})
+		if err != nil {
+			t.Fatalf("Failed to create capture: %v", err)
+		}
+		if !created.Created || created.NotePath == reused.NotePath {
+			t.Fatalf("Expected a new capture, got %+v", created)
+		}
+		content, err := os.ReadFile(filepath.Join(parachuteRoot, created.NotePath))
+		if err != nil || string(content) != "Water the beans.\n" {
+			t.Errorf("Expected the new capture to hold the content, got %q (%v)", content, err)
+		}
+	})
+
+	t.Run("EditedCaptureNotReused", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(parachuteRoot, existingPath), []byte("Water the tomatoes twice.\n"), 0644); err != nil {
+			t.Fatalf("Failed to edit capture: %v", err)
+		}
+		created, err := service.CreateAndLinkCapture(spaceID, spacePath, params)
+		if err != nil {
+			t.Fatalf("Failed to create capture: %v", err)
+		}
+		if !created.Created {
+			t.Errorf("Expected a new capture once the original was edited, got %+v", created)
+		}
+	})
+
+	t.Run("ReuseOff", func(t *testing.T) {
+		params.ReuseIdentical = false
+		created, err := service.CreateAndLinkCapture(spaceID, spacePath, params)
+		if err != nil {
+			t.Fatalf("Failed to create capture: %v", err)
+		}
+		if !created.Created {
+			t.Errorf("Expected a new capture without reuse_identical, got %+v", created)
+		}
+	})
+}
+
+func TestCreateAndLinkCaptureUsesCapturesDir(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+	if err := service.SetSetting(spacePath, space.SettingCapturesDir, "research/captures"); err != nil {
+		t.Fatalf("Failed to set captures_dir: %v", err)
+	}
+
+	// One write token is enough for a create and its link
+	service.SetWriteRateLimit(space.WriteRateLimit{PerSecond: 0.001, Burst: 1})
+
+	params := space.CreateCaptureParams{Content: "Trial plot notes.\n", ReuseIdentical: true}
+	created, err := service.CreateAndLinkCapture(spaceID, spacePath, params)
+	if err != nil {
+		t.Fatalf("Failed to create capture: %v", err)
+	}
+	if !created.Created || filepath.Dir(created.NotePath) != "research/captures" {
+		t.Fatalf("Expected a new capture in research/captures, got %+v", created)
+	}
+	if _, err := os.Stat(filepath.Join(parachuteRoot, "research", "captures", filepath.Base(created.NotePath))); err != nil {
+		t.Errorf("Expected the capture file in the captures_dir: %v", err)
+	}
+
+	service.SetWriteRateLimit(space.WriteRateLimit{})
+	again, err := service.CreateAndLinkCapture(spaceID, spacePath, params)
+	if err != nil {
+		t.Fatalf("Failed to create capture again: %v", err)
+	}
+	if again != (space.CaptureCreation{CaptureID: created.CaptureID, NotePath: created.NotePath}) {
+		t.Errorf("Expected the capture in the captures_dir to be reused, got %+v", again)
+	}
+}
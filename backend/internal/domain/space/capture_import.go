@@ -0,0 +1,163 @@
+package space
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/unforced/parachute-backend/internal/domain/file"
+)
+
+// CaptureRef identifies a capture file to link into a space
+type CaptureRef struct {
+	CaptureID string `json:"capture_id"`
+	NotePath  string `json:"note_path"`
+}
+
+// Capture import outcomes
+const (
+	ImportStatusLinked  = "linked"
+	ImportStatusSkipped = "skipped" // Already linked to the space
+	ImportStatusError   = "error"
+)
+
+// CaptureImportOutcome reports what happened to one capture in an import
+type CaptureImportOutcome struct {
+	CaptureID string   `json:"capture_id"`
+	NotePath  string   `json:"note_path"`
+	Status    string   `json:"status"`
+	Context   string   `json:"context,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// ImportResult summarizes a LinkNotesFromCaptures call
+type ImportResult struct {
+	BatchID  string                 `json:"batch_id,omitempty"` // Set on every note linked by this call; empty if none were
+	Linked   int                    `json:"linked"`
+	Skipped  int                    `json:"skipped"`
+	Failed   int                    `json:"failed"`
+	Outcomes []CaptureImportOutcome `json:"outcomes"`
+}
+
+// LinkNotesFromCaptures links several existing capture files to a space. Each
+// capture's context is taken from its first heading (or frontmatter title) and
+// its tags are defaultTags plus the #tags found in the file (and its
+// frontmatter tags, unless SetInheritFrontmatterTags turned that off). Captures already
+// linked are skipped; a capture that fails is reported in its outcome without
+// stopping the rest. The notes linked share a new batch ID, so the import can
+// be reviewed (or undone) as a unit with NoteFilters.BatchID.
+func (s *SpaceDatabaseService) LinkNotesFromCaptures(spaceID, spacePath string, captures []CaptureRef, defaultTags []string) (ImportResult, error) {
+	result := ImportResult{Outcomes: make([]CaptureImportOutcome, 0, len(captures))}
+
+	if err := s.checkWritable(spacePath); err != nil {
+		return result, err
+	}
+
+	ids := make([]string, len(captures))
+	for i, ref := range captures {
+		ids[i] = ref.CaptureID
+	}
+	existing, err := s.GetNotesByIDs(spacePath, ids)
+	if err != nil {
+		return result, err
+	}
+	linked := make(map[string]bool, len(existing))
+	for _, note := range existing {
+		linked[note.CaptureID] = true
+	}
+
+	batchID := uuid.New().String()
+	for _, ref := range captures {
+		outcome := CaptureImportOutcome{CaptureID: ref.CaptureID, NotePath: ref.NotePath}
+
+		switch {
+		case ref.CaptureID == "" || ref.NotePath == "":
+			outcome.Status = ImportStatusError
+			outcome.Error = "capture_id and note_path are required"
+		case linked[ref.CaptureID]:
+			outcome.Status = ImportStatusSkipped
+		default:
+			if err := s.importCapture(spaceID, spacePath, ref, defaultTags, batchID, &outcome); err != nil {
+				outcome.Status = ImportStatusError
+				outcome.Error = err.Error()
+			} else {
+				outcome.Status = ImportStatusLinked
+				linked[ref.CaptureID] = true
+			}
+		}
+
+		switch outcome.Status {
+		case ImportStatusLinked:
+			result.Linked++
+		case ImportStatusSkipped:
+			result.Skipped++
+		default:
+			result.Failed++
+		}
+		result.Outcomes = append(result.Outcomes, outcome)
+	}
+
+	if result.Linked > 0 {
+		result.BatchID = batchID
+	}
+
+	return result, nil
+}
+
+// importCapture reads one capture file, derives its context and tags, and links it
+func (s *SpaceDatabaseService) importCapture(spaceID, spacePath string, ref CaptureRef, defaultTags []string, batchID string, outcome *CaptureImportOutcome) error {
+	fullPath, err := s.ResolveNoteFile(spacePath, ref.NotePath)
+	if err != nil {
+		return err
+	}
+
+	content, err := s.ReadCapture(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("capture file not found: %s", ref.NotePath)
+		}
+		return fmt.Errorf("failed to read capture: %w", err)
+	}
+
+	structure := file.ParseCaptureDetailed(string(content))
+
+	context := structure.Frontmatter["title"]
+	if len(structure.Headings) > 0 {
+		context = structure.Headings[0].Text
+	}
+
+	tags := mergeTags(defaultTags, structure.Tags)
+	if s.inheritTags {
+		tags = mergeTags(tags, inheritableTags(string(content)))
+	}
+
+	// Frontmatter tags were merged above so the outcome reports them
+	inherit := false
+	err = s.withBusyRetry(func() error {
+		return s.linkNote(spaceID, spacePath, ref.CaptureID, ref.NotePath, context, tags, batchID, LinkOptions{InheritFrontmatterTags: &inherit})
+	})
+	if err != nil {
+		return err
+	}
+
+	outcome.Context = context
+	outcome.Tags = tags
+	return nil
+}
+
+// mergeTags concatenates tag lists, dropping duplicates and keeping first-seen order
+func mergeTags(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	merged := []string{}
+	for _, list := range lists {
+		for _, tag := range list {
+			if tag == "" || seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
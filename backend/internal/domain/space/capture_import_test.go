@@ -0,0 +1,129 @@
+package space_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestLinkNotesFromCaptures(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	idA, pathA := createNamedCapture(t, parachuteRoot, "a.md", "# Soil testing\n\nPH was low. #soil\n")
+	idB, pathB := createNamedCapture(t, parachuteRoot, "b.md", "---\ntitle: Seed order\n---\nOrdered #seeds and #soil amendments.\n")
+	idC, pathC := createNamedCapture(t, parachuteRoot, "c.md", "## Watering\nEvery other day.\n")
+
+	captures := []space.CaptureRef{
+		{CaptureID: idA, NotePath: pathA},
+		{CaptureID: "missing", NotePath: "captures/missing.md"},
+		{CaptureID: idB, NotePath: pathB},
+		{CaptureID: idC, NotePath: pathC},
+	}
+
+	t.Run("LinksWithDerivedContext", func(t *testing.T) {
+		result, err := service.LinkNotesFromCaptures(spaceID, spacePath, captures, []string{"garden"})
+		if err != nil {
+			t.Fatalf("Failed to import captures: %v", err)
+		}
+
+		if result.Linked != 3 || result.Failed != 1 || result.Skipped != 0 {
+			t.Fatalf("Expected 3 linked and 1 failed, got %+v", result)
+		}
+		if len(result.Outcomes) != len(captures) {
+			t.Fatalf("Expected an outcome per capture, got %d", len(result.Outcomes))
+		}
+		if missing := result.Outcomes[1]; missing.Status != space.ImportStatusError || missing.Error == "" {
+			t.Errorf("Expected missing capture to be reported as an error, got %+v", missing)
+		}
+
+		want := map[string]struct {
+			context string
+			tags    []string
+		}{
+			idA: {"Soil testing", []string{"garden", "soil"}},
+			idB: {"Seed order", []string{"garden", "seeds", "soil"}},
+			idC: {"Watering", []string{"garden"}},
+		}
+		for id, w := range want {
+			note, err := service.GetNoteByID(spacePath, id)
+			if err != nil {
+				t.Fatalf("Expected %s to be linked: %v", id, err)
+			}
+			if note.Context != w.context {
+				t.Errorf("Expected context %q, got %q", w.context, note.Context)
+			}
+			if !reflect.DeepEqual(note.Tags, w.tags) {
+				t.Errorf("Expected tags %v, got %v", w.tags, note.Tags)
+			}
+		}
+	})
+
+	t.Run("SkipsAlreadyLinked", func(t *testing.T) {
+		result, err := service.LinkNotesFromCaptures(spaceID, spacePath, captures[:1], nil)
+		if err != nil {
+			t.Fatalf("Failed to import captures: %v", err)
+		}
+		if result.Skipped != 1 || result.Outcomes[0].Status != space.ImportStatusSkipped {
+			t.Errorf("Expected already-linked capture to be skipped, got %+v", result)
+		}
+
+		note, err := service.GetNoteByID(spacePath, idA)
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if len(note.Tags) != 2 {
+			t.Errorf("Expected skipped capture to keep its tags, got %v", note.Tags)
+		}
+		if result.BatchID != "" {
+			t.Errorf("Expected no batch ID when nothing was linked, got %q", result.BatchID)
+		}
+	})
+
+	t.Run("SharesBatchID", func(t *testing.T) {
+		idD, pathD := createNamedCapture(t, parachuteRoot, "d.md", "Compost turned.\n")
+		idE, pathE := createNamedCapture(t, parachuteRoot, "e.md", "Mulch delivered.\n")
+		idF, pathF := createNamedCapture(t, parachuteRoot, "f.md", "Linked by hand.\n")
+		if err := service.LinkNote(spaceID, spacePath, idF, pathF, "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+
+		result, err := service.LinkNotesFromCaptures(spaceID, spacePath, []space.CaptureRef{
+			{CaptureID: idD, NotePath: pathD},
+			{CaptureID: idE, NotePath: pathE},
+		}, nil)
+		if err != nil {
+			t.Fatalf("Failed to import captures: %v", err)
+		}
+		if result.BatchID == "" {
+			t.Fatal("Expected the import to report its batch ID")
+		}
+
+		notes, err := service.GetRelevantNotes(spacePath, space.NoteFilters{BatchID: result.BatchID})
+		if err != nil {
+			t.Fatalf("Failed to query notes: %v", err)
+		}
+		got := map[string]bool{}
+		for _, note := range notes {
+			got[note.CaptureID] = true
+			if note.BatchID != result.BatchID {
+				t.Errorf("Expected batch ID %q, got %q", result.BatchID, note.BatchID)
+			}
+		}
+		if len(notes) != 2 || !got[idD] || !got[idE] {
+			t.Errorf("Expected exactly the two imported notes, got %v", got)
+		}
+
+		manual, err := service.GetNoteByID(spacePath, idF)
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if manual.BatchID != "" {
+			t.Errorf("Expected a manual link to have no batch ID, got %q", manual.BatchID)
+		}
+	})
+}
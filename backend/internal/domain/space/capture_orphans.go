@@ -0,0 +1,134 @@
+package space
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CaptureFile is a markdown capture in the vault's captures directory
+type CaptureFile struct {
+	NotePath   string    `json:"note_path"` // Vault-relative, as it would be linked
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+// FindUnlinkedCaptures returns the markdown captures under the vault's
+// captures/ directory, including nested folders, that no space of the user
+// links. Linked paths are resolved the way ResolveNoteFile does, so a note
+// found through a space's captures_dir counts as linked. Hidden files and
+// folders are skipped. Results are ordered by note path.
+func (s *Service) FindUnlinkedCaptures(ctx context.Context, userID string) ([]CaptureFile, error) {
+	if s.dbService == nil {
+		return nil, fmt.Errorf("space database service not configured")
+	}
+
+	spaces, err := s.repo.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	linked := make(map[string]bool)
+	for _, sp := range spaces {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		paths, err := linkedNotePaths(sp.Path)
+		if err != nil {
+			return nil, fmt.Errorf("space %s: %w", sp.ID, err)
+		}
+		for _, notePath := range paths {
+			fullPath, err := s.dbService.ResolveNoteFile(sp.Path, notePath)
+			if err != nil {
+				continue
+			}
+			linked[filepath.Clean(fullPath)] = true
+		}
+	}
+
+	capturesDir := filepath.Join(s.parachuteRoot, DefaultCapturesDir)
+	unlinked := []CaptureFile{}
+	err = filepath.WalkDir(capturesDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == capturesDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if path != capturesDir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".md") || linked[filepath.Clean(path)] {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(s.parachuteRoot, path)
+		if err != nil {
+			return err
+		}
+		unlinked = append(unlinked, CaptureFile{
+			NotePath:   filepath.ToSlash(rel),
+			Size:       info.Size(),
+			ModifiedAt: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan captures: %w", err)
+	}
+
+	sort.Slice(unlinked, func(i, j int) bool {
+		return unlinked[i].NotePath < unlinked[j].NotePath
+	})
+
+	return unlinked, nil
+}
+
+// linkedNotePaths returns the stored path of every note linked in a space,
+// regardless of status or expiry
+func linkedNotePaths(spacePath string) ([]string, error) {
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("space database not found")
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT note_path FROM relevant_notes")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notes: %w", err)
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var notePath string
+		if err := rows.Scan(&notePath); err != nil {
+			return nil, fmt.Errorf("failed to scan note path: %w", err)
+		}
+		paths = append(paths, notePath)
+	}
+	return paths, rows.Err()
+}
@@ -0,0 +1,56 @@
+package space_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+	sqliteStorage "github.com/unforced/parachute-backend/internal/storage/sqlite"
+)
+
+func TestFindUnlinkedCaptures(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	db, err := sqliteStorage.NewDatabase(filepath.Join(parachuteRoot, "parachute.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	service := space.NewService(sqliteStorage.NewSpaceRepository(db.DB), parachuteRoot)
+	service.SetDatabaseService(dbService)
+
+	linkedID, linkedPath := createNamedCapture(t, parachuteRoot, "linked.md", "Filed away.\n")
+	createNamedCapture(t, parachuteRoot, "inbox.md", "Not filed yet.\n")
+	createNamedCapture(t, parachuteRoot, "2024/05/nested.md", "Also not filed.\n")
+
+	sp, err := service.Create(ctx, "default", space.CreateSpaceParams{Name: "Garden"})
+	if err != nil {
+		t.Fatalf("Failed to create space: %v", err)
+	}
+	if err := dbService.InitializeSpaceDatabase(sp.ID, sp.Path); err != nil {
+		t.Fatalf("Failed to initialize space database: %v", err)
+	}
+	if err := dbService.LinkNote(sp.ID, sp.Path, linkedID, linkedPath, "", nil); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	unlinked, err := service.FindUnlinkedCaptures(ctx, "default")
+	if err != nil {
+		t.Fatalf("Failed to find unlinked captures: %v", err)
+	}
+
+	want := []string{"captures/2024/05/nested.md", "captures/inbox.md"}
+	if len(unlinked) != len(want) {
+		t.Fatalf("Expected %d unlinked captures, got %+v", len(want), unlinked)
+	}
+	for i, path := range want {
+		if unlinked[i].NotePath != path {
+			t.Errorf("Expected %s at %d, got %s", path, i, unlinked[i].NotePath)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package space
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// DefaultMaxCaptureSize is the largest capture file ReadCapture loads by
+// default, far above any note a person writes
+const DefaultMaxCaptureSize int64 = 10 << 20 // 10 MB
+
+// ErrCaptureTooLarge is matched (with errors.Is) by the CaptureTooLargeError
+// returned when a capture file is over the maximum capture size
+var ErrCaptureTooLarge = errors.New("capture file exceeds the maximum size")
+
+// CaptureTooLargeError reports a capture file ReadCapture refused to load
+type CaptureTooLargeError struct {
+	Size  int64 // Size of the file when it was checked, in bytes
+	Limit int64 // The maximum capture size, in bytes
+}
+
+func (e *CaptureTooLargeError) Error() string {
+	return fmt.Sprintf("%v (%d bytes, limit %d)", ErrCaptureTooLarge, e.Size, e.Limit)
+}
+
+// Unwrap lets errors.Is match ErrCaptureTooLarge
+func (e *CaptureTooLargeError) Unwrap() error {
+	return ErrCaptureTooLarge
+}
+
+// SetMaxCaptureSize sets the largest capture file ReadCapture loads, in
+// bytes. Zero or less removes the limit.
+func (s *SpaceDatabaseService) SetMaxCaptureSize(limit int64) {
+	s.maxCaptureSize = limit
+}
+
+// ReadCapture reads a capture file, refusing with a CaptureTooLargeError one
+// over the maximum capture size instead of loading it into memory. A file
+// that grows past the limit while being read is refused too.
+func (s *SpaceDatabaseService) ReadCapture(fullPath string) ([]byte, error) {
+	f, err := os.Open(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	limit := s.maxCaptureSize
+	if limit <= 0 {
+		return io.ReadAll(f)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > limit {
+		return nil, &CaptureTooLargeError{Size: info.Size(), Limit: limit}
+	}
+
+	content, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > limit {
+		return nil, &CaptureTooLargeError{Size: int64(len(content)), Limit: limit}
+	}
+	return content, nil
+}
@@ -0,0 +1,86 @@
+package space_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestReadCapture(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	service.SetMaxCaptureSize(1024)
+
+	write := func(name string, size int) string {
+		path := filepath.Join(parachuteRoot, "captures", name)
+		if err := os.WriteFile(path, bytes.Repeat([]byte("a"), size), 0644); err != nil {
+			t.Fatalf("Failed to write capture: %v", err)
+		}
+		return path
+	}
+
+	t.Run("AtLimit", func(t *testing.T) {
+		content, err := service.ReadCapture(write("at-limit.md", 1024))
+		if err != nil {
+			t.Fatalf("Expected a file at the limit to be read, got %v", err)
+		}
+		if len(content) != 1024 {
+			t.Errorf("Expected 1024 bytes, got %d", len(content))
+		}
+	})
+
+	t.Run("OverLimit", func(t *testing.T) {
+		_, err := service.ReadCapture(write("over-limit.md", 1025))
+		if !errors.Is(err, space.ErrCaptureTooLarge) {
+			t.Fatalf("Expected ErrCaptureTooLarge, got %v", err)
+		}
+		var tooLarge *space.CaptureTooLargeError
+		if !errors.As(err, &tooLarge) || tooLarge.Size != 1025 || tooLarge.Limit != 1024 {
+			t.Errorf("Expected size 1025 and limit 1024, got %+v", tooLarge)
+		}
+	})
+
+	t.Run("Configurable", func(t *testing.T) {
+		path := write("large.md", 4096)
+
+		service.SetMaxCaptureSize(0)
+		if _, err := service.ReadCapture(path); err != nil {
+			t.Errorf("Expected no limit, got %v", err)
+		}
+
+		defaults := space.NewSpaceDatabaseService(parachuteRoot)
+		if _, err := defaults.ReadCapture(path); err != nil {
+			t.Errorf("Expected the default limit to allow 4 KB, got %v", err)
+		}
+	})
+
+	t.Run("InjectedContextPlaceholder", func(t *testing.T) {
+		spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+		captureID, notePath := createNamedCapture(t, parachuteRoot, "huge.md", strings.Repeat("a", 2048))
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+
+		service.SetMaxCaptureSize(1024)
+		injected, err := space.NewContextService(service).BuildInjectedContext(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to build injected context: %v", err)
+		}
+		if !strings.Contains(injected, "_(capture file too large to include)_") || strings.Contains(injected, "aaaa") {
+			t.Errorf("Expected a placeholder for the oversized capture, got:\n%s", injected)
+		}
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		if _, err := service.ReadCapture(filepath.Join(parachuteRoot, "captures", "missing.md")); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("Expected a not-exist error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package space
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// UpdateNotePath points a linked note at a new file, e.g. after its capture
+// was renamed on disk. newPath is normalized like a linked note path.
+func (s *SpaceDatabaseService) UpdateNotePath(spacePath, captureID, newPath string) error {
+	if err := s.checkWritable(spacePath); err != nil {
+		return err
+	}
+	return s.withBusyRetry(func() error {
+		return s.updateNotePath(spacePath, captureID, newPath)
+	})
+}
+
+// updateNotePath implements UpdateNotePath without retries
+func (s *SpaceDatabaseService) updateNotePath(spacePath, captureID, newPath string) error {
+	normalized, err := s.NormalizeNotePath(newPath)
+	if err != nil {
+		return err
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	result, err := db.Exec("UPDATE relevant_notes SET note_path = ? WHERE capture_id = ?", normalized, captureID)
+	if err != nil {
+		return fmt.Errorf("failed to update note path: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return domain.NewNotFoundError("note", captureID)
+	}
+
+	if err := bumpContextVersion(db); err != nil {
+		return err
+	}
+
+	s.syncManifest(spacePath)
+	return nil
+}
+
+// RenameCaptureEverywhere rewrites the path of a renamed capture in every one
+// of the user's spaces that links it at oldPath, returning how many spaces
+// were updated. Both paths are normalized per space like a linked note path,
+// so a bare filename means the space's captures directory. Links to the
+// capture at any other path are left alone, as are read-only spaces and
+// spaces without a database. A NotFoundError is returned when no space links
+// the capture at oldPath.
+func (s *Service) RenameCaptureEverywhere(ctx context.Context, userID, captureID, oldPath, newPath string) (int, error) {
+	if s.dbService == nil {
+		return 0, fmt.Errorf("space database service not configured")
+	}
+	if captureID == "" {
+		return 0, domain.NewValidationError("capture_id", "capture_id is required")
+	}
+
+	if _, err := s.dbService.vaultRelative(oldPath, "old_path"); err != nil {
+		return 0, err
+	}
+	if _, err := s.dbService.vaultRelative(newPath, "new_path"); err != nil {
+		return 0, err
+	}
+
+	spaces, err := s.repo.List(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	matched, updated := 0, 0
+	for _, sp := range spaces {
+		if err := ctx.Err(); err != nil {
+			return updated, err
+		}
+
+		if _, err := os.Stat(filepath.Join(sp.Path, "space.sqlite")); os.IsNotExist(err) {
+			continue
+		}
+
+		oldNormalized, err := s.dbService.normalizeLinkPath(sp.Path, oldPath)
+		if err != nil {
+			return updated, fmt.Errorf("space %s: %w", sp.ID, err)
+		}
+		newNormalized, err := s.dbService.normalizeLinkPath(sp.Path, newPath)
+		if err != nil {
+			return updated, fmt.Errorf("space %s: %w", sp.ID, err)
+		}
+
+		notes, err := s.dbService.GetNotesByIDs(sp.Path, []string{captureID})
+		if err != nil {
+			return updated, fmt.Errorf("space %s: %w", sp.ID, err)
+		}
+		if len(notes) == 0 || notes[0].NotePath != oldNormalized {
+			continue
+		}
+		matched++
+		if oldNormalized == newNormalized {
+			continue
+		}
+
+		err = s.dbService.UpdateNotePath(sp.Path, captureID, newNormalized)
+		if errors.Is(err, ErrSpaceReadOnly) {
+			continue
+		}
+		if err != nil {
+			return updated, fmt.Errorf("space %s: %w", sp.ID, err)
+		}
+		updated++
+	}
+
+	if matched == 0 {
+		return 0, domain.NewNotFoundError("capture", captureID)
+	}
+
+	return updated, nil
+}
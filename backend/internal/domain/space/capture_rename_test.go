@@ -0,0 +1,146 @@
+package space_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+	sqliteStorage "github.com/unforced/parachute-backend/internal/storage/sqlite"
+)
+
+func TestUpdateNotePath(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	if err := service.LinkNote(spaceID, spacePath, "note-1", "captures/old.md", "", nil); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	t.Run("Normalizes", func(t *testing.T) {
+		if err := service.UpdateNotePath(spacePath, "note-1", filepath.Join(parachuteRoot, "captures", "new.md")); err != nil {
+			t.Fatalf("Failed to update note path: %v", err)
+		}
+		note, err := service.GetNoteByID(spacePath, "note-1")
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if note.NotePath != "captures/new.md" {
+			t.Errorf("Expected captures/new.md, got %s", note.NotePath)
+		}
+	})
+
+	t.Run("OutsideVault", func(t *testing.T) {
+		err := service.UpdateNotePath(spacePath, "note-1", "../elsewhere.md")
+		var validationErr *domain.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error, got %v", err)
+		}
+	})
+
+	t.Run("NotLinked", func(t *testing.T) {
+		err := service.UpdateNotePath(spacePath, "missing", "captures/new.md")
+		var notFound *domain.NotFoundError
+		if !errors.As(err, &notFound) {
+			t.Errorf("Expected not found error, got %v", err)
+		}
+	})
+}
+
+func TestRenameCaptureEverywhere(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	db, err := sqliteStorage.NewDatabase(filepath.Join(parachuteRoot, "parachute.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	service := space.NewService(sqliteStorage.NewSpaceRepository(db.DB), parachuteRoot)
+	service.SetDatabaseService(dbService)
+
+	create := func(name string, notes map[string]string) *space.Space {
+		sp, err := service.Create(ctx, "default", space.CreateSpaceParams{Name: name})
+		if err != nil {
+			t.Fatalf("Failed to create space %s: %v", name, err)
+		}
+		if err := dbService.InitializeSpaceDatabase(sp.ID, sp.Path); err != nil {
+			t.Fatalf("Failed to initialize space database: %v", err)
+		}
+		for captureID, notePath := range notes {
+			if err := dbService.LinkNote(sp.ID, sp.Path, captureID, notePath, "", nil); err != nil {
+				t.Fatalf("Failed to link note: %v", err)
+			}
+		}
+		return sp
+	}
+
+	garden := create("Garden", map[string]string{"walk": "captures/walk.md", "other": "captures/other.md"})
+	farm := create("Farm", map[string]string{"walk": "captures/walk.md"})
+	stale := create("Stale", map[string]string{"walk": "captures/archived/walk.md"})
+
+	notePath := func(t *testing.T, sp *space.Space, captureID string) string {
+		note, err := dbService.GetNoteByID(sp.Path, captureID)
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		return note.NotePath
+	}
+
+	updated, err := service.RenameCaptureEverywhere(ctx, "default", "walk", "captures/walk.md", "captures/garden-walk.md")
+	if err != nil {
+		t.Fatalf("Failed to rename capture: %v", err)
+	}
+	if updated != 2 {
+		t.Errorf("Expected 2 spaces updated, got %d", updated)
+	}
+
+	for _, sp := range []*space.Space{garden, farm} {
+		if got := notePath(t, sp, "walk"); got != "captures/garden-walk.md" {
+			t.Errorf("Expected %s to link captures/garden-walk.md, got %s", sp.Name, got)
+		}
+	}
+	if got := notePath(t, garden, "other"); got != "captures/other.md" {
+		t.Errorf("Expected unrelated link untouched, got %s", got)
+	}
+	if got := notePath(t, stale, "walk"); got != "captures/archived/walk.md" {
+		t.Errorf("Expected link at another path untouched, got %s", got)
+	}
+
+	t.Run("BareFilename", func(t *testing.T) {
+		updated, err := service.RenameCaptureEverywhere(ctx, "default", "other", "other.md", "other-renamed.md")
+		if err != nil {
+			t.Fatalf("Failed to rename capture: %v", err)
+		}
+		if updated != 1 {
+			t.Errorf("Expected 1 space updated, got %d", updated)
+		}
+		if got := notePath(t, garden, "other"); got != "captures/other-renamed.md" {
+			t.Errorf("Expected the bare filename to match in the captures directory, got %s", got)
+		}
+	})
+
+	t.Run("NoMatch", func(t *testing.T) {
+		_, err := service.RenameCaptureEverywhere(ctx, "default", "walk", "captures/nowhere.md", "captures/elsewhere.md")
+		var notFoundErr *domain.NotFoundError
+		if !errors.As(err, &notFoundErr) {
+			t.Errorf("Expected not found error, got %v", err)
+		}
+	})
+
+	t.Run("PathTraversal", func(t *testing.T) {
+		_, err := service.RenameCaptureEverywhere(ctx, "default", "walk", "captures/garden-walk.md", "../../etc/passwd")
+		var validationErr *domain.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package space
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// captureSnippetLength caps the matching line returned with a search result
+const captureSnippetLength = 200
+
+// Vault capture search result caps: DefaultCaptureSearchLimit applies when
+// no limit is given and larger limits are clamped to MaxCaptureSearchLimit
+const (
+	DefaultCaptureSearchLimit = 50
+	MaxCaptureSearchLimit     = 500
+)
+
+// CaptureSearchResult is one capture whose content matched a vault search
+type CaptureSearchResult struct {
+	CaptureID string             `json:"capture_id"`
+	NotePath  string             `json:"note_path"`
+	Snippet   string             `json:"snippet"` // First matching line, trimmed
+	Spaces    []CaptureSpaceLink `json:"spaces"`  // Every space linking the capture
+}
+
+// CaptureSpaceLink is a space a capture is linked to
+type CaptureSpaceLink struct {
+	SpaceID   string `json:"space_id"`
+	SpaceName string `json:"space_name"`
+}
+
+// SearchVaultCaptures searches the content of every capture linked in the
+// user's spaces for query, case-insensitively. A capture linked to several
+// spaces is read once and returned once, listing all of them. Spaces whose
+// database cannot be read and captures whose file is missing are skipped.
+// Results are ordered by note path.
+//
+// At most limit results are returned (DefaultCaptureSearchLimit if limit is
+// not positive, at most MaxCaptureSearchLimit). Captures are read in result
+// order and reading stops once the limit is passed, in which case truncated
+// is true.
+func (s *Service) SearchVaultCaptures(ctx context.Context, userID, query string, limit int) (results []CaptureSearchResult, truncated bool, err error) {
+	if s.dbService == nil {
+		return nil, false, fmt.Errorf("space database service not configured")
+	}
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, false, domain.NewValidationError("q", "search query is required")
+	}
+
+	if limit <= 0 {
+		limit = DefaultCaptureSearchLimit
+	}
+	if limit > MaxCaptureSearchLimit {
+		limit = MaxCaptureSearchLimit
+	}
+
+	spaces, err := s.repo.List(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Collect each capture once, with where to read it and the spaces linking it
+	type capture struct {
+		result   CaptureSearchResult
+		fullPath string
+	}
+	byID := make(map[string]*capture)
+	for _, sp := range spaces {
+		if err := ctx.Err(); err != nil {
+			return nil, false, err
+		}
+
+		notes, err := s.dbService.GetRelevantNotes(sp.Path, NoteFilters{})
+		if err != nil {
+			// One unreadable space database shouldn't fail the whole search
+			continue
+		}
+
+		for _, note := range notes {
+			link := CaptureSpaceLink{SpaceID: sp.ID, SpaceName: sp.Name}
+			if c, ok := byID[note.CaptureID]; ok {
+				c.result.Spaces = append(c.result.Spaces, link)
+				continue
+			}
+
+			fullPath, err := s.dbService.ResolveNoteFile(sp.Path, note.NotePath)
+			if err != nil {
+				continue
+			}
+			byID[note.CaptureID] = &capture{
+				result:   CaptureSearchResult{CaptureID: note.CaptureID, NotePath: note.NotePath, Spaces: []CaptureSpaceLink{link}},
+				fullPath: fullPath,
+			}
+		}
+	}
+
+	// Read captures in result order so the scan can stop at the limit
+	candidates := make([]*capture, 0, len(byID))
+	for _, c := range byID {
+		candidates = append(candidates, c)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i].result, candidates[j].result
+		if a.NotePath != b.NotePath {
+			return a.NotePath < b.NotePath
+		}
+		return a.CaptureID < b.CaptureID
+	})
+
+	needle := strings.ToLower(query)
+	results = []CaptureSearchResult{}
+	for _, c := range candidates {
+		if err := ctx.Err(); err != nil {
+			return nil, false, err
+		}
+
+		content, err := s.dbService.ReadCapture(c.fullPath)
+		if err != nil {
+			continue
+		}
+
+		snippet, ok := matchingLine(string(content), needle)
+		if !ok {
+			continue
+		}
+		if len(results) == limit {
+			return results, true, nil
+		}
+		c.result.Snippet = snippet
+		results = append(results, c.result)
+	}
+
+	return results, false, nil
+}
+
+// matchingLine returns the first line of content containing needle (already
+// lowercased), trimmed to captureSnippetLength runes
+func matchingLine(content, needle string) (string, bool) {
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.Contains(strings.ToLower(line), needle) {
+			continue
+		}
+
+		line = strings.TrimSpace(line)
+		if utf8.RuneCountInString(line) > captureSnippetLength {
+			line = string([]rune(line)[:captureSnippetLength]) + "…"
+		}
+		return line, true
+	}
+	return "", false
+}
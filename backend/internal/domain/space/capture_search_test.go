@@ -0,0 +1,121 @@
+package space_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+	sqliteStorage "github.com/unforced/parachute-backend/internal/storage/sqlite"
+)
+
+func TestSearchVaultCaptures(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	db, err := sqliteStorage.NewDatabase(filepath.Join(parachuteRoot, "parachute.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	service := space.NewService(sqliteStorage.NewSpaceRepository(db.DB), parachuteRoot)
+	service.SetDatabaseService(dbService)
+
+	shared, sharedPath := createNamedCapture(t, parachuteRoot, "shared.md", "# Soil\nThe Compost pile is warm.\n")
+	single, singlePath := createNamedCapture(t, parachuteRoot, "single.md", "Turned the compost again.\n")
+	other, otherPath := createNamedCapture(t, parachuteRoot, "other.md", "Tractor maintenance.\n")
+
+	link := func(name string, captures map[string]string) *space.Space {
+		sp, err := service.Create(ctx, "default", space.CreateSpaceParams{Name: name})
+		if err != nil {
+			t.Fatalf("Failed to create space %s: %v", name, err)
+		}
+		if err := dbService.InitializeSpaceDatabase(sp.ID, sp.Path); err != nil {
+			t.Fatalf("Failed to initialize space database: %v", err)
+		}
+		for captureID, notePath := range captures {
+			if err := dbService.LinkNote(sp.ID, sp.Path, captureID, notePath, "", nil); err != nil {
+				t.Fatalf("Failed to link note: %v", err)
+			}
+		}
+		return sp
+	}
+
+	garden := link("Garden", map[string]string{shared: sharedPath, single: singlePath})
+	farm := link("Farm", map[string]string{shared: sharedPath, other: otherPath})
+
+	results, truncated, err := service.SearchVaultCaptures(ctx, "default", "COMPOST", 0)
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(results) != 2 || truncated {
+		t.Fatalf("Expected 2 matching captures, got %+v", results)
+	}
+
+	// Ordered by path: captures/shared.md before captures/single.md
+	sharedResult := results[0]
+	if sharedResult.CaptureID != shared || sharedResult.Snippet != "The Compost pile is warm." {
+		t.Errorf("Expected the shared capture with its matching line, got %+v", sharedResult)
+	}
+	linked := map[string]bool{}
+	for _, l := range sharedResult.Spaces {
+		linked[l.SpaceID] = true
+	}
+	if len(sharedResult.Spaces) != 2 || !linked[garden.ID] || !linked[farm.ID] {
+		t.Errorf("Expected the shared capture to list both spaces, got %+v", sharedResult.Spaces)
+	}
+	if results[1].CaptureID != single || len(results[1].Spaces) != 1 {
+		t.Errorf("Expected the single capture in one space, got %+v", results[1])
+	}
+
+	t.Run("Limit", func(t *testing.T) {
+		results, truncated, err := service.SearchVaultCaptures(ctx, "default", "compost", 1)
+		if err != nil {
+			t.Fatalf("Failed to search: %v", err)
+		}
+		if len(results) != 1 || results[0].CaptureID != shared || !truncated {
+			t.Errorf("Expected only the first match and truncated, got %+v (truncated %v)", results, truncated)
+		}
+
+		_, truncated, err = service.SearchVaultCaptures(ctx, "default", "compost", 2)
+		if err != nil {
+			t.Fatalf("Failed to search: %v", err)
+		}
+		if truncated {
+			t.Errorf("Expected a limit equal to the matches not to be truncated")
+		}
+	})
+
+	t.Run("SkipsBrokenSpace", func(t *testing.T) {
+		broken, err := service.Create(ctx, "default", space.CreateSpaceParams{Name: "Broken"})
+		if err != nil {
+			t.Fatalf("Failed to create space: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(broken.Path, "space.sqlite"), []byte("not a database"), 0644); err != nil {
+			t.Fatalf("Failed to corrupt space database: %v", err)
+		}
+		defer service.Delete(ctx, broken.ID)
+
+		results, _, err := service.SearchVaultCaptures(ctx, "default", "compost", 0)
+		if err != nil {
+			t.Fatalf("Expected the broken space to be skipped, got %v", err)
+		}
+		if len(results) != 2 {
+			t.Errorf("Expected the other spaces' 2 matches, got %+v", results)
+		}
+	})
+
+	t.Run("EmptyQuery", func(t *testing.T) {
+		_, _, err := service.SearchVaultCaptures(ctx, "default", "  ", 0)
+		var validationErr *domain.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error, got %v", err)
+		}
+	})
+}
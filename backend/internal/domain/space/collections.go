@@ -0,0 +1,463 @@
+package space
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// maxCollectionNameLength caps collection names, in runes
+const maxCollectionNameLength = 100
+
+// Collection is a named, ordered list of a space's notes, like a playlist.
+// A note can be in any number of collections.
+type Collection struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	NoteCount int       `json:"note_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// validateCollectionName trims a collection name and checks its length
+func validateCollectionName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", domain.NewValidationError("name", "is required")
+	}
+	if utf8.RuneCountInString(name) > maxCollectionNameLength {
+		return "", domain.NewValidationError("name", fmt.Sprintf("must be at most %d characters", maxCollectionNameLength))
+	}
+	return name, nil
+}
+
+// CreateCollection creates an empty collection. Names are unique within a
+// space.
+func (s *SpaceDatabaseService) CreateCollection(spacePath, name string) (*Collection, error) {
+	name, err := validateCollectionName(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkWritable(spacePath); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	id := uuid.New().String()
+	if err := checkCollectionName(db, name, id); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	_, err = db.Exec("INSERT INTO collections (id, name, created_at, updated_at) VALUES (?, ?, ?, ?)", id, name, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create collection: %w", err)
+	}
+
+	return s.GetCollection(spacePath, id)
+}
+
+// GetCollection returns a collection by ID
+func (s *SpaceDatabaseService) GetCollection(spacePath, collectionID string) (*Collection, error) {
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return nil, domain.NewNotFoundError("collection", collectionID)
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	collection, err := getCollection(db, collectionID)
+	if err == sql.ErrNoRows {
+		return nil, domain.NewNotFoundError("collection", collectionID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection: %w", err)
+	}
+
+	return collection, nil
+}
+
+// ListCollections returns a space's collections ordered by name
+func (s *SpaceDatabaseService) ListCollections(spacePath string) ([]Collection, error) {
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return []Collection{}, nil
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query(collectionSelect + " GROUP BY c.id ORDER BY c.name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collections: %w", err)
+	}
+	defer rows.Close()
+
+	collections := []Collection{}
+	for rows.Next() {
+		collection, err := scanCollection(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan collection: %w", err)
+		}
+		collections = append(collections, *collection)
+	}
+
+	return collections, rows.Err()
+}
+
+// RenameCollection changes a collection's name
+func (s *SpaceDatabaseService) RenameCollection(spacePath, collectionID, name string) (*Collection, error) {
+	name, err := validateCollectionName(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkWritable(spacePath); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	if err := checkCollectionName(db, name, collectionID); err != nil {
+		return nil, err
+	}
+
+	result, err := db.Exec("UPDATE collections SET name = ?, updated_at = ? WHERE id = ?", name, time.Now().Unix(), collectionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename collection: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return nil, domain.NewNotFoundError("collection", collectionID)
+	}
+
+	return s.GetCollection(spacePath, collectionID)
+}
+
+// DeleteCollection removes a collection. Its notes stay linked to the space.
+func (s *SpaceDatabaseService) DeleteCollection(spacePath, collectionID string) error {
+	if err := s.checkWritable(spacePath); err != nil {
+		return err
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		return fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin collection delete: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM collections WHERE id = ?", collectionID)
+	if err != nil {
+		return fmt.Errorf("failed to delete collection: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return domain.NewNotFoundError("collection", collectionID)
+	}
+	if _, err := tx.Exec("DELETE FROM collection_items WHERE collection_id = ?", collectionID); err != nil {
+		return fmt.Errorf("failed to delete collection items: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit collection delete: %w", err)
+	}
+	return nil
+}
+
+// AddToCollection appends notes to the end of a collection in the order
+// given. Every ID must be linked to the space; notes already in the
+// collection keep their place.
+func (s *SpaceDatabaseService) AddToCollection(spacePath, collectionID string, captureIDs []string) (*Collection, error) {
+	if len(captureIDs) == 0 {
+		return nil, domain.NewValidationError("capture_ids", "is required")
+	}
+	if err := s.checkWritable(spacePath); err != nil {
+		return nil, err
+	}
+	if err := s.requireLinked(spacePath, captureIDs); err != nil {
+		return nil, err
+	}
+
+	return s.updateCollectionItems(spacePath, collectionID, func(tx *sql.Tx, current []string) error {
+		inCollection := make(map[string]bool, len(current))
+		for _, id := range current {
+			inCollection[id] = true
+		}
+
+		now := time.Now().Unix()
+		position := len(current)
+		for _, id := range captureIDs {
+			if inCollection[id] {
+				continue
+			}
+			inCollection[id] = true
+			if _, err := tx.Exec("INSERT INTO collection_items (collection_id, capture_id, position, added_at) VALUES (?, ?, ?, ?)", collectionID, id, position, now); err != nil {
+				return fmt.Errorf("failed to add note to collection: %w", err)
+			}
+			position++
+		}
+		return nil
+	})
+}
+
+// RemoveFromCollection takes a note out of a collection, closing the gap it
+// leaves. The note stays linked to the space.
+func (s *SpaceDatabaseService) RemoveFromCollection(spacePath, collectionID, captureID string) (*Collection, error) {
+	if err := s.checkWritable(spacePath); err != nil {
+		return nil, err
+	}
+
+	return s.updateCollectionItems(spacePath, collectionID, func(tx *sql.Tx, current []string) error {
+		found := false
+		for _, id := range current {
+			if id == captureID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return domain.NewNotFoundError("collection note", captureID)
+		}
+
+		if _, err := tx.Exec("DELETE FROM collection_items WHERE collection_id = ? AND capture_id = ?", collectionID, captureID); err != nil {
+			return fmt.Errorf("failed to remove note from collection: %w", err)
+		}
+		return renumberCollection(tx, collectionID)
+	})
+}
+
+// ReorderCollection sets the order of a collection's notes. captureIDs must
+// list every note in the collection exactly once.
+func (s *SpaceDatabaseService) ReorderCollection(spacePath, collectionID string, captureIDs []string) (*Collection, error) {
+	if err := s.checkWritable(spacePath); err != nil {
+		return nil, err
+	}
+
+	return s.updateCollectionItems(spacePath, collectionID, func(tx *sql.Tx, current []string) error {
+		remaining := make(map[string]bool, len(current))
+		for _, id := range current {
+			remaining[id] = true
+		}
+		for _, id := range captureIDs {
+			if !remaining[id] {
+				return domain.NewValidationError("capture_ids", fmt.Sprintf("%q is not in the collection or is listed more than once", id))
+			}
+			delete(remaining, id)
+		}
+		if len(remaining) > 0 {
+			return domain.NewValidationError("capture_ids", "must list every note in the collection")
+		}
+
+		for position, id := range captureIDs {
+			if _, err := tx.Exec("UPDATE collection_items SET position = ? WHERE collection_id = ? AND capture_id = ?", position, collectionID, id); err != nil {
+				return fmt.Errorf("failed to reorder collection: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetCollectionNotes returns a collection's notes in their curated order
+func (s *SpaceDatabaseService) GetCollectionNotes(spacePath, collectionID string) ([]RelevantNote, error) {
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return nil, domain.NewNotFoundError("collection", collectionID)
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	if _, err := getCollection(db, collectionID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.NewNotFoundError("collection", collectionID)
+		}
+		return nil, fmt.Errorf("failed to get collection: %w", err)
+	}
+
+	ids, err := collectionItemIDs(db, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetNotesByIDs(spacePath, ids)
+}
+
+// updateCollectionItems runs update in a transaction with the collection's
+// current capture IDs in order, then bumps the collection's updated_at and
+// returns it
+func (s *SpaceDatabaseService) updateCollectionItems(spacePath, collectionID string, update func(tx *sql.Tx, current []string) error) (*Collection, error) {
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin collection update: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE collections SET updated_at = ? WHERE id = ?", time.Now().Unix(), collectionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update collection: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return nil, domain.NewNotFoundError("collection", collectionID)
+	}
+
+	current, err := collectionItemIDs(tx, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := update(tx, current); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit collection update: %w", err)
+	}
+
+	return s.GetCollection(spacePath, collectionID)
+}
+
+// requireLinked checks that every capture ID is listed once and linked to the space
+func (s *SpaceDatabaseService) requireLinked(spacePath string, captureIDs []string) error {
+	seen := make(map[string]bool, len(captureIDs))
+	for _, id := range captureIDs {
+		if seen[id] {
+			return domain.NewValidationError("capture_ids", fmt.Sprintf("%q is listed more than once", id))
+		}
+		seen[id] = true
+	}
+
+	linked, err := s.GetNotesByIDs(spacePath, captureIDs)
+	if err != nil {
+		return err
+	}
+	if len(linked) == len(captureIDs) {
+		return nil
+	}
+	for _, note := range linked {
+		delete(seen, note.CaptureID)
+	}
+	for _, id := range captureIDs {
+		if seen[id] {
+			return domain.NewValidationError("capture_ids", fmt.Sprintf("%q is not linked to this space", id))
+		}
+	}
+	return nil
+}
+
+// checkCollectionName returns a ConflictError if a collection other than
+// collectionID already has name
+func checkCollectionName(db *sql.DB, name, collectionID string) error {
+	var existing string
+	err := db.QueryRow("SELECT id FROM collections WHERE name = ? AND id != ?", name, collectionID).Scan(&existing)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check collection name: %w", err)
+	}
+	return domain.NewConflictError("collection", fmt.Sprintf("a collection named %q already exists", name))
+}
+
+// renumberCollection closes gaps in a collection's positions, keeping the order
+func renumberCollection(tx *sql.Tx, collectionID string) error {
+	ids, err := collectionItemIDs(tx, collectionID)
+	if err != nil {
+		return err
+	}
+	for position, id := range ids {
+		if _, err := tx.Exec("UPDATE collection_items SET position = ? WHERE collection_id = ? AND capture_id = ?", position, collectionID, id); err != nil {
+			return fmt.Errorf("failed to renumber collection: %w", err)
+		}
+	}
+	return nil
+}
+
+// collectionQuerier is the part of *sql.DB and *sql.Tx the collection reads need
+type collectionQuerier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// collectionItemIDs reads a collection's capture IDs in order
+func collectionItemIDs(q collectionQuerier, collectionID string) ([]string, error) {
+	rows, err := q.Query("SELECT capture_id FROM collection_items WHERE collection_id = ? ORDER BY position", collectionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collection notes: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan collection note: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// collectionSelect reads collections with their note counts; callers add
+// WHERE/GROUP BY/ORDER BY
+const collectionSelect = `
+	SELECT c.id, c.name, COUNT(i.capture_id), c.created_at, c.updated_at
+	FROM collections c
+	LEFT JOIN collection_items i ON i.collection_id = c.id
+`
+
+// getCollection reads one collection, returning sql.ErrNoRows if it does not exist
+func getCollection(q collectionQuerier, collectionID string) (*Collection, error) {
+	return scanCollection(q.QueryRow(collectionSelect+" WHERE c.id = ? GROUP BY c.id", collectionID))
+}
+
+// scanCollection reads a row selected by collectionSelect
+func scanCollection(row rowScanner) (*Collection, error) {
+	var collection Collection
+	var createdAt, updatedAt int64
+
+	if err := row.Scan(&collection.ID, &collection.Name, &collection.NoteCount, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+
+	collection.CreatedAt = time.Unix(createdAt, 0)
+	collection.UpdatedAt = time.Unix(updatedAt, 0)
+	return &collection, nil
+}
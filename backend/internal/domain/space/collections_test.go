@@ -0,0 +1,140 @@
+package space_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestCollections(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	for _, id := range []string{"a", "b", "c"} {
+		if err := service.LinkNote(spaceID, spacePath, id, "captures/"+id+".md", "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	captureIDs := func(notes []space.RelevantNote) []string {
+		ids := []string{}
+		for _, note := range notes {
+			ids = append(ids, note.CaptureID)
+		}
+		return ids
+	}
+	expectOrder := func(collectionID string, want ...string) {
+		t.Helper()
+		notes, err := service.GetCollectionNotes(spacePath, collectionID)
+		if err != nil {
+			t.Fatalf("Failed to get collection notes: %v", err)
+		}
+		got := captureIDs(notes)
+		if len(got) != len(want) {
+			t.Fatalf("Expected %v, got %v", want, got)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("Expected %v, got %v", want, got)
+			}
+		}
+	}
+
+	reading, err := service.CreateCollection(spacePath, " Reading list ")
+	if err != nil {
+		t.Fatalf("Failed to create collection: %v", err)
+	}
+	if reading.Name != "Reading list" || reading.NoteCount != 0 {
+		t.Errorf("Expected an empty, trimmed collection, got %+v", reading)
+	}
+
+	if _, err := service.AddToCollection(spacePath, reading.ID, []string{"c", "a"}); err != nil {
+		t.Fatalf("Failed to add notes: %v", err)
+	}
+	updated, err := service.AddToCollection(spacePath, reading.ID, []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Failed to add notes: %v", err)
+	}
+	if updated.NoteCount != 3 {
+		t.Errorf("Expected 3 notes, got %d", updated.NoteCount)
+	}
+	expectOrder(reading.ID, "c", "a", "b")
+
+	t.Run("Reorder", func(t *testing.T) {
+		if _, err := service.ReorderCollection(spacePath, reading.ID, []string{"b", "c", "a"}); err != nil {
+			t.Fatalf("Failed to reorder: %v", err)
+		}
+		expectOrder(reading.ID, "b", "c", "a")
+
+		var validationErr *domain.ValidationError
+		if _, err := service.ReorderCollection(spacePath, reading.ID, []string{"b", "c"}); !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error for a partial order, got %v", err)
+		}
+		if _, err := service.ReorderCollection(spacePath, reading.ID, []string{"b", "b", "a"}); !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error for a repeated note, got %v", err)
+		}
+	})
+
+	t.Run("InSeveralCollections", func(t *testing.T) {
+		later, err := service.CreateCollection(spacePath, "Later")
+		if err != nil {
+			t.Fatalf("Failed to create collection: %v", err)
+		}
+		if _, err := service.AddToCollection(spacePath, later.ID, []string{"a"}); err != nil {
+			t.Fatalf("Failed to add note: %v", err)
+		}
+		expectOrder(later.ID, "a")
+
+		collections, err := service.ListCollections(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to list collections: %v", err)
+		}
+		if len(collections) != 2 || collections[0].Name != "Later" || collections[1].NoteCount != 3 {
+			t.Errorf("Expected both collections by name with counts, got %+v", collections)
+		}
+	})
+
+	t.Run("UnlinkedNoteRejected", func(t *testing.T) {
+		var validationErr *domain.ValidationError
+		if _, err := service.AddToCollection(spacePath, reading.ID, []string{"missing"}); !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error, got %v", err)
+		}
+	})
+
+	t.Run("DuplicateName", func(t *testing.T) {
+		var conflictErr *domain.ConflictError
+		if _, err := service.CreateCollection(spacePath, "Reading list"); !errors.As(err, &conflictErr) {
+			t.Errorf("Expected conflict error, got %v", err)
+		}
+	})
+
+	t.Run("RemoveAndUnlink", func(t *testing.T) {
+		if _, err := service.RemoveFromCollection(spacePath, reading.ID, "c"); err != nil {
+			t.Fatalf("Failed to remove note: %v", err)
+		}
+		expectOrder(reading.ID, "b", "a")
+
+		if err := service.UnlinkNote(spacePath, "b"); err != nil {
+			t.Fatalf("Failed to unlink note: %v", err)
+		}
+		expectOrder(reading.ID, "a")
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if err := service.DeleteCollection(spacePath, reading.ID); err != nil {
+			t.Fatalf("Failed to delete collection: %v", err)
+		}
+		var notFoundErr *domain.NotFoundError
+		if _, err := service.GetCollectionNotes(spacePath, reading.ID); !errors.As(err, &notFoundErr) {
+			t.Errorf("Expected not found error, got %v", err)
+		}
+		if _, err := service.GetNoteByID(spacePath, "a"); err != nil {
+			t.Errorf("Expected notes to stay linked after the collection is deleted: %v", err)
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package space
+
+import (
+	"strings"
+)
+
+// maxContentStatsFiles caps how many capture files one content stats request reads
+const maxContentStatsFiles = 1000
+
+// NoteContentStats summarizes the content of a space's linked notes. It is
+// computed from the capture files, so it is only included on request.
+type NoteContentStats struct {
+	TotalWords   int     `json:"total_words"`
+	AverageWords float64 `json:"average_words"` // Per note that could be read
+	FilesScanned int     `json:"files_scanned"`
+	FilesMissing int     `json:"files_missing"`
+	Truncated    bool    `json:"truncated"` // More notes were linked than were scanned
+}
+
+// GetContentStats counts the words in the space's linked notes, reading at
+// most maxContentStatsFiles capture files. Missing or unreadable files are
+// counted in FilesMissing and left out of the average.
+func (s *SpaceDatabaseService) GetContentStats(spacePath string) (*NoteContentStats, error) {
+	notes, err := s.GetRelevantNotes(spacePath, NoteFilters{})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &NoteContentStats{}
+	if len(notes) > maxContentStatsFiles {
+		notes = notes[:maxContentStatsFiles]
+		stats.Truncated = true
+	}
+
+	for _, note := range notes {
+		fullPath, err := s.ResolveNoteFile(spacePath, note.NotePath)
+		if err != nil {
+			stats.FilesMissing++
+			continue
+		}
+
+		content, err := s.ReadCapture(fullPath)
+		if err != nil {
+			stats.FilesMissing++
+			continue
+		}
+
+		stats.FilesScanned++
+		stats.TotalWords += len(strings.Fields(string(content)))
+	}
+
+	if stats.FilesScanned > 0 {
+		stats.AverageWords = float64(stats.TotalWords) / float64(stats.FilesScanned)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,47 @@
+package space_test
+
+import (
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestGetContentStats(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	files := map[string]string{
+		"short.md": "one two three",
+		"long.md":  "the quick brown fox\njumps over the lazy dog",
+	}
+	for filename, content := range files {
+		captureID, notePath := createNamedCapture(t, parachuteRoot, filename, content)
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+	if err := service.LinkNote(spaceID, spacePath, "gone", "captures/gone.md", "", nil); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	stats, err := service.GetContentStats(spacePath)
+	if err != nil {
+		t.Fatalf("Failed to get content stats: %v", err)
+	}
+
+	if stats.TotalWords != 12 {
+		t.Errorf("Expected 12 words, got %d", stats.TotalWords)
+	}
+	if stats.AverageWords != 6 {
+		t.Errorf("Expected an average of 6 words, got %v", stats.AverageWords)
+	}
+	if stats.FilesScanned != 2 || stats.FilesMissing != 1 {
+		t.Errorf("Expected 2 scanned and 1 missing, got %d and %d", stats.FilesScanned, stats.FilesMissing)
+	}
+	if stats.Truncated {
+		t.Error("Expected stats not to be truncated")
+	}
+}
@@ -0,0 +1,154 @@
+package space
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// VariableSpec describes one SPACE.md variable, for editors offering
+// autocomplete
+type VariableSpec struct {
+	Name          string `json:"name"` // Referenced as {{name}}, or {{name:PARAM}} when parameterized
+	Description   string `json:"description"`
+	Parameterized bool   `json:"parameterized"`
+	Parameter     string `json:"parameter,omitempty"` // Placeholder for the argument, e.g. "TAG"
+	Example       string `json:"example"`             // A complete reference, braces included
+}
+
+// variableSpecs is every variable ResolveVariables supports, in the order
+// they are resolved. New variables must be added here to be allowed.
+var variableSpecs = []VariableSpec{
+	{Name: "note_count", Description: "Total number of linked notes", Example: "{{note_count}}"},
+	{Name: "untagged_count", Description: "Number of linked notes without tags", Example: "{{untagged_count}}"},
+	{Name: "notes_from_web", Description: "Number of linked notes clipped from the web (with a source URL)", Example: "{{notes_from_web}}"},
+	{Name: "recent_tags", Description: "Top 5 most used tags from the last 30 days", Example: "{{recent_tags}}"},
+	{Name: "recent_notes", Description: "Last 5 notes (title + date), ordered per the recent_notes_order setting", Example: "{{recent_notes}}"},
+	{Name: "featured_notes", Description: "Hand-picked featured notes (title + date), in their curated order", Example: "{{featured_notes}}"},
+	{Name: "notes_tagged", Description: "Count of notes with a specific tag", Parameterized: true, Parameter: "TAG", Example: "{{notes_tagged:research}}"},
+	{Name: "notes_with_status", Description: "Notes with a workflow status (title + date), most recently linked first", Parameterized: true, Parameter: "STATUS", Example: "{{notes_with_status:in_progress}}"},
+	{Name: "notes_due", Description: "Unfinished notes due within a window, including overdue ones, soonest first", Parameterized: true, Parameter: "WINDOW", Example: "{{notes_due:7d}}"},
+	{Name: "active_reminders", Description: "Reminders whose time has come and that have not been dismissed, oldest first", Example: "{{active_reminders}}"},
+	{Name: "priority_notes", Description: "Notes given a positive priority (title + priority), highest first", Example: "{{priority_notes}}"},
+	{Name: "space_age", Description: "Time since the space was created (e.g. \"3 days\")", Example: "{{space_age}}"},
+	{Name: "last_activity", Description: "Time since a note was last linked or referenced, or \"never\"", Example: "{{last_activity}}"},
+	{Name: "injected_notes", Description: "Full content of recently linked notes with their space context", Example: "{{injected_notes}}"},
+}
+
+// ContextVariables lists the SPACE.md variables ResolveVariables supports.
+// Parameterized variables such as {{notes_tagged:TAG}} are named without
+// their argument.
+var ContextVariables = func() []string {
+	names := make([]string, len(variableSpecs))
+	for i, spec := range variableSpecs {
+		names[i] = spec.Name
+	}
+	return names
+}()
+
+// VariablePreview is one variable referenced by a SPACE.md template
+type VariablePreview struct {
+	Variable string `json:"variable"` // The reference without braces, e.g. "notes_tagged:soil"
+	Name     string `json:"name"`     // The variable name, e.g. "notes_tagged"
+	Allowed  bool   `json:"allowed"`
+	Value    string `json:"value,omitempty"` // Resolved value; empty when disallowed
+}
+
+// SetAllowedVariables restricts which variables ResolveVariables expands;
+// disallowed variables are left in the text unresolved. A nil list allows
+// every variable, which is the default. Empty names are skipped, so a list
+// with none left allows no variables. Unknown names are rejected, leaving
+// the previous allowlist in place.
+func (s *ContextService) SetAllowedVariables(names []string) error {
+	if names == nil {
+		s.allowedVariables = nil
+		return nil
+	}
+
+	allowed := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !isContextVariable(name) {
+			return domain.NewValidationError("variables", fmt.Sprintf("unknown context variable: %s", name))
+		}
+		allowed[name] = true
+	}
+	s.allowedVariables = allowed
+	return nil
+}
+
+// ListSupportedVariables describes every SPACE.md variable this server can
+// resolve, whether or not SetAllowedVariables allows it
+func (s *ContextService) ListSupportedVariables() []VariableSpec {
+	specs := make([]VariableSpec, len(variableSpecs))
+	copy(specs, variableSpecs)
+	return specs
+}
+
+// variableAllowed reports whether the named variable may be expanded
+func (s *ContextService) variableAllowed(name string) bool {
+	return s.allowedVariables == nil || s.allowedVariables[name]
+}
+
+// isContextVariable reports whether name is one of ContextVariables
+func isContextVariable(name string) bool {
+	for _, v := range ContextVariables {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// PreviewVariables lists each distinct variable referenced in spaceMD, in
+// order of first use, with whether it is allowed and its resolved value.
+// Conditional block tags are not variables and are skipped.
+func (s *ContextService) PreviewVariables(spaceMD, spacePath string) ([]VariablePreview, error) {
+	previews := []VariablePreview{}
+	seen := make(map[string]bool)
+	var refs []string
+	var allowed []int // Indexes into previews of the allowed variables, in refs order
+
+	for _, ref := range variablePattern.FindAllString(spaceMD, -1) {
+		variable := ref[2 : len(ref)-2]
+		if strings.HasPrefix(variable, "#") || strings.HasPrefix(variable, "/") || seen[variable] {
+			continue
+		}
+		seen[variable] = true
+
+		name, _, _ := strings.Cut(variable, ":")
+		preview := VariablePreview{
+			Variable: variable,
+			Name:     name,
+			Allowed:  s.variableAllowed(name),
+		}
+		if preview.Allowed {
+			refs = append(refs, ref)
+			allowed = append(allowed, len(previews))
+		}
+		previews = append(previews, preview)
+	}
+
+	values, err := s.ResolveVariablesBatch(refs, spacePath)
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range values {
+		previews[allowed[i]].Value = value
+	}
+
+	return previews, nil
+}
+
+// PreviewSpaceVariables runs PreviewVariables on a space's SPACE.md
+func (s *ContextService) PreviewSpaceVariables(space *Space) ([]VariablePreview, error) {
+	spaceMD, err := readSpaceContextFile(space.Path)
+	if err != nil {
+		return nil, err
+	}
+	return s.PreviewVariables(spaceMD, space.Path)
+}
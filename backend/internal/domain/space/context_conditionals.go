@@ -0,0 +1,102 @@
+package space
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+)
+
+// conditionalOpenPattern matches the opening tag of a conditional block,
+// {{#if_notes}} or {{#if_tagged:TAG}}
+var conditionalOpenPattern = regexp.MustCompile(`\{\{#(if_notes|if_tagged:[^{}]+)\}\}`)
+
+// resolveConditionals keeps or removes {{#NAME}}...{{/NAME}} blocks:
+// - {{#if_notes}} - The space has at least one linked note
+// - {{#if_tagged:TAG}} - At least one note has TAG
+//
+// A kept block loses only its tags; a removed block disappears entirely. Tags
+// on a line of their own take their line break with them, so removing a block
+// leaves no blank line behind. An opening tag without a matching close is left
+// as-is. Blocks may nest as long as nested blocks have different names.
+func (s *ContextService) resolveConditionals(text string, db *sql.DB) string {
+	var out strings.Builder
+	for {
+		loc := conditionalOpenPattern.FindStringSubmatchIndex(text)
+		if loc == nil {
+			out.WriteString(text)
+			return out.String()
+		}
+
+		name := text[loc[2]:loc[3]]
+		closeTag := "{{/" + name + "}}"
+		closeStart := strings.Index(text[loc[1]:], closeTag)
+		if closeStart < 0 {
+			// Unmatched opening tag: leave it and keep scanning after it
+			out.WriteString(text[:loc[1]])
+			text = text[loc[1]:]
+			continue
+		}
+		closeStart += loc[1]
+		closeEnd := closeStart + len(closeTag)
+
+		openStart, bodyStart := trimTagLine(text, loc[0], loc[1])
+		bodyEnd, afterClose := trimTagLine(text, closeStart, closeEnd)
+		if bodyEnd < bodyStart {
+			bodyEnd = bodyStart
+		}
+
+		out.WriteString(text[:openStart])
+		rest := text[afterClose:]
+		if s.conditionHolds(name, db) {
+			// Re-scan the body so nested blocks are resolved too
+			text = text[bodyStart:bodyEnd] + rest
+		} else {
+			text = rest
+		}
+	}
+}
+
+// trimTagLine widens the tag at text[start:end] to its whole line (including
+// the line break) when nothing else is on that line
+func trimTagLine(text string, start, end int) (int, int) {
+	lineStart := strings.LastIndex(text[:start], "\n") + 1
+	if strings.TrimSpace(text[lineStart:start]) != "" {
+		return start, end
+	}
+
+	lineEnd := strings.Index(text[end:], "\n")
+	if lineEnd < 0 {
+		lineEnd = len(text) - end
+	}
+	if strings.TrimSpace(text[end:end+lineEnd]) != "" {
+		return start, end
+	}
+
+	afterLine := end + lineEnd
+	if afterLine < len(text) {
+		afterLine++
+	}
+	return lineStart, afterLine
+}
+
+// taggedCondition matches relevant_notes rows carrying exactly the tag bound to
+// its placeholder (a substring match would let "farm" match "farming")
+const taggedCondition = "EXISTS (SELECT 1 FROM json_each(CASE WHEN json_valid(tags) THEN tags END) WHERE value = ?)"
+
+// conditionHolds evaluates a conditional block's condition. Query errors count
+// as false, so blocks about notes are hidden when the database is unreadable.
+func (s *ContextService) conditionHolds(name string, db *sql.DB) bool {
+	var count int
+	var err error
+
+	if tag, ok := strings.CutPrefix(name, "if_tagged:"); ok {
+		err = db.QueryRow(`
+			SELECT COUNT(*) FROM relevant_notes
+			WHERE `+taggedCondition+` AND `+unexpiredCondition+`
+		`, strings.TrimSpace(tag)).Scan(&count)
+	} else {
+		err = db.QueryRow("SELECT COUNT(*) FROM relevant_notes WHERE " + unexpiredCondition).Scan(&count)
+	}
+
+	return err == nil && count > 0
+}
@@ -0,0 +1,67 @@
+package space
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// DefaultInjectedNoteDelimiter introduces each note in {{injected_notes}}
+// unless the space's injected_note_delimiter setting says otherwise
+const DefaultInjectedNoteDelimiter = "### {filename}"
+
+// injectedNotePlaceholders are the placeholders a note delimiter may use
+var injectedNotePlaceholders = map[string]func(note RelevantNote) string{
+	"filename":   func(note RelevantNote) string { return filepath.Base(note.NotePath) },
+	"note_path":  func(note RelevantNote) string { return note.NotePath },
+	"capture_id": func(note RelevantNote) string { return note.CaptureID },
+	"tags":       func(note RelevantNote) string { return strings.Join(note.Tags, ", ") },
+	"linked_at":  func(note RelevantNote) string { return note.LinkedAt.Format("2006-01-02") },
+}
+
+// placeholderPattern matches a {placeholder} in a note delimiter
+var placeholderPattern = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// validateNoteDelimiter checks that a delimiter is a single line whose braces
+// only enclose known placeholders
+func validateNoteDelimiter(delimiter string) error {
+	if strings.ContainsAny(delimiter, "\r\n") {
+		return domain.NewValidationError(SettingInjectedNoteDelimiter, "must be a single line")
+	}
+
+	for _, match := range placeholderPattern.FindAllStringSubmatch(delimiter, -1) {
+		if _, ok := injectedNotePlaceholders[match[1]]; !ok {
+			return domain.NewValidationError(SettingInjectedNoteDelimiter, fmt.Sprintf("unknown placeholder {%s}; use %s", match[1], placeholderNames()))
+		}
+	}
+
+	if rest := placeholderPattern.ReplaceAllString(delimiter, ""); strings.ContainsAny(rest, "{}") {
+		return domain.NewValidationError(SettingInjectedNoteDelimiter, "has an unmatched brace")
+	}
+
+	return nil
+}
+
+// renderNoteDelimiter fills in a validated delimiter's placeholders for a note
+func renderNoteDelimiter(delimiter string, note RelevantNote) string {
+	return placeholderPattern.ReplaceAllStringFunc(delimiter, func(match string) string {
+		if value, ok := injectedNotePlaceholders[match[1:len(match)-1]]; ok {
+			return value(note)
+		}
+		return match
+	})
+}
+
+// placeholderNames lists the note delimiter placeholders for error messages
+func placeholderNames() string {
+	names := make([]string, 0, len(injectedNotePlaceholders))
+	for name := range injectedNotePlaceholders {
+		names = append(names, "{"+name+"}")
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,63 @@
+package space_test
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestInjectedNoteDelimiter(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(dbService)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	captureID, notePath := createNamedCapture(t, parachuteRoot, "soil.md", "Cover crops matter.\n")
+	if err := dbService.LinkNote(spaceID, spacePath, captureID, notePath, "", []string{"soil", "farming"}); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	t.Run("DefaultWhenUnset", func(t *testing.T) {
+		injected, err := contextService.BuildInjectedContext(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to build injected context: %v", err)
+		}
+		if !strings.HasPrefix(injected, "### soil.md\n") {
+			t.Errorf("Expected the default heading, got:\n%s", injected)
+		}
+	})
+
+	t.Run("Custom", func(t *testing.T) {
+		if err := dbService.SetSetting(spacePath, space.SettingInjectedNoteDelimiter, "=== {filename} [{tags}] ==="); err != nil {
+			t.Fatalf("Failed to set delimiter: %v", err)
+		}
+		defer dbService.SetSetting(spacePath, space.SettingInjectedNoteDelimiter, "")
+
+		injected, err := contextService.BuildInjectedContext(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to build injected context: %v", err)
+		}
+		if !strings.HasPrefix(injected, "=== "+filepath.Base(notePath)+" [soil, farming] ===\n") {
+			t.Errorf("Expected the custom delimiter before the note, got:\n%s", injected)
+		}
+		if !strings.Contains(injected, "Cover crops matter.") {
+			t.Errorf("Expected the note content after the delimiter, got:\n%s", injected)
+		}
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		for _, delimiter := range []string{"== {title} ==", "== {filename ==", "first\nsecond"} {
+			err := dbService.SetSetting(spacePath, space.SettingInjectedNoteDelimiter, delimiter)
+			var validationErr *domain.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Errorf("Expected ValidationError for %q, got %v", delimiter, err)
+			}
+		}
+	})
+}
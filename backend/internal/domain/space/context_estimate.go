@@ -0,0 +1,95 @@
+package space
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// ModelWindow describes the context window of an agent model and the
+// heuristic used to turn characters into an approximate token count
+type ModelWindow struct {
+	ContextTokens int     `json:"context_tokens"`
+	CharsPerToken float64 `json:"chars_per_token"`
+}
+
+// defaultCharsPerToken is the common rule of thumb for English prose
+const defaultCharsPerToken = 4.0
+
+// DefaultModelWindows returns the built-in model window table.
+// A fresh map is returned each time so callers may modify it freely.
+func DefaultModelWindows() map[string]ModelWindow {
+	return map[string]ModelWindow{
+		"claude-opus-4-1":   {ContextTokens: 200000, CharsPerToken: 3.5},
+		"claude-sonnet-4-5": {ContextTokens: 200000, CharsPerToken: 3.5},
+		"claude-haiku-4-5":  {ContextTokens: 200000, CharsPerToken: 3.5},
+		"gpt-4o":            {ContextTokens: 128000, CharsPerToken: defaultCharsPerToken},
+		"gpt-4o-mini":       {ContextTokens: 128000, CharsPerToken: defaultCharsPerToken},
+		"llama-3-8b":        {ContextTokens: 8192, CharsPerToken: defaultCharsPerToken},
+	}
+}
+
+// TokenEstimate is the estimated size of a space's rendered context for a model
+type TokenEstimate struct {
+	Model           string `json:"model"`
+	Characters      int    `json:"characters"`
+	EstimatedTokens int    `json:"estimated_tokens"`
+	ContextWindow   int    `json:"context_window"`
+	RemainingTokens int    `json:"remaining_tokens"`
+	Fits            bool   `json:"fits"`
+}
+
+// SetModelWindows replaces the model window table used for estimates
+func (s *ContextService) SetModelWindows(windows map[string]ModelWindow) {
+	s.modelWindows = make(map[string]ModelWindow, len(windows))
+	for model, window := range windows {
+		s.modelWindows[model] = window
+	}
+}
+
+// ModelWindows returns the names of all models with a known context window
+func (s *ContextService) ModelWindows() []string {
+	models := make([]string, 0, len(s.modelWindows))
+	for model := range s.modelWindows {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	return models
+}
+
+// EstimateContextTokens renders a space's context and estimates how many
+// tokens it will consume in the given model's context window
+func (s *ContextService) EstimateContextTokens(space *Space, model string) (TokenEstimate, error) {
+	if model == "" {
+		return TokenEstimate{}, domain.NewValidationError("model", "model is required")
+	}
+
+	window, ok := s.modelWindows[model]
+	if !ok {
+		return TokenEstimate{}, domain.NewValidationError("model", fmt.Sprintf("unknown model: %s", model))
+	}
+
+	rendered, err := s.RenderSpaceMD(space)
+	if err != nil {
+		return TokenEstimate{}, fmt.Errorf("failed to render space context: %w", err)
+	}
+
+	charsPerToken := window.CharsPerToken
+	if charsPerToken <= 0 {
+		charsPerToken = defaultCharsPerToken
+	}
+
+	chars := utf8.RuneCountInString(rendered)
+	tokens := int(float64(chars)/charsPerToken + 0.5)
+
+	return TokenEstimate{
+		Model:           model,
+		Characters:      chars,
+		EstimatedTokens: tokens,
+		ContextWindow:   window.ContextTokens,
+		RemainingTokens: window.ContextTokens - tokens,
+		Fits:            tokens <= window.ContextTokens,
+	}, nil
+}
@@ -0,0 +1,90 @@
+package space_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestEstimateContextTokens(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(dbService)
+	contextService.SetModelWindows(map[string]space.ModelWindow{
+		"tiny-model":  {ContextTokens: 100, CharsPerToken: 4},
+		"large-model": {ContextTokens: 100000, CharsPerToken: 4},
+	})
+
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+	spaceObj := &space.Space{ID: spaceID, Path: spacePath}
+
+	writeSpaceMD := func(t *testing.T, content string) {
+		if err := os.WriteFile(filepath.Join(spacePath, "SPACE.md"), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write SPACE.md: %v", err)
+		}
+	}
+
+	t.Run("SmallSpaceFitsBothModels", func(t *testing.T) {
+		// 40 characters after resolving {{note_count}} -> "0"
+		writeSpaceMD(t, "# Small\n\nNotes: {{note_count}}\n"+strings.Repeat("x", 22))
+
+		for _, model := range []string{"tiny-model", "large-model"} {
+			estimate, err := contextService.EstimateContextTokens(spaceObj, model)
+			if err != nil {
+				t.Fatalf("Failed to estimate for %s: %v", model, err)
+			}
+			if estimate.Characters != 40 {
+				t.Errorf("Expected 40 characters (variables resolved), got %d", estimate.Characters)
+			}
+			if estimate.EstimatedTokens != 10 {
+				t.Errorf("Expected 10 tokens, got %d", estimate.EstimatedTokens)
+			}
+			if !estimate.Fits {
+				t.Errorf("Expected small space to fit %s", model)
+			}
+		}
+	})
+
+	t.Run("LargeSpaceOverflowsSmallWindow", func(t *testing.T) {
+		writeSpaceMD(t, strings.Repeat("word ", 2000)) // 10,000 chars
+
+		tiny, err := contextService.EstimateContextTokens(spaceObj, "tiny-model")
+		if err != nil {
+			t.Fatalf("Failed to estimate: %v", err)
+		}
+		if tiny.Fits {
+			t.Error("Expected large space not to fit the tiny model window")
+		}
+		if tiny.EstimatedTokens != 2500 {
+			t.Errorf("Expected 2500 tokens, got %d", tiny.EstimatedTokens)
+		}
+		if tiny.RemainingTokens != 100-2500 {
+			t.Errorf("Expected negative remaining tokens, got %d", tiny.RemainingTokens)
+		}
+
+		large, err := contextService.EstimateContextTokens(spaceObj, "large-model")
+		if err != nil {
+			t.Fatalf("Failed to estimate: %v", err)
+		}
+		if !large.Fits {
+			t.Error("Expected large space to fit the large model window")
+		}
+	})
+
+	t.Run("UnknownModel", func(t *testing.T) {
+		if _, err := contextService.EstimateContextTokens(spaceObj, "no-such-model"); err == nil {
+			t.Error("Expected error for unknown model")
+		}
+	})
+
+	t.Run("DefaultTableHasModels", func(t *testing.T) {
+		if len(space.DefaultModelWindows()) == 0 {
+			t.Error("Expected default model window table to be populated")
+		}
+	})
+}
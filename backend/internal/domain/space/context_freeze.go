@@ -0,0 +1,154 @@
+package space
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// space_metadata keys holding a frozen context snapshot
+const (
+	frozenContextKey   = "frozen_context"
+	frozenContextAtKey = "frozen_context_at"
+)
+
+// FrozenContext is a rendered SPACE.md pinned with FreezeContext
+type FrozenContext struct {
+	Content  string    `json:"content"`
+	FrozenAt time.Time `json:"frozen_at"`
+}
+
+// FreezeContext renders the space's SPACE.md and pins the result: until
+// UnfreezeContext is called, RenderSpaceMD returns this snapshot instead of
+// resolving variables again. Freezing an already frozen space replaces the
+// snapshot with a fresh rendering.
+func (s *ContextService) FreezeContext(spacePath string) error {
+	spaceMD, err := readSpaceContextFile(spacePath)
+	if err != nil {
+		return err
+	}
+
+	rendered := ""
+	if spaceMD != "" {
+		if rendered, err = s.ResolveVariables(spaceMD, spacePath); err != nil {
+			return err
+		}
+	}
+
+	return s.spaceDBService.SetFrozenContext(spacePath, FrozenContext{
+		Content:  rendered,
+		FrozenAt: time.Now(),
+	})
+}
+
+// UnfreezeContext drops the space's frozen snapshot so RenderSpaceMD resolves
+// variables live again
+func (s *ContextService) UnfreezeContext(spacePath string) error {
+	return s.spaceDBService.ClearFrozenContext(spacePath)
+}
+
+// SetFrozenContext stores a frozen context snapshot for the space
+func (s *SpaceDatabaseService) SetFrozenContext(spacePath string, frozen FrozenContext) error {
+	if err := s.checkWritable(spacePath); err != nil {
+		return err
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin freeze: %w", err)
+	}
+	defer tx.Rollback()
+
+	for key, value := range map[string]string{
+		frozenContextKey:   frozen.Content,
+		frozenContextAtKey: strconv.FormatInt(frozen.FrozenAt.Unix(), 10),
+	} {
+		_, err := tx.Exec(`
+			INSERT INTO space_metadata (key, value) VALUES (?, ?)
+			ON CONFLICT(key) DO UPDATE SET value = excluded.value
+		`, key, value)
+		if err != nil {
+			return fmt.Errorf("failed to freeze context: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit freeze: %w", err)
+	}
+
+	return nil
+}
+
+// ClearFrozenContext removes the space's frozen snapshot, if any. The
+// context version is bumped since the rendered context may change.
+func (s *SpaceDatabaseService) ClearFrozenContext(spacePath string) error {
+	if err := s.checkWritable(spacePath); err != nil {
+		return err
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	result, err := db.Exec("DELETE FROM space_metadata WHERE key IN (?, ?)", frozenContextKey, frozenContextAtKey)
+	if err != nil {
+		return fmt.Errorf("failed to unfreeze context: %w", err)
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return nil
+	}
+
+	return bumpContextVersion(db)
+}
+
+// GetFrozenContext returns the space's frozen snapshot, or nil when the
+// context is not frozen
+func (s *SpaceDatabaseService) GetFrozenContext(spacePath string) (*FrozenContext, error) {
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	var content string
+	err = db.QueryRow("SELECT value FROM space_metadata WHERE key = ?", frozenContextKey).Scan(&content)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get frozen context: %w", err)
+	}
+
+	frozen := &FrozenContext{Content: content}
+
+	var frozenAt string
+	if err := db.QueryRow("SELECT value FROM space_metadata WHERE key = ?", frozenContextAtKey).Scan(&frozenAt); err == nil {
+		if unix, err := strconv.ParseInt(frozenAt, 10, 64); err == nil {
+			frozen.FrozenAt = time.Unix(unix, 0)
+		}
+	}
+
+	return frozen, nil
+}
@@ -0,0 +1,80 @@
+package space_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestFreezeContext(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(dbService)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+	spaceObj := &space.Space{ID: spaceID, Path: spacePath}
+
+	if err := os.WriteFile(filepath.Join(spacePath, "SPACE.md"), []byte("Notes: {{note_count}}"), 0644); err != nil {
+		t.Fatalf("Failed to write SPACE.md: %v", err)
+	}
+
+	link := func(t *testing.T, content string) {
+		captureID, notePath := createMockCapture(t, parachuteRoot, content)
+		if err := dbService.LinkNote(spaceID, spacePath, captureID, notePath, "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+	render := func(t *testing.T) string {
+		rendered, err := contextService.RenderSpaceMD(spaceObj)
+		if err != nil {
+			t.Fatalf("Failed to render: %v", err)
+		}
+		return rendered
+	}
+
+	link(t, "First")
+
+	t.Run("CapturesCurrentResolution", func(t *testing.T) {
+		if err := contextService.FreezeContext(spacePath); err != nil {
+			t.Fatalf("Failed to freeze context: %v", err)
+		}
+
+		frozen, err := dbService.GetFrozenContext(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to get frozen context: %v", err)
+		}
+		if frozen == nil || frozen.Content != "Notes: 1" {
+			t.Fatalf("Expected frozen content %q, got %+v", "Notes: 1", frozen)
+		}
+		if frozen.FrozenAt.IsZero() {
+			t.Error("Expected frozen_at to be set")
+		}
+	})
+
+	t.Run("IgnoresNoteChanges", func(t *testing.T) {
+		link(t, "Second")
+		if got := render(t); got != "Notes: 1" {
+			t.Errorf("Expected frozen output %q, got %q", "Notes: 1", got)
+		}
+	})
+
+	t.Run("UnfreezeResumesLiveRendering", func(t *testing.T) {
+		if err := contextService.UnfreezeContext(spacePath); err != nil {
+			t.Fatalf("Failed to unfreeze context: %v", err)
+		}
+		if got := render(t); got != "Notes: 2" {
+			t.Errorf("Expected live output %q, got %q", "Notes: 2", got)
+		}
+
+		frozen, err := dbService.GetFrozenContext(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to get frozen context: %v", err)
+		}
+		if frozen != nil {
+			t.Errorf("Expected no frozen context, got %+v", frozen)
+		}
+	})
+}
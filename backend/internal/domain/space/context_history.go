@@ -0,0 +1,126 @@
+package space
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// contextHistoryLimit caps how many rendered contexts are kept per space
+const contextHistoryLimit = 50
+
+// variablePattern matches a {{variable}} reference in SPACE.md
+var variablePattern = regexp.MustCompile(`\{\{[^{}]+\}\}`)
+
+// ContextHistoryEntry is one audited rendering of a space's SPACE.md
+type ContextHistoryEntry struct {
+	ID         int64             `json:"id"`
+	RenderedAt time.Time         `json:"rendered_at"`
+	Content    string            `json:"content"`
+	Variables  map[string]string `json:"variables"` // Resolved value of each variable in the template
+}
+
+// RecordContextHistory stores a rendered context and prunes the oldest entries
+// beyond the history cap
+func (s *SpaceDatabaseService) RecordContextHistory(spacePath string, entry ContextHistoryEntry) error {
+	if err := s.checkWritable(spacePath); err != nil {
+		return err
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	variablesJSON, err := json.Marshal(entry.Variables)
+	if err != nil {
+		return fmt.Errorf("failed to marshal variables: %w", err)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO context_history (rendered_at, content, variables)
+		VALUES (?, ?, ?)
+	`, entry.RenderedAt.Unix(), entry.Content, string(variablesJSON))
+	if err != nil {
+		return fmt.Errorf("failed to record context history: %w", err)
+	}
+
+	_, err = db.Exec(`
+		DELETE FROM context_history WHERE id NOT IN (
+			SELECT id FROM context_history ORDER BY id DESC LIMIT ?
+		)
+	`, contextHistoryLimit)
+	if err != nil {
+		return fmt.Errorf("failed to prune context history: %w", err)
+	}
+
+	return nil
+}
+
+// GetContextHistory returns audited renderings, newest first
+func (s *SpaceDatabaseService) GetContextHistory(spacePath string, limit int) ([]ContextHistoryEntry, error) {
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	// Check if database exists
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return []ContextHistoryEntry{}, nil
+	}
+
+	if limit <= 0 || limit > contextHistoryLimit {
+		limit = contextHistoryLimit
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT id, rendered_at, content, variables
+		FROM context_history
+		ORDER BY id DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query context history: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []ContextHistoryEntry{}
+	for rows.Next() {
+		var entry ContextHistoryEntry
+		var renderedAt int64
+		var variablesJSON sql.NullString
+
+		if err := rows.Scan(&entry.ID, &renderedAt, &entry.Content, &variablesJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan context history: %w", err)
+		}
+
+		entry.RenderedAt = time.Unix(renderedAt, 0)
+		if variablesJSON.Valid && variablesJSON.String != "" {
+			json.Unmarshal([]byte(variablesJSON.String), &entry.Variables)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// auditRender records a rendered context and the values its variables
+// resolved to in the space's history. Auditing never fails the render itself.
+func (s *ContextService) auditRender(rendered string, variables map[string]string, spacePath string) {
+	_ = s.spaceDBService.RecordContextHistory(spacePath, ContextHistoryEntry{
+		RenderedAt: time.Now(),
+		Content:    rendered,
+		Variables:  variables,
+	})
+}
@@ -0,0 +1,137 @@
+package space_test
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestContextHistory(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(dbService)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+	spaceObj := &space.Space{ID: spaceID, Path: spacePath}
+
+	spaceMD := "# Farm\n\nNotes: {{note_count}}\nSoil: {{notes_tagged:soil}}\n"
+	if err := os.WriteFile(filepath.Join(spacePath, "SPACE.md"), []byte(spaceMD), 0644); err != nil {
+		t.Fatalf("Failed to write SPACE.md: %v", err)
+	}
+
+	captureID, notePath := createMockCapture(t, parachuteRoot, "Soil note")
+	if err := dbService.LinkNote(spaceID, spacePath, captureID, notePath, "", []string{"soil"}); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	t.Run("OffByDefault", func(t *testing.T) {
+		if _, err := contextService.RenderSpaceMD(spaceObj); err != nil {
+			t.Fatalf("Failed to render: %v", err)
+		}
+
+		history, err := dbService.GetContextHistory(spacePath, 0)
+		if err != nil {
+			t.Fatalf("Failed to get history: %v", err)
+		}
+		if len(history) != 0 {
+			t.Errorf("Expected no history with auditing off, got %d entries", len(history))
+		}
+	})
+
+	if err := dbService.SetSetting(spacePath, space.SettingContextAudit, "true"); err != nil {
+		t.Fatalf("Failed to enable auditing: %v", err)
+	}
+
+	t.Run("RenderRecordsEntry", func(t *testing.T) {
+		rendered, err := contextService.RenderSpaceMD(spaceObj)
+		if err != nil {
+			t.Fatalf("Failed to render: %v", err)
+		}
+
+		history, err := dbService.GetContextHistory(spacePath, 0)
+		if err != nil {
+			t.Fatalf("Failed to get history: %v", err)
+		}
+		if len(history) != 1 {
+			t.Fatalf("Expected 1 history entry, got %d", len(history))
+		}
+
+		entry := history[0]
+		if entry.Content != rendered {
+			t.Errorf("Expected entry to hold the rendered context, got:\n%s", entry.Content)
+		}
+		if entry.Variables["note_count"] != "1" {
+			t.Errorf("Expected note_count 1, got %q", entry.Variables["note_count"])
+		}
+		if entry.Variables["notes_tagged:soil"] != "1" {
+			t.Errorf("Expected notes_tagged:soil 1, got %q", entry.Variables["notes_tagged:soil"])
+		}
+		if entry.RenderedAt.IsZero() {
+			t.Error("Expected a render timestamp")
+		}
+	})
+
+	t.Run("ResolvesOnce", func(t *testing.T) {
+		opens := 0
+		restore := space.SetOpenContextDB(func(dbPath string) (*sql.DB, error) {
+			opens++
+			return sql.Open("sqlite", dbPath)
+		})
+		defer restore()
+
+		if _, err := contextService.RenderSpaceMD(spaceObj); err != nil {
+			t.Fatalf("Failed to render: %v", err)
+		}
+		if opens != 1 {
+			t.Errorf("Expected the variables to be resolved in one pass, got %d database opens", opens)
+		}
+	})
+
+	t.Run("SkipsRemovedBlocks", func(t *testing.T) {
+		conditionalMD := "Notes: {{note_count}}\n{{#if_tagged:fishing}}Fish: {{notes_tagged:fishing}}{{/if_tagged:fishing}}\n"
+		if err := os.WriteFile(filepath.Join(spacePath, "SPACE.md"), []byte(conditionalMD), 0644); err != nil {
+			t.Fatalf("Failed to write SPACE.md: %v", err)
+		}
+		defer os.WriteFile(filepath.Join(spacePath, "SPACE.md"), []byte(spaceMD), 0644)
+
+		if _, err := contextService.RenderSpaceMD(spaceObj); err != nil {
+			t.Fatalf("Failed to render: %v", err)
+		}
+
+		history, err := dbService.GetContextHistory(spacePath, 1)
+		if err != nil {
+			t.Fatalf("Failed to get history: %v", err)
+		}
+		if len(history) != 1 {
+			t.Fatalf("Expected a history entry, got %d", len(history))
+		}
+		if _, ok := history[0].Variables["notes_tagged:fishing"]; ok {
+			t.Errorf("Expected a variable in a removed block to be left out, got %v", history[0].Variables)
+		}
+		if history[0].Variables["note_count"] != "1" {
+			t.Errorf("Expected note_count 1, got %v", history[0].Variables)
+		}
+	})
+
+	t.Run("HistoryIsCapped", func(t *testing.T) {
+		for i := 0; i < 55; i++ {
+			contextService.RenderSpaceMD(spaceObj)
+		}
+
+		history, err := dbService.GetContextHistory(spacePath, 0)
+		if err != nil {
+			t.Fatalf("Failed to get history: %v", err)
+		}
+		if len(history) != 50 {
+			t.Errorf("Expected history capped at 50, got %d", len(history))
+		}
+		if !strings.Contains(history[0].Content, "Notes: 1") {
+			t.Errorf("Expected newest entry first, got:\n%s", history[0].Content)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package space
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Codes of the notices ContextNotices reports
+const (
+	NoticeNoContextFile = "no_context_file" // No SPACE.md (or legacy agents.md/CLAUDE.md)
+	NoticeNoNotes       = "no_notes"        // No notes are linked to the space
+)
+
+// ContextNotice describes a degraded state of a space's context for the UI to
+// show. Notices are informational; the context still renders.
+type ContextNotice struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// spaceContextFiles are the context file names readSpaceContextFile tries
+var spaceContextFiles = []string{"SPACE.md", "agents.md", "CLAUDE.md"}
+
+// ContextNotices reports why a space's rendered context may be sparse: a
+// missing context file, or no linked notes for variables to draw on. A space
+// with both returns no notices.
+func (s *ContextService) ContextNotices(space *Space) ([]ContextNotice, error) {
+	notices := []ContextNotice{}
+
+	hasContextFile := false
+	for _, name := range spaceContextFiles {
+		if _, err := os.Stat(filepath.Join(space.Path, name)); err == nil {
+			hasContextFile = true
+			break
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to check space context file: %w", err)
+		}
+	}
+	if !hasContextFile {
+		notices = append(notices, ContextNotice{
+			Code:    NoticeNoContextFile,
+			Message: "This space has no SPACE.md; agents get no space context",
+		})
+	}
+
+	count, err := countLinkedNotes(space.Path)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		notices = append(notices, ContextNotice{
+			Code:    NoticeNoNotes,
+			Message: "This space has no linked notes; context will be sparse",
+		})
+	}
+
+	return notices, nil
+}
+
+// countLinkedNotes returns how many unexpired notes are linked to a space,
+// zero if it has no database yet
+func countLinkedNotes(spacePath string) (int, error) {
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return 0, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM relevant_notes WHERE " + unexpiredCondition).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count notes: %w", err)
+	}
+	return count, nil
+}
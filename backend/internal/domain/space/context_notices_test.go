@@ -0,0 +1,82 @@
+package space_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestContextNotices(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(service)
+
+	codes := func(spacePath string) []string {
+		notices, err := contextService.ContextNotices(&space.Space{Path: spacePath})
+		if err != nil {
+			t.Fatalf("Failed to get notices: %v", err)
+		}
+		codes := make([]string, len(notices))
+		for i, notice := range notices {
+			if notice.Message == "" {
+				t.Errorf("Expected notice %s to have a message", notice.Code)
+			}
+			codes[i] = notice.Code
+		}
+		return codes
+	}
+
+	t.Run("EmptySpace", func(t *testing.T) {
+		_, spacePath := setupTestSpace(t, parachuteRoot)
+		os.WriteFile(filepath.Join(spacePath, "SPACE.md"), []byte("# Empty\n"), 0644)
+
+		if got := codes(spacePath); len(got) != 1 || got[0] != space.NoticeNoNotes {
+			t.Errorf("Expected only a no notes notice, got %v", got)
+		}
+	})
+
+	t.Run("MissingContextFile", func(t *testing.T) {
+		spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+		if err := service.LinkNote(spaceID, spacePath, "walk", "captures/walk.md", "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+
+		if got := codes(spacePath); len(got) != 1 || got[0] != space.NoticeNoContextFile {
+			t.Errorf("Expected only a no context file notice, got %v", got)
+		}
+
+		// Legacy context files count
+		os.WriteFile(filepath.Join(spacePath, "CLAUDE.md"), []byte("# Legacy\n"), 0644)
+		if got := codes(spacePath); len(got) != 0 {
+			t.Errorf("Expected CLAUDE.md to count as a context file, got %v", got)
+		}
+	})
+
+	t.Run("NoDatabase", func(t *testing.T) {
+		spacePath := filepath.Join(parachuteRoot, "spaces", "bare")
+		os.MkdirAll(spacePath, 0755)
+
+		if got := codes(spacePath); len(got) != 2 {
+			t.Errorf("Expected both notices, got %v", got)
+		}
+		if _, err := os.Stat(filepath.Join(spacePath, "space.sqlite")); !os.IsNotExist(err) {
+			t.Error("Expected checking notices not to create space.sqlite")
+		}
+	})
+
+	t.Run("PopulatedSpace", func(t *testing.T) {
+		spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+		os.WriteFile(filepath.Join(spacePath, "SPACE.md"), []byte("Notes: {{note_count}}\n"), 0644)
+		if err := service.LinkNote(spaceID, spacePath, "soil", "captures/soil.md", "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+
+		if got := codes(spacePath); len(got) != 0 {
+			t.Errorf("Expected no notices, got %v", got)
+		}
+	})
+}
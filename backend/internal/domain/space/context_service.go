@@ -3,9 +3,11 @@ package space
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 )
@@ -13,59 +15,243 @@ import (
 // ContextService handles dynamic variable resolution for SPACE.md context files
 type ContextService struct {
 	spaceDBService *SpaceDatabaseService
+	modelWindows   map[string]ModelWindow
+	suggester      ContextSuggester
+
+	// allowedVariables restricts which variables are expanded; nil allows all
+	allowedVariables map[string]bool
 }
 
 // NewContextService creates a new context service
 func NewContextService(spaceDBService *SpaceDatabaseService) *ContextService {
 	return &ContextService{
 		spaceDBService: spaceDBService,
+		modelWindows:   DefaultModelWindows(),
+		suggester:      OpeningTextSuggester{},
+	}
+}
+
+// RenderSpaceMD reads a space's SPACE.md and resolves its dynamic variables.
+// This is the context an agent actually receives for the space. While the
+// context is frozen (see FreezeContext) the frozen snapshot is returned as is.
+// Either way the space's pinned context, if any, is added (see
+// applyPinnedContext).
+func (s *ContextService) RenderSpaceMD(space *Space) (string, error) {
+	frozen, err := s.spaceDBService.GetFrozenContext(space.Path)
+	if err != nil {
+		return "", err
+	}
+	if frozen != nil {
+		return s.applyPinnedContext(frozen.Content, space.Path), nil
+	}
+
+	spaceMD, err := readSpaceContextFile(space.Path)
+	if err != nil {
+		return "", err
 	}
+	if spaceMD == "" {
+		return s.applyPinnedContext("", space.Path), nil
+	}
+
+	// With auditing on, the variable values are recorded as they are resolved
+	var variables map[string]string
+	audit := s.spaceDBService.boolSetting(space.Path, SettingContextAudit)
+	if audit {
+		variables = make(map[string]string)
+	}
+
+	rendered, err := s.resolveVariables(spaceMD, space.Path, variables)
+	if err != nil {
+		return "", err
+	}
+
+	if audit {
+		s.auditRender(rendered, variables, space.Path)
+	}
+
+	return s.applyPinnedContext(rendered, space.Path), nil
 }
 
 // ResolveVariables processes a SPACE.md template and replaces dynamic variables
 // Supported variables:
 // - {{note_count}} - Total number of linked notes
+// - {{untagged_count}} - Number of linked notes without tags
+// - {{notes_from_web}} - Number of linked notes clipped from the web (with a source URL)
 // - {{recent_tags}} - Top 5 most used tags (last 30 days)
-// - {{recent_notes}} - Last 5 referenced notes (title + date)
+// - {{recent_notes}} - Last 5 notes (title + date), ordered per the recent_notes_order setting
+// - {{featured_notes}} - Notes picked with SetFeaturedNotes (title + date), in their curated order
 // - {{notes_tagged:TAG}} - Count of notes with specific tag
+// - {{notes_with_status:STATUS}} - Notes with a workflow status (title + date), most recently linked first
+// - {{notes_due:WINDOW}} - Unfinished notes due within WINDOW (e.g. 7d), including overdue ones, soonest first
+// - {{active_reminders}} - Reminders that are due and not dismissed (text + note), oldest first
+// - {{priority_notes}} - Notes given a positive priority (title + priority), highest first
+// - {{space_age}} - Time since the space was created (e.g. "3 days")
+// - {{last_activity}} - Time since a note was last linked or referenced (e.g. "2 hours ago"), or "never"
+// - {{injected_notes}} - Full content of recently linked notes with their space context
+//
+// Variables not allowed by SetAllowedVariables are left unresolved.
+//
+// Conditional blocks are resolved before variables:
+// - {{#if_notes}}...{{/if_notes}} - Kept only when the space has notes
+// - {{#if_tagged:TAG}}...{{/if_tagged:TAG}} - Kept only when a note has TAG
 func (s *ContextService) ResolveVariables(spaceMD string, spacePath string) (string, error) {
-	result := spaceMD
+	return s.resolveVariables(spaceMD, spacePath, nil)
+}
 
+// resolveVariables implements ResolveVariables, adding the value of each
+// variable it resolves to record unless record is nil
+func (s *ContextService) resolveVariables(spaceMD string, spacePath string, record map[string]string) (string, error) {
 	// Get space database connection
 	dbPath := filepath.Join(spacePath, "space.sqlite")
-	db, err := sql.Open("sqlite", dbPath)
+	db, err := openContextDB(dbPath)
 	if err != nil {
 		// If database doesn't exist yet, return template as-is
 		return spaceMD, nil
 	}
 	defer db.Close()
 
-	// Replace {{note_count}}
-	result = s.replaceNoteCount(result, db)
+	// Keep or remove {{#if_...}} blocks
+	result := s.resolveConditionals(spaceMD, db)
 
-	// Replace {{recent_tags}}
-	result = s.replaceRecentTags(result, db)
+	return s.replaceVariables(result, db, spacePath, record), nil
+}
 
-	// Replace {{recent_notes}}
-	result = s.replaceRecentNotes(result, db, spacePath)
+// openContextDB opens the space database variables are resolved against. It
+// is a variable so tests can count the connections made.
+var openContextDB = func(dbPath string) (*sql.DB, error) {
+	return sql.Open("sqlite", dbPath)
+}
 
-	// Replace {{notes_tagged:TAG}} patterns
-	result = s.replaceNotesTagged(result, db)
+// batchSeparator joins the templates of a ResolveVariablesBatch call. The
+// closing braces keep variable patterns from matching across templates.
+const batchSeparator = "\x00}}\x00"
 
-	return result, nil
+// ResolveVariablesBatch resolves several templates against the same space,
+// e.g. a base prompt, SPACE.md and an addendum, with one database connection.
+// Conditional blocks are resolved per template, then the templates are
+// resolved together so each variable is computed once however many of them
+// use it. The result is the same as calling ResolveVariables on each.
+func (s *ContextService) ResolveVariablesBatch(templates []string, spacePath string) ([]string, error) {
+	resolved := make([]string, len(templates))
+
+	db, err := openContextDB(filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		// If database doesn't exist yet, return templates as-is
+		copy(resolved, templates)
+		return resolved, nil
+	}
+	defer db.Close()
+
+	separate := false
+	for i, template := range templates {
+		resolved[i] = s.resolveConditionals(template, db)
+		separate = separate || strings.Contains(template, batchSeparator)
+	}
+
+	if !separate {
+		joined := strings.Split(s.replaceVariables(strings.Join(resolved, batchSeparator), db, spacePath, nil), batchSeparator)
+		if len(joined) == len(resolved) {
+			return joined, nil
+		}
+	}
+
+	// A template or a resolved value contained the separator; resolve them one by one
+	for i := range resolved {
+		resolved[i] = s.replaceVariables(resolved[i], db, spacePath, nil)
+	}
+	return resolved, nil
+}
+
+// replaceVariables replaces each allowed variable in text; disallowed ones
+// stay as written. Unless record is nil, each distinct variable is resolved on
+// its own and its value added to record under the variable as written (e.g.
+// "notes_tagged:soil").
+func (s *ContextService) replaceVariables(text string, db *sql.DB, spacePath string, record map[string]string) string {
+	result := text
+	replacers := []struct {
+		name    string
+		replace func(string) string
+	}{
+		{"note_count", func(text string) string { return s.replaceNoteCount(text, db) }},
+		{"untagged_count", func(text string) string { return s.replaceUntaggedCount(text, db) }},
+		{"notes_from_web", func(text string) string { return s.replaceNotesFromWeb(text, db) }},
+		{"recent_tags", func(text string) string { return s.replaceRecentTags(text, db) }},
+		{"recent_notes", func(text string) string { return s.replaceRecentNotes(text, db, spacePath) }},
+		{"featured_notes", func(text string) string { return s.replaceFeaturedNotes(text, spacePath) }},
+		{"notes_tagged", func(text string) string { return s.replaceNotesTagged(text, db) }},
+		{"notes_with_status", func(text string) string { return s.replaceNotesWithStatus(text, db) }},
+		{"notes_due", func(text string) string { return s.replaceNotesDue(text, db) }},
+		{"active_reminders", func(text string) string { return s.replaceActiveReminders(text, db) }},
+		{"priority_notes", func(text string) string { return s.replacePriorityNotes(text, db) }},
+		{"space_age", func(text string) string { return s.replaceSpaceAge(text, db, spacePath) }},
+		{"last_activity", func(text string) string { return s.replaceLastActivity(text, spacePath) }},
+		{"injected_notes", func(text string) string { return s.replaceInjectedNotes(text, spacePath) }},
+	}
+	for _, r := range replacers {
+		if !s.variableAllowed(r.name) {
+			continue
+		}
+		if record == nil {
+			result = r.replace(result)
+			continue
+		}
+
+		for _, ref := range variablePattern.FindAllString(result, -1) {
+			variable := ref[2 : len(ref)-2]
+			if name, _, _ := strings.Cut(variable, ":"); name != r.name {
+				continue
+			}
+			if _, ok := record[variable]; ok {
+				continue
+			}
+
+			value := r.replace(ref)
+			if value == ref {
+				// Left unresolved, e.g. an invalid argument
+				continue
+			}
+			record[variable] = value
+			result = strings.ReplaceAll(result, ref, value)
+		}
+	}
+
+	return result
 }
 
 // replaceNoteCount replaces {{note_count}} with the total number of linked notes
 func (s *ContextService) replaceNoteCount(text string, db *sql.DB) string {
 	var count int
-	err := db.QueryRow("SELECT COUNT(*) FROM relevant_notes").Scan(&count)
+	err := db.QueryRow("SELECT COUNT(*) FROM relevant_notes WHERE " + unexpiredCondition).Scan(&count)
 	if err != nil {
 		return strings.ReplaceAll(text, "{{note_count}}", "0")
 	}
 	return strings.ReplaceAll(text, "{{note_count}}", fmt.Sprintf("%d", count))
 }
 
-// replaceRecentTags replaces {{recent_tags}} with top 5 most used tags from last 30 days
+// replaceUntaggedCount replaces {{untagged_count}} with the number of linked
+// notes without tags
+func (s *ContextService) replaceUntaggedCount(text string, db *sql.DB) string {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM relevant_notes WHERE " + untaggedCondition + " AND " + unexpiredCondition).Scan(&count)
+	if err != nil {
+		return strings.ReplaceAll(text, "{{untagged_count}}", "0")
+	}
+	return strings.ReplaceAll(text, "{{untagged_count}}", fmt.Sprintf("%d", count))
+}
+
+// replaceNotesFromWeb replaces {{notes_from_web}} with the number of linked
+// notes that have a source URL
+func (s *ContextService) replaceNotesFromWeb(text string, db *sql.DB) string {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM relevant_notes WHERE " + hasSourceCondition + " AND " + unexpiredCondition).Scan(&count)
+	if err != nil {
+		return strings.ReplaceAll(text, "{{notes_from_web}}", "0")
+	}
+	return strings.ReplaceAll(text, "{{notes_from_web}}", fmt.Sprintf("%d", count))
+}
+
+// replaceRecentTags replaces {{recent_tags}} with top 5 most used tags from last
+// 30 days. Tags used equally often are listed alphabetically.
 func (s *ContextService) replaceRecentTags(text string, db *sql.DB) string {
 	if !strings.Contains(text, "{{recent_tags}}") {
 		return text
@@ -76,7 +262,7 @@ func (s *ContextService) replaceRecentTags(text string, db *sql.DB) string {
 
 	rows, err := db.Query(`
 		SELECT tags FROM relevant_notes
-		WHERE linked_at >= ? OR last_referenced >= ?
+		WHERE (linked_at >= ? OR last_referenced >= ?) AND `+unexpiredCondition+`
 		ORDER BY COALESCE(last_referenced, linked_at) DESC
 	`, thirtyDaysAgo, thirtyDaysAgo)
 	if err != nil {
@@ -112,14 +298,14 @@ func (s *ContextService) replaceRecentTags(text string, db *sql.DB) string {
 		topTags = append(topTags, tagCount{tag, count})
 	}
 
-	// Sort by count (simple bubble sort for small data)
-	for i := 0; i < len(topTags); i++ {
-		for j := i + 1; j < len(topTags); j++ {
-			if topTags[j].count > topTags[i].count {
-				topTags[i], topTags[j] = topTags[j], topTags[i]
-			}
+	// Sort by count, breaking ties alphabetically so the same data always
+	// renders the same string (map iteration order is random)
+	sort.Slice(topTags, func(i, j int) bool {
+		if topTags[i].count != topTags[j].count {
+			return topTags[i].count > topTags[j].count
 		}
-	}
+		return topTags[i].tag < topTags[j].tag
+	})
 
 	// Take top 5
 	limit := 5
@@ -140,16 +326,26 @@ func (s *ContextService) replaceRecentTags(text string, db *sql.DB) string {
 	return strings.ReplaceAll(text, "{{recent_tags}}", strings.Join(tagNames, ", "))
 }
 
-// replaceRecentNotes replaces {{recent_notes}} with last 5 referenced notes
+// replaceRecentNotes replaces {{recent_notes}} with the last 5 notes. By default
+// ("referenced") notes are ordered by last reference, falling back to link
+// time for notes never referenced; "linked" orders strictly by link time.
 func (s *ContextService) replaceRecentNotes(text string, db *sql.DB, spacePath string) string {
 	if !strings.Contains(text, "{{recent_notes}}") {
 		return text
 	}
 
+	byLinked := s.spaceDBService.recentNotesOrder(spacePath) == RecentNotesOrderLinked
+
+	orderBy := "COALESCE(last_referenced, linked_at) DESC"
+	if byLinked {
+		orderBy = "linked_at DESC"
+	}
+
 	rows, err := db.Query(`
 		SELECT note_path, linked_at, last_referenced
 		FROM relevant_notes
-		ORDER BY COALESCE(last_referenced, linked_at) DESC
+		WHERE ` + unexpiredCondition + `
+		ORDER BY ` + orderBy + `
 		LIMIT 5
 	`)
 	if err != nil {
@@ -172,7 +368,7 @@ func (s *ContextService) replaceRecentNotes(text string, db *sql.DB, spacePath s
 
 		// Format date
 		var dateStr string
-		if lastReferenced.Valid {
+		if lastReferenced.Valid && !byLinked {
 			dateStr = time.Unix(lastReferenced.Int64, 0).Format("Jan 2")
 		} else {
 			dateStr = time.Unix(linkedAt, 0).Format("Jan 2")
@@ -188,6 +384,26 @@ func (s *ContextService) replaceRecentNotes(text string, db *sql.DB, spacePath s
 	return strings.ReplaceAll(text, "{{recent_notes}}", strings.Join(notes, "\n"))
 }
 
+// replaceFeaturedNotes replaces {{featured_notes}} with the space's featured
+// notes, in the order they were featured
+func (s *ContextService) replaceFeaturedNotes(text string, spacePath string) string {
+	if !strings.Contains(text, "{{featured_notes}}") {
+		return text
+	}
+
+	featured, err := s.spaceDBService.GetFeaturedNotes(spacePath)
+	if err != nil || len(featured) == 0 {
+		return strings.ReplaceAll(text, "{{featured_notes}}", "none")
+	}
+
+	notes := make([]string, 0, len(featured))
+	for _, note := range featured {
+		notes = append(notes, fmt.Sprintf("- %s (%s)", filepath.Base(note.NotePath), note.LinkedAt.Format("Jan 2")))
+	}
+
+	return strings.ReplaceAll(text, "{{featured_notes}}", strings.Join(notes, "\n"))
+}
+
 // replaceNotesTagged replaces {{notes_tagged:TAG}} patterns with counts
 func (s *ContextService) replaceNotesTagged(text string, db *sql.DB) string {
 	// Find all {{notes_tagged:TAG}} patterns
@@ -202,8 +418,8 @@ func (s *ContextService) replaceNotesTagged(text string, db *sql.DB) string {
 		var count int
 		err := db.QueryRow(`
 			SELECT COUNT(*) FROM relevant_notes
-			WHERE tags LIKE ?
-		`, "%\""+tag+"\"%").Scan(&count)
+			WHERE `+taggedCondition+` AND `+unexpiredCondition+`
+		`, tag).Scan(&count)
 
 		if err != nil {
 			text = strings.ReplaceAll(text, fullMatch, "0")
@@ -214,3 +430,167 @@ func (s *ContextService) replaceNotesTagged(text string, db *sql.DB) string {
 
 	return text
 }
+
+// notesWithStatusLimit caps how many notes {{notes_with_status:STATUS}} lists
+const notesWithStatusLimit = 20
+
+// notesWithStatusPattern matches {{notes_with_status:STATUS}}
+var notesWithStatusPattern = regexp.MustCompile(`\{\{notes_with_status:([^}]+)\}\}`)
+
+// replaceNotesWithStatus replaces {{notes_with_status:STATUS}} patterns with
+// the notes in that status
+func (s *ContextService) replaceNotesWithStatus(text string, db *sql.DB) string {
+	for _, match := range notesWithStatusPattern.FindAllStringSubmatch(text, -1) {
+		fullMatch := match[0]
+		status := strings.TrimSpace(match[1])
+
+		rows, err := db.Query(`
+			SELECT note_path, linked_at
+			FROM relevant_notes
+			WHERE status = ? AND `+unexpiredCondition+`
+			ORDER BY linked_at DESC
+			LIMIT ?
+		`, status, notesWithStatusLimit)
+		if err != nil {
+			text = strings.ReplaceAll(text, fullMatch, "none")
+			continue
+		}
+
+		var notes []string
+		for rows.Next() {
+			var notePath string
+			var linkedAt int64
+			if err := rows.Scan(&notePath, &linkedAt); err != nil {
+				continue
+			}
+			notes = append(notes, fmt.Sprintf("- %s (%s)", filepath.Base(notePath), time.Unix(linkedAt, 0).Format("Jan 2")))
+		}
+		rows.Close()
+
+		if len(notes) == 0 {
+			text = strings.ReplaceAll(text, fullMatch, "none")
+		} else {
+			text = strings.ReplaceAll(text, fullMatch, strings.Join(notes, "\n"))
+		}
+	}
+
+	return text
+}
+
+// notesDueLimit caps how many notes {{notes_due:WINDOW}} lists
+const notesDueLimit = 20
+
+// notesDuePattern matches {{notes_due:WINDOW}}
+var notesDuePattern = regexp.MustCompile(`\{\{notes_due:([^}]+)\}\}`)
+
+// replaceNotesDue replaces {{notes_due:WINDOW}} patterns with the notes due
+// before now+WINDOW. Overdue notes are included; done and archived notes and
+// notes without a due date are not.
+func (s *ContextService) replaceNotesDue(text string, db *sql.DB) string {
+	now := time.Now()
+
+	for _, match := range notesDuePattern.FindAllStringSubmatch(text, -1) {
+		fullMatch := match[0]
+
+		window, err := parseDueWindow(match[1])
+		if err != nil {
+			text = strings.ReplaceAll(text, fullMatch, "none")
+			continue
+		}
+
+		rows, err := db.Query(`
+			SELECT note_path, due_at
+			FROM relevant_notes
+			WHERE due_at IS NOT NULL AND due_at <= ? AND status NOT IN (?, ?) AND `+unexpiredCondition+`
+			ORDER BY due_at ASC
+			LIMIT ?
+		`, now.Add(window).Unix(), NoteStatusDone, NoteStatusArchived, notesDueLimit)
+		if err != nil {
+			text = strings.ReplaceAll(text, fullMatch, "none")
+			continue
+		}
+
+		var notes []string
+		for rows.Next() {
+			var notePath string
+			var dueAt int64
+			if err := rows.Scan(&notePath, &dueAt); err != nil {
+				continue
+			}
+			notes = append(notes, fmt.Sprintf("- %s (due %s)", filepath.Base(notePath), time.Unix(dueAt, 0).Format("Jan 2")))
+		}
+		rows.Close()
+
+		if len(notes) == 0 {
+			text = strings.ReplaceAll(text, fullMatch, "none")
+		} else {
+			text = strings.ReplaceAll(text, fullMatch, strings.Join(notes, "\n"))
+		}
+	}
+
+	return text
+}
+
+// injectedNotesLimit caps how many notes {{injected_notes}} inlines
+const injectedNotesLimit = 10
+
+// replaceInjectedNotes replaces {{injected_notes}} with the content of recently linked notes
+func (s *ContextService) replaceInjectedNotes(text string, spacePath string) string {
+	if !strings.Contains(text, "{{injected_notes}}") {
+		return text
+	}
+
+	injected, err := s.BuildInjectedContext(spacePath)
+	if err != nil || injected == "" {
+		return strings.ReplaceAll(text, "{{injected_notes}}", "none")
+	}
+
+	return strings.ReplaceAll(text, "{{injected_notes}}", injected)
+}
+
+// BuildInjectedContext renders the most recently linked notes as markdown for
+// injection into a prompt: each note's delimiter line (the space's
+// injected_note_delimiter setting) and space context (plain and structured),
+// followed by the full capture content
+func (s *ContextService) BuildInjectedContext(spacePath string) (string, error) {
+	notes, err := s.spaceDBService.GetRelevantNotes(spacePath, NoteFilters{Limit: injectedNotesLimit})
+	if err != nil {
+		return "", err
+	}
+
+	delimiter, err := s.spaceDBService.GetSetting(spacePath, SettingInjectedNoteDelimiter)
+	if err != nil {
+		delimiter = DefaultInjectedNoteDelimiter
+	}
+
+	var sections []string
+	for _, note := range notes {
+		var b strings.Builder
+
+		fmt.Fprintf(&b, "%s\n", renderNoteDelimiter(delimiter, note))
+		if len(note.Tags) > 0 {
+			fmt.Fprintf(&b, "Tags: %s\n", strings.Join(note.Tags, ", "))
+		}
+		if note.Context != "" {
+			fmt.Fprintf(&b, "Context: %s\n", note.Context)
+		}
+		b.WriteString(note.ContextStructured.Markdown())
+
+		notePath, err := s.spaceDBService.ResolveNoteFile(spacePath, note.NotePath)
+		var content []byte
+		if err == nil {
+			content, err = s.spaceDBService.ReadCapture(notePath)
+		}
+		if errors.Is(err, ErrCaptureTooLarge) {
+			b.WriteString("\n_(capture file too large to include)_\n")
+		} else if err != nil {
+			b.WriteString("\n_(capture file not found)_\n")
+		} else {
+			fmt.Fprintf(&b, "\n%s\n", strings.TrimSpace(string(content)))
+		}
+
+		sections = append(sections, b.String())
+	}
+
+	return strings.Join(sections, "\n---\n\n"), nil
+}
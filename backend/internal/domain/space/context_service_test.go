@@ -1,6 +1,7 @@
 package space_test
 
 import (
+	"database/sql"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -263,6 +264,65 @@ func TestResolveVariablesWithReferences(t *testing.T) {
 	})
 }
 
+func TestRecentNotesOrder(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(dbService)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	olderID, olderPath := createNamedCapture(t, parachuteRoot, "older.md", "Older")
+	newerID, newerPath := createNamedCapture(t, parachuteRoot, "newer.md", "Newer")
+	for _, n := range []struct{ id, path string }{{olderID, olderPath}, {newerID, newerPath}} {
+		if err := dbService.LinkNote(spaceID, spacePath, n.id, n.path, "Context", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	// Backdate the link of the older note, then reference it now
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to open space database: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("UPDATE relevant_notes SET linked_at = ? WHERE capture_id = ?", time.Now().Add(-48*time.Hour).Unix(), olderID); err != nil {
+		t.Fatalf("Failed to backdate note: %v", err)
+	}
+	if err := dbService.TrackNoteReference(spacePath, olderID); err != nil {
+		t.Fatalf("Failed to track reference: %v", err)
+	}
+
+	firstNote := func(t *testing.T) string {
+		result, err := contextService.ResolveVariables("{{recent_notes}}", spacePath)
+		if err != nil {
+			t.Fatalf("Failed to resolve variables: %v", err)
+		}
+		return strings.SplitN(result, "\n", 2)[0]
+	}
+
+	t.Run("ReferencedFirstByDefault", func(t *testing.T) {
+		if first := firstNote(t); !strings.Contains(first, "older.md") {
+			t.Errorf("Expected recently referenced note first, got %q", first)
+		}
+	})
+
+	t.Run("LinkedOrder", func(t *testing.T) {
+		if err := dbService.SetSetting(spacePath, space.SettingRecentNotesOrder, space.RecentNotesOrderLinked); err != nil {
+			t.Fatalf("Failed to set recent_notes_order: %v", err)
+		}
+		if first := firstNote(t); !strings.Contains(first, "newer.md") {
+			t.Errorf("Expected most recently linked note first, got %q", first)
+		}
+	})
+
+	t.Run("RejectsUnknownOrder", func(t *testing.T) {
+		if err := dbService.SetSetting(spacePath, space.SettingRecentNotesOrder, "alphabetical"); err == nil {
+			t.Error("Expected unknown recent_notes_order to be rejected")
+		}
+	})
+}
+
 func TestResolveVariablesWithManyTags(t *testing.T) {
 	parachuteRoot, cleanup := setupTestEnvironment(t)
 	defer cleanup()
@@ -307,6 +367,96 @@ func TestResolveVariablesWithManyTags(t *testing.T) {
 	})
 }
 
+func TestRecentTagsTieOrder(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(dbService)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	// zebra and apple are tied; soil is more frequent
+	tagSets := [][]string{{"zebra", "soil"}, {"apple", "soil"}, {"mango"}, {"mango"}, {"soil"}}
+	for _, tags := range tagSets {
+		captureID, notePath := createMockCapture(t, parachuteRoot, "Note")
+		if err := dbService.LinkNote(spaceID, spacePath, captureID, notePath, "", tags); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	want := "soil, mango, apple, zebra"
+	for i := 0; i < 20; i++ {
+		result, err := contextService.ResolveVariables("{{recent_tags}}", spacePath)
+		if err != nil {
+			t.Fatalf("Failed to resolve variables: %v", err)
+		}
+		if result != want {
+			t.Fatalf("Resolution %d: expected %q, got %q", i, want, result)
+		}
+	}
+}
+
+func TestConditionalBlocks(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(dbService)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	template := "# Garden\n{{#if_notes}}\n## Recent Activity\n{{note_count}} notes\n{{/if_notes}}\nEnd"
+
+	t.Run("StrippedWhenEmpty", func(t *testing.T) {
+		result, err := contextService.ResolveVariables(template, spacePath)
+		if err != nil {
+			t.Fatalf("Failed to resolve: %v", err)
+		}
+		if result != "# Garden\nEnd" {
+			t.Errorf("Expected block to be removed, got %q", result)
+		}
+	})
+
+	captureID, notePath := createMockCapture(t, parachuteRoot, "Note")
+	if err := dbService.LinkNote(spaceID, spacePath, captureID, notePath, "", []string{"farming"}); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	t.Run("IncludedWithNotes", func(t *testing.T) {
+		result, err := contextService.ResolveVariables(template, spacePath)
+		if err != nil {
+			t.Fatalf("Failed to resolve: %v", err)
+		}
+		if result != "# Garden\n## Recent Activity\n1 notes\nEnd" {
+			t.Errorf("Expected block content without its tags, got %q", result)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"TaggedHolds", "A{{#if_tagged:farming}} farm{{/if_tagged:farming}}B", "A farmB"},
+		{"TaggedFails", "A{{#if_tagged:fishing}} fish{{/if_tagged:fishing}}B", "AB"},
+		{"TaggedExactOnly", "A{{#if_tagged:farm%}} farm{{/if_tagged:farm%}}B", "AB"},
+		{"CountTaggedExactOnly", "{{notes_tagged:%}} {{notes_tagged:farming}}", "0 1"},
+		{"Nested", "{{#if_notes}}[{{#if_tagged:fishing}}fish{{/if_tagged:fishing}}]{{/if_notes}}", "[]"},
+		{"UnmatchedLeftAsIs", "{{#if_notes}} open", "{{#if_notes}} open"},
+		{"NoConditionals", "Count: {{note_count}}", "Count: 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := contextService.ResolveVariables(tt.template, spacePath)
+			if err != nil {
+				t.Fatalf("Failed to resolve: %v", err)
+			}
+			if result != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, result)
+			}
+		})
+	}
+}
+
 func TestResolveVariablesEdgeCases(t *testing.T) {
 	parachuteRoot, cleanup := setupTestEnvironment(t)
 	defer cleanup()
@@ -503,3 +653,251 @@ You are assisting with development of Parachute, a second brain app.
 		t.Logf("Resolved template:\n%s", result)
 	})
 }
+
+func TestTimelineVariables(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(dbService)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	template := "Age: {{space_age}}. Last activity: {{last_activity}}."
+
+	t.Run("FreshSpace", func(t *testing.T) {
+		result, err := contextService.ResolveVariables(template, spacePath)
+		if err != nil {
+			t.Fatalf("Failed to resolve: %v", err)
+		}
+		if result != "Age: less than a minute. Last activity: never." {
+			t.Errorf("Unexpected result: %q", result)
+		}
+	})
+
+	t.Run("WithNotes", func(t *testing.T) {
+		captureID, notePath := createMockCapture(t, parachuteRoot, "Note")
+		if err := dbService.LinkNote(spaceID, spacePath, captureID, notePath, "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+
+		db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+		if err != nil {
+			t.Fatalf("Failed to open space database: %v", err)
+		}
+		now := time.Now()
+		_, err = db.Exec("UPDATE relevant_notes SET linked_at = ?", now.Add(-3*time.Hour-time.Minute).Unix())
+		if err == nil {
+			_, err = db.Exec("UPDATE space_metadata SET value = ? WHERE key = 'created_at'", now.AddDate(0, 0, -10).Unix())
+		}
+		db.Close()
+		if err != nil {
+			t.Fatalf("Failed to backdate space: %v", err)
+		}
+
+		result, err := contextService.ResolveVariables(template, spacePath)
+		if err != nil {
+			t.Fatalf("Failed to resolve: %v", err)
+		}
+		if result != "Age: 10 days. Last activity: 3 hours ago." {
+			t.Errorf("Unexpected result: %q", result)
+		}
+	})
+}
+
+func TestAllowedVariables(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(dbService)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	captureID, notePath := createMockCapture(t, parachuteRoot, "Note")
+	if err := dbService.LinkNote(spaceID, spacePath, captureID, notePath, "", []string{"soil"}); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	template := "Notes: {{note_count}}\nRecent:\n{{recent_notes}}"
+
+	if err := contextService.SetAllowedVariables([]string{"note_count"}); err != nil {
+		t.Fatalf("Failed to set allowlist: %v", err)
+	}
+
+	t.Run("DisallowedLeftUnresolved", func(t *testing.T) {
+		result, err := contextService.ResolveVariables(template, spacePath)
+		if err != nil {
+			t.Fatalf("Failed to resolve: %v", err)
+		}
+		if result != "Notes: 1\nRecent:\n{{recent_notes}}" {
+			t.Errorf("Expected only note_count to resolve, got %q", result)
+		}
+	})
+
+	t.Run("Preview", func(t *testing.T) {
+		previews, err := contextService.PreviewVariables(template+" {{note_count}} {{notes_tagged:soil}}", spacePath)
+		if err != nil {
+			t.Fatalf("Failed to preview: %v", err)
+		}
+		want := []space.VariablePreview{
+			{Variable: "note_count", Name: "note_count", Allowed: true, Value: "1"},
+			{Variable: "recent_notes", Name: "recent_notes", Allowed: false},
+			{Variable: "notes_tagged:soil", Name: "notes_tagged", Allowed: false},
+		}
+		if len(previews) != len(want) {
+			t.Fatalf("Expected %d previews, got %+v", len(want), previews)
+		}
+		for i := range want {
+			if previews[i] != want[i] {
+				t.Errorf("Expected %+v, got %+v", want[i], previews[i])
+			}
+		}
+	})
+
+	t.Run("UnknownVariableRejected", func(t *testing.T) {
+		if err := contextService.SetAllowedVariables([]string{"note_count", "secrets"}); err == nil {
+			t.Error("Expected an unknown variable to be rejected")
+		}
+	})
+
+	t.Run("EmptyNamesSkipped", func(t *testing.T) {
+		if err := contextService.SetAllowedVariables([]string{"note_count", " ", ""}); err != nil {
+			t.Fatalf("Expected empty names to be skipped, got %v", err)
+		}
+		result, err := contextService.ResolveVariables(template, spacePath)
+		if err != nil {
+			t.Fatalf("Failed to resolve: %v", err)
+		}
+		if result != "Notes: 1\nRecent:\n{{recent_notes}}" {
+			t.Errorf("Expected only note_count to resolve, got %q", result)
+		}
+	})
+
+	t.Run("RejectedListKeepsPrevious", func(t *testing.T) {
+		if err := contextService.SetAllowedVariables([]string{"recent_notes", "note_cuont"}); err == nil {
+			t.Fatal("Expected a misspelled variable to be rejected")
+		}
+		result, err := contextService.ResolveVariables(template, spacePath)
+		if err != nil {
+			t.Fatalf("Failed to resolve: %v", err)
+		}
+		if result != "Notes: 1\nRecent:\n{{recent_notes}}" {
+			t.Errorf("Expected the previous allowlist to stay in force, got %q", result)
+		}
+	})
+
+	t.Run("NilAllowsAll", func(t *testing.T) {
+		if err := contextService.SetAllowedVariables(nil); err != nil {
+			t.Fatalf("Failed to reset allowlist: %v", err)
+		}
+		result, err := contextService.ResolveVariables("{{recent_notes}}", spacePath)
+		if err != nil {
+			t.Fatalf("Failed to resolve: %v", err)
+		}
+		if strings.Contains(result, "{{recent_notes}}") {
+			t.Errorf("Expected recent_notes to resolve, got %q", result)
+		}
+	})
+}
+
+func TestListSupportedVariables(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(dbService)
+	_, spacePath := setupTestSpace(t, parachuteRoot)
+
+	specs := contextService.ListSupportedVariables()
+	byName := make(map[string]space.VariableSpec, len(specs))
+	for _, spec := range specs {
+		byName[spec.Name] = spec
+	}
+
+	t.Run("IncludesKnownVariables", func(t *testing.T) {
+		for _, name := range []string{"note_count", "recent_tags", "recent_notes", "notes_tagged"} {
+			if spec, ok := byName[name]; !ok || spec.Description == "" {
+				t.Errorf("Expected %s with a description, got %+v", name, spec)
+			}
+		}
+		if len(specs) != len(space.ContextVariables) {
+			t.Errorf("Expected a spec per context variable, got %d for %d", len(specs), len(space.ContextVariables))
+		}
+	})
+
+	t.Run("Parameterized", func(t *testing.T) {
+		if spec := byName["notes_tagged"]; !spec.Parameterized || spec.Parameter != "TAG" {
+			t.Errorf("Expected notes_tagged to take a TAG parameter, got %+v", spec)
+		}
+		if spec := byName["note_count"]; spec.Parameterized || spec.Parameter != "" {
+			t.Errorf("Expected note_count to take no parameter, got %+v", spec)
+		}
+	})
+
+	t.Run("ExamplesResolve", func(t *testing.T) {
+		for _, spec := range specs {
+			result, err := contextService.ResolveVariables(spec.Example, spacePath)
+			if err != nil {
+				t.Fatalf("Failed to resolve %s: %v", spec.Example, err)
+			}
+			if result == spec.Example {
+				t.Errorf("Expected %s to resolve", spec.Example)
+			}
+		}
+	})
+}
+
+func TestResolveVariablesBatch(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(dbService)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	for _, tags := range [][]string{{"farming"}, {"farming", "soil"}, nil} {
+		captureID, notePath := createMockCapture(t, parachuteRoot, "Note")
+		if err := dbService.LinkNote(spaceID, spacePath, captureID, notePath, "", tags); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	templates := []string{
+		"You are helping with a space of {{note_count}} notes.",
+		"# Garden\n{{#if_notes}}Tags: {{recent_tags}}{{/if_notes}}\nFarming: {{notes_tagged:farming}}",
+		"Untagged: {{untagged_count}}{{#if_tagged:fishing}} fish{{/if_tagged:fishing}}",
+		"",
+	}
+
+	var want []string
+	for _, template := range templates {
+		resolved, err := contextService.ResolveVariables(template, spacePath)
+		if err != nil {
+			t.Fatalf("Failed to resolve: %v", err)
+		}
+		want = append(want, resolved)
+	}
+
+	opens := 0
+	restore := space.SetOpenContextDB(func(dbPath string) (*sql.DB, error) {
+		opens++
+		return sql.Open("sqlite", dbPath)
+	})
+	defer restore()
+
+	got, err := contextService.ResolveVariablesBatch(templates, spacePath)
+	if err != nil {
+		t.Fatalf("Failed to resolve batch: %v", err)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d results, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Template %d: expected %q, got %q", i, want[i], got[i])
+		}
+	}
+	if opens != 1 {
+		t.Errorf("Expected the database to be opened once, got %d", opens)
+	}
+}
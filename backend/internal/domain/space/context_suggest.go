@@ -0,0 +1,119 @@
+package space
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/file"
+)
+
+// ContextSuggester proposes space-specific context for a note being linked.
+// Implementations may call out to an LLM; suggestions are only ever shown to
+// the user, never saved automatically.
+type ContextSuggester interface {
+	// SuggestContext returns suggested context for a capture, given its
+	// content and the space's description (its SPACE.md, or its name)
+	SuggestContext(ctx context.Context, captureContent, spaceDescription string) (string, error)
+}
+
+// maxSuggestionLength caps the heuristic suggestion, in runes
+const maxSuggestionLength = 280
+
+// OpeningTextSuggester is the default ContextSuggester. It needs no LLM: it
+// suggests the capture's first paragraph of text, falling back to its first
+// heading or frontmatter title.
+type OpeningTextSuggester struct{}
+
+// SuggestContext implements ContextSuggester
+func (OpeningTextSuggester) SuggestContext(ctx context.Context, captureContent, spaceDescription string) (string, error) {
+	structure := file.ParseCaptureDetailed(captureContent)
+
+	inFence := false
+	for _, paragraph := range strings.Split(strings.ReplaceAll(structure.Body, "\r\n", "\n"), "\n\n") {
+		var lines []string
+		for _, line := range strings.Split(paragraph, "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "```") {
+				inFence = !inFence
+				continue
+			}
+			if inFence || line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) > 0 {
+			return truncateSuggestion(strings.Join(lines, " ")), nil
+		}
+	}
+
+	if len(structure.Headings) > 0 {
+		return truncateSuggestion(structure.Headings[0].Text), nil
+	}
+	return truncateSuggestion(structure.Frontmatter["title"]), nil
+}
+
+// truncateSuggestion shortens text to maxSuggestionLength runes, cutting at a word boundary
+func truncateSuggestion(text string) string {
+	if utf8.RuneCountInString(text) <= maxSuggestionLength {
+		return text
+	}
+
+	cut := string([]rune(text)[:maxSuggestionLength])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:") + "…"
+}
+
+// SetContextSuggester replaces the suggester used by SuggestContext (the
+// default is OpeningTextSuggester)
+func (s *ContextService) SetContextSuggester(suggester ContextSuggester) {
+	s.suggester = suggester
+}
+
+// SuggestContext suggests space-specific context for a capture without saving
+// it. notePath locates the capture file; it may be empty for a note that is
+// already linked to the space.
+func (s *ContextService) SuggestContext(ctx context.Context, space *Space, captureID, notePath string) (string, error) {
+	if notePath == "" {
+		note, err := s.spaceDBService.GetNoteByID(space.Path, captureID)
+		var goneErr *domain.GoneError
+		switch {
+		case err == nil:
+		case errors.As(err, &goneErr):
+			return "", err
+		case err.Error() == "note not found in space":
+			// Captures not linked to the space have to be located with note_path
+			return "", domain.NewNotFoundError("note", captureID)
+		default:
+			return "", fmt.Errorf("failed to look up note: %w", err)
+		}
+		notePath = note.NotePath
+	}
+
+	fullPath, err := s.spaceDBService.ResolveNoteFile(space.Path, notePath)
+	if err != nil {
+		return "", domain.NewValidationError("note_path", err.Error())
+	}
+
+	content, err := s.spaceDBService.ReadCapture(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", domain.NewNotFoundError("capture file", notePath)
+		}
+		return "", fmt.Errorf("failed to read capture: %w", err)
+	}
+
+	description, err := readSpaceContextFile(space.Path)
+	if err != nil || description == "" {
+		description = space.Name
+	}
+
+	return s.suggester.SuggestContext(ctx, string(content), description)
+}
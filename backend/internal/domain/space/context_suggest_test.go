@@ -0,0 +1,158 @@
+package space_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestOpeningTextSuggester(t *testing.T) {
+	suggester := space.OpeningTextSuggester{}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "FirstParagraph",
+			content: "Soil pH was 6.2 this morning,\nbetter than last week.\n\nSecond paragraph.",
+			want:    "Soil pH was 6.2 this morning, better than last week.",
+		},
+		{
+			name:    "SkipsFrontmatterAndHeadings",
+			content: "---\ntitle: Soil\n---\n# Soil test\n\nLime helped.\n",
+			want:    "Lime helped.",
+		},
+		{
+			name:    "SkipsCodeBlocks",
+			content: "```\nph=6.2\n```\n\nRecorded the reading.",
+			want:    "Recorded the reading.",
+		},
+		{
+			name:    "HeadingOnly",
+			content: "# Watering schedule\n",
+			want:    "Watering schedule",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := suggester.SuggestContext(context.Background(), tt.content, "")
+			if err != nil {
+				t.Fatalf("Failed to suggest context: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("TruncatesLongText", func(t *testing.T) {
+		got, _ := suggester.SuggestContext(context.Background(), strings.Repeat("compost ", 100), "")
+		if !strings.HasSuffix(got, "compost…") || len([]rune(got)) > 281 {
+			t.Errorf("Expected truncation at a word boundary, got %q", got)
+		}
+	})
+}
+
+// recordingSuggester captures the arguments it was called with
+type recordingSuggester struct {
+	content     string
+	description string
+}
+
+func (r *recordingSuggester) SuggestContext(ctx context.Context, captureContent, spaceDescription string) (string, error) {
+	r.content = captureContent
+	r.description = spaceDescription
+	return "suggested", nil
+}
+
+func TestContextServiceSuggestContext(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(service)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+	spaceObj := &space.Space{ID: spaceID, Name: "Garden", Path: spacePath}
+
+	if err := os.WriteFile(filepath.Join(spacePath, "SPACE.md"), []byte("# Garden\nVegetable beds."), 0644); err != nil {
+		t.Fatalf("Failed to write SPACE.md: %v", err)
+	}
+
+	linkedID, linkedPath := createNamedCapture(t, parachuteRoot, "linked.md", "Mulched the beds.")
+	if err := service.LinkNote(spaceID, spacePath, linkedID, linkedPath, "Existing context", nil); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+	_, unlinkedPath := createNamedCapture(t, parachuteRoot, "unlinked.md", "Planted garlic.")
+
+	t.Run("DefaultForLinkedNote", func(t *testing.T) {
+		got, err := contextService.SuggestContext(context.Background(), spaceObj, linkedID, "")
+		if err != nil {
+			t.Fatalf("Failed to suggest context: %v", err)
+		}
+		if got != "Mulched the beds." {
+			t.Errorf("Expected the capture's opening text, got %q", got)
+		}
+
+		// Suggestions are never saved
+		note, _ := service.GetNoteByID(spacePath, linkedID)
+		if note.Context != "Existing context" {
+			t.Errorf("Expected context to be unchanged, got %q", note.Context)
+		}
+	})
+
+	t.Run("UnlinkedNoteNeedsPath", func(t *testing.T) {
+		_, err := contextService.SuggestContext(context.Background(), spaceObj, "unlinked", "")
+		var notFound *domain.NotFoundError
+		if !errors.As(err, &notFound) {
+			t.Errorf("Expected not found error without note_path, got %v", err)
+		}
+
+		got, err := contextService.SuggestContext(context.Background(), spaceObj, "unlinked", unlinkedPath)
+		if err != nil {
+			t.Fatalf("Failed to suggest context: %v", err)
+		}
+		if got != "Planted garlic." {
+			t.Errorf("Expected the capture's opening text, got %q", got)
+		}
+	})
+
+	t.Run("DatabaseFailureNotReportedAsMissing", func(t *testing.T) {
+		brokenPath := filepath.Join(parachuteRoot, "spaces", "broken")
+		if err := os.MkdirAll(brokenPath, 0755); err != nil {
+			t.Fatalf("Failed to create space directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(brokenPath, "space.sqlite"), []byte("not a database"), 0644); err != nil {
+			t.Fatalf("Failed to write database: %v", err)
+		}
+
+		broken := &space.Space{ID: "broken", Name: "Broken", Path: brokenPath}
+		_, err := contextService.SuggestContext(context.Background(), broken, linkedID, "")
+		var notFound *domain.NotFoundError
+		if err == nil || errors.As(err, &notFound) {
+			t.Errorf("Expected a lookup failure, got %v", err)
+		}
+	})
+
+	t.Run("CustomSuggester", func(t *testing.T) {
+		recorder := &recordingSuggester{}
+		contextService.SetContextSuggester(recorder)
+		defer contextService.SetContextSuggester(space.OpeningTextSuggester{})
+
+		got, err := contextService.SuggestContext(context.Background(), spaceObj, linkedID, "")
+		if err != nil {
+			t.Fatalf("Failed to suggest context: %v", err)
+		}
+		if got != "suggested" || recorder.content != "Mulched the beds." || !strings.Contains(recorder.description, "Vegetable beds.") {
+			t.Errorf("Expected suggester to get capture content and SPACE.md, got %+v", recorder)
+		}
+	})
+}
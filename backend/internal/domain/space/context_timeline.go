@@ -0,0 +1,88 @@
+package space
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// replaceSpaceAge replaces {{space_age}} with how long ago the space was
+// created, e.g. "3 days". The creation time comes from the space record when a
+// repository is set, otherwise from space.sqlite's metadata.
+func (s *ContextService) replaceSpaceAge(text string, db *sql.DB, spacePath string) string {
+	if !strings.Contains(text, "{{space_age}}") {
+		return text
+	}
+
+	createdAt, ok := s.spaceCreatedAt(db, spacePath)
+	if !ok {
+		return strings.ReplaceAll(text, "{{space_age}}", "unknown")
+	}
+	return strings.ReplaceAll(text, "{{space_age}}", formatAge(time.Since(createdAt)))
+}
+
+// replaceLastActivity replaces {{last_activity}} with the time since a note was
+// last linked or referenced, e.g. "2 hours ago", or "never" for a space
+// without notes
+func (s *ContextService) replaceLastActivity(text string, spacePath string) string {
+	if !strings.Contains(text, "{{last_activity}}") {
+		return text
+	}
+
+	lastActivity, err := s.spaceDBService.LastActivity(spacePath)
+	if err != nil || lastActivity == nil {
+		return strings.ReplaceAll(text, "{{last_activity}}", "never")
+	}
+	return strings.ReplaceAll(text, "{{last_activity}}", formatAge(time.Since(*lastActivity))+" ago")
+}
+
+// spaceCreatedAt looks up when the space at spacePath was created
+func (s *ContextService) spaceCreatedAt(db *sql.DB, spacePath string) (time.Time, bool) {
+	if repo := s.spaceDBService.spaceRepo; repo != nil {
+		if spaceObj, err := repo.GetByPath(context.Background(), spacePath); err == nil && spaceObj != nil && !spaceObj.CreatedAt.IsZero() {
+			return spaceObj.CreatedAt, true
+		}
+	}
+
+	var value string
+	if err := db.QueryRow("SELECT value FROM space_metadata WHERE key = 'created_at'").Scan(&value); err != nil {
+		return time.Time{}, false
+	}
+	unix, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(unix, 0), true
+}
+
+// formatAge renders a duration in its largest whole unit, from minutes up to
+// years ("1 minute", "5 days", "2 years")
+func formatAge(d time.Duration) string {
+	const day = 24 * time.Hour
+
+	switch {
+	case d < time.Minute:
+		return "less than a minute"
+	case d < time.Hour:
+		return pluralize(int(d/time.Minute), "minute")
+	case d < day:
+		return pluralize(int(d/time.Hour), "hour")
+	case d < 30*day:
+		return pluralize(int(d/day), "day")
+	case d < 365*day:
+		return pluralize(int(d/(30*day)), "month")
+	default:
+		return pluralize(int(d/(365*day)), "year")
+	}
+}
+
+// pluralize formats a count with its unit, adding "s" unless the count is 1
+func pluralize(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
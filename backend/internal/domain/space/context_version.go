@@ -0,0 +1,70 @@
+package space
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// contextVersionKey is the space_metadata key holding the context version
+const contextVersionKey = "context_version"
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// bumpContextVersion increments the space's context version. Call it after any
+// mutation that can change resolved SPACE.md variables so clients caching the
+// rendered context know to re-fetch it. Mutations made in a transaction should
+// pass it, so the bump commits (or retries) with them.
+func bumpContextVersion(db execer) error {
+	_, err := db.Exec(`
+		INSERT INTO space_metadata (key, value) VALUES (?, '1')
+		ON CONFLICT(key) DO UPDATE SET value = CAST(value AS INTEGER) + 1
+	`, contextVersionKey)
+	if err != nil {
+		return fmt.Errorf("failed to bump context version: %w", err)
+	}
+	return nil
+}
+
+// GetContextVersion returns the space's context version, a counter that
+// increases whenever linked notes change. A space with no changes yet, or no
+// database yet, is at 0.
+func (s *SpaceDatabaseService) GetContextVersion(spacePath string) (int64, error) {
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	var value string
+	err = db.QueryRow("SELECT value FROM space_metadata WHERE key = ?", contextVersionKey).Scan(&value)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get context version: %w", err)
+	}
+
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid context version %q: %w", value, err)
+	}
+
+	return version, nil
+}
+
+// ContextVersion returns the context version of a space (see GetContextVersion)
+func (cs *ContextService) ContextVersion(space *Space) (int64, error) {
+	return cs.spaceDBService.GetContextVersion(space.Path)
+}
@@ -0,0 +1,97 @@
+package space_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestContextVersion(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(service)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	version := func(t *testing.T) int64 {
+		v, err := service.GetContextVersion(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to get context version: %v", err)
+		}
+		return v
+	}
+
+	if v := version(t); v != 0 {
+		t.Fatalf("Expected new space to be at version 0, got %d", v)
+	}
+
+	captureID, notePath := createMockCapture(t, parachuteRoot, "Soil notes")
+
+	t.Run("LinkBumpsVersion", func(t *testing.T) {
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "Context", []string{"soil"}); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		if v := version(t); v != 1 {
+			t.Errorf("Expected version 1 after link, got %d", v)
+		}
+	})
+
+	t.Run("ReadsDoNotBumpVersion", func(t *testing.T) {
+		before := version(t)
+
+		service.GetRelevantNotes(spacePath, space.NoteFilters{})
+		service.GetNoteByID(spacePath, captureID)
+		service.TrackNoteReference(spacePath, captureID)
+		service.GetNotesGroupedByTag(spacePath, space.NoteFilters{}, 0)
+		contextService.ResolveVariables("{{note_count}} {{recent_notes}}", spacePath)
+
+		if v := version(t); v != before {
+			t.Errorf("Expected reads to leave version at %d, got %d", before, v)
+		}
+	})
+
+	t.Run("UpdateAndUnlinkBumpVersion", func(t *testing.T) {
+		before := version(t)
+
+		newContext := "Updated"
+		if err := service.UpdateNoteContext(spacePath, captureID, &newContext, nil); err != nil {
+			t.Fatalf("Failed to update note: %v", err)
+		}
+		if err := service.UnlinkNote(spacePath, captureID); err != nil {
+			t.Fatalf("Failed to unlink note: %v", err)
+		}
+
+		if v := version(t); v != before+2 {
+			t.Errorf("Expected version %d, got %d", before+2, v)
+		}
+	})
+
+	t.Run("FailedMutationDoesNotBumpVersion", func(t *testing.T) {
+		before := version(t)
+
+		if err := service.UnlinkNote(spacePath, "missing"); err == nil {
+			t.Fatal("Expected error unlinking missing note")
+		}
+
+		if v := version(t); v != before {
+			t.Errorf("Expected version to stay at %d, got %d", before, v)
+		}
+	})
+
+	t.Run("NoDatabase", func(t *testing.T) {
+		emptyPath := filepath.Join(parachuteRoot, "spaces", "empty")
+		if err := os.MkdirAll(emptyPath, 0755); err != nil {
+			t.Fatalf("Failed to create space directory: %v", err)
+		}
+
+		if v, err := service.GetContextVersion(emptyPath); err != nil || v != 0 {
+			t.Errorf("Expected version 0 without a database, got %d (%v)", v, err)
+		}
+		if _, err := os.Stat(filepath.Join(emptyPath, "space.sqlite")); !os.IsNotExist(err) {
+			t.Error("Expected no database to be created")
+		}
+	})
+}
@@ -1,29 +1,112 @@
 package space
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/unforced/parachute-backend/internal/domain"
 	_ "modernc.org/sqlite"
 )
 
 // SpaceDatabaseService manages space-specific SQLite databases
 type SpaceDatabaseService struct {
-	parachuteRoot string
+	parachuteRoot  string
+	spaceRepo      Repository // optional, used to look up space flags such as read_only
+	activity       activityCache
+	titles         titleCache
+	locks          spaceLocks
+	autoInit       bool        // create space.sqlite on first write if it is missing
+	retry          RetryPolicy // retries for writes that hit a busy database
+	inheritTags    bool        // merge capture frontmatter tags into links unless a call opts out
+	limiter        writeLimiter
+	reindexes      reindexJobs
+	captureHashes  captureHashIndex
+	maxCaptureSize int64 // largest capture file ReadCapture loads; zero or less for no limit
 }
 
 // NewSpaceDatabaseService creates a new space database service
 func NewSpaceDatabaseService(parachuteRoot string) *SpaceDatabaseService {
 	return &SpaceDatabaseService{
-		parachuteRoot: parachuteRoot,
+		parachuteRoot:  parachuteRoot,
+		retry:          DefaultRetryPolicy,
+		inheritTags:    true,
+		maxCaptureSize: DefaultMaxCaptureSize,
 	}
 }
 
+// SetSpaceRepository sets the repository used to look up space records.
+// Without it every space is treated as writable.
+func (s *SpaceDatabaseService) SetSpaceRepository(repo Repository) {
+	s.spaceRepo = repo
+}
+
+// SetAutoInitialize controls whether writes to a space without a space.sqlite
+// create the database first. It is off by default, so a missing database
+// surfaces as an error instead of being silently created.
+func (s *SpaceDatabaseService) SetAutoInitialize(enabled bool) {
+	s.autoInit = enabled
+}
+
+// ensureDatabase initializes space.sqlite for a write when auto-initialize is
+// enabled and the database does not exist yet. An empty file, as left behind
+// by a failed write to a missing database, counts as missing.
+func (s *SpaceDatabaseService) ensureDatabase(spaceID, spacePath string) error {
+	if !s.autoInit {
+		return nil
+	}
+
+	info, err := os.Stat(filepath.Join(spacePath, "space.sqlite"))
+	if err == nil && info.Size() > 0 {
+		return nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to check space database: %w", err)
+	}
+
+	return s.InitializeSpaceDatabase(spaceID, spacePath)
+}
+
+// checkWritable returns ErrSpaceReadOnly if the space at spacePath is
+// read-only, ErrSpaceBusy if its database is being reset, or a RateLimitError
+// if it is taking writes too fast. Every note mutation calls it first.
+func (s *SpaceDatabaseService) checkWritable(spacePath string) error {
+	if err := s.checkReadOnly(spacePath); err != nil {
+		return err
+	}
+	if err := s.checkResetting(spacePath); err != nil {
+		return err
+	}
+	return s.takeWriteToken(spacePath)
+}
+
+// checkReadOnly returns ErrSpaceReadOnly if the space at spacePath is read-only.
+// Spaces not found in the repository are treated as writable.
+func (s *SpaceDatabaseService) checkReadOnly(spacePath string) error {
+	if s.spaceRepo == nil {
+		return nil
+	}
+
+	spaceObj, err := s.spaceRepo.GetByPath(context.Background(), spacePath)
+	if err != nil || spaceObj == nil {
+		return nil
+	}
+
+	if spaceObj.ReadOnly {
+		return ErrSpaceReadOnly
+	}
+
+	return nil
+}
+
 // MigrateAllSpaces initializes space.sqlite for all existing spaces
 func (s *SpaceDatabaseService) MigrateAllSpaces(spaceRepo Repository) error {
 	// Get all spaces from repository
@@ -52,7 +135,11 @@ func (s *SpaceDatabaseService) MigrateAllSpaces(spaceRepo Repository) error {
 
 		// Check if space.sqlite already exists
 		if _, err := os.Stat(dbPath); err == nil {
-			continue // Already migrated
+			// Already initialized - bring its schema up to date
+			if err := s.MigrateSpaceDatabase(spacePath); err != nil {
+				return fmt.Errorf("failed to migrate space %s: %w", entry.Name(), err)
+			}
+			continue
 		}
 
 		// Initialize database for this space
@@ -74,27 +161,169 @@ func (s *SpaceDatabaseService) MigrateAllSpaces(spaceRepo Repository) error {
 
 // RelevantNote represents a note linked to a space
 type RelevantNote struct {
-	ID             string                 `json:"id"`
-	CaptureID      string                 `json:"capture_id"`
-	NotePath       string                 `json:"note_path"`
-	LinkedAt       time.Time              `json:"linked_at"`
-	Context        string                 `json:"context"`
-	Tags           []string               `json:"tags"`
-	LastReferenced *time.Time             `json:"last_referenced,omitempty"`
-	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	ID                string                 `json:"id"`
+	CaptureID         string                 `json:"capture_id"`
+	NotePath          string                 `json:"note_path"`
+	LinkedAt          time.Time              `json:"linked_at"`
+	Context           string                 `json:"context"`
+	ContextStructured StructuredContext      `json:"context_structured,omitempty"`
+	Title             string                 `json:"title,omitempty"` // Set only when requested, see AttachTitles
+	Tags              []string               `json:"tags"`
+	Status            string                 `json:"status"`
+	DueAt             *time.Time             `json:"due_at,omitempty"`
+	BatchID           string                 `json:"batch_id,omitempty"`    // Shared by notes linked in one batch operation
+	CapturedAt        *time.Time             `json:"captured_at,omitempty"` // Set at link time, else parsed from the capture filename
+	Reminder          *NoteReminder          `json:"reminder,omitempty"`
+	Priority          int                    `json:"priority"`                    // Higher matters more; 0 unless set
+	ExpiresAt         *time.Time             `json:"expires_at,omitempty"`        // Hidden from listings after this, and unlinked by the expiry sweep
+	ParentCaptureID   string                 `json:"parent_capture_id,omitempty"` // Note this one replies to, see GetThread
+	Relevance         float64                `json:"relevance"`                   // 1 when linked or referenced, lowered by ApplyRelevanceDecay
+	SourceURL         string                 `json:"source_url,omitempty"`        // Web page the note was clipped from
+	LastReferenced    *time.Time             `json:"last_referenced,omitempty"`
+	Metadata          map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// noteColumns lists the relevant_notes columns read by scanNote, in scan order
+const noteColumns = "id, capture_id, note_path, linked_at, context, tags, last_referenced, metadata, context_structured, status, due_at, batch_id, captured_at, reminder_text, reminder_at, reminder_dismissed_at, priority, expires_at, parent_capture_id, relevance, source_url"
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanNote reads a relevant_notes row selected with noteColumns
+func scanNote(row rowScanner) (RelevantNote, error) {
+	var note RelevantNote
+	var linkedAtUnix int64
+	var lastRefUnix, dueUnix, capturedUnix, reminderUnix, dismissedUnix, expiresUnix sql.NullInt64
+	var tagsJSON, metadataJSON, structuredJSON, batchID, reminderText, parentID, sourceURL sql.NullString
+
+	err := row.Scan(
+		&note.ID,
+		&note.CaptureID,
+		&note.NotePath,
+		&linkedAtUnix,
+		&note.Context,
+		&tagsJSON,
+		&lastRefUnix,
+		&metadataJSON,
+		&structuredJSON,
+		&note.Status,
+		&dueUnix,
+		&batchID,
+		&capturedUnix,
+		&reminderText,
+		&reminderUnix,
+		&dismissedUnix,
+		&note.Priority,
+		&expiresUnix,
+		&parentID,
+		&note.Relevance,
+		&sourceURL,
+	)
+	if err != nil {
+		return note, err
+	}
+
+	note.LinkedAt = time.Unix(linkedAtUnix, 0)
+
+	if lastRefUnix.Valid {
+		lastRef := time.Unix(lastRefUnix.Int64, 0)
+		note.LastReferenced = &lastRef
+	}
+
+	if dueUnix.Valid {
+		due := time.Unix(dueUnix.Int64, 0)
+		note.DueAt = &due
+	}
+
+	note.BatchID = batchID.String
+	note.ParentCaptureID = parentID.String
+	note.SourceURL = sourceURL.String
+
+	if expiresUnix.Valid {
+		expires := time.Unix(expiresUnix.Int64, 0)
+		note.ExpiresAt = &expires
+	}
+
+	if capturedUnix.Valid {
+		captured := time.Unix(capturedUnix.Int64, 0)
+		note.CapturedAt = &captured
+	} else if captured, ok := capturedAt(note.NotePath); ok {
+		note.CapturedAt = &captured
+	}
+
+	if reminderText.Valid && reminderUnix.Valid {
+		note.Reminder = &NoteReminder{Text: reminderText.String, At: time.Unix(reminderUnix.Int64, 0)}
+		if dismissedUnix.Valid {
+			dismissed := time.Unix(dismissedUnix.Int64, 0)
+			note.Reminder.DismissedAt = &dismissed
+		}
+	}
+
+	if tagsJSON.Valid {
+		if err := json.Unmarshal([]byte(tagsJSON.String), &note.Tags); err != nil {
+			note.Tags = []string{}
+		}
+	}
+
+	if metadataJSON.Valid && metadataJSON.String != "" {
+		if err := json.Unmarshal([]byte(metadataJSON.String), &note.Metadata); err != nil {
+			note.Metadata = map[string]interface{}{}
+		}
+	}
+
+	if structuredJSON.Valid && structuredJSON.String != "" {
+		if err := json.Unmarshal([]byte(structuredJSON.String), &note.ContextStructured); err != nil {
+			note.ContextStructured = nil
+		}
+	}
+
+	return note, nil
+}
+
+// Orderings accepted by NoteFilters.Sort, newest first
+const (
+	NoteSortLinkedAt   = "linked_at"
+	NoteSortCapturedAt = "captured_at" // Notes with no capture time come last, by link time
+	NoteSortPriority   = "priority"    // Highest priority first, ties by link time
+	NoteSortRelevance  = "relevance"   // Most relevant first, ties by link time
+)
+
 // NoteFilters for querying relevant notes (exported for use in handlers)
 type NoteFilters struct {
-	Tags      []string
-	StartDate *time.Time
-	EndDate   *time.Time
-	Limit     int
-	Offset    int
+	Tags               []string   `json:"tags,omitempty"`
+	Status             string     `json:"status,omitempty"` // Workflow status; empty matches any
+	StartDate          *time.Time `json:"start_date,omitempty"`
+	EndDate            *time.Time `json:"end_date,omitempty"`
+	DueBefore          *time.Time `json:"due_before,omitempty"`       // Only notes with a due date at or before this time
+	BatchID            string     `json:"batch_id,omitempty"`         // Only notes linked in this batch
+	HasTags            *bool      `json:"has_tags,omitempty"`         // Only notes with (true) or without (false) any tags
+	HasSource          *bool      `json:"has_source,omitempty"`       // Only notes with (true) or without (false) a source URL
+	HasMetadataKey     string     `json:"has_meta,omitempty"`         // Only notes whose metadata sets this top-level key to a non-null value
+	MissingMetadataKey string     `json:"missing_meta,omitempty"`     // Only notes whose metadata lacks this key or sets it to null
+	ContextContains    string     `json:"context_contains,omitempty"` // Only notes whose space context contains this text, ignoring case
+	IncludeExpired     bool       `json:"include_expired,omitempty"`  // Also return notes past their expires_at
+	Sort               string     `json:"sort,omitempty"`             // NoteSortLinkedAt (default), NoteSortCapturedAt, NoteSortPriority or NoteSortRelevance
+	Limit              int        `json:"limit,omitempty"`
+	Offset             int        `json:"offset,omitempty"`
+
+	// ExistsOnDisk, when set, keeps only notes whose capture file is present
+	// (true) or missing (false). Checking requires reading the capture
+	// directories, so leave it nil unless needed.
+	ExistsOnDisk *bool `json:"exists,omitempty"`
 }
 
 // InitializeSpaceDatabase creates or updates space.sqlite for a space
 func (s *SpaceDatabaseService) InitializeSpaceDatabase(spaceID, spacePath string) error {
+	return s.initializeSpaceDatabase(spaceID, spacePath, func(db *sql.DB) error {
+		return s.migrateLocked(spacePath, db)
+	})
+}
+
+// initializeSpaceDatabase implements InitializeSpaceDatabase, applying schema
+// changes since the base schema with migrate
+func (s *SpaceDatabaseService) initializeSpaceDatabase(spaceID, spacePath string, migrate func(db *sql.DB) error) error {
 	dbPath := filepath.Join(spacePath, "space.sqlite")
 
 	// Create space directory if it doesn't exist
@@ -164,11 +393,340 @@ func (s *SpaceDatabaseService) InitializeSpaceDatabase(spaceID, spacePath string
 	}
 	// If space_id exists, we don't update it (preserve existing metadata)
 
+	// Apply any schema changes made since the base schema
+	return migrate(db)
+}
+
+// spaceMigration represents a schema change to space.sqlite
+type spaceMigration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// spaceMigrations lists schema changes applied on top of the base schema
+// created by InitializeSpaceDatabase (which is schema version 1).
+// The current version is stored under schema_version in space_metadata.
+var spaceMigrations = []spaceMigration{
+	{
+		Version: 2,
+		Name:    "add_context_structured",
+		SQL:     `ALTER TABLE relevant_notes ADD COLUMN context_structured TEXT;`,
+	},
+	{
+		Version: 3,
+		Name:    "add_context_history",
+		SQL: `
+		CREATE TABLE IF NOT EXISTS context_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			rendered_at INTEGER NOT NULL,
+			content TEXT NOT NULL,
+			variables TEXT
+		);
+		CREATE INDEX IF NOT EXISTS idx_context_history_rendered_at ON context_history(rendered_at DESC);
+		`,
+	},
+	{
+		Version: 4,
+		Name:    "add_deleted_notes",
+		SQL: `
+		CREATE TABLE IF NOT EXISTS deleted_notes (
+			capture_id TEXT PRIMARY KEY,
+			note_path TEXT NOT NULL,
+			deleted_at INTEGER NOT NULL
+		);
+		`,
+	},
+	{
+		Version: 5,
+		Name:    "add_note_status",
+		SQL: `
+		ALTER TABLE relevant_notes ADD COLUMN status TEXT NOT NULL DEFAULT 'open';
+		CREATE INDEX IF NOT EXISTS idx_relevant_notes_status ON relevant_notes(status);
+		`,
+	},
+	{
+		Version: 6,
+		Name:    "add_note_due_at",
+		SQL: `
+		ALTER TABLE relevant_notes ADD COLUMN due_at INTEGER;
+		CREATE INDEX IF NOT EXISTS idx_relevant_notes_due_at ON relevant_notes(due_at);
+		`,
+	},
+	{
+		Version: 7,
+		Name:    "add_saved_searches",
+		SQL: `
+		CREATE TABLE IF NOT EXISTS saved_searches (
+			name TEXT PRIMARY KEY,
+			filters TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		);
+		`,
+	},
+	{
+		Version: 8,
+		Name:    "add_note_batch_id",
+		SQL: `
+		ALTER TABLE relevant_notes ADD COLUMN batch_id TEXT;
+		CREATE INDEX IF NOT EXISTS idx_relevant_notes_batch_id ON relevant_notes(batch_id);
+		`,
+	},
+	{
+		Version: 9,
+		Name:    "add_featured_notes",
+		SQL: `
+		CREATE TABLE IF NOT EXISTS featured_notes (
+			capture_id TEXT PRIMARY KEY,
+			position INTEGER NOT NULL
+		);
+		`,
+	},
+	{
+		Version: 10,
+		Name:    "add_note_captured_at",
+		SQL: `
+		ALTER TABLE relevant_notes ADD COLUMN captured_at INTEGER;
+		`,
+	},
+	{
+		Version: 11,
+		Name:    "add_note_reminders",
+		SQL: `
+		ALTER TABLE relevant_notes ADD COLUMN reminder_text TEXT;
+		ALTER TABLE relevant_notes ADD COLUMN reminder_at INTEGER;
+		ALTER TABLE relevant_notes ADD COLUMN reminder_dismissed_at INTEGER;
+		CREATE INDEX IF NOT EXISTS idx_relevant_notes_reminder_at ON relevant_notes(reminder_at);
+		`,
+	},
+	{
+		Version: 12,
+		Name:    "add_note_priority",
+		SQL: `
+		ALTER TABLE relevant_notes ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+		CREATE INDEX IF NOT EXISTS idx_relevant_notes_priority ON relevant_notes(priority);
+		`,
+	},
+	{
+		Version: 13,
+		Name:    "add_note_expiry",
+		SQL: `
+		ALTER TABLE relevant_notes ADD COLUMN expires_at INTEGER;
+		CREATE INDEX IF NOT EXISTS idx_relevant_notes_expires_at ON relevant_notes(expires_at);
+		`,
+	},
+	{
+		Version: 14,
+		Name:    "add_note_threads",
+		SQL: `
+		ALTER TABLE relevant_notes ADD COLUMN parent_capture_id TEXT;
+		CREATE INDEX IF NOT EXISTS idx_relevant_notes_parent ON relevant_notes(parent_capture_id);
+		`,
+	},
+	{
+		Version: 15,
+		Name:    "add_note_relevance",
+		SQL: `
+		ALTER TABLE relevant_notes ADD COLUMN relevance REAL NOT NULL DEFAULT 1.0;
+		ALTER TABLE relevant_notes ADD COLUMN relevance_decayed_at INTEGER;
+		CREATE INDEX IF NOT EXISTS idx_relevant_notes_relevance ON relevant_notes(relevance);
+		`,
+	},
+	{
+		Version: 16,
+		Name:    "add_reference_log",
+		SQL: `
+		CREATE TABLE IF NOT EXISTS note_references (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			capture_id TEXT NOT NULL,
+			referenced_at INTEGER NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_note_references_at ON note_references(referenced_at);
+		`,
+	},
+	{
+		Version: 17,
+		Name:    "add_collections",
+		SQL: `
+		CREATE TABLE IF NOT EXISTS collections (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS collection_items (
+			collection_id TEXT NOT NULL,
+			capture_id TEXT NOT NULL,
+			position INTEGER NOT NULL,
+			added_at INTEGER NOT NULL,
+			PRIMARY KEY (collection_id, capture_id)
+		);
+		CREATE INDEX IF NOT EXISTS idx_collection_items_capture ON collection_items(capture_id);
+		`,
+	},
+	{
+		Version: 18,
+		Name:    "add_note_source_url",
+		SQL: `
+		ALTER TABLE relevant_notes ADD COLUMN source_url TEXT;
+		`,
+	},
+}
+
+// LatestSchemaVersion returns the schema version of a fully migrated space.sqlite
+func LatestSchemaVersion() int {
+	if len(spaceMigrations) == 0 {
+		return 1
+	}
+	return spaceMigrations[len(spaceMigrations)-1].Version
+}
+
+// MigrateSpaceDatabase applies pending schema migrations to an existing space.sqlite
+func (s *SpaceDatabaseService) MigrateSpaceDatabase(spacePath string) error {
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return fmt.Errorf("space database not found")
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	return s.migrateLocked(spacePath, db)
+}
+
+// migrationWait bounds how long migrateLocked waits for another maintenance
+// operation on the space (such as a concurrent migration) to finish
+var migrationWait = 30 * time.Second
+
+// migrationPollInterval is how often migrateLocked retries the lock while waiting
+const migrationPollInterval = 50 * time.Millisecond
+
+// migrateLocked applies pending migrations while holding the space's
+// maintenance lock. An up-to-date database is left alone without taking the
+// lock, so routine initialization never fails with ErrSpaceBusy. While another
+// operation holds the lock it waits (up to migrationWait) and checks again, so
+// a request racing a running migration sees the migrated database instead of
+// ErrSpaceBusy.
+func (s *SpaceDatabaseService) migrateLocked(spacePath string, db *sql.DB) error {
+	deadline := time.Now().Add(migrationWait)
+	for {
+		currentVersion, err := readSchemaVersion(db)
+		if err == nil && currentVersion >= LatestSchemaVersion() {
+			return nil
+		}
+
+		// A database busy with the running migration counts as locked
+		if err == nil {
+			var unlock func()
+			unlock, err = s.lockSpace(spacePath)
+			if err == nil {
+				defer unlock()
+				return applySpaceMigrations(db)
+			}
+		}
+		if !(errors.Is(err, ErrSpaceBusy) || isBusyError(err)) || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(migrationPollInterval)
+	}
+}
+
+// readSchemaVersion returns the schema_version recorded in space_metadata
+func readSchemaVersion(db *sql.DB) (int, error) {
+	var versionStr string
+	err := db.QueryRow("SELECT value FROM space_metadata WHERE key = 'schema_version'").Scan(&versionStr)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	currentVersion := 1
+	if versionStr != "" {
+		fmt.Sscanf(versionStr, "%d", &currentVersion)
+	}
+	return currentVersion, nil
+}
+
+// applySpaceMigrations brings a space database up to LatestSchemaVersion
+func applySpaceMigrations(db *sql.DB) error {
+	currentVersion, err := readSchemaVersion(db)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range spaceMigrations {
+		if migration.Version <= currentVersion {
+			continue
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin migration %d: %w", migration.Version, err)
+		}
+
+		if _, err := tx.Exec(migration.SQL); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply space migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO space_metadata (key, value) VALUES ('schema_version', ?)
+			ON CONFLICT(key) DO UPDATE SET value = excluded.value
+		`, fmt.Sprintf("%d", migration.Version))
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record space migration %d: %w", migration.Version, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit space migration %d: %w", migration.Version, err)
+		}
+	}
+
 	return nil
 }
 
 // LinkNote adds a capture to a space's relevant_notes
 func (s *SpaceDatabaseService) LinkNote(spaceID, spacePath, captureID, notePath, context string, tags []string) error {
+	if err := s.checkWritable(spacePath); err != nil {
+		return err
+	}
+	return s.withBusyRetry(func() error {
+		return s.linkNote(spaceID, spacePath, captureID, notePath, context, tags, "", LinkOptions{})
+	})
+}
+
+// linkNote inserts or updates a relevant_notes row. batchID is recorded only
+// when the note is first linked; an empty batchID leaves the column NULL.
+// Callers check the space is writable first.
+func (s *SpaceDatabaseService) linkNote(spaceID, spacePath, captureID, notePath, context string, tags []string, batchID string, opts LinkOptions) error {
+	if err := s.ensureDatabase(spaceID, spacePath); err != nil {
+		return err
+	}
+
+	notePath, err := s.normalizeLinkPath(spacePath, notePath)
+	if err != nil {
+		return err
+	}
+
+	inheritTags := s.inheritTags
+	if opts.InheritFrontmatterTags != nil {
+		inheritTags = *opts.InheritFrontmatterTags
+	}
+	if inheritTags {
+		if inherited := s.frontmatterTags(spacePath, notePath); len(inherited) > 0 {
+			tags = mergeTags(tags, inherited)
+		}
+	}
+
+	if err := validateTags(tags); err != nil {
+		return err
+	}
+
 	dbPath := filepath.Join(spacePath, "space.sqlite")
 
 	db, err := sql.Open("sqlite", dbPath)
@@ -187,23 +745,75 @@ func (s *SpaceDatabaseService) LinkNote(spaceID, spacePath, captureID, notePath,
 	id := uuid.New().String()
 	now := time.Now().Unix()
 
+	var capturedAtUnix sql.NullInt64
+	if opts.CapturedAt != nil {
+		capturedAtUnix = sql.NullInt64{Int64: opts.CapturedAt.Unix(), Valid: true}
+	}
+
+	var sourceURL sql.NullString
+	if opts.SourceURL != "" {
+		if err := ValidateSourceURL(opts.SourceURL); err != nil {
+			return err
+		}
+		sourceURL = sql.NullString{String: opts.SourceURL, Valid: true}
+	}
+
+	var parentID sql.NullString
+	if opts.ParentCaptureID != "" {
+		if err := checkThreadParent(db, captureID, opts.ParentCaptureID); err != nil {
+			return err
+		}
+		parentID = sql.NullString{String: opts.ParentCaptureID, Valid: true}
+	}
+
 	_, err = db.Exec(`
-		INSERT INTO relevant_notes (id, capture_id, note_path, linked_at, context, tags)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO relevant_notes (id, capture_id, note_path, linked_at, context, tags, batch_id, captured_at, parent_capture_id, source_url)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(capture_id) DO UPDATE SET
 			context = excluded.context,
-			tags = excluded.tags
-	`, id, captureID, notePath, now, context, string(tagsJSON))
+			tags = excluded.tags,
+			captured_at = COALESCE(excluded.captured_at, captured_at),
+			parent_capture_id = COALESCE(excluded.parent_capture_id, parent_capture_id),
+			source_url = COALESCE(excluded.source_url, source_url),
+			relevance = 1.0,
+			relevance_decayed_at = excluded.linked_at
+	`, id, captureID, notePath, now, context, string(tagsJSON), sql.NullString{String: batchID, Valid: batchID != ""}, capturedAtUnix, parentID, sourceURL)
 
 	if err != nil {
 		return fmt.Errorf("failed to link note: %w", err)
 	}
 
+	// Relinking clears any tombstone left by an earlier unlink
+	if _, err := db.Exec("DELETE FROM deleted_notes WHERE capture_id = ?", captureID); err != nil {
+		return fmt.Errorf("failed to clear unlinked note: %w", err)
+	}
+
+	if err := bumpContextVersion(db); err != nil {
+		return err
+	}
+
+	s.syncFrontmatter(spacePath, captureID)
+	s.syncManifest(spacePath)
 	return nil
 }
 
 // GetRelevantNotes queries linked notes for a space
 func (s *SpaceDatabaseService) GetRelevantNotes(spacePath string, filters NoteFilters) ([]RelevantNote, error) {
+	if err := validateFilterTags(filters.Tags); err != nil {
+		return nil, err
+	}
+	if err := validateMetadataKey("has_meta", filters.HasMetadataKey); err != nil {
+		return nil, err
+	}
+	if err := validateMetadataKey("missing_meta", filters.MissingMetadataKey); err != nil {
+		return nil, err
+	}
+	switch filters.Sort {
+	case "", NoteSortLinkedAt, NoteSortCapturedAt, NoteSortPriority, NoteSortRelevance:
+	default:
+		return nil, domain.NewValidationError("sort", fmt.Sprintf("must be %s, %s, %s or %s", NoteSortLinkedAt, NoteSortCapturedAt, NoteSortPriority, NoteSortRelevance))
+	}
+
 	dbPath := filepath.Join(spacePath, "space.sqlite")
 
 	// Check if database exists
@@ -218,17 +828,28 @@ func (s *SpaceDatabaseService) GetRelevantNotes(spacePath string, filters NoteFi
 	defer db.Close()
 
 	// Build query
-	query := "SELECT id, capture_id, note_path, linked_at, context, tags, last_referenced, metadata FROM relevant_notes WHERE 1=1"
+	query := "SELECT " + noteColumns + " FROM relevant_notes WHERE 1=1"
 	args := []interface{}{}
 
 	// Add filters
-	if len(filters.Tags) > 0 {
-		// Simple tag filtering (contains any of the tags)
-		// Note: For production, consider full-text search or JSON operators
-		for _, tag := range filters.Tags {
-			query += " AND tags LIKE ?"
-			args = append(args, "%\""+tag+"\"%")
+	if tags := mergeTags(filters.Tags); len(tags) > 0 {
+		// Notes must have every filter tag. The tags are passed as one JSON
+		// array, so the query stays the same size however many there are.
+		tagsJSON, err := json.Marshal(tags)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal tag filter: %w", err)
 		}
+		query += ` AND (
+			SELECT COUNT(DISTINCT note_tag.value)
+			FROM json_each(CASE WHEN json_valid(relevant_notes.tags) THEN relevant_notes.tags END) AS note_tag
+			WHERE note_tag.value IN (SELECT value FROM json_each(?))
+		) = ?`
+		args = append(args, string(tagsJSON), len(tags))
+	}
+
+	if filters.Status != "" {
+		query += " AND status = ?"
+		args = append(args, filters.Status)
 	}
 
 	if filters.StartDate != nil {
@@ -241,17 +862,75 @@ func (s *SpaceDatabaseService) GetRelevantNotes(spacePath string, filters NoteFi
 		args = append(args, filters.EndDate.Unix())
 	}
 
-	// Order by most recently linked
-	query += " ORDER BY linked_at DESC"
+	if filters.DueBefore != nil {
+		query += " AND due_at IS NOT NULL AND due_at <= ?"
+		args = append(args, filters.DueBefore.Unix())
+	}
 
-	// Pagination
-	if filters.Limit > 0 {
-		query += " LIMIT ?"
-		args = append(args, filters.Limit)
+	if filters.BatchID != "" {
+		query += " AND batch_id = ?"
+		args = append(args, filters.BatchID)
 	}
-	if filters.Offset > 0 {
-		query += " OFFSET ?"
-		args = append(args, filters.Offset)
+
+	if filters.HasTags != nil {
+		if *filters.HasTags {
+			query += " AND NOT " + untaggedCondition
+		} else {
+			query += " AND " + untaggedCondition
+		}
+	}
+
+	if filters.HasSource != nil {
+		if *filters.HasSource {
+			query += " AND " + hasSourceCondition
+		} else {
+			query += " AND NOT " + hasSourceCondition
+		}
+	}
+
+	if filters.HasMetadataKey != "" {
+		query += " AND " + metadataValueExpr + " IS NOT NULL"
+		args = append(args, metadataKeyPath(filters.HasMetadataKey))
+	}
+
+	if filters.MissingMetadataKey != "" {
+		query += " AND " + metadataValueExpr + " IS NULL"
+		args = append(args, metadataKeyPath(filters.MissingMetadataKey))
+	}
+
+	if filters.ContextContains != "" {
+		query += ` AND context LIKE ? ESCAPE '\'`
+		args = append(args, "%"+escapeLike(filters.ContextContains)+"%")
+	}
+
+	// Expired notes are hidden even before the expiry sweep unlinks them
+	if !filters.IncludeExpired {
+		query += " AND (expires_at IS NULL OR expires_at > ?)"
+		args = append(args, time.Now().Unix())
+	}
+
+	// Order by most recently linked, after priority or relevance when sorting by them
+	switch filters.Sort {
+	case NoteSortPriority:
+		query += " ORDER BY priority DESC, linked_at DESC"
+	case NoteSortRelevance:
+		query += " ORDER BY relevance DESC, linked_at DESC"
+	default:
+		query += " ORDER BY linked_at DESC"
+	}
+
+	// Pagination (applied after the disk check when filtering on file
+	// existence, and after sorting when capture times come from filenames)
+	paginateInQuery := filters.ExistsOnDisk == nil && filters.Sort != NoteSortCapturedAt
+	if paginateInQuery {
+		if filters.Limit > 0 {
+			query += " LIMIT ?"
+			args = append(args, filters.Limit)
+		}
+		if filters.Offset > 0 {
+			query += " OFFSET ?"
+			args = append(args, filters.Offset)
+		}
 	}
 
 	rows, err := db.Query(query, args...)
@@ -262,52 +941,124 @@ func (s *SpaceDatabaseService) GetRelevantNotes(spacePath string, filters NoteFi
 
 	notes := []RelevantNote{}
 	for rows.Next() {
-		var note RelevantNote
-		var linkedAtUnix int64
-		var lastRefUnix sql.NullInt64
-		var tagsJSON, metadataJSON sql.NullString
-
-		err := rows.Scan(
-			&note.ID,
-			&note.CaptureID,
-			&note.NotePath,
-			&linkedAtUnix,
-			&note.Context,
-			&tagsJSON,
-			&lastRefUnix,
-			&metadataJSON,
-		)
+		note, err := scanNote(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan note: %w", err)
 		}
 
-		note.LinkedAt = time.Unix(linkedAtUnix, 0)
+		notes = append(notes, note)
+	}
+
+	if filters.ExistsOnDisk != nil {
+		notes = s.filterByExistence(spacePath, notes, *filters.ExistsOnDisk)
+	}
+	if filters.Sort == NoteSortCapturedAt {
+		sortByCapturedAt(notes)
+	}
+	if !paginateInQuery {
+		notes = paginate(notes, filters.Limit, filters.Offset)
+	}
+
+	s.applyTagDisplayOrder(spacePath, notes)
+	return notes, nil
+}
 
-		if lastRefUnix.Valid {
-			lastRef := time.Unix(lastRefUnix.Int64, 0)
-			note.LastReferenced = &lastRef
+// sortByCapturedAt orders notes by capture time, newest first. Notes without
+// one keep their link order after the rest.
+func sortByCapturedAt(notes []RelevantNote) {
+	sort.SliceStable(notes, func(i, j int) bool {
+		a, b := notes[i].CapturedAt, notes[j].CapturedAt
+		if a == nil || b == nil {
+			return a != nil && b == nil
 		}
+		return a.After(*b)
+	})
+}
 
-		if tagsJSON.Valid {
-			if err := json.Unmarshal([]byte(tagsJSON.String), &note.Tags); err != nil {
-				note.Tags = []string{}
-			}
+// filterByExistence keeps the notes whose capture file presence matches exists
+func (s *SpaceDatabaseService) filterByExistence(spacePath string, notes []RelevantNote, exists bool) []RelevantNote {
+	checker := s.newNoteFileChecker(spacePath)
+
+	filtered := []RelevantNote{}
+	for _, note := range notes {
+		if checker.exists(note.NotePath) == exists {
+			filtered = append(filtered, note)
 		}
+	}
+	return filtered
+}
 
-		if metadataJSON.Valid && metadataJSON.String != "" {
-			if err := json.Unmarshal([]byte(metadataJSON.String), &note.Metadata); err != nil {
-				note.Metadata = map[string]interface{}{}
-			}
+// paginate applies limit (0 means no limit) and offset to notes
+func paginate(notes []RelevantNote, limit, offset int) []RelevantNote {
+	if offset >= len(notes) {
+		return []RelevantNote{}
+	}
+	notes = notes[offset:]
+	if limit > 0 && limit < len(notes) {
+		notes = notes[:limit]
+	}
+	return notes
+}
+
+// UntaggedBucket is the GetNotesGroupedByTag bucket holding notes without tags
+const UntaggedBucket = "untagged"
+
+// GetNotesGroupedByTag returns the notes matching filters bucketed by tag.
+// A note appears in the bucket of every tag it carries; notes with no tags
+// go in the UntaggedBucket. Each bucket holds at most bucketLimit notes
+// (no cap when bucketLimit <= 0), keeping the most recently linked.
+func (s *SpaceDatabaseService) GetNotesGroupedByTag(spacePath string, filters NoteFilters, bucketLimit int) (map[string][]RelevantNote, error) {
+	notes, err := s.GetRelevantNotes(spacePath, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string][]RelevantNote)
+	for _, note := range notes {
+		if len(note.Tags) == 0 {
+			groups[UntaggedBucket] = appendCapped(groups[UntaggedBucket], note, bucketLimit)
+			continue
 		}
 
-		notes = append(notes, note)
+		seen := make(map[string]bool, len(note.Tags))
+		for _, tag := range note.Tags {
+			if seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			groups[tag] = appendCapped(groups[tag], note, bucketLimit)
+		}
 	}
 
-	return notes, nil
+	return groups, nil
+}
+
+// appendCapped appends note to bucket unless the bucket already holds limit notes
+func appendCapped(bucket []RelevantNote, note RelevantNote, limit int) []RelevantNote {
+	if limit > 0 && len(bucket) >= limit {
+		return bucket
+	}
+	return append(bucket, note)
 }
 
 // UpdateNoteContext updates the space-specific context and/or tags for a note
 func (s *SpaceDatabaseService) UpdateNoteContext(spacePath, captureID string, context *string, tags *[]string) error {
+	if err := s.checkWritable(spacePath); err != nil {
+		return err
+	}
+	return s.withBusyRetry(func() error {
+		return s.updateNoteContext(spacePath, captureID, context, tags)
+	})
+}
+
+// updateNoteContext implements UpdateNoteContext without retries
+func (s *SpaceDatabaseService) updateNoteContext(spacePath, captureID string, context *string, tags *[]string) error {
+	if tags != nil {
+		if err := validateTags(*tags); err != nil {
+			return err
+		}
+	}
+
 	dbPath := filepath.Join(spacePath, "space.sqlite")
 
 	db, err := sql.Open("sqlite", dbPath)
@@ -352,11 +1103,27 @@ func (s *SpaceDatabaseService) UpdateNoteContext(spacePath, captureID string, co
 		return fmt.Errorf("note not found in space")
 	}
 
+	if err := bumpContextVersion(db); err != nil {
+		return err
+	}
+
+	s.syncFrontmatter(spacePath, captureID)
+	s.syncManifest(spacePath)
 	return nil
 }
 
 // UnlinkNote removes a note from a space's relevant_notes
 func (s *SpaceDatabaseService) UnlinkNote(spacePath, captureID string) error {
+	if err := s.checkWritable(spacePath); err != nil {
+		return err
+	}
+	return s.withBusyRetry(func() error {
+		return s.unlinkNote(spacePath, captureID)
+	})
+}
+
+// unlinkNote implements UnlinkNote without retries
+func (s *SpaceDatabaseService) unlinkNote(spacePath, captureID string) error {
 	dbPath := filepath.Join(spacePath, "space.sqlite")
 
 	db, err := sql.Open("sqlite", dbPath)
@@ -365,21 +1132,46 @@ func (s *SpaceDatabaseService) UnlinkNote(spacePath, captureID string) error {
 	}
 	defer db.Close()
 
-	result, err := db.Exec("DELETE FROM relevant_notes WHERE capture_id = ?", captureID)
+	tx, err := db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to unlink note: %w", err)
+		return fmt.Errorf("failed to begin unlink: %w", err)
 	}
+	defer tx.Rollback()
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
+	// Leave a tombstone so lookups can tell an unlinked note from one never linked
+	removed, err := unlinkInTx(tx, []string{captureID})
+	if err != nil {
+		return err
+	}
+	if len(removed) == 0 {
 		return fmt.Errorf("note not found in space")
 	}
 
+	if err := bumpContextVersion(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit unlink: %w", err)
+	}
+
+	s.syncManifest(spacePath)
 	return nil
 }
 
-// TrackNoteReference updates the last_referenced timestamp for a note
+// TrackNoteReference updates the last_referenced timestamp for a note,
+// restores its relevance to 1 and records the reference in the reference log
+// (see GetReferenceAnalytics), dropping log entries older than
+// referenceLogRetention. It runs on every content read, so it doesn't bump the
+// context version.
 func (s *SpaceDatabaseService) TrackNoteReference(spacePath, captureID string) error {
+	return s.withBusyRetry(func() error {
+		return s.trackNoteReference(spacePath, captureID)
+	})
+}
+
+// trackNoteReference implements TrackNoteReference without retries
+func (s *SpaceDatabaseService) trackNoteReference(spacePath, captureID string) error {
 	dbPath := filepath.Join(spacePath, "space.sqlite")
 
 	db, err := sql.Open("sqlite", dbPath)
@@ -388,12 +1180,35 @@ func (s *SpaceDatabaseService) TrackNoteReference(spacePath, captureID string) e
 	}
 	defer db.Close()
 
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin reference tracking: %w", err)
+	}
+	defer tx.Rollback()
+
 	now := time.Now().Unix()
-	_, err = db.Exec("UPDATE relevant_notes SET last_referenced = ? WHERE capture_id = ?", now, captureID)
+	result, err := tx.Exec("UPDATE relevant_notes SET last_referenced = ?, relevance = 1.0, relevance_decayed_at = ? WHERE capture_id = ?", now, now, captureID)
 	if err != nil {
 		return fmt.Errorf("failed to track note reference: %w", err)
 	}
 
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec("INSERT INTO note_references (capture_id, referenced_at) VALUES (?, ?)", captureID, now); err != nil {
+		return fmt.Errorf("failed to log note reference: %w", err)
+	}
+	cutoff := time.Now().Add(-referenceLogRetention).Unix()
+	if _, err := tx.Exec("DELETE FROM note_references WHERE referenced_at < ?", cutoff); err != nil {
+		return fmt.Errorf("failed to prune reference log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit note reference: %w", err)
+	}
+
 	return nil
 }
 
@@ -412,52 +1227,79 @@ func (s *SpaceDatabaseService) GetNoteByID(spacePath, captureID string) (*Releva
 	}
 	defer db.Close()
 
-	var note RelevantNote
-	var linkedAtUnix int64
-	var lastRefUnix sql.NullInt64
-	var tagsJSON, metadataJSON sql.NullString
-
-	err = db.QueryRow(`
-		SELECT id, capture_id, note_path, linked_at, context, tags, last_referenced, metadata
-		FROM relevant_notes WHERE capture_id = ?
-	`, captureID).Scan(
-		&note.ID,
-		&note.CaptureID,
-		&note.NotePath,
-		&linkedAtUnix,
-		&note.Context,
-		&tagsJSON,
-		&lastRefUnix,
-		&metadataJSON,
-	)
-
+	note, err := scanNote(db.QueryRow("SELECT "+noteColumns+" FROM relevant_notes WHERE capture_id = ?", captureID))
 	if err == sql.ErrNoRows {
+		// A note that was linked and later unlinked is gone rather than not found
+		var deletedAt int64
+		if db.QueryRow("SELECT deleted_at FROM deleted_notes WHERE capture_id = ?", captureID).Scan(&deletedAt) == nil {
+			return nil, domain.NewGoneError("note", captureID)
+		}
 		return nil, fmt.Errorf("note not found in space")
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to query note: %w", err)
 	}
 
-	note.LinkedAt = time.Unix(linkedAtUnix, 0)
+	notes := []RelevantNote{note}
+	s.applyTagDisplayOrder(spacePath, notes)
+	return &notes[0], nil
+}
 
-	if lastRefUnix.Valid {
-		lastRef := time.Unix(lastRefUnix.Int64, 0)
-		note.LastReferenced = &lastRef
+// GetNotesByIDs fetches the linked notes for several capture IDs in one query.
+// Notes are returned in the order their IDs were requested; IDs that are not
+// linked to the space are omitted.
+func (s *SpaceDatabaseService) GetNotesByIDs(spacePath string, captureIDs []string) ([]RelevantNote, error) {
+	if len(captureIDs) == 0 {
+		return []RelevantNote{}, nil
 	}
 
-	if tagsJSON.Valid {
-		if err := json.Unmarshal([]byte(tagsJSON.String), &note.Tags); err != nil {
-			note.Tags = []string{}
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	// Check if database exists
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return []RelevantNote{}, nil // Return empty list if no database yet
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	placeholders := make([]string, len(captureIDs))
+	args := make([]interface{}, len(captureIDs))
+	for i, id := range captureIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := "SELECT " + noteColumns + " FROM relevant_notes WHERE capture_id IN (" + joinStrings(placeholders, ", ") + ")"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notes: %w", err)
+	}
+	defer rows.Close()
+
+	found := make(map[string]RelevantNote, len(captureIDs))
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan note: %w", err)
 		}
+		found[note.CaptureID] = note
 	}
 
-	if metadataJSON.Valid && metadataJSON.String != "" {
-		if err := json.Unmarshal([]byte(metadataJSON.String), &note.Metadata); err != nil {
-			note.Metadata = map[string]interface{}{}
+	notes := make([]RelevantNote, 0, len(found))
+	for _, id := range captureIDs {
+		if note, ok := found[id]; ok {
+			notes = append(notes, note)
+			delete(found, id) // Duplicate IDs return the note once
 		}
 	}
 
-	return &note, nil
+	s.applyTagDisplayOrder(spacePath, notes)
+	return notes, nil
 }
 
 // Helper function to join strings
@@ -482,6 +1324,8 @@ type SpaceDatabaseStats struct {
 	RecentNotes   []RelevantNote    `json:"recent_notes"`
 	Metadata      map[string]string `json:"metadata"`
 	Tables        []string          `json:"tables"`
+	ContentStats  *NoteContentStats `json:"content_stats,omitempty"` // Only set when requested
+	BackupStatus
 }
 
 // GetDatabaseStats retrieves comprehensive statistics about a space database
@@ -521,6 +1365,8 @@ func (s *SpaceDatabaseService) GetDatabaseStats(spacePath string) (*SpaceDatabas
 					var createdAt int64
 					fmt.Sscanf(value, "%d", &createdAt)
 					stats.CreatedAt = createdAt
+				case lastExportedAtKey:
+					stats.BackupStatus = parseBackupStatus(value, time.Now())
 				}
 			}
 		}
@@ -580,14 +1426,47 @@ type TableRow map[string]interface{}
 
 // TableQueryResult represents the result of querying a table
 type TableQueryResult struct {
-	TableName string     `json:"table_name"`
-	Columns   []string   `json:"columns"`
-	Rows      []TableRow `json:"rows"`
-	RowCount  int        `json:"row_count"`
+	TableName   string        `json:"table_name"`
+	Columns     []string      `json:"columns"`
+	ColumnTypes []TableColumn `json:"column_types"` // In the same order as Columns
+	Rows        []TableRow    `json:"rows"`
+	RowCount    int           `json:"row_count"`
+}
+
+// TableColumn describes a table column, from PRAGMA table_info
+type TableColumn struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"` // Declared type, e.g. INTEGER or TEXT
+	NotNull    bool   `json:"not_null"`
+	PrimaryKey bool   `json:"primary_key"`
+	JSON       bool   `json:"json"` // TEXT holding JSON, returned parsed in rows
+}
+
+// jsonColumns lists the TEXT columns of space tables that hold JSON
+var jsonColumns = map[string]map[string]bool{
+	"relevant_notes":  {"tags": true, "metadata": true, "context_structured": true},
+	"context_history": {"variables": true},
+	"saved_searches":  {"filters": true},
+}
+
+// TableFilter narrows the rows returned by QueryTableFiltered
+type TableFilter struct {
+	// Search keeps rows where any text column contains the term (case-insensitive)
+	Search string
+
+	// Column and Value keep rows where Column equals Value exactly. Column
+	// must be a column of the table.
+	Column string
+	Value  string
 }
 
 // QueryTable retrieves all rows from a specific table in a space database
 func (s *SpaceDatabaseService) QueryTable(spacePath, tableName string) (*TableQueryResult, error) {
+	return s.QueryTableFiltered(spacePath, tableName, TableFilter{})
+}
+
+// QueryTableFiltered retrieves the rows of a table in a space database that match filter
+func (s *SpaceDatabaseService) QueryTableFiltered(spacePath, tableName string, filter TableFilter) (*TableQueryResult, error) {
 	dbPath := filepath.Join(spacePath, "space.sqlite")
 
 	// Check if database exists
@@ -634,18 +1513,66 @@ func (s *SpaceDatabaseService) QueryTable(spacePath, tableName string) (*TableQu
 	}
 	defer rows.Close()
 
+	var textColumns []string
 	for rows.Next() {
-		var cid int
+		var cid, notNull, pk int
 		var name, colType string
-		var notNull, dfltValue, pk interface{}
+		var dfltValue interface{}
 		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
 			continue
 		}
 		result.Columns = append(result.Columns, name)
+		result.ColumnTypes = append(result.ColumnTypes, TableColumn{
+			Name:       name,
+			Type:       colType,
+			NotNull:    notNull != 0,
+			PrimaryKey: pk != 0,
+			JSON:       jsonColumns[tableName][name],
+		})
+		if isTextColumnType(colType) {
+			textColumns = append(textColumns, name)
+		}
+	}
+
+	// Build the filter from column names read from the schema; values are parameters
+	var conditions []string
+	var args []interface{}
+
+	if filter.Search != "" {
+		pattern := "%" + escapeLike(filter.Search) + "%"
+		var matches []string
+		for _, col := range textColumns {
+			matches = append(matches, fmt.Sprintf(`"%s" LIKE ? ESCAPE '\'`, col))
+			args = append(args, pattern)
+		}
+		if len(matches) == 0 {
+			// No text columns, so nothing can match
+			matches = append(matches, "0")
+		}
+		conditions = append(conditions, "("+strings.Join(matches, " OR ")+")")
+	}
+
+	if filter.Column != "" {
+		found := false
+		for _, col := range result.Columns {
+			if col == filter.Column {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, domain.NewValidationError("column", fmt.Sprintf("table %s has no column %s", tableName, filter.Column))
+		}
+		conditions = append(conditions, fmt.Sprintf(`"%s" = ?`, filter.Column))
+		args = append(args, filter.Value)
+	}
+
+	query := fmt.Sprintf("SELECT * FROM %s", tableName)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
-	// Query all rows from the table
-	dataRows, err := db.Query(fmt.Sprintf("SELECT * FROM %s", tableName))
+	dataRows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query table: %w", err)
 	}
@@ -707,3 +1634,16 @@ func (s *SpaceDatabaseService) QueryTable(spacePath, tableName string) (*TableQu
 	result.RowCount = len(result.Rows)
 	return result, nil
 }
+
+// isTextColumnType reports whether a declared SQLite column type has text
+// affinity (or none, in which case values are usually stored as text)
+func isTextColumnType(colType string) bool {
+	colType = strings.ToUpper(colType)
+	return colType == "" || strings.Contains(colType, "TEXT") ||
+		strings.Contains(colType, "CHAR") || strings.Contains(colType, "CLOB")
+}
+
+// escapeLike escapes LIKE wildcards so a search term matches literally
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term)
+}
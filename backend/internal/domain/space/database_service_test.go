@@ -3,12 +3,17 @@ package space_test
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/unforced/parachute-backend/internal/domain"
 	"github.com/unforced/parachute-backend/internal/domain/space"
 	sqliteStorage "github.com/unforced/parachute-backend/internal/storage/sqlite"
 )
@@ -71,6 +76,23 @@ func createMockCapture(t *testing.T, parachuteRoot string, content string) (capt
 	return captureID, notePath
 }
 
+// createNamedCapture creates a capture file with an explicit filename in captures/,
+// for tests where several captures created in the same second must not collide
+func createNamedCapture(t *testing.T, parachuteRoot, filename, content string) (captureID, notePath string) {
+	captureID = uuid.New().String()
+	notePath = filepath.Join("captures", filename)
+	fullPath := filepath.Join(parachuteRoot, notePath)
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		t.Fatalf("Failed to create capture directory: %v", err)
+	}
+	if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create named capture: %v", err)
+	}
+
+	return captureID, notePath
+}
+
 func TestInitializeSpaceDatabase(t *testing.T) {
 	parachuteRoot, cleanup := setupTestEnvironment(t)
 	defer cleanup()
@@ -250,6 +272,76 @@ func TestLinkNote(t *testing.T) {
 	})
 }
 
+func TestAutoInitialize(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	newSpace := func(t *testing.T, name string) string {
+		spacePath := filepath.Join(parachuteRoot, "spaces", name)
+		if err := os.MkdirAll(spacePath, 0755); err != nil {
+			t.Fatalf("Failed to create space directory: %v", err)
+		}
+		return spacePath
+	}
+
+	t.Run("OffByDefault", func(t *testing.T) {
+		service := space.NewSpaceDatabaseService(parachuteRoot)
+		spacePath := newSpace(t, "uninitialized")
+		captureID, notePath := createNamedCapture(t, parachuteRoot, "off.md", "Note")
+
+		if err := service.LinkNote(uuid.New().String(), spacePath, captureID, notePath, "", nil); err == nil {
+			t.Error("Expected linking to an uninitialized space to fail")
+		}
+
+		notes, err := service.GetRelevantNotes(newSpace(t, "never-written"), space.NoteFilters{})
+		if err != nil || len(notes) != 0 {
+			t.Errorf("Expected no notes and no error, got %d notes, %v", len(notes), err)
+		}
+	})
+
+	t.Run("CreatesDatabaseOnLink", func(t *testing.T) {
+		service := space.NewSpaceDatabaseService(parachuteRoot)
+		service.SetAutoInitialize(true)
+		spaceID := uuid.New().String()
+		spacePath := newSpace(t, "auto")
+		captureID, notePath := createNamedCapture(t, parachuteRoot, "on.md", "Note")
+
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "Auto", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+
+		note, err := service.GetNoteByID(spacePath, captureID)
+		if err != nil {
+			t.Fatalf("Expected note to be linked: %v", err)
+		}
+		if note.Context != "Auto" {
+			t.Errorf("Expected context Auto, got %q", note.Context)
+		}
+
+		health, err := service.GetDatabaseHealth(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to check health: %v", err)
+		}
+		if !health.UpToDate {
+			t.Errorf("Expected a fully migrated database, got %+v", health)
+		}
+	})
+
+	t.Run("RecoversFromFailedWrite", func(t *testing.T) {
+		service := space.NewSpaceDatabaseService(parachuteRoot)
+		spacePath := newSpace(t, "recovered")
+		captureID, notePath := createNamedCapture(t, parachuteRoot, "recovered.md", "Note")
+
+		// A failed write leaves an empty space.sqlite behind
+		service.LinkNote(uuid.New().String(), spacePath, captureID, notePath, "", nil)
+
+		service.SetAutoInitialize(true)
+		if err := service.LinkNote(uuid.New().String(), spacePath, captureID, notePath, "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	})
+}
+
 func TestGetRelevantNotes(t *testing.T) {
 	parachuteRoot, cleanup := setupTestEnvironment(t)
 	defer cleanup()
@@ -407,6 +499,244 @@ func TestGetRelevantNotes(t *testing.T) {
 	})
 }
 
+func TestExistsOnDiskFilter(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	presentIDs := map[string]bool{}
+	var deletedID string
+	for i, name := range []string{"2024-08-01_10-00-00.md", "2024-08-02_10-00-00.md", "2024-08-03_10-00-00.md"} {
+		captureID, notePath := createNamedCapture(t, parachuteRoot, name, "Content")
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		if i == 1 {
+			deletedID = captureID
+			os.Remove(filepath.Join(parachuteRoot, notePath))
+		} else {
+			presentIDs[captureID] = true
+		}
+	}
+
+	exists, missing := true, false
+
+	t.Run("OnlyExisting", func(t *testing.T) {
+		notes, err := service.GetRelevantNotes(spacePath, space.NoteFilters{ExistsOnDisk: &exists})
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		if len(notes) != 2 {
+			t.Fatalf("Expected 2 existing notes, got %d", len(notes))
+		}
+		for _, note := range notes {
+			if !presentIDs[note.CaptureID] {
+				t.Errorf("Unexpected note %s in existing set", note.CaptureID)
+			}
+		}
+	})
+
+	t.Run("OnlyMissing", func(t *testing.T) {
+		notes, err := service.GetRelevantNotes(spacePath, space.NoteFilters{ExistsOnDisk: &missing})
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		if len(notes) != 1 || notes[0].CaptureID != deletedID {
+			t.Errorf("Expected only the deleted note, got %+v", notes)
+		}
+	})
+
+	t.Run("PaginatesAfterFiltering", func(t *testing.T) {
+		notes, err := service.GetRelevantNotes(spacePath, space.NoteFilters{ExistsOnDisk: &exists, Limit: 1, Offset: 1})
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		if len(notes) != 1 || !presentIDs[notes[0].CaptureID] {
+			t.Errorf("Expected the second existing note, got %+v", notes)
+		}
+	})
+
+	t.Run("BatchedDirectoryReads", func(t *testing.T) {
+		reads := 0
+		restore := space.SetReadDir(func(dir string) ([]os.DirEntry, error) {
+			reads++
+			return os.ReadDir(dir)
+		})
+		defer restore()
+
+		if _, err := service.GetRelevantNotes(spacePath, space.NoteFilters{ExistsOnDisk: &exists}); err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		if reads != 1 {
+			t.Errorf("Expected captures/ to be listed once, got %d reads", reads)
+		}
+
+		reads = 0
+		notes, err := service.GetRelevantNotes(spacePath, space.NoteFilters{})
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		if reads != 0 {
+			t.Errorf("Expected no disk checks without the option, got %d reads", reads)
+		}
+		if len(notes) != 3 {
+			t.Errorf("Expected all 3 notes without the option, got %d", len(notes))
+		}
+	})
+}
+
+func TestContextContainsFilter(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	links := []struct {
+		captureID string
+		context   string
+		tags      []string
+	}{
+		{"soil", "Notes on Compost temperature", []string{"garden"}},
+		{"kitchen", "Kitchen scraps for the compost bin", []string{"home"}},
+		{"seeds", "Seed order for spring", []string{"garden"}},
+		{"percent", "Germination rate 100% this year", nil},
+	}
+	for _, l := range links {
+		if err := service.LinkNote(spaceID, spacePath, l.captureID, "captures/"+l.captureID+".md", l.context, l.tags); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	captureIDs := func(notes []space.RelevantNote) map[string]bool {
+		ids := map[string]bool{}
+		for _, note := range notes {
+			ids[note.CaptureID] = true
+		}
+		return ids
+	}
+
+	t.Run("MatchesIgnoringCase", func(t *testing.T) {
+		notes, err := service.GetRelevantNotes(spacePath, space.NoteFilters{ContextContains: "COMPOST"})
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		if ids := captureIDs(notes); len(ids) != 2 || !ids["soil"] || !ids["kitchen"] {
+			t.Errorf("Expected soil and kitchen, got %v", ids)
+		}
+	})
+
+	t.Run("ComposesWithTags", func(t *testing.T) {
+		notes, err := service.GetRelevantNotes(spacePath, space.NoteFilters{ContextContains: "compost", Tags: []string{"garden"}})
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		if len(notes) != 1 || notes[0].CaptureID != "soil" {
+			t.Errorf("Expected only soil, got %v", captureIDs(notes))
+		}
+	})
+
+	t.Run("WildcardsMatchLiterally", func(t *testing.T) {
+		notes, err := service.GetRelevantNotes(spacePath, space.NoteFilters{ContextContains: "0%"})
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		if len(notes) != 1 || notes[0].CaptureID != "percent" {
+			t.Errorf("Expected only the note containing 0%%, got %v", captureIDs(notes))
+		}
+
+		notes, _ = service.GetRelevantNotes(spacePath, space.NoteFilters{ContextContains: "_"})
+		if len(notes) != 0 {
+			t.Errorf("Expected _ to match no context, got %v", captureIDs(notes))
+		}
+	})
+}
+
+func TestGetNotesGroupedByTag(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	testNotes := []struct {
+		tags []string
+	}{
+		{[]string{"farming", "regeneration"}},
+		{[]string{"farming", "soil"}},
+		{[]string{"regeneration", "biodiversity"}},
+		{[]string{"farming", "biodiversity"}},
+		{[]string{"soil", "compost"}},
+		{nil},
+	}
+
+	for i, tn := range testNotes {
+		captureID, notePath := createMockCapture(t, parachuteRoot, "Note "+string(rune('A'+i)))
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "Context", tn.tags); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	t.Run("GroupsEveryTag", func(t *testing.T) {
+		groups, err := service.GetNotesGroupedByTag(spacePath, space.NoteFilters{}, 0)
+		if err != nil {
+			t.Fatalf("Failed to group notes: %v", err)
+		}
+
+		expected := map[string]int{
+			"farming":            3,
+			"soil":               2,
+			"regeneration":       2,
+			"biodiversity":       2,
+			"compost":            1,
+			space.UntaggedBucket: 1,
+		}
+		if len(groups) != len(expected) {
+			t.Errorf("Expected %d buckets, got %d", len(expected), len(groups))
+		}
+		for tag, count := range expected {
+			if len(groups[tag]) != count {
+				t.Errorf("Expected %d notes tagged %s, got %d", count, tag, len(groups[tag]))
+			}
+		}
+	})
+
+	t.Run("BucketLimit", func(t *testing.T) {
+		groups, err := service.GetNotesGroupedByTag(spacePath, space.NoteFilters{}, 1)
+		if err != nil {
+			t.Fatalf("Failed to group notes: %v", err)
+		}
+
+		for tag, notes := range groups {
+			if len(notes) > 1 {
+				t.Errorf("Expected bucket %s capped at 1, got %d", tag, len(notes))
+			}
+		}
+	})
+
+	t.Run("RespectsFilters", func(t *testing.T) {
+		groups, err := service.GetNotesGroupedByTag(spacePath, space.NoteFilters{Tags: []string{"soil"}}, 0)
+		if err != nil {
+			t.Fatalf("Failed to group notes: %v", err)
+		}
+
+		if len(groups["soil"]) != 2 {
+			t.Errorf("Expected 2 soil notes, got %d", len(groups["soil"]))
+		}
+		if len(groups["farming"]) != 1 {
+			t.Errorf("Expected 1 farming note among soil notes, got %d", len(groups["farming"]))
+		}
+		if _, ok := groups["regeneration"]; ok {
+			t.Error("Expected no regeneration bucket when filtering by soil")
+		}
+		if _, ok := groups[space.UntaggedBucket]; ok {
+			t.Error("Expected no untagged bucket when filtering by tag")
+		}
+	})
+}
+
 func TestUpdateNoteContext(t *testing.T) {
 	parachuteRoot, cleanup := setupTestEnvironment(t)
 	defer cleanup()
@@ -550,6 +880,29 @@ func TestUnlinkNote(t *testing.T) {
 			t.Errorf("Expected 'note not found in space' error, got: %v", err)
 		}
 	})
+
+	t.Run("UnlinkedNoteIsGone", func(t *testing.T) {
+		var goneErr *domain.GoneError
+
+		_, err := service.GetNoteByID(spacePath, captureID)
+		if !errors.As(err, &goneErr) {
+			t.Errorf("Expected GoneError for unlinked note, got %v", err)
+		}
+
+		_, err = service.GetNoteByID(spacePath, uuid.New().String())
+		if errors.As(err, &goneErr) || err == nil || err.Error() != "note not found in space" {
+			t.Errorf("Expected not found for never-linked note, got %v", err)
+		}
+	})
+
+	t.Run("RelinkClearsGone", func(t *testing.T) {
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "Again", nil); err != nil {
+			t.Fatalf("Failed to relink note: %v", err)
+		}
+		if _, err := service.GetNoteByID(spacePath, captureID); err != nil {
+			t.Errorf("Expected relinked note to be found, got %v", err)
+		}
+	})
 }
 
 func TestTrackNoteReference(t *testing.T) {
@@ -678,6 +1031,77 @@ func TestGetNoteByID(t *testing.T) {
 	})
 }
 
+func TestGetNotesByIDs(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		captureID, notePath := createMockCapture(t, parachuteRoot, "Content")
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, fmt.Sprintf("Context %d", i), nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		ids = append(ids, captureID)
+	}
+
+	t.Run("MixOfExistingAndMissing", func(t *testing.T) {
+		requested := []string{ids[2], "missing-1", ids[0], "missing-2"}
+
+		notes, err := service.GetNotesByIDs(spacePath, requested)
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+
+		if len(notes) != 2 {
+			t.Fatalf("Expected 2 notes, got %d", len(notes))
+		}
+		if notes[0].CaptureID != ids[2] || notes[1].CaptureID != ids[0] {
+			t.Errorf("Expected notes in requested order, got %s, %s", notes[0].CaptureID, notes[1].CaptureID)
+		}
+	})
+
+	t.Run("DuplicateIDs", func(t *testing.T) {
+		notes, err := service.GetNotesByIDs(spacePath, []string{ids[1], ids[1]})
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		if len(notes) != 1 {
+			t.Errorf("Expected duplicate IDs to return one note, got %d", len(notes))
+		}
+	})
+
+	t.Run("EmptyList", func(t *testing.T) {
+		notes, err := service.GetNotesByIDs(spacePath, nil)
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		if len(notes) != 0 {
+			t.Errorf("Expected no notes, got %d", len(notes))
+		}
+	})
+
+	t.Run("NoDatabase", func(t *testing.T) {
+		emptyPath := filepath.Join(parachuteRoot, "spaces", "no-database")
+		if err := os.MkdirAll(emptyPath, 0755); err != nil {
+			t.Fatalf("Failed to create space dir: %v", err)
+		}
+
+		notes, err := service.GetNotesByIDs(emptyPath, ids)
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		if len(notes) != 0 {
+			t.Errorf("Expected no notes, got %d", len(notes))
+		}
+		if _, err := os.Stat(filepath.Join(emptyPath, "space.sqlite")); !os.IsNotExist(err) {
+			t.Errorf("Expected no space.sqlite to be created, got %v", err)
+		}
+	})
+}
+
 func TestGetDatabaseStats(t *testing.T) {
 	parachuteRoot, cleanup := setupTestEnvironment(t)
 	defer cleanup()
@@ -709,8 +1133,9 @@ func TestGetDatabaseStats(t *testing.T) {
 			t.Errorf("Expected space_id %s, got %s", spaceID, stats.SpaceID)
 		}
 
-		if stats.SchemaVersion != "1" {
-			t.Errorf("Expected schema_version 1, got %s", stats.SchemaVersion)
+		expectedVersion := fmt.Sprintf("%d", space.LatestSchemaVersion())
+		if stats.SchemaVersion != expectedVersion {
+			t.Errorf("Expected schema_version %s, got %s", expectedVersion, stats.SchemaVersion)
 		}
 
 		// Should have at least "common" tag
@@ -777,7 +1202,7 @@ func TestQueryTable(t *testing.T) {
 		}
 
 		// Check columns
-		expectedColumns := []string{"id", "capture_id", "note_path", "linked_at", "context", "tags", "last_referenced", "metadata"}
+		expectedColumns := []string{"id", "capture_id", "note_path", "linked_at", "context", "tags", "last_referenced", "metadata", "context_structured", "status", "due_at", "batch_id", "captured_at", "reminder_text", "reminder_at", "reminder_dismissed_at", "priority", "expires_at", "parent_capture_id", "relevance", "relevance_decayed_at", "source_url"}
 		if len(result.Columns) != len(expectedColumns) {
 			t.Errorf("Expected %d columns, got %d", len(expectedColumns), len(result.Columns))
 		}
@@ -802,6 +1227,44 @@ func TestQueryTable(t *testing.T) {
 		}
 	})
 
+	t.Run("ColumnTypes", func(t *testing.T) {
+		result, err := service.QueryTable(spacePath, "relevant_notes")
+		if err != nil {
+			t.Fatalf("Failed to query table: %v", err)
+		}
+
+		if len(result.ColumnTypes) != len(result.Columns) {
+			t.Fatalf("Expected a column type per column, got %d for %d", len(result.ColumnTypes), len(result.Columns))
+		}
+
+		byName := make(map[string]space.TableColumn)
+		for i, col := range result.ColumnTypes {
+			if col.Name != result.Columns[i] {
+				t.Errorf("Expected column types in column order, got %s at %d", col.Name, i)
+			}
+			byName[col.Name] = col
+		}
+
+		expected := map[string]space.TableColumn{
+			"id":         {Name: "id", Type: "TEXT", PrimaryKey: true},
+			"capture_id": {Name: "capture_id", Type: "TEXT", NotNull: true},
+			"linked_at":  {Name: "linked_at", Type: "INTEGER", NotNull: true},
+			"tags":       {Name: "tags", Type: "TEXT", JSON: true},
+			"metadata":   {Name: "metadata", Type: "TEXT", JSON: true},
+			"context":    {Name: "context", Type: "TEXT"},
+		}
+		for name, want := range expected {
+			if got := byName[name]; got != want {
+				t.Errorf("Expected %s to be %+v, got %+v", name, want, got)
+			}
+		}
+
+		// Row data is unchanged
+		if _, ok := result.Rows[0]["tags"].([]interface{}); !ok {
+			t.Error("Expected tags still parsed as JSON array")
+		}
+	})
+
 	t.Run("QueryMetadataTable", func(t *testing.T) {
 		result, err := service.QueryTable(spacePath, "space_metadata")
 		if err != nil {
@@ -826,6 +1289,55 @@ func TestQueryTable(t *testing.T) {
 			t.Error("Expected error for non-existent table")
 		}
 	})
+
+	otherID, otherPath := createNamedCapture(t, parachuteRoot, "other.md", "Other capture")
+	if err := service.LinkNote(spaceID, spacePath, otherID, otherPath, "Unrelated", nil); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	t.Run("SearchTextColumns", func(t *testing.T) {
+		result, err := service.QueryTableFiltered(spacePath, "relevant_notes", space.TableFilter{
+			Search: strings.ToUpper(captureID[:8]),
+		})
+		if err != nil {
+			t.Fatalf("Failed to search table: %v", err)
+		}
+		if result.RowCount != 1 || result.Rows[0]["capture_id"] != captureID {
+			t.Errorf("Expected only %s to match, got %+v", captureID, result.Rows)
+		}
+
+		result, err = service.QueryTableFiltered(spacePath, "relevant_notes", space.TableFilter{Search: "%"})
+		if err != nil {
+			t.Fatalf("Failed to search table: %v", err)
+		}
+		if result.RowCount != 0 {
+			t.Errorf("Expected LIKE wildcards to match literally, got %d rows", result.RowCount)
+		}
+	})
+
+	t.Run("FilterByColumnValue", func(t *testing.T) {
+		result, err := service.QueryTableFiltered(spacePath, "relevant_notes", space.TableFilter{
+			Column: "context",
+			Value:  "Unrelated",
+		})
+		if err != nil {
+			t.Fatalf("Failed to filter table: %v", err)
+		}
+		if result.RowCount != 1 || result.Rows[0]["capture_id"] != otherID {
+			t.Errorf("Expected only %s to match, got %+v", otherID, result.Rows)
+		}
+	})
+
+	t.Run("FilterByUnknownColumn", func(t *testing.T) {
+		_, err := service.QueryTableFiltered(spacePath, "relevant_notes", space.TableFilter{
+			Column: "context = context; --",
+			Value:  "x",
+		})
+		var validationErr *domain.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error for unknown column, got %v", err)
+		}
+	})
 }
 
 func TestMigrateAllSpaces(t *testing.T) {
@@ -910,6 +1422,77 @@ func TestMigrateAllSpaces(t *testing.T) {
 	})
 }
 
+func TestMigrateSpaceDatabase(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spacePath := filepath.Join(parachuteRoot, "spaces", "legacy")
+	if err := os.MkdirAll(spacePath, 0755); err != nil {
+		t.Fatalf("Failed to create space directory: %v", err)
+	}
+
+	// Build a version 1 database by hand, as created before migrations existed
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE space_metadata (key TEXT PRIMARY KEY, value TEXT NOT NULL);
+		CREATE TABLE relevant_notes (
+			id TEXT PRIMARY KEY,
+			capture_id TEXT NOT NULL,
+			note_path TEXT NOT NULL,
+			linked_at INTEGER NOT NULL,
+			context TEXT,
+			tags TEXT,
+			last_referenced INTEGER,
+			metadata TEXT,
+			UNIQUE(capture_id)
+		);
+		INSERT INTO space_metadata (key, value) VALUES ('schema_version', '1'), ('space_id', 'legacy-id');
+		INSERT INTO relevant_notes (id, capture_id, note_path, linked_at, context, tags)
+		VALUES ('n1', 'capture-1', 'captures/old.md', 1700000000, 'Old context', '["old"]');
+	`)
+	db.Close()
+	if err != nil {
+		t.Fatalf("Failed to build legacy schema: %v", err)
+	}
+
+	t.Run("UpgradesLegacyDatabase", func(t *testing.T) {
+		if err := service.MigrateSpaceDatabase(spacePath); err != nil {
+			t.Fatalf("Failed to migrate: %v", err)
+		}
+
+		stats, err := service.GetDatabaseStats(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to get stats: %v", err)
+		}
+		if stats.SchemaVersion != fmt.Sprintf("%d", space.LatestSchemaVersion()) {
+			t.Errorf("Expected schema_version %d, got %s", space.LatestSchemaVersion(), stats.SchemaVersion)
+		}
+
+		// Existing rows remain readable through the new columns
+		note, err := service.GetNoteByID(spacePath, "capture-1")
+		if err != nil {
+			t.Fatalf("Failed to read migrated note: %v", err)
+		}
+		if note.Context != "Old context" {
+			t.Errorf("Expected existing context to survive migration, got %s", note.Context)
+		}
+		if note.Status != space.NoteStatusOpen {
+			t.Errorf("Expected existing note to be migrated to status open, got %q", note.Status)
+		}
+	})
+
+	t.Run("MigrateIsIdempotent", func(t *testing.T) {
+		if err := service.MigrateSpaceDatabase(spacePath); err != nil {
+			t.Fatalf("Second migration should be a no-op: %v", err)
+		}
+	})
+}
+
 func TestUnicodeAndSpecialCharacters(t *testing.T) {
 	parachuteRoot, cleanup := setupTestEnvironment(t)
 	defer cleanup()
@@ -940,6 +1523,39 @@ func TestUnicodeAndSpecialCharacters(t *testing.T) {
 			t.Errorf("Expected 3 unicode tags, got %d", len(note.Tags))
 		}
 	})
+
+	t.Run("TagLengthLimit", func(t *testing.T) {
+		// 100 runes but 300 bytes: the limit counts runes
+		maxTag := strings.Repeat("你", space.MaxTagLength)
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "Context", []string{maxTag}); err != nil {
+			t.Fatalf("Expected %d-rune tag to be accepted, got %v", space.MaxTagLength, err)
+		}
+
+		longTag := strings.Repeat("你", 200)
+		var validationErr *domain.ValidationError
+
+		err := service.LinkNote(spaceID, spacePath, captureID, notePath, "Context", []string{"ok", longTag})
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("Expected ValidationError linking a 200-rune tag, got %v", err)
+		}
+		if !strings.Contains(err.Error(), longTag) {
+			t.Errorf("Expected error to name the offending tag, got %v", err)
+		}
+
+		tags := []string{longTag}
+		err = service.UpdateNoteContext(spacePath, captureID, nil, &tags)
+		if !errors.As(err, &validationErr) {
+			t.Errorf("Expected ValidationError updating to a 200-rune tag, got %v", err)
+		}
+
+		note, err := service.GetNoteByID(spacePath, captureID)
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if len(note.Tags) != 1 || note.Tags[0] != maxTag {
+			t.Errorf("Expected rejected writes to leave tags unchanged, got %v", note.Tags)
+		}
+	})
 }
 
 func TestLargeData(t *testing.T) {
@@ -993,6 +1609,39 @@ func TestLargeData(t *testing.T) {
 			t.Errorf("Expected 50 tags, got %d", len(note.Tags))
 		}
 	})
+
+	t.Run("ManyFilterTags", func(t *testing.T) {
+		filterTags := make([]string, space.MaxFilterTags+1)
+		for i := range filterTags {
+			filterTags[i] = fmt.Sprintf("filter%d", i)
+		}
+
+		err := service.LinkNote(spaceID, spacePath, captureID, notePath, "Context", filterTags[:space.MaxFilterTags])
+		if err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+
+		// Every tag up to the cap must match
+		notes, err := service.GetRelevantNotes(spacePath, space.NoteFilters{Tags: filterTags[:space.MaxFilterTags]})
+		if err != nil {
+			t.Fatalf("Failed to filter by %d tags: %v", space.MaxFilterTags, err)
+		}
+		if len(notes) != 1 || notes[0].CaptureID != captureID {
+			t.Errorf("Expected the note with all tags, got %d notes", len(notes))
+		}
+
+		// Duplicates don't count toward the cap
+		repeated := append(filterTags[:space.MaxFilterTags:space.MaxFilterTags], filterTags[0])
+		if _, err := service.GetRelevantNotes(spacePath, space.NoteFilters{Tags: repeated}); err != nil {
+			t.Errorf("Expected repeated tags to be accepted, got %v", err)
+		}
+
+		_, err = service.GetRelevantNotes(spacePath, space.NoteFilters{Tags: filterTags})
+		var validationErr *domain.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error over the cap, got %v", err)
+		}
+	})
 }
 
 func TestMetadataField(t *testing.T) {
@@ -1045,3 +1694,82 @@ func TestMetadataField(t *testing.T) {
 		}
 	})
 }
+
+func TestMetadataKeyFilters(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	metadata := map[string]string{
+		"rated":     `{"rating": 4, "source": "book"}`,
+		"zero":      `{"rating": 0}`,
+		"null":      `{"rating": null}`,
+		"unrated":   `{"source": "web"}`,
+		"none":      "",
+		"malformed": `{"rating":`,
+	}
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+	for captureID := range metadata {
+		if err := service.LinkNote(spaceID, spacePath, captureID, "captures/"+captureID+".md", "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	// Notes get metadata outside the API, as in TestMetadataField
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	for captureID, value := range metadata {
+		if value == "" {
+			continue
+		}
+		if _, err := db.Exec("UPDATE relevant_notes SET metadata = ? WHERE capture_id = ?", value, captureID); err != nil {
+			t.Fatalf("Failed to update metadata: %v", err)
+		}
+	}
+	db.Close()
+
+	captureIDs := func(filters space.NoteFilters) []string {
+		filters.Sort = space.NoteSortLinkedAt
+		notes, err := service.GetRelevantNotes(spacePath, filters)
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		ids := make([]string, 0, len(notes))
+		for _, note := range notes {
+			ids = append(ids, note.CaptureID)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	t.Run("Partition", func(t *testing.T) {
+		has := captureIDs(space.NoteFilters{HasMetadataKey: "rating"})
+		if strings.Join(has, ",") != "rated,zero" {
+			t.Errorf("Expected rated and zero to have a rating, got %v", has)
+		}
+
+		missing := captureIDs(space.NoteFilters{MissingMetadataKey: "rating"})
+		if strings.Join(missing, ",") != "malformed,none,null,unrated" {
+			t.Errorf("Expected the other notes to lack a rating, got %v", missing)
+		}
+	})
+
+	t.Run("Combined", func(t *testing.T) {
+		ids := captureIDs(space.NoteFilters{HasMetadataKey: "source", MissingMetadataKey: "rating"})
+		if strings.Join(ids, ",") != "unrated" {
+			t.Errorf("Expected only unrated, got %v", ids)
+		}
+	})
+
+	t.Run("InvalidKey", func(t *testing.T) {
+		_, err := service.GetRelevantNotes(spacePath, space.NoteFilters{HasMetadataKey: `rat"ing`})
+		var validationErr *domain.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("Expected a validation error, got %v", err)
+		}
+	})
+}
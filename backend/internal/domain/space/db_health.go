@@ -0,0 +1,164 @@
+package space
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Overall space database health, from best to worst
+const (
+	DBHealthOK               = "ok"
+	DBHealthMigrationPending = "migration pending" // Older schema; migrated on next startup
+	DBHealthCorrupt          = "corrupt"           // PRAGMA integrity_check reported problems
+	DBHealthMissing          = "missing"           // space.sqlite does not exist
+)
+
+// maxIntegrityProblems caps how many integrity_check messages DBHealth reports
+const maxIntegrityProblems = 10
+
+// DBHealth is a diagnostic report on one space's space.sqlite
+type DBHealth struct {
+	Status              string         `json:"status"`
+	Exists              bool           `json:"exists"`
+	SchemaVersion       int            `json:"schema_version"`
+	LatestSchemaVersion int            `json:"latest_schema_version"`
+	UpToDate            bool           `json:"up_to_date"`
+	Integrity           []string       `json:"integrity"` // ["ok"] when healthy
+	RowCounts           map[string]int `json:"row_counts"`
+}
+
+// GetDatabaseHealth checks that a space's space.sqlite exists, passes
+// PRAGMA integrity_check and is at the latest schema version, and counts the
+// rows in each table. A missing database is reported in the result rather
+// than as an error.
+func (s *SpaceDatabaseService) GetDatabaseHealth(spacePath string) (DBHealth, error) {
+	health := DBHealth{
+		LatestSchemaVersion: LatestSchemaVersion(),
+		Integrity:           []string{},
+		RowCounts:           map[string]int{},
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	// Check before opening, which would create an empty database
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		health.Status = DBHealthMissing
+		return health, nil
+	} else if err != nil {
+		return health, fmt.Errorf("failed to stat space database: %w", err)
+	}
+	health.Exists = true
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return health, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query(fmt.Sprintf("PRAGMA integrity_check(%d)", maxIntegrityProblems))
+	if err != nil {
+		return health, fmt.Errorf("failed to check integrity: %w", err)
+	}
+	for rows.Next() {
+		var message string
+		if err := rows.Scan(&message); err == nil {
+			health.Integrity = append(health.Integrity, message)
+		}
+	}
+	rows.Close()
+
+	// Same default as readSchemaVersion: no recorded version means the base schema
+	health.SchemaVersion = 1
+	var versionStr string
+	if err := db.QueryRow("SELECT value FROM space_metadata WHERE key = 'schema_version'").Scan(&versionStr); err == nil {
+		if version, err := strconv.Atoi(versionStr); err == nil {
+			health.SchemaVersion = version
+		}
+	}
+	health.UpToDate = health.SchemaVersion >= health.LatestSchemaVersion
+
+	tables, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return health, fmt.Errorf("failed to list tables: %w", err)
+	}
+	var names []string
+	for tables.Next() {
+		var name string
+		if err := tables.Scan(&name); err == nil {
+			names = append(names, name)
+		}
+	}
+	tables.Close()
+
+	for _, name := range names {
+		var count int
+		quoted := `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + quoted).Scan(&count); err == nil {
+			health.RowCounts[name] = count
+		}
+	}
+
+	switch {
+	case len(health.Integrity) != 1 || health.Integrity[0] != "ok":
+		health.Status = DBHealthCorrupt
+	case !health.UpToDate:
+		health.Status = DBHealthMigrationPending
+	default:
+		health.Status = DBHealthOK
+	}
+
+	return health, nil
+}
+
+// DBStatus says whether a space's database is ready for use
+type DBStatus struct {
+	Initialized    bool `json:"initialized"`
+	SchemaVersion  int  `json:"schema_version"` // 0 when not initialized
+	LatestVersion  int  `json:"latest_version"`
+	NeedsMigration bool `json:"needs_migration"` // Initialized but at an older schema
+}
+
+// GetDatabaseStatus reports whether a space's space.sqlite is initialized
+// and at the latest schema version. Unlike GetDatabaseHealth it only stats the
+// file and reads the schema version, so it is cheap enough to call before
+// each operation. A file without the space tables counts as uninitialized.
+func (s *SpaceDatabaseService) GetDatabaseStatus(spacePath string) (DBStatus, error) {
+	status := DBStatus{LatestVersion: LatestSchemaVersion()}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	// Check before opening, which would create an empty database
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return status, nil
+	} else if err != nil {
+		return status, fmt.Errorf("failed to stat space database: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return status, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	var tables int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'space_metadata'").Scan(&tables); err != nil {
+		return status, fmt.Errorf("failed to read space database: %w", err)
+	}
+	if tables == 0 {
+		return status, nil
+	}
+
+	version, err := readSchemaVersion(db)
+	if err != nil {
+		return status, err
+	}
+
+	status.Initialized = true
+	status.SchemaVersion = version
+	status.NeedsMigration = version < status.LatestVersion
+	return status, nil
+}
@@ -0,0 +1,142 @@
+package space_test
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestGetDatabaseHealth(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	captureID, notePath := createMockCapture(t, parachuteRoot, "Healthy note")
+	if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "", nil); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	t.Run("HealthyDatabase", func(t *testing.T) {
+		health, err := service.GetDatabaseHealth(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to check health: %v", err)
+		}
+
+		if health.Status != space.DBHealthOK || !health.Exists || !health.UpToDate {
+			t.Errorf("Expected a healthy, current database, got %+v", health)
+		}
+		if health.SchemaVersion != space.LatestSchemaVersion() {
+			t.Errorf("Expected schema version %d, got %d", space.LatestSchemaVersion(), health.SchemaVersion)
+		}
+		if len(health.Integrity) != 1 || health.Integrity[0] != "ok" {
+			t.Errorf("Expected integrity ok, got %v", health.Integrity)
+		}
+		if health.RowCounts["relevant_notes"] != 1 {
+			t.Errorf("Expected 1 relevant_notes row, got %v", health.RowCounts)
+		}
+	})
+
+	t.Run("MissingDatabase", func(t *testing.T) {
+		emptyPath := filepath.Join(parachuteRoot, "spaces", "no-db")
+		if err := os.MkdirAll(emptyPath, 0755); err != nil {
+			t.Fatalf("Failed to create space directory: %v", err)
+		}
+
+		health, err := service.GetDatabaseHealth(emptyPath)
+		if err != nil {
+			t.Fatalf("Failed to check health: %v", err)
+		}
+		if health.Status != space.DBHealthMissing || health.Exists {
+			t.Errorf("Expected missing database, got %+v", health)
+		}
+		if _, err := os.Stat(filepath.Join(emptyPath, "space.sqlite")); !os.IsNotExist(err) {
+			t.Error("Health check should not create the database")
+		}
+	})
+
+	t.Run("OlderSchemaVersion", func(t *testing.T) {
+		db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+		if err != nil {
+			t.Fatalf("Failed to open space database: %v", err)
+		}
+		_, err = db.Exec("UPDATE space_metadata SET value = '2' WHERE key = 'schema_version'")
+		db.Close()
+		if err != nil {
+			t.Fatalf("Failed to downgrade schema version: %v", err)
+		}
+
+		health, err := service.GetDatabaseHealth(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to check health: %v", err)
+		}
+		if health.Status != space.DBHealthMigrationPending || health.UpToDate || health.SchemaVersion != 2 {
+			t.Errorf("Expected migration pending at version 2, got %+v", health)
+		}
+	})
+}
+
+func TestGetDatabaseStatus(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	_, spacePath := setupTestSpace(t, parachuteRoot)
+
+	t.Run("UpToDate", func(t *testing.T) {
+		status, err := service.GetDatabaseStatus(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to get status: %v", err)
+		}
+		want := space.DBStatus{
+			Initialized:   true,
+			SchemaVersion: space.LatestSchemaVersion(),
+			LatestVersion: space.LatestSchemaVersion(),
+		}
+		if status != want {
+			t.Errorf("Expected %+v, got %+v", want, status)
+		}
+	})
+
+	t.Run("Uninitialized", func(t *testing.T) {
+		emptyPath := filepath.Join(parachuteRoot, "spaces", "no-db")
+		if err := os.MkdirAll(emptyPath, 0755); err != nil {
+			t.Fatalf("Failed to create space directory: %v", err)
+		}
+
+		status, err := service.GetDatabaseStatus(emptyPath)
+		if err != nil {
+			t.Fatalf("Failed to get status: %v", err)
+		}
+		if status.Initialized || status.NeedsMigration || status.SchemaVersion != 0 {
+			t.Errorf("Expected an uninitialized database, got %+v", status)
+		}
+		if _, err := os.Stat(filepath.Join(emptyPath, "space.sqlite")); !os.IsNotExist(err) {
+			t.Error("Status check should not create the database")
+		}
+	})
+
+	t.Run("OlderSchemaVersion", func(t *testing.T) {
+		db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+		if err != nil {
+			t.Fatalf("Failed to open space database: %v", err)
+		}
+		_, err = db.Exec("UPDATE space_metadata SET value = '2' WHERE key = 'schema_version'")
+		db.Close()
+		if err != nil {
+			t.Fatalf("Failed to downgrade schema version: %v", err)
+		}
+
+		status, err := service.GetDatabaseStatus(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to get status: %v", err)
+		}
+		if !status.NeedsMigration || status.SchemaVersion != 2 {
+			t.Errorf("Expected a pending migration from version 2, got %+v", status)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package space
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ResetSpaceDatabase gives a space a clean space.sqlite without losing the
+// old one: the existing database is renamed to a timestamped backup next to
+// it (e.g. space.sqlite.20251026-150405.bak) and a fresh database at the
+// latest schema is created under the same space ID. All links, settings and
+// history start over. It holds the space's maintenance lock throughout, and
+// note mutations made through this service fail with ErrSpaceBusy until it
+// finishes; writes that were already under way when it started, or that come
+// from another process, aren't held back. It returns the backup's path.
+func (s *SpaceDatabaseService) ResetSpaceDatabase(spacePath string) (string, error) {
+	if err := s.checkWritable(spacePath); err != nil {
+		return "", err
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return "", fmt.Errorf("space database not found")
+	}
+
+	unlock, err := s.lockSpace(spacePath)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	defer s.markResetting(spacePath)()
+
+	spaceID := s.resetSpaceID(spacePath)
+
+	backupPath, err := resetBackupPath(dbPath, time.Now())
+	if err != nil {
+		return "", err
+	}
+	if err := os.Rename(dbPath, backupPath); err != nil {
+		return "", fmt.Errorf("failed to back up space database: %w", err)
+	}
+
+	if err := s.initializeSpaceDatabase(spaceID, spacePath, applySpaceMigrations); err != nil {
+		return backupPath, fmt.Errorf("failed to create new space database (old one kept at %s): %w", backupPath, err)
+	}
+
+	s.syncManifest(spacePath)
+	return backupPath, nil
+}
+
+// resetSpaceID returns the ID to give a reset space database: the one in the
+// current database, else the space's record, else a new one if neither can
+// be read (e.g. the database is too damaged to open)
+func (s *SpaceDatabaseService) resetSpaceID(spacePath string) string {
+	if spaceID, err := s.spaceIDFromDatabase(spacePath); err == nil && spaceID != "" {
+		return spaceID
+	}
+	if s.spaceRepo != nil {
+		if spaceObj, err := s.spaceRepo.GetByPath(context.Background(), spacePath); err == nil && spaceObj != nil {
+			return spaceObj.ID
+		}
+	}
+	return uuid.New().String()
+}
+
+// resetBackupPath picks an unused backup name for dbPath, adding a counter
+// when a backup from the same second exists
+func resetBackupPath(dbPath string, now time.Time) (string, error) {
+	stamp := now.Format("20060102-150405")
+	for i := 0; i <= maxCollisionSuffix; i++ {
+		name := fmt.Sprintf("%s.%s.bak", dbPath, stamp)
+		if i > 0 {
+			name = fmt.Sprintf("%s.%s-%d.bak", dbPath, stamp, i)
+		}
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("failed to choose a backup name for %s", dbPath)
+}
@@ -0,0 +1,114 @@
+package space_test
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestResetSpaceDatabase(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	for _, captureID := range []string{"walk", "seeds"} {
+		if err := service.LinkNote(spaceID, spacePath, captureID, "captures/"+captureID+".md", "", []string{"garden"}); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	t.Run("RejectedWhileLocked", func(t *testing.T) {
+		unlock, err := service.LockSpace(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to lock space: %v", err)
+		}
+		defer unlock()
+
+		if _, err := service.ResetSpaceDatabase(spacePath); !errors.Is(err, space.ErrSpaceBusy) {
+			t.Errorf("Expected ErrSpaceBusy while locked, got %v", err)
+		}
+	})
+
+	t.Run("WritesRejectedDuringReset", func(t *testing.T) {
+		done := service.MarkResetting(spacePath)
+		defer done()
+
+		if err := service.LinkNote(spaceID, spacePath, "late", "captures/late.md", "", nil); !errors.Is(err, space.ErrSpaceBusy) {
+			t.Errorf("Expected ErrSpaceBusy during a reset, got %v", err)
+		}
+		if err := service.UnlinkNote(spacePath, "walk"); !errors.Is(err, space.ErrSpaceBusy) {
+			t.Errorf("Expected ErrSpaceBusy during a reset, got %v", err)
+		}
+	})
+
+	backupPath, err := service.ResetSpaceDatabase(spacePath)
+	if err != nil {
+		t.Fatalf("Failed to reset space database: %v", err)
+	}
+
+	t.Run("BackupKeepsOldData", func(t *testing.T) {
+		if filepath.Dir(backupPath) != spacePath || !strings.HasPrefix(filepath.Base(backupPath), "space.sqlite.") {
+			t.Errorf("Expected backup next to the database, got %s", backupPath)
+		}
+
+		db, err := sql.Open("sqlite", backupPath)
+		if err != nil {
+			t.Fatalf("Failed to open backup: %v", err)
+		}
+		defer db.Close()
+
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM relevant_notes").Scan(&count); err != nil {
+			t.Fatalf("Failed to count backed up notes: %v", err)
+		}
+		if count != 2 {
+			t.Errorf("Expected 2 notes in the backup, got %d", count)
+		}
+	})
+
+	t.Run("NewDatabaseEmptyAtLatestSchema", func(t *testing.T) {
+		health, err := service.GetDatabaseHealth(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to check health: %v", err)
+		}
+		if health.Status != space.DBHealthOK || health.SchemaVersion != space.LatestSchemaVersion() {
+			t.Errorf("Expected healthy database at schema %d, got %+v", space.LatestSchemaVersion(), health)
+		}
+		if health.RowCounts["relevant_notes"] != 0 || health.RowCounts["deleted_notes"] != 0 {
+			t.Errorf("Expected no notes after reset, got %v", health.RowCounts)
+		}
+
+		if _, err := service.GetNoteByID(spacePath, "walk"); err == nil || err.Error() != "note not found in space" {
+			t.Errorf("Expected old note to be not found rather than gone, got %v", err)
+		}
+	})
+
+	t.Run("KeepsSpaceID", func(t *testing.T) {
+		db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+		if err != nil {
+			t.Fatalf("Failed to open space database: %v", err)
+		}
+		defer db.Close()
+
+		var got string
+		if err := db.QueryRow("SELECT value FROM space_metadata WHERE key = 'space_id'").Scan(&got); err != nil || got != spaceID {
+			t.Errorf("Expected space ID %s kept, got %q (%v)", spaceID, got, err)
+		}
+	})
+
+	t.Run("SecondResetGetsItsOwnBackup", func(t *testing.T) {
+		second, err := service.ResetSpaceDatabase(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to reset again: %v", err)
+		}
+		if second == backupPath {
+			t.Errorf("Expected a new backup path, got %s twice", second)
+		}
+	})
+}
@@ -0,0 +1,16 @@
+package space
+
+import (
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// ErrSpaceReadOnly is returned when a note mutation targets a read-only space
+var ErrSpaceReadOnly = domain.NewForbiddenError("space", "space is read-only")
+
+// ErrSpaceLimitExceeded is returned when a user already has the maximum number of spaces
+var ErrSpaceLimitExceeded = domain.NewForbiddenError("space", "space limit exceeded")
+
+// ErrSpaceBusy is returned when a maintenance operation (a migration, a
+// recompute or a reset) is already running on the space, and by note
+// mutations while the space's database is being reset
+var ErrSpaceBusy = domain.NewConflictError("space", "another maintenance operation is running on this space")
@@ -0,0 +1,50 @@
+package space
+
+import (
+	"database/sql"
+	"os"
+	"time"
+)
+
+// SetReadDir replaces the directory listing used by the capture file existence
+// check and returns a function restoring the original
+func SetReadDir(fn func(string) ([]os.DirEntry, error)) (restore func()) {
+	original := readDir
+	readDir = fn
+	return func() { readDir = original }
+}
+
+// LockSpace takes a space's maintenance lock
+func (s *SpaceDatabaseService) LockSpace(spacePath string) (unlock func(), err error) {
+	return s.lockSpace(spacePath)
+}
+
+// MarkResetting flags a space as having its database reset, as
+// ResetSpaceDatabase does, until the returned function is called
+func (s *SpaceDatabaseService) MarkResetting(spacePath string) (done func()) {
+	return s.markResetting(spacePath)
+}
+
+// SetRemoveMovedNote replaces the step of MoveNoteToSpace that unlinks the
+// note from its source space and returns a function restoring the original
+func SetRemoveMovedNote(fn func(spacePath, captureID string) error) (restore func()) {
+	original := removeMovedNote
+	removeMovedNote = fn
+	return func() { removeMovedNote = original }
+}
+
+// SetOpenContextDB replaces how ContextService opens the space database for
+// variable resolution and returns a function restoring the original
+func SetOpenContextDB(fn func(dbPath string) (*sql.DB, error)) (restore func()) {
+	original := openContextDB
+	openContextDB = fn
+	return func() { openContextDB = original }
+}
+
+// SetMigrationWait replaces how long migrations wait for a locked space for
+// the duration of a test
+func SetMigrationWait(wait time.Duration) (restore func()) {
+	original := migrationWait
+	migrationWait = wait
+	return func() { migrationWait = original }
+}
@@ -0,0 +1,93 @@
+package space
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SetFeaturedNotes replaces a space's featured notes, a hand-picked list
+// rendered in order by {{featured_notes}}. Every ID must be linked to the
+// space; an empty list clears the featured notes. Unlinking a note also
+// removes it from the list.
+func (s *SpaceDatabaseService) SetFeaturedNotes(spacePath string, captureIDs []string) error {
+	if err := s.checkWritable(spacePath); err != nil {
+		return err
+	}
+
+	if err := s.requireLinked(spacePath, captureIDs); err != nil {
+		return err
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin featured notes update: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM featured_notes"); err != nil {
+		return fmt.Errorf("failed to clear featured notes: %w", err)
+	}
+	for position, id := range captureIDs {
+		if _, err := tx.Exec("INSERT INTO featured_notes (capture_id, position) VALUES (?, ?)", id, position); err != nil {
+			return fmt.Errorf("failed to feature note: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit featured notes: %w", err)
+	}
+
+	return bumpContextVersion(db)
+}
+
+// GetFeaturedNotes returns a space's featured notes in their curated order
+func (s *SpaceDatabaseService) GetFeaturedNotes(spacePath string) ([]RelevantNote, error) {
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return []RelevantNote{}, nil
+	}
+
+	ids, err := featuredNoteIDs(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetNotesByIDs(spacePath, ids)
+}
+
+// featuredNoteIDs reads the featured capture IDs in order
+func featuredNoteIDs(dbPath string) ([]string, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT capture_id FROM featured_notes ORDER BY position")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query featured notes: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan featured note: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
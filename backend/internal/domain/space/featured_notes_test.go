@@ -0,0 +1,92 @@
+package space_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestFeaturedNotes(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(service)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	ids := map[string]string{}
+	for _, name := range []string{"beds", "compost", "garlic"} {
+		captureID, notePath := createNamedCapture(t, parachuteRoot, name+".md", name)
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		ids[name] = captureID
+	}
+
+	render := func(t *testing.T) string {
+		result, err := contextService.ResolveVariables("{{featured_notes}}", spacePath)
+		if err != nil {
+			t.Fatalf("Failed to resolve variables: %v", err)
+		}
+		return result
+	}
+
+	t.Run("EmptyByDefault", func(t *testing.T) {
+		if got := render(t); got != "none" {
+			t.Errorf("Expected none, got %q", got)
+		}
+	})
+
+	t.Run("PreservesOrder", func(t *testing.T) {
+		if err := service.SetFeaturedNotes(spacePath, []string{ids["garlic"], ids["beds"]}); err != nil {
+			t.Fatalf("Failed to set featured notes: %v", err)
+		}
+
+		featured, err := service.GetFeaturedNotes(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to get featured notes: %v", err)
+		}
+		if len(featured) != 2 || featured[0].CaptureID != ids["garlic"] || featured[1].CaptureID != ids["beds"] {
+			t.Errorf("Expected garlic then beds, got %+v", featured)
+		}
+
+		got := render(t)
+		want := "- garlic.md (" + featured[0].LinkedAt.Format("Jan 2") + ")\n- beds.md (" + featured[1].LinkedAt.Format("Jan 2") + ")"
+		if got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("RemovingUpdatesOutput", func(t *testing.T) {
+		if err := service.SetFeaturedNotes(spacePath, []string{ids["beds"]}); err != nil {
+			t.Fatalf("Failed to set featured notes: %v", err)
+		}
+		if got := render(t); !strings.HasPrefix(got, "- beds.md (") || strings.Contains(got, "garlic") {
+			t.Errorf("Expected only beds, got %q", got)
+		}
+
+		// Unlinking a featured note drops it too
+		if err := service.UnlinkNote(spacePath, ids["beds"]); err != nil {
+			t.Fatalf("Failed to unlink note: %v", err)
+		}
+		if got := render(t); got != "none" {
+			t.Errorf("Expected none after unlinking, got %q", got)
+		}
+	})
+
+	t.Run("RejectsInvalidIDs", func(t *testing.T) {
+		for name, captureIDs := range map[string][]string{
+			"NotLinked": {ids["garlic"], "missing"},
+			"Duplicate": {ids["garlic"], ids["garlic"]},
+		} {
+			err := service.SetFeaturedNotes(spacePath, captureIDs)
+			var validationErr *domain.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Errorf("%s: expected validation error, got %v", name, err)
+			}
+		}
+	})
+}
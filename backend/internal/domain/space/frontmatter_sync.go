@@ -0,0 +1,136 @@
+package space
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/file"
+)
+
+// frontmatterNamespace prefixes the frontmatter keys written for a space's
+// link, e.g. "parachute.spaces.<space_id>.context"
+const frontmatterNamespace = "parachute.spaces."
+
+// SyncLinkToFrontmatter writes a linked note's space context and tags into its
+// capture file's frontmatter, under keys namespaced by the space ID, so the
+// relationship survives outside the app. Other frontmatter keys are left as
+// they are, and re-syncing replaces the space's keys rather than adding more.
+// The file is found as ResolveNoteFile finds it, so notes kept in the space's
+// captures_dir are synced too.
+func (s *SpaceDatabaseService) SyncLinkToFrontmatter(spacePath, captureID string) error {
+	note, err := s.GetNoteByID(spacePath, captureID)
+	if err != nil {
+		return err
+	}
+
+	spaceID, err := s.spaceIDFromDatabase(spacePath)
+	if err != nil {
+		return err
+	}
+
+	fullPath, err := s.ResolveNoteFile(spacePath, note.NotePath)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return domain.NewNotFoundError("capture file", note.NotePath)
+		}
+		return fmt.Errorf("failed to stat capture: %w", err)
+	}
+
+	content, err := s.ReadCapture(fullPath)
+	if err != nil {
+		return fmt.Errorf("failed to read capture: %w", err)
+	}
+
+	contextJSON, err := json.Marshal(note.Context)
+	if err != nil {
+		return fmt.Errorf("failed to marshal context: %w", err)
+	}
+	tags := note.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	tagsJSON, err := json.Marshal(tags)
+	if err != nil {
+		return fmt.Errorf("failed to marshal tags: %w", err)
+	}
+
+	// JSON strings and arrays are also valid YAML scalars and flow sequences
+	prefix := frontmatterNamespace + spaceID + "."
+	updated := mergeFrontmatter(string(content), prefix, []string{
+		prefix + "context: " + string(contextJSON),
+		prefix + "tags: " + string(tagsJSON),
+	})
+	if updated == string(content) {
+		return nil
+	}
+
+	if err := os.WriteFile(fullPath, []byte(updated), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write capture: %w", err)
+	}
+	return nil
+}
+
+// syncFrontmatter syncs the given notes to their capture files when the
+// frontmatter_sync setting is on. Like context auditing, syncing is best
+// effort and never fails the change that triggered it.
+func (s *SpaceDatabaseService) syncFrontmatter(spacePath string, captureIDs ...string) {
+	if !s.boolSetting(spacePath, SettingFrontmatterSync) {
+		return
+	}
+
+	for _, captureID := range captureIDs {
+		_ = s.SyncLinkToFrontmatter(spacePath, captureID)
+	}
+}
+
+// spaceIDFromDatabase returns the space ID recorded in space.sqlite
+func (s *SpaceDatabaseService) spaceIDFromDatabase(spacePath string) (string, error) {
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		return "", fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	var spaceID string
+	if err := db.QueryRow("SELECT value FROM space_metadata WHERE key = 'space_id'").Scan(&spaceID); err != nil {
+		return "", fmt.Errorf("failed to read space id: %w", err)
+	}
+	return spaceID, nil
+}
+
+// mergeFrontmatter replaces the frontmatter lines whose key starts with prefix
+// by lines, adding a frontmatter block if the content has none. Lines are
+// appended after the block's other keys, which keep their order, and the
+// block is written with the content's own line endings.
+func mergeFrontmatter(content, prefix string, lines []string) string {
+	newline := "\n"
+	if end := strings.IndexByte(content, '\n'); end > 0 && content[end-1] == '\r' {
+		newline = "\r\n"
+	}
+
+	body := content
+	var kept []string
+
+	if block, bodyStart, ok := file.SplitFrontmatter(content); ok {
+		for _, line := range block {
+			key, _, _ := strings.Cut(line, ":")
+			if strings.HasPrefix(strings.TrimSpace(key), prefix) {
+				continue
+			}
+			kept = append(kept, line)
+		}
+		body = content[bodyStart:]
+	}
+
+	return "---" + newline + strings.Join(append(kept, lines...), newline) + newline + "---" + newline + body
+}
@@ -0,0 +1,172 @@
+package space_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/file"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestSyncLinkToFrontmatter(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+	prefix := "parachute.spaces." + spaceID + "."
+
+	readCapture := func(t *testing.T, notePath string) string {
+		content, err := os.ReadFile(filepath.Join(parachuteRoot, notePath))
+		if err != nil {
+			t.Fatalf("Failed to read capture: %v", err)
+		}
+		return string(content)
+	}
+
+	t.Run("AddsNamespacedKeys", func(t *testing.T) {
+		captureID, notePath := createNamedCapture(t, parachuteRoot, "tagged.md", "---\ntitle: Soil test\nsource: phone\n---\nPH was 6.2.\n")
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "Soil: acidic", []string{"soil", "garden"}); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+
+		if err := service.SyncLinkToFrontmatter(spacePath, captureID); err != nil {
+			t.Fatalf("Failed to sync frontmatter: %v", err)
+		}
+
+		content := readCapture(t, notePath)
+		frontmatter := file.ParseCaptureDetailed(content).Frontmatter
+		if frontmatter[prefix+"context"] != `"Soil: acidic"` {
+			t.Errorf("Expected context key, got %q", frontmatter[prefix+"context"])
+		}
+		if frontmatter[prefix+"tags"] != `["soil","garden"]` {
+			t.Errorf("Expected tags key, got %q", frontmatter[prefix+"tags"])
+		}
+		if frontmatter["title"] != "Soil test" || frontmatter["source"] != "phone" {
+			t.Errorf("Expected existing keys to be kept, got %v", frontmatter)
+		}
+		if !strings.HasSuffix(content, "\n---\nPH was 6.2.\n") {
+			t.Errorf("Expected body to be unchanged, got %q", content)
+		}
+
+		// Re-syncing after an update replaces the keys in place
+		context := "Soil: limed"
+		if err := service.UpdateNoteContext(spacePath, captureID, &context, nil); err != nil {
+			t.Fatalf("Failed to update context: %v", err)
+		}
+		for i := 0; i < 2; i++ {
+			if err := service.SyncLinkToFrontmatter(spacePath, captureID); err != nil {
+				t.Fatalf("Failed to sync frontmatter: %v", err)
+			}
+		}
+
+		content = readCapture(t, notePath)
+		if n := strings.Count(content, prefix+"context:"); n != 1 {
+			t.Errorf("Expected one context key, got %d in %q", n, content)
+		}
+		if n := strings.Count(content, "title:"); n != 1 {
+			t.Errorf("Expected one title key, got %d in %q", n, content)
+		}
+		if frontmatter := file.ParseCaptureDetailed(content).Frontmatter; frontmatter[prefix+"context"] != `"Soil: limed"` {
+			t.Errorf("Expected updated context, got %q", frontmatter[prefix+"context"])
+		}
+	})
+
+	t.Run("AddsFrontmatterBlock", func(t *testing.T) {
+		captureID, notePath := createNamedCapture(t, parachuteRoot, "plain.md", "# Compost\nTurned the pile.\n")
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+
+		if err := service.SyncLinkToFrontmatter(spacePath, captureID); err != nil {
+			t.Fatalf("Failed to sync frontmatter: %v", err)
+		}
+
+		want := "---\n" + prefix + "context: \"\"\n" + prefix + "tags: []\n---\n# Compost\nTurned the pile.\n"
+		if got := readCapture(t, notePath); got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("TolerantFrontmatter", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			content string
+			want    string
+		}{
+			{"CRLF", "---\r\ntitle: Walk\r\n---\r\nBody\r\n", "---\r\ntitle: Walk\r\n" + prefix + "context: \"\"\r\n" + prefix + "tags: []\r\n---\r\nBody\r\n"},
+			{"EmptyBlock", "---\n---\nBody\n", "---\n" + prefix + "context: \"\"\n" + prefix + "tags: []\n---\nBody\n"},
+			{"ClosingAtEOF", "---\ntitle: Walk\n---", "---\ntitle: Walk\n" + prefix + "context: \"\"\n" + prefix + "tags: []\n---\n"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				captureID, notePath := createNamedCapture(t, parachuteRoot, "tolerant-"+tt.name+".md", tt.content)
+				if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "", nil); err != nil {
+					t.Fatalf("Failed to link note: %v", err)
+				}
+
+				for i := 0; i < 2; i++ {
+					if err := service.SyncLinkToFrontmatter(spacePath, captureID); err != nil {
+						t.Fatalf("Failed to sync frontmatter: %v", err)
+					}
+				}
+				if got := readCapture(t, notePath); got != tt.want {
+					t.Errorf("Expected %q, got %q", tt.want, got)
+				}
+			})
+		}
+	})
+
+	t.Run("CapturesDir", func(t *testing.T) {
+		otherID, otherPath := setupTestSpace(t, parachuteRoot)
+		if err := service.SetSetting(otherPath, space.SettingCapturesDir, "research/captures"); err != nil {
+			t.Fatalf("Failed to set captures_dir: %v", err)
+		}
+		researchDir := filepath.Join(parachuteRoot, "research", "captures")
+		if err := os.MkdirAll(researchDir, 0755); err != nil {
+			t.Fatalf("Failed to create research dir: %v", err)
+		}
+		filename := "2024-07-03_09-00-00.md"
+		if err := os.WriteFile(filepath.Join(researchDir, filename), []byte("Trial plot.\n"), 0644); err != nil {
+			t.Fatalf("Failed to write capture: %v", err)
+		}
+
+		if err := service.LinkNote(otherID, otherPath, "research-note", filename, "Trials", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		if err := service.SyncLinkToFrontmatter(otherPath, "research-note"); err != nil {
+			t.Fatalf("Failed to sync frontmatter: %v", err)
+		}
+
+		content := readCapture(t, filepath.Join("research", "captures", filename))
+		if got := file.ParseCaptureDetailed(content).Frontmatter["parachute.spaces."+otherID+".context"]; got != `"Trials"` {
+			t.Errorf("Expected the captures_dir note to be synced, got %q", content)
+		}
+	})
+
+	t.Run("OptInSetting", func(t *testing.T) {
+		captureID, notePath := createNamedCapture(t, parachuteRoot, "auto.md", "Mulched.\n")
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "Beds", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		if content := readCapture(t, notePath); content != "Mulched.\n" {
+			t.Errorf("Expected no sync while the setting is off, got %q", content)
+		}
+
+		if err := service.SetSetting(spacePath, space.SettingFrontmatterSync, "true"); err != nil {
+			t.Fatalf("Failed to enable frontmatter sync: %v", err)
+		}
+		tags := []string{"mulch"}
+		if err := service.UpdateNoteContext(spacePath, captureID, nil, &tags); err != nil {
+			t.Fatalf("Failed to update tags: %v", err)
+		}
+
+		frontmatter := file.ParseCaptureDetailed(readCapture(t, notePath)).Frontmatter
+		if frontmatter[prefix+"tags"] != `["mulch"]` || frontmatter[prefix+"context"] != `"Beds"` {
+			t.Errorf("Expected the update to sync, got %v", frontmatter)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package space
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/unforced/parachute-backend/internal/domain/file"
+)
+
+// LinkOptions adjusts how LinkNoteWithOptions links a note
+type LinkOptions struct {
+	// InheritFrontmatterTags merges the tags listed in the capture file's
+	// frontmatter into the link's tags. Nil uses the service default.
+	InheritFrontmatterTags *bool
+
+	// CapturedAt records when the note was really captured, overriding the
+	// time parsed from its filename (e.g. for notes migrated from another
+	// tool). Nil keeps any value set by an earlier link.
+	CapturedAt *time.Time
+
+	// ParentCaptureID links the note as a reply to another note linked in
+	// the space (see GetThread). Empty keeps any parent set earlier.
+	ParentCaptureID string
+
+	// SourceURL records the web page a clipped note came from. It must be an
+	// absolute http or https URL. Empty keeps any URL set earlier.
+	SourceURL string
+}
+
+// SetInheritFrontmatterTags sets whether linking a note merges the tags from
+// its capture file's frontmatter into the provided tags by default. It is on
+// unless turned off here or per call with LinkOptions.
+func (s *SpaceDatabaseService) SetInheritFrontmatterTags(enabled bool) {
+	s.inheritTags = enabled
+}
+
+// LinkNoteWithOptions is LinkNote with per-call options
+func (s *SpaceDatabaseService) LinkNoteWithOptions(spaceID, spacePath, captureID, notePath, context string, tags []string, opts LinkOptions) error {
+	if err := s.checkWritable(spacePath); err != nil {
+		return err
+	}
+	return s.withBusyRetry(func() error {
+		return s.linkNote(spaceID, spacePath, captureID, notePath, context, tags, "", opts)
+	})
+}
+
+// frontmatterTags returns the frontmatter tags of a note's capture file. A
+// missing or unreadable file has no tags, and tags too long to store are
+// dropped, so inheriting never fails a link.
+func (s *SpaceDatabaseService) frontmatterTags(spacePath, notePath string) []string {
+	fullPath, err := s.ResolveNoteFile(spacePath, notePath)
+	if err != nil {
+		return nil
+	}
+
+	content, err := s.ReadCapture(fullPath)
+	if err != nil {
+		return nil
+	}
+	return inheritableTags(string(content))
+}
+
+// inheritableTags returns the frontmatter tags in content that fit within
+// MaxTagLength
+func inheritableTags(content string) []string {
+	var tags []string
+	for _, tag := range file.FrontmatterTags(content) {
+		if utf8.RuneCountInString(tag) <= MaxTagLength {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
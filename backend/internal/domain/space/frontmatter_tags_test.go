@@ -0,0 +1,80 @@
+package space_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestInheritFrontmatterTags(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+	content := "---\ntitle: Soil test\ntags: [soil, garden]\n---\nPH was 6.2.\n"
+
+	linkedTags := func(t *testing.T, captureID string) []string {
+		note, err := service.GetNoteByID(spacePath, captureID)
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		return note.Tags
+	}
+
+	t.Run("MergesWithProvidedTags", func(t *testing.T) {
+		captureID, notePath := createNamedCapture(t, parachuteRoot, "merged.md", content)
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "", []string{"compost", "soil"}); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+
+		want := []string{"compost", "soil", "garden"}
+		if got := linkedTags(t, captureID); !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("OptOut", func(t *testing.T) {
+		captureID, notePath := createNamedCapture(t, parachuteRoot, "optout.md", content)
+		inherit := false
+		if err := service.LinkNoteWithOptions(spaceID, spacePath, captureID, notePath, "", []string{"compost"}, space.LinkOptions{InheritFrontmatterTags: &inherit}); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+
+		if got := linkedTags(t, captureID); !reflect.DeepEqual(got, []string{"compost"}) {
+			t.Errorf("Expected only the provided tags, got %v", got)
+		}
+	})
+
+	t.Run("ServiceDefault", func(t *testing.T) {
+		service.SetInheritFrontmatterTags(false)
+		defer service.SetInheritFrontmatterTags(true)
+
+		captureID, notePath := createNamedCapture(t, parachuteRoot, "default.md", content)
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		if got := linkedTags(t, captureID); len(got) != 0 {
+			t.Errorf("Expected no tags with inheriting off, got %v", got)
+		}
+
+		inherit := true
+		captureID, notePath = createNamedCapture(t, parachuteRoot, "override.md", content)
+		if err := service.LinkNoteWithOptions(spaceID, spacePath, captureID, notePath, "", nil, space.LinkOptions{InheritFrontmatterTags: &inherit}); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		if got := linkedTags(t, captureID); !reflect.DeepEqual(got, []string{"soil", "garden"}) {
+			t.Errorf("Expected the per-call option to win, got %v", got)
+		}
+	})
+
+	t.Run("MissingFile", func(t *testing.T) {
+		if err := service.LinkNote(spaceID, spacePath, "missing", "captures/missing.md", "", []string{"compost"}); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		if got := linkedTags(t, "missing"); !reflect.DeepEqual(got, []string{"compost"}) {
+			t.Errorf("Expected only the provided tags, got %v", got)
+		}
+	})
+}
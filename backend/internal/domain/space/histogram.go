@@ -0,0 +1,164 @@
+package space
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// Bucket sizes accepted by GetNoteHistogram and GetTagTimeline
+const (
+	HistogramBucketDay   = "day"
+	HistogramBucketWeek  = "week" // Weeks start on Monday
+	HistogramBucketMonth = "month"
+)
+
+// Timestamps GetNoteHistogram can bucket notes by
+const (
+	HistogramFieldLinkedAt   = "linked_at"   // When the note was linked to the space
+	HistogramFieldCapturedAt = "captured_at" // Set at link time, else parsed from the capture filename
+)
+
+// captureFilenameLayout is the timestamp prefix of capture filenames, e.g.
+// "2025-10-26_00-00-17.md"
+const captureFilenameLayout = "2006-01-02_15-04-05"
+
+// HistogramBucket counts the notes whose timestamp falls in the bucket
+// starting at Start
+type HistogramBucket struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+}
+
+// GetNoteHistogram counts a space's notes per day, week or month of the given
+// field. Buckets run from the earliest to the latest note with empty buckets
+// included, so the result can be charted directly. With captured_at, notes
+// with no capture time (none set when linking, and no timestamp at the start
+// of the filename) are skipped.
+func (s *SpaceDatabaseService) GetNoteHistogram(spacePath, bucket, field string) ([]HistogramBucket, error) {
+	if err := validateHistogramBucket(bucket); err != nil {
+		return nil, err
+	}
+	if field != HistogramFieldLinkedAt && field != HistogramFieldCapturedAt {
+		return nil, domain.NewValidationError("field", fmt.Sprintf("must be %s or %s",
+			HistogramFieldLinkedAt, HistogramFieldCapturedAt))
+	}
+
+	notes, err := s.GetRelevantNotes(spacePath, NoteFilters{})
+	if err != nil {
+		return nil, err
+	}
+
+	return histogram(notes, bucket, field)
+}
+
+// GetTagTimeline counts the notes carrying tag per day, week or month of
+// linking, from the first such note to the last with empty buckets included
+func (s *SpaceDatabaseService) GetTagTimeline(spacePath, tag, bucket string) ([]HistogramBucket, error) {
+	if err := validateHistogramBucket(bucket); err != nil {
+		return nil, err
+	}
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return nil, domain.NewValidationError("tag", "tag is required")
+	}
+
+	notes, err := s.GetRelevantNotes(spacePath, NoteFilters{Tags: []string{tag}})
+	if err != nil {
+		return nil, err
+	}
+
+	return histogram(notes, bucket, HistogramFieldLinkedAt)
+}
+
+// validateHistogramBucket checks a bucket size
+func validateHistogramBucket(bucket string) error {
+	if bucket != HistogramBucketDay && bucket != HistogramBucketWeek && bucket != HistogramBucketMonth {
+		return domain.NewValidationError("bucket", fmt.Sprintf("must be one of %s, %s, %s",
+			HistogramBucketDay, HistogramBucketWeek, HistogramBucketMonth))
+	}
+	return nil
+}
+
+// histogram counts notes per bucket of field, zero-filling the buckets
+// between the earliest and latest. Spans of more than maxAnalyticsBuckets
+// (e.g. from a stray captured_at decades off) are rejected rather than filled.
+func histogram(notes []RelevantNote, bucket, field string) ([]HistogramBucket, error) {
+	counts := make(map[time.Time]int)
+	var first, last time.Time
+	for _, note := range notes {
+		timestamp := note.LinkedAt
+		if field == HistogramFieldCapturedAt {
+			if note.CapturedAt == nil {
+				continue
+			}
+			timestamp = *note.CapturedAt
+		}
+
+		start := bucketStart(timestamp, bucket)
+		counts[start]++
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if start.After(last) {
+			last = start
+		}
+	}
+
+	buckets := []HistogramBucket{}
+	if len(counts) == 0 {
+		return buckets, nil
+	}
+	for start := first; !start.After(last); start = nextBucket(start, bucket) {
+		if len(buckets) == maxAnalyticsBuckets {
+			return nil, domain.NewValidationError("bucket", fmt.Sprintf("notes span more than %d buckets", maxAnalyticsBuckets))
+		}
+		buckets = append(buckets, HistogramBucket{Start: start, Count: counts[start]})
+	}
+
+	return buckets, nil
+}
+
+// capturedAt parses the capture time from a note's filename
+func capturedAt(notePath string) (time.Time, bool) {
+	name := filepath.Base(notePath)
+	if len(name) < len(captureFilenameLayout) {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(captureFilenameLayout, name[:len(captureFilenameLayout)], time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// bucketStart returns local midnight at the start of the bucket containing t
+func bucketStart(t time.Time, bucket string) time.Time {
+	t = t.In(time.Local)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+
+	switch bucket {
+	case HistogramBucketWeek:
+		daysSinceMonday := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -daysSinceMonday)
+	case HistogramBucketMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.Local)
+	default:
+		return day
+	}
+}
+
+// nextBucket returns the start of the bucket after the one starting at start
+func nextBucket(start time.Time, bucket string) time.Time {
+	switch bucket {
+	case HistogramBucketWeek:
+		return start.AddDate(0, 0, 7)
+	case HistogramBucketMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
@@ -0,0 +1,193 @@
+package space_test
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestGetNoteHistogram(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	// 2024-03-04 is a Monday; the week of 2024-03-11 has no notes
+	files := []string{
+		"2024-03-04_09-00-00.md",
+		"2024-03-06_18-30-00.md",
+		"2024-03-21_07-15-00.md",
+		"untimestamped.md",
+	}
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to open space database: %v", err)
+	}
+	for i, file := range files {
+		captureID, notePath := createNamedCapture(t, parachuteRoot, file, "Note")
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		linkedAt := time.Date(2024, 5, 1+i/2, 12, 0, 0, 0, time.Local).Unix()
+		if _, err := db.Exec("UPDATE relevant_notes SET linked_at = ? WHERE capture_id = ?", linkedAt, captureID); err != nil {
+			t.Fatalf("Failed to set linked_at: %v", err)
+		}
+	}
+	db.Close()
+
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2024, month, d, 0, 0, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name   string
+		bucket string
+		field  string
+		want   []space.HistogramBucket
+	}{
+		{
+			name:   "WeeklyCapturedAtWithEmptyWeek",
+			bucket: space.HistogramBucketWeek,
+			field:  space.HistogramFieldCapturedAt,
+			want: []space.HistogramBucket{
+				{Start: day(3, 4), Count: 2},
+				{Start: day(3, 11), Count: 0},
+				{Start: day(3, 18), Count: 1},
+			},
+		},
+		{
+			name:   "MonthlyCapturedAt",
+			bucket: space.HistogramBucketMonth,
+			field:  space.HistogramFieldCapturedAt,
+			want:   []space.HistogramBucket{{Start: day(3, 1), Count: 3}},
+		},
+		{
+			name:   "DailyLinkedAt",
+			bucket: space.HistogramBucketDay,
+			field:  space.HistogramFieldLinkedAt,
+			want: []space.HistogramBucket{
+				{Start: day(5, 1), Count: 2},
+				{Start: day(5, 2), Count: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := service.GetNoteHistogram(spacePath, tt.bucket, tt.field)
+			if err != nil {
+				t.Fatalf("Failed to get histogram: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %d buckets, got %+v", len(tt.want), got)
+			}
+			for i := range got {
+				if !got[i].Start.Equal(tt.want[i].Start) || got[i].Count != tt.want[i].Count {
+					t.Errorf("Bucket %d: expected %v with %d, got %v with %d",
+						i, tt.want[i].Start, tt.want[i].Count, got[i].Start, got[i].Count)
+				}
+			}
+		})
+	}
+
+	t.Run("RejectsUnknownBucket", func(t *testing.T) {
+		var validationErr *domain.ValidationError
+		if _, err := service.GetNoteHistogram(spacePath, "year", space.HistogramFieldLinkedAt); !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error, got %v", err)
+		}
+	})
+
+	t.Run("RejectsOversizedSpan", func(t *testing.T) {
+		db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+		if err != nil {
+			t.Fatalf("Failed to open space database: %v", err)
+		}
+		defer db.Close()
+		if _, err := db.Exec("UPDATE relevant_notes SET captured_at = ? WHERE note_path LIKE '%untimestamped.md'", day(1, 1).AddDate(-30, 0, 0).Unix()); err != nil {
+			t.Fatalf("Failed to set captured_at: %v", err)
+		}
+
+		var validationErr *domain.ValidationError
+		if _, err := service.GetNoteHistogram(spacePath, space.HistogramBucketDay, space.HistogramFieldCapturedAt); !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error for a daily span of 30 years, got %v", err)
+		}
+		if buckets, err := service.GetNoteHistogram(spacePath, space.HistogramBucketMonth, space.HistogramFieldCapturedAt); err != nil || len(buckets) != 30*12+3 {
+			t.Errorf("Expected monthly buckets to fit, got %d buckets, %v", len(buckets), err)
+		}
+	})
+}
+
+func TestGetTagTimeline(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	// compost notes in January (2), March and April, none in February;
+	// the February note is tagged differently
+	links := []struct {
+		captureID string
+		tags      []string
+		linkedAt  time.Time
+	}{
+		{"jan-a", []string{"compost"}, time.Date(2024, 1, 5, 12, 0, 0, 0, time.Local)},
+		{"jan-b", []string{"compost", "garden"}, time.Date(2024, 1, 28, 12, 0, 0, 0, time.Local)},
+		{"feb", []string{"garden"}, time.Date(2024, 2, 14, 12, 0, 0, 0, time.Local)},
+		{"mar", []string{"compost"}, time.Date(2024, 3, 2, 12, 0, 0, 0, time.Local)},
+		{"apr", []string{"compost"}, time.Date(2024, 4, 30, 12, 0, 0, 0, time.Local)},
+	}
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to open space database: %v", err)
+	}
+	for _, l := range links {
+		if err := service.LinkNote(spaceID, spacePath, l.captureID, "captures/"+l.captureID+".md", "", l.tags); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		if _, err := db.Exec("UPDATE relevant_notes SET linked_at = ? WHERE capture_id = ?", l.linkedAt.Unix(), l.captureID); err != nil {
+			t.Fatalf("Failed to set linked_at: %v", err)
+		}
+	}
+	db.Close()
+
+	t.Run("MonthlyWithEmptyMonth", func(t *testing.T) {
+		got, err := service.GetTagTimeline(spacePath, "compost", space.HistogramBucketMonth)
+		if err != nil {
+			t.Fatalf("Failed to get timeline: %v", err)
+		}
+		want := []int{2, 0, 1, 1}
+		if len(got) != len(want) {
+			t.Fatalf("Expected %d months, got %+v", len(want), got)
+		}
+		for i, count := range want {
+			start := time.Date(2024, time.Month(1+i), 1, 0, 0, 0, 0, time.Local)
+			if !got[i].Start.Equal(start) || got[i].Count != count {
+				t.Errorf("Month %d: expected %v with %d, got %v with %d", i, start, count, got[i].Start, got[i].Count)
+			}
+		}
+	})
+
+	t.Run("UnusedTag", func(t *testing.T) {
+		got, err := service.GetTagTimeline(spacePath, "kitchen", space.HistogramBucketMonth)
+		if err != nil || len(got) != 0 {
+			t.Errorf("Expected no buckets, got %+v (%v)", got, err)
+		}
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		var validationErr *domain.ValidationError
+		if _, err := service.GetTagTimeline(spacePath, " ", space.HistogramBucketMonth); !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error for empty tag, got %v", err)
+		}
+		if _, err := service.GetTagTimeline(spacePath, "compost", "year"); !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error for unknown bucket, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package space
+
+import (
+	"context"
+	"fmt"
+)
+
+// DefaultMaxSpacesPerUser is the per-user space limit applied unless configured otherwise
+const DefaultMaxSpacesPerUser = 100
+
+// SpaceLimits configures how many spaces each user may create
+type SpaceLimits struct {
+	MaxSpacesPerUser int            // Global default; 0 or less means unlimited
+	PerUser          map[string]int // Overrides of MaxSpacesPerUser for specific users
+	CountArchived    bool           // Whether archived spaces count toward the limit
+}
+
+// DefaultSpaceLimits returns the limits used by a new Service. Archived
+// spaces count toward the limit, as they still take up a directory and a
+// database.
+func DefaultSpaceLimits() SpaceLimits {
+	return SpaceLimits{MaxSpacesPerUser: DefaultMaxSpacesPerUser, CountArchived: true}
+}
+
+// limitFor returns the space limit for a user (0 or less means unlimited)
+func (l SpaceLimits) limitFor(userID string) int {
+	if limit, ok := l.PerUser[userID]; ok {
+		return limit
+	}
+	return l.MaxSpacesPerUser
+}
+
+// SetSpaceLimits replaces the per-user space limits
+func (s *Service) SetSpaceLimits(limits SpaceLimits) {
+	s.limits = limits
+}
+
+// checkSpaceLimit returns ErrSpaceLimitExceeded if the user cannot create another space
+func (s *Service) checkSpaceLimit(ctx context.Context, userID string) error {
+	limit := s.limits.limitFor(userID)
+	if limit <= 0 {
+		return nil
+	}
+
+	spaces, err := s.repo.List(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to count spaces: %w", err)
+	}
+
+	count := 0
+	for _, sp := range spaces {
+		if s.limits.CountArchived || !sp.Archived {
+			count++
+		}
+	}
+
+	if count >= limit {
+		return ErrSpaceLimitExceeded
+	}
+
+	return nil
+}
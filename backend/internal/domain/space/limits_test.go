@@ -0,0 +1,97 @@
+package space_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+	sqliteStorage "github.com/unforced/parachute-backend/internal/storage/sqlite"
+)
+
+func TestSpaceLimits(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	db, err := sqliteStorage.NewDatabase(filepath.Join(parachuteRoot, "parachute.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	service := space.NewService(sqliteStorage.NewSpaceRepository(db.DB), parachuteRoot)
+	service.SetSpaceLimits(space.SpaceLimits{
+		MaxSpacesPerUser: 2,
+		PerUser:          map[string]int{"importer": 0},
+	})
+
+	create := func(userID, name string) (*space.Space, error) {
+		return service.Create(ctx, userID, space.CreateSpaceParams{Name: name})
+	}
+
+	t.Run("RejectsBeyondLimit", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			if _, err := create("alice", fmt.Sprintf("Alice %d", i)); err != nil {
+				t.Fatalf("Expected space %d within the limit, got %v", i, err)
+			}
+		}
+
+		if _, err := create("alice", "Alice extra"); !errors.Is(err, space.ErrSpaceLimitExceeded) {
+			t.Errorf("Expected ErrSpaceLimitExceeded, got %v", err)
+		}
+	})
+
+	t.Run("DeletingFreesSlot", func(t *testing.T) {
+		spaces, err := service.List(ctx, "alice")
+		if err != nil {
+			t.Fatalf("Failed to list spaces: %v", err)
+		}
+		if err := service.Delete(ctx, spaces[0].ID); err != nil {
+			t.Fatalf("Failed to delete space: %v", err)
+		}
+
+		if _, err := create("alice", "Alice replacement"); err != nil {
+			t.Errorf("Expected creation to succeed after delete, got %v", err)
+		}
+	})
+
+	t.Run("LimitsArePerUser", func(t *testing.T) {
+		if _, err := create("bob", "Bob 0"); err != nil {
+			t.Errorf("Expected another user to be unaffected, got %v", err)
+		}
+	})
+
+	t.Run("ArchivedSpaces", func(t *testing.T) {
+		service.SetSpaceLimits(space.SpaceLimits{MaxSpacesPerUser: 1, CountArchived: true})
+		defer service.SetSpaceLimits(space.SpaceLimits{MaxSpacesPerUser: 2, PerUser: map[string]int{"importer": 0}})
+
+		archivedSpace, err := create("carol", "Carol archived")
+		if err != nil {
+			t.Fatalf("Failed to create space: %v", err)
+		}
+		archived := true
+		if _, err := service.Update(ctx, archivedSpace.ID, space.UpdateSpaceParams{Archived: &archived}); err != nil {
+			t.Fatalf("Failed to archive space: %v", err)
+		}
+
+		if _, err := create("carol", "Carol counted"); !errors.Is(err, space.ErrSpaceLimitExceeded) {
+			t.Errorf("Expected the archived space to count, got %v", err)
+		}
+
+		service.SetSpaceLimits(space.SpaceLimits{MaxSpacesPerUser: 1, CountArchived: false})
+		if _, err := create("carol", "Carol active"); err != nil {
+			t.Errorf("Expected the archived space to be left out, got %v", err)
+		}
+	})
+
+	t.Run("PerUserOverride", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			if _, err := create("importer", fmt.Sprintf("Import %d", i)); err != nil {
+				t.Fatalf("Expected unlimited override to allow space %d, got %v", i, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package space
+
+import (
+	"time"
+)
+
+// How a note came to be linked, as reported by ExplainLink
+const (
+	LinkSourceManual = "manual" // Linked on its own, e.g. with LinkNote
+	LinkSourceImport = "import" // Linked in a batch by LinkNotesFromCaptures
+)
+
+// LinkExplanation says why a note is linked to a space
+type LinkExplanation struct {
+	CaptureID string    `json:"capture_id"`
+	Source    string    `json:"source"`             // LinkSourceManual or LinkSourceImport
+	BatchID   string    `json:"batch_id,omitempty"` // The import batch, for imported notes
+	LinkedAt  time.Time `json:"linked_at"`
+	Tags      []string  `json:"tags"` // The link's tags
+
+	// FrontmatterTags are the link's tags also listed in the capture file's
+	// frontmatter, which linking inherits unless told not to
+	FrontmatterTags []string `json:"frontmatter_tags"`
+}
+
+// ExplainLink reports how a linked note came to be in the space and where its
+// tags came from. A missing capture file just has no frontmatter tags.
+func (s *SpaceDatabaseService) ExplainLink(spacePath, captureID string) (LinkExplanation, error) {
+	note, err := s.GetNoteByID(spacePath, captureID)
+	if err != nil {
+		return LinkExplanation{}, err
+	}
+
+	explanation := LinkExplanation{
+		CaptureID:       note.CaptureID,
+		Source:          LinkSourceManual,
+		BatchID:         note.BatchID,
+		LinkedAt:        note.LinkedAt,
+		Tags:            note.Tags,
+		FrontmatterTags: []string{},
+	}
+	if note.BatchID != "" {
+		explanation.Source = LinkSourceImport
+	}
+	if explanation.Tags == nil {
+		explanation.Tags = []string{}
+	}
+
+	inFrontmatter := make(map[string]bool)
+	for _, tag := range s.frontmatterTags(spacePath, note.NotePath) {
+		inFrontmatter[tag] = true
+	}
+	for _, tag := range explanation.Tags {
+		if inFrontmatter[tag] {
+			explanation.FrontmatterTags = append(explanation.FrontmatterTags, tag)
+		}
+	}
+
+	return explanation, nil
+}
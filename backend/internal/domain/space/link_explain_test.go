@@ -0,0 +1,59 @@
+package space_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestExplainLink(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	t.Run("Manual", func(t *testing.T) {
+		captureID, notePath := createNamedCapture(t, parachuteRoot, "manual.md", "Just a note\n")
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "", []string{"garden"}); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+
+		explanation, err := service.ExplainLink(spacePath, captureID)
+		if err != nil {
+			t.Fatalf("Failed to explain link: %v", err)
+		}
+		if explanation.Source != space.LinkSourceManual || explanation.BatchID != "" {
+			t.Errorf("Expected a manual link, got %+v", explanation)
+		}
+		if !reflect.DeepEqual(explanation.Tags, []string{"garden"}) || len(explanation.FrontmatterTags) != 0 {
+			t.Errorf("Expected only the given tag, got %+v", explanation)
+		}
+	})
+
+	t.Run("Imported", func(t *testing.T) {
+		captureID, notePath := createNamedCapture(t, parachuteRoot, "imported.md", "---\ntags: [soil]\n---\nPH was 6.2.\n")
+		result, err := service.LinkNotesFromCaptures(spaceID, spacePath, []space.CaptureRef{{CaptureID: captureID, NotePath: notePath}}, []string{"inbox"})
+		if err != nil {
+			t.Fatalf("Failed to import capture: %v", err)
+		}
+
+		explanation, err := service.ExplainLink(spacePath, captureID)
+		if err != nil {
+			t.Fatalf("Failed to explain link: %v", err)
+		}
+		if explanation.Source != space.LinkSourceImport || explanation.BatchID != result.BatchID {
+			t.Errorf("Expected an import in batch %s, got %+v", result.BatchID, explanation)
+		}
+		if !reflect.DeepEqual(explanation.FrontmatterTags, []string{"soil"}) {
+			t.Errorf("Expected soil to come from frontmatter, got %+v", explanation)
+		}
+	})
+
+	t.Run("NotLinked", func(t *testing.T) {
+		if _, err := service.ExplainLink(spacePath, "missing"); err == nil {
+			t.Error("Expected an error for a note that isn't linked")
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package space
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Markdown export formats
+const (
+	ExportFormatBullets = "bullets" // Per-note sections with full content (ExportNotesMarkdown)
+	ExportFormatTable   = "table"   // One table row per note, without content (ExportNotesTable)
+)
+
+// ExportNotesMarkdown renders the notes matching filters as one markdown
+// document, in the order GetRelevantNotes returns them. Each note gets a
+// heading with its filename, a metadata block (tags, context, structured
+// context) and the full capture content; notes are separated by "---". Notes
+// whose capture file is missing get a placeholder instead of content.
+func (s *SpaceDatabaseService) ExportNotesMarkdown(spacePath string, filters NoteFilters) (string, error) {
+	notes, err := s.GetRelevantNotes(spacePath, filters)
+	if err != nil {
+		return "", err
+	}
+
+	sections := make([]string, 0, len(notes))
+	for _, note := range notes {
+		var b strings.Builder
+
+		fmt.Fprintf(&b, "## %s\n\n", filepath.Base(note.NotePath))
+		fmt.Fprintf(&b, "- **File:** %s\n", note.NotePath)
+		fmt.Fprintf(&b, "- **Linked:** %s\n", note.LinkedAt.Format("2006-01-02"))
+		if len(note.Tags) > 0 {
+			fmt.Fprintf(&b, "- **Tags:** %s\n", strings.Join(note.Tags, ", "))
+		}
+		if note.Context != "" {
+			fmt.Fprintf(&b, "- **Context:** %s\n", note.Context)
+		}
+		if structured := note.ContextStructured.Markdown(); structured != "" {
+			fmt.Fprintf(&b, "\n%s", structured)
+		}
+
+		notePath, err := s.ResolveNoteFile(spacePath, note.NotePath)
+		var content []byte
+		if err == nil {
+			content, err = s.ReadCapture(notePath)
+		}
+		if errors.Is(err, ErrCaptureTooLarge) {
+			b.WriteString("\n_(capture file too large to include)_\n")
+		} else if err != nil {
+			b.WriteString("\n_(capture file not found)_\n")
+		} else {
+			fmt.Fprintf(&b, "\n%s\n", strings.TrimSpace(string(content)))
+		}
+
+		sections = append(sections, b.String())
+	}
+
+	return strings.Join(sections, "\n---\n\n"), nil
+}
+
+// ExportNotesTable renders the notes matching filters as a markdown table with
+// filename, tags, linked date and context columns, in the order
+// GetRelevantNotes returns them. Capture content is not included.
+func (s *SpaceDatabaseService) ExportNotesTable(spacePath string, filters NoteFilters) (string, error) {
+	notes, err := s.GetRelevantNotes(spacePath, filters)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("| File | Tags | Linked | Context |\n")
+	b.WriteString("| --- | --- | --- | --- |\n")
+	for _, note := range notes {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n",
+			escapeTableCell(filepath.Base(note.NotePath)),
+			escapeTableCell(strings.Join(note.Tags, ", ")),
+			note.LinkedAt.Format("2006-01-02"),
+			escapeTableCell(note.Context),
+		)
+	}
+
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// escapeTableCell makes text safe for a single markdown table cell: line
+// breaks become spaces and pipes are escaped. Backslashes are escaped first so
+// a "\|" already in the text cannot leave its pipe unescaped.
+func escapeTableCell(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	return strings.ReplaceAll(text, "|", `\|`)
+}
@@ -0,0 +1,136 @@
+package space_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestExportNotesMarkdown(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	idA, pathA := createNamedCapture(t, parachuteRoot, "soil.md", "# Soil\n\nPH was 6.2.\n")
+	idB, pathB := createNamedCapture(t, parachuteRoot, "seeds.md", "Ordered tomato seeds.\n")
+	if err := service.LinkNote(spaceID, spacePath, idA, pathA, "Soil baseline", []string{"soil"}); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+	if err := service.LinkNote(spaceID, spacePath, idB, pathB, "Seed order", []string{"seeds", "spring"}); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+	if err := service.LinkNote(spaceID, spacePath, "missing", "captures/missing.md", "", []string{"soil"}); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	// Link times are in seconds; spread them out so the order is deterministic
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to open space database: %v", err)
+	}
+	for i, captureID := range []string{idA, idB, "missing"} {
+		linkedAt := time.Now().Add(time.Duration(i-3) * time.Hour).Unix()
+		if _, err := db.Exec("UPDATE relevant_notes SET linked_at = ? WHERE capture_id = ?", linkedAt, captureID); err != nil {
+			t.Fatalf("Failed to set linked_at: %v", err)
+		}
+	}
+	db.Close()
+
+	t.Run("InlinesContentWithMetadata", func(t *testing.T) {
+		doc, err := service.ExportNotesMarkdown(spacePath, space.NoteFilters{})
+		if err != nil {
+			t.Fatalf("Failed to export: %v", err)
+		}
+
+		sections := strings.Split(doc, "\n---\n\n")
+		if len(sections) != 3 {
+			t.Fatalf("Expected 3 sections, got %d:\n%s", len(sections), doc)
+		}
+
+		// Most recently linked first, as GetRelevantNotes orders them
+		for i, want := range [][]string{
+			{"## missing.md", "_(capture file not found)_"},
+			{"## seeds.md", "- **Tags:** seeds, spring", "- **Context:** Seed order", "Ordered tomato seeds."},
+			{"## soil.md", "- **Tags:** soil", "- **Context:** Soil baseline", "# Soil\n\nPH was 6.2."},
+		} {
+			for _, fragment := range want {
+				if !strings.Contains(sections[i], fragment) {
+					t.Errorf("Expected section %d to contain %q, got:\n%s", i, fragment, sections[i])
+				}
+			}
+		}
+	})
+
+	t.Run("RespectsFilters", func(t *testing.T) {
+		doc, err := service.ExportNotesMarkdown(spacePath, space.NoteFilters{Tags: []string{"seeds"}})
+		if err != nil {
+			t.Fatalf("Failed to export: %v", err)
+		}
+		if !strings.Contains(doc, "## seeds.md") || strings.Contains(doc, "## soil.md") {
+			t.Errorf("Expected only the seeds note, got:\n%s", doc)
+		}
+	})
+
+	t.Run("Table", func(t *testing.T) {
+		context := "pH | acidity\nsee C:\\soil\\|notes"
+		if err := service.UpdateNoteContext(spacePath, idA, &context, nil); err != nil {
+			t.Fatalf("Failed to update context: %v", err)
+		}
+
+		doc, err := service.ExportNotesTable(spacePath, space.NoteFilters{})
+		if err != nil {
+			t.Fatalf("Failed to export: %v", err)
+		}
+
+		lines := strings.Split(doc, "\n")
+		if len(lines) != 5 {
+			t.Fatalf("Expected a header, a separator and 3 rows, got:\n%s", doc)
+		}
+		for _, line := range lines {
+			if cells := splitTableRow(line); len(cells) != 4 {
+				t.Errorf("Expected 4 cells, got %d in %q", len(cells), line)
+			}
+		}
+
+		soil := splitTableRow(lines[4])
+		if soil[0] != "soil.md" || soil[1] != "soil" {
+			t.Errorf("Expected the soil note last, got %v", soil)
+		}
+		if soil[3] != `pH \| acidity see C:\\soil\\\|notes` {
+			t.Errorf("Expected escaped context on one line, got %q", soil[3])
+		}
+		if seeds := splitTableRow(lines[3]); seeds[1] != "seeds, spring" {
+			t.Errorf("Expected comma-separated tags, got %q", seeds[1])
+		}
+	})
+}
+
+// splitTableRow splits a markdown table row into its trimmed cells, treating a
+// backslash as escaping the character after it
+func splitTableRow(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(row); i++ {
+		switch {
+		case row[i] == '\\' && i+1 < len(row):
+			cell.WriteByte(row[i])
+			cell.WriteByte(row[i+1])
+			i++
+		case row[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(row[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
@@ -0,0 +1,167 @@
+package space
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/file"
+)
+
+// DefaultDuplicateThreshold is the similarity above which captures count as near-duplicates
+const DefaultDuplicateThreshold = 0.8
+
+// maxDuplicateScanNotes caps how many notes FindNearDuplicates compares, since
+// every pair is compared. The most recently linked notes are scanned.
+const maxDuplicateScanNotes = 1000
+
+// shingleSize is the number of consecutive words in each shingle
+const shingleSize = 3
+
+// DuplicateGroup is a set of linked captures that are near-duplicates of each
+// other, directly or through a chain of similar captures
+type DuplicateGroup struct {
+	Notes []RelevantNote  `json:"notes"`
+	Pairs []DuplicatePair `json:"pairs"` // Every pair in the group at or above the threshold
+}
+
+// DuplicatePair is the similarity of two captures, by capture ID
+type DuplicatePair struct {
+	A          string  `json:"a"`
+	B          string  `json:"b"`
+	Similarity float64 `json:"similarity"` // Jaccard similarity of their word shingles, 0 to 1
+}
+
+// FindNearDuplicates compares the content of a space's linked captures and
+// groups those whose similarity is at or above threshold (between 0 and 1).
+// Similarity is the Jaccard index of each capture body's 3-word shingles,
+// ignoring case, punctuation and frontmatter. Captures that can't be read are
+// skipped. Groups are ordered by their highest similarity, pairs likewise.
+func (s *SpaceDatabaseService) FindNearDuplicates(spacePath string, threshold float64) ([]DuplicateGroup, error) {
+	if threshold <= 0 || threshold > 1 {
+		return nil, domain.NewValidationError("threshold", "threshold must be greater than 0 and at most 1")
+	}
+
+	notes, err := s.GetRelevantNotes(spacePath, NoteFilters{Limit: maxDuplicateScanNotes})
+	if err != nil {
+		return nil, err
+	}
+
+	var scanned []RelevantNote
+	var shingleSets []map[string]bool
+	for _, note := range notes {
+		fullPath, err := s.ResolveNoteFile(spacePath, note.NotePath)
+		if err != nil {
+			continue
+		}
+		content, err := s.ReadCapture(fullPath)
+		if err != nil {
+			continue
+		}
+		shingles := shingle(file.ParseCaptureDetailed(string(content)).Body)
+		if len(shingles) == 0 {
+			continue
+		}
+		scanned = append(scanned, note)
+		shingleSets = append(shingleSets, shingles)
+	}
+
+	// Union-find over the similar pairs
+	parent := make([]int, len(scanned))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	type scoredPair struct {
+		i, j       int
+		similarity float64
+	}
+	var pairs []scoredPair
+	for i := range scanned {
+		for j := i + 1; j < len(scanned); j++ {
+			similarity := jaccard(shingleSets[i], shingleSets[j])
+			if similarity < threshold {
+				continue
+			}
+			pairs = append(pairs, scoredPair{i, j, similarity})
+			parent[find(i)] = find(j)
+		}
+	}
+
+	sort.SliceStable(pairs, func(a, b int) bool {
+		return pairs[a].similarity > pairs[b].similarity
+	})
+
+	groupIndex := make(map[int]int)
+	groups := []DuplicateGroup{}
+	for _, p := range pairs {
+		root := find(p.i)
+		g, ok := groupIndex[root]
+		if !ok {
+			g = len(groups)
+			groupIndex[root] = g
+			groups = append(groups, DuplicateGroup{Notes: []RelevantNote{}, Pairs: []DuplicatePair{}})
+		}
+		groups[g].Pairs = append(groups[g].Pairs, DuplicatePair{
+			A:          scanned[p.i].CaptureID,
+			B:          scanned[p.j].CaptureID,
+			Similarity: p.similarity,
+		})
+	}
+
+	// Notes keep GetRelevantNotes order (most recently linked first)
+	for i, note := range scanned {
+		if g, ok := groupIndex[find(i)]; ok {
+			groups[g].Notes = append(groups[g].Notes, note)
+		}
+	}
+
+	return groups, nil
+}
+
+// shingle splits text into lowercase words and returns the set of runs of
+// shingleSize consecutive words. Text shorter than that is one shingle.
+func shingle(text string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	shingles := make(map[string]bool)
+	if len(words) == 0 {
+		return shingles
+	}
+	if len(words) < shingleSize {
+		shingles[strings.Join(words, " ")] = true
+		return shingles
+	}
+	for i := 0; i+shingleSize <= len(words); i++ {
+		shingles[strings.Join(words[i:i+shingleSize], " ")] = true
+	}
+	return shingles
+}
+
+// jaccard returns the size of the intersection of two sets over the size of their union
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	shared := 0
+	for s := range a {
+		if b[s] {
+			shared++
+		}
+	}
+	union := len(a) + len(b) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
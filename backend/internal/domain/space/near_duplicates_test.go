@@ -0,0 +1,101 @@
+package space_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestFindNearDuplicates(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	captures := []struct{ filename, content string }{
+		{"first.md", "The tomatoes on the south bed need staking before the storm arrives this weekend."},
+		{"second.md", "---\ntitle: Again\n---\nThe tomatoes on the south bed need staking before the storm arrives this weekend!"},
+		{"distinct.md", "Ordered garlic bulbs from the seed catalogue for autumn planting."},
+	}
+	ids := make([]string, len(captures))
+	for i, c := range captures {
+		captureID, notePath := createNamedCapture(t, parachuteRoot, c.filename, c.content)
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		ids[i] = captureID
+	}
+
+	groups, err := service.FindNearDuplicates(spacePath, space.DefaultDuplicateThreshold)
+	if err != nil {
+		t.Fatalf("Failed to find duplicates: %v", err)
+	}
+
+	if len(groups) != 1 {
+		t.Fatalf("Expected 1 duplicate group, got %d: %+v", len(groups), groups)
+	}
+	group := groups[0]
+	if len(group.Notes) != 2 || len(group.Pairs) != 1 {
+		t.Fatalf("Expected a pair of notes, got %+v", group)
+	}
+	grouped := map[string]bool{group.Notes[0].CaptureID: true, group.Notes[1].CaptureID: true}
+	if !grouped[ids[0]] || !grouped[ids[1]] || grouped[ids[2]] {
+		t.Errorf("Expected the two similar captures to be grouped, got %v", grouped)
+	}
+	if pair := group.Pairs[0]; pair.Similarity != 1 {
+		t.Errorf("Expected identical bodies to score 1, got %v", pair.Similarity)
+	}
+
+	t.Run("LowerThresholdStillExcludesDistinct", func(t *testing.T) {
+		groups, err := service.FindNearDuplicates(spacePath, 0.1)
+		if err != nil {
+			t.Fatalf("Failed to find duplicates: %v", err)
+		}
+		for _, note := range groups[0].Notes {
+			if note.CaptureID == ids[2] {
+				t.Error("Expected the distinct capture not to be grouped")
+			}
+		}
+	})
+
+	t.Run("CapturesDir", func(t *testing.T) {
+		otherID, otherPath := setupTestSpace(t, parachuteRoot)
+		if err := service.SetSetting(otherPath, space.SettingCapturesDir, "research/captures"); err != nil {
+			t.Fatalf("Failed to set captures_dir: %v", err)
+		}
+		researchDir := filepath.Join(parachuteRoot, "research", "captures")
+		if err := os.MkdirAll(researchDir, 0755); err != nil {
+			t.Fatalf("Failed to create research dir: %v", err)
+		}
+		for _, filename := range []string{"trial-a.md", "trial-b.md"} {
+			content := []byte("Trial plot three showed the best germination rate this season.\n")
+			if err := os.WriteFile(filepath.Join(researchDir, filename), content, 0644); err != nil {
+				t.Fatalf("Failed to write capture: %v", err)
+			}
+			if err := service.LinkNote(otherID, otherPath, filename, filename, "", nil); err != nil {
+				t.Fatalf("Failed to link note: %v", err)
+			}
+		}
+
+		groups, err := service.FindNearDuplicates(otherPath, space.DefaultDuplicateThreshold)
+		if err != nil {
+			t.Fatalf("Failed to find duplicates: %v", err)
+		}
+		if len(groups) != 1 || len(groups[0].Notes) != 2 {
+			t.Errorf("Expected the captures_dir notes to be grouped, got %+v", groups)
+		}
+	})
+
+	t.Run("InvalidThreshold", func(t *testing.T) {
+		_, err := service.FindNearDuplicates(spacePath, 1.5)
+		var validationErr *domain.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error, got %v", err)
+		}
+	})
+}
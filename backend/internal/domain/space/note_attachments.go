@@ -0,0 +1,78 @@
+package space
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/file"
+)
+
+// NoteAttachment is a file referenced by a linked note, resolved in the vault
+type NoteAttachment struct {
+	file.Attachment
+	ResolvedPath string `json:"resolved_path,omitempty"` // Vault-relative; empty if the path leaves the vault
+	Exists       bool   `json:"exists"`
+}
+
+// GetNoteAttachments lists the images and local files a linked note
+// references and whether each exists. Paths are resolved like markdown
+// links: relative to the capture's directory, falling back to the vault
+// root, with a leading / meaning the vault root. Paths leaving the vault
+// are reported as missing.
+func (s *SpaceDatabaseService) GetNoteAttachments(spacePath, captureID string) ([]NoteAttachment, error) {
+	note, err := s.GetNoteByID(spacePath, captureID)
+	if err != nil {
+		return nil, err
+	}
+
+	fullPath, err := s.ResolveNoteFile(spacePath, note.NotePath)
+	if err != nil {
+		return nil, err
+	}
+	content, err := s.ReadCapture(fullPath)
+	if os.IsNotExist(err) {
+		return nil, domain.NewNotFoundError("capture file", note.NotePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	noteDir, err := filepath.Rel(s.parachuteRoot, filepath.Dir(fullPath))
+	if err != nil {
+		noteDir = "."
+	}
+	noteDir = filepath.ToSlash(noteDir)
+
+	attachments := []NoteAttachment{}
+	for _, attachment := range file.ExtractAttachments(string(content)) {
+		resolved := NoteAttachment{Attachment: attachment}
+
+		var candidates []string
+		if rooted, ok := strings.CutPrefix(attachment.Path, "/"); ok {
+			candidates = []string{path.Clean(rooted)}
+		} else {
+			candidates = []string{path.Join(noteDir, attachment.Path), path.Clean(attachment.Path)}
+		}
+
+		for _, candidate := range candidates {
+			if !filepath.IsLocal(filepath.FromSlash(candidate)) {
+				continue
+			}
+			if resolved.ResolvedPath == "" {
+				resolved.ResolvedPath = candidate
+			}
+			if _, err := os.Stat(filepath.Join(s.parachuteRoot, filepath.FromSlash(candidate))); err == nil {
+				resolved.ResolvedPath = candidate
+				resolved.Exists = true
+				break
+			}
+		}
+
+		attachments = append(attachments, resolved)
+	}
+
+	return attachments, nil
+}
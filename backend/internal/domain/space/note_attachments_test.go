@@ -0,0 +1,73 @@
+package space_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestGetNoteAttachments(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	for _, rel := range []string{"captures/images/beds.jpg", "docs/seeds.pdf"} {
+		fullPath := filepath.Join(parachuteRoot, rel)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte("data"), 0644); err != nil {
+			t.Fatalf("Failed to write attachment: %v", err)
+		}
+	}
+
+	content := "![Beds](images/beds.jpg)\n[Seeds](/docs/seeds.pdf)\n![Gone](images/gone.png)\n[Secret](../../etc/passwd)\n"
+	captureID, notePath := createNamedCapture(t, parachuteRoot, "walk.md", content)
+	if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "", nil); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	attachments, err := service.GetNoteAttachments(spacePath, captureID)
+	if err != nil {
+		t.Fatalf("Failed to get attachments: %v", err)
+	}
+	if len(attachments) != 4 {
+		t.Fatalf("Expected 4 attachments, got %+v", attachments)
+	}
+
+	want := []struct {
+		resolved string
+		exists   bool
+	}{
+		{"captures/images/beds.jpg", true},
+		{"docs/seeds.pdf", true},
+		{"captures/images/gone.png", false},
+		{"", false},
+	}
+	for i, w := range want {
+		got := attachments[i]
+		if got.ResolvedPath != w.resolved || got.Exists != w.exists {
+			t.Errorf("%s: expected %q (exists %v), got %q (exists %v)", got.Path, w.resolved, w.exists, got.ResolvedPath, got.Exists)
+		}
+	}
+	if !attachments[0].Image || attachments[1].Image {
+		t.Error("Expected only image references flagged as images")
+	}
+
+	t.Run("MissingCaptureFile", func(t *testing.T) {
+		if err := service.LinkNote(spaceID, spacePath, "gone", "captures/gone.md", "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		_, err := service.GetNoteAttachments(spacePath, "gone")
+		var notFound *domain.NotFoundError
+		if !errors.As(err, &notFound) {
+			t.Errorf("Expected not found error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package space_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestNoteCapturedAt(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	link := func(t *testing.T, filename string, opts space.LinkOptions) string {
+		captureID, notePath := createNamedCapture(t, parachuteRoot, filename, "Note")
+		if err := service.LinkNoteWithOptions(spaceID, spacePath, captureID, notePath, "", nil, opts); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		return captureID
+	}
+
+	// Migrated from another tool: the filename says 2024, but it was captured in 2019
+	migratedAt := time.Date(2019, 6, 1, 8, 0, 0, 0, time.UTC)
+	migrated := link(t, "2024-05-01_10-00-00.md", space.LinkOptions{CapturedAt: &migratedAt})
+	recent := link(t, "2024-04-01_10-00-00.md", space.LinkOptions{})
+	untimed := link(t, "untimestamped.md", space.LinkOptions{})
+
+	t.Run("OverridesFilename", func(t *testing.T) {
+		note, err := service.GetNoteByID(spacePath, migrated)
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if note.CapturedAt == nil || !note.CapturedAt.Equal(migratedAt) {
+			t.Errorf("Expected captured_at %v, got %v", migratedAt, note.CapturedAt)
+		}
+	})
+
+	t.Run("FallsBackToFilename", func(t *testing.T) {
+		note, err := service.GetNoteByID(spacePath, recent)
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		want := time.Date(2024, 4, 1, 10, 0, 0, 0, time.Local)
+		if note.CapturedAt == nil || !note.CapturedAt.Equal(want) {
+			t.Errorf("Expected captured_at %v, got %v", want, note.CapturedAt)
+		}
+	})
+
+	t.Run("RelinkKeepsOverride", func(t *testing.T) {
+		note, err := service.GetNoteByID(spacePath, migrated)
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if err := service.LinkNote(spaceID, spacePath, migrated, note.NotePath, "Updated", nil); err != nil {
+			t.Fatalf("Failed to relink note: %v", err)
+		}
+		note, err = service.GetNoteByID(spacePath, migrated)
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if note.CapturedAt == nil || !note.CapturedAt.Equal(migratedAt) {
+			t.Errorf("Expected relinking to keep %v, got %v", migratedAt, note.CapturedAt)
+		}
+	})
+
+	t.Run("SortByCapturedAt", func(t *testing.T) {
+		notes, err := service.GetRelevantNotes(spacePath, space.NoteFilters{Sort: space.NoteSortCapturedAt})
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+
+		want := []string{recent, migrated, untimed}
+		if len(notes) != len(want) {
+			t.Fatalf("Expected %d notes, got %d", len(want), len(notes))
+		}
+		for i, id := range want {
+			if notes[i].CaptureID != id {
+				t.Errorf("Position %d: expected %s, got %s", i, id, notes[i].CaptureID)
+			}
+		}
+	})
+
+	t.Run("SortPaginates", func(t *testing.T) {
+		notes, err := service.GetRelevantNotes(spacePath, space.NoteFilters{Sort: space.NoteSortCapturedAt, Limit: 1, Offset: 1})
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		if len(notes) != 1 || notes[0].CaptureID != migrated {
+			t.Errorf("Expected only the migrated note, got %v", notes)
+		}
+	})
+
+	t.Run("InvalidSort", func(t *testing.T) {
+		_, err := service.GetRelevantNotes(spacePath, space.NoteFilters{Sort: "title"})
+		var validationErr *domain.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package space
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SetNoteDueDate sets the due date of a linked note, or clears it when due is nil
+func (s *SpaceDatabaseService) SetNoteDueDate(spacePath, captureID string, due *time.Time) error {
+	if err := s.checkWritable(spacePath); err != nil {
+		return err
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	var value interface{}
+	if due != nil {
+		value = due.Unix()
+	}
+
+	result, err := db.Exec("UPDATE relevant_notes SET due_at = ? WHERE capture_id = ?", value, captureID)
+	if err != nil {
+		return fmt.Errorf("failed to set note due date: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("note not found in space")
+	}
+
+	return bumpContextVersion(db)
+}
+
+// parseDueWindow parses the window of a {{notes_due:WINDOW}} variable. Days
+// ("7d") and weeks ("2w") are accepted as well as Go durations ("36h").
+func parseDueWindow(window string) (time.Duration, error) {
+	window = strings.TrimSpace(window)
+
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if count, ok := strings.CutSuffix(window, suffix); ok {
+			n, err := strconv.Atoi(count)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid due window: %s", window)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(window)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid due window: %s", window)
+	}
+	return d, nil
+}
@@ -0,0 +1,126 @@
+package space_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestNoteDueDate(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(service)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	notes := map[string]string{}
+	for _, name := range []string{"tomorrow.md", "next-month.md", "overdue.md", "finished.md", "undated.md"} {
+		captureID, notePath := createNamedCapture(t, parachuteRoot, name, name)
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		notes[name] = captureID
+	}
+
+	now := time.Now()
+	due := map[string]time.Time{
+		"tomorrow.md":   now.Add(24 * time.Hour),
+		"next-month.md": now.AddDate(0, 1, 0),
+		"overdue.md":    now.Add(-48 * time.Hour),
+		"finished.md":   now.Add(2 * time.Hour),
+	}
+
+	t.Run("SetDueDate", func(t *testing.T) {
+		for name, at := range due {
+			if err := service.SetNoteDueDate(spacePath, notes[name], &at); err != nil {
+				t.Fatalf("Failed to set due date: %v", err)
+			}
+		}
+		if err := service.SetNoteStatus(spacePath, notes["finished.md"], space.NoteStatusDone); err != nil {
+			t.Fatalf("Failed to set status: %v", err)
+		}
+
+		note, err := service.GetNoteByID(spacePath, notes["tomorrow.md"])
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if note.DueAt == nil || note.DueAt.Unix() != due["tomorrow.md"].Unix() {
+			t.Errorf("Expected due date %v, got %v", due["tomorrow.md"], note.DueAt)
+		}
+
+		undated, err := service.GetNoteByID(spacePath, notes["undated.md"])
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if undated.DueAt != nil {
+			t.Errorf("Expected no due date, got %v", undated.DueAt)
+		}
+	})
+
+	t.Run("FilterByDueWindow", func(t *testing.T) {
+		before := now.AddDate(0, 0, 7)
+		result, err := service.GetRelevantNotes(spacePath, space.NoteFilters{DueBefore: &before})
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+
+		got := map[string]bool{}
+		for _, note := range result {
+			got[note.CaptureID] = true
+		}
+		for _, name := range []string{"tomorrow.md", "overdue.md", "finished.md"} {
+			if !got[notes[name]] {
+				t.Errorf("Expected %s to be due within a week", name)
+			}
+		}
+		if len(result) != 3 {
+			t.Errorf("Expected 3 notes due within a week, got %d", len(result))
+		}
+	})
+
+	t.Run("NotesDueVariable", func(t *testing.T) {
+		result, err := contextService.ResolveVariables("{{notes_due:7d}}", spacePath)
+		if err != nil {
+			t.Fatalf("Failed to resolve variables: %v", err)
+		}
+
+		lines := strings.Split(result, "\n")
+		if len(lines) != 2 || !strings.HasPrefix(lines[0], "- overdue.md (due ") || !strings.HasPrefix(lines[1], "- tomorrow.md (due ") {
+			t.Errorf("Expected overdue then tomorrow, got %q", result)
+		}
+
+		invalid, _ := contextService.ResolveVariables("{{notes_due:soon}}", spacePath)
+		if invalid != "none" {
+			t.Errorf("Expected invalid window to resolve to none, got %q", invalid)
+		}
+	})
+
+	t.Run("ClearDueDate", func(t *testing.T) {
+		if err := service.SetNoteDueDate(spacePath, notes["tomorrow.md"], nil); err != nil {
+			t.Fatalf("Failed to clear due date: %v", err)
+		}
+
+		note, err := service.GetNoteByID(spacePath, notes["tomorrow.md"])
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if note.DueAt != nil {
+			t.Errorf("Expected due date to be cleared, got %v", note.DueAt)
+		}
+
+		result, _ := contextService.ResolveVariables("{{notes_due:1w}}", spacePath)
+		if strings.Contains(result, "tomorrow.md") {
+			t.Errorf("Expected cleared note to drop out of due list, got %q", result)
+		}
+	})
+
+	t.Run("UnknownNote", func(t *testing.T) {
+		err := service.SetNoteDueDate(spacePath, "missing", nil)
+		if err == nil || err.Error() != "note not found in space" {
+			t.Errorf("Expected note not found, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package space
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// unexpiredCondition matches relevant_notes rows that haven't expired, for
+// queries that hide expired notes as GetRelevantNotes does
+const unexpiredCondition = "(expires_at IS NULL OR expires_at > CAST(strftime('%s', 'now') AS INTEGER))"
+
+// SetNoteExpiry sets when a linked note expires, or clears it when expiresAt
+// is nil. Once expired the note is left out of GetRelevantNotes, and
+// UnlinkExpiredNotes unlinks it.
+func (s *SpaceDatabaseService) SetNoteExpiry(spacePath, captureID string, expiresAt *time.Time) error {
+	if err := s.checkWritable(spacePath); err != nil {
+		return err
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	var value interface{}
+	if expiresAt != nil {
+		value = expiresAt.Unix()
+	}
+
+	result, err := db.Exec("UPDATE relevant_notes SET expires_at = ? WHERE capture_id = ?", value, captureID)
+	if err != nil {
+		return fmt.Errorf("failed to set note expiry: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("note not found in space")
+	}
+
+	return bumpContextVersion(db)
+}
+
+// UnlinkExpiredNotes unlinks every note in the space whose expiry has passed,
+// leaving tombstones as UnlinkNote does, and returns how many were unlinked
+func (s *SpaceDatabaseService) UnlinkExpiredNotes(spacePath string) (int, error) {
+	if err := s.checkWritable(spacePath); err != nil {
+		return 0, err
+	}
+
+	var unlinked int
+	err := s.withBusyRetry(func() error {
+		var err error
+		unlinked, err = s.unlinkExpiredNotes(spacePath)
+		return err
+	})
+	return unlinked, err
+}
+
+// unlinkExpiredNotes implements UnlinkExpiredNotes without retries
+func (s *SpaceDatabaseService) unlinkExpiredNotes(spacePath string) (int, error) {
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin expiry sweep: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT capture_id FROM relevant_notes WHERE expires_at IS NOT NULL AND expires_at <= ?", time.Now().Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to query expired notes: %w", err)
+	}
+	var expired []string
+	for rows.Next() {
+		var captureID string
+		if err := rows.Scan(&captureID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan expired note: %w", err)
+		}
+		expired = append(expired, captureID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read expired notes: %w", err)
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	removed, err := unlinkInTx(tx, expired)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(removed) > 0 {
+		if err := bumpContextVersion(tx); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit expiry sweep: %w", err)
+	}
+
+	if len(removed) > 0 {
+		s.syncManifest(spacePath)
+	}
+	return len(removed), nil
+}
+
+// SweepExpiredNotes runs UnlinkExpiredNotes on every space under
+// vaultRoot/spaces, returning the total unlinked. Spaces without a database,
+// read-only spaces and spaces being reset are skipped. A space that fails
+// doesn't stop the sweep; its error is returned, joined with any others, once
+// every space has been tried. As a background job the sweep doesn't count
+// against the spaces' write rate limits.
+func (s *SpaceDatabaseService) SweepExpiredNotes(vaultRoot string) (int, error) {
+	spacesDir := filepath.Join(vaultRoot, "spaces")
+
+	entries, err := os.ReadDir(spacesDir)
+	if os.IsNotExist(err) {
+		return 0, nil // No spaces to sweep
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read spaces directory: %w", err)
+	}
+
+	unlinked := 0
+	var errs []error
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		spacePath := filepath.Join(spacesDir, entry.Name())
+		if _, err := os.Stat(filepath.Join(spacePath, "space.sqlite")); err != nil {
+			continue
+		}
+		if s.checkReadOnly(spacePath) != nil || s.checkResetting(spacePath) != nil {
+			continue
+		}
+
+		var n int
+		err := s.withBusyRetry(func() error {
+			var err error
+			n, err = s.unlinkExpiredNotes(spacePath)
+			return err
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to sweep space %s: %w", entry.Name(), err))
+		}
+		unlinked += n
+	}
+
+	return unlinked, errors.Join(errs...)
+}
@@ -0,0 +1,151 @@
+package space_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestNoteExpiry(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	for _, captureID := range []string{"sprint", "roadmap", "evergreen"} {
+		if err := service.LinkNote(spaceID, spacePath, captureID, "captures/"+captureID+".md", "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(24 * time.Hour)
+	if err := service.SetNoteExpiry(spacePath, "sprint", &past); err != nil {
+		t.Fatalf("Failed to set expiry: %v", err)
+	}
+	if err := service.SetNoteExpiry(spacePath, "roadmap", &future); err != nil {
+		t.Fatalf("Failed to set expiry: %v", err)
+	}
+
+	listed := func(filters space.NoteFilters) map[string]bool {
+		notes, err := service.GetRelevantNotes(spacePath, filters)
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		ids := map[string]bool{}
+		for _, note := range notes {
+			ids[note.CaptureID] = true
+		}
+		return ids
+	}
+
+	t.Run("ExpiredHiddenBeforeSweep", func(t *testing.T) {
+		ids := listed(space.NoteFilters{})
+		if ids["sprint"] || !ids["roadmap"] || !ids["evergreen"] {
+			t.Errorf("Expected only the unexpired notes, got %v", ids)
+		}
+		if !listed(space.NoteFilters{IncludeExpired: true})["sprint"] {
+			t.Error("Expected expired note when including expired")
+		}
+
+		note, err := service.GetNoteByID(spacePath, "roadmap")
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if note.ExpiresAt == nil || note.ExpiresAt.Unix() != future.Unix() {
+			t.Errorf("Expected expiry %v, got %v", future, note.ExpiresAt)
+		}
+	})
+
+	t.Run("ExpiredNotCounted", func(t *testing.T) {
+		contextService := space.NewContextService(service)
+		result, err := contextService.ResolveVariables("{{note_count}} {{untagged_count}}", spacePath)
+		if err != nil {
+			t.Fatalf("Failed to resolve: %v", err)
+		}
+		if result != "2 2" {
+			t.Errorf("Expected counts without the expired note, got %q", result)
+		}
+	})
+
+	t.Run("SweepUnlinksExpired", func(t *testing.T) {
+		unlinked, err := service.UnlinkExpiredNotes(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to unlink expired notes: %v", err)
+		}
+		if unlinked != 1 {
+			t.Errorf("Expected 1 note unlinked, got %d", unlinked)
+		}
+
+		var goneErr *domain.GoneError
+		if _, err := service.GetNoteByID(spacePath, "sprint"); !errors.As(err, &goneErr) {
+			t.Errorf("Expected expired note to be gone, got %v", err)
+		}
+		if ids := listed(space.NoteFilters{IncludeExpired: true}); len(ids) != 2 || !ids["roadmap"] {
+			t.Errorf("Expected future-expiry and evergreen notes to remain, got %v", ids)
+		}
+
+		if again, _ := service.UnlinkExpiredNotes(spacePath); again != 0 {
+			t.Errorf("Expected nothing left to sweep, got %d", again)
+		}
+	})
+
+	t.Run("ClearExpiry", func(t *testing.T) {
+		if err := service.SetNoteExpiry(spacePath, "roadmap", nil); err != nil {
+			t.Fatalf("Failed to clear expiry: %v", err)
+		}
+		note, _ := service.GetNoteByID(spacePath, "roadmap")
+		if note == nil || note.ExpiresAt != nil {
+			t.Errorf("Expected expiry cleared, got %+v", note)
+		}
+		if err := service.SetNoteExpiry(spacePath, "missing", &future); err == nil || err.Error() != "note not found in space" {
+			t.Errorf("Expected note not found, got %v", err)
+		}
+	})
+}
+
+func TestSweepExpiredNotes(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	// Sorts before the test space, so the sweep meets it first
+	brokenPath := filepath.Join(parachuteRoot, "spaces", "0-broken")
+	if err := os.MkdirAll(brokenPath, 0755); err != nil {
+		t.Fatalf("Failed to create space directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(brokenPath, "space.sqlite"), []byte("not a database"), 0644); err != nil {
+		t.Fatalf("Failed to write database: %v", err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	for _, captureID := range []string{"stale-1", "stale-2"} {
+		if err := service.LinkNote(spaceID, spacePath, captureID, "captures/"+captureID+".md", "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		if err := service.SetNoteExpiry(spacePath, captureID, &past); err != nil {
+			t.Fatalf("Failed to set expiry: %v", err)
+		}
+	}
+
+	// Use up the space's write tokens; the sweep is a background job and
+	// shouldn't need any
+	service.SetWriteRateLimit(space.WriteRateLimit{PerSecond: 0.001, Burst: 1})
+	service.SetNoteExpiry(spacePath, "stale-1", &past)
+
+	unlinked, err := service.SweepExpiredNotes(parachuteRoot)
+	if err == nil {
+		t.Error("Expected the broken space's error to be reported")
+	}
+	if unlinked != 2 {
+		t.Errorf("Expected both expired notes unlinked past the broken space, got %d", unlinked)
+	}
+}
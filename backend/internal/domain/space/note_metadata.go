@@ -0,0 +1,37 @@
+package space
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// maxMetadataKeyLength caps the metadata key a note filter may name
+const maxMetadataKeyLength = 100
+
+// metadataValueExpr extracts a top-level key from a note's metadata, or NULL
+// if the key is missing, set to null or the metadata isn't valid JSON. The
+// key's JSON path is its argument.
+const metadataValueExpr = "json_extract(CASE WHEN json_valid(metadata) THEN metadata END, ?)"
+
+// validateMetadataKey checks a metadata key named by a note filter. Keys are
+// quoted in a JSON path, so they may not contain double quotes.
+func validateMetadataKey(field, key string) error {
+	if key == "" {
+		return nil
+	}
+	if len(key) > maxMetadataKeyLength {
+		return domain.NewValidationError(field, fmt.Sprintf("must be at most %d characters", maxMetadataKeyLength))
+	}
+	if strings.ContainsRune(key, '"') {
+		return domain.NewValidationError(field, "must not contain double quotes")
+	}
+	return nil
+}
+
+// metadataKeyPath returns the JSON path of a top-level metadata key. Quoting
+// lets keys contain dots and brackets.
+func metadataKeyPath(key string) string {
+	return `$."` + key + `"`
+}
@@ -0,0 +1,274 @@
+package space
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// Recovery states reported by MoveNoteError
+const (
+	MoveStateRolledBack   = "rolled_back"    // The target link was removed again; both spaces are as before
+	MoveStateLinkedInBoth = "linked_in_both" // Rolling back failed too; the note is linked in both spaces
+)
+
+// MoveNoteError reports a move that failed after the note was linked in the
+// target space, and what state the two spaces were left in
+type MoveNoteError struct {
+	CaptureID   string
+	State       string // MoveStateRolledBack or MoveStateLinkedInBoth
+	Err         error  // Why the note could not be removed from the source space
+	RollbackErr error  // Why the target link could not be removed, for MoveStateLinkedInBoth
+}
+
+func (e *MoveNoteError) Error() string {
+	if e.State == MoveStateLinkedInBoth {
+		return fmt.Sprintf("failed to move note %s: removing it from the source failed (%v) and so did undoing the target link (%v); the note is linked in both spaces",
+			e.CaptureID, e.Err, e.RollbackErr)
+	}
+	return fmt.Sprintf("failed to move note %s: removing it from the source failed (%v); the target link was rolled back",
+		e.CaptureID, e.Err)
+}
+
+func (e *MoveNoteError) Unwrap() error {
+	return e.Err
+}
+
+// movedNoteColumns are the relevant_notes columns a move carries over. The
+// row ID is new, and the batch and thread parent only mean something in the
+// source space, so they are left out.
+var movedNoteColumns = []string{
+	"capture_id", "note_path", "linked_at", "context", "tags", "last_referenced",
+	"metadata", "context_structured", "status", "due_at", "captured_at",
+	"reminder_text", "reminder_at", "reminder_dismissed_at", "priority", "expires_at",
+	"relevance", "relevance_decayed_at", "source_url",
+}
+
+// removeMovedNote unlinks a moved note from its source space. It is a
+// variable so tests can make the source step fail.
+var removeMovedNote = unlinkMovedNote
+
+// MoveNoteToSpace moves a linked note, with its context, tags and other link
+// fields, from the space at fromSpacePath to another space. Two databases are
+// involved, so it works in two phases: the note is linked in the target and
+// read back, then unlinked from the source (leaving a tombstone as UnlinkNote
+// does). If the source step fails the target link is removed again and a
+// *MoveNoteError says whether that worked. A note already linked in the
+// target is a conflict, and both spaces must be writable.
+func (s *SpaceDatabaseService) MoveNoteToSpace(fromSpacePath, toSpaceID, toSpacePath, captureID string) error {
+	if filepath.Clean(fromSpacePath) == filepath.Clean(toSpacePath) {
+		return domain.NewValidationError("to_space_id", "source and target space are the same")
+	}
+	if err := s.checkWritable(fromSpacePath); err != nil {
+		return err
+	}
+	if err := s.checkWritable(toSpacePath); err != nil {
+		return err
+	}
+	if err := s.ensureDatabase(toSpaceID, toSpacePath); err != nil {
+		return err
+	}
+
+	// Reports a missing or unlinked note the same way a single note lookup does
+	if _, err := s.GetNoteByID(fromSpacePath, captureID); err != nil {
+		return err
+	}
+
+	values, err := readMovedNote(fromSpacePath, captureID)
+	if err != nil {
+		return err
+	}
+
+	tombstone, err := insertMovedNote(toSpacePath, captureID, values)
+	if err != nil {
+		return err
+	}
+
+	if err := removeMovedNote(fromSpacePath, captureID); err != nil {
+		moveErr := &MoveNoteError{CaptureID: captureID, State: MoveStateRolledBack, Err: err}
+		if rollbackErr := deleteMovedNote(toSpacePath, captureID, tombstone); rollbackErr != nil {
+			moveErr.State = MoveStateLinkedInBoth
+			moveErr.RollbackErr = rollbackErr
+		}
+		return moveErr
+	}
+
+	s.syncFrontmatter(toSpacePath, captureID)
+	s.syncManifest(fromSpacePath)
+	s.syncManifest(toSpacePath)
+	return nil
+}
+
+// readMovedNote reads the movedNoteColumns of a note in the source space
+func readMovedNote(spacePath, captureID string) ([]interface{}, error) {
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open source space database: %w", err)
+	}
+	defer db.Close()
+
+	values := make([]interface{}, len(movedNoteColumns))
+	dest := make([]interface{}, len(values))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	err = db.QueryRow("SELECT "+strings.Join(movedNoteColumns, ", ")+" FROM relevant_notes WHERE capture_id = ?", captureID).Scan(dest...)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("note not found in space")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read note: %w", err)
+	}
+	return values, nil
+}
+
+// movedTombstone is the target space's record of an earlier unlink of a moved
+// note, kept so a rolled-back move can put it back
+type movedTombstone struct {
+	notePath  string
+	deletedAt int64
+}
+
+// insertMovedNote links a moved note in the target space and reads it back,
+// undoing the insert if the row is not there as written. It returns the
+// tombstone the move cleared, if there was one.
+func insertMovedNote(spacePath, captureID string, values []interface{}) (*movedTombstone, error) {
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open target space database: %w", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin move: %w", err)
+	}
+	defer tx.Rollback()
+
+	var existing int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM relevant_notes WHERE capture_id = ?", captureID).Scan(&existing); err != nil {
+		return nil, fmt.Errorf("failed to check target space: %w", err)
+	}
+	if existing > 0 {
+		return nil, domain.NewConflictError("note", "note is already linked in the target space")
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(movedNoteColumns)+1), ", ")
+	args := append([]interface{}{uuid.New().String()}, values...)
+	if _, err := tx.Exec("INSERT INTO relevant_notes (id, "+strings.Join(movedNoteColumns, ", ")+") VALUES ("+placeholders+")", args...); err != nil {
+		return nil, fmt.Errorf("failed to link note in target space: %w", err)
+	}
+
+	// Moving in clears any tombstone left by an earlier unlink
+	var tombstone *movedTombstone
+	var cleared movedTombstone
+	err = tx.QueryRow("SELECT note_path, deleted_at FROM deleted_notes WHERE capture_id = ?", captureID).Scan(&cleared.notePath, &cleared.deletedAt)
+	switch {
+	case err == nil:
+		tombstone = &cleared
+	case err != sql.ErrNoRows:
+		return nil, fmt.Errorf("failed to check unlinked note: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM deleted_notes WHERE capture_id = ?", captureID); err != nil {
+		return nil, fmt.Errorf("failed to clear unlinked note: %w", err)
+	}
+
+	var notePath string
+	if err := tx.QueryRow("SELECT note_path FROM relevant_notes WHERE capture_id = ?", captureID).Scan(&notePath); err != nil {
+		return nil, fmt.Errorf("failed to verify moved note: %w", err)
+	}
+	if notePath != fmt.Sprint(values[1]) {
+		return nil, fmt.Errorf("failed to verify moved note: stored path %q does not match %q", notePath, values[1])
+	}
+
+	if err := bumpContextVersion(tx); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit move: %w", err)
+	}
+
+	return tombstone, nil
+}
+
+// unlinkMovedNote removes a moved note from the source space, leaving a
+// tombstone
+func unlinkMovedNote(spacePath, captureID string) error {
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		return fmt.Errorf("failed to open source space database: %w", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin move: %w", err)
+	}
+	defer tx.Rollback()
+
+	removed, err := unlinkInTx(tx, []string{captureID})
+	if err != nil {
+		return err
+	}
+	if len(removed) == 0 {
+		return fmt.Errorf("note not found in space")
+	}
+
+	if err := bumpContextVersion(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit move: %w", err)
+	}
+
+	return nil
+}
+
+// deleteMovedNote undoes insertMovedNote, putting back the tombstone it
+// cleared (if any). No new tombstone is left, since as far as the target space
+// is concerned the note was never linked.
+func deleteMovedNote(spacePath, captureID string, tombstone *movedTombstone) error {
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		return fmt.Errorf("failed to open target space database: %w", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin rollback: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM relevant_notes WHERE capture_id = ?", captureID); err != nil {
+		return fmt.Errorf("failed to remove moved note from target space: %w", err)
+	}
+	if tombstone != nil {
+		_, err := tx.Exec(`
+			INSERT INTO deleted_notes (capture_id, note_path, deleted_at) VALUES (?, ?, ?)
+			ON CONFLICT(capture_id) DO UPDATE SET
+				note_path = excluded.note_path,
+				deleted_at = excluded.deleted_at
+		`, captureID, tombstone.notePath, tombstone.deletedAt)
+		if err != nil {
+			return fmt.Errorf("failed to restore unlinked note in target space: %w", err)
+		}
+	}
+
+	if err := bumpContextVersion(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit rollback: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package space_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestMoveNoteToSpace(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	fromID, fromPath := setupTestSpace(t, parachuteRoot)
+	toID, toPath := setupTestSpace(t, parachuteRoot)
+
+	link := func(captureID string) {
+		t.Helper()
+		if err := service.LinkNote(fromID, fromPath, captureID, "captures/"+captureID+".md", "Move context", []string{"moved"}); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	t.Run("MovesNoteAndLinkFields", func(t *testing.T) {
+		link("note-1")
+
+		if err := service.MoveNoteToSpace(fromPath, toID, toPath, "note-1"); err != nil {
+			t.Fatalf("Failed to move note: %v", err)
+		}
+
+		moved, err := service.GetNoteByID(toPath, "note-1")
+		if err != nil {
+			t.Fatalf("Moved note not found in target: %v", err)
+		}
+		if moved.Context != "Move context" {
+			t.Errorf("Expected context to move with the note, got %q", moved.Context)
+		}
+		if len(moved.Tags) != 1 || moved.Tags[0] != "moved" {
+			t.Errorf("Expected tags to move with the note, got %v", moved.Tags)
+		}
+
+		_, err = service.GetNoteByID(fromPath, "note-1")
+		var goneErr *domain.GoneError
+		if !errors.As(err, &goneErr) {
+			t.Errorf("Expected note to be unlinked from source, got %v", err)
+		}
+	})
+
+	t.Run("SourceFailureRollsBackTarget", func(t *testing.T) {
+		link("note-2")
+
+		restore := space.SetRemoveMovedNote(func(spacePath, captureID string) error {
+			return errors.New("disk full")
+		})
+		defer restore()
+
+		err := service.MoveNoteToSpace(fromPath, toID, toPath, "note-2")
+		var moveErr *space.MoveNoteError
+		if !errors.As(err, &moveErr) {
+			t.Fatalf("Expected MoveNoteError, got %v", err)
+		}
+		if moveErr.State != space.MoveStateRolledBack {
+			t.Errorf("Expected state %s, got %s", space.MoveStateRolledBack, moveErr.State)
+		}
+
+		if _, err := service.GetNoteByID(fromPath, "note-2"); err != nil {
+			t.Errorf("Expected note to stay linked in source: %v", err)
+		}
+		if _, err := service.GetNoteByID(toPath, "note-2"); err == nil || err.Error() != "note not found in space" {
+			t.Errorf("Expected note to be absent from target, got %v", err)
+		}
+	})
+
+	t.Run("RollbackRestoresTargetTombstone", func(t *testing.T) {
+		link("note-4")
+		if err := service.LinkNote(toID, toPath, "note-4", "captures/note-4.md", "", nil); err != nil {
+			t.Fatalf("Failed to link note in target: %v", err)
+		}
+		if err := service.UnlinkNote(toPath, "note-4"); err != nil {
+			t.Fatalf("Failed to unlink note in target: %v", err)
+		}
+
+		restore := space.SetRemoveMovedNote(func(spacePath, captureID string) error {
+			return errors.New("disk full")
+		})
+		defer restore()
+
+		if err := service.MoveNoteToSpace(fromPath, toID, toPath, "note-4"); err == nil {
+			t.Fatal("Expected the move to fail")
+		}
+
+		_, err := service.GetNoteByID(toPath, "note-4")
+		var goneErr *domain.GoneError
+		if !errors.As(err, &goneErr) {
+			t.Errorf("Expected the target's earlier unlink to be kept, got %v", err)
+		}
+	})
+
+	t.Run("AlreadyLinkedInTarget", func(t *testing.T) {
+		link("note-3")
+		if err := service.LinkNote(toID, toPath, "note-3", "captures/note-3.md", "", nil); err != nil {
+			t.Fatalf("Failed to link note in target: %v", err)
+		}
+
+		err := service.MoveNoteToSpace(fromPath, toID, toPath, "note-3")
+		var conflictErr *domain.ConflictError
+		if !errors.As(err, &conflictErr) {
+			t.Fatalf("Expected ConflictError, got %v", err)
+		}
+		if _, err := service.GetNoteByID(fromPath, "note-3"); err != nil {
+			t.Errorf("Expected note to stay linked in source: %v", err)
+		}
+	})
+}
@@ -0,0 +1,177 @@
+package space
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// NormalizeNotePath converts a note path to the canonical form stored in
+// relevant_notes: relative to the vault root, cleaned, with forward slashes.
+// Absolute paths must be under the vault root; paths that resolve outside it
+// are rejected.
+func (s *SpaceDatabaseService) NormalizeNotePath(notePath string) (string, error) {
+	return s.vaultRelative(notePath, "note_path")
+}
+
+// vaultRelative cleans path into vault-relative form, reporting problems
+// against field. The vault root itself counts as outside the vault.
+func (s *SpaceDatabaseService) vaultRelative(path, field string) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", domain.NewValidationError(field, field+" is required")
+	}
+
+	relPath := path
+	if filepath.IsAbs(path) {
+		rel, err := filepath.Rel(filepath.Clean(s.parachuteRoot), filepath.Clean(path))
+		if err != nil {
+			return "", domain.NewValidationError(field, field+" is outside the vault")
+		}
+		relPath = rel
+	}
+
+	relPath = filepath.Clean(relPath)
+	if relPath == "." || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", domain.NewValidationError(field, field+" is outside the vault")
+	}
+
+	return filepath.ToSlash(relPath), nil
+}
+
+// normalizeLinkPath normalizes a note path being linked to a space. A bare
+// filename is taken to live in the space's captures directory.
+func (s *SpaceDatabaseService) normalizeLinkPath(spacePath, notePath string) (string, error) {
+	normalized, err := s.NormalizeNotePath(notePath)
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.Contains(normalized, "/") {
+		capturesDir, err := s.CapturesDir(spacePath)
+		if err != nil {
+			return "", err
+		}
+		normalized = capturesDir + "/" + normalized
+	}
+
+	return normalized, nil
+}
+
+// VaultRoot returns the directory stored note paths are relative to
+func (s *SpaceDatabaseService) VaultRoot() string {
+	return s.parachuteRoot
+}
+
+// ResolveNoteFile returns the absolute path of a linked note's file. Stored
+// paths are vault-relative; when the file is missing and the path points into
+// the shared captures/ directory, the space's captures_dir is tried instead so
+// spaces that keep their captures elsewhere still resolve.
+func (s *SpaceDatabaseService) ResolveNoteFile(spacePath, notePath string) (string, error) {
+	capturesDir := func() string {
+		dir, err := s.CapturesDir(spacePath)
+		if err != nil {
+			return DefaultCapturesDir
+		}
+		return dir
+	}
+	exists := func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+
+	return s.resolveNoteFile(notePath, capturesDir, exists)
+}
+
+// resolveNoteFile implements ResolveNoteFile with pluggable lookups so callers
+// checking many notes can cache the captures_dir and directory listings
+func (s *SpaceDatabaseService) resolveNoteFile(notePath string, capturesDir func() string, exists func(string) bool) (string, error) {
+	normalized, err := s.NormalizeNotePath(notePath)
+	if err != nil {
+		return "", err
+	}
+
+	fullPath := filepath.Join(s.parachuteRoot, filepath.FromSlash(normalized))
+	if exists(fullPath) {
+		return fullPath, nil
+	}
+
+	dir := capturesDir()
+	if dir == DefaultCapturesDir {
+		return fullPath, nil
+	}
+
+	rest := normalized
+	if strings.HasPrefix(normalized, DefaultCapturesDir+"/") {
+		rest = strings.TrimPrefix(normalized, DefaultCapturesDir+"/")
+	} else if strings.Contains(normalized, "/") {
+		return fullPath, nil
+	}
+
+	return filepath.Join(s.parachuteRoot, filepath.FromSlash(dir), filepath.FromSlash(rest)), nil
+}
+
+// readDir lists a directory for noteFileChecker
+var readDir = os.ReadDir
+
+// noteFileChecker reports whether linked notes' files exist on disk. Each
+// directory is listed once, so checking many notes costs one read per
+// directory rather than one stat per note.
+type noteFileChecker struct {
+	s           *SpaceDatabaseService
+	spacePath   string
+	capturesDir string
+	dirs        map[string]map[string]bool
+}
+
+// newNoteFileChecker creates a checker for the notes of one space
+func (s *SpaceDatabaseService) newNoteFileChecker(spacePath string) *noteFileChecker {
+	return &noteFileChecker{
+		s:         s,
+		spacePath: spacePath,
+		dirs:      make(map[string]map[string]bool),
+	}
+}
+
+// exists reports whether the file for notePath is present
+func (c *noteFileChecker) exists(notePath string) bool {
+	fullPath, err := c.s.resolveNoteFile(notePath, c.spaceCapturesDir, c.fileExists)
+	if err != nil {
+		return false
+	}
+	return c.fileExists(fullPath)
+}
+
+// spaceCapturesDir returns the space's captures_dir, looked up once
+func (c *noteFileChecker) spaceCapturesDir() string {
+	if c.capturesDir == "" {
+		dir, err := c.s.CapturesDir(c.spacePath)
+		if err != nil {
+			dir = DefaultCapturesDir
+		}
+		c.capturesDir = dir
+	}
+	return c.capturesDir
+}
+
+// fileExists checks fullPath against a cached listing of its directory
+func (c *noteFileChecker) fileExists(fullPath string) bool {
+	dir, name := filepath.Split(fullPath)
+	dir = filepath.Clean(dir)
+
+	names, ok := c.dirs[dir]
+	if !ok {
+		names = make(map[string]bool)
+		if entries, err := readDir(dir); err == nil {
+			for _, entry := range entries {
+				if !entry.IsDir() {
+					names[entry.Name()] = true
+				}
+			}
+		}
+		c.dirs[dir] = names
+	}
+
+	return names[name]
+}
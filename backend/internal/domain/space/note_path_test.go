@@ -0,0 +1,66 @@
+package space_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestNormalizeNotePath(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	t.Run("AbsolutePathUnderVaultStoredRelative", func(t *testing.T) {
+		captureID, notePath := createNamedCapture(t, parachuteRoot, "2024-06-01_12-00-00.md", "Absolute")
+		absPath := filepath.Join(parachuteRoot, notePath)
+
+		if err := service.LinkNote(spaceID, spacePath, captureID, absPath, "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+
+		note, err := service.GetNoteByID(spacePath, captureID)
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if note.NotePath != "captures/2024-06-01_12-00-00.md" {
+			t.Errorf("Expected vault-relative path, got %s", note.NotePath)
+		}
+	})
+
+	t.Run("RelativePathCleaned", func(t *testing.T) {
+		got, err := service.NormalizeNotePath("./captures/../captures/note.md")
+		if err != nil {
+			t.Fatalf("Failed to normalize: %v", err)
+		}
+		if got != "captures/note.md" {
+			t.Errorf("Expected captures/note.md, got %s", got)
+		}
+	})
+
+	t.Run("PathsOutsideVaultRejected", func(t *testing.T) {
+		outside := []string{
+			filepath.Join(filepath.Dir(parachuteRoot), "elsewhere", "note.md"),
+			"../secrets.md",
+			"captures/../../secrets.md",
+			parachuteRoot,
+		}
+
+		for _, path := range outside {
+			if _, err := service.NormalizeNotePath(path); err == nil {
+				t.Errorf("Expected %s to be rejected", path)
+			}
+		}
+
+		err := service.LinkNote(spaceID, spacePath, "outside-capture", outside[0], "", nil)
+		if err == nil {
+			t.Fatal("Expected linking a path outside the vault to fail")
+		}
+		if _, err := service.GetNoteByID(spacePath, "outside-capture"); err == nil {
+			t.Error("Expected rejected note not to be stored")
+		}
+	})
+}
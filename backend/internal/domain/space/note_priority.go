@@ -0,0 +1,74 @@
+package space
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// priorityNotesLimit caps how many notes {{priority_notes}} lists
+const priorityNotesLimit = 10
+
+// SetNotePriority sets a linked note's priority. Higher values matter more;
+// 0 is the default and negative values rank a note below unprioritized ones.
+func (s *SpaceDatabaseService) SetNotePriority(spacePath, captureID string, priority int) error {
+	if err := s.checkWritable(spacePath); err != nil {
+		return err
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	result, err := db.Exec("UPDATE relevant_notes SET priority = ? WHERE capture_id = ?", priority, captureID)
+	if err != nil {
+		return fmt.Errorf("failed to set note priority: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("note not found in space")
+	}
+
+	return bumpContextVersion(db)
+}
+
+// replacePriorityNotes replaces {{priority_notes}} with the notes given a
+// positive priority, highest first and most recently linked among equals
+func (s *ContextService) replacePriorityNotes(text string, db *sql.DB) string {
+	if !strings.Contains(text, "{{priority_notes}}") {
+		return text
+	}
+
+	rows, err := db.Query(`
+		SELECT note_path, priority
+		FROM relevant_notes
+		WHERE priority > 0 AND `+unexpiredCondition+`
+		ORDER BY priority DESC, linked_at DESC
+		LIMIT ?
+	`, priorityNotesLimit)
+	if err != nil {
+		return strings.ReplaceAll(text, "{{priority_notes}}", "none")
+	}
+	defer rows.Close()
+
+	var notes []string
+	for rows.Next() {
+		var notePath string
+		var priority int
+		if err := rows.Scan(&notePath, &priority); err != nil {
+			continue
+		}
+		notes = append(notes, fmt.Sprintf("- %s (priority %d)", filepath.Base(notePath), priority))
+	}
+
+	if len(notes) == 0 {
+		return strings.ReplaceAll(text, "{{priority_notes}}", "none")
+	}
+	return strings.ReplaceAll(text, "{{priority_notes}}", strings.Join(notes, "\n"))
+}
@@ -0,0 +1,85 @@
+package space_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestNotePriority(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(service)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	for _, id := range []string{"seeds", "compost", "harvest", "weeds"} {
+		if err := service.LinkNote(spaceID, spacePath, id, "captures/"+id+".md", "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	t.Run("DefaultsToZero", func(t *testing.T) {
+		note, err := service.GetNoteByID(spacePath, "seeds")
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if note.Priority != 0 {
+			t.Errorf("Expected priority 0, got %d", note.Priority)
+		}
+	})
+
+	priorities := map[string]int{"seeds": 2, "compost": 5, "weeds": -1}
+	for id, priority := range priorities {
+		if err := service.SetNotePriority(spacePath, id, priority); err != nil {
+			t.Fatalf("Failed to set priority: %v", err)
+		}
+	}
+
+	t.Run("SortByPriority", func(t *testing.T) {
+		notes, err := service.GetRelevantNotes(spacePath, space.NoteFilters{Sort: space.NoteSortPriority})
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		var order []string
+		for _, note := range notes {
+			order = append(order, note.CaptureID)
+		}
+		if got := strings.Join(order, ","); got != "compost,seeds,harvest,weeds" {
+			t.Errorf("Expected highest priority first, got %s", got)
+		}
+
+		limited, _ := service.GetRelevantNotes(spacePath, space.NoteFilters{Sort: space.NoteSortPriority, Limit: 1})
+		if len(limited) != 1 || limited[0].CaptureID != "compost" {
+			t.Errorf("Expected the top note when limited, got %+v", limited)
+		}
+	})
+
+	t.Run("PriorityNotesVariable", func(t *testing.T) {
+		result, err := contextService.ResolveVariables("{{priority_notes}}", spacePath)
+		if err != nil {
+			t.Fatalf("Failed to resolve variables: %v", err)
+		}
+		if result != "- compost.md (priority 5)\n- seeds.md (priority 2)" {
+			t.Errorf("Expected positive priorities highest first, got %q", result)
+		}
+	})
+
+	t.Run("NoneWhenUnprioritized", func(t *testing.T) {
+		for id := range priorities {
+			service.SetNotePriority(spacePath, id, 0)
+		}
+		result, _ := contextService.ResolveVariables("{{priority_notes}}", spacePath)
+		if result != "none" {
+			t.Errorf("Expected none, got %q", result)
+		}
+	})
+
+	t.Run("NoteNotFound", func(t *testing.T) {
+		if err := service.SetNotePriority(spacePath, "missing", 1); err == nil || err.Error() != "note not found in space" {
+			t.Errorf("Expected note not found, got %v", err)
+		}
+	})
+}
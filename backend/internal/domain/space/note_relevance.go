@@ -0,0 +1,97 @@
+package space
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"path/filepath"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// DefaultRelevanceHalfLife is the half-life used when none is given
+const DefaultRelevanceHalfLife = 30 * 24 * time.Hour
+
+// ApplyRelevanceDecay lowers each note's relevance by half for every halfLife
+// elapsed since it was last decayed, referenced or (failing both) linked.
+// Referencing a note (TrackNoteReference) or linking it again restores its
+// relevance to 1, so notes in regular use stay near the top of the relevance
+// sort while forgotten ones fade. Decaying twice over a period has the same
+// result as decaying once at the end of it. Returns the number of notes whose
+// relevance changed.
+func (s *SpaceDatabaseService) ApplyRelevanceDecay(spacePath string, halfLife time.Duration) (int, error) {
+	if halfLife <= 0 {
+		return 0, domain.NewValidationError("half_life", "must be positive")
+	}
+	if err := s.checkWritable(spacePath); err != nil {
+		return 0, err
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin decay: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id, relevance, COALESCE(relevance_decayed_at, last_referenced, linked_at) FROM relevant_notes")
+	if err != nil {
+		return 0, fmt.Errorf("failed to query notes: %w", err)
+	}
+
+	type decayed struct {
+		id        string
+		relevance float64
+	}
+	now := time.Now()
+	var updates []decayed
+	for rows.Next() {
+		var id string
+		var relevance float64
+		var sinceUnix int64
+		if err := rows.Scan(&id, &relevance, &sinceUnix); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan note: %w", err)
+		}
+		if next := decayRelevance(relevance, now.Sub(time.Unix(sinceUnix, 0)), halfLife); next != relevance {
+			updates = append(updates, decayed{id, next})
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return 0, fmt.Errorf("failed to query notes: %w", err)
+	}
+
+	for _, u := range updates {
+		if _, err := tx.Exec("UPDATE relevant_notes SET relevance = ?, relevance_decayed_at = ? WHERE id = ?", u.relevance, now.Unix(), u.id); err != nil {
+			return 0, fmt.Errorf("failed to update relevance: %w", err)
+		}
+	}
+
+	// Relevance orders the relevance sort and {{recent_notes}}
+	if len(updates) > 0 {
+		if err := bumpContextVersion(tx); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit decay: %w", err)
+	}
+
+	return len(updates), nil
+}
+
+// decayRelevance returns relevance after elapsed time at the given half-life.
+// Elapsed time under a second (or negative, from clock skew) leaves it as is.
+func decayRelevance(relevance float64, elapsed, halfLife time.Duration) float64 {
+	if elapsed < time.Second {
+		return relevance
+	}
+	return relevance * math.Pow(0.5, float64(elapsed)/float64(halfLife))
+}
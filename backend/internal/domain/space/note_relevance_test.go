@@ -0,0 +1,129 @@
+package space_test
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestApplyRelevanceDecay(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	for _, id := range []string{"fresh", "stale"} {
+		if err := service.LinkNote(spaceID, spacePath, id, "captures/"+id+".md", "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to open space database: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	referenced := map[string]time.Time{
+		"fresh": now.Add(-time.Hour),
+		"stale": now.Add(-60 * 24 * time.Hour),
+	}
+	for id, at := range referenced {
+		if _, err := db.Exec("UPDATE relevant_notes SET last_referenced = ? WHERE capture_id = ?", at.Unix(), id); err != nil {
+			t.Fatalf("Failed to set last_referenced: %v", err)
+		}
+	}
+
+	relevance := func(id string) float64 {
+		note, err := service.GetNoteByID(spacePath, id)
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		return note.Relevance
+	}
+
+	if relevance("fresh") != 1 || relevance("stale") != 1 {
+		t.Fatalf("Expected both notes to start at relevance 1")
+	}
+
+	versionBefore, err := service.GetContextVersion(spacePath)
+	if err != nil {
+		t.Fatalf("Failed to get context version: %v", err)
+	}
+
+	halfLife := 30 * 24 * time.Hour
+	updated, err := service.ApplyRelevanceDecay(spacePath, halfLife)
+	if err != nil {
+		t.Fatalf("Failed to apply decay: %v", err)
+	}
+	if updated != 2 {
+		t.Errorf("Expected 2 notes updated, got %d", updated)
+	}
+	if version, _ := service.GetContextVersion(spacePath); version <= versionBefore {
+		t.Errorf("Expected decay to bump the context version past %d, got %d", versionBefore, version)
+	}
+
+	fresh, stale := relevance("fresh"), relevance("stale")
+	if fresh < 0.99 {
+		t.Errorf("Expected recently referenced note to barely change, got %f", fresh)
+	}
+	if math.Abs(stale-0.25) > 0.01 {
+		t.Errorf("Expected note unreferenced for two half-lives to be near 0.25, got %f", stale)
+	}
+
+	t.Run("SortByRelevance", func(t *testing.T) {
+		notes, err := service.GetRelevantNotes(spacePath, space.NoteFilters{Sort: space.NoteSortRelevance})
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		if len(notes) != 2 || notes[0].CaptureID != "fresh" {
+			t.Errorf("Expected the fresh note first, got %+v", notes)
+		}
+	})
+
+	t.Run("NotCompounded", func(t *testing.T) {
+		if _, err := service.ApplyRelevanceDecay(spacePath, halfLife); err != nil {
+			t.Fatalf("Failed to apply decay: %v", err)
+		}
+		if again := relevance("stale"); math.Abs(again-stale) > 0.001 {
+			t.Errorf("Expected a second decay right after to keep %f, got %f", stale, again)
+		}
+	})
+
+	t.Run("ReferenceRestores", func(t *testing.T) {
+		if err := service.TrackNoteReference(spacePath, "stale"); err != nil {
+			t.Fatalf("Failed to track reference: %v", err)
+		}
+		if got := relevance("stale"); got != 1 {
+			t.Errorf("Expected relevance 1 after a reference, got %f", got)
+		}
+	})
+
+	t.Run("RelinkRestores", func(t *testing.T) {
+		if relevance("fresh") == 1 {
+			t.Fatal("Expected the fresh note to have decayed a little")
+		}
+		if err := service.LinkNote(spaceID, spacePath, "fresh", "captures/fresh.md", "", nil); err != nil {
+			t.Fatalf("Failed to relink note: %v", err)
+		}
+		if got := relevance("fresh"); got != 1 {
+			t.Errorf("Expected relevance 1 after relinking, got %f", got)
+		}
+	})
+
+	t.Run("InvalidHalfLife", func(t *testing.T) {
+		_, err := service.ApplyRelevanceDecay(spacePath, 0)
+		var validationErr *domain.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("Expected ValidationError, got %v", err)
+		}
+	})
+}
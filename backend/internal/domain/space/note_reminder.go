@@ -0,0 +1,108 @@
+package space
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// activeRemindersLimit caps how many reminders {{active_reminders}} lists
+const activeRemindersLimit = 20
+
+// NoteReminder is a nudge attached to a linked note. Once At has passed it is
+// listed by {{active_reminders}} until dismissed.
+type NoteReminder struct {
+	Text        string     `json:"text"`
+	At          time.Time  `json:"at"`
+	DismissedAt *time.Time `json:"dismissed_at,omitempty"`
+}
+
+// SetReminder sets a linked note's reminder, replacing any earlier one
+// (dismissed or not)
+func (s *SpaceDatabaseService) SetReminder(spacePath, captureID, text string, at time.Time) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return domain.NewValidationError("text", "reminder text is required")
+	}
+	if at.IsZero() {
+		return domain.NewValidationError("at", "reminder time is required")
+	}
+
+	return s.updateReminder(spacePath, captureID,
+		"UPDATE relevant_notes SET reminder_text = ?, reminder_at = ?, reminder_dismissed_at = NULL WHERE capture_id = ?",
+		text, at.Unix(), captureID)
+}
+
+// DismissReminder dismisses a linked note's reminder so it no longer appears
+// in {{active_reminders}}. Dismissing a note without a reminder is a no-op.
+func (s *SpaceDatabaseService) DismissReminder(spacePath, captureID string) error {
+	return s.updateReminder(spacePath, captureID,
+		"UPDATE relevant_notes SET reminder_dismissed_at = COALESCE(reminder_dismissed_at, CASE WHEN reminder_at IS NULL THEN NULL ELSE ? END) WHERE capture_id = ?",
+		time.Now().Unix(), captureID)
+}
+
+// updateReminder runs a reminder update against one linked note
+func (s *SpaceDatabaseService) updateReminder(spacePath, captureID, query string, args ...interface{}) error {
+	if err := s.checkWritable(spacePath); err != nil {
+		return err
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update reminder: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("note not found in space")
+	}
+
+	return bumpContextVersion(db)
+}
+
+// replaceActiveReminders replaces {{active_reminders}} with the reminders
+// whose time has passed and that have not been dismissed, oldest first
+func (s *ContextService) replaceActiveReminders(text string, db *sql.DB) string {
+	if !strings.Contains(text, "{{active_reminders}}") {
+		return text
+	}
+
+	rows, err := db.Query(`
+		SELECT reminder_text, note_path, reminder_at
+		FROM relevant_notes
+		WHERE reminder_at IS NOT NULL AND reminder_at <= ? AND reminder_dismissed_at IS NULL AND `+unexpiredCondition+`
+		ORDER BY reminder_at ASC
+		LIMIT ?
+	`, time.Now().Unix(), activeRemindersLimit)
+	if err != nil {
+		return strings.ReplaceAll(text, "{{active_reminders}}", "none")
+	}
+	defer rows.Close()
+
+	var reminders []string
+	for rows.Next() {
+		var reminderText, notePath string
+		var at int64
+		if err := rows.Scan(&reminderText, &notePath, &at); err != nil {
+			continue
+		}
+		reminders = append(reminders, fmt.Sprintf("- %s (%s, since %s)", reminderText, filepath.Base(notePath), time.Unix(at, 0).Format("Jan 2")))
+	}
+
+	if len(reminders) == 0 {
+		return strings.ReplaceAll(text, "{{active_reminders}}", "none")
+	}
+	return strings.ReplaceAll(text, "{{active_reminders}}", strings.Join(reminders, "\n"))
+}
@@ -0,0 +1,112 @@
+package space_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestNoteReminders(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(service)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	notes := map[string]string{}
+	for _, name := range []string{"seeds.md", "compost.md", "harvest.md"} {
+		captureID, notePath := createNamedCapture(t, parachuteRoot, name, name)
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		notes[name] = captureID
+	}
+
+	now := time.Now()
+	reminders := map[string]struct {
+		text string
+		at   time.Time
+	}{
+		"seeds.md":   {"Order seeds", now.Add(-time.Hour)},
+		"compost.md": {"Turn the compost", now.Add(-2 * time.Hour)},
+		"harvest.md": {"Harvest squash", now.Add(24 * time.Hour)},
+	}
+	for name, r := range reminders {
+		if err := service.SetReminder(spacePath, notes[name], r.text, r.at); err != nil {
+			t.Fatalf("Failed to set reminder: %v", err)
+		}
+	}
+
+	t.Run("StoredOnNote", func(t *testing.T) {
+		note, err := service.GetNoteByID(spacePath, notes["seeds.md"])
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if note.Reminder == nil || note.Reminder.Text != "Order seeds" || note.Reminder.DismissedAt != nil {
+			t.Errorf("Expected an active reminder, got %+v", note.Reminder)
+		}
+	})
+
+	t.Run("ActiveRemindersVariable", func(t *testing.T) {
+		result, err := contextService.ResolveVariables("{{active_reminders}}", spacePath)
+		if err != nil {
+			t.Fatalf("Failed to resolve variables: %v", err)
+		}
+
+		lines := strings.Split(result, "\n")
+		if len(lines) != 2 {
+			t.Fatalf("Expected 2 active reminders, got %q", result)
+		}
+		if !strings.Contains(lines[0], "Turn the compost") || !strings.Contains(lines[1], "Order seeds (seeds.md") {
+			t.Errorf("Expected oldest reminder first with its note, got %q", result)
+		}
+		if strings.Contains(result, "Harvest squash") {
+			t.Error("Expected future reminder to be left out")
+		}
+	})
+
+	t.Run("DismissedLeftOut", func(t *testing.T) {
+		if err := service.DismissReminder(spacePath, notes["compost.md"]); err != nil {
+			t.Fatalf("Failed to dismiss reminder: %v", err)
+		}
+
+		result, _ := contextService.ResolveVariables("{{active_reminders}}", spacePath)
+		if strings.Contains(result, "Turn the compost") || !strings.Contains(result, "Order seeds") {
+			t.Errorf("Expected only the undismissed reminder, got %q", result)
+		}
+
+		note, err := service.GetNoteByID(spacePath, notes["compost.md"])
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if note.Reminder == nil || note.Reminder.DismissedAt == nil {
+			t.Errorf("Expected reminder marked dismissed, got %+v", note.Reminder)
+		}
+	})
+
+	t.Run("NoneActive", func(t *testing.T) {
+		if err := service.DismissReminder(spacePath, notes["seeds.md"]); err != nil {
+			t.Fatalf("Failed to dismiss reminder: %v", err)
+		}
+		result, _ := contextService.ResolveVariables("{{active_reminders}}", spacePath)
+		if result != "none" {
+			t.Errorf("Expected none, got %q", result)
+		}
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		err := service.SetReminder(spacePath, notes["seeds.md"], "  ", now)
+		var validationErr *domain.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error for empty text, got %v", err)
+		}
+		if err := service.SetReminder(spacePath, "missing", "Text", now); err == nil || err.Error() != "note not found in space" {
+			t.Errorf("Expected note not found, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package space
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"path/filepath"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// maxSourceURLLength caps the length of a note's source URL
+const maxSourceURLLength = 2048
+
+// hasSourceCondition matches relevant_notes rows with a source URL
+const hasSourceCondition = "COALESCE(source_url, '') != ''"
+
+// ValidateSourceURL checks that raw is an absolute http or https URL with a
+// host, as required of a note's source URL
+func ValidateSourceURL(raw string) error {
+	if len(raw) > maxSourceURLLength {
+		return domain.NewValidationError("source_url", fmt.Sprintf("must be at most %d characters", maxSourceURLLength))
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return domain.NewValidationError("source_url", "must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// SetNoteSourceURL records the web page a linked note was clipped from, or
+// clears it when sourceURL is empty. Filter with NoteFilters.HasSource to
+// list clipped notes.
+func (s *SpaceDatabaseService) SetNoteSourceURL(spacePath, captureID, sourceURL string) error {
+	var value interface{}
+	if sourceURL != "" {
+		if err := ValidateSourceURL(sourceURL); err != nil {
+			return err
+		}
+		value = sourceURL
+	}
+
+	if err := s.checkWritable(spacePath); err != nil {
+		return err
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	result, err := db.Exec("UPDATE relevant_notes SET source_url = ? WHERE capture_id = ?", value, captureID)
+	if err != nil {
+		return fmt.Errorf("failed to set note source URL: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("note not found in space")
+	}
+
+	if err := bumpContextVersion(db); err != nil {
+		return err
+	}
+
+	s.syncManifest(spacePath)
+	return nil
+}
@@ -0,0 +1,113 @@
+package space_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestNoteSourceURL(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(service)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	clipped := space.LinkOptions{SourceURL: "https://example.com/articles/soil?ref=feed"}
+	if err := service.LinkNoteWithOptions(spaceID, spacePath, "clipped", "captures/clipped.md", "", nil, clipped); err != nil {
+		t.Fatalf("Failed to link clipped note: %v", err)
+	}
+	if err := service.LinkNote(spaceID, spacePath, "typed", "captures/typed.md", "", nil); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	t.Run("Link", func(t *testing.T) {
+		note, err := service.GetNoteByID(spacePath, "clipped")
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if note.SourceURL != clipped.SourceURL {
+			t.Errorf("Expected source URL %q, got %q", clipped.SourceURL, note.SourceURL)
+		}
+
+		// Relinking without a source URL keeps the one recorded
+		if err := service.LinkNote(spaceID, spacePath, "clipped", "captures/clipped.md", "relinked", nil); err != nil {
+			t.Fatalf("Failed to relink note: %v", err)
+		}
+		note, _ = service.GetNoteByID(spacePath, "clipped")
+		if note.SourceURL != clipped.SourceURL {
+			t.Errorf("Expected relinking to keep the source URL, got %q", note.SourceURL)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, raw := range []string{"not a url", "example.com/page", "ftp://example.com/file", "https://", "javascript:alert(1)"} {
+			err := service.LinkNoteWithOptions(spaceID, spacePath, "invalid", "captures/invalid.md", "", nil, space.LinkOptions{SourceURL: raw})
+			var validationErr *domain.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Errorf("Expected a validation error for %q, got %v", raw, err)
+			}
+			if err := service.SetNoteSourceURL(spacePath, "typed", raw); !errors.As(err, &validationErr) {
+				t.Errorf("Expected a validation error setting %q, got %v", raw, err)
+			}
+		}
+		if _, err := service.GetNoteByID(spacePath, "invalid"); err == nil {
+			t.Error("Expected a note with an invalid source URL not to be linked")
+		}
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		hasSource := true
+		notes, err := service.GetRelevantNotes(spacePath, space.NoteFilters{HasSource: &hasSource})
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		if len(notes) != 1 || notes[0].CaptureID != "clipped" {
+			t.Errorf("Expected only the clipped note, got %+v", notes)
+		}
+
+		hasSource = false
+		notes, err = service.GetRelevantNotes(spacePath, space.NoteFilters{HasSource: &hasSource})
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		if len(notes) != 1 || notes[0].CaptureID != "typed" {
+			t.Errorf("Expected only the typed note, got %+v", notes)
+		}
+	})
+
+	t.Run("ContextVariable", func(t *testing.T) {
+		result, err := contextService.ResolveVariables("From the web: {{notes_from_web}}", spacePath)
+		if err != nil {
+			t.Fatalf("Failed to resolve variables: %v", err)
+		}
+		if result != "From the web: 1" {
+			t.Errorf("Expected 'From the web: 1', got %q", result)
+		}
+	})
+
+	t.Run("SetAndClear", func(t *testing.T) {
+		if err := service.SetNoteSourceURL(spacePath, "typed", "http://example.org/post"); err != nil {
+			t.Fatalf("Failed to set source URL: %v", err)
+		}
+		note, _ := service.GetNoteByID(spacePath, "typed")
+		if note.SourceURL != "http://example.org/post" {
+			t.Errorf("Expected source URL to be set, got %q", note.SourceURL)
+		}
+
+		if err := service.SetNoteSourceURL(spacePath, "clipped", ""); err != nil {
+			t.Fatalf("Failed to clear source URL: %v", err)
+		}
+		note, _ = service.GetNoteByID(spacePath, "clipped")
+		if note.SourceURL != "" {
+			t.Errorf("Expected source URL to be cleared, got %q", note.SourceURL)
+		}
+
+		if err := service.SetNoteSourceURL(spacePath, "missing", "https://example.com"); err == nil {
+			t.Error("Expected an error for a note not linked in the space")
+		}
+	})
+}
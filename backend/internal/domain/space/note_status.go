@@ -0,0 +1,62 @@
+package space
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// Workflow statuses for a linked note
+const (
+	NoteStatusOpen       = "open" // Default for new and migrated links
+	NoteStatusInProgress = "in_progress"
+	NoteStatusDone       = "done"
+	NoteStatusArchived   = "archived"
+)
+
+// noteStatuses lists the valid note statuses
+var noteStatuses = []string{NoteStatusOpen, NoteStatusInProgress, NoteStatusDone, NoteStatusArchived}
+
+// validateNoteStatus rejects statuses other than the ones in noteStatuses
+func validateNoteStatus(status string) error {
+	for _, valid := range noteStatuses {
+		if status == valid {
+			return nil
+		}
+	}
+	return domain.NewValidationError("status", fmt.Sprintf("must be one of %s", strings.Join(noteStatuses, ", ")))
+}
+
+// SetNoteStatus sets the workflow status of a linked note
+func (s *SpaceDatabaseService) SetNoteStatus(spacePath, captureID, status string) error {
+	if err := s.checkWritable(spacePath); err != nil {
+		return err
+	}
+
+	if err := validateNoteStatus(status); err != nil {
+		return err
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	result, err := db.Exec("UPDATE relevant_notes SET status = ? WHERE capture_id = ?", status, captureID)
+	if err != nil {
+		return fmt.Errorf("failed to set note status: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("note not found in space")
+	}
+
+	return bumpContextVersion(db)
+}
@@ -0,0 +1,87 @@
+package space_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestNoteStatus(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(service)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	idA, pathA := createNamedCapture(t, parachuteRoot, "plant-beds.md", "Plant the beds")
+	idB, pathB := createNamedCapture(t, parachuteRoot, "order-seeds.md", "Order seeds")
+	for _, note := range [][2]string{{idA, pathA}, {idB, pathB}} {
+		if err := service.LinkNote(spaceID, spacePath, note[0], note[1], "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	t.Run("DefaultsToOpen", func(t *testing.T) {
+		note, err := service.GetNoteByID(spacePath, idA)
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if note.Status != space.NoteStatusOpen {
+			t.Errorf("Expected new link to be %q, got %q", space.NoteStatusOpen, note.Status)
+		}
+	})
+
+	t.Run("SetAndFilterByStatus", func(t *testing.T) {
+		if err := service.SetNoteStatus(spacePath, idB, space.NoteStatusDone); err != nil {
+			t.Fatalf("Failed to set status: %v", err)
+		}
+
+		done, err := service.GetRelevantNotes(spacePath, space.NoteFilters{Status: space.NoteStatusDone})
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		if len(done) != 1 || done[0].CaptureID != idB || done[0].Status != space.NoteStatusDone {
+			t.Errorf("Expected only %s to be done, got %+v", idB, done)
+		}
+
+		open, err := service.GetRelevantNotes(spacePath, space.NoteFilters{Status: space.NoteStatusOpen})
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		if len(open) != 1 || open[0].CaptureID != idA {
+			t.Errorf("Expected only %s to be open, got %+v", idA, open)
+		}
+	})
+
+	t.Run("RejectsInvalidStatus", func(t *testing.T) {
+		err := service.SetNoteStatus(spacePath, idA, "blocked")
+		var validationErr *domain.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error, got %v", err)
+		}
+	})
+
+	t.Run("UnknownNote", func(t *testing.T) {
+		err := service.SetNoteStatus(spacePath, "missing", space.NoteStatusDone)
+		if err == nil || err.Error() != "note not found in space" {
+			t.Errorf("Expected note not found, got %v", err)
+		}
+	})
+
+	t.Run("NotesWithStatusVariable", func(t *testing.T) {
+		result, err := contextService.ResolveVariables("Done:\n{{notes_with_status:done}}\nArchived: {{notes_with_status:archived}}", spacePath)
+		if err != nil {
+			t.Fatalf("Failed to resolve variables: %v", err)
+		}
+		if !strings.Contains(result, "- order-seeds.md (") || strings.Contains(result, "plant-beds.md") {
+			t.Errorf("Expected only the done note to be listed, got %q", result)
+		}
+		if !strings.Contains(result, "Archived: none") {
+			t.Errorf("Expected no archived notes, got %q", result)
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package space
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// maxThreadDepth caps how many levels of replies a thread may have
+const maxThreadDepth = 100
+
+// SetNoteParent makes a linked note a reply to another note linked in the
+// space, or a thread root again when parentCaptureID is empty. The parent
+// must be linked and must not be the note itself or one of its replies.
+func (s *SpaceDatabaseService) SetNoteParent(spacePath, captureID, parentCaptureID string) error {
+	if err := s.checkWritable(spacePath); err != nil {
+		return err
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	var value interface{}
+	if parentCaptureID != "" {
+		if err := checkThreadParent(db, captureID, parentCaptureID); err != nil {
+			return err
+		}
+		value = parentCaptureID
+	}
+
+	result, err := db.Exec("UPDATE relevant_notes SET parent_capture_id = ? WHERE capture_id = ?", value, captureID)
+	if err != nil {
+		return fmt.Errorf("failed to set note parent: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("note not found in space")
+	}
+
+	if err := bumpContextVersion(db); err != nil {
+		return err
+	}
+
+	s.syncManifest(spacePath)
+	return nil
+}
+
+// GetThread returns a linked note and every reply under it, level by level
+// and oldest first within a level. Each note's ParentCaptureID says where it
+// sits in the thread. Replies whose parent was unlinked start threads of
+// their own.
+func (s *SpaceDatabaseService) GetThread(spacePath, rootCaptureID string) ([]RelevantNote, error) {
+	// Reports a missing or unlinked root the same way a single note lookup does
+	if _, err := s.GetNoteByID(spacePath, rootCaptureID); err != nil {
+		return nil, err
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("space database not found")
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		WITH RECURSIVE thread(capture_id, depth) AS (
+			SELECT capture_id, 0 FROM relevant_notes WHERE capture_id = ?
+			UNION
+			SELECT r.capture_id, t.depth + 1
+			FROM relevant_notes r JOIN thread t ON r.parent_capture_id = t.capture_id
+			WHERE t.depth < ?
+		)
+		SELECT `+noteColumns+`
+		FROM relevant_notes JOIN thread USING (capture_id)
+		ORDER BY depth ASC, linked_at ASC, capture_id ASC
+	`, rootCaptureID, maxThreadDepth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query thread: %w", err)
+	}
+	defer rows.Close()
+
+	notes := []RelevantNote{}
+	seen := map[string]bool{}
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan note: %w", err)
+		}
+		if seen[note.CaptureID] {
+			continue // Reached again through a cycle in hand-edited data
+		}
+		seen[note.CaptureID] = true
+		notes = append(notes, note)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read thread: %w", err)
+	}
+
+	s.applyTagDisplayOrder(spacePath, notes)
+	return notes, nil
+}
+
+// checkThreadParent validates parentID as the parent of captureID: it must be
+// linked in the space, and captureID must not be among its ancestors
+func checkThreadParent(db *sql.DB, captureID, parentID string) error {
+	if parentID == captureID {
+		return domain.NewValidationError("parent_capture_id", "a note cannot be its own parent")
+	}
+
+	current := parentID
+	for depth := 0; ; depth++ {
+		if depth >= maxThreadDepth {
+			return domain.NewValidationError("parent_capture_id", fmt.Sprintf("threads may be at most %d levels deep", maxThreadDepth))
+		}
+
+		var next sql.NullString
+		err := db.QueryRow("SELECT parent_capture_id FROM relevant_notes WHERE capture_id = ?", current).Scan(&next)
+		if err == sql.ErrNoRows {
+			if current == parentID {
+				return domain.NewValidationError("parent_capture_id", "parent note is not linked in this space")
+			}
+			return nil // The thread above an unlinked ancestor is broken anyway
+		}
+		if err != nil {
+			return fmt.Errorf("failed to check note parent: %w", err)
+		}
+
+		if !next.Valid || next.String == "" {
+			return nil
+		}
+		if next.String == captureID {
+			return domain.NewValidationError("parent_capture_id", "parent note is a reply to this note")
+		}
+		current = next.String
+	}
+}
@@ -0,0 +1,120 @@
+package space_test
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestNoteThreads(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	// root <- later-reply, early-reply <- nested; early-reply is linked
+	// after later-reply but dated before it
+	links := []struct {
+		captureID string
+		parent    string
+		linkedAt  time.Time
+	}{
+		{"root", "", time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)},
+		{"later-reply", "root", time.Date(2024, 5, 3, 9, 0, 0, 0, time.UTC)},
+		{"early-reply", "root", time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC)},
+		{"nested", "early-reply", time.Date(2024, 5, 4, 9, 0, 0, 0, time.UTC)},
+	}
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to open space database: %v", err)
+	}
+	for _, l := range links {
+		opts := space.LinkOptions{ParentCaptureID: l.parent}
+		if err := service.LinkNoteWithOptions(spaceID, spacePath, l.captureID, "captures/"+l.captureID+".md", "", nil, opts); err != nil {
+			t.Fatalf("Failed to link %s: %v", l.captureID, err)
+		}
+		if _, err := db.Exec("UPDATE relevant_notes SET linked_at = ? WHERE capture_id = ?", l.linkedAt.Unix(), l.captureID); err != nil {
+			t.Fatalf("Failed to set linked_at: %v", err)
+		}
+	}
+	db.Close()
+
+	threadIDs := func(rootID string) []string {
+		notes, err := service.GetThread(spacePath, rootID)
+		if err != nil {
+			t.Fatalf("Failed to get thread: %v", err)
+		}
+		ids := []string{}
+		for _, note := range notes {
+			ids = append(ids, note.CaptureID)
+		}
+		return ids
+	}
+
+	t.Run("OrderedByDepthThenTime", func(t *testing.T) {
+		got := threadIDs("root")
+		want := []string{"root", "early-reply", "later-reply", "nested"}
+		if len(got) != len(want) {
+			t.Fatalf("Expected %v, got %v", want, got)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("Expected %v, got %v", want, got)
+			}
+		}
+
+		note, _ := service.GetNoteByID(spacePath, "nested")
+		if note == nil || note.ParentCaptureID != "early-reply" {
+			t.Errorf("Expected nested reply to early-reply, got %+v", note)
+		}
+	})
+
+	t.Run("Subthread", func(t *testing.T) {
+		if got := threadIDs("early-reply"); len(got) != 2 || got[1] != "nested" {
+			t.Errorf("Expected early-reply and nested, got %v", got)
+		}
+	})
+
+	t.Run("RelinkKeepsParent", func(t *testing.T) {
+		if err := service.LinkNote(spaceID, spacePath, "nested", "captures/nested.md", "Updated", nil); err != nil {
+			t.Fatalf("Failed to relink: %v", err)
+		}
+		note, _ := service.GetNoteByID(spacePath, "nested")
+		if note == nil || note.ParentCaptureID != "early-reply" {
+			t.Errorf("Expected parent kept on relink, got %+v", note)
+		}
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		var validationErr *domain.ValidationError
+		err := service.LinkNoteWithOptions(spaceID, spacePath, "orphan", "captures/orphan.md", "", nil,
+			space.LinkOptions{ParentCaptureID: "not-linked"})
+		if !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error for unlinked parent, got %v", err)
+		}
+		if err := service.SetNoteParent(spacePath, "root", "nested"); !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error for cycle, got %v", err)
+		}
+		if err := service.SetNoteParent(spacePath, "root", "root"); !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error for self parent, got %v", err)
+		}
+		if _, err := service.GetThread(spacePath, "not-linked"); err == nil || err.Error() != "note not found in space" {
+			t.Errorf("Expected note not found, got %v", err)
+		}
+	})
+
+	t.Run("Detach", func(t *testing.T) {
+		if err := service.SetNoteParent(spacePath, "early-reply", ""); err != nil {
+			t.Fatalf("Failed to clear parent: %v", err)
+		}
+		if got := threadIDs("root"); len(got) != 2 || got[1] != "later-reply" {
+			t.Errorf("Expected root and later-reply only, got %v", got)
+		}
+	})
+}
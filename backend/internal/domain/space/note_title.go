@@ -0,0 +1,77 @@
+package space
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain/file"
+)
+
+// titleCacheEntry caches a capture's derived title, keyed on the file's state
+// on disk so an edit invalidates it
+type titleCacheEntry struct {
+	modTime time.Time
+	size    int64
+	title   string
+}
+
+// titleCache holds derived titles per capture file path
+type titleCache struct {
+	mu      sync.Mutex
+	entries map[string]titleCacheEntry
+}
+
+// AttachTitles sets Title on each note from its capture file (see
+// file.ResolveTitle), falling back to the filename for captures that are
+// missing or have no text. Titles are cached until the file changes on disk.
+func (s *SpaceDatabaseService) AttachTitles(spacePath string, notes []RelevantNote) {
+	for i := range notes {
+		notes[i].Title = s.noteTitle(spacePath, notes[i].NotePath)
+	}
+}
+
+// noteTitle returns the title of one capture
+func (s *SpaceDatabaseService) noteTitle(spacePath, notePath string) string {
+	fallback := strings.TrimSuffix(filepath.Base(notePath), ".md")
+
+	fullPath, err := s.ResolveNoteFile(spacePath, notePath)
+	if err != nil {
+		return fallback
+	}
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return fallback
+	}
+
+	s.titles.mu.Lock()
+	entry, ok := s.titles.entries[fullPath]
+	s.titles.mu.Unlock()
+	if ok && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+		return entry.title
+	}
+
+	content, err := s.ReadCapture(fullPath)
+	if err != nil {
+		return fallback
+	}
+	title := file.ResolveTitle(string(content))
+	if title == "" {
+		title = fallback
+	}
+
+	s.titles.mu.Lock()
+	if s.titles.entries == nil {
+		s.titles.entries = make(map[string]titleCacheEntry)
+	}
+	s.titles.entries[fullPath] = titleCacheEntry{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		title:   title,
+	}
+	s.titles.mu.Unlock()
+
+	return title
+}
@@ -0,0 +1,60 @@
+package space_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestAttachTitles(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	titledID, titledPath := createNamedCapture(t, parachuteRoot, "2024-01-02_15-04-05.md", "# Seed order\n\nTomatoes and beans.")
+	missingPath := filepath.Join("captures", "2024-01-03_09-00-00.md")
+	for _, note := range [][2]string{{titledID, titledPath}, {"missing", missingPath}} {
+		if err := service.LinkNote(spaceID, spacePath, note[0], note[1], "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	titles := func() map[string]string {
+		notes, err := service.GetRelevantNotes(spacePath, space.NoteFilters{})
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		service.AttachTitles(spacePath, notes)
+
+		byID := map[string]string{}
+		for _, note := range notes {
+			byID[note.CaptureID] = note.Title
+		}
+		return byID
+	}
+
+	got := titles()
+	if got[titledID] != "Seed order" {
+		t.Errorf("Expected title from H1, got %q", got[titledID])
+	}
+	if got["missing"] != "2024-01-03_09-00-00" {
+		t.Errorf("Expected filename fallback for a missing capture, got %q", got["missing"])
+	}
+
+	// Editing the capture invalidates the cached title
+	fullPath := filepath.Join(parachuteRoot, titledPath)
+	if err := os.WriteFile(fullPath, []byte("---\ntitle: Seed order (final)\n---\nBody"), 0644); err != nil {
+		t.Fatalf("Failed to edit capture: %v", err)
+	}
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(fullPath, later, later)
+
+	if got := titles(); got[titledID] != "Seed order (final)" {
+		t.Errorf("Expected updated title after edit, got %q", got[titledID])
+	}
+}
@@ -0,0 +1,102 @@
+package space
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// NotesManifestFile is the plain-text manifest of a space's links, written to
+// the space directory when the notes_manifest setting is on
+const NotesManifestFile = "notes.json"
+
+// NotesManifest is the content of notes.json. Notes are ordered by capture ID
+// and nothing that changes on read is included, so the file only changes when
+// the links do and sync tools show meaningful diffs.
+type NotesManifest struct {
+	SpaceID string         `json:"space_id"`
+	Notes   []ManifestNote `json:"notes"`
+}
+
+// ManifestNote is one linked note in notes.json
+type ManifestNote struct {
+	CaptureID  string     `json:"capture_id"`
+	NotePath   string     `json:"note_path"`
+	Context    string     `json:"context"`
+	Tags       []string   `json:"tags"`
+	LinkedAt   time.Time  `json:"linked_at"`
+	CapturedAt *time.Time `json:"captured_at,omitempty"`
+}
+
+// ExportManifest writes (or rewrites) the space's notes.json from space.sqlite,
+// whatever the notes_manifest setting. The file is replaced atomically.
+func (s *SpaceDatabaseService) ExportManifest(spacePath string) error {
+	spaceID, err := s.spaceIDFromDatabase(spacePath)
+	if err != nil {
+		return err
+	}
+
+	notes, err := s.GetRelevantNotes(spacePath, NoteFilters{})
+	if err != nil {
+		return err
+	}
+
+	manifest := NotesManifest{SpaceID: spaceID, Notes: make([]ManifestNote, 0, len(notes))}
+	for _, note := range notes {
+		tags := note.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		entry := ManifestNote{
+			CaptureID: note.CaptureID,
+			NotePath:  note.NotePath,
+			Context:   note.Context,
+			Tags:      tags,
+			LinkedAt:  note.LinkedAt.UTC(),
+		}
+		if note.CapturedAt != nil {
+			capturedAt := note.CapturedAt.UTC()
+			entry.CapturedAt = &capturedAt
+		}
+		manifest.Notes = append(manifest.Notes, entry)
+	}
+	sort.Slice(manifest.Notes, func(i, j int) bool {
+		return manifest.Notes[i].CaptureID < manifest.Notes[j].CaptureID
+	})
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(spacePath, ".notes-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to create manifest: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(spacePath, NotesManifestFile)); err != nil {
+		return fmt.Errorf("failed to replace manifest: %w", err)
+	}
+	return nil
+}
+
+// syncManifest rewrites notes.json when the notes_manifest setting is on.
+// Like frontmatter sync it is best effort and never fails the change that
+// triggered it; ExportManifest regenerates the file if it falls behind.
+func (s *SpaceDatabaseService) syncManifest(spacePath string) {
+	if !s.boolSetting(spacePath, SettingNotesManifest) {
+		return
+	}
+	_ = s.ExportManifest(spacePath)
+}
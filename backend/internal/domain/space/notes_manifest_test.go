@@ -0,0 +1,99 @@
+package space_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestNotesManifest(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+	manifestPath := filepath.Join(spacePath, space.NotesManifestFile)
+
+	readManifest := func(t *testing.T) space.NotesManifest {
+		data, err := os.ReadFile(manifestPath)
+		if err != nil {
+			t.Fatalf("Failed to read manifest: %v", err)
+		}
+		var manifest space.NotesManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			t.Fatalf("Failed to parse manifest: %v", err)
+		}
+		return manifest
+	}
+
+	t.Run("OffByDefault", func(t *testing.T) {
+		if err := service.LinkNote(spaceID, spacePath, "before", "captures/before.md", "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		if _, err := os.Stat(manifestPath); !os.IsNotExist(err) {
+			t.Errorf("Expected no manifest without the setting, got %v", err)
+		}
+	})
+
+	t.Run("EnablingWritesManifest", func(t *testing.T) {
+		if err := service.SetSetting(spacePath, space.SettingNotesManifest, "true"); err != nil {
+			t.Fatalf("Failed to enable manifest: %v", err)
+		}
+		manifest := readManifest(t)
+		if manifest.SpaceID != spaceID || len(manifest.Notes) != 1 || manifest.Notes[0].CaptureID != "before" {
+			t.Errorf("Expected the existing link, got %+v", manifest)
+		}
+	})
+
+	t.Run("LinkAddsEntry", func(t *testing.T) {
+		if err := service.LinkNote(spaceID, spacePath, "walk", "captures/walk.md", "Garden walk", []string{"garden"}); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+
+		manifest := readManifest(t)
+		if len(manifest.Notes) != 2 {
+			t.Fatalf("Expected 2 notes, got %+v", manifest.Notes)
+		}
+		// Ordered by capture ID
+		walk := manifest.Notes[1]
+		if walk.CaptureID != "walk" || walk.NotePath != "captures/walk.md" || walk.Context != "Garden walk" ||
+			len(walk.Tags) != 1 || walk.Tags[0] != "garden" || walk.LinkedAt.IsZero() {
+			t.Errorf("Expected the walk entry, got %+v", walk)
+		}
+	})
+
+	t.Run("UpdateRewritesEntry", func(t *testing.T) {
+		context := "Evening walk"
+		if err := service.UpdateNoteContext(spacePath, "walk", &context, nil); err != nil {
+			t.Fatalf("Failed to update note: %v", err)
+		}
+		if manifest := readManifest(t); manifest.Notes[1].Context != context {
+			t.Errorf("Expected updated context, got %+v", manifest.Notes[1])
+		}
+	})
+
+	t.Run("UnlinkRemovesEntry", func(t *testing.T) {
+		if err := service.UnlinkNote(spacePath, "walk"); err != nil {
+			t.Fatalf("Failed to unlink note: %v", err)
+		}
+		manifest := readManifest(t)
+		if len(manifest.Notes) != 1 || manifest.Notes[0].CaptureID != "before" {
+			t.Errorf("Expected only the first note left, got %+v", manifest.Notes)
+		}
+	})
+
+	t.Run("ExportRegenerates", func(t *testing.T) {
+		if err := os.Remove(manifestPath); err != nil {
+			t.Fatalf("Failed to remove manifest: %v", err)
+		}
+		if err := service.ExportManifest(spacePath); err != nil {
+			t.Fatalf("Failed to export manifest: %v", err)
+		}
+		if manifest := readManifest(t); len(manifest.Notes) != 1 {
+			t.Errorf("Expected 1 note, got %+v", manifest.Notes)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package space
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// maxPinnedContextLength caps the pinned_context setting, in characters
+const maxPinnedContextLength = 4000
+
+// Markers around the pinned context in the rendered SPACE.md
+const (
+	pinnedContextStart = "<!-- pinned context -->"
+	pinnedContextEnd   = "<!-- /pinned context -->"
+)
+
+// validatePinnedContext trims a pinned context and checks its length
+func validatePinnedContext(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if utf8.RuneCountInString(value) > maxPinnedContextLength {
+		return "", domain.NewValidationError(SettingPinnedContext, fmt.Sprintf("must be at most %d characters", maxPinnedContextLength))
+	}
+	return value, nil
+}
+
+// applyPinnedContext adds the space's pinned context, between markers, before
+// or after rendered per its pinned_context_position. Variables in the pinned
+// text are not resolved. Lookup failures leave rendered unchanged.
+func (s *ContextService) applyPinnedContext(rendered, spacePath string) string {
+	pinned, err := s.spaceDBService.GetSetting(spacePath, SettingPinnedContext)
+	if err != nil || pinned == "" {
+		return rendered
+	}
+
+	block := pinnedContextStart + "\n" + pinned + "\n" + pinnedContextEnd
+	if rendered == "" {
+		return block
+	}
+
+	position, err := s.spaceDBService.GetSetting(spacePath, SettingPinnedContextPosition)
+	if err == nil && position == PinnedContextBottom {
+		return strings.TrimRight(rendered, "\n") + "\n\n" + block + "\n"
+	}
+	return block + "\n\n" + rendered
+}
@@ -0,0 +1,75 @@
+package space_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestPinnedContext(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(dbService)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+	spaceObj := &space.Space{ID: spaceID, Path: spacePath}
+
+	writeSpaceMD := func(t *testing.T, content string) {
+		if err := os.WriteFile(filepath.Join(spacePath, "SPACE.md"), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write SPACE.md: %v", err)
+		}
+	}
+	render := func(t *testing.T) string {
+		rendered, err := contextService.RenderSpaceMD(spaceObj)
+		if err != nil {
+			t.Fatalf("Failed to render: %v", err)
+		}
+		return rendered
+	}
+
+	writeSpaceMD(t, "# Garden\nNotes: {{note_count}}\n")
+
+	t.Run("NoneByDefault", func(t *testing.T) {
+		if got := render(t); got != "# Garden\nNotes: 0\n" {
+			t.Errorf("Expected SPACE.md alone, got %q", got)
+		}
+	})
+
+	if err := dbService.SetSetting(spacePath, space.SettingPinnedContext, "  Always answer in metric units.  "); err != nil {
+		t.Fatalf("Failed to set pinned context: %v", err)
+	}
+
+	t.Run("PrependedByDefault", func(t *testing.T) {
+		want := "<!-- pinned context -->\nAlways answer in metric units.\n<!-- /pinned context -->\n\n# Garden\nNotes: 0\n"
+		if got := render(t); got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("SurvivesSpaceMDReset", func(t *testing.T) {
+		writeSpaceMD(t, "# Garden\n\n## Context\nAdd relevant context here.\n")
+		got := render(t)
+		if !strings.HasPrefix(got, "<!-- pinned context -->\nAlways answer in metric units.") || !strings.HasSuffix(got, "Add relevant context here.\n") {
+			t.Errorf("Expected the pinned block before the new SPACE.md, got %q", got)
+		}
+	})
+
+	t.Run("Appended", func(t *testing.T) {
+		if err := dbService.SetSetting(spacePath, space.SettingPinnedContextPosition, space.PinnedContextBottom); err != nil {
+			t.Fatalf("Failed to set position: %v", err)
+		}
+		if got := render(t); !strings.HasSuffix(got, "here.\n\n<!-- pinned context -->\nAlways answer in metric units.\n<!-- /pinned context -->\n") {
+			t.Errorf("Expected the pinned block after SPACE.md, got %q", got)
+		}
+	})
+
+	t.Run("TooLong", func(t *testing.T) {
+		if err := dbService.SetSetting(spacePath, space.SettingPinnedContext, strings.Repeat("x", 4001)); err == nil {
+			t.Error("Expected an over-long pinned context to be rejected")
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package space
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is matched (with errors.Is) by the RateLimitError returned
+// when a space receives writes faster than its write rate limit allows
+var ErrRateLimited = errors.New("space write rate limit exceeded")
+
+// RateLimitError reports a write rejected by the space's rate limit
+type RateLimitError struct {
+	RetryAfter time.Duration // How long until the next write would be allowed
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%v, retry after %v", ErrRateLimited, e.RetryAfter)
+}
+
+// Unwrap lets errors.Is match ErrRateLimited
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// WriteRateLimit is a token bucket applied to each space's writes: a space
+// may make Burst writes at once, after which writes are allowed at
+// PerSecond. A zero PerSecond disables the limit.
+type WriteRateLimit struct {
+	PerSecond float64
+	Burst     int
+}
+
+// DefaultWriteRateLimit is far above what a person or a well-behaved sync
+// client produces, and only stops clients writing in a tight loop
+var DefaultWriteRateLimit = WriteRateLimit{PerSecond: 50, Burst: 200}
+
+// writeLimiter holds a token bucket per space
+type writeLimiter struct {
+	mu      sync.Mutex
+	limit   WriteRateLimit
+	buckets map[string]*tokenBucket
+}
+
+// tokenBucket is one space's remaining writes as of last
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// SetWriteRateLimit limits how fast each space accepts note mutations;
+// writes beyond the limit fail with a RateLimitError. Writes are unlimited
+// unless this is called. A write is charged once however many times it is
+// retried while the database is busy, and a bulk operation (an import, bulk
+// tag change or bulk unlink) is charged as one write.
+func (s *SpaceDatabaseService) SetWriteRateLimit(limit WriteRateLimit) {
+	s.limiter.mu.Lock()
+	defer s.limiter.mu.Unlock()
+
+	s.limiter.limit = limit
+	s.limiter.buckets = nil
+}
+
+// takeWriteToken spends one of the space's write tokens, returning a
+// RateLimitError when none are left
+func (s *SpaceDatabaseService) takeWriteToken(spacePath string) error {
+	l := &s.limiter
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit.PerSecond <= 0 {
+		return nil
+	}
+	burst := float64(max(l.limit.Burst, 1))
+
+	key := filepath.Clean(spacePath)
+	now := time.Now()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		if l.buckets == nil {
+			l.buckets = make(map[string]*tokenBucket)
+		}
+		bucket = &tokenBucket{tokens: burst, last: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens = min(burst, bucket.tokens+now.Sub(bucket.last).Seconds()*l.limit.PerSecond)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		wait := (1 - bucket.tokens) / l.limit.PerSecond
+		return &RateLimitError{RetryAfter: time.Duration(math.Ceil(wait * float64(time.Second)))}
+	}
+
+	bucket.tokens--
+	return nil
+}
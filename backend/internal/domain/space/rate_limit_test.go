@@ -0,0 +1,89 @@
+package space_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestWriteRateLimit(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+	otherID, otherPath := setupTestSpace(t, parachuteRoot)
+
+	link := func(id, path string, i int) error {
+		return service.LinkNote(id, path, fmt.Sprintf("note-%d", i), fmt.Sprintf("captures/note-%d.md", i), "", nil)
+	}
+
+	t.Run("UnlimitedByDefault", func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			if err := link(spaceID, spacePath, i); err != nil {
+				t.Fatalf("Expected write %d to succeed, got %v", i, err)
+			}
+		}
+	})
+
+	service.SetWriteRateLimit(space.WriteRateLimit{PerSecond: 10, Burst: 3})
+
+	t.Run("RejectsBeyondBurst", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			if err := link(spaceID, spacePath, i); err != nil {
+				t.Fatalf("Expected write %d within the burst to succeed, got %v", i, err)
+			}
+		}
+
+		err := link(spaceID, spacePath, 3)
+		var limitErr *space.RateLimitError
+		if !errors.Is(err, space.ErrRateLimited) || !errors.As(err, &limitErr) {
+			t.Fatalf("Expected a rate limit error, got %v", err)
+		}
+		if limitErr.RetryAfter <= 0 || limitErr.RetryAfter > 100*time.Millisecond {
+			t.Errorf("Expected a retry within 100ms, got %v", limitErr.RetryAfter)
+		}
+	})
+
+	t.Run("PerSpace", func(t *testing.T) {
+		if err := link(otherID, otherPath, 0); err != nil {
+			t.Errorf("Expected another space's writes to be unaffected, got %v", err)
+		}
+	})
+
+	t.Run("ResumesAfterRefill", func(t *testing.T) {
+		time.Sleep(150 * time.Millisecond)
+		if err := link(spaceID, spacePath, 4); err != nil {
+			t.Errorf("Expected write to succeed after the bucket refilled, got %v", err)
+		}
+	})
+
+	t.Run("ImportChargedOnce", func(t *testing.T) {
+		importID, importPath := setupTestSpace(t, parachuteRoot)
+
+		var captures []space.CaptureRef
+		for i := 0; i < 10; i++ {
+			id, path := createNamedCapture(t, parachuteRoot, fmt.Sprintf("import-%d.md", i), "# Imported\n")
+			captures = append(captures, space.CaptureRef{CaptureID: id, NotePath: path})
+		}
+
+		result, err := service.LinkNotesFromCaptures(importID, importPath, captures, nil)
+		if err != nil {
+			t.Fatalf("Failed to import captures: %v", err)
+		}
+		if result.Linked != len(captures) || result.Failed != 0 {
+			t.Errorf("Expected an import larger than the burst to link every capture, got %+v", result)
+		}
+	})
+
+	t.Run("ReadsUnlimited", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			if _, err := service.GetRelevantNotes(spacePath, space.NoteFilters{}); err != nil {
+				t.Fatalf("Expected reads to be unaffected, got %v", err)
+			}
+		}
+	})
+}
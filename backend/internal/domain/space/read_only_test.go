@@ -0,0 +1,107 @@
+package space_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+	sqliteStorage "github.com/unforced/parachute-backend/internal/storage/sqlite"
+)
+
+func TestReadOnlySpace(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	db, err := sqliteStorage.NewDatabase(filepath.Join(parachuteRoot, "parachute.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	spaceRepo := sqliteStorage.NewSpaceRepository(db.DB)
+	spaceService := space.NewService(spaceRepo, parachuteRoot)
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	dbService.SetSpaceRepository(spaceRepo)
+
+	spaceObj, err := spaceService.Create(ctx, "test-user", space.CreateSpaceParams{Name: "Published"})
+	if err != nil {
+		t.Fatalf("Failed to create space: %v", err)
+	}
+	if err := dbService.InitializeSpaceDatabase(spaceObj.ID, spaceObj.Path); err != nil {
+		t.Fatalf("Failed to initialize space database: %v", err)
+	}
+
+	linkedID, linkedPath := createNamedCapture(t, parachuteRoot, "2024-04-01_10-00-00.md", "Linked before publishing")
+	if err := dbService.LinkNote(spaceObj.ID, spaceObj.Path, linkedID, linkedPath, "Context", []string{"soil"}); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	readOnly := true
+	updated, err := spaceService.Update(ctx, spaceObj.ID, space.UpdateSpaceParams{ReadOnly: &readOnly})
+	if err != nil {
+		t.Fatalf("Failed to mark space read-only: %v", err)
+	}
+	if !updated.ReadOnly {
+		t.Fatal("Expected space to be read-only after update")
+	}
+
+	t.Run("FlagPersists", func(t *testing.T) {
+		got, err := spaceService.GetByID(ctx, spaceObj.ID)
+		if err != nil {
+			t.Fatalf("Failed to get space: %v", err)
+		}
+		if !got.ReadOnly {
+			t.Error("Expected read_only to be persisted")
+		}
+	})
+
+	t.Run("MutationsRejected", func(t *testing.T) {
+		newID, newPath := createNamedCapture(t, parachuteRoot, "2024-04-02_10-00-00.md", "New")
+		if err := dbService.LinkNote(spaceObj.ID, spaceObj.Path, newID, newPath, "", nil); !errors.Is(err, space.ErrSpaceReadOnly) {
+			t.Errorf("Expected ErrSpaceReadOnly linking, got %v", err)
+		}
+
+		newContext := "Changed"
+		if err := dbService.UpdateNoteContext(spaceObj.Path, linkedID, &newContext, nil); !errors.Is(err, space.ErrSpaceReadOnly) {
+			t.Errorf("Expected ErrSpaceReadOnly updating, got %v", err)
+		}
+		if err := dbService.UnlinkNote(spaceObj.Path, linkedID); !errors.Is(err, space.ErrSpaceReadOnly) {
+			t.Errorf("Expected ErrSpaceReadOnly unlinking, got %v", err)
+		}
+		if _, err := dbService.SaveSearch(spaceObj.Path, "Soil", space.NoteFilters{Tags: []string{"soil"}}); !errors.Is(err, space.ErrSpaceReadOnly) {
+			t.Errorf("Expected ErrSpaceReadOnly saving a search, got %v", err)
+		}
+		if err := dbService.DeleteSavedSearch(spaceObj.Path, "Soil"); !errors.Is(err, space.ErrSpaceReadOnly) {
+			t.Errorf("Expected ErrSpaceReadOnly deleting a search, got %v", err)
+		}
+		if err := dbService.SetSetting(spaceObj.Path, space.SettingPinnedContext, "Pinned"); !errors.Is(err, space.ErrSpaceReadOnly) {
+			t.Errorf("Expected ErrSpaceReadOnly changing a setting, got %v", err)
+		}
+		if err := dbService.RecordContextHistory(spaceObj.Path, space.ContextHistoryEntry{Content: "Rendered"}); !errors.Is(err, space.ErrSpaceReadOnly) {
+			t.Errorf("Expected ErrSpaceReadOnly recording context history, got %v", err)
+		}
+	})
+
+	t.Run("ReadsStillWork", func(t *testing.T) {
+		notes, err := dbService.GetRelevantNotes(spaceObj.Path, space.NoteFilters{})
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		if len(notes) != 1 || notes[0].Context != "Context" {
+			t.Errorf("Expected the original note unchanged, got %+v", notes)
+		}
+	})
+
+	t.Run("WritableAgainAfterToggle", func(t *testing.T) {
+		writable := false
+		if _, err := spaceService.Update(ctx, spaceObj.ID, space.UpdateSpaceParams{ReadOnly: &writable}); err != nil {
+			t.Fatalf("Failed to clear read-only: %v", err)
+		}
+		if err := dbService.UnlinkNote(spaceObj.Path, linkedID); err != nil {
+			t.Errorf("Expected unlink to succeed on writable space, got %v", err)
+		}
+	})
+}
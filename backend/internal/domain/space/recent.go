@@ -0,0 +1,157 @@
+package space
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultRecentSpacesLimit is the number of spaces ListRecentSpaces returns when no limit is given
+const DefaultRecentSpacesLimit = 10
+
+// Activity sources reported in SpaceActivitySummary
+const (
+	ActivitySourceNotes   = "notes"   // Latest note link or reference
+	ActivitySourceCreated = "created" // No note activity, falls back to space creation
+)
+
+// SpaceActivitySummary describes when a space was last active
+type SpaceActivitySummary struct {
+	Space          *Space    `json:"space"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+	ActivitySource string    `json:"activity_source"`
+}
+
+// activityCacheEntry caches a space's last activity, keyed on the state of its
+// space.sqlite file so any write to the database invalidates it
+type activityCacheEntry struct {
+	modTime      time.Time
+	size         int64
+	lastActivity *time.Time
+}
+
+// activityCache holds last-activity lookups per space path
+type activityCache struct {
+	mu      sync.Mutex
+	entries map[string]activityCacheEntry
+}
+
+// LastActivity returns the most recent linked_at or last_referenced time of
+// the space's notes, or nil if the space has no notes (or no database yet).
+// Results are cached until space.sqlite changes on disk.
+func (s *SpaceDatabaseService) LastActivity(spacePath string) (*time.Time, error) {
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	info, err := os.Stat(dbPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat space database: %w", err)
+	}
+
+	s.activity.mu.Lock()
+	entry, ok := s.activity.entries[spacePath]
+	s.activity.mu.Unlock()
+	if ok && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+		return entry.lastActivity, nil
+	}
+
+	lastActivity, err := queryLastActivity(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	s.activity.mu.Lock()
+	if s.activity.entries == nil {
+		s.activity.entries = make(map[string]activityCacheEntry)
+	}
+	s.activity.entries[spacePath] = activityCacheEntry{
+		modTime:      info.ModTime(),
+		size:         info.Size(),
+		lastActivity: lastActivity,
+	}
+	s.activity.mu.Unlock()
+
+	return lastActivity, nil
+}
+
+// queryLastActivity reads the latest note activity from a space database
+func queryLastActivity(dbPath string) (*time.Time, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	var maxLinked, maxReferenced sql.NullInt64
+	err = db.QueryRow("SELECT MAX(linked_at), MAX(last_referenced) FROM relevant_notes").Scan(&maxLinked, &maxReferenced)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query last activity: %w", err)
+	}
+
+	if !maxLinked.Valid && !maxReferenced.Valid {
+		return nil, nil
+	}
+
+	latest := maxLinked.Int64
+	if maxReferenced.Valid && maxReferenced.Int64 > latest {
+		latest = maxReferenced.Int64
+	}
+
+	t := time.Unix(latest, 0)
+	return &t, nil
+}
+
+// SetDatabaseService sets the space database service used to read note
+// activity. Without it ListRecentSpaces orders spaces by creation time only.
+func (s *Service) SetDatabaseService(dbService *SpaceDatabaseService) {
+	s.dbService = dbService
+}
+
+// ListRecentSpaces returns a user's spaces ordered by latest note activity,
+// most recent first. Spaces without note activity use their creation time.
+func (s *Service) ListRecentSpaces(ctx context.Context, userID string, limit int) ([]SpaceActivitySummary, error) {
+	if limit <= 0 {
+		limit = DefaultRecentSpacesLimit
+	}
+
+	spaces, err := s.repo.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]SpaceActivitySummary, 0, len(spaces))
+	for _, sp := range spaces {
+		summary := SpaceActivitySummary{
+			Space:          sp,
+			LastActivityAt: sp.CreatedAt,
+			ActivitySource: ActivitySourceCreated,
+		}
+
+		if s.dbService != nil {
+			// A space whose database can't be read still appears, ordered by creation time
+			if lastActivity, err := s.dbService.LastActivity(sp.Path); err == nil && lastActivity != nil {
+				summary.LastActivityAt = *lastActivity
+				summary.ActivitySource = ActivitySourceNotes
+			}
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return summaries[i].LastActivityAt.After(summaries[j].LastActivityAt)
+	})
+
+	if len(summaries) > limit {
+		summaries = summaries[:limit]
+	}
+
+	return summaries, nil
+}
@@ -0,0 +1,138 @@
+package space_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+	sqliteStorage "github.com/unforced/parachute-backend/internal/storage/sqlite"
+)
+
+func TestListRecentSpaces(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	db, err := sqliteStorage.NewDatabase(filepath.Join(parachuteRoot, "parachute.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	service := space.NewService(sqliteStorage.NewSpaceRepository(db.DB), parachuteRoot)
+	service.SetDatabaseService(dbService)
+
+	create := func(name string) *space.Space {
+		sp, err := service.Create(ctx, "default", space.CreateSpaceParams{Name: name})
+		if err != nil {
+			t.Fatalf("Failed to create space %s: %v", name, err)
+		}
+		return sp
+	}
+
+	// setActivity links a note with the given timestamps directly in space.sqlite
+	setActivity := func(sp *space.Space, captureID string, linkedAt time.Time, lastReferenced *time.Time) {
+		if err := dbService.InitializeSpaceDatabase(sp.ID, sp.Path); err != nil {
+			t.Fatalf("Failed to initialize space database: %v", err)
+		}
+
+		spaceDB, err := sql.Open("sqlite", filepath.Join(sp.Path, "space.sqlite"))
+		if err != nil {
+			t.Fatalf("Failed to open space database: %v", err)
+		}
+		defer spaceDB.Close()
+
+		var lastRef sql.NullInt64
+		if lastReferenced != nil {
+			lastRef = sql.NullInt64{Int64: lastReferenced.Unix(), Valid: true}
+		}
+
+		_, err = spaceDB.Exec(`
+			INSERT INTO relevant_notes (id, capture_id, note_path, linked_at, last_referenced)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(capture_id) DO UPDATE SET
+				linked_at = excluded.linked_at,
+				last_referenced = excluded.last_referenced
+		`, captureID, captureID, "captures/"+captureID+".md", linkedAt.Unix(), lastRef)
+		if err != nil {
+			t.Fatalf("Failed to insert note: %v", err)
+		}
+	}
+
+	names := func(summaries []space.SpaceActivitySummary) []string {
+		result := make([]string, len(summaries))
+		for i, summary := range summaries {
+			result[i] = summary.Space.Name
+		}
+		return result
+	}
+
+	now := time.Now()
+	linkedOnly := create("Linked Only")
+	referenced := create("Referenced")
+	create("Idle")
+
+	// Idle has no notes, so it falls back to its creation time (now), which is
+	// later than any note activity below.
+	setActivity(linkedOnly, "note-a", now.Add(-1*time.Hour), nil)
+	recentRef := now.Add(-1 * time.Minute)
+	setActivity(referenced, "note-b", now.Add(-2*time.Hour), &recentRef)
+
+	t.Run("OrdersByLatestActivity", func(t *testing.T) {
+		summaries, err := service.ListRecentSpaces(ctx, "default", 0)
+		if err != nil {
+			t.Fatalf("Failed to list recent spaces: %v", err)
+		}
+
+		got := names(summaries)
+		want := []string{"Idle", "Referenced", "Linked Only"}
+		if len(got) != len(want) {
+			t.Fatalf("Expected %v, got %v", want, got)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("Expected %v, got %v", want, got)
+			}
+		}
+
+		if summaries[0].ActivitySource != space.ActivitySourceCreated {
+			t.Errorf("Expected idle space to use creation time, got %s", summaries[0].ActivitySource)
+		}
+		if summaries[1].ActivitySource != space.ActivitySourceNotes {
+			t.Errorf("Expected referenced space to use note activity, got %s", summaries[1].ActivitySource)
+		}
+		if summaries[1].LastActivityAt.Unix() != recentRef.Unix() {
+			t.Errorf("Expected last_referenced to win over linked_at, got %v", summaries[1].LastActivityAt)
+		}
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		summaries, err := service.ListRecentSpaces(ctx, "default", 2)
+		if err != nil {
+			t.Fatalf("Failed to list recent spaces: %v", err)
+		}
+		if len(summaries) != 2 {
+			t.Errorf("Expected 2 spaces, got %d", len(summaries))
+		}
+	})
+
+	t.Run("NewActivityInvalidatesCache", func(t *testing.T) {
+		// Prime the cache, then record newer activity in the oldest space
+		if _, err := service.ListRecentSpaces(ctx, "default", 0); err != nil {
+			t.Fatalf("Failed to list recent spaces: %v", err)
+		}
+		setActivity(linkedOnly, "note-c", now.Add(time.Hour), nil)
+
+		summaries, err := service.ListRecentSpaces(ctx, "default", 0)
+		if err != nil {
+			t.Fatalf("Failed to list recent spaces: %v", err)
+		}
+		if summaries[0].Space.ID != linkedOnly.ID {
+			t.Errorf("Expected newly active space first, got %v", names(summaries))
+		}
+	})
+}
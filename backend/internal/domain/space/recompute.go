@@ -0,0 +1,235 @@
+package space
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Repair checks run by RecomputeDenormalized
+const (
+	RepairCheckTags           = "tags"            // relevant_notes.tags is not a clean JSON list
+	RepairCheckTombstone      = "tombstone"       // deleted_notes entry for a note that is linked
+	RepairCheckContextVersion = "context_version" // space_metadata context_version is not a counter
+)
+
+// RepairCorrection describes one value fixed by RecomputeDenormalized
+type RepairCorrection struct {
+	Check  string `json:"check"`
+	Target string `json:"target"` // Capture ID or metadata key
+	Before string `json:"before"`
+	After  string `json:"after,omitempty"` // Empty when the row was removed
+}
+
+// RepairReport summarizes a RecomputeDenormalized run
+type RepairReport struct {
+	NotesChecked int                `json:"notes_checked"`
+	Corrections  []RepairCorrection `json:"corrections"`
+}
+
+// RecomputeDenormalized recalculates data in space.sqlite that is derived from
+// other columns and fixes anything that has drifted, e.g. after a crash or a
+// manual edit of the database. It currently checks that tags are a valid,
+// de-duplicated JSON list, that no unlink tombstone shadows a linked note, and
+// that context_version is a valid counter. All fixes are applied in one
+// transaction.
+func (s *SpaceDatabaseService) RecomputeDenormalized(spacePath string) (RepairReport, error) {
+	if err := s.checkWritable(spacePath); err != nil {
+		return RepairReport{Corrections: []RepairCorrection{}}, err
+	}
+
+	return s.recomputeDenormalized(spacePath)
+}
+
+// recomputeDenormalized is RecomputeDenormalized without the writability check
+func (s *SpaceDatabaseService) recomputeDenormalized(spacePath string) (RepairReport, error) {
+	report := RepairReport{Corrections: []RepairCorrection{}}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return report, fmt.Errorf("space database not found")
+	}
+
+	unlock, err := s.lockSpace(spacePath)
+	if err != nil {
+		return report, err
+	}
+	defer unlock()
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return report, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return report, fmt.Errorf("failed to begin repair: %w", err)
+	}
+	defer tx.Rollback()
+
+	tagFixes, checked, err := repairTags(tx)
+	if err != nil {
+		return report, err
+	}
+	report.NotesChecked = checked
+	report.Corrections = append(report.Corrections, tagFixes...)
+
+	tombstoneFixes, err := repairTombstones(tx)
+	if err != nil {
+		return report, err
+	}
+	report.Corrections = append(report.Corrections, tombstoneFixes...)
+
+	versionFix, err := repairContextVersion(tx)
+	if err != nil {
+		return report, err
+	}
+	if versionFix != nil {
+		report.Corrections = append(report.Corrections, *versionFix)
+	} else if len(tagFixes) > 0 {
+		// Repaired tags can change resolved SPACE.md variables
+		if _, err := tx.Exec(`
+			UPDATE space_metadata SET value = CAST(value AS INTEGER) + 1 WHERE key = ?
+		`, contextVersionKey); err != nil {
+			return report, fmt.Errorf("failed to bump context version: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return report, fmt.Errorf("failed to commit repair: %w", err)
+	}
+
+	return report, nil
+}
+
+// repairTags rewrites tags that are not valid JSON or contain empty or
+// duplicate entries. It returns the corrections and the number of notes checked.
+func repairTags(tx *sql.Tx) ([]RepairCorrection, int, error) {
+	rows, err := tx.Query("SELECT capture_id, tags FROM relevant_notes")
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query tags: %w", err)
+	}
+
+	type tagRow struct {
+		captureID string
+		tags      sql.NullString
+	}
+	var all []tagRow
+	for rows.Next() {
+		var row tagRow
+		if err := rows.Scan(&row.captureID, &row.tags); err != nil {
+			rows.Close()
+			return nil, 0, fmt.Errorf("failed to scan tags: %w", err)
+		}
+		all = append(all, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read tags: %w", err)
+	}
+
+	var fixes []RepairCorrection
+	for _, row := range all {
+		if !row.tags.Valid {
+			continue
+		}
+
+		var tags []string
+		var repaired string
+		if err := json.Unmarshal([]byte(row.tags.String), &tags); err != nil {
+			repaired = "[]"
+		} else if tags == nil {
+			continue
+		} else {
+			clean := mergeTags(tags)
+			if len(clean) == len(tags) {
+				continue
+			}
+			encoded, _ := json.Marshal(clean)
+			repaired = string(encoded)
+		}
+
+		if _, err := tx.Exec("UPDATE relevant_notes SET tags = ? WHERE capture_id = ?", repaired, row.captureID); err != nil {
+			return nil, 0, fmt.Errorf("failed to repair tags: %w", err)
+		}
+		fixes = append(fixes, RepairCorrection{
+			Check:  RepairCheckTags,
+			Target: row.captureID,
+			Before: row.tags.String,
+			After:  repaired,
+		})
+	}
+
+	return fixes, len(all), nil
+}
+
+// repairTombstones removes unlink tombstones for notes that are still linked
+func repairTombstones(tx *sql.Tx) ([]RepairCorrection, error) {
+	rows, err := tx.Query(`
+		SELECT d.capture_id, d.note_path FROM deleted_notes d
+		JOIN relevant_notes r ON r.capture_id = d.capture_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tombstones: %w", err)
+	}
+
+	var fixes []RepairCorrection
+	for rows.Next() {
+		var captureID, notePath string
+		if err := rows.Scan(&captureID, &notePath); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan tombstone: %w", err)
+		}
+		fixes = append(fixes, RepairCorrection{
+			Check:  RepairCheckTombstone,
+			Target: captureID,
+			Before: notePath,
+		})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tombstones: %w", err)
+	}
+
+	for _, fix := range fixes {
+		if _, err := tx.Exec("DELETE FROM deleted_notes WHERE capture_id = ?", fix.Target); err != nil {
+			return nil, fmt.Errorf("failed to remove tombstone: %w", err)
+		}
+	}
+
+	return fixes, nil
+}
+
+// repairContextVersion resets a context_version that is not a non-negative
+// integer. Clients could not have read the corrupt value, so restarting the
+// counter at 1 is enough to signal a change.
+func repairContextVersion(tx *sql.Tx) (*RepairCorrection, error) {
+	var value string
+	err := tx.QueryRow("SELECT value FROM space_metadata WHERE key = ?", contextVersionKey).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read context version: %w", err)
+	}
+
+	if version, err := strconv.ParseInt(value, 10, 64); err == nil && version >= 0 {
+		return nil, nil
+	}
+
+	if _, err := tx.Exec("UPDATE space_metadata SET value = '1' WHERE key = ?", contextVersionKey); err != nil {
+		return nil, fmt.Errorf("failed to reset context version: %w", err)
+	}
+
+	return &RepairCorrection{
+		Check:  RepairCheckContextVersion,
+		Target: contextVersionKey,
+		Before: value,
+		After:  "1",
+	}, nil
+}
@@ -0,0 +1,97 @@
+package space_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestRecomputeDenormalized(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	idA, pathA := createNamedCapture(t, parachuteRoot, "a.md", "Soil")
+	idB, pathB := createNamedCapture(t, parachuteRoot, "b.md", "Seeds")
+	for _, note := range [][2]string{{idA, pathA}, {idB, pathB}} {
+		if err := service.LinkNote(spaceID, spacePath, note[0], note[1], "", []string{"garden"}); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	t.Run("CleanDatabaseNeedsNoRepair", func(t *testing.T) {
+		report, err := service.RecomputeDenormalized(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to recompute: %v", err)
+		}
+		if report.NotesChecked != 2 || len(report.Corrections) != 0 {
+			t.Errorf("Expected 2 notes checked and no corrections, got %+v", report)
+		}
+	})
+
+	t.Run("RepairsCorruptedData", func(t *testing.T) {
+		db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+		if err != nil {
+			t.Fatalf("Failed to open space database: %v", err)
+		}
+		corrupt := []string{
+			`UPDATE relevant_notes SET tags = '["garden","garden",""]' WHERE capture_id = '` + idA + `'`,
+			`UPDATE relevant_notes SET tags = 'not json' WHERE capture_id = '` + idB + `'`,
+			`INSERT INTO deleted_notes (capture_id, note_path, deleted_at) VALUES ('` + idA + `', 'captures/a.md', 0)`,
+			`UPDATE space_metadata SET value = 'garbage' WHERE key = 'context_version'`,
+		}
+		for _, stmt := range corrupt {
+			if _, err := db.Exec(stmt); err != nil {
+				t.Fatalf("Failed to corrupt database: %v", err)
+			}
+		}
+		db.Close()
+
+		report, err := service.RecomputeDenormalized(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to recompute: %v", err)
+		}
+
+		checks := map[string]int{}
+		for _, correction := range report.Corrections {
+			checks[correction.Check]++
+		}
+		want := map[string]int{
+			space.RepairCheckTags:           2,
+			space.RepairCheckTombstone:      1,
+			space.RepairCheckContextVersion: 1,
+		}
+		if !reflect.DeepEqual(checks, want) {
+			t.Errorf("Expected corrections %v, got %v", want, checks)
+		}
+
+		noteA, err := service.GetNoteByID(spacePath, idA)
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if !reflect.DeepEqual(noteA.Tags, []string{"garden"}) {
+			t.Errorf("Expected tags to be de-duplicated, got %v", noteA.Tags)
+		}
+
+		if version, err := service.GetContextVersion(spacePath); err != nil || version != 1 {
+			t.Errorf("Expected context version reset to 1, got %d (%v)", version, err)
+		}
+
+		if err := service.UnlinkNote(spacePath, idA); err != nil {
+			t.Fatalf("Failed to unlink note: %v", err)
+		}
+
+		again, err := service.RecomputeDenormalized(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to recompute: %v", err)
+		}
+		if len(again.Corrections) != 0 {
+			t.Errorf("Expected a repaired database to stay clean, got %+v", again.Corrections)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package space
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// topReferencedLimit caps how many notes AnalyticsSeries.TopNotes lists
+const topReferencedLimit = 10
+
+// maxAnalyticsBuckets caps how many buckets one analytics request may span
+const maxAnalyticsBuckets = 1000
+
+// referenceLogRetention is how long the reference log keeps an entry
+const referenceLogRetention = 365 * 24 * time.Hour
+
+// ReferencedNote is a note with how often it was referenced in a window
+type ReferencedNote struct {
+	CaptureID  string `json:"capture_id"`
+	NotePath   string `json:"note_path,omitempty"` // Empty when the note has since been unlinked
+	References int    `json:"references"`
+}
+
+// AnalyticsSeries is a space's note references over a time window
+type AnalyticsSeries struct {
+	Bucket   string            `json:"bucket"`
+	From     time.Time         `json:"from"`
+	To       time.Time         `json:"to"`
+	Total    int               `json:"total"`
+	Buckets  []HistogramBucket `json:"buckets"`   // Every bucket in the window, zero-filled
+	TopNotes []ReferencedNote  `json:"top_notes"` // Most referenced first, ties by capture ID
+}
+
+// GetReferenceAnalytics counts the references logged by TrackNoteReference in
+// [from, to) per day, week or month, with every bucket overlapping the window
+// included, and lists the most referenced notes in the window. Only
+// references made since the reference log was added, and within
+// referenceLogRetention, are counted; a note's references are dropped when its
+// unlinked record is purged.
+func (s *SpaceDatabaseService) GetReferenceAnalytics(spacePath, bucket string, from, to time.Time) (AnalyticsSeries, error) {
+	series := AnalyticsSeries{Bucket: bucket, From: from, To: to, Buckets: []HistogramBucket{}, TopNotes: []ReferencedNote{}}
+
+	if err := validateHistogramBucket(bucket); err != nil {
+		return series, err
+	}
+	if !from.Before(to) {
+		return series, domain.NewValidationError("from", "must be before to")
+	}
+
+	first, last := bucketStart(from, bucket), bucketStart(to.Add(-time.Nanosecond), bucket)
+	counts := make(map[time.Time]int)
+	for start := first; !start.After(last); start = nextBucket(start, bucket) {
+		if len(counts) == maxAnalyticsBuckets {
+			return series, domain.NewValidationError("bucket", fmt.Sprintf("window spans more than %d buckets", maxAnalyticsBuckets))
+		}
+		counts[start] = 0
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return series, fmt.Errorf("space database not found")
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return series, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	lo, hi := ceilUnix(from), ceilUnix(to)
+	rows, err := db.Query("SELECT referenced_at FROM note_references WHERE referenced_at >= ? AND referenced_at < ?", lo, hi)
+	if err != nil {
+		return series, fmt.Errorf("failed to query references: %w", err)
+	}
+	for rows.Next() {
+		var referencedAt int64
+		if err := rows.Scan(&referencedAt); err != nil {
+			rows.Close()
+			return series, fmt.Errorf("failed to scan reference: %w", err)
+		}
+		counts[bucketStart(time.Unix(referencedAt, 0), bucket)]++
+		series.Total++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return series, fmt.Errorf("failed to query references: %w", err)
+	}
+
+	for start := first; !start.After(last); start = nextBucket(start, bucket) {
+		series.Buckets = append(series.Buckets, HistogramBucket{Start: start, Count: counts[start]})
+	}
+
+	top, err := db.Query(`
+		SELECT r.capture_id, COALESCE(n.note_path, ''), COUNT(*) AS refs
+		FROM note_references r
+		LEFT JOIN relevant_notes n ON n.capture_id = r.capture_id
+		WHERE r.referenced_at >= ? AND r.referenced_at < ?
+		GROUP BY r.capture_id
+		ORDER BY refs DESC, r.capture_id
+		LIMIT ?
+	`, lo, hi, topReferencedLimit)
+	if err != nil {
+		return series, fmt.Errorf("failed to query top notes: %w", err)
+	}
+	defer top.Close()
+
+	for top.Next() {
+		var note ReferencedNote
+		if err := top.Scan(&note.CaptureID, &note.NotePath, &note.References); err != nil {
+			return series, fmt.Errorf("failed to scan top note: %w", err)
+		}
+		series.TopNotes = append(series.TopNotes, note)
+	}
+
+	return series, top.Err()
+}
+
+// ceilUnix rounds t up to a whole second, so comparing it with the log's
+// second-resolution timestamps keeps the window bounds exact
+func ceilUnix(t time.Time) int64 {
+	if t.Nanosecond() > 0 {
+		return t.Unix() + 1
+	}
+	return t.Unix()
+}
@@ -0,0 +1,104 @@
+package space_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestGetReferenceAnalytics(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	for _, id := range []string{"soil", "seeds"} {
+		if err := service.LinkNote(spaceID, spacePath, id, "captures/"+id+".md", "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to open space database: %v", err)
+	}
+	defer db.Close()
+
+	day := func(d, hour int) time.Time { return time.Date(2024, 6, d, hour, 0, 0, 0, time.Local) }
+	references := []struct {
+		captureID string
+		at        time.Time
+	}{
+		{"soil", day(3, 9)},
+		{"soil", day(3, 17)},
+		{"seeds", day(3, 12)},
+		{"soil", day(5, 8)},
+		{"soil", day(9, 8)}, // Outside the window
+	}
+	for _, r := range references {
+		if _, err := db.Exec("INSERT INTO note_references (capture_id, referenced_at) VALUES (?, ?)", r.captureID, r.at.Unix()); err != nil {
+			t.Fatalf("Failed to insert reference: %v", err)
+		}
+	}
+
+	t.Run("DailySeries", func(t *testing.T) {
+		series, err := service.GetReferenceAnalytics(spacePath, space.HistogramBucketDay, day(3, 0), day(6, 0))
+		if err != nil {
+			t.Fatalf("Failed to get analytics: %v", err)
+		}
+
+		want := []int{3, 0, 1}
+		if len(series.Buckets) != len(want) {
+			t.Fatalf("Expected %d buckets, got %+v", len(want), series.Buckets)
+		}
+		for i, count := range want {
+			if !series.Buckets[i].Start.Equal(day(3+i, 0)) || series.Buckets[i].Count != count {
+				t.Errorf("Bucket %d: expected %d on %s, got %+v", i, count, day(3+i, 0), series.Buckets[i])
+			}
+		}
+		if series.Total != 4 {
+			t.Errorf("Expected 4 references in the window, got %d", series.Total)
+		}
+
+		if len(series.TopNotes) != 2 || series.TopNotes[0].CaptureID != "soil" || series.TopNotes[0].References != 3 || series.TopNotes[0].NotePath != "captures/soil.md" {
+			t.Errorf("Expected soil to top the window with 3 references, got %+v", series.TopNotes)
+		}
+	})
+
+	t.Run("TrackNoteReferenceLogs", func(t *testing.T) {
+		if err := service.TrackNoteReference(spacePath, "seeds"); err != nil {
+			t.Fatalf("Failed to track reference: %v", err)
+		}
+
+		now := time.Now()
+		series, err := service.GetReferenceAnalytics(spacePath, space.HistogramBucketDay, now.Add(-time.Hour), now.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("Failed to get analytics: %v", err)
+		}
+		if series.Total != 1 || len(series.TopNotes) != 1 || series.TopNotes[0].CaptureID != "seeds" {
+			t.Errorf("Expected the tracked reference to be logged, got %+v", series)
+		}
+
+		// The 2024 references are past the log's retention
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM note_references").Scan(&count); err != nil {
+			t.Fatalf("Failed to count references: %v", err)
+		}
+		if count != 1 {
+			t.Errorf("Expected old references to be pruned, got %d left", count)
+		}
+	})
+
+	t.Run("InvalidWindow", func(t *testing.T) {
+		if _, err := service.GetReferenceAnalytics(spacePath, space.HistogramBucketDay, day(6, 0), day(3, 0)); err == nil {
+			t.Error("Expected an error for from after to")
+		}
+		if _, err := service.GetReferenceAnalytics(spacePath, "hour", day(3, 0), day(6, 0)); err == nil {
+			t.Error("Expected an error for an unknown bucket")
+		}
+	})
+}
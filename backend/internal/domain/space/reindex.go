@@ -0,0 +1,204 @@
+package space
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// Reindex job states
+const (
+	ReindexStateRunning   = "running"
+	ReindexStateCompleted = "completed"
+	ReindexStateFailed    = "failed"
+	ReindexStateCancelled = "cancelled"
+)
+
+// reindexJobRetention is how long a finished reindex job's status stays queryable
+const reindexJobRetention = time.Hour
+
+// ReindexStatus reports the progress of a reindex job
+type ReindexStatus struct {
+	JobID      string       `json:"job_id"`
+	State      string       `json:"state"`
+	Processed  int          `json:"processed"` // Notes processed so far
+	Total      int          `json:"total"`     // Notes linked when the job started
+	Words      int          `json:"words"`     // Words counted in the notes processed
+	Repair     RepairReport `json:"repair"`
+	Error      string       `json:"error,omitempty"`
+	StartedAt  time.Time    `json:"started_at"`
+	FinishedAt *time.Time   `json:"finished_at,omitempty"`
+}
+
+// reindexJob is a reindex running (or run) in the background
+type reindexJob struct {
+	spacePath string
+	cancel    context.CancelFunc
+	status    ReindexStatus
+}
+
+// reindexJobs holds this process's reindex jobs by ID
+type reindexJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*reindexJob
+}
+
+// StartReindex starts recomputing a space's derived data in the background
+// and returns the job ID to poll with GetReindexStatus. The job repairs the
+// denormalized columns (see RecomputeDenormalized), then walks the linked
+// notes refreshing their cached titles, syncing frontmatter when that setting
+// is on and counting words, and finally rewrites the notes manifest when that
+// setting is on. If a reindex is already running on the space its job ID is
+// returned instead of starting another.
+func (s *SpaceDatabaseService) StartReindex(spacePath string) (string, error) {
+	if err := s.checkWritable(spacePath); err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(filepath.Join(spacePath, "space.sqlite")); os.IsNotExist(err) {
+		return "", fmt.Errorf("space database not found")
+	}
+
+	key := filepath.Clean(spacePath)
+
+	s.reindexes.mu.Lock()
+	defer s.reindexes.mu.Unlock()
+
+	now := time.Now()
+	for id, job := range s.reindexes.jobs {
+		if job.status.State == ReindexStateRunning {
+			if job.spacePath == key {
+				return id, nil
+			}
+			continue
+		}
+		if job.status.FinishedAt != nil && now.Sub(*job.status.FinishedAt) > reindexJobRetention {
+			delete(s.reindexes.jobs, id)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &reindexJob{
+		spacePath: key,
+		cancel:    cancel,
+		status: ReindexStatus{
+			JobID:     uuid.New().String(),
+			State:     ReindexStateRunning,
+			Repair:    RepairReport{Corrections: []RepairCorrection{}},
+			StartedAt: now,
+		},
+	}
+	if s.reindexes.jobs == nil {
+		s.reindexes.jobs = make(map[string]*reindexJob)
+	}
+	s.reindexes.jobs[job.status.JobID] = job
+
+	go s.runReindex(ctx, job)
+
+	return job.status.JobID, nil
+}
+
+// GetReindexStatus returns a snapshot of the progress of one of the space's
+// reindex jobs
+func (s *SpaceDatabaseService) GetReindexStatus(spacePath, jobID string) (ReindexStatus, error) {
+	s.reindexes.mu.Lock()
+	defer s.reindexes.mu.Unlock()
+
+	job, err := s.reindexes.lookup(spacePath, jobID)
+	if err != nil {
+		return ReindexStatus{}, err
+	}
+	return job.status, nil
+}
+
+// CancelReindex asks one of the space's running reindex jobs to stop after the
+// note it is processing. Cancelling a finished job is a no-op.
+func (s *SpaceDatabaseService) CancelReindex(spacePath, jobID string) error {
+	s.reindexes.mu.Lock()
+	job, err := s.reindexes.lookup(spacePath, jobID)
+	s.reindexes.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	job.cancel()
+	return nil
+}
+
+// lookup finds a job by ID, treating another space's job as not found so job
+// IDs can't be used across spaces. The caller must hold mu.
+func (r *reindexJobs) lookup(spacePath, jobID string) (*reindexJob, error) {
+	job, ok := r.jobs[jobID]
+	if !ok || job.spacePath != filepath.Clean(spacePath) {
+		return nil, domain.NewNotFoundError("reindex job", jobID)
+	}
+	return job, nil
+}
+
+// runReindex does the work of a reindex job, recording progress as it goes
+func (s *SpaceDatabaseService) runReindex(ctx context.Context, job *reindexJob) {
+	defer job.cancel()
+
+	update := func(fn func(status *ReindexStatus)) {
+		s.reindexes.mu.Lock()
+		fn(&job.status)
+		s.reindexes.mu.Unlock()
+	}
+	finish := func(state string, err error) {
+		update(func(status *ReindexStatus) {
+			now := time.Now()
+			status.State = state
+			status.FinishedAt = &now
+			if err != nil {
+				status.Error = err.Error()
+			}
+		})
+	}
+
+	report, err := s.recomputeDenormalized(job.spacePath)
+	if err != nil {
+		finish(ReindexStateFailed, err)
+		return
+	}
+
+	notes, err := s.GetRelevantNotes(job.spacePath, NoteFilters{})
+	if err != nil {
+		finish(ReindexStateFailed, err)
+		return
+	}
+	update(func(status *ReindexStatus) {
+		status.Repair = report
+		status.Total = len(notes)
+	})
+
+	for _, note := range notes {
+		if ctx.Err() != nil {
+			finish(ReindexStateCancelled, nil)
+			return
+		}
+
+		words := 0
+		if fullPath, err := s.ResolveNoteFile(job.spacePath, note.NotePath); err == nil {
+			if content, err := s.ReadCapture(fullPath); err == nil {
+				words = len(strings.Fields(string(content)))
+			}
+		}
+		s.noteTitle(job.spacePath, note.NotePath)
+		s.syncFrontmatter(job.spacePath, note.CaptureID)
+
+		update(func(status *ReindexStatus) {
+			status.Processed++
+			status.Words += words
+		})
+	}
+
+	s.syncManifest(job.spacePath)
+	finish(ReindexStateCompleted, nil)
+}
@@ -0,0 +1,94 @@
+package space_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestReindex(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	contents := map[string]string{
+		"seeds.md":   "Order tomato seeds",
+		"compost.md": "Turn the compost pile",
+		"harvest.md": "Harvest squash",
+	}
+	for name, content := range contents {
+		captureID, notePath := createNamedCapture(t, parachuteRoot, name, content)
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "", []string{"garden"}); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	jobID, err := service.StartReindex(spacePath)
+	if err != nil {
+		t.Fatalf("Failed to start reindex: %v", err)
+	}
+
+	var status space.ReindexStatus
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, err = service.GetReindexStatus(spacePath, jobID)
+		if err != nil {
+			t.Fatalf("Failed to get reindex status: %v", err)
+		}
+		if status.State != space.ReindexStateRunning || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Run("Completes", func(t *testing.T) {
+		if status.State != space.ReindexStateCompleted {
+			t.Fatalf("Expected completed job, got %+v", status)
+		}
+		if status.Processed != 3 || status.Total != 3 {
+			t.Errorf("Expected 3 of 3 notes processed, got %d of %d", status.Processed, status.Total)
+		}
+		if status.Words != 9 {
+			t.Errorf("Expected 9 words counted, got %d", status.Words)
+		}
+		if status.FinishedAt == nil {
+			t.Error("Expected finish time on completed job")
+		}
+	})
+
+	t.Run("CancelFinishedIsNoOp", func(t *testing.T) {
+		if err := service.CancelReindex(spacePath, jobID); err != nil {
+			t.Fatalf("Failed to cancel: %v", err)
+		}
+		if again, _ := service.GetReindexStatus(spacePath, jobID); again.State != space.ReindexStateCompleted {
+			t.Errorf("Expected job to stay completed, got %q", again.State)
+		}
+	})
+
+	t.Run("UnknownJob", func(t *testing.T) {
+		var notFound *domain.NotFoundError
+		if _, err := service.GetReindexStatus(spacePath, "missing"); !errors.As(err, &notFound) {
+			t.Errorf("Expected not found error, got %v", err)
+		}
+		if err := service.CancelReindex(spacePath, "missing"); !errors.As(err, &notFound) {
+			t.Errorf("Expected not found error, got %v", err)
+		}
+	})
+
+	t.Run("OtherSpaceJob", func(t *testing.T) {
+		var notFound *domain.NotFoundError
+		otherPath := filepath.Join(parachuteRoot, "spaces", "other")
+		if _, err := service.GetReindexStatus(otherPath, jobID); !errors.As(err, &notFound) {
+			t.Errorf("Expected not found error, got %v", err)
+		}
+		if err := service.CancelReindex(otherPath, jobID); !errors.As(err, &notFound) {
+			t.Errorf("Expected not found error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package space
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// Rename changes a space's name and moves its directory to match the new
+// sanitized name. The directory is moved with a single rename so SPACE.md,
+// files/ and space.sqlite move together, and the move is undone if the
+// record can't be saved.
+func (s *Service) Rename(ctx context.Context, id string, newName string) (*Space, error) {
+	if newName == "" {
+		return nil, domain.NewValidationError("name", "space name is required")
+	}
+
+	sanitized := sanitizeName(newName)
+	if sanitized == "" {
+		return nil, domain.NewValidationError("name", "space name contains no valid characters")
+	}
+
+	space, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	oldName, oldPath := space.Name, space.Path
+	newPath := filepath.Join(s.parachuteRoot, "spaces", sanitized)
+
+	// Same directory (e.g. only capitalization changed): just update the name
+	if newPath == oldPath {
+		space.Name = newName
+		if err := s.repo.Update(ctx, space); err != nil {
+			return nil, fmt.Errorf("failed to update space: %w", err)
+		}
+		return space, nil
+	}
+
+	// Check for collisions with other spaces and with anything already on disk
+	if existing, err := s.repo.GetByPath(ctx, newPath); err == nil && existing != nil {
+		return nil, domain.NewConflictError("space", fmt.Sprintf("space already exists with name: %s", newName))
+	}
+	if _, err := os.Lstat(newPath); err == nil {
+		return nil, domain.NewConflictError("space", fmt.Sprintf("path already exists: %s", newPath))
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to check space path: %w", err)
+	}
+
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return nil, fmt.Errorf("failed to move space directory: %w", err)
+	}
+
+	space.Name = newName
+	space.Path = newPath
+	if err := s.repo.Update(ctx, space); err != nil {
+		space.Name, space.Path = oldName, oldPath
+		if rollbackErr := os.Rename(newPath, oldPath); rollbackErr != nil {
+			return nil, fmt.Errorf("failed to update space: %w (rollback of directory move also failed: %v)", err, rollbackErr)
+		}
+		return nil, fmt.Errorf("failed to update space: %w", err)
+	}
+
+	return space, nil
+}
@@ -0,0 +1,132 @@
+package space_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+	sqliteStorage "github.com/unforced/parachute-backend/internal/storage/sqlite"
+)
+
+// failingUpdateRepo wraps a repository so Update always fails
+type failingUpdateRepo struct {
+	space.Repository
+}
+
+func (r failingUpdateRepo) Update(ctx context.Context, s *space.Space) error {
+	return errors.New("update failed")
+}
+
+func TestRenameSpace(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	db, err := sqliteStorage.NewDatabase(filepath.Join(parachuteRoot, "parachute.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	repo := sqliteStorage.NewSpaceRepository(db.DB)
+	service := space.NewService(repo, parachuteRoot)
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+
+	original, err := service.Create(ctx, "default", space.CreateSpaceParams{Name: "Garden Plans"})
+	if err != nil {
+		t.Fatalf("Failed to create space: %v", err)
+	}
+	if err := dbService.InitializeSpaceDatabase(original.ID, original.Path); err != nil {
+		t.Fatalf("Failed to initialize space database: %v", err)
+	}
+	captureID, notePath := createMockCapture(t, parachuteRoot, "Tomatoes")
+	if err := dbService.LinkNote(original.ID, original.Path, captureID, notePath, "Planting", []string{"garden"}); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	oldPath := original.Path
+
+	t.Run("MovesDirectoryAndRecord", func(t *testing.T) {
+		renamed, err := service.Rename(ctx, original.ID, "Orchard Plans")
+		if err != nil {
+			t.Fatalf("Failed to rename space: %v", err)
+		}
+
+		wantPath := filepath.Join(parachuteRoot, "spaces", "orchard-plans")
+		if renamed.Path != wantPath || renamed.Name != "Orchard Plans" {
+			t.Errorf("Expected %q at %s, got %q at %s", "Orchard Plans", wantPath, renamed.Name, renamed.Path)
+		}
+
+		stored, err := service.GetByID(ctx, original.ID)
+		if err != nil {
+			t.Fatalf("Failed to get space: %v", err)
+		}
+		if stored.Path != wantPath || stored.Name != "Orchard Plans" {
+			t.Errorf("Expected stored record to be renamed, got %q at %s", stored.Name, stored.Path)
+		}
+
+		if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+			t.Errorf("Expected old directory to be gone, got %v", err)
+		}
+		for _, name := range []string{"SPACE.md", "files", "space.sqlite"} {
+			if _, err := os.Stat(filepath.Join(wantPath, name)); err != nil {
+				t.Errorf("Expected %s in renamed space: %v", name, err)
+			}
+		}
+
+		note, err := dbService.GetNoteByID(renamed.Path, captureID)
+		if err != nil {
+			t.Fatalf("Expected linked note to remain accessible: %v", err)
+		}
+		if note.Context != "Planting" {
+			t.Errorf("Expected note context to survive rename, got %q", note.Context)
+		}
+	})
+
+	t.Run("RejectsCollision", func(t *testing.T) {
+		other, err := service.Create(ctx, "default", space.CreateSpaceParams{Name: "Compost"})
+		if err != nil {
+			t.Fatalf("Failed to create space: %v", err)
+		}
+
+		_, err = service.Rename(ctx, other.ID, "Orchard Plans")
+		var conflictErr *domain.ConflictError
+		if !errors.As(err, &conflictErr) {
+			t.Fatalf("Expected ConflictError, got %v", err)
+		}
+		if _, err := os.Stat(other.Path); err != nil {
+			t.Errorf("Expected colliding space to stay in place: %v", err)
+		}
+	})
+
+	t.Run("RollsBackOnFailedUpdate", func(t *testing.T) {
+		failing := space.NewService(failingUpdateRepo{Repository: repo}, parachuteRoot)
+
+		current, err := service.GetByID(ctx, original.ID)
+		if err != nil {
+			t.Fatalf("Failed to get space: %v", err)
+		}
+
+		if _, err := failing.Rename(ctx, original.ID, "Vineyard"); err == nil {
+			t.Fatal("Expected rename to fail")
+		}
+
+		if _, err := os.Stat(filepath.Join(current.Path, "space.sqlite")); err != nil {
+			t.Errorf("Expected directory to be moved back: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(parachuteRoot, "spaces", "vineyard")); !os.IsNotExist(err) {
+			t.Errorf("Expected no directory at the new path, got %v", err)
+		}
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		var validationErr *domain.ValidationError
+		if _, err := service.Rename(ctx, original.ID, "!!!"); !errors.As(err, &validationErr) {
+			t.Errorf("Expected ValidationError, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,210 @@
+package space
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// maxSavedSearchNameLength caps saved search names, in runes
+const maxSavedSearchNameLength = 100
+
+// SavedSearch is a named set of note filters stored with a space
+type SavedSearch struct {
+	Name      string      `json:"name"`
+	Filters   NoteFilters `json:"filters"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// validateSavedSearchName trims a saved search name and checks its length
+func validateSavedSearchName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", domain.NewValidationError("name", "is required")
+	}
+	if utf8.RuneCountInString(name) > maxSavedSearchNameLength {
+		return "", domain.NewValidationError("name", fmt.Sprintf("must be at most %d characters", maxSavedSearchNameLength))
+	}
+	return name, nil
+}
+
+// SaveSearch stores filters under name, replacing any saved search with the
+// same name
+func (s *SpaceDatabaseService) SaveSearch(spacePath, name string, filters NoteFilters) (*SavedSearch, error) {
+	if err := s.checkWritable(spacePath); err != nil {
+		return nil, err
+	}
+
+	name, err := validateSavedSearchName(name)
+	if err != nil {
+		return nil, err
+	}
+	if filters.Status != "" {
+		if err := validateNoteStatus(filters.Status); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateFilterTags(filters.Tags); err != nil {
+		return nil, err
+	}
+
+	filtersJSON, err := json.Marshal(filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal filters: %w", err)
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	now := time.Now().Unix()
+	_, err = db.Exec(`
+		INSERT INTO saved_searches (name, filters, created_at, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET filters = excluded.filters, updated_at = excluded.updated_at
+	`, name, string(filtersJSON), now, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save search: %w", err)
+	}
+
+	return s.GetSavedSearch(spacePath, name)
+}
+
+// GetSavedSearch returns the saved search with the given name
+func (s *SpaceDatabaseService) GetSavedSearch(spacePath, name string) (*SavedSearch, error) {
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return nil, domain.NewNotFoundError("saved search", name)
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	row := db.QueryRow(`
+		SELECT name, filters, created_at, updated_at
+		FROM saved_searches
+		WHERE name = ?
+	`, strings.TrimSpace(name))
+
+	search, err := scanSavedSearch(row)
+	if err == sql.ErrNoRows {
+		return nil, domain.NewNotFoundError("saved search", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved search: %w", err)
+	}
+
+	return search, nil
+}
+
+// ListSavedSearches returns a space's saved searches ordered by name
+func (s *SpaceDatabaseService) ListSavedSearches(spacePath string) ([]SavedSearch, error) {
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return []SavedSearch{}, nil
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT name, filters, created_at, updated_at
+		FROM saved_searches
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	searches := []SavedSearch{}
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan saved search: %w", err)
+		}
+		searches = append(searches, *search)
+	}
+
+	return searches, nil
+}
+
+// DeleteSavedSearch removes a saved search
+func (s *SpaceDatabaseService) DeleteSavedSearch(spacePath, name string) error {
+	if err := s.checkWritable(spacePath); err != nil {
+		return err
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return domain.NewNotFoundError("saved search", name)
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	result, err := db.Exec("DELETE FROM saved_searches WHERE name = ?", strings.TrimSpace(name))
+	if err != nil {
+		return fmt.Errorf("failed to delete saved search: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return domain.NewNotFoundError("saved search", name)
+	}
+
+	return nil
+}
+
+// RunSavedSearch returns the notes matching a saved search's filters
+func (s *SpaceDatabaseService) RunSavedSearch(spacePath, name string) ([]RelevantNote, error) {
+	search, err := s.GetSavedSearch(spacePath, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetRelevantNotes(spacePath, search.Filters)
+}
+
+// scanSavedSearch reads a saved_searches row
+func scanSavedSearch(row rowScanner) (*SavedSearch, error) {
+	var search SavedSearch
+	var filtersJSON string
+	var createdAt, updatedAt int64
+
+	if err := row.Scan(&search.Name, &filtersJSON, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(filtersJSON), &search.Filters); err != nil {
+		return nil, fmt.Errorf("invalid filters for saved search %s: %w", search.Name, err)
+	}
+	search.CreatedAt = time.Unix(createdAt, 0)
+	search.UpdatedAt = time.Unix(updatedAt, 0)
+
+	return &search, nil
+}
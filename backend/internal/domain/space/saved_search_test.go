@@ -0,0 +1,143 @@
+package space_test
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestSavedSearches(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	notes := []struct {
+		file string
+		tags []string
+		age  time.Duration
+	}{
+		{"old-garden.md", []string{"garden"}, 72 * time.Hour},
+		{"new-garden.md", []string{"garden", "soil"}, 2 * time.Hour},
+		{"new-kitchen.md", []string{"kitchen"}, time.Hour},
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to open space database: %v", err)
+	}
+	for _, n := range notes {
+		captureID, notePath := createNamedCapture(t, parachuteRoot, n.file, n.file)
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "", n.tags); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		linkedAt := time.Now().Add(-n.age).Unix()
+		if _, err := db.Exec("UPDATE relevant_notes SET linked_at = ? WHERE capture_id = ?", linkedAt, captureID); err != nil {
+			t.Fatalf("Failed to set linked_at: %v", err)
+		}
+	}
+	db.Close()
+
+	since := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	filters := space.NoteFilters{Tags: []string{"garden"}, StartDate: &since}
+
+	t.Run("RunMatchesDirectQuery", func(t *testing.T) {
+		if _, err := service.SaveSearch(spacePath, "Recent garden", filters); err != nil {
+			t.Fatalf("Failed to save search: %v", err)
+		}
+
+		got, err := service.RunSavedSearch(spacePath, "Recent garden")
+		if err != nil {
+			t.Fatalf("Failed to run saved search: %v", err)
+		}
+		want, err := service.GetRelevantNotes(spacePath, filters)
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+
+		if len(got) != 1 || len(want) != 1 || got[0].CaptureID != want[0].CaptureID {
+			t.Fatalf("Expected saved search to match the direct query, got %+v want %+v", got, want)
+		}
+		if got[0].NotePath != filepath.Join("captures", "new-garden.md") {
+			t.Errorf("Expected new-garden.md, got %s", got[0].NotePath)
+		}
+	})
+
+	t.Run("FiltersRoundTrip", func(t *testing.T) {
+		search, err := service.GetSavedSearch(spacePath, "Recent garden")
+		if err != nil {
+			t.Fatalf("Failed to get saved search: %v", err)
+		}
+		if len(search.Filters.Tags) != 1 || search.Filters.StartDate == nil || !search.Filters.StartDate.Equal(since) {
+			t.Errorf("Expected stored filters to round-trip, got %+v", search.Filters)
+		}
+	})
+
+	t.Run("SaveReplacesExisting", func(t *testing.T) {
+		if _, err := service.SaveSearch(spacePath, "Recent garden", space.NoteFilters{Tags: []string{"kitchen"}}); err != nil {
+			t.Fatalf("Failed to save search: %v", err)
+		}
+		if _, err := service.SaveSearch(spacePath, "All", space.NoteFilters{}); err != nil {
+			t.Fatalf("Failed to save search: %v", err)
+		}
+
+		searches, err := service.ListSavedSearches(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to list saved searches: %v", err)
+		}
+		if len(searches) != 2 || searches[0].Name != "All" || searches[1].Name != "Recent garden" {
+			t.Fatalf("Expected two saved searches ordered by name, got %+v", searches)
+		}
+
+		got, _ := service.RunSavedSearch(spacePath, "Recent garden")
+		if len(got) != 1 || got[0].NotePath != filepath.Join("captures", "new-kitchen.md") {
+			t.Errorf("Expected the replaced filters to apply, got %+v", got)
+		}
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		var validationErr *domain.ValidationError
+		if _, err := service.SaveSearch(spacePath, "  ", space.NoteFilters{}); !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error for an empty name, got %v", err)
+		}
+		if _, err := service.SaveSearch(spacePath, "Bad", space.NoteFilters{Status: "someday"}); !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error for an unknown status, got %v", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if err := service.DeleteSavedSearch(spacePath, "All"); err != nil {
+			t.Fatalf("Failed to delete saved search: %v", err)
+		}
+
+		var notFoundErr *domain.NotFoundError
+		if _, err := service.RunSavedSearch(spacePath, "All"); !errors.As(err, &notFoundErr) {
+			t.Errorf("Expected not found after delete, got %v", err)
+		}
+		if err := service.DeleteSavedSearch(spacePath, "All"); !errors.As(err, &notFoundErr) {
+			t.Errorf("Expected not found deleting twice, got %v", err)
+		}
+	})
+
+	t.Run("DeleteWithoutDatabase", func(t *testing.T) {
+		emptyPath := filepath.Join(parachuteRoot, "spaces", "no-database")
+		if err := os.MkdirAll(emptyPath, 0755); err != nil {
+			t.Fatalf("Failed to create space directory: %v", err)
+		}
+
+		var notFoundErr *domain.NotFoundError
+		if err := service.DeleteSavedSearch(emptyPath, "All"); !errors.As(err, &notFoundErr) {
+			t.Errorf("Expected not found, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(emptyPath, "space.sqlite")); !os.IsNotExist(err) {
+			t.Error("Expected no database to be created")
+		}
+	})
+}
@@ -17,6 +17,8 @@ import (
 type Service struct {
 	repo          Repository
 	parachuteRoot string
+	limits        SpaceLimits
+	dbService     *SpaceDatabaseService // optional, used to read note activity
 }
 
 // NewService creates a new space service
@@ -24,6 +26,7 @@ func NewService(repo Repository, parachuteRoot string) *Service {
 	return &Service{
 		repo:          repo,
 		parachuteRoot: parachuteRoot,
+		limits:        DefaultSpaceLimits(),
 	}
 }
 
@@ -73,7 +76,23 @@ func (s *Service) Create(ctx context.Context, userID string, params CreateSpaceP
 		return nil, domain.NewConflictError("space", fmt.Sprintf("space already exists with name: %s", params.Name))
 	}
 
+	// Enforce the per-user space limit
+	if err := s.checkSpaceLimit(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if params.Template != nil {
+		if s.dbService == nil {
+			return nil, fmt.Errorf("space database service not configured")
+		}
+		if err := s.dbService.validateTemplate(params.Template); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create the directory structure
+	_, statErr := os.Stat(spacePath)
+	createdDir := os.IsNotExist(statErr)
 	if err := os.MkdirAll(spacePath, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create space directory: %w", err)
 	}
@@ -123,6 +142,16 @@ See the files/ directory for uploaded documents and resources.
 		UpdatedAt: now,
 	}
 
+	// Applied before the record exists, so a failure leaves no half-made space
+	if params.Template != nil {
+		if err := s.dbService.applyTemplate(space.ID, space.Path, params.Template); err != nil {
+			if createdDir {
+				os.RemoveAll(spacePath)
+			}
+			return nil, fmt.Errorf("failed to apply template: %w", err)
+		}
+	}
+
 	if err := s.repo.Create(ctx, space); err != nil {
 		return nil, fmt.Errorf("failed to create space: %w", err)
 	}
@@ -162,6 +191,12 @@ func (s *Service) Update(ctx context.Context, id string, params UpdateSpaceParam
 	if params.Color != "" {
 		space.Color = params.Color
 	}
+	if params.ReadOnly != nil {
+		space.ReadOnly = *params.ReadOnly
+	}
+	if params.Archived != nil {
+		space.Archived = *params.Archived
+	}
 
 	// Save
 	if err := s.repo.Update(ctx, space); err != nil {
@@ -189,22 +224,28 @@ func (s *Service) GetMCPConfigPath(space *Space) string {
 // ReadSpaceMD reads the SPACE.md file for a space
 // Falls back to agents.md or CLAUDE.md for backward compatibility
 func (s *Service) ReadSpaceMD(space *Space) (string, error) {
+	return readSpaceContextFile(space.Path)
+}
+
+// readSpaceContextFile reads the context file from a space directory,
+// trying SPACE.md, then agents.md, then CLAUDE.md (see spaceContextFiles)
+func readSpaceContextFile(spacePath string) (string, error) {
 	// Try SPACE.md first (current standard)
-	spaceMDPath := filepath.Join(space.Path, "SPACE.md")
+	spaceMDPath := filepath.Join(spacePath, "SPACE.md")
 	data, err := os.ReadFile(spaceMDPath)
 	if err == nil {
 		return string(data), nil
 	}
 
 	// Fall back to agents.md (previous iteration)
-	agentsMDPath := filepath.Join(space.Path, "agents.md")
+	agentsMDPath := filepath.Join(spacePath, "agents.md")
 	data, err = os.ReadFile(agentsMDPath)
 	if err == nil {
 		return string(data), nil
 	}
 
 	// Fall back to CLAUDE.md (legacy)
-	claudeMDPath := filepath.Join(space.Path, "CLAUDE.md")
+	claudeMDPath := filepath.Join(spacePath, "CLAUDE.md")
 	data, err = os.ReadFile(claudeMDPath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -0,0 +1,369 @@
+package space
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// Per-space setting keys
+const (
+	// SettingCapturesDir is the vault-relative directory holding the space's captures
+	SettingCapturesDir = "captures_dir"
+
+	// SettingContextAudit records each rendered SPACE.md in the context history when "true"
+	SettingContextAudit = "context_audit"
+
+	// SettingRecentNotesOrder selects how {{recent_notes}} orders notes
+	SettingRecentNotesOrder = "recent_notes_order"
+
+	// SettingFrontmatterSync mirrors each link's context and tags into the
+	// capture file's frontmatter when "true" (see SyncLinkToFrontmatter)
+	SettingFrontmatterSync = "frontmatter_sync"
+
+	// SettingNotesManifest keeps a plain-text notes.json of the space's links
+	// in the space directory when "true" (see ExportManifest)
+	SettingNotesManifest = "notes_manifest"
+
+	// SettingTagDisplayOrder selects the order of each note's tags when notes
+	// are returned; the stored order is never changed
+	SettingTagDisplayOrder = "tag_display_order"
+
+	// SettingInjectedNoteDelimiter is the line introducing each note in
+	// {{injected_notes}}, with placeholders (see injectedNotePlaceholders)
+	SettingInjectedNoteDelimiter = "injected_note_delimiter"
+
+	// SettingPinnedContext is text RenderSpaceMD always adds to the rendered
+	// SPACE.md, kept out of the file so edits to it cannot lose it
+	SettingPinnedContext = "pinned_context"
+
+	// SettingPinnedContextPosition selects whether the pinned context goes
+	// before or after the rendered SPACE.md
+	SettingPinnedContextPosition = "pinned_context_position"
+)
+
+// Values for SettingRecentNotesOrder
+const (
+	// RecentNotesOrderReferenced orders by last reference, falling back to link
+	// time for never-referenced notes, so a recently referenced note ranks above
+	// a more recently linked one. This is the default.
+	RecentNotesOrderReferenced = "referenced"
+
+	// RecentNotesOrderLinked orders strictly by link time, ignoring references
+	RecentNotesOrderLinked = "linked"
+)
+
+// Values for SettingTagDisplayOrder
+const (
+	// TagDisplayOrderInsertion returns tags in the order they were added. This is the default.
+	TagDisplayOrderInsertion = "insertion"
+
+	// TagDisplayOrderAlphabetical returns tags sorted alphabetically
+	TagDisplayOrderAlphabetical = "alphabetical"
+)
+
+// Values for SettingPinnedContextPosition
+const (
+	// PinnedContextTop puts the pinned context before SPACE.md. This is the default.
+	PinnedContextTop = "top"
+
+	// PinnedContextBottom puts the pinned context after SPACE.md
+	PinnedContextBottom = "bottom"
+)
+
+// DefaultCapturesDir is the shared captures directory used when a space has no override
+const DefaultCapturesDir = "captures"
+
+// settingSpec describes a per-space setting: its default, how to validate
+// (and canonicalize) a new value, and whether changing it changes the
+// rendered context (and so bumps the context version)
+type settingSpec struct {
+	Default        string
+	Validate       func(s *SpaceDatabaseService, value string) (string, error)
+	AffectsContext bool
+}
+
+// spaceSettings lists the settings a space accepts. Values are stored in
+// space_metadata under the setting key; a missing row means the default.
+var spaceSettings = map[string]settingSpec{
+	SettingCapturesDir: {
+		Default: DefaultCapturesDir,
+		Validate: func(s *SpaceDatabaseService, value string) (string, error) {
+			return s.vaultRelative(value, SettingCapturesDir)
+		},
+		AffectsContext: true,
+	},
+	SettingContextAudit: {
+		Default:  "false",
+		Validate: validateBoolSetting(SettingContextAudit),
+	},
+	SettingRecentNotesOrder: {
+		Default:        RecentNotesOrderReferenced,
+		Validate:       validateEnumSetting(SettingRecentNotesOrder, RecentNotesOrderReferenced, RecentNotesOrderLinked),
+		AffectsContext: true,
+	},
+	SettingFrontmatterSync: {
+		Default:  "false",
+		Validate: validateBoolSetting(SettingFrontmatterSync),
+	},
+	SettingNotesManifest: {
+		Default:  "false",
+		Validate: validateBoolSetting(SettingNotesManifest),
+	},
+	SettingTagDisplayOrder: {
+		Default:        TagDisplayOrderInsertion,
+		Validate:       validateEnumSetting(SettingTagDisplayOrder, TagDisplayOrderInsertion, TagDisplayOrderAlphabetical),
+		AffectsContext: true,
+	},
+	SettingInjectedNoteDelimiter: {
+		Default: DefaultInjectedNoteDelimiter,
+		Validate: func(_ *SpaceDatabaseService, value string) (string, error) {
+			return value, validateNoteDelimiter(value)
+		},
+		AffectsContext: true,
+	},
+	SettingPinnedContext: {
+		Default: "",
+		Validate: func(_ *SpaceDatabaseService, value string) (string, error) {
+			return validatePinnedContext(value)
+		},
+		AffectsContext: true,
+	},
+	SettingPinnedContextPosition: {
+		Default:        PinnedContextTop,
+		Validate:       validateEnumSetting(SettingPinnedContextPosition, PinnedContextTop, PinnedContextBottom),
+		AffectsContext: true,
+	},
+}
+
+// validateBoolSetting returns a validator accepting boolean values, stored as "true"/"false"
+func validateBoolSetting(key string) func(*SpaceDatabaseService, string) (string, error) {
+	return func(_ *SpaceDatabaseService, value string) (string, error) {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", domain.NewValidationError(key, "must be true or false")
+		}
+		return strconv.FormatBool(b), nil
+	}
+}
+
+// validateEnumSetting returns a validator accepting only the given values
+func validateEnumSetting(key string, allowed ...string) func(*SpaceDatabaseService, string) (string, error) {
+	return func(_ *SpaceDatabaseService, value string) (string, error) {
+		for _, a := range allowed {
+			if value == a {
+				return value, nil
+			}
+		}
+		return "", domain.NewValidationError(key, fmt.Sprintf("must be one of: %s", strings.Join(allowed, ", ")))
+	}
+}
+
+// SettingKeys returns the names of all per-space settings in sorted order
+func SettingKeys() []string {
+	keys := make([]string, 0, len(spaceSettings))
+	for key := range spaceSettings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// lookupSetting returns the spec for key or a validation error if it is unknown
+func lookupSetting(key string) (settingSpec, error) {
+	spec, ok := spaceSettings[key]
+	if !ok {
+		return settingSpec{}, domain.NewValidationError("key", fmt.Sprintf("unknown setting: %s", key))
+	}
+	return spec, nil
+}
+
+// GetSetting returns a space setting, or its default if it has not been set
+func (s *SpaceDatabaseService) GetSetting(spacePath, key string) (string, error) {
+	spec, err := lookupSetting(key)
+	if err != nil {
+		return "", err
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return spec.Default, nil
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	var value string
+	err = db.QueryRow("SELECT value FROM space_metadata WHERE key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return spec.Default, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get setting: %w", err)
+	}
+
+	return value, nil
+}
+
+// GetSettings returns every space setting with defaults filled in
+func (s *SpaceDatabaseService) GetSettings(spacePath string) (map[string]string, error) {
+	settings := make(map[string]string, len(spaceSettings))
+	for _, key := range SettingKeys() {
+		value, err := s.GetSetting(spacePath, key)
+		if err != nil {
+			return nil, err
+		}
+		settings[key] = value
+	}
+	return settings, nil
+}
+
+// SetSetting validates and stores a space setting. An empty value resets the
+// setting to its default.
+func (s *SpaceDatabaseService) SetSetting(spacePath, key, value string) error {
+	return s.ImportSettings(spacePath, map[string]string{key: value})
+}
+
+// ExportSettings returns every setting of a space, defaults included, for
+// copying its configuration to another space with ImportSettings. Reserved
+// space_metadata keys (space_id, schema_version, ...) are never included.
+func (s *SpaceDatabaseService) ExportSettings(spacePath string) (map[string]string, error) {
+	return s.GetSettings(spacePath)
+}
+
+// ImportSettings applies a set of settings to a space in one transaction.
+// Every value is validated before any is written, so an unknown key (including
+// a reserved space_metadata key) or invalid value leaves the space unchanged.
+// Settings missing from the map keep their current values.
+func (s *SpaceDatabaseService) ImportSettings(spacePath string, settings map[string]string) error {
+	values := make(map[string]string, len(settings))
+	for key, value := range settings {
+		canonical, err := s.validateSetting(key, value)
+		if err != nil {
+			return err
+		}
+		values[key] = canonical
+	}
+
+	if err := s.checkWritable(spacePath); err != nil {
+		return err
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	affectsContext := false
+	for _, key := range SettingKeys() {
+		value, ok := values[key]
+		if !ok {
+			continue
+		}
+
+		if value == "" {
+			_, err = tx.Exec("DELETE FROM space_metadata WHERE key = ?", key)
+		} else {
+			_, err = tx.Exec(`
+				INSERT INTO space_metadata (key, value) VALUES (?, ?)
+				ON CONFLICT(key) DO UPDATE SET value = excluded.value
+			`, key, value)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to set setting: %w", err)
+		}
+
+		if spaceSettings[key].AffectsContext {
+			affectsContext = true
+		}
+	}
+
+	if affectsContext {
+		if err := bumpContextVersion(tx); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// Turning the manifest on writes it straight away rather than at the next change
+	if _, ok := values[SettingNotesManifest]; ok {
+		s.syncManifest(spacePath)
+	}
+
+	return nil
+}
+
+// validateSetting checks that key is a known setting and returns value in
+// canonical form. An empty value (reset to default) is always valid.
+func (s *SpaceDatabaseService) validateSetting(key, value string) (string, error) {
+	spec, err := lookupSetting(key)
+	if err != nil {
+		return "", err
+	}
+
+	if value != "" && spec.Validate != nil {
+		return spec.Validate(s, value)
+	}
+	return value, nil
+}
+
+// CapturesDir returns the vault-relative captures directory for a space
+func (s *SpaceDatabaseService) CapturesDir(spacePath string) (string, error) {
+	return s.GetSetting(spacePath, SettingCapturesDir)
+}
+
+// boolSetting returns a boolean setting, treating lookup failures as false
+func (s *SpaceDatabaseService) boolSetting(spacePath, key string) bool {
+	value, err := s.GetSetting(spacePath, key)
+	if err != nil {
+		return false
+	}
+	b, _ := strconv.ParseBool(value)
+	return b
+}
+
+// recentNotesOrder returns the space's recent_notes_order, treating lookup
+// failures as the default
+func (s *SpaceDatabaseService) recentNotesOrder(spacePath string) string {
+	value, err := s.GetSetting(spacePath, SettingRecentNotesOrder)
+	if err != nil {
+		return RecentNotesOrderReferenced
+	}
+	return value
+}
+
+// applyTagDisplayOrder orders each note's tags per the space's
+// tag_display_order, treating lookup failures as the default
+func (s *SpaceDatabaseService) applyTagDisplayOrder(spacePath string, notes []RelevantNote) {
+	if len(notes) == 0 {
+		return
+	}
+	value, err := s.GetSetting(spacePath, SettingTagDisplayOrder)
+	if err != nil || value != TagDisplayOrderAlphabetical {
+		return
+	}
+	for i := range notes {
+		sort.Strings(notes[i].Tags)
+	}
+}
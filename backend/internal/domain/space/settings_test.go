@@ -0,0 +1,323 @@
+package space_test
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestSpaceSettings(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	_, spacePath := setupTestSpace(t, parachuteRoot)
+
+	t.Run("DefaultsWhenUnset", func(t *testing.T) {
+		settings, err := service.GetSettings(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to get settings: %v", err)
+		}
+		if settings[space.SettingCapturesDir] != space.DefaultCapturesDir {
+			t.Errorf("Expected default captures_dir, got %q", settings[space.SettingCapturesDir])
+		}
+	})
+
+	t.Run("SetAndReset", func(t *testing.T) {
+		if err := service.SetSetting(spacePath, space.SettingCapturesDir, "research/./captures/"); err != nil {
+			t.Fatalf("Failed to set setting: %v", err)
+		}
+		got, _ := service.CapturesDir(spacePath)
+		if got != "research/captures" {
+			t.Errorf("Expected canonical research/captures, got %q", got)
+		}
+
+		if err := service.SetSetting(spacePath, space.SettingCapturesDir, ""); err != nil {
+			t.Fatalf("Failed to reset setting: %v", err)
+		}
+		got, _ = service.CapturesDir(spacePath)
+		if got != space.DefaultCapturesDir {
+			t.Errorf("Expected reset to default, got %q", got)
+		}
+	})
+
+	t.Run("RejectsInvalid", func(t *testing.T) {
+		if err := service.SetSetting(spacePath, space.SettingCapturesDir, "../outside"); err == nil {
+			t.Error("Expected captures_dir outside the vault to be rejected")
+		}
+		if err := service.SetSetting(spacePath, "no_such_setting", "x"); err == nil {
+			t.Error("Expected unknown setting to be rejected")
+		}
+	})
+
+	t.Run("BumpsContextVersion", func(t *testing.T) {
+		before, _ := service.GetContextVersion(spacePath)
+		if err := service.SetSetting(spacePath, space.SettingPinnedContext, "Always water at dawn."); err != nil {
+			t.Fatalf("Failed to set pinned context: %v", err)
+		}
+		pinned, _ := service.GetContextVersion(spacePath)
+		if pinned != before+1 {
+			t.Errorf("Expected pinned_context to bump the version to %d, got %d", before+1, pinned)
+		}
+
+		if err := service.SetSetting(spacePath, space.SettingContextAudit, "true"); err != nil {
+			t.Fatalf("Failed to set context_audit: %v", err)
+		}
+		if got, _ := service.GetContextVersion(spacePath); got != pinned {
+			t.Errorf("Expected context_audit to leave the version at %d, got %d", pinned, got)
+		}
+
+		err := service.ImportSettings(spacePath, map[string]string{
+			space.SettingRecentNotesOrder: space.RecentNotesOrderLinked,
+			space.SettingTagDisplayOrder:  space.TagDisplayOrderAlphabetical,
+		})
+		if err != nil {
+			t.Fatalf("Failed to import settings: %v", err)
+		}
+		if got, _ := service.GetContextVersion(spacePath); got != pinned+1 {
+			t.Errorf("Expected one bump for the whole import (%d), got %d", pinned+1, got)
+		}
+	})
+}
+
+func TestCapturesDirOverride(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(service)
+	overrideID, overridePath := setupTestSpace(t, parachuteRoot)
+	defaultID, defaultPath := setupTestSpace(t, parachuteRoot)
+
+	if err := service.SetSetting(overridePath, space.SettingCapturesDir, "research/captures"); err != nil {
+		t.Fatalf("Failed to set captures_dir: %v", err)
+	}
+
+	// Same filename in both directories with different content
+	filename := "2024-07-01_09-00-00.md"
+	createNamedCapture(t, parachuteRoot, filename, "Shared capture")
+	researchDir := filepath.Join(parachuteRoot, "research", "captures")
+	if err := os.MkdirAll(researchDir, 0755); err != nil {
+		t.Fatalf("Failed to create research dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(researchDir, filename), []byte("Research capture"), 0644); err != nil {
+		t.Fatalf("Failed to write research capture: %v", err)
+	}
+
+	readNote := func(t *testing.T, spacePath, captureID string) string {
+		note, err := service.GetNoteByID(spacePath, captureID)
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		fullPath, err := service.ResolveNoteFile(spacePath, note.NotePath)
+		if err != nil {
+			t.Fatalf("Failed to resolve note file: %v", err)
+		}
+		content, err := os.ReadFile(fullPath)
+		if err != nil {
+			t.Fatalf("Failed to read note: %v", err)
+		}
+		return string(content)
+	}
+
+	t.Run("BareFilenameLinksIntoCapturesDir", func(t *testing.T) {
+		if err := service.LinkNote(overrideID, overridePath, "override-bare", filename, "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		note, _ := service.GetNoteByID(overridePath, "override-bare")
+		if note.NotePath != "research/captures/"+filename {
+			t.Errorf("Expected note in research/captures, got %s", note.NotePath)
+		}
+		if got := readNote(t, overridePath, "override-bare"); got != "Research capture" {
+			t.Errorf("Expected research capture content, got %q", got)
+		}
+	})
+
+	t.Run("SharedPathFallsBackToCapturesDir", func(t *testing.T) {
+		// Only the research copy exists for this one
+		researchOnly := "2024-07-02_09-00-00.md"
+		os.WriteFile(filepath.Join(researchDir, researchOnly), []byte("Only in research"), 0644)
+
+		if err := service.LinkNote(overrideID, overridePath, "override-shared", "captures/"+researchOnly, "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		if got := readNote(t, overridePath, "override-shared"); got != "Only in research" {
+			t.Errorf("Expected fallback to captures_dir, got %q", got)
+		}
+
+		injected, err := contextService.BuildInjectedContext(overridePath)
+		if err != nil {
+			t.Fatalf("Failed to build injected context: %v", err)
+		}
+		if !strings.Contains(injected, "Only in research") {
+			t.Errorf("Expected injected notes to read from captures_dir, got:\n%s", injected)
+		}
+	})
+
+	t.Run("DefaultSpaceUnaffected", func(t *testing.T) {
+		if err := service.LinkNote(defaultID, defaultPath, "default-bare", filename, "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		note, _ := service.GetNoteByID(defaultPath, "default-bare")
+		if note.NotePath != "captures/"+filename {
+			t.Errorf("Expected note in captures/, got %s", note.NotePath)
+		}
+		if got := readNote(t, defaultPath, "default-bare"); got != "Shared capture" {
+			t.Errorf("Expected shared capture content, got %q", got)
+		}
+	})
+}
+
+func TestTagDisplayOrder(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	if err := service.LinkNote(spaceID, spacePath, "note-1", "captures/note-1.md", "", []string{"zebra", "apple", "mango"}); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	// tagsFromEveryGetter returns the note's tags as seen by each lookup
+	tagsFromEveryGetter := func(t *testing.T) map[string][]string {
+		note, err := service.GetNoteByID(spacePath, "note-1")
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		notes, err := service.GetRelevantNotes(spacePath, space.NoteFilters{})
+		if err != nil || len(notes) != 1 {
+			t.Fatalf("Failed to list notes: %v", err)
+		}
+		byIDs, err := service.GetNotesByIDs(spacePath, []string{"note-1"})
+		if err != nil || len(byIDs) != 1 {
+			t.Fatalf("Failed to get notes by IDs: %v", err)
+		}
+		return map[string][]string{
+			"GetNoteByID":      note.Tags,
+			"GetRelevantNotes": notes[0].Tags,
+			"GetNotesByIDs":    byIDs[0].Tags,
+		}
+	}
+
+	t.Run("InsertionByDefault", func(t *testing.T) {
+		for getter, tags := range tagsFromEveryGetter(t) {
+			if strings.Join(tags, ",") != "zebra,apple,mango" {
+				t.Errorf("%s: expected insertion order, got %v", getter, tags)
+			}
+		}
+	})
+
+	t.Run("Alphabetical", func(t *testing.T) {
+		if err := service.SetSetting(spacePath, space.SettingTagDisplayOrder, space.TagDisplayOrderAlphabetical); err != nil {
+			t.Fatalf("Failed to set tag order: %v", err)
+		}
+		for getter, tags := range tagsFromEveryGetter(t) {
+			if strings.Join(tags, ",") != "apple,mango,zebra" {
+				t.Errorf("%s: expected alphabetical order, got %v", getter, tags)
+			}
+		}
+	})
+
+	t.Run("StoredOrderUnchanged", func(t *testing.T) {
+		if err := service.SetSetting(spacePath, space.SettingTagDisplayOrder, ""); err != nil {
+			t.Fatalf("Failed to reset tag order: %v", err)
+		}
+		for getter, tags := range tagsFromEveryGetter(t) {
+			if strings.Join(tags, ",") != "zebra,apple,mango" {
+				t.Errorf("%s: expected original order after reset, got %v", getter, tags)
+			}
+		}
+	})
+
+	t.Run("RejectsUnknownOrder", func(t *testing.T) {
+		if err := service.SetSetting(spacePath, space.SettingTagDisplayOrder, "random"); err == nil {
+			t.Error("Expected unknown order to be rejected")
+		}
+	})
+}
+
+func TestExportImportSettings(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	_, sourcePath := setupTestSpace(t, parachuteRoot)
+	targetID, targetPath := setupTestSpace(t, parachuteRoot)
+
+	// reservedKeys returns the target's space_id and schema_version rows
+	reservedKeys := func(t *testing.T) map[string]string {
+		db, err := sql.Open("sqlite", filepath.Join(targetPath, "space.sqlite"))
+		if err != nil {
+			t.Fatalf("Failed to open space database: %v", err)
+		}
+		defer db.Close()
+
+		keys := map[string]string{}
+		for _, key := range []string{"space_id", "schema_version"} {
+			var value string
+			if err := db.QueryRow("SELECT value FROM space_metadata WHERE key = ?", key).Scan(&value); err != nil {
+				t.Fatalf("Failed to read %s: %v", key, err)
+			}
+			keys[key] = value
+		}
+		return keys
+	}
+	before := reservedKeys(t)
+
+	service.SetSetting(sourcePath, space.SettingRecentNotesOrder, space.RecentNotesOrderLinked)
+	service.SetSetting(sourcePath, space.SettingContextAudit, "true")
+	service.SetSetting(targetPath, space.SettingFrontmatterSync, "true")
+
+	exported, err := service.ExportSettings(sourcePath)
+	if err != nil {
+		t.Fatalf("Failed to export settings: %v", err)
+	}
+	if len(exported) != len(space.SettingKeys()) {
+		t.Errorf("Expected every setting exported, got %v", exported)
+	}
+	for _, key := range []string{"space_id", "schema_version"} {
+		if _, ok := exported[key]; ok {
+			t.Errorf("Expected reserved key %s left out of export", key)
+		}
+	}
+
+	t.Run("ApplyToAnotherSpace", func(t *testing.T) {
+		if err := service.ImportSettings(targetPath, exported); err != nil {
+			t.Fatalf("Failed to import settings: %v", err)
+		}
+		got, _ := service.GetSettings(targetPath)
+		for key, value := range exported {
+			if got[key] != value {
+				t.Errorf("%s: expected %q, got %q", key, value, got[key])
+			}
+		}
+
+		after := reservedKeys(t)
+		if after["space_id"] != targetID || after["schema_version"] != before["schema_version"] {
+			t.Errorf("Expected reserved keys untouched, got %v (was %v)", after, before)
+		}
+	})
+
+	t.Run("RejectsReservedKeys", func(t *testing.T) {
+		for _, key := range []string{"space_id", "schema_version"} {
+			err := service.ImportSettings(targetPath, map[string]string{key: "x", space.SettingContextAudit: "false"})
+			var validationErr *domain.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Errorf("%s: expected validation error, got %v", key, err)
+			}
+		}
+		if value, _ := service.GetSetting(targetPath, space.SettingContextAudit); value != "true" {
+			t.Errorf("Expected rejected import to change nothing, got context_audit %q", value)
+		}
+		if after := reservedKeys(t); after["space_id"] != targetID || after["schema_version"] != before["schema_version"] {
+			t.Errorf("Expected reserved keys untouched, got %v", after)
+		}
+	})
+}
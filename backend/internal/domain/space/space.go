@@ -12,6 +12,8 @@ type Space struct {
 	Path      string    `json:"path"`            // Absolute path to directory (auto-generated from name)
 	Icon      string    `json:"icon,omitempty"`  // Emoji icon for the space
 	Color     string    `json:"color,omitempty"` // Hex color code (e.g., "#2E7D32")
+	ReadOnly  bool      `json:"read_only"`       // Rejects note link/update/unlink when set
+	Archived  bool      `json:"archived"`        // Kept but set aside; may be left out of the space limit
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -21,11 +23,16 @@ type CreateSpaceParams struct {
 	Name  string `json:"name"`
 	Icon  string `json:"icon,omitempty"`
 	Color string `json:"color,omitempty"`
+
+	// Template, if set, supplies the new space's SPACE.md and settings (see ExportAsTemplate)
+	Template *SpaceTemplate `json:"template,omitempty"`
 }
 
 // UpdateSpaceParams represents parameters for updating a space
 type UpdateSpaceParams struct {
-	Name  string `json:"name,omitempty"`
-	Icon  string `json:"icon,omitempty"`
-	Color string `json:"color,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Icon     string `json:"icon,omitempty"`
+	Color    string `json:"color,omitempty"`
+	ReadOnly *bool  `json:"read_only,omitempty"`
+	Archived *bool  `json:"archived,omitempty"`
 }
@@ -0,0 +1,124 @@
+package space
+
+import "time"
+
+// SpaceDiff compares the notes linked to two spaces, A and B. Each partition
+// is keyed by capture ID.
+type SpaceDiff struct {
+	OnlyInA map[string]RelevantNote `json:"only_in_a"`
+	OnlyInB map[string]RelevantNote `json:"only_in_b"`
+	Shared  map[string]NoteDiff     `json:"shared"`
+}
+
+// NoteDiff describes how a capture linked to both spaces differs between them.
+// Differences is empty when the links match.
+type NoteDiff struct {
+	Differences []FieldDiff `json:"differences"`
+	TagsOnlyInA []string    `json:"tags_only_in_a"`
+	TagsOnlyInB []string    `json:"tags_only_in_b"`
+}
+
+// FieldDiff is one link field whose value differs between the two spaces
+type FieldDiff struct {
+	Field string      `json:"field"`
+	A     interface{} `json:"a"`
+	B     interface{} `json:"b"`
+}
+
+// DiffSpaces partitions the notes of two spaces into those linked only to A,
+// only to B, and to both, with a field-level diff of context, tags, status,
+// due date and note path for the shared ones. Tag order is ignored.
+func (s *SpaceDatabaseService) DiffSpaces(spaceAPath, spaceBPath string) (SpaceDiff, error) {
+	diff := SpaceDiff{
+		OnlyInA: map[string]RelevantNote{},
+		OnlyInB: map[string]RelevantNote{},
+		Shared:  map[string]NoteDiff{},
+	}
+
+	notesA, err := s.GetRelevantNotes(spaceAPath, NoteFilters{})
+	if err != nil {
+		return diff, err
+	}
+	notesB, err := s.GetRelevantNotes(spaceBPath, NoteFilters{})
+	if err != nil {
+		return diff, err
+	}
+
+	inB := make(map[string]RelevantNote, len(notesB))
+	for _, note := range notesB {
+		inB[note.CaptureID] = note
+	}
+
+	for _, a := range notesA {
+		b, shared := inB[a.CaptureID]
+		if !shared {
+			diff.OnlyInA[a.CaptureID] = a
+			continue
+		}
+		diff.Shared[a.CaptureID] = diffNotes(a, b)
+		delete(inB, a.CaptureID)
+	}
+	for id, b := range inB {
+		diff.OnlyInB[id] = b
+	}
+
+	return diff, nil
+}
+
+// diffNotes compares two links of the same capture
+func diffNotes(a, b RelevantNote) NoteDiff {
+	d := NoteDiff{
+		Differences: []FieldDiff{},
+		TagsOnlyInA: tagsMissingFrom(a.Tags, b.Tags),
+		TagsOnlyInB: tagsMissingFrom(b.Tags, a.Tags),
+	}
+
+	add := func(field string, valueA, valueB interface{}) {
+		d.Differences = append(d.Differences, FieldDiff{Field: field, A: valueA, B: valueB})
+	}
+
+	if a.Context != b.Context {
+		add("context", a.Context, b.Context)
+	}
+	if len(d.TagsOnlyInA) > 0 || len(d.TagsOnlyInB) > 0 {
+		add("tags", a.Tags, b.Tags)
+	}
+	if a.Status != b.Status {
+		add("status", a.Status, b.Status)
+	}
+	if !equalTimes(a.DueAt, b.DueAt) {
+		add("due_at", a.DueAt, b.DueAt)
+	}
+	if a.Priority != b.Priority {
+		add("priority", a.Priority, b.Priority)
+	}
+	if a.NotePath != b.NotePath {
+		add("note_path", a.NotePath, b.NotePath)
+	}
+
+	return d
+}
+
+// tagsMissingFrom returns the tags in tags that are not in other
+func tagsMissingFrom(tags, other []string) []string {
+	present := make(map[string]bool, len(other))
+	for _, tag := range other {
+		present[tag] = true
+	}
+
+	missing := []string{}
+	for _, tag := range mergeTags(tags) {
+		if !present[tag] {
+			missing = append(missing, tag)
+		}
+	}
+	return missing
+}
+
+// equalTimes reports whether two optional times are both unset or equal
+func equalTimes(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
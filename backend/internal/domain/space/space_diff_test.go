@@ -0,0 +1,80 @@
+package space_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestDiffSpaces(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceAID, spaceAPath := setupTestSpace(t, parachuteRoot)
+	spaceBID := uuid.New().String()
+	spaceBPath := filepath.Join(parachuteRoot, "spaces", "other-space")
+	if err := service.InitializeSpaceDatabase(spaceBID, spaceBPath); err != nil {
+		t.Fatalf("Failed to initialize second space: %v", err)
+	}
+
+	sharedID, sharedPath := createNamedCapture(t, parachuteRoot, "shared.md", "Both")
+	onlyAID, onlyAPath := createNamedCapture(t, parachuteRoot, "only-a.md", "A")
+	onlyBID, onlyBPath := createNamedCapture(t, parachuteRoot, "only-b.md", "B")
+
+	links := []struct {
+		spaceID, spacePath, captureID, notePath, context string
+		tags                                             []string
+	}{
+		{spaceAID, spaceAPath, sharedID, sharedPath, "Soil plan", []string{"soil", "garden"}},
+		{spaceBID, spaceBPath, sharedID, sharedPath, "Soil plan", []string{"garden", "budget"}},
+		{spaceAID, spaceAPath, onlyAID, onlyAPath, "", nil},
+		{spaceBID, spaceBPath, onlyBID, onlyBPath, "", nil},
+	}
+	for _, l := range links {
+		if err := service.LinkNote(l.spaceID, l.spacePath, l.captureID, l.notePath, l.context, l.tags); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	diff, err := service.DiffSpaces(spaceAPath, spaceBPath)
+	if err != nil {
+		t.Fatalf("Failed to diff spaces: %v", err)
+	}
+
+	if _, ok := diff.OnlyInA[onlyAID]; !ok || len(diff.OnlyInA) != 1 {
+		t.Errorf("Expected only %s in A, got %v", onlyAID, diff.OnlyInA)
+	}
+	if _, ok := diff.OnlyInB[onlyBID]; !ok || len(diff.OnlyInB) != 1 {
+		t.Errorf("Expected only %s in B, got %v", onlyBID, diff.OnlyInB)
+	}
+
+	shared, ok := diff.Shared[sharedID]
+	if !ok || len(diff.Shared) != 1 {
+		t.Fatalf("Expected %s to be shared, got %v", sharedID, diff.Shared)
+	}
+	if len(shared.Differences) != 1 || shared.Differences[0].Field != "tags" {
+		t.Errorf("Expected only tags to differ, got %+v", shared.Differences)
+	}
+	if !reflect.DeepEqual(shared.TagsOnlyInA, []string{"soil"}) || !reflect.DeepEqual(shared.TagsOnlyInB, []string{"budget"}) {
+		t.Errorf("Expected soil only in A and budget only in B, got %v and %v", shared.TagsOnlyInA, shared.TagsOnlyInB)
+	}
+
+	t.Run("IdenticalLinks", func(t *testing.T) {
+		diff, err := service.DiffSpaces(spaceAPath, spaceAPath)
+		if err != nil {
+			t.Fatalf("Failed to diff spaces: %v", err)
+		}
+		if len(diff.OnlyInA) != 0 || len(diff.OnlyInB) != 0 || len(diff.Shared) != 2 {
+			t.Errorf("Expected every note shared, got %+v", diff)
+		}
+		for id, note := range diff.Shared {
+			if len(note.Differences) != 0 {
+				t.Errorf("Expected no differences for %s, got %+v", id, note.Differences)
+			}
+		}
+	})
+}
@@ -0,0 +1,209 @@
+package space
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// spaceFilesDir is the directory inside a space holding uploaded files
+const spaceFilesDir = "files"
+
+// maxCollisionSuffix bounds the "name (N).ext" attempts when a target exists
+const maxCollisionSuffix = 1000
+
+// MoveFile moves a file from one space's files/ directory to another's,
+// keeping its relative path. relPath is relative to files/. If the target
+// already has a file at that path, the moved file is renamed "name (1).ext",
+// "name (2).ext" and so on. It returns the file's new path relative to the
+// target's files/ directory.
+func (s *Service) MoveFile(ctx context.Context, fromSpaceID, toSpaceID, relPath string) (string, error) {
+	if fromSpaceID == toSpaceID {
+		return "", domain.NewValidationError("to_space_id", "source and target space are the same")
+	}
+	return s.transferFile(ctx, fromSpaceID, toSpaceID, relPath, true)
+}
+
+// CopyFile copies a file into another (or the same) space's files/ directory,
+// with the same path and collision handling as MoveFile
+func (s *Service) CopyFile(ctx context.Context, fromSpaceID, toSpaceID, relPath string) (string, error) {
+	return s.transferFile(ctx, fromSpaceID, toSpaceID, relPath, false)
+}
+
+// transferFile moves or copies a file between space files/ directories
+func (s *Service) transferFile(ctx context.Context, fromSpaceID, toSpaceID, relPath string, move bool) (string, error) {
+	relPath = filepath.Clean(filepath.FromSlash(strings.TrimSpace(relPath)))
+	if relPath == "." || !filepath.IsLocal(relPath) {
+		return "", domain.NewValidationError("path", "must be a relative path inside the space's files/ directory")
+	}
+
+	from, err := s.GetByID(ctx, fromSpaceID)
+	if err != nil {
+		return "", err
+	}
+	to, err := s.GetByID(ctx, toSpaceID)
+	if err != nil {
+		return "", err
+	}
+	if to.ReadOnly || (move && from.ReadOnly) {
+		return "", ErrSpaceReadOnly
+	}
+
+	srcPath := filepath.Join(from.Path, spaceFilesDir, relPath)
+	info, err := os.Lstat(srcPath)
+	if os.IsNotExist(err) {
+		return "", domain.NewNotFoundError("file", filepath.ToSlash(relPath))
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to stat file: %w", err)
+	}
+	if err := checkInsideDir(filepath.Join(from.Path, spaceFilesDir), filepath.Dir(srcPath)); err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", domain.NewValidationError("path", "only regular files can be moved or copied")
+	}
+
+	targetDir := filepath.Join(to.Path, spaceFilesDir)
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create target directory: %w", err)
+	}
+
+	// Check the part of the target path that already exists before creating
+	// the rest, so a symlinked parent can't have directories made outside
+	// the space; once created, the full path is checked again
+	destDir := filepath.Dir(filepath.Join(targetDir, relPath))
+	if err := checkInsideDir(targetDir, existingAncestor(destDir)); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create target directory: %w", err)
+	}
+	if err := checkInsideDir(targetDir, destDir); err != nil {
+		return "", err
+	}
+
+	destRel, err := freeFilePath(targetDir, relPath)
+	if err != nil {
+		return "", err
+	}
+	destPath := filepath.Join(targetDir, destRel)
+
+	if move {
+		err = moveRegularFile(srcPath, destPath)
+	} else {
+		err = copyRegularFile(srcPath, destPath, info.Mode().Perm())
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.ToSlash(destRel), nil
+}
+
+// checkInsideDir rejects a path whose parent directories resolve, through
+// symlinks, to somewhere outside root
+func checkInsideDir(root, dir string) error {
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return fmt.Errorf("failed to resolve files directory: %w", err)
+	}
+	resolvedDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve directory: %w", err)
+	}
+
+	rel, err := filepath.Rel(resolvedRoot, resolvedDir)
+	if err != nil || !filepath.IsLocal(rel) {
+		return domain.NewValidationError("path", "must stay inside the space's files/ directory")
+	}
+	return nil
+}
+
+// existingAncestor returns dir, or its nearest parent that exists
+func existingAncestor(dir string) string {
+	for {
+		if _, err := os.Lstat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// freeFilePath returns relPath, or "name (N).ext" beside it, whichever is
+// first not taken inside dir
+func freeFilePath(dir, relPath string) (string, error) {
+	ext := filepath.Ext(relPath)
+	base := strings.TrimSuffix(relPath, ext)
+
+	candidate := relPath
+	for n := 1; n <= maxCollisionSuffix; n++ {
+		if _, err := os.Lstat(filepath.Join(dir, candidate)); os.IsNotExist(err) {
+			return candidate, nil
+		} else if err != nil {
+			return "", fmt.Errorf("failed to check target path: %w", err)
+		}
+		candidate = fmt.Sprintf("%s (%d)%s", base, n, ext)
+	}
+	return "", domain.NewConflictError("file", fmt.Sprintf("too many files named %s in target space", filepath.Base(relPath)))
+}
+
+// moveRegularFile renames src to dst, falling back to copy and delete when
+// they're on different filesystems
+func moveRegularFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, syscall.EXDEV) {
+		return fmt.Errorf("failed to move file: %w", err)
+	}
+
+	info, statErr := os.Stat(src)
+	if statErr != nil {
+		return fmt.Errorf("failed to move file: %w", err)
+	}
+	if err := copyRegularFile(src, dst, info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err := os.Remove(src); err != nil {
+		return fmt.Errorf("copied file but failed to remove original: %w", err)
+	}
+	return nil
+}
+
+// copyRegularFile copies src to a new file dst, removing dst if the copy fails
+func copyRegularFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return fmt.Errorf("failed to create target file: %w", err)
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+	return nil
+}
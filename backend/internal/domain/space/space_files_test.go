@@ -0,0 +1,148 @@
+package space_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+	sqliteStorage "github.com/unforced/parachute-backend/internal/storage/sqlite"
+)
+
+func TestMoveAndCopyFiles(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	db, err := sqliteStorage.NewDatabase(filepath.Join(parachuteRoot, "parachute.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	service := space.NewService(sqliteStorage.NewSpaceRepository(db.DB), parachuteRoot)
+
+	source, err := service.Create(ctx, "default", space.CreateSpaceParams{Name: "Garden"})
+	if err != nil {
+		t.Fatalf("Failed to create space: %v", err)
+	}
+	target, err := service.Create(ctx, "default", space.CreateSpaceParams{Name: "Orchard"})
+	if err != nil {
+		t.Fatalf("Failed to create space: %v", err)
+	}
+
+	writeFile := func(spacePath, rel, content string) {
+		path := filepath.Join(spacePath, "files", rel)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	t.Run("MoveToAnotherSpace", func(t *testing.T) {
+		writeFile(source.Path, "plans/layout.pdf", "layout")
+
+		newPath, err := service.MoveFile(ctx, source.ID, target.ID, "plans/layout.pdf")
+		if err != nil {
+			t.Fatalf("Failed to move file: %v", err)
+		}
+		if newPath != "plans/layout.pdf" {
+			t.Errorf("Expected path to be kept, got %s", newPath)
+		}
+
+		if _, err := os.Stat(filepath.Join(source.Path, "files", "plans", "layout.pdf")); !os.IsNotExist(err) {
+			t.Error("Expected file to be gone from the source space")
+		}
+		content, err := os.ReadFile(filepath.Join(target.Path, "files", "plans", "layout.pdf"))
+		if err != nil || string(content) != "layout" {
+			t.Errorf("Expected file in the target space, got %q (%v)", content, err)
+		}
+	})
+
+	t.Run("RenamesOnConflict", func(t *testing.T) {
+		writeFile(source.Path, "plans/layout.pdf", "second layout")
+
+		newPath, err := service.MoveFile(ctx, source.ID, target.ID, "plans/layout.pdf")
+		if err != nil {
+			t.Fatalf("Failed to move file: %v", err)
+		}
+		if newPath != "plans/layout (1).pdf" {
+			t.Errorf("Expected renamed path, got %s", newPath)
+		}
+
+		original, _ := os.ReadFile(filepath.Join(target.Path, "files", "plans", "layout.pdf"))
+		if string(original) != "layout" {
+			t.Errorf("Expected existing target file to be untouched, got %q", original)
+		}
+	})
+
+	t.Run("CopyKeepsSource", func(t *testing.T) {
+		writeFile(source.Path, "seeds.csv", "tomato,bean")
+
+		newPath, err := service.CopyFile(ctx, source.ID, target.ID, "seeds.csv")
+		if err != nil {
+			t.Fatalf("Failed to copy file: %v", err)
+		}
+		for _, path := range []string{
+			filepath.Join(source.Path, "files", "seeds.csv"),
+			filepath.Join(target.Path, "files", newPath),
+		} {
+			if content, err := os.ReadFile(path); err != nil || string(content) != "tomato,bean" {
+				t.Errorf("Expected %s to hold the file, got %q (%v)", path, content, err)
+			}
+		}
+	})
+
+	t.Run("RejectsTraversal", func(t *testing.T) {
+		var validationErr *domain.ValidationError
+		for _, rel := range []string{"../SPACE.md", "/etc/passwd", "plans/../../space.sqlite", ""} {
+			if _, err := service.MoveFile(ctx, source.ID, target.ID, rel); !errors.As(err, &validationErr) {
+				t.Errorf("%q: expected validation error, got %v", rel, err)
+			}
+		}
+	})
+
+	t.Run("RejectsSymlinkedDirectory", func(t *testing.T) {
+		outside := filepath.Join(parachuteRoot, "outside")
+		os.MkdirAll(outside, 0755)
+		os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644)
+		if err := os.Symlink(outside, filepath.Join(source.Path, "files", "escape")); err != nil {
+			t.Skipf("Symlinks not supported: %v", err)
+		}
+
+		var validationErr *domain.ValidationError
+		if _, err := service.MoveFile(ctx, source.ID, target.ID, "escape/secret.txt"); !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(outside, "secret.txt")); err != nil {
+			t.Error("File outside the space should not be moved")
+		}
+	})
+
+	t.Run("RejectsSymlinkedTargetDirectory", func(t *testing.T) {
+		outside := filepath.Join(parachuteRoot, "outside-target")
+		os.MkdirAll(outside, 0755)
+		if err := os.Symlink(outside, filepath.Join(target.Path, "files", "linked")); err != nil {
+			t.Skipf("Symlinks not supported: %v", err)
+		}
+		writeFile(source.Path, "linked/deep/notes.txt", "notes")
+
+		var validationErr *domain.ValidationError
+		if _, err := service.CopyFile(ctx, source.ID, target.ID, "linked/deep/notes.txt"); !errors.As(err, &validationErr) {
+			t.Errorf("Expected validation error, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(outside, "deep")); !os.IsNotExist(err) {
+			t.Error("Expected no directory to be created outside the space")
+		}
+	})
+
+	t.Run("MissingFile", func(t *testing.T) {
+		var notFoundErr *domain.NotFoundError
+		if _, err := service.MoveFile(ctx, source.ID, target.ID, "nope.txt"); !errors.As(err, &notFoundErr) {
+			t.Errorf("Expected not found, got %v", err)
+		}
+	})
+}
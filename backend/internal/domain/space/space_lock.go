@@ -0,0 +1,114 @@
+package space
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// spaceLockFile is created in a space directory while a maintenance operation
+// (a migration or a recompute) runs, so other processes sharing the vault back off
+const spaceLockFile = ".space.lock"
+
+// spaceLockStaleAfter is how old a lock file must be before it is assumed to
+// be left over from a crashed process and taken over
+const spaceLockStaleAfter = 15 * time.Minute
+
+// spaceLocks tracks the spaces locked by this process, and those of them
+// having their database reset
+type spaceLocks struct {
+	mu        sync.Mutex
+	held      map[string]bool
+	resetting map[string]bool
+}
+
+// lockSpace takes the maintenance lock for a space, returning ErrSpaceBusy if
+// another operation holds it in this or another process. Call the returned
+// function to release it.
+func (s *SpaceDatabaseService) lockSpace(spacePath string) (func(), error) {
+	key := filepath.Clean(spacePath)
+
+	s.locks.mu.Lock()
+	if s.locks.held[key] {
+		s.locks.mu.Unlock()
+		return nil, ErrSpaceBusy
+	}
+	if s.locks.held == nil {
+		s.locks.held = make(map[string]bool)
+	}
+	s.locks.held[key] = true
+	s.locks.mu.Unlock()
+
+	release := func() {
+		s.locks.mu.Lock()
+		delete(s.locks.held, key)
+		s.locks.mu.Unlock()
+	}
+
+	lockPath := filepath.Join(spacePath, spaceLockFile)
+	if err := createLockFile(lockPath); err != nil {
+		release()
+		return nil, err
+	}
+
+	return func() {
+		os.Remove(lockPath)
+		release()
+	}, nil
+}
+
+// createLockFile creates the lock file exclusively, replacing it once if it is stale
+func createLockFile(lockPath string) error {
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			fmt.Fprintf(f, "pid %d\nlocked_at %s\n", os.Getpid(), time.Now().Format(time.RFC3339))
+			return f.Close()
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("failed to create space lock: %w", err)
+		}
+
+		info, statErr := os.Stat(lockPath)
+		if statErr != nil || time.Since(info.ModTime()) < spaceLockStaleAfter {
+			return ErrSpaceBusy
+		}
+		os.Remove(lockPath)
+	}
+	return ErrSpaceBusy
+}
+
+// markResetting records that the space's database is being reset, so
+// checkResetting turns writers away, until the returned function is called.
+// The caller must hold the space's maintenance lock.
+func (s *SpaceDatabaseService) markResetting(spacePath string) func() {
+	key := filepath.Clean(spacePath)
+
+	s.locks.mu.Lock()
+	if s.locks.resetting == nil {
+		s.locks.resetting = make(map[string]bool)
+	}
+	s.locks.resetting[key] = true
+	s.locks.mu.Unlock()
+
+	return func() {
+		s.locks.mu.Lock()
+		delete(s.locks.resetting, key)
+		s.locks.mu.Unlock()
+	}
+}
+
+// checkResetting returns ErrSpaceBusy while this process is resetting the
+// space's database
+func (s *SpaceDatabaseService) checkResetting(spacePath string) error {
+	s.locks.mu.Lock()
+	defer s.locks.mu.Unlock()
+
+	if s.locks.resetting[filepath.Clean(spacePath)] {
+		return ErrSpaceBusy
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package space_test
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestSpaceMaintenanceLock(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	_, spacePath := setupTestSpace(t, parachuteRoot)
+	lockPath := filepath.Join(spacePath, ".space.lock")
+
+	t.Run("SecondOperationRejected", func(t *testing.T) {
+		unlock, err := service.LockSpace(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to lock space: %v", err)
+		}
+
+		if _, err := service.RecomputeDenormalized(spacePath); !errors.Is(err, space.ErrSpaceBusy) {
+			t.Errorf("Expected ErrSpaceBusy while locked, got %v", err)
+		}
+
+		unlock()
+		if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+			t.Error("Expected lock file to be removed on release")
+		}
+		if _, err := service.RecomputeDenormalized(spacePath); err != nil {
+			t.Errorf("Expected recompute to succeed after release, got %v", err)
+		}
+	})
+
+	t.Run("PendingMigrationTimesOut", func(t *testing.T) {
+		db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+		if err != nil {
+			t.Fatalf("Failed to open space database: %v", err)
+		}
+		defer db.Close()
+		// Pretend the last migration hasn't run
+		if _, err := db.Exec("UPDATE space_metadata SET value = ? WHERE key = 'schema_version'", space.LatestSchemaVersion()-1); err != nil {
+			t.Fatalf("Failed to downgrade schema version: %v", err)
+		}
+
+		unlock, err := service.LockSpace(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to lock space: %v", err)
+		}
+		restore := space.SetMigrationWait(100 * time.Millisecond)
+		err = service.MigrateSpaceDatabase(spacePath)
+		restore()
+		if !errors.Is(err, space.ErrSpaceBusy) {
+			t.Errorf("Expected ErrSpaceBusy once the wait runs out, got %v", err)
+		}
+		unlock()
+
+		if _, err := db.Exec("UPDATE space_metadata SET value = ? WHERE key = 'schema_version'", space.LatestSchemaVersion()); err != nil {
+			t.Fatalf("Failed to restore schema version: %v", err)
+		}
+	})
+
+	t.Run("PendingMigrationWaitsForLock", func(t *testing.T) {
+		db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+		if err != nil {
+			t.Fatalf("Failed to open space database: %v", err)
+		}
+		defer db.Close()
+		if _, err := db.Exec("UPDATE space_metadata SET value = ? WHERE key = 'schema_version'", space.LatestSchemaVersion()-1); err != nil {
+			t.Fatalf("Failed to downgrade schema version: %v", err)
+		}
+
+		unlock, err := service.LockSpace(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to lock space: %v", err)
+		}
+		// The lock holder finishes the migration, then releases the lock
+		finished := make(chan error, 1)
+		time.AfterFunc(100*time.Millisecond, func() {
+			_, err := db.Exec("UPDATE space_metadata SET value = ? WHERE key = 'schema_version'", space.LatestSchemaVersion())
+			unlock()
+			finished <- err
+		})
+
+		if err := service.MigrateSpaceDatabase(spacePath); err != nil {
+			t.Errorf("Expected the migration to wait for the running one, got %v", err)
+		}
+		if err := <-finished; err != nil {
+			t.Fatalf("Failed to restore schema version: %v", err)
+		}
+	})
+
+	t.Run("UpToDateInitializationSkipsLock", func(t *testing.T) {
+		unlock, err := service.LockSpace(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to lock space: %v", err)
+		}
+		defer unlock()
+
+		if err := service.InitializeSpaceDatabase("id", spacePath); err != nil {
+			t.Errorf("Expected initialization of a current database to succeed, got %v", err)
+		}
+	})
+
+	t.Run("LockFileFromAnotherProcess", func(t *testing.T) {
+		if err := os.WriteFile(lockPath, []byte("pid 1\n"), 0644); err != nil {
+			t.Fatalf("Failed to write lock file: %v", err)
+		}
+
+		if _, err := service.RecomputeDenormalized(spacePath); !errors.Is(err, space.ErrSpaceBusy) {
+			t.Errorf("Expected ErrSpaceBusy with a fresh lock file, got %v", err)
+		}
+
+		// A lock file left by a crashed process is taken over once stale
+		old := time.Now().Add(-time.Hour)
+		os.Chtimes(lockPath, old, old)
+		if _, err := service.RecomputeDenormalized(spacePath); err != nil {
+			t.Errorf("Expected stale lock to be taken over, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package space
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SpaceTemplate is a reusable space setup: its SPACE.md with variables left
+// unresolved, and the settings it overrides. Linked notes are not part of it.
+type SpaceTemplate struct {
+	SpaceMD  string            `json:"space_md"`
+	Settings map[string]string `json:"settings"` // Only settings that differ from their default
+}
+
+// ExportAsTemplate turns a space's setup into a template for creating similar
+// spaces (see CreateSpaceParams.Template)
+func (s *SpaceDatabaseService) ExportAsTemplate(spacePath string) (SpaceTemplate, error) {
+	spaceMD, err := readSpaceContextFile(spacePath)
+	if err != nil {
+		return SpaceTemplate{}, err
+	}
+
+	template := SpaceTemplate{
+		SpaceMD:  spaceMD,
+		Settings: map[string]string{},
+	}
+
+	settings, err := s.GetSettings(spacePath)
+	if err != nil {
+		return SpaceTemplate{}, err
+	}
+	for key, value := range settings {
+		if value != spaceSettings[key].Default {
+			template.Settings[key] = value
+		}
+	}
+
+	return template, nil
+}
+
+// validateTemplate checks a template's settings before a space is created from it
+func (s *SpaceDatabaseService) validateTemplate(template *SpaceTemplate) error {
+	for key, value := range template.Settings {
+		if _, err := s.validateSetting(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyTemplate writes a template's SPACE.md and settings into a new space
+func (s *SpaceDatabaseService) applyTemplate(spaceID, spacePath string, template *SpaceTemplate) error {
+	if template.SpaceMD != "" {
+		if err := os.WriteFile(filepath.Join(spacePath, "SPACE.md"), []byte(template.SpaceMD), 0644); err != nil {
+			return fmt.Errorf("failed to write SPACE.md: %w", err)
+		}
+	}
+
+	if len(template.Settings) == 0 {
+		return nil
+	}
+
+	if err := s.InitializeSpaceDatabase(spaceID, spacePath); err != nil {
+		return err
+	}
+	return s.ImportSettings(spacePath, template.Settings)
+}
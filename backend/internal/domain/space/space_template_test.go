@@ -0,0 +1,126 @@
+package space_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+	sqliteStorage "github.com/unforced/parachute-backend/internal/storage/sqlite"
+)
+
+func TestSpaceTemplate(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	db, err := sqliteStorage.NewDatabase(filepath.Join(parachuteRoot, "parachute.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	service := space.NewService(sqliteStorage.NewSpaceRepository(db.DB), parachuteRoot)
+	service.SetDatabaseService(dbService)
+
+	source, err := service.Create(ctx, "default", space.CreateSpaceParams{Name: "Garden"})
+	if err != nil {
+		t.Fatalf("Failed to create space: %v", err)
+	}
+	if err := dbService.InitializeSpaceDatabase(source.ID, source.Path); err != nil {
+		t.Fatalf("Failed to initialize space database: %v", err)
+	}
+
+	spaceMD := "# Garden\n\nNotes: {{note_count}}\n"
+	if err := os.WriteFile(filepath.Join(source.Path, "SPACE.md"), []byte(spaceMD), 0644); err != nil {
+		t.Fatalf("Failed to write SPACE.md: %v", err)
+	}
+	if err := dbService.SetSetting(source.Path, space.SettingTagDisplayOrder, space.TagDisplayOrderAlphabetical); err != nil {
+		t.Fatalf("Failed to set setting: %v", err)
+	}
+	if err := dbService.LinkNote(source.ID, source.Path, "walk", "captures/walk.md", "", nil); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	template, err := dbService.ExportAsTemplate(source.Path)
+	if err != nil {
+		t.Fatalf("Failed to export template: %v", err)
+	}
+
+	t.Run("Export", func(t *testing.T) {
+		if template.SpaceMD != spaceMD {
+			t.Errorf("Expected raw SPACE.md, got %q", template.SpaceMD)
+		}
+		if len(template.Settings) != 1 || template.Settings[space.SettingTagDisplayOrder] != space.TagDisplayOrderAlphabetical {
+			t.Errorf("Expected only the overridden setting, got %v", template.Settings)
+		}
+	})
+
+	t.Run("CreateFromTemplate", func(t *testing.T) {
+		created, err := service.Create(ctx, "default", space.CreateSpaceParams{Name: "Orchard", Template: &template})
+		if err != nil {
+			t.Fatalf("Failed to create space from template: %v", err)
+		}
+
+		content, err := os.ReadFile(filepath.Join(created.Path, "SPACE.md"))
+		if err != nil || string(content) != spaceMD {
+			t.Errorf("Expected template SPACE.md, got %q (%v)", content, err)
+		}
+
+		value, err := dbService.GetSetting(created.Path, space.SettingTagDisplayOrder)
+		if err != nil || value != space.TagDisplayOrderAlphabetical {
+			t.Errorf("Expected template setting applied, got %q (%v)", value, err)
+		}
+
+		notes, err := dbService.GetRelevantNotes(created.Path, space.NoteFilters{})
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		if len(notes) != 0 {
+			t.Errorf("Expected no linked notes from the template, got %d", len(notes))
+		}
+	})
+
+	t.Run("RejectsInvalidSettings", func(t *testing.T) {
+		bad := space.SpaceTemplate{Settings: map[string]string{"no_such_setting": "x"}}
+		_, err := service.Create(ctx, "default", space.CreateSpaceParams{Name: "Broken", Template: &bad})
+		var validationErr *domain.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("Expected validation error, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(parachuteRoot, "spaces", "broken")); !os.IsNotExist(err) {
+			t.Error("Expected no space directory for a rejected template")
+		}
+	})
+
+	t.Run("FailedTemplateLeavesNoSpace", func(t *testing.T) {
+		// A leftover directory with a broken database makes applying the settings fail
+		spacePath := filepath.Join(parachuteRoot, "spaces", "meadow")
+		if err := os.MkdirAll(spacePath, 0755); err != nil {
+			t.Fatalf("Failed to create space directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(spacePath, "space.sqlite"), []byte("not a database"), 0644); err != nil {
+			t.Fatalf("Failed to write database: %v", err)
+		}
+
+		if _, err := service.Create(ctx, "default", space.CreateSpaceParams{Name: "Meadow", Template: &template}); err == nil {
+			t.Fatal("Expected the template to fail")
+		}
+		spaces, err := service.List(ctx, "default")
+		if err != nil {
+			t.Fatalf("Failed to list spaces: %v", err)
+		}
+		for _, listed := range spaces {
+			if listed.Path == spacePath {
+				t.Error("Expected no space record for a failed template")
+			}
+		}
+		if _, err := os.Stat(filepath.Join(spacePath, "space.sqlite")); err != nil {
+			t.Errorf("Expected the directory that was already there to be left alone: %v", err)
+		}
+	})
+}
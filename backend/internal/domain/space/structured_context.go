@@ -0,0 +1,170 @@
+package space
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// StructuredContext is space-specific context for a linked note expressed as
+// fields agents can consume reliably. The well-known fields are why_relevant,
+// summary and key_points; any additional fields are kept as-is.
+type StructuredContext map[string]interface{}
+
+// Well-known structured context fields
+const (
+	StructuredWhyRelevant = "why_relevant"
+	StructuredSummary     = "summary"
+	StructuredKeyPoints   = "key_points"
+)
+
+// Validate checks the types of the well-known fields. Unknown fields are allowed.
+func (sc StructuredContext) Validate() error {
+	for _, field := range []string{StructuredWhyRelevant, StructuredSummary} {
+		if value, ok := sc[field]; ok && value != nil {
+			if _, isString := value.(string); !isString {
+				return domain.NewValidationError("context_structured."+field, "must be a string")
+			}
+		}
+	}
+
+	if value, ok := sc[StructuredKeyPoints]; ok && value != nil && !isStringList(value) {
+		return domain.NewValidationError("context_structured."+StructuredKeyPoints, "must be a list of strings")
+	}
+
+	return nil
+}
+
+// isStringList reports whether a decoded JSON value is a list of strings
+func isStringList(value interface{}) bool {
+	switch list := value.(type) {
+	case []string:
+		return true
+	case []interface{}:
+		for _, item := range list {
+			if _, ok := item.(string); !ok {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Markdown renders the structured context as markdown, well-known fields first
+// and any extra fields afterwards in alphabetical order
+func (sc StructuredContext) Markdown() string {
+	if len(sc) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+
+	if why, ok := sc[StructuredWhyRelevant].(string); ok && why != "" {
+		fmt.Fprintf(&b, "**Why relevant:** %s\n", why)
+	}
+	if summary, ok := sc[StructuredSummary].(string); ok && summary != "" {
+		fmt.Fprintf(&b, "**Summary:** %s\n", summary)
+	}
+	if points := sc.keyPoints(); len(points) > 0 {
+		b.WriteString("**Key points:**\n")
+		for _, point := range points {
+			fmt.Fprintf(&b, "- %s\n", point)
+		}
+	}
+
+	var extras []string
+	for key := range sc {
+		switch key {
+		case StructuredWhyRelevant, StructuredSummary, StructuredKeyPoints:
+			continue
+		}
+		extras = append(extras, key)
+	}
+	sort.Strings(extras)
+
+	for _, key := range extras {
+		value := sc[key]
+		if str, ok := value.(string); ok {
+			fmt.Fprintf(&b, "**%s:** %s\n", key, str)
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&b, "**%s:** %s\n", key, string(encoded))
+	}
+
+	return b.String()
+}
+
+// keyPoints returns the key_points field as strings
+func (sc StructuredContext) keyPoints() []string {
+	switch points := sc[StructuredKeyPoints].(type) {
+	case []string:
+		return points
+	case []interface{}:
+		result := make([]string, 0, len(points))
+		for _, point := range points {
+			if str, ok := point.(string); ok {
+				result = append(result, str)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// SetStructuredContext sets (or clears, when nil) the structured context for a linked note
+func (s *SpaceDatabaseService) SetStructuredContext(spacePath, captureID string, structured StructuredContext) error {
+	if err := s.checkWritable(spacePath); err != nil {
+		return err
+	}
+
+	var value interface{}
+	if structured != nil {
+		if err := structured.Validate(); err != nil {
+			return err
+		}
+		encoded, err := json.Marshal(structured)
+		if err != nil {
+			return fmt.Errorf("failed to marshal structured context: %w", err)
+		}
+		value = string(encoded)
+	}
+
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	result, err := db.Exec("UPDATE relevant_notes SET context_structured = ? WHERE capture_id = ?", value, captureID)
+	if err != nil {
+		return fmt.Errorf("failed to set structured context: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("note not found in space")
+	}
+
+	return bumpContextVersion(db)
+}
+
+// GetStructuredContext returns the structured context for a linked note (nil if unset)
+func (s *SpaceDatabaseService) GetStructuredContext(spacePath, captureID string) (StructuredContext, error) {
+	note, err := s.GetNoteByID(spacePath, captureID)
+	if err != nil {
+		return nil, err
+	}
+	return note.ContextStructured, nil
+}
@@ -0,0 +1,118 @@
+package space_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestStructuredContext(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(service)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	structuredID, structuredPath := createNamedCapture(t, parachuteRoot, "2024-03-01_09-00-00.md", "# Soil notes\n\nCover crops matter.")
+	if err := service.LinkNote(spaceID, spacePath, structuredID, structuredPath, "Soil health", []string{"soil"}); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	t.Run("RoundTripWithExtraFields", func(t *testing.T) {
+		structured := space.StructuredContext{
+			"why_relevant": "Explains our cover crop choice",
+			"summary":      "Cover crops improve soil",
+			"key_points":   []string{"Clover fixes nitrogen", "Rye prevents erosion"},
+			"confidence":   "high",
+		}
+
+		if err := service.SetStructuredContext(spacePath, structuredID, structured); err != nil {
+			t.Fatalf("Failed to set structured context: %v", err)
+		}
+
+		got, err := service.GetStructuredContext(spacePath, structuredID)
+		if err != nil {
+			t.Fatalf("Failed to get structured context: %v", err)
+		}
+
+		if got["why_relevant"] != "Explains our cover crop choice" {
+			t.Errorf("Unexpected why_relevant: %v", got["why_relevant"])
+		}
+		if got["confidence"] != "high" {
+			t.Errorf("Expected extra field to round-trip, got %v", got["confidence"])
+		}
+		points, ok := got["key_points"].([]interface{})
+		if !ok || len(points) != 2 {
+			t.Errorf("Expected 2 key points, got %v", got["key_points"])
+		}
+
+		// Plain context is untouched
+		note, _ := service.GetNoteByID(spacePath, structuredID)
+		if note.Context != "Soil health" {
+			t.Errorf("Expected plain context to be preserved, got %s", note.Context)
+		}
+	})
+
+	t.Run("PlainContextWithoutStructured", func(t *testing.T) {
+		plainID, plainPath := createMockCapture(t, parachuteRoot, "Plain capture")
+		if err := service.LinkNote(spaceID, spacePath, plainID, plainPath, "Just a string", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+
+		note, err := service.GetNoteByID(spacePath, plainID)
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if note.Context != "Just a string" {
+			t.Errorf("Expected plain context, got %s", note.Context)
+		}
+		if note.ContextStructured != nil {
+			t.Errorf("Expected no structured context, got %v", note.ContextStructured)
+		}
+	})
+
+	t.Run("RejectsInvalidKnownField", func(t *testing.T) {
+		err := service.SetStructuredContext(spacePath, structuredID, space.StructuredContext{
+			"key_points": "not a list",
+		})
+		if err == nil {
+			t.Error("Expected validation error for non-list key_points")
+		}
+	})
+
+	t.Run("ClearStructuredContext", func(t *testing.T) {
+		otherID, otherPath := createMockCapture(t, parachuteRoot, "Other")
+		service.LinkNote(spaceID, spacePath, otherID, otherPath, "", nil)
+		service.SetStructuredContext(spacePath, otherID, space.StructuredContext{"summary": "temp"})
+
+		if err := service.SetStructuredContext(spacePath, otherID, nil); err != nil {
+			t.Fatalf("Failed to clear structured context: %v", err)
+		}
+		got, _ := service.GetStructuredContext(spacePath, otherID)
+		if got != nil {
+			t.Errorf("Expected structured context to be cleared, got %v", got)
+		}
+	})
+
+	t.Run("RenderedInInjectedNotes", func(t *testing.T) {
+		result, err := contextService.ResolveVariables("{{injected_notes}}", spacePath)
+		if err != nil {
+			t.Fatalf("Failed to resolve variables: %v", err)
+		}
+
+		expected := []string{
+			"**Why relevant:** Explains our cover crop choice",
+			"**Key points:**\n- Clover fixes nitrogen",
+			"**confidence:** high",
+			"Context: Soil health",
+			"Cover crops matter.",
+		}
+		for _, want := range expected {
+			if !strings.Contains(result, want) {
+				t.Errorf("Expected injected notes to contain %q, got:\n%s", want, result)
+			}
+		}
+	})
+}
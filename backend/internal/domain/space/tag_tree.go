@@ -0,0 +1,101 @@
+package space
+
+import (
+	"sort"
+	"strings"
+)
+
+// TagNode is one segment of a slash-delimited tag hierarchy. For the tag
+// "project/alpha" the "alpha" node has Path "project/alpha".
+type TagNode struct {
+	Name        string     `json:"name"`
+	Path        string     `json:"path"`
+	DirectCount int        `json:"direct_count"` // Notes tagged with exactly Path
+	Count       int        `json:"count"`        // Notes tagged with Path or anything under it, each counted once
+	Children    []*TagNode `json:"children"`
+}
+
+// GetTagTree builds a tree of a space's tags by splitting them on "/". The
+// returned root has an empty name and path; its Count is the number of tagged
+// notes. A note tagged with several children of the same parent counts once
+// toward the parent.
+func (s *SpaceDatabaseService) GetTagTree(spacePath string) (TagNode, error) {
+	root := TagNode{Children: []*TagNode{}}
+
+	notes, err := s.GetRelevantNotes(spacePath, NoteFilters{})
+	if err != nil {
+		return root, err
+	}
+
+	nodes := map[string]*TagNode{"": &root}
+	node := func(path string) *TagNode {
+		if n, ok := nodes[path]; ok {
+			return n
+		}
+		parentPath, name := "", path
+		if i := strings.LastIndex(path, "/"); i >= 0 {
+			parentPath, name = path[:i], path[i+1:]
+		}
+		n := &TagNode{Name: name, Path: path, Children: []*TagNode{}}
+		nodes[path] = n
+		parent := nodes[parentPath]
+		parent.Children = append(parent.Children, n)
+		return n
+	}
+
+	for _, note := range notes {
+		direct := map[string]bool{}
+		touched := map[string]bool{}
+
+		for _, tag := range note.Tags {
+			path := normalizeTagPath(tag)
+			if path == "" {
+				continue
+			}
+			direct[path] = true
+
+			// Create ancestors first so each node can find its parent
+			segments := strings.Split(path, "/")
+			for i := range segments {
+				prefix := strings.Join(segments[:i+1], "/")
+				node(prefix)
+				touched[prefix] = true
+			}
+		}
+
+		for path := range direct {
+			nodes[path].DirectCount++
+		}
+		for path := range touched {
+			nodes[path].Count++
+		}
+		if len(touched) > 0 {
+			root.Count++
+		}
+	}
+
+	sortTagNodes(&root)
+	return root, nil
+}
+
+// normalizeTagPath trims surrounding whitespace and drops empty segments, so
+// "/project//alpha/" becomes "project/alpha"
+func normalizeTagPath(tag string) string {
+	var segments []string
+	for _, segment := range strings.Split(strings.TrimSpace(tag), "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// sortTagNodes orders children alphabetically at every level
+func sortTagNodes(n *TagNode) {
+	sort.Slice(n.Children, func(i, j int) bool {
+		return n.Children[i].Name < n.Children[j].Name
+	})
+	for _, child := range n.Children {
+		sortTagNodes(child)
+	}
+}
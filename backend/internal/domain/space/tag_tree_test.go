@@ -0,0 +1,81 @@
+package space_test
+
+import (
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestGetTagTree(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	tagSets := [][]string{
+		{"project/alpha/frontend", "project/alpha/backend"}, // Two children of the same parent
+		{"project/alpha/frontend"},
+		{"project/beta", "garden"},
+		{"project"},
+		{},
+	}
+	for _, tags := range tagSets {
+		captureID, notePath := createMockCapture(t, parachuteRoot, "Note")
+		if err := service.LinkNote(spaceID, spacePath, captureID, notePath, "", tags); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	tree, err := service.GetTagTree(spacePath)
+	if err != nil {
+		t.Fatalf("Failed to build tag tree: %v", err)
+	}
+
+	find := func(path ...string) *space.TagNode {
+		node := &tree
+		for _, name := range path {
+			var next *space.TagNode
+			for _, child := range node.Children {
+				if child.Name == name {
+					next = child
+				}
+			}
+			if next == nil {
+				t.Fatalf("Expected node %v in tag tree", path)
+			}
+			node = next
+		}
+		return node
+	}
+
+	tests := []struct {
+		path   []string
+		direct int
+		count  int
+	}{
+		{[]string{"project"}, 1, 4},
+		{[]string{"project", "alpha"}, 0, 2}, // Union, not 3
+		{[]string{"project", "alpha", "frontend"}, 2, 2},
+		{[]string{"project", "alpha", "backend"}, 1, 1},
+		{[]string{"project", "beta"}, 1, 1},
+		{[]string{"garden"}, 1, 1},
+	}
+	for _, tt := range tests {
+		node := find(tt.path...)
+		if node.DirectCount != tt.direct || node.Count != tt.count {
+			t.Errorf("%s: expected direct %d and count %d, got %d and %d",
+				node.Path, tt.direct, tt.count, node.DirectCount, node.Count)
+		}
+	}
+
+	if tree.Count != 4 {
+		t.Errorf("Expected root to count 4 tagged notes, got %d", tree.Count)
+	}
+	if tree.Children[0].Name != "garden" || tree.Children[1].Name != "project" {
+		t.Errorf("Expected children sorted alphabetically, got %s, %s", tree.Children[0].Name, tree.Children[1].Name)
+	}
+	if alpha := find("project", "alpha"); alpha.Path != "project/alpha" {
+		t.Errorf("Expected path project/alpha, got %s", alpha.Path)
+	}
+}
@@ -0,0 +1,33 @@
+package space
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// MaxTagLength is the maximum length of a single tag, in runes
+const MaxTagLength = 100
+
+// MaxFilterTags is the most tags a NoteFilters may require
+const MaxFilterTags = 50
+
+// validateTags rejects any tag longer than MaxTagLength runes. Length is
+// counted in runes rather than bytes so multi-byte tags get the same allowance.
+func validateTags(tags []string) error {
+	for _, tag := range tags {
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			return domain.NewValidationError("tags", fmt.Sprintf("tag %q exceeds %d characters", tag, MaxTagLength))
+		}
+	}
+	return nil
+}
+
+// validateFilterTags rejects tag filters with more than MaxFilterTags distinct tags
+func validateFilterTags(tags []string) error {
+	if n := len(mergeTags(tags)); n > MaxFilterTags {
+		return domain.NewValidationError("tags", fmt.Sprintf("at most %d tags can be filtered on, got %d", MaxFilterTags, n))
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package space
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+)
+
+// DefaultTrashRetention is how long unlinked notes are remembered before
+// PurgeExpiredDeleted removes them, unless configured otherwise
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// PurgeExpiredDeleted permanently removes the records of notes unlinked more
+// than retention ago from every space under vaultRoot/spaces, returning how
+// many were purged. Once purged, a note is reported as not found rather than
+// gone. Spaces without a database, read-only spaces and spaces being reset
+// are skipped. A space that fails doesn't stop the purge; its error is
+// returned, joined with any others, once every space has been tried.
+func (s *SpaceDatabaseService) PurgeExpiredDeleted(vaultRoot string, retention time.Duration) (int, error) {
+	if retention < 0 {
+		return 0, domain.NewValidationError("retention", "must not be negative")
+	}
+
+	spacesDir := filepath.Join(vaultRoot, "spaces")
+
+	entries, err := os.ReadDir(spacesDir)
+	if os.IsNotExist(err) {
+		return 0, nil // No spaces to purge
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read spaces directory: %w", err)
+	}
+
+	cutoff := time.Now().Add(-retention).Unix()
+
+	purged := 0
+	var errs []error
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		spacePath := filepath.Join(spacesDir, entry.Name())
+		if _, err := os.Stat(filepath.Join(spacePath, "space.sqlite")); err != nil {
+			continue
+		}
+		if s.checkReadOnly(spacePath) != nil || s.checkResetting(spacePath) != nil {
+			continue
+		}
+
+		n, err := purgeDeletedBefore(spacePath, cutoff)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to purge space %s: %w", entry.Name(), err))
+			continue
+		}
+		purged += n
+	}
+
+	return purged, errors.Join(errs...)
+}
+
+// purgeDeletedBefore removes a space's deleted_notes entries older than
+// cutoff, with the reference log entries of those notes not linked again
+func purgeDeletedBefore(spacePath string, cutoff int64) (int, error) {
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin purge: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		DELETE FROM note_references
+		WHERE capture_id IN (SELECT capture_id FROM deleted_notes WHERE deleted_at < ?)
+		AND capture_id NOT IN (SELECT capture_id FROM relevant_notes)
+	`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge note references: %w", err)
+	}
+
+	result, err := tx.Exec("DELETE FROM deleted_notes WHERE deleted_at < ?", cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge unlinked notes: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit purge: %w", err)
+	}
+
+	n, _ := result.RowsAffected()
+	return int(n), nil
+}
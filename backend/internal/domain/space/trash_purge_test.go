@@ -0,0 +1,110 @@
+package space_test
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain"
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestPurgeExpiredDeleted(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	// unlinkAt links and unlinks a note, then backdates its tombstone
+	unlinkAt := func(captureID string, deletedAt time.Time) {
+		if err := service.LinkNote(spaceID, spacePath, captureID, "captures/"+captureID+".md", "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		if err := service.TrackNoteReference(spacePath, captureID); err != nil {
+			t.Fatalf("Failed to track reference: %v", err)
+		}
+		if err := service.UnlinkNote(spacePath, captureID); err != nil {
+			t.Fatalf("Failed to unlink note: %v", err)
+		}
+
+		db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+		if err != nil {
+			t.Fatalf("Failed to open space database: %v", err)
+		}
+		defer db.Close()
+		if _, err := db.Exec("UPDATE deleted_notes SET deleted_at = ? WHERE capture_id = ?", deletedAt.Unix(), captureID); err != nil {
+			t.Fatalf("Failed to backdate tombstone: %v", err)
+		}
+	}
+
+	unlinkAt("old", time.Now().Add(-45*24*time.Hour))
+	unlinkAt("recent", time.Now().Add(-2*24*time.Hour))
+
+	purged, err := service.PurgeExpiredDeleted(parachuteRoot, space.DefaultTrashRetention)
+	if err != nil {
+		t.Fatalf("Failed to purge: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("Expected 1 purged, got %d", purged)
+	}
+
+	// The purged note is no longer known; the recent one is still gone
+	_, err = service.GetNoteByID(spacePath, "old")
+	var goneErr *domain.GoneError
+	if err == nil || errors.As(err, &goneErr) {
+		t.Errorf("Expected the old note to be forgotten, got %v", err)
+	}
+	_, err = service.GetNoteByID(spacePath, "recent")
+	if !errors.As(err, &goneErr) {
+		t.Errorf("Expected the recent note to be retained as gone, got %v", err)
+	}
+
+	// The purged note's references go with it
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to open space database: %v", err)
+	}
+	defer db.Close()
+	for captureID, want := range map[string]int{"old": 0, "recent": 1} {
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM note_references WHERE capture_id = ?", captureID).Scan(&count); err != nil {
+			t.Fatalf("Failed to count references: %v", err)
+		}
+		if count != want {
+			t.Errorf("Expected %d references logged for %s, got %d", want, captureID, count)
+		}
+	}
+
+	// A second sweep has nothing left to do
+	if purged, _ := service.PurgeExpiredDeleted(parachuteRoot, space.DefaultTrashRetention); purged != 0 {
+		t.Errorf("Expected nothing to purge, got %d", purged)
+	}
+
+	if _, err := service.PurgeExpiredDeleted(parachuteRoot, -time.Hour); err == nil {
+		t.Error("Expected negative retention to be rejected")
+	}
+
+	t.Run("ContinuesPastFailedSpace", func(t *testing.T) {
+		// Sorts before the test space, so the purge meets it first
+		brokenPath := filepath.Join(parachuteRoot, "spaces", "0-broken")
+		if err := os.MkdirAll(brokenPath, 0755); err != nil {
+			t.Fatalf("Failed to create space directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(brokenPath, "space.sqlite"), []byte("not a database"), 0644); err != nil {
+			t.Fatalf("Failed to write database: %v", err)
+		}
+		unlinkAt("older", time.Now().Add(-60*24*time.Hour))
+
+		purged, err := service.PurgeExpiredDeleted(parachuteRoot, space.DefaultTrashRetention)
+		if err == nil {
+			t.Error("Expected the broken space's error to be reported")
+		}
+		if purged != 1 {
+			t.Errorf("Expected the other space to be purged, got %d", purged)
+		}
+	})
+}
@@ -0,0 +1,34 @@
+package space
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// untaggedCondition matches relevant_notes rows with no tags: an empty
+// array, a JSON null, a missing value or anything that isn't valid JSON
+const untaggedCondition = "COALESCE(json_array_length(CASE WHEN json_valid(tags) THEN tags END), 0) = 0"
+
+// CountUntaggedNotes returns how many of a space's linked notes have no tags.
+// Filter with NoteFilters.HasTags to list them.
+func (s *SpaceDatabaseService) CountUntaggedNotes(spacePath string) (int, error) {
+	dbPath := filepath.Join(spacePath, "space.sqlite")
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM relevant_notes WHERE " + untaggedCondition).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count untagged notes: %w", err)
+	}
+	return count, nil
+}
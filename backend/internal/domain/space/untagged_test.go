@@ -0,0 +1,87 @@
+package space_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+)
+
+func TestUntaggedNotes(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := space.NewSpaceDatabaseService(parachuteRoot)
+	contextService := space.NewContextService(service)
+	spaceID, spacePath := setupTestSpace(t, parachuteRoot)
+
+	links := []struct {
+		captureID string
+		tags      []string
+	}{
+		{"empty", []string{}},
+		{"nil", nil},
+		{"tagged", []string{"soil"}},
+	}
+	for _, l := range links {
+		if err := service.LinkNote(spaceID, spacePath, l.captureID, "captures/"+l.captureID+".md", "", l.tags); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	// Rows written before tags were always stored as arrays
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to open space database: %v", err)
+	}
+	if _, err := db.Exec("UPDATE relevant_notes SET tags = NULL WHERE capture_id = 'nil'"); err != nil {
+		t.Fatalf("Failed to clear tags: %v", err)
+	}
+	db.Close()
+
+	t.Run("Count", func(t *testing.T) {
+		count, err := service.CountUntaggedNotes(spacePath)
+		if err != nil {
+			t.Fatalf("Failed to count untagged notes: %v", err)
+		}
+		if count != 2 {
+			t.Errorf("Expected 2 untagged notes, got %d", count)
+		}
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		hasTags := false
+		notes, err := service.GetRelevantNotes(spacePath, space.NoteFilters{HasTags: &hasTags})
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		if len(notes) != 2 {
+			t.Fatalf("Expected 2 untagged notes, got %d", len(notes))
+		}
+		for _, note := range notes {
+			if note.CaptureID == "tagged" {
+				t.Errorf("Expected the tagged note to be excluded")
+			}
+		}
+
+		hasTags = true
+		notes, err = service.GetRelevantNotes(spacePath, space.NoteFilters{HasTags: &hasTags})
+		if err != nil {
+			t.Fatalf("Failed to get notes: %v", err)
+		}
+		if len(notes) != 1 || notes[0].CaptureID != "tagged" {
+			t.Errorf("Expected only the tagged note, got %+v", notes)
+		}
+	})
+
+	t.Run("ContextVariable", func(t *testing.T) {
+		result, err := contextService.ResolveVariables("Untagged: {{untagged_count}}", spacePath)
+		if err != nil {
+			t.Fatalf("Failed to resolve: %v", err)
+		}
+		if result != "Untagged: 2" {
+			t.Errorf("Expected 'Untagged: 2', got %q", result)
+		}
+	})
+}
@@ -0,0 +1,228 @@
+package space
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// userExportManifestName is the manifest's path in an ExportAllUserData archive
+const userExportManifestName = "manifest.json"
+
+// UserExportManifest describes the contents of an ExportAllUserData archive
+type UserExportManifest struct {
+	ExportedAt time.Time       `json:"exported_at"`
+	UserID     string          `json:"user_id"`
+	Spaces     []ExportedSpace `json:"spaces"`
+	Captures   int             `json:"captures"` // Linked capture files included under captures/
+}
+
+// ExportedSpace is a space's entry in a UserExportManifest
+type ExportedSpace struct {
+	ID              string   `json:"id"`
+	Name            string   `json:"name"`
+	Dir             string   `json:"dir"` // Folder holding the space in the archive
+	Notes           int      `json:"notes"`
+	MissingCaptures []string `json:"missing_captures,omitempty"` // Linked note paths with no file to include
+}
+
+// ExportAllUserData writes a zip of everything the user owns to w, streaming
+// it entry by entry so large vaults are never held in memory:
+//
+//	spaces/<dir>/...   each space's folder (SPACE.md, files/, space.sqlite, ...)
+//	captures/...       every capture linked by any of the spaces, once
+//	manifest.json      the spaces exported and what was included
+//
+// space.sqlite is exported from a snapshot, so notes being linked during the
+// export cannot leave it inconsistent. The manifest is written last, once the
+// counts are known. Once the archive is complete each space's last export
+// time is recorded (see GetBackupStatus).
+func (s *Service) ExportAllUserData(ctx context.Context, userID string, w io.Writer) error {
+	if s.dbService == nil {
+		return fmt.Errorf("space database service not configured")
+	}
+
+	spaces, err := s.repo.List(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	manifest := UserExportManifest{ExportedAt: time.Now(), UserID: userID, Spaces: []ExportedSpace{}}
+	captures := make(map[string]bool)
+	dirs := make(map[string]bool)
+
+	for _, sp := range spaces {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		dir := filepath.Base(sp.Path)
+		if dirs[dir] {
+			dir += "-" + sp.ID
+		}
+		dirs[dir] = true
+
+		entry := ExportedSpace{ID: sp.ID, Name: sp.Name, Dir: path.Join("spaces", dir)}
+		if err := addSpaceDir(ctx, zw, sp.Path, entry.Dir); err != nil {
+			return fmt.Errorf("space %s: %w", sp.ID, err)
+		}
+
+		var notePaths []string
+		if _, err := os.Stat(filepath.Join(sp.Path, "space.sqlite")); err == nil {
+			if notePaths, err = linkedNotePaths(sp.Path); err != nil {
+				return fmt.Errorf("space %s: %w", sp.ID, err)
+			}
+		}
+		entry.Notes = len(notePaths)
+
+		for _, notePath := range notePaths {
+			name, ok := s.exportCaptureName(sp.Path, notePath)
+			if !ok {
+				entry.MissingCaptures = append(entry.MissingCaptures, notePath)
+				continue
+			}
+			if captures[name] {
+				continue
+			}
+			if err := addFile(zw, filepath.Join(s.parachuteRoot, filepath.FromSlash(name)), name); err != nil {
+				return fmt.Errorf("space %s: %w", sp.ID, err)
+			}
+			captures[name] = true
+		}
+
+		manifest.Spaces = append(manifest.Spaces, entry)
+	}
+	manifest.Captures = len(captures)
+
+	mw, err := zw.Create(userExportManifestName)
+	if err != nil {
+		return fmt.Errorf("failed to add manifest: %w", err)
+	}
+	enc := json.NewEncoder(mw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	// The archive is complete, so recording it is best effort
+	exportedAt := time.Now()
+	for _, sp := range spaces {
+		_ = s.dbService.RecordExport(sp.ID, sp.Path, exportedAt)
+	}
+	return nil
+}
+
+// exportCaptureName resolves a linked note to its archive path, the file's
+// vault-relative path. It reports false if the file is missing or lies
+// outside the vault.
+func (s *Service) exportCaptureName(spacePath, notePath string) (string, bool) {
+	fullPath, err := s.dbService.ResolveNoteFile(spacePath, notePath)
+	if err != nil {
+		return "", false
+	}
+	if info, err := os.Stat(fullPath); err != nil || !info.Mode().IsRegular() {
+		return "", false
+	}
+
+	rel, err := filepath.Rel(s.parachuteRoot, fullPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// addSpaceDir adds the files under spacePath to the archive below prefix.
+// space.sqlite is replaced by a snapshot and its WAL files are left out.
+func addSpaceDir(ctx context.Context, zw *zip.Writer, spacePath, prefix string) error {
+	return filepath.WalkDir(spacePath, func(fullPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(spacePath, fullPath)
+		if err != nil {
+			return err
+		}
+		name := path.Join(prefix, filepath.ToSlash(rel))
+
+		switch rel {
+		case "space.sqlite":
+			return addDatabaseSnapshot(zw, fullPath, name)
+		case "space.sqlite-wal", "space.sqlite-shm":
+			return nil
+		}
+		return addFile(zw, fullPath, name)
+	})
+}
+
+// addDatabaseSnapshot adds a consistent copy of a SQLite database, taken with
+// VACUUM INTO so writes during the export do not tear it
+func addDatabaseSnapshot(zw *zip.Writer, dbPath, name string) error {
+	tmp, err := os.MkdirTemp("", "parachute-export-*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	snapshot := filepath.Join(tmp, "space.sqlite")
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open space database: %w", err)
+	}
+	_, err = db.Exec("VACUUM INTO ?", snapshot)
+	db.Close()
+	if err != nil {
+		return fmt.Errorf("failed to snapshot space database: %w", err)
+	}
+
+	return addFile(zw, snapshot, name)
+}
+
+// addFile copies one file into the archive, keeping its modification time
+func addFile(zw *zip.Writer, fullPath, name string) error {
+	f, err := os.Open(fullPath)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", name, err)
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+
+	fw, err := zw.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	if _, err := io.Copy(fw, f); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
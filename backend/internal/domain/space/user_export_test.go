@@ -0,0 +1,100 @@
+package space_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+	sqliteStorage "github.com/unforced/parachute-backend/internal/storage/sqlite"
+)
+
+func TestExportAllUserData(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	db, err := sqliteStorage.NewDatabase(filepath.Join(parachuteRoot, "parachute.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	service := space.NewService(sqliteStorage.NewSpaceRepository(db.DB), parachuteRoot)
+	service.SetDatabaseService(dbService)
+
+	sharedID, sharedPath := createNamedCapture(t, parachuteRoot, "shared.md", "In both spaces.\n")
+
+	var created []*space.Space
+	for _, name := range []string{"Garden", "Kitchen"} {
+		sp, err := service.Create(ctx, "default", space.CreateSpaceParams{Name: name})
+		if err != nil {
+			t.Fatalf("Failed to create space %s: %v", name, err)
+		}
+		if err := dbService.InitializeSpaceDatabase(sp.ID, sp.Path); err != nil {
+			t.Fatalf("Failed to initialize space database: %v", err)
+		}
+		if err := dbService.LinkNote(sp.ID, sp.Path, sharedID, sharedPath, "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		created = append(created, sp)
+	}
+	if err := dbService.LinkNote(created[0].ID, created[0].Path, "gone", "captures/gone.md", "", nil); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := service.ExportAllUserData(ctx, "default", &buf); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to read archive: %v", err)
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	mf, ok := files["manifest.json"]
+	if !ok {
+		t.Fatalf("Expected a manifest, got entries %v", files)
+	}
+	rc, err := mf.Open()
+	if err != nil {
+		t.Fatalf("Failed to open manifest: %v", err)
+	}
+	var manifest space.UserExportManifest
+	if err := json.NewDecoder(rc).Decode(&manifest); err != nil {
+		t.Fatalf("Failed to decode manifest: %v", err)
+	}
+	rc.Close()
+
+	if len(manifest.Spaces) != 2 || manifest.Captures != 1 {
+		t.Fatalf("Expected 2 spaces and 1 capture in the manifest, got %+v", manifest)
+	}
+	for _, entry := range manifest.Spaces {
+		for _, name := range []string{"SPACE.md", "space.sqlite"} {
+			if _, ok := files[path.Join(entry.Dir, name)]; !ok {
+				t.Errorf("Expected %s in %s", name, entry.Dir)
+			}
+		}
+	}
+	if _, ok := files["captures/shared.md"]; !ok {
+		t.Errorf("Expected the linked capture in the archive")
+	}
+
+	garden := manifest.Spaces[0]
+	if garden.ID != created[0].ID {
+		garden = manifest.Spaces[1]
+	}
+	if garden.Notes != 2 || len(garden.MissingCaptures) != 1 || garden.MissingCaptures[0] != "captures/gone.md" {
+		t.Errorf("Expected the missing capture to be reported, got %+v", garden)
+	}
+}
@@ -0,0 +1,81 @@
+package space
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SpaceReport is the vault report's entry for one space
+type SpaceReport struct {
+	SpaceID     string   `json:"space_id"`
+	SpaceName   string   `json:"space_name"`
+	Healthy     bool     `json:"healthy"` // Database ok, no broken links and no errors
+	Database    DBHealth `json:"database"`
+	BrokenLinks []string `json:"broken_links"`     // Paths of linked notes whose capture file is missing
+	Errors      []string `json:"errors,omitempty"` // Checks that could not run
+}
+
+// VaultReport is a consolidated health check of every space in a vault
+type VaultReport struct {
+	CheckedAt time.Time     `json:"checked_at"`
+	Healthy   int           `json:"healthy"`
+	Unhealthy int           `json:"unhealthy"`
+	Spaces    []SpaceReport `json:"spaces"`
+}
+
+// ValidateVault checks each of the user's spaces: the database's integrity
+// and schema version (see GetDatabaseHealth) and the linked notes whose
+// capture file is missing. A space whose checks fail is reported with the
+// errors and counted unhealthy; the remaining spaces are still checked.
+func (s *Service) ValidateVault(ctx context.Context, userID string) (VaultReport, error) {
+	report := VaultReport{CheckedAt: time.Now(), Spaces: []SpaceReport{}}
+
+	if s.dbService == nil {
+		return report, fmt.Errorf("space database service not configured")
+	}
+
+	spaces, err := s.repo.List(ctx, userID)
+	if err != nil {
+		return report, err
+	}
+
+	missing := false
+	for _, sp := range spaces {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		entry := SpaceReport{SpaceID: sp.ID, SpaceName: sp.Name, BrokenLinks: []string{}}
+
+		health, err := s.dbService.GetDatabaseHealth(sp.Path)
+		if err != nil {
+			entry.Errors = append(entry.Errors, err.Error())
+			if health.Exists {
+				// A file that cannot even be checked is not a usable database
+				health.Status = DBHealthCorrupt
+			}
+		}
+		entry.Database = health
+
+		if err == nil && health.Exists {
+			notes, err := s.dbService.GetRelevantNotes(sp.Path, NoteFilters{IncludeExpired: true, ExistsOnDisk: &missing})
+			if err != nil {
+				entry.Errors = append(entry.Errors, fmt.Sprintf("failed to check links: %v", err))
+			}
+			for _, note := range notes {
+				entry.BrokenLinks = append(entry.BrokenLinks, note.NotePath)
+			}
+		}
+
+		entry.Healthy = health.Status == DBHealthOK && len(entry.BrokenLinks) == 0 && len(entry.Errors) == 0
+		if entry.Healthy {
+			report.Healthy++
+		} else {
+			report.Unhealthy++
+		}
+		report.Spaces = append(report.Spaces, entry)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,78 @@
+package space_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+	sqliteStorage "github.com/unforced/parachute-backend/internal/storage/sqlite"
+)
+
+func TestValidateVault(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	db, err := sqliteStorage.NewDatabase(filepath.Join(parachuteRoot, "parachute.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	service := space.NewService(sqliteStorage.NewSpaceRepository(db.DB), parachuteRoot)
+	service.SetDatabaseService(dbService)
+
+	create := func(name string) *space.Space {
+		sp, err := service.Create(ctx, "default", space.CreateSpaceParams{Name: name})
+		if err != nil {
+			t.Fatalf("Failed to create space %s: %v", name, err)
+		}
+		if err := dbService.InitializeSpaceDatabase(sp.ID, sp.Path); err != nil {
+			t.Fatalf("Failed to initialize space database: %v", err)
+		}
+		return sp
+	}
+
+	healthy := create("Healthy")
+	captureID, notePath := createNamedCapture(t, parachuteRoot, "present.md", "Here.\n")
+	if err := dbService.LinkNote(healthy.ID, healthy.Path, captureID, notePath, "", nil); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	corrupted := create("Corrupted")
+	if err := os.WriteFile(filepath.Join(corrupted.Path, "space.sqlite"), []byte("this is not a database"), 0644); err != nil {
+		t.Fatalf("Failed to corrupt database: %v", err)
+	}
+
+	broken := create("Broken")
+	if err := dbService.LinkNote(broken.ID, broken.Path, "gone", "captures/gone.md", "", nil); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	report, err := service.ValidateVault(ctx, "default")
+	if err != nil {
+		t.Fatalf("Failed to validate vault: %v", err)
+	}
+
+	if len(report.Spaces) != 3 || report.Healthy != 1 || report.Unhealthy != 2 {
+		t.Fatalf("Expected 1 healthy and 2 unhealthy spaces, got %+v", report)
+	}
+
+	byID := map[string]space.SpaceReport{}
+	for _, entry := range report.Spaces {
+		byID[entry.SpaceID] = entry
+	}
+
+	if entry := byID[healthy.ID]; !entry.Healthy || entry.Database.Status != space.DBHealthOK || len(entry.BrokenLinks) != 0 || len(entry.Errors) != 0 {
+		t.Errorf("Expected the healthy space to be clean, got %+v", entry)
+	}
+	if entry := byID[corrupted.ID]; entry.Healthy || entry.Database.Status != space.DBHealthCorrupt || len(entry.Errors) == 0 {
+		t.Errorf("Expected the corrupted space to be flagged, got %+v", entry)
+	}
+	if entry := byID[broken.ID]; entry.Healthy || len(entry.BrokenLinks) != 1 || entry.BrokenLinks[0] != "captures/gone.md" {
+		t.Errorf("Expected the broken link to be reported, got %+v", entry)
+	}
+}
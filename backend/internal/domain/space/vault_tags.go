@@ -0,0 +1,169 @@
+package space
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Limits on how many tags SuggestTagsVaultWide returns
+const (
+	DefaultTagSuggestionLimit = 10
+	MaxTagSuggestionLimit     = 100
+)
+
+// VaultTagStats is the tag vocabulary used across all of a user's spaces
+type VaultTagStats struct {
+	Tags          []VaultTag `json:"tags"`           // Most used first, ties alphabetical
+	SpacesScanned int        `json:"spaces_scanned"` // Spaces with a database that were read
+}
+
+// VaultTag is one tag's usage across spaces
+type VaultTag struct {
+	Tag    string          `json:"tag"`
+	Count  int             `json:"count"`  // Notes with the tag, summed over spaces
+	Spaces []TagSpaceUsage `json:"spaces"` // Spaces using the tag, most used first
+}
+
+// TagSpaceUsage is how many notes in one space carry a tag
+type TagSpaceUsage struct {
+	SpaceID   string `json:"space_id"`
+	SpaceName string `json:"space_name"`
+	Count     int    `json:"count"`
+}
+
+// GetVaultTagStats counts the tags on linked notes across every space the user
+// has, and which spaces use each one. Spaces without a space.sqlite are
+// skipped. Databases are opened read-only, one at a time.
+func (s *Service) GetVaultTagStats(ctx context.Context, userID string) (VaultTagStats, error) {
+	stats := VaultTagStats{Tags: []VaultTag{}}
+
+	spaces, err := s.repo.List(ctx, userID)
+	if err != nil {
+		return stats, err
+	}
+
+	byTag := make(map[string]*VaultTag)
+	for _, sp := range spaces {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		dbPath := filepath.Join(sp.Path, "space.sqlite")
+		if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+			continue
+		}
+
+		counts, err := countSpaceTags(dbPath)
+		if err != nil {
+			return stats, fmt.Errorf("space %s: %w", sp.ID, err)
+		}
+		stats.SpacesScanned++
+
+		for tag, count := range counts {
+			entry, ok := byTag[tag]
+			if !ok {
+				entry = &VaultTag{Tag: tag}
+				byTag[tag] = entry
+			}
+			entry.Count += count
+			entry.Spaces = append(entry.Spaces, TagSpaceUsage{SpaceID: sp.ID, SpaceName: sp.Name, Count: count})
+		}
+	}
+
+	for _, entry := range byTag {
+		sort.Slice(entry.Spaces, func(i, j int) bool {
+			if entry.Spaces[i].Count != entry.Spaces[j].Count {
+				return entry.Spaces[i].Count > entry.Spaces[j].Count
+			}
+			return entry.Spaces[i].SpaceName < entry.Spaces[j].SpaceName
+		})
+		stats.Tags = append(stats.Tags, *entry)
+	}
+	sort.Slice(stats.Tags, func(i, j int) bool {
+		if stats.Tags[i].Count != stats.Tags[j].Count {
+			return stats.Tags[i].Count > stats.Tags[j].Count
+		}
+		return stats.Tags[i].Tag < stats.Tags[j].Tag
+	})
+
+	return stats, nil
+}
+
+// TagSuggestion is a tag offered for autocomplete, with its usage across the
+// user's spaces
+type TagSuggestion struct {
+	Tag        string `json:"tag"`
+	Count      int    `json:"count"`       // Notes with the tag, summed over spaces
+	SpaceCount int    `json:"space_count"` // Spaces using the tag
+}
+
+// SuggestTagsVaultWide returns the tags used in any of the user's spaces that
+// start with prefix, ignoring case, most used first with ties alphabetical.
+// An empty prefix suggests the most used tags. limit is clamped to
+// MaxTagSuggestionLimit; zero or less means DefaultTagSuggestionLimit.
+func (s *Service) SuggestTagsVaultWide(ctx context.Context, userID, prefix string, limit int) ([]TagSuggestion, error) {
+	if limit <= 0 {
+		limit = DefaultTagSuggestionLimit
+	}
+	if limit > MaxTagSuggestionLimit {
+		limit = MaxTagSuggestionLimit
+	}
+
+	stats, err := s.GetVaultTagStats(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	suggestions := []TagSuggestion{}
+	for _, tag := range stats.Tags {
+		if !strings.HasPrefix(strings.ToLower(tag.Tag), prefix) {
+			continue
+		}
+		suggestions = append(suggestions, TagSuggestion{Tag: tag.Tag, Count: tag.Count, SpaceCount: len(tag.Spaces)})
+		if len(suggestions) == limit {
+			break
+		}
+	}
+
+	return suggestions, nil
+}
+
+// countSpaceTags returns how many notes in a space database carry each tag
+func countSpaceTags(dbPath string) (map[string]int, error) {
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open space database: %w", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT tags FROM relevant_notes")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var tagsJSON sql.NullString
+		if err := rows.Scan(&tagsJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan tags: %w", err)
+		}
+
+		var tags []string
+		if err := json.Unmarshal([]byte(tagsJSON.String), &tags); err != nil {
+			continue
+		}
+		for _, tag := range mergeTags(tags) {
+			counts[tag]++
+		}
+	}
+
+	return counts, rows.Err()
+}
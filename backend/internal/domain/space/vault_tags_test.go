@@ -0,0 +1,154 @@
+package space_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/unforced/parachute-backend/internal/domain/space"
+	sqliteStorage "github.com/unforced/parachute-backend/internal/storage/sqlite"
+)
+
+func TestGetVaultTagStats(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	db, err := sqliteStorage.NewDatabase(filepath.Join(parachuteRoot, "parachute.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	service := space.NewService(sqliteStorage.NewSpaceRepository(db.DB), parachuteRoot)
+
+	create := func(name string, notes map[string][]string) *space.Space {
+		sp, err := service.Create(ctx, "default", space.CreateSpaceParams{Name: name})
+		if err != nil {
+			t.Fatalf("Failed to create space %s: %v", name, err)
+		}
+		if notes == nil {
+			return sp
+		}
+		if err := dbService.InitializeSpaceDatabase(sp.ID, sp.Path); err != nil {
+			t.Fatalf("Failed to initialize space database: %v", err)
+		}
+		for captureID, tags := range notes {
+			if err := dbService.LinkNote(sp.ID, sp.Path, captureID, "captures/"+captureID+".md", "", tags); err != nil {
+				t.Fatalf("Failed to link note: %v", err)
+			}
+		}
+		return sp
+	}
+
+	garden := create("Garden", map[string][]string{
+		"g1": {"soil", "compost"},
+		"g2": {"soil"},
+	})
+	farm := create("Farm", map[string][]string{
+		"f1": {"soil", "tractor"},
+	})
+	create("Empty", nil)
+
+	stats, err := service.GetVaultTagStats(ctx, "default")
+	if err != nil {
+		t.Fatalf("Failed to get vault tag stats: %v", err)
+	}
+
+	if stats.SpacesScanned != 2 {
+		t.Errorf("Expected the uninitialized space to be skipped, scanned %d", stats.SpacesScanned)
+	}
+	if len(stats.Tags) != 3 {
+		t.Fatalf("Expected 3 tags, got %+v", stats.Tags)
+	}
+
+	soil := stats.Tags[0]
+	if soil.Tag != "soil" || soil.Count != 3 || len(soil.Spaces) != 2 {
+		t.Fatalf("Expected soil first with 3 notes in 2 spaces, got %+v", soil)
+	}
+	if soil.Spaces[0].SpaceID != garden.ID || soil.Spaces[0].Count != 2 || soil.Spaces[1].SpaceID != farm.ID || soil.Spaces[1].Count != 1 {
+		t.Errorf("Expected Garden (2) then Farm (1), got %+v", soil.Spaces)
+	}
+
+	// Ties are alphabetical; unique tags map to their one space
+	if stats.Tags[1].Tag != "compost" || stats.Tags[2].Tag != "tractor" {
+		t.Errorf("Expected compost then tractor, got %+v", stats.Tags[1:])
+	}
+	if spaces := stats.Tags[2].Spaces; len(spaces) != 1 || spaces[0].SpaceName != "Farm" {
+		t.Errorf("Expected tractor only in Farm, got %+v", spaces)
+	}
+
+	// Reading never creates or changes a database
+	if _, err := os.Stat(filepath.Join(parachuteRoot, "spaces", "empty", "space.sqlite")); !os.IsNotExist(err) {
+		t.Errorf("Expected no database for the empty space, got %v", err)
+	}
+}
+
+func TestSuggestTagsVaultWide(t *testing.T) {
+	parachuteRoot, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	db, err := sqliteStorage.NewDatabase(filepath.Join(parachuteRoot, "parachute.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	dbService := space.NewSpaceDatabaseService(parachuteRoot)
+	service := space.NewService(sqliteStorage.NewSpaceRepository(db.DB), parachuteRoot)
+
+	spaces := map[string]map[string][]string{
+		"Garden": {"g1": {"soil", "sowing"}, "g2": {"soil"}, "g3": {"Seeds"}},
+		"Farm":   {"f1": {"soil", "tractor"}, "f2": {"sowing"}},
+	}
+	for name, notes := range spaces {
+		sp, err := service.Create(ctx, "default", space.CreateSpaceParams{Name: name})
+		if err != nil {
+			t.Fatalf("Failed to create space %s: %v", name, err)
+		}
+		if err := dbService.InitializeSpaceDatabase(sp.ID, sp.Path); err != nil {
+			t.Fatalf("Failed to initialize space database: %v", err)
+		}
+		for captureID, tags := range notes {
+			if err := dbService.LinkNote(sp.ID, sp.Path, captureID, "captures/"+captureID+".md", "", tags); err != nil {
+				t.Fatalf("Failed to link note: %v", err)
+			}
+		}
+	}
+
+	t.Run("PrefixAcrossSpaces", func(t *testing.T) {
+		suggestions, err := service.SuggestTagsVaultWide(ctx, "default", "so", 10)
+		if err != nil {
+			t.Fatalf("Failed to suggest tags: %v", err)
+		}
+		want := []space.TagSuggestion{
+			{Tag: "soil", Count: 3, SpaceCount: 2},
+			{Tag: "sowing", Count: 2, SpaceCount: 2},
+		}
+		if len(suggestions) != len(want) {
+			t.Fatalf("Expected %v, got %v", want, suggestions)
+		}
+		for i := range want {
+			if suggestions[i] != want[i] {
+				t.Errorf("Expected %+v at %d, got %+v", want[i], i, suggestions[i])
+			}
+		}
+	})
+
+	t.Run("IgnoresCase", func(t *testing.T) {
+		suggestions, _ := service.SuggestTagsVaultWide(ctx, "default", "SE", 10)
+		if len(suggestions) != 1 || suggestions[0].Tag != "Seeds" || suggestions[0].SpaceCount != 1 {
+			t.Errorf("Expected Seeds from one space, got %+v", suggestions)
+		}
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		suggestions, _ := service.SuggestTagsVaultWide(ctx, "default", "", 2)
+		if len(suggestions) != 2 || suggestions[0].Tag != "soil" {
+			t.Errorf("Expected the two most used tags, got %+v", suggestions)
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/unforced/parachute-backend/internal/domain/idempotency"
+)
+
+// IdempotencyStore implements the idempotency.Store interface
+type IdempotencyStore struct {
+	db *sql.DB
+}
+
+// NewIdempotencyStore creates a new idempotency store
+func NewIdempotencyStore(db *sql.DB) *IdempotencyStore {
+	return &IdempotencyStore{db: db}
+}
+
+// Get retrieves a stored response, returning nil if the key is unknown or older than since
+func (s *IdempotencyStore) Get(ctx context.Context, key, method, path string, since time.Time) (*idempotency.Record, error) {
+	record := &idempotency.Record{}
+	var createdAt int64
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT key, method, path, status_code, content_type, body, created_at
+		FROM idempotency_keys
+		WHERE key = ? AND method = ? AND path = ? AND created_at >= ?
+	`, key, method, path, since.Unix()).Scan(
+		&record.Key, &record.Method, &record.Path, &record.StatusCode,
+		&record.ContentType, &record.Body, &createdAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	record.CreatedAt = time.Unix(createdAt, 0)
+	return record, nil
+}
+
+// Save stores the response for a processed key, replacing any expired record
+func (s *IdempotencyStore) Save(ctx context.Context, record *idempotency.Record) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT OR REPLACE INTO idempotency_keys (key, method, path, status_code, content_type, body, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, record.Key, record.Method, record.Path, record.StatusCode,
+		record.ContentType, record.Body, record.CreatedAt.Unix())
+
+	if err != nil {
+		return fmt.Errorf("failed to save idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes records created before the given time
+func (s *IdempotencyStore) DeleteExpired(ctx context.Context, before time.Time) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE created_at < ?", before.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	return nil
+}
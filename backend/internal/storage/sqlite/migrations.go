@@ -124,6 +124,41 @@ ALTER TABLE spaces ADD COLUMN last_accessed INTEGER;
 
 -- Add config column
 ALTER TABLE spaces ADD COLUMN config TEXT DEFAULT '';
+`,
+	},
+	{
+		Version: 4,
+		Name:    "add_idempotency_keys",
+		SQL: `
+-- Processed Idempotency-Key requests and their responses, replayed on retry
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key TEXT NOT NULL,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    status_code INTEGER NOT NULL,
+    content_type TEXT NOT NULL DEFAULT '',
+    body BLOB,
+    created_at INTEGER NOT NULL,
+    PRIMARY KEY (key, method, path)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys(created_at);
+`,
+	},
+	{
+		Version: 5,
+		Name:    "add_space_read_only",
+		SQL: `
+-- Read-only spaces reject note link/update/unlink
+ALTER TABLE spaces ADD COLUMN read_only INTEGER NOT NULL DEFAULT 0;
+`,
+	},
+	{
+		Version: 6,
+		Name:    "add_space_archived",
+		SQL: `
+-- Archived spaces are kept but may be left out of the per-user space limit
+ALTER TABLE spaces ADD COLUMN archived INTEGER NOT NULL DEFAULT 0;
 `,
 	},
 }
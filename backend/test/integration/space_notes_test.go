@@ -1,20 +1,29 @@
 package integration
 
 import (
+	"archive/zip"
 	"bytes"
+	"compress/gzip"
 	"context"
+	"database/sql"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
+	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/etag"
 	"github.com/google/uuid"
 	"github.com/unforced/parachute-backend/internal/api/handlers"
+	"github.com/unforced/parachute-backend/internal/domain/file"
 	"github.com/unforced/parachute-backend/internal/domain/space"
 	sqliteStorage "github.com/unforced/parachute-backend/internal/storage/sqlite"
 )
@@ -26,6 +35,7 @@ type testContext struct {
 	db             *sqliteStorage.Database
 	spaceService   *space.Service
 	spaceDBService *space.SpaceDatabaseService
+	contextService *space.ContextService
 	cleanup        func()
 }
 
@@ -48,23 +58,124 @@ func setupTestApp(t *testing.T) *testContext {
 	spaceRepo := sqliteStorage.NewSpaceRepository(db.DB)
 	spaceService := space.NewService(spaceRepo, tmpDir)
 	spaceDBService := space.NewSpaceDatabaseService(tmpDir)
+	spaceDBService.SetSpaceRepository(spaceRepo)
+	spaceService.SetDatabaseService(spaceDBService)
+	contextService := space.NewContextService(spaceDBService)
+	fileService, err := file.NewService(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create file service: %v", err)
+	}
+	idempotencyStore := sqliteStorage.NewIdempotencyStore(db.DB)
 
 	// Create handlers
+	spaceHandler := handlers.NewSpaceHandler(spaceService)
 	spaceNotesHandler := handlers.NewSpaceNotesHandler(spaceService, spaceDBService)
+	spaceContextHandler := handlers.NewSpaceContextHandler(spaceService, spaceDBService, contextService)
+	spaceSettingsHandler := handlers.NewSpaceSettingsHandler(spaceService, spaceDBService)
+	spaceSavedSearchHandler := handlers.NewSpaceSavedSearchHandler(spaceService, spaceDBService)
+	spaceCollectionHandler := handlers.NewSpaceCollectionHandler(spaceService, spaceDBService)
+	fileHandler := handlers.NewFileHandler(fileService)
+	adminHandler := handlers.NewAdminHandler(spaceService, spaceDBService, space.DefaultTrashRetention)
+	idempotent := handlers.Idempotency(idempotencyStore, handlers.DefaultIdempotencyTTL)
+	compressed := handlers.Compression(handlers.DefaultCompressionMinSize)
+	etagged := etag.New()
 
 	// Create Fiber app
 	app := fiber.New()
 
 	// Register routes
 	api := app.Group("/api")
+	api.Get("/tags/vault", spaceHandler.GetVaultTagStats)
+	api.Get("/tags/suggest", spaceHandler.SuggestTags)
+	api.Get("/export/all", spaceHandler.ExportAllUserData)
+	api.Get("/context/variables", spaceContextHandler.ListSupportedVariables)
+	api.Post("/admin/purge-trash", adminHandler.PurgeTrash)
+	api.Get("/admin/vault-report", adminHandler.VaultReport)
 	spaces := api.Group("/spaces")
-	spaces.Get("/:id/notes", spaceNotesHandler.GetNotes)
-	spaces.Post("/:id/notes", spaceNotesHandler.LinkNote)
+	spaces.Post("/", spaceHandler.Create)
+	spaces.Get("/stale-backups", spaceHandler.ListStaleBackups)
+	spaces.Post("/:id/files/move", spaceHandler.MoveFile)
+	spaces.Post("/:id/files/copy", spaceHandler.CopyFile)
+	spaces.Get("/:id/notes", spaceNotesHandler.GetNotes, compressed, etagged)
+	spaces.Get("/:id/notes/grouped", spaceNotesHandler.GetNotesGroupedByTag, compressed, etagged)
+	spaces.Get("/:id/notes/histogram", spaceNotesHandler.GetNoteHistogram)
+	spaces.Get("/:id/notes/untagged", spaceNotesHandler.GetUntaggedNotes)
+	spaces.Get("/:id/notes/duplicates", spaceNotesHandler.FindNearDuplicates)
+	spaces.Get("/:id/tags/tree", spaceNotesHandler.GetTagTree)
+	spaces.Get("/:id/tags/:tag/timeline", spaceNotesHandler.GetTagTimeline)
+	spaces.Get("/:id/analytics/references", spaceNotesHandler.GetReferenceAnalytics)
+	spaces.Get("/:id/diff", spaceNotesHandler.DiffSpaces)
+	spaces.Get("/:id/featured-notes", spaceNotesHandler.GetFeaturedNotes)
+	spaces.Put("/:id/featured-notes", spaceNotesHandler.SetFeaturedNotes)
+	spaces.Post("/:id/notes", spaceNotesHandler.LinkNote, idempotent)
+	spaces.Post("/:id/captures", spaceNotesHandler.CreateCapture, idempotent)
+	spaces.Post("/:id/notes/batch-get", spaceNotesHandler.BatchGetNotes, compressed)
+	spaces.Post("/:id/notes/from-captures", spaceNotesHandler.LinkFromCaptures)
+	spaces.Post("/:id/notes/bulk-tags", spaceNotesHandler.BulkUpdateTags)
+	spaces.Post("/:id/notes/bulk-unlink", spaceNotesHandler.BulkUnlink)
+	spaces.Post("/:id/notes/decay", spaceNotesHandler.DecayRelevance)
 	spaces.Put("/:id/notes/:capture_id", spaceNotesHandler.UpdateNoteContext)
 	spaces.Delete("/:id/notes/:capture_id", spaceNotesHandler.UnlinkNote)
-	spaces.Get("/:id/notes/:capture_id/content", spaceNotesHandler.GetNoteContent)
+	spaces.Put("/:id/notes/:capture_id/status", spaceNotesHandler.SetNoteStatus)
+	spaces.Put("/:id/notes/:capture_id/due", spaceNotesHandler.SetNoteDue)
+	spaces.Put("/:id/notes/:capture_id/expiry", spaceNotesHandler.SetNoteExpiry)
+	spaces.Get("/:id/notes/:capture_id/thread", spaceNotesHandler.GetNoteThread)
+	spaces.Post("/:id/notes/:capture_id/move", spaceNotesHandler.MoveNote)
+	spaces.Put("/:id/notes/:capture_id/priority", spaceNotesHandler.SetNotePriority)
+	spaces.Put("/:id/notes/:capture_id/reminder", spaceNotesHandler.SetReminder)
+	spaces.Delete("/:id/notes/:capture_id/reminder", spaceNotesHandler.DismissReminder)
+	spaces.Get("/:id/notes/:capture_id/content", spaceNotesHandler.GetNoteContent, compressed, etagged)
+	spaces.Get("/:id/notes/:capture_id/structure", spaceNotesHandler.GetNoteStructure)
+	spaces.Get("/:id/notes/:capture_id/chunks", spaceNotesHandler.GetNoteChunks)
+	spaces.Get("/:id/notes/:capture_id/explain", spaceNotesHandler.ExplainLink)
+	spaces.Get("/:id/notes/:capture_id/attachments", spaceNotesHandler.GetNoteAttachments)
+	spaces.Get("/:id/export/markdown", spaceNotesHandler.ExportNotesMarkdown, compressed)
 	spaces.Get("/:id/database/stats", spaceNotesHandler.GetDatabaseStats)
+	spaces.Get("/:id/database/health", spaceNotesHandler.GetDatabaseHealth)
+	spaces.Get("/:id/database/status", spaceNotesHandler.GetDatabaseStatus)
+	spaces.Post("/:id/database/recompute", spaceNotesHandler.RecomputeDatabase)
+	spaces.Post("/:id/database/reset", spaceNotesHandler.ResetDatabase)
+	spaces.Post("/:id/reindex", spaceNotesHandler.StartReindex)
+	spaces.Get("/:id/reindex/:job_id", spaceNotesHandler.GetReindexStatus)
+	spaces.Delete("/:id/reindex/:job_id", spaceNotesHandler.CancelReindex)
+	spaces.Post("/:id/manifest", spaceNotesHandler.ExportManifest)
 	spaces.Get("/:id/database/tables/:table_name", spaceNotesHandler.GetTableData)
+	spaces.Get("/:id/context/estimate", spaceContextHandler.EstimateTokens)
+	spaces.Get("/:id/context/version", spaceContextHandler.GetContextVersion)
+	spaces.Get("/:id/context/variables", spaceContextHandler.PreviewVariables)
+	spaces.Post("/:id/context/preview", spaceContextHandler.PreviewContext)
+	spaces.Get("/:id/context/history", spaceContextHandler.GetContextHistory)
+	spaces.Post("/:id/context/freeze", spaceContextHandler.FreezeContext)
+	spaces.Delete("/:id/context/freeze", spaceContextHandler.UnfreezeContext)
+	spaces.Get("/:id/notes/:capture_id/suggest-context", spaceContextHandler.SuggestContext)
+	spaces.Get("/:id/settings", spaceSettingsHandler.GetSettings)
+	spaces.Get("/:id/settings/bulk", spaceSettingsHandler.ExportSettings)
+	spaces.Put("/:id/settings/bulk", spaceSettingsHandler.ImportSettings) // Before :key so "bulk" is not taken as a key
+	spaces.Put("/:id/settings/:key", spaceSettingsHandler.SetSetting)
+	spaces.Get("/:id/export/template", spaceSettingsHandler.ExportTemplate)
+	spaces.Get("/:id/pinned-context", spaceSettingsHandler.GetPinnedContext)
+	spaces.Put("/:id/pinned-context", spaceSettingsHandler.SetPinnedContext)
+	spaces.Get("/:id/saved-searches", spaceSavedSearchHandler.ListSavedSearches)
+	spaces.Get("/:id/saved-searches/:name", spaceSavedSearchHandler.GetSavedSearch)
+	spaces.Put("/:id/saved-searches/:name", spaceSavedSearchHandler.SaveSearch)
+	spaces.Delete("/:id/saved-searches/:name", spaceSavedSearchHandler.DeleteSavedSearch)
+	spaces.Get("/:id/saved-searches/:name/notes", spaceSavedSearchHandler.RunSavedSearch)
+	spaces.Get("/:id/saved-searches/:name/export", spaceSavedSearchHandler.ExportSavedSearch)
+	spaces.Get("/:id/collections", spaceCollectionHandler.ListCollections)
+	spaces.Post("/:id/collections", spaceCollectionHandler.CreateCollection)
+	spaces.Get("/:id/collections/:collection_id", spaceCollectionHandler.GetCollection)
+	spaces.Put("/:id/collections/:collection_id", spaceCollectionHandler.RenameCollection)
+	spaces.Delete("/:id/collections/:collection_id", spaceCollectionHandler.DeleteCollection)
+	spaces.Get("/:id/collections/:collection_id/notes", spaceCollectionHandler.GetCollectionNotes)
+	spaces.Post("/:id/collections/:collection_id/notes", spaceCollectionHandler.AddToCollection)
+	spaces.Put("/:id/collections/:collection_id/notes", spaceCollectionHandler.ReorderCollection)
+	spaces.Delete("/:id/collections/:collection_id/notes/:capture_id", spaceCollectionHandler.RemoveFromCollection)
+	captures := api.Group("/captures")
+	captures.Post("/upload", fileHandler.UploadCapture, idempotent)
+	captures.Get("/", fileHandler.ListCaptures)
+	captures.Post("/:capture_id/rename", spaceHandler.RenameCapture)
+	captures.Get("/search", spaceHandler.SearchCaptures)
+	captures.Get("/unlinked", spaceHandler.ListUnlinkedCaptures)
 
 	cleanup := func() {
 		db.Close()
@@ -77,6 +188,7 @@ func setupTestApp(t *testing.T) *testContext {
 		db:             db,
 		spaceService:   spaceService,
 		spaceDBService: spaceDBService,
+		contextService: contextService,
 		cleanup:        cleanup,
 	}
 }
@@ -220,6 +332,48 @@ func TestLinkNoteEndpoint(t *testing.T) {
 		}
 	})
 
+	t.Run("ErrorMalformedJSON", func(t *testing.T) {
+		for _, body := range []string{`{"capture_id": "abc", "note_path"`, `{"capture_id": 42}`, `not json`} {
+			req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/notes", spaceID), strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := ctx.app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+
+			if resp.StatusCode != fiber.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", body, resp.StatusCode)
+			}
+
+			var result map[string]interface{}
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				t.Fatalf("%s: expected a JSON error body: %v", body, err)
+			}
+			if result["error"] != "invalid JSON body" {
+				t.Errorf("%s: expected invalid JSON body error, got %v", body, result["error"])
+			}
+			if detail, _ := result["detail"].(string); detail == "" {
+				t.Errorf("%s: expected decoder detail, got %v", body, result)
+			}
+		}
+	})
+
+	t.Run("MissingFieldIsNotMalformed", func(t *testing.T) {
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/notes", spaceID), strings.NewReader(`{"note_path": "x.md"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != fiber.StatusBadRequest || strings.Contains(string(body), "invalid JSON body") {
+			t.Errorf("Expected a validation 400 rather than a malformed body error, got %d %s", resp.StatusCode, body)
+		}
+	})
+
 	t.Run("ErrorInvalidSpaceID", func(t *testing.T) {
 		reqBody := map[string]interface{}{
 			"capture_id": captureID,
@@ -275,6 +429,60 @@ func TestLinkNoteEndpoint(t *testing.T) {
 			t.Errorf("Expected 2 notes total, got %d", len(notes))
 		}
 	})
+
+	t.Run("PathOutsideVault", func(t *testing.T) {
+		reqBody := handlers.LinkNoteRequest{
+			CaptureID: uuid.New().String(),
+			NotePath:  "../outside.md",
+		}
+
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/notes", spaceID), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestCreateCaptureEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, _ := createTestSpace(t, ctx)
+
+	create := func(body string) (*http.Response, space.CaptureCreation) {
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/captures", spaceID), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		var result space.CaptureCreation
+		json.NewDecoder(resp.Body).Decode(&result)
+		return resp, result
+	}
+
+	body := `{"content": "Seed order for spring.", "context": "Garden", "reuse_identical": true}`
+	resp, first := create(body)
+	if resp.StatusCode != fiber.StatusCreated || !first.Created {
+		t.Fatalf("Expected status 201 and a new capture, got %d %+v", resp.StatusCode, first)
+	}
+
+	resp, second := create(body)
+	if resp.StatusCode != fiber.StatusOK || second.Created || second.CaptureID != first.CaptureID {
+		t.Errorf("Expected status 200 reusing %s, got %d %+v", first.CaptureID, resp.StatusCode, second)
+	}
+
+	if resp, _ := create(`{"content": ""}`); resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("Expected status 400 for empty content, got %d", resp.StatusCode)
+	}
 }
 
 func TestGetNotesEndpoint(t *testing.T) {
@@ -354,6 +562,24 @@ func TestGetNotesEndpoint(t *testing.T) {
 		}
 	})
 
+	t.Run("FilterByContextText", func(t *testing.T) {
+		for query, want := range map[string]int{"context_q=context&tags=tag3": 2, "context_q=pruning": 0} {
+			req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes?%s", spaceID, query), nil)
+			resp, err := ctx.app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+
+			var result map[string]interface{}
+			json.NewDecoder(resp.Body).Decode(&result)
+
+			notes := result["notes"].([]interface{})
+			if len(notes) != want {
+				t.Errorf("%s: expected %d notes, got %d", query, want, len(notes))
+			}
+		}
+	})
+
 	t.Run("Pagination", func(t *testing.T) {
 		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes?limit=2&offset=0", spaceID), nil)
 		resp, err := ctx.app.Test(req)
@@ -401,370 +627,4123 @@ func TestGetNotesEndpoint(t *testing.T) {
 	})
 }
 
-func TestUpdateNoteContextEndpoint(t *testing.T) {
+func TestGetNotesIncludeTitle(t *testing.T) {
 	ctx := setupTestApp(t)
 	defer ctx.cleanup()
 
 	spaceID, spacePath := createTestSpace(t, ctx)
-	captureID, notePath := createTestCapture(t, ctx.tmpDir, "Test capture")
-
-	// Link initial note
-	ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, "Original context", []string{"original"})
-
-	t.Run("UpdateContextOnly", func(t *testing.T) {
-		reqBody := map[string]interface{}{
-			"context": "Updated context only",
-		}
-
-		body, _ := json.Marshal(reqBody)
-		req := httptest.NewRequest("PUT",
-			fmt.Sprintf("/api/spaces/%s/notes/%s", spaceID, captureID),
-			bytes.NewReader(body))
-		req.Header.Set("Content-Type", "application/json")
+	captureID, notePath := createTestCapture(t, ctx.tmpDir, "---\ntitle: Soil test\n---\nResults came back.")
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, "", nil)
 
+	getNotes := func(t *testing.T, query string) handlers.GetNotesResponse {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes%s", spaceID, query), nil)
 		resp, err := ctx.app.Test(req)
 		if err != nil {
 			t.Fatalf("Request failed: %v", err)
 		}
 
-		if resp.StatusCode != fiber.StatusOK {
-			t.Errorf("Expected status 200, got %d", resp.StatusCode)
+		var result handlers.GetNotesResponse
+		json.NewDecoder(resp.Body).Decode(&result)
+		if result.Total != 1 {
+			t.Fatalf("Expected 1 note, got %d", result.Total)
+		}
+		return result
+	}
+
+	t.Run("OmittedByDefault", func(t *testing.T) {
+		if title := getNotes(t, "").Notes[0].Title; title != "" {
+			t.Errorf("Expected no title without include=title, got %q", title)
 		}
 	})
 
-	t.Run("UpdateTagsOnly", func(t *testing.T) {
-		reqBody := map[string]interface{}{
-			"tags": []string{"new", "tags"},
+	t.Run("IncludedOnRequest", func(t *testing.T) {
+		if title := getNotes(t, "?include=title").Notes[0].Title; title != "Soil test" {
+			t.Errorf("Expected title from frontmatter, got %q", title)
 		}
+	})
+}
 
-		body, _ := json.Marshal(reqBody)
-		req := httptest.NewRequest("PUT",
-			fmt.Sprintf("/api/spaces/%s/notes/%s", spaceID, captureID),
-			bytes.NewReader(body))
-		req.Header.Set("Content-Type", "application/json")
+func TestGetNotesExistsFilter(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+
+	capturesDir := filepath.Join(ctx.tmpDir, "captures")
+	os.MkdirAll(capturesDir, 0755)
+	os.WriteFile(filepath.Join(capturesDir, "present.md"), []byte("Here"), 0644)
+
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, "present", "captures/present.md", "", nil)
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, "broken", "captures/broken.md", "", nil)
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes?exists=false", spaceID), nil)
+	resp, err := ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+
+	var result handlers.GetNotesResponse
+	json.NewDecoder(resp.Body).Decode(&result)
 
+	if len(result.Notes) != 1 || result.Notes[0].CaptureID != "broken" {
+		t.Errorf("Expected only the broken link, got %+v", result.Notes)
+	}
+}
+
+func TestGetNotesCompression(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+
+	// Enough notes to push the listing well past the compression threshold
+	for i := 0; i < 50; i++ {
+		context := fmt.Sprintf("Note %d about soil, seeds, and watering schedules", i)
+		ctx.spaceDBService.LinkNote(spaceID, spacePath, uuid.New().String(), fmt.Sprintf("captures/note-%d.md", i), context, []string{"garden"})
+	}
+	url := fmt.Sprintf("/api/spaces/%s/notes?limit=50", spaceID)
+
+	var gzipETag string
+
+	t.Run("GzipWhenAccepted", func(t *testing.T) {
+		req := httptest.NewRequest("GET", url, nil)
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
 		resp, err := ctx.app.Test(req)
 		if err != nil {
 			t.Fatalf("Request failed: %v", err)
 		}
 
-		if resp.StatusCode != fiber.StatusOK {
-			t.Errorf("Expected status 200, got %d", resp.StatusCode)
+		if resp.StatusCode != 200 {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
 		}
-	})
-
-	t.Run("UpdateBoth", func(t *testing.T) {
-		reqBody := map[string]interface{}{
-			"context": "Both updated",
-			"tags":    []string{"both"},
+		if enc := resp.Header.Get("Content-Encoding"); enc != "gzip" {
+			t.Fatalf("Expected gzip Content-Encoding, got %q", enc)
+		}
+		if vary := resp.Header.Get("Vary"); vary != "Accept-Encoding" {
+			t.Errorf("Expected Vary: Accept-Encoding, got %q", vary)
+		}
+		gzipETag = resp.Header.Get("ETag")
+		if !strings.HasSuffix(gzipETag, `-gzip"`) {
+			t.Errorf("Expected encoding-aware ETag, got %q", gzipETag)
 		}
 
-		body, _ := json.Marshal(reqBody)
-		req := httptest.NewRequest("PUT",
-			fmt.Sprintf("/api/spaces/%s/notes/%s", spaceID, captureID),
-			bytes.NewReader(body))
-		req.Header.Set("Content-Type", "application/json")
+		reader, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			t.Fatalf("Failed to open gzip body: %v", err)
+		}
+		var result handlers.GetNotesResponse
+		if err := json.NewDecoder(reader).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode compressed body: %v", err)
+		}
+		if len(result.Notes) != 50 {
+			t.Errorf("Expected 50 notes, got %d", len(result.Notes))
+		}
+	})
 
+	t.Run("IdentityWithoutAcceptEncoding", func(t *testing.T) {
+		req := httptest.NewRequest("GET", url, nil)
 		resp, err := ctx.app.Test(req)
 		if err != nil {
 			t.Fatalf("Request failed: %v", err)
 		}
 
-		if resp.StatusCode != fiber.StatusOK {
-			t.Errorf("Expected status 200, got %d", resp.StatusCode)
+		if enc := resp.Header.Get("Content-Encoding"); enc != "" {
+			t.Errorf("Expected no Content-Encoding, got %q", enc)
 		}
-	})
-
-	t.Run("ErrorNoteNotFound", func(t *testing.T) {
-		nonExistentID := uuid.New().String()
-		reqBody := map[string]interface{}{
-			"context": "Should fail",
+		if etag := resp.Header.Get("ETag"); etag == "" || etag == gzipETag {
+			t.Errorf("Expected an ETag distinct from the gzip one, got %q", etag)
 		}
 
-		body, _ := json.Marshal(reqBody)
-		req := httptest.NewRequest("PUT",
-			fmt.Sprintf("/api/spaces/%s/notes/%s", spaceID, nonExistentID),
-			bytes.NewReader(body))
-		req.Header.Set("Content-Type", "application/json")
+		var result handlers.GetNotesResponse
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode body: %v", err)
+		}
+		if len(result.Notes) != 50 {
+			t.Errorf("Expected 50 notes, got %d", len(result.Notes))
+		}
+	})
 
+	t.Run("NotModifiedWithGzipETag", func(t *testing.T) {
+		req := httptest.NewRequest("GET", url, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		req.Header.Set("If-None-Match", gzipETag)
 		resp, err := ctx.app.Test(req)
 		if err != nil {
 			t.Fatalf("Request failed: %v", err)
 		}
 
-		if resp.StatusCode != fiber.StatusNotFound {
-			t.Errorf("Expected status 404, got %d", resp.StatusCode)
+		if resp.StatusCode != 304 {
+			t.Fatalf("Expected status 304, got %d", resp.StatusCode)
+		}
+		if etag := resp.Header.Get("ETag"); etag != gzipETag {
+			t.Errorf("Expected ETag %q on 304, got %q", gzipETag, etag)
 		}
 	})
 
-	t.Run("ErrorNoFieldsProvided", func(t *testing.T) {
-		reqBody := map[string]interface{}{}
-
-		body, _ := json.Marshal(reqBody)
-		req := httptest.NewRequest("PUT",
-			fmt.Sprintf("/api/spaces/%s/notes/%s", spaceID, captureID),
-			bytes.NewReader(body))
-		req.Header.Set("Content-Type", "application/json")
-
+	t.Run("SmallResponsesUncompressed", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes?limit=1", spaceID), nil)
+		req.Header.Set("Accept-Encoding", "gzip")
 		resp, err := ctx.app.Test(req)
 		if err != nil {
 			t.Fatalf("Request failed: %v", err)
 		}
 
-		if resp.StatusCode != fiber.StatusBadRequest {
-			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		if enc := resp.Header.Get("Content-Encoding"); enc != "" {
+			t.Errorf("Expected small response to stay uncompressed, got %q", enc)
 		}
 	})
 }
 
-func TestUnlinkNoteEndpoint(t *testing.T) {
+func TestRelativeLinkDateFilters(t *testing.T) {
 	ctx := setupTestApp(t)
 	defer ctx.cleanup()
 
 	spaceID, spacePath := createTestSpace(t, ctx)
-	captureID, notePath := createTestCapture(t, ctx.tmpDir, "Test capture")
-
-	// Link note
-	ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, "Context", []string{"tag"})
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, "recent", "captures/recent.md", "", nil)
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, "old", "captures/old.md", "", nil)
 
-	t.Run("SuccessfulUnlink", func(t *testing.T) {
-		req := httptest.NewRequest("DELETE",
-			fmt.Sprintf("/api/spaces/%s/notes/%s", spaceID, captureID),
-			nil)
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to open space database: %v", err)
+	}
+	monthAgo := time.Now().AddDate(0, 0, -30).Unix()
+	if _, err := db.Exec("UPDATE relevant_notes SET linked_at = ? WHERE capture_id = 'old'", monthAgo); err != nil {
+		t.Fatalf("Failed to age note: %v", err)
+	}
+	db.Close()
 
+	getNotes := func(query string) (*http.Response, []space.RelevantNote) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes?%s", spaceID, query), nil)
 		resp, err := ctx.app.Test(req)
 		if err != nil {
 			t.Fatalf("Request failed: %v", err)
 		}
+		var result handlers.GetNotesResponse
+		json.NewDecoder(resp.Body).Decode(&result)
+		return resp, result.Notes
+	}
 
+	t.Run("LinkedWithinWeek", func(t *testing.T) {
+		resp, notes := getNotes("linked_within=7d")
 		if resp.StatusCode != fiber.StatusOK {
-			t.Errorf("Expected status 200, got %d", resp.StatusCode)
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
 		}
-
-		// Verify note is gone
-		getReq := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes", spaceID), nil)
-		getResp, _ := ctx.app.Test(getReq)
-
-		var result map[string]interface{}
-		json.NewDecoder(getResp.Body).Decode(&result)
-
-		notes := result["notes"].([]interface{})
-		if len(notes) != 0 {
-			t.Errorf("Expected 0 notes after unlink, got %d", len(notes))
+		if len(notes) != 1 || notes[0].CaptureID != "recent" {
+			t.Errorf("Expected only the recent note, got %+v", notes)
 		}
 	})
 
-	t.Run("ErrorNoteNotFound", func(t *testing.T) {
-		nonExistentID := uuid.New().String()
-		req := httptest.NewRequest("DELETE",
-			fmt.Sprintf("/api/spaces/%s/notes/%s", spaceID, nonExistentID),
-			nil)
-
-		resp, err := ctx.app.Test(req)
-		if err != nil {
-			t.Fatalf("Request failed: %v", err)
+	t.Run("LinkedBeforeKeyword", func(t *testing.T) {
+		_, notes := getNotes("linked_before=yesterday")
+		if len(notes) != 1 || notes[0].CaptureID != "old" {
+			t.Errorf("Expected only the old note, got %+v", notes)
 		}
+	})
 
-		if resp.StatusCode != fiber.StatusNotFound {
-			t.Errorf("Expected status 404, got %d", resp.StatusCode)
+	t.Run("InvalidExpression", func(t *testing.T) {
+		for _, query := range []string{"linked_within=soon", "linked_after=7x", "linked_before=-2d"} {
+			if resp, _ := getNotes(query); resp.StatusCode != fiber.StatusBadRequest {
+				t.Errorf("Expected status 400 for %s, got %d", query, resp.StatusCode)
+			}
 		}
 	})
 }
 
-func TestGetNoteContentEndpoint(t *testing.T) {
+func TestGetNoteHistogramEndpoint(t *testing.T) {
 	ctx := setupTestApp(t)
 	defer ctx.cleanup()
 
 	spaceID, spacePath := createTestSpace(t, ctx)
-	captureContent := "# Test Capture\n\nThis is the content of the capture."
-	captureID, notePath := createTestCapture(t, ctx.tmpDir, captureContent)
-
-	// Link note
-	contextText := "Space-specific context"
-	tags := []string{"test", "content"}
-	ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, contextText, tags)
+	for _, file := range []string{"2024-03-04_09-00-00.md", "2024-03-21_07-15-00.md"} {
+		ctx.spaceDBService.LinkNote(spaceID, spacePath, uuid.New().String(), filepath.Join("captures", file), "", nil)
+	}
 
-	t.Run("SuccessfulGetContent", func(t *testing.T) {
+	t.Run("WeeklyZeroFilled", func(t *testing.T) {
 		req := httptest.NewRequest("GET",
-			fmt.Sprintf("/api/spaces/%s/notes/%s/content", spaceID, captureID),
-			nil)
-
+			fmt.Sprintf("/api/spaces/%s/notes/histogram?bucket=week&field=captured_at", spaceID), nil)
 		resp, err := ctx.app.Test(req)
 		if err != nil {
 			t.Fatalf("Request failed: %v", err)
 		}
-
 		if resp.StatusCode != fiber.StatusOK {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			t.Errorf("Expected status 200, got %d. Body: %s", resp.StatusCode, string(bodyBytes))
-		}
-
-		var result map[string]interface{}
-		json.NewDecoder(resp.Body).Decode(&result)
-
-		if result["content"] != captureContent {
-			t.Errorf("Expected content %s, got %v", captureContent, result["content"])
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
 		}
 
-		if result["space_context"] != contextText {
-			t.Errorf("Expected space_context %s, got %v", contextText, result["space_context"])
+		var result struct {
+			Buckets []space.HistogramBucket `json:"buckets"`
 		}
+		json.NewDecoder(resp.Body).Decode(&result)
 
-		if result["capture_id"] != captureID {
-			t.Errorf("Expected capture_id %s, got %v", captureID, result["capture_id"])
+		counts := []int{}
+		for _, b := range result.Buckets {
+			counts = append(counts, b.Count)
 		}
-
-		// Verify tags
-		resultTags := result["tags"].([]interface{})
-		if len(resultTags) != 2 {
-			t.Errorf("Expected 2 tags, got %d", len(resultTags))
+		if fmt.Sprint(counts) != "[1 0 1]" {
+			t.Errorf("Expected weekly counts [1 0 1], got %v", counts)
 		}
 	})
 
-	t.Run("ErrorNoteNotFound", func(t *testing.T) {
-		nonExistentID := uuid.New().String()
-		req := httptest.NewRequest("GET",
-			fmt.Sprintf("/api/spaces/%s/notes/%s/content", spaceID, nonExistentID),
-			nil)
-
+	t.Run("InvalidBucket", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes/histogram?bucket=year", spaceID), nil)
 		resp, err := ctx.app.Test(req)
 		if err != nil {
 			t.Fatalf("Request failed: %v", err)
 		}
-
-		if resp.StatusCode != fiber.StatusNotFound {
-			t.Errorf("Expected status 404, got %d", resp.StatusCode)
-		}
-	})
-
-	t.Run("LastReferencedTracking", func(t *testing.T) {
-		// Get the note (which should track the reference)
-		req := httptest.NewRequest("GET",
-			fmt.Sprintf("/api/spaces/%s/notes/%s/content", spaceID, captureID),
-			nil)
-		ctx.app.Test(req)
-
-		// Get the note metadata to check last_referenced was set
-		note, _ := ctx.spaceDBService.GetNoteByID(spacePath, captureID)
-		if note.LastReferenced == nil {
-			t.Error("Expected last_referenced to be set after getting content")
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
 		}
 	})
 }
 
-func TestGetDatabaseStatsEndpoint(t *testing.T) {
+func TestGetTagTimelineEndpoint(t *testing.T) {
 	ctx := setupTestApp(t)
 	defer ctx.cleanup()
 
 	spaceID, spacePath := createTestSpace(t, ctx)
+	for _, tags := range [][]string{{"project/alpha"}, {"project/alpha", "garden"}, {"garden"}} {
+		ctx.spaceDBService.LinkNote(spaceID, spacePath, uuid.New().String(), "captures/note.md", "", tags)
+	}
 
-	// Link some notes
-	for i := 0; i < 3; i++ {
-		captureID, notePath := createTestCapture(t, ctx.tmpDir, fmt.Sprintf("Capture %d", i))
-		ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, "Context", []string{"tag1", "tag2"})
+	t.Run("EscapedNestedTag", func(t *testing.T) {
+		req := httptest.NewRequest("GET",
+			fmt.Sprintf("/api/spaces/%s/tags/project%%2Falpha/timeline?bucket=month", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var result struct {
+			Tag     string                  `json:"tag"`
+			Bucket  string                  `json:"bucket"`
+			Buckets []space.HistogramBucket `json:"buckets"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+
+		if result.Tag != "project/alpha" || result.Bucket != "month" {
+			t.Errorf("Expected project/alpha by month, got %q by %q", result.Tag, result.Bucket)
+		}
+		if len(result.Buckets) != 1 || result.Buckets[0].Count != 2 {
+			t.Errorf("Expected one month with 2 notes, got %+v", result.Buckets)
+		}
+	})
+
+	t.Run("InvalidBucket", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/tags/garden/timeline?bucket=year", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestGetReferenceAnalyticsEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, "soil", "captures/soil.md", "", nil)
+	ctx.spaceDBService.TrackNoteReference(spacePath, "soil")
+	ctx.spaceDBService.TrackNoteReference(spacePath, "soil")
+
+	get := func(query string) *http.Response {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/analytics/references%s", spaceID, query), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp
 	}
 
-	t.Run("GetStats", func(t *testing.T) {
-		req := httptest.NewRequest("GET",
-			fmt.Sprintf("/api/spaces/%s/database/stats", spaceID),
-			nil)
+	t.Run("LastWeek", func(t *testing.T) {
+		resp := get("?bucket=day&from=6d")
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var series space.AnalyticsSeries
+		json.NewDecoder(resp.Body).Decode(&series)
+		if len(series.Buckets) != 7 || series.Buckets[6].Count != 2 || series.Total != 2 {
+			t.Errorf("Expected 7 daily buckets ending with today's 2 references, got %+v", series)
+		}
+		if len(series.TopNotes) != 1 || series.TopNotes[0].CaptureID != "soil" {
+			t.Errorf("Expected soil as the top note, got %+v", series.TopNotes)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		if resp := get("?bucket=hour"); resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400 for an unknown bucket, got %d", resp.StatusCode)
+		}
+		if resp := get("?from=someday"); resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400 for an invalid from, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestResetDatabaseEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, "walk", "captures/walk.md", "", nil)
+
+	req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/database/reset", spaceID), nil)
+	resp, err := ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Backup string `json:"backup"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if _, err := os.Stat(filepath.Join(spacePath, result.Backup)); result.Backup == "" || err != nil {
+		t.Errorf("Expected backup file in the space directory, got %q (%v)", result.Backup, err)
+	}
 
+	notes, err := ctx.spaceDBService.GetRelevantNotes(spacePath, space.NoteFilters{})
+	if err != nil || len(notes) != 0 {
+		t.Errorf("Expected no notes after reset, got %d (%v)", len(notes), err)
+	}
+}
+
+func TestReindexEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	for _, content := range []string{"First note", "Second note here"} {
+		captureID, notePath := createTestCapture(t, ctx.tmpDir, content)
+		ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, "", nil)
+	}
+
+	req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/reindex", spaceID), nil)
+	resp, err := ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d", resp.StatusCode)
+	}
+	var started struct {
+		JobID string `json:"job_id"`
+	}
+	json.NewDecoder(resp.Body).Decode(&started)
+
+	t.Run("ProgressesToCompletion", func(t *testing.T) {
+		var status space.ReindexStatus
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/reindex/%s", spaceID, started.JobID), nil)
+			resp, err := ctx.app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != fiber.StatusOK {
+				t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+			}
+			json.NewDecoder(resp.Body).Decode(&status)
+			if status.State != space.ReindexStateRunning {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		if status.State != space.ReindexStateCompleted || status.Processed != 2 || status.Total != 2 {
+			t.Errorf("Expected 2 of 2 notes processed to completion, got %+v", status)
+		}
+	})
+
+	t.Run("UnknownJob", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", fmt.Sprintf("/api/spaces/%s/reindex/missing", spaceID), nil)
 		resp, err := ctx.app.Test(req)
 		if err != nil {
 			t.Fatalf("Request failed: %v", err)
 		}
+		if resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestExportNotesMarkdownEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	captureID, notePath := createTestCapture(t, ctx.tmpDir, "Compost needs turning weekly.")
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, "Compost routine", []string{"compost"})
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/export/markdown?tags=compost", spaceID), nil)
+	resp, err := ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/markdown") {
+		t.Errorf("Expected markdown content type, got %q", contentType)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{"# Test Space", "- **Context:** Compost routine", "Compost needs turning weekly."} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Expected export to contain %q, got:\n%s", want, body)
+		}
+	}
 
+	t.Run("TableFormat", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/export/markdown?format=table", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
 		if resp.StatusCode != fiber.StatusOK {
-			t.Errorf("Expected status 200, got %d", resp.StatusCode)
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
 		}
 
-		var result map[string]interface{}
-		json.NewDecoder(resp.Body).Decode(&result)
+		body, _ := io.ReadAll(resp.Body)
+		if !strings.Contains(string(body), "| File | Tags | Linked | Context |") || !strings.Contains(string(body), "| compost |") {
+			t.Errorf("Expected a notes table, got:\n%s", body)
+		}
+		if strings.Contains(string(body), "Compost needs turning weekly.") {
+			t.Errorf("Expected the table to leave out capture content, got:\n%s", body)
+		}
+	})
 
-		if result["total_notes"] != float64(3) {
-			t.Errorf("Expected total_notes 3, got %v", result["total_notes"])
+	t.Run("UnknownFormat", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/export/markdown?format=csv", spaceID), nil)
+		resp, _ := ctx.app.Test(req)
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
 		}
+	})
+}
 
-		if result["space_id"] != spaceID {
-			t.Errorf("Expected space_id %s, got %v", spaceID, result["space_id"])
+func TestGetNotesGroupedEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+
+	testNotes := []struct {
+		tags []string
+	}{
+		{[]string{"farming", "soil"}},
+		{[]string{"farming", "compost"}},
+		{[]string{"farming"}},
+		{nil},
+	}
+
+	for _, tn := range testNotes {
+		captureID, notePath := createTestCapture(t, ctx.tmpDir, "Content")
+		ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, "Context", tn.tags)
+	}
+
+	t.Run("GroupedWithBucketLimit", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes/grouped?bucket_limit=2", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
 		}
 
-		if result["schema_version"] != "1" {
-			t.Errorf("Expected schema_version 1, got %v", result["schema_version"])
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
 		}
 
-		// Check tables array
-		tables := result["tables"].([]interface{})
-		if len(tables) < 2 {
-			t.Errorf("Expected at least 2 tables, got %d", len(tables))
+		var result struct {
+			Groups      map[string][]interface{} `json:"groups"`
+			BucketLimit int                      `json:"bucket_limit"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+
+		if result.BucketLimit != 2 {
+			t.Errorf("Expected bucket_limit 2, got %d", result.BucketLimit)
+		}
+		if len(result.Groups["farming"]) != 2 {
+			t.Errorf("Expected farming bucket capped at 2, got %d", len(result.Groups["farming"]))
+		}
+		if len(result.Groups["untagged"]) != 1 {
+			t.Errorf("Expected 1 untagged note, got %d", len(result.Groups["untagged"]))
+		}
+	})
+
+	t.Run("SpaceNotFound", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/spaces/nonexistent/notes/grouped", nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		if resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", resp.StatusCode)
 		}
 	})
 }
 
-func TestGetTableDataEndpoint(t *testing.T) {
+func TestBatchGetNotesEndpoint(t *testing.T) {
 	ctx := setupTestApp(t)
 	defer ctx.cleanup()
 
 	spaceID, spacePath := createTestSpace(t, ctx)
 
-	// Link a note
-	captureID, notePath := createTestCapture(t, ctx.tmpDir, "Test")
-	ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, "Context", []string{"tag1"})
+	var ids []string
+	for i := 0; i < 2; i++ {
+		captureID, notePath := createTestCapture(t, ctx.tmpDir, "Content")
+		ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, "Context", nil)
+		ids = append(ids, captureID)
+	}
 
-	t.Run("QueryRelevantNotesTable", func(t *testing.T) {
-		req := httptest.NewRequest("GET",
-			fmt.Sprintf("/api/spaces/%s/database/tables/relevant_notes", spaceID),
-			nil)
+	postBatch := func(t *testing.T, captureIDs []string) *http.Response {
+		body, _ := json.Marshal(handlers.BatchGetNotesRequest{CaptureIDs: captureIDs})
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/notes/batch-get", spaceID), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
 
 		resp, err := ctx.app.Test(req)
 		if err != nil {
 			t.Fatalf("Request failed: %v", err)
 		}
+		return resp
+	}
 
+	t.Run("OnlyExistingReturned", func(t *testing.T) {
+		resp := postBatch(t, []string{ids[0], "nonexistent", ids[1]})
 		if resp.StatusCode != fiber.StatusOK {
-			t.Errorf("Expected status 200, got %d", resp.StatusCode)
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
 		}
 
-		var result map[string]interface{}
+		var result handlers.GetNotesResponse
 		json.NewDecoder(resp.Body).Decode(&result)
 
-		if result["table_name"] != "relevant_notes" {
-			t.Errorf("Expected table_name 'relevant_notes', got %v", result["table_name"])
+		if result.Total != 2 || len(result.Notes) != 2 {
+			t.Errorf("Expected 2 notes, got %d", len(result.Notes))
+		}
+		for _, note := range result.Notes {
+			if note.CaptureID == "nonexistent" {
+				t.Error("Expected nonexistent ID to be omitted")
+			}
 		}
+	})
 
-		if result["row_count"] != float64(1) {
-			t.Errorf("Expected row_count 1, got %v", result["row_count"])
+	t.Run("EmptyIDsRejected", func(t *testing.T) {
+		resp := postBatch(t, nil)
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
 		}
 	})
+}
 
-	t.Run("QueryInvalidTable", func(t *testing.T) {
-		// Use a simple invalid table name (the actual SQL injection protection is tested in unit tests)
-		req := httptest.NewRequest("GET",
-			fmt.Sprintf("/api/spaces/%s/database/tables/invalid_table_name", spaceID),
-			nil)
+func TestLinkFromCapturesEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+
+	// Distinct filenames: createTestCapture names files by the current second
+	var captures []space.CaptureRef
+	for i, content := range []string{"# First\n#alpha", "# Second\nbody", "# Third\n#beta"} {
+		notePath := filepath.Join("captures", fmt.Sprintf("import-%d.md", i))
+		if err := os.WriteFile(filepath.Join(ctx.tmpDir, notePath), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create capture file: %v", err)
+		}
+		captures = append(captures, space.CaptureRef{CaptureID: uuid.New().String(), NotePath: notePath})
+	}
+	captures = append(captures, space.CaptureRef{CaptureID: uuid.New().String(), NotePath: "captures/missing.md"})
 
+	body, _ := json.Marshal(handlers.LinkFromCapturesRequest{Captures: captures, DefaultTags: []string{"imported"}})
+	req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/notes/from-captures", spaceID), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		t.Fatalf("Expected status 200, got %d. Body: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var result space.ImportResult
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	if result.Linked != 3 || result.Failed != 1 {
+		t.Errorf("Expected 3 linked and 1 failed, got %+v", result)
+	}
+
+	note, err := ctx.spaceDBService.GetNoteByID(spacePath, captures[2].CaptureID)
+	if err != nil {
+		t.Fatalf("Expected capture to be linked: %v", err)
+	}
+	if note.Context != "Third" || len(note.Tags) != 2 {
+		t.Errorf("Expected derived context and tags, got %q %v", note.Context, note.Tags)
+	}
+}
+
+func TestBulkTagsEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	taggedID := uuid.New().String()
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, taggedID, filepath.Join("captures", "a.md"), "", []string{"garden", "draft"})
+	otherID := uuid.New().String()
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, otherID, filepath.Join("captures", "b.md"), "", []string{"kitchen"})
+
+	postBulkTags := func(t *testing.T, body string) *http.Response {
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/notes/bulk-tags", spaceID), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
 		resp, err := ctx.app.Test(req)
 		if err != nil {
 			t.Fatalf("Request failed: %v", err)
 		}
+		return resp
+	}
 
-		if resp.StatusCode != fiber.StatusInternalServerError {
-			t.Errorf("Expected status 500 for invalid table, got %d", resp.StatusCode)
+	t.Run("AddAndRemove", func(t *testing.T) {
+		resp := postBulkTags(t, `{"filters": {"tags": ["garden"]}, "add": ["reviewed"], "remove": ["draft"]}`)
+		if resp.StatusCode != fiber.StatusOK {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			t.Fatalf("Expected status 200, got %d. Body: %s", resp.StatusCode, string(bodyBytes))
+		}
+
+		var result struct {
+			Updated    int      `json:"updated"`
+			CaptureIDs []string `json:"capture_ids"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+		if result.Updated != 1 || len(result.CaptureIDs) != 1 || result.CaptureIDs[0] != taggedID {
+			t.Errorf("Expected 1 note updated, got %+v", result)
+		}
+
+		note, _ := ctx.spaceDBService.GetNoteByID(spacePath, taggedID)
+		if fmt.Sprint(note.Tags) != "[garden reviewed]" {
+			t.Errorf("Expected [garden reviewed], got %v", note.Tags)
+		}
+		other, _ := ctx.spaceDBService.GetNoteByID(spacePath, otherID)
+		if fmt.Sprint(other.Tags) != "[kitchen]" {
+			t.Errorf("Expected unmatched note unchanged, got %v", other.Tags)
 		}
 	})
 
-	t.Run("QueryNonExistentTable", func(t *testing.T) {
-		req := httptest.NewRequest("GET",
-			fmt.Sprintf("/api/spaces/%s/database/tables/non_existent", spaceID),
-			nil)
+	t.Run("NoTagsRejected", func(t *testing.T) {
+		resp := postBulkTags(t, `{"filters": {}}`)
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestDecayRelevanceEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, "fresh", "captures/fresh.md", "", nil)
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, "stale", "captures/stale.md", "", nil)
+
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to open space database: %v", err)
+	}
+	db.Exec("UPDATE relevant_notes SET last_referenced = ? WHERE capture_id = 'stale'", time.Now().Add(-14*24*time.Hour).Unix())
+	db.Close()
 
+	decay := func(query string) *http.Response {
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/notes/decay%s", spaceID, query), nil)
 		resp, err := ctx.app.Test(req)
 		if err != nil {
 			t.Fatalf("Request failed: %v", err)
 		}
+		return resp
+	}
 
-		if resp.StatusCode != fiber.StatusInternalServerError {
-			t.Errorf("Expected status 500 for non-existent table, got %d", resp.StatusCode)
+	t.Run("Decay", func(t *testing.T) {
+		resp := decay("?half_life_days=7")
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes?sort=relevance", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		var result handlers.GetNotesResponse
+		json.NewDecoder(resp.Body).Decode(&result)
+		if len(result.Notes) != 2 || result.Notes[0].CaptureID != "fresh" || result.Notes[1].Relevance > 0.3 {
+			t.Errorf("Expected the stale note last near a quarter relevance, got %+v", result.Notes)
+		}
+	})
+
+	t.Run("InvalidHalfLife", func(t *testing.T) {
+		if resp := decay("?half_life_days=-1"); resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
 		}
 	})
 }
+
+func TestBulkUnlinkEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	for _, l := range []struct {
+		captureID string
+		tags      []string
+	}{
+		{"draft-1", []string{"draft"}},
+		{"draft-2", []string{"draft", "garden"}},
+		{"keeper", []string{"garden"}},
+	} {
+		ctx.spaceDBService.LinkNote(spaceID, spacePath, l.captureID, "captures/"+l.captureID+".md", "", l.tags)
+	}
+
+	type unlinkResult struct {
+		Unlinked   int      `json:"unlinked"`
+		CaptureIDs []string `json:"capture_ids"`
+		DryRun     bool     `json:"dry_run"`
+	}
+
+	postBulkUnlink := func(t *testing.T, query string) unlinkResult {
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/notes/bulk-unlink%s", spaceID, query),
+			strings.NewReader(`{"filters": {"tags": ["draft"]}}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			t.Fatalf("Expected status 200, got %d. Body: %s", resp.StatusCode, string(bodyBytes))
+		}
+
+		var result unlinkResult
+		json.NewDecoder(resp.Body).Decode(&result)
+		return result
+	}
+
+	var preview unlinkResult
+
+	t.Run("DryRunListsWithoutUnlinking", func(t *testing.T) {
+		preview = postBulkUnlink(t, "?dry_run=true")
+		if !preview.DryRun || preview.Unlinked != 2 {
+			t.Fatalf("Expected a dry run matching 2 notes, got %+v", preview)
+		}
+
+		notes, _ := ctx.spaceDBService.GetRelevantNotes(spacePath, space.NoteFilters{})
+		if len(notes) != 3 {
+			t.Errorf("Expected all 3 notes still linked after a dry run, got %d", len(notes))
+		}
+	})
+
+	t.Run("RealRunUnlinksTheSameSet", func(t *testing.T) {
+		result := postBulkUnlink(t, "")
+		if result.DryRun || fmt.Sprint(result.CaptureIDs) != fmt.Sprint(preview.CaptureIDs) {
+			t.Errorf("Expected the previewed notes %v unlinked, got %+v", preview.CaptureIDs, result)
+		}
+
+		notes, _ := ctx.spaceDBService.GetRelevantNotes(spacePath, space.NoteFilters{})
+		if len(notes) != 1 || notes[0].CaptureID != "keeper" {
+			t.Errorf("Expected only keeper to remain, got %+v", notes)
+		}
+		if _, err := ctx.spaceDBService.GetNoteByID(spacePath, "draft-1"); err == nil {
+			t.Error("Expected draft-1 to be gone")
+		}
+	})
+
+	t.Run("MissingFiltersRejected", func(t *testing.T) {
+		for _, body := range []string{`{}`, `{"filters": {}}`, `{"filters": {"sort": "priority"}}`} {
+			req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/notes/bulk-unlink", spaceID), strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := ctx.app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != fiber.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", body, resp.StatusCode)
+			}
+		}
+
+		notes, _ := ctx.spaceDBService.GetRelevantNotes(spacePath, space.NoteFilters{})
+		if len(notes) != 1 {
+			t.Errorf("Expected keeper to stay linked, got %d notes", len(notes))
+		}
+	})
+
+	t.Run("InvalidDryRun", func(t *testing.T) {
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/notes/bulk-unlink?dry_run=maybe", spaceID),
+			strings.NewReader(`{"filters": {}, "all": true}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestFindNearDuplicatesEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	os.MkdirAll(filepath.Join(ctx.tmpDir, "captures"), 0755)
+	for _, c := range []struct{ name, content string }{
+		{"a.md", "Remember to water the seedlings in the greenhouse every morning."},
+		{"b.md", "Remember to water the seedlings in the greenhouse every morning please."},
+		{"c.md", "Invoice for the new irrigation pump arrived today."},
+	} {
+		notePath := filepath.Join("captures", c.name)
+		os.WriteFile(filepath.Join(ctx.tmpDir, notePath), []byte(c.content), 0644)
+		ctx.spaceDBService.LinkNote(spaceID, spacePath, uuid.New().String(), notePath, "", nil)
+	}
+
+	t.Run("GroupsSimilarCaptures", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes/duplicates?threshold=0.7", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			t.Fatalf("Expected status 200, got %d. Body: %s", resp.StatusCode, string(bodyBytes))
+		}
+
+		var result struct {
+			Groups []space.DuplicateGroup `json:"groups"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+		if len(result.Groups) != 1 || len(result.Groups[0].Notes) != 2 {
+			t.Fatalf("Expected one group of two notes, got %+v", result.Groups)
+		}
+		if sim := result.Groups[0].Pairs[0].Similarity; sim < 0.7 || sim >= 1 {
+			t.Errorf("Expected a similarity between 0.7 and 1, got %v", sim)
+		}
+	})
+
+	t.Run("InvalidThreshold", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes/duplicates?threshold=abc", spaceID), nil)
+		resp, _ := ctx.app.Test(req)
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestDiffSpacesEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceAID, spaceAPath := createTestSpace(t, ctx)
+	spaceBID, spaceBPath := createTestSpace(t, ctx)
+	sharedID := uuid.New().String()
+	ctx.spaceDBService.LinkNote(spaceAID, spaceAPath, sharedID, filepath.Join("captures", "shared.md"), "Same", []string{"garden"})
+	ctx.spaceDBService.LinkNote(spaceBID, spaceBPath, sharedID, filepath.Join("captures", "shared.md"), "Different", []string{"garden"})
+	onlyAID := uuid.New().String()
+	ctx.spaceDBService.LinkNote(spaceAID, spaceAPath, onlyAID, filepath.Join("captures", "a.md"), "", nil)
+
+	t.Run("Partitions", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/diff?other=%s", spaceAID, spaceBID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			t.Fatalf("Expected status 200, got %d. Body: %s", resp.StatusCode, string(bodyBytes))
+		}
+
+		var diff space.SpaceDiff
+		json.NewDecoder(resp.Body).Decode(&diff)
+		if _, ok := diff.OnlyInA[onlyAID]; !ok || len(diff.OnlyInB) != 0 {
+			t.Errorf("Expected %s only in A and nothing only in B, got %+v", onlyAID, diff)
+		}
+		shared := diff.Shared[sharedID]
+		if len(shared.Differences) != 1 || shared.Differences[0].Field != "context" {
+			t.Errorf("Expected only context to differ, got %+v", shared.Differences)
+		}
+	})
+
+	t.Run("OtherRequired", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/diff", spaceAID), nil)
+		resp, _ := ctx.app.Test(req)
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("UnknownOther", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/diff?other=%s", spaceAID, uuid.New().String()), nil)
+		resp, _ := ctx.app.Test(req)
+		if resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestUpdateNoteContextEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	captureID, notePath := createTestCapture(t, ctx.tmpDir, "Test capture")
+
+	// Link initial note
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, "Original context", []string{"original"})
+
+	t.Run("UpdateContextOnly", func(t *testing.T) {
+		reqBody := map[string]interface{}{
+			"context": "Updated context only",
+		}
+
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest("PUT",
+			fmt.Sprintf("/api/spaces/%s/notes/%s", spaceID, captureID),
+			bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		if resp.StatusCode != fiber.StatusOK {
+			t.Errorf("Expected status 200, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("UpdateTagsOnly", func(t *testing.T) {
+		reqBody := map[string]interface{}{
+			"tags": []string{"new", "tags"},
+		}
+
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest("PUT",
+			fmt.Sprintf("/api/spaces/%s/notes/%s", spaceID, captureID),
+			bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		if resp.StatusCode != fiber.StatusOK {
+			t.Errorf("Expected status 200, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("UpdateBoth", func(t *testing.T) {
+		reqBody := map[string]interface{}{
+			"context": "Both updated",
+			"tags":    []string{"both"},
+		}
+
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest("PUT",
+			fmt.Sprintf("/api/spaces/%s/notes/%s", spaceID, captureID),
+			bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		if resp.StatusCode != fiber.StatusOK {
+			t.Errorf("Expected status 200, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("ErrorNoteNotFound", func(t *testing.T) {
+		nonExistentID := uuid.New().String()
+		reqBody := map[string]interface{}{
+			"context": "Should fail",
+		}
+
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest("PUT",
+			fmt.Sprintf("/api/spaces/%s/notes/%s", spaceID, nonExistentID),
+			bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		if resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("ErrorNoFieldsProvided", func(t *testing.T) {
+		reqBody := map[string]interface{}{}
+
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest("PUT",
+			fmt.Sprintf("/api/spaces/%s/notes/%s", spaceID, captureID),
+			bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestSetNoteStatusEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, "task-1", "captures/task-1.md", "", nil)
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, "task-2", "captures/task-2.md", "", nil)
+
+	setStatus := func(captureID, status string) *http.Response {
+		body, _ := json.Marshal(map[string]string{"status": status})
+		req := httptest.NewRequest("PUT",
+			fmt.Sprintf("/api/spaces/%s/notes/%s/status", spaceID, captureID),
+			bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("SetStatusAndFilter", func(t *testing.T) {
+		if resp := setStatus("task-1", "in_progress"); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes?status=in_progress", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		var result handlers.GetNotesResponse
+		json.NewDecoder(resp.Body).Decode(&result)
+		if len(result.Notes) != 1 || result.Notes[0].CaptureID != "task-1" || result.Notes[0].Status != "in_progress" {
+			t.Errorf("Expected only task-1 in progress, got %+v", result.Notes)
+		}
+	})
+
+	t.Run("InvalidStatus", func(t *testing.T) {
+		if resp := setStatus("task-2", "someday"); resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("UnknownNote", func(t *testing.T) {
+		if resp := setStatus("missing", "done"); resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestSetNoteExpiryEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, "sprint", "captures/sprint.md", "", nil)
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, "roadmap", "captures/roadmap.md", "", nil)
+
+	setExpiry := func(captureID string, body string) *http.Response {
+		req := httptest.NewRequest("PUT",
+			fmt.Sprintf("/api/spaces/%s/notes/%s/expiry", spaceID, captureID),
+			bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp
+	}
+	listNotes := func(query string) []space.RelevantNote {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes%s", spaceID, query), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		var result handlers.GetNotesResponse
+		json.NewDecoder(resp.Body).Decode(&result)
+		return result.Notes
+	}
+
+	t.Run("ExpiredHiddenFromListing", func(t *testing.T) {
+		past := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+		if resp := setExpiry("sprint", fmt.Sprintf(`{"expires_at": %q}`, past)); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		if notes := listNotes(""); len(notes) != 1 || notes[0].CaptureID != "roadmap" {
+			t.Errorf("Expected only the unexpired note, got %+v", notes)
+		}
+		if notes := listNotes("?include_expired=true"); len(notes) != 2 {
+			t.Errorf("Expected both notes when including expired, got %d", len(notes))
+		}
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		if resp := setExpiry("sprint", `{"expires_at": null}`); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		if notes := listNotes(""); len(notes) != 2 {
+			t.Errorf("Expected both notes after clearing expiry, got %d", len(notes))
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		if resp := setExpiry("sprint", `{"expires_at": "next week"}`); resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400 for bad time, got %d", resp.StatusCode)
+		}
+		if resp := setExpiry("missing", `{"expires_at": null}`); resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestGetNoteThreadEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, "root", "captures/root.md", "", nil)
+	ctx.spaceDBService.LinkNoteWithOptions(spaceID, spacePath, "reply", "captures/reply.md", "", nil,
+		space.LinkOptions{ParentCaptureID: "root"})
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, "follow-up", "captures/follow-up.md", "", nil)
+
+	setParent := func(captureID, parentID string) *http.Response {
+		req := httptest.NewRequest("PUT",
+			fmt.Sprintf("/api/spaces/%s/notes/%s", spaceID, captureID),
+			bytes.NewReader([]byte(fmt.Sprintf(`{"parent_capture_id": %q}`, parentID))))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("TwoLevelThread", func(t *testing.T) {
+		if resp := setParent("follow-up", "reply"); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes/root/thread", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var result struct {
+			Notes []space.RelevantNote `json:"notes"`
+			Count int                  `json:"count"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+		if result.Count != 3 || result.Notes[0].CaptureID != "root" || result.Notes[2].ParentCaptureID != "reply" {
+			t.Errorf("Expected root, reply, follow-up, got %+v", result.Notes)
+		}
+	})
+
+	t.Run("RejectsCycle", func(t *testing.T) {
+		if resp := setParent("root", "follow-up"); resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("UnknownNote", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes/missing/thread", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestMoveNoteEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	fromID, fromPath := createTestSpace(t, ctx)
+	toID, toPath := createTestSpace(t, ctx)
+	ctx.spaceDBService.LinkNote(fromID, fromPath, "draft", "captures/draft.md", "Draft context", nil)
+
+	moveNote := func(captureID, body string) *http.Response {
+		req := httptest.NewRequest("POST",
+			fmt.Sprintf("/api/spaces/%s/notes/%s/move", fromID, captureID),
+			bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("Move", func(t *testing.T) {
+		resp := moveNote("draft", fmt.Sprintf(`{"to_space_id": %q}`, toID))
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		note, err := ctx.spaceDBService.GetNoteByID(toPath, "draft")
+		if err != nil {
+			t.Fatalf("Moved note not found in target: %v", err)
+		}
+		if note.Context != "Draft context" {
+			t.Errorf("Expected context to move with the note, got %q", note.Context)
+		}
+		if _, err := ctx.spaceDBService.GetNoteByID(fromPath, "draft"); err == nil {
+			t.Error("Expected note to be gone from the source space")
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		if resp := moveNote("draft", `{}`); resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400 without to_space_id, got %d", resp.StatusCode)
+		}
+		if resp := moveNote("draft", `{"to_space_id": "missing"}`); resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("Expected status 404 for unknown target, got %d", resp.StatusCode)
+		}
+		if resp := moveNote("draft", fmt.Sprintf(`{"to_space_id": %q}`, toID)); resp.StatusCode != fiber.StatusGone {
+			t.Errorf("Expected status 410 for moved note, got %d", resp.StatusCode)
+		}
+		if resp := moveNote("missing", fmt.Sprintf(`{"to_space_id": %q}`, toID)); resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("Expected status 404 for unknown note, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestSetNoteDueEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, "reminder", "captures/reminder.md", "", nil)
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, "someday", "captures/someday.md", "", nil)
+
+	setDue := func(captureID string, body string) *http.Response {
+		req := httptest.NewRequest("PUT",
+			fmt.Sprintf("/api/spaces/%s/notes/%s/due", spaceID, captureID),
+			bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp
+	}
+
+	dueAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+
+	t.Run("SetAndFilterByDueBefore", func(t *testing.T) {
+		if resp := setDue("reminder", fmt.Sprintf(`{"due_at": %q}`, dueAt.Format(time.RFC3339))); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		dueBefore := dueAt.Add(time.Hour).Format(time.RFC3339)
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes?due_before=%s", spaceID, dueBefore), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		var result handlers.GetNotesResponse
+		json.NewDecoder(resp.Body).Decode(&result)
+		if len(result.Notes) != 1 || result.Notes[0].CaptureID != "reminder" {
+			t.Fatalf("Expected only the reminder to be due, got %+v", result.Notes)
+		}
+		if result.Notes[0].DueAt == nil || !result.Notes[0].DueAt.Equal(dueAt) {
+			t.Errorf("Expected due_at %v, got %v", dueAt, result.Notes[0].DueAt)
+		}
+	})
+
+	t.Run("ClearWithNull", func(t *testing.T) {
+		if resp := setDue("reminder", `{"due_at": null}`); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		note, err := ctx.spaceDBService.GetNoteByID(spacePath, "reminder")
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if note.DueAt != nil {
+			t.Errorf("Expected due date to be cleared, got %v", note.DueAt)
+		}
+	})
+
+	t.Run("InvalidTimestamp", func(t *testing.T) {
+		if resp := setDue("someday", `{"due_at": "next tuesday"}`); resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestSetNotePriorityEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, "seeds", "captures/seeds.md", "", nil)
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, "compost", "captures/compost.md", "", nil)
+
+	setPriority := func(captureID string, body string) *http.Response {
+		req := httptest.NewRequest("PUT",
+			fmt.Sprintf("/api/spaces/%s/notes/%s/priority", spaceID, captureID),
+			bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("SetAndSortByPriority", func(t *testing.T) {
+		if resp := setPriority("seeds", `{"priority": 3}`); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes?sort=priority", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		var result handlers.GetNotesResponse
+		json.NewDecoder(resp.Body).Decode(&result)
+		if len(result.Notes) != 2 || result.Notes[0].CaptureID != "seeds" || result.Notes[0].Priority != 3 {
+			t.Errorf("Expected seeds first with priority 3, got %+v", result.Notes)
+		}
+	})
+
+	t.Run("ErrorMissingPriority", func(t *testing.T) {
+		if resp := setPriority("seeds", `{}`); resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("ErrorNoteNotFound", func(t *testing.T) {
+		if resp := setPriority("missing", `{"priority": 1}`); resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestUnlinkNoteEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	captureID, notePath := createTestCapture(t, ctx.tmpDir, "Test capture")
+
+	// Link note
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, "Context", []string{"tag"})
+
+	t.Run("SuccessfulUnlink", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE",
+			fmt.Sprintf("/api/spaces/%s/notes/%s", spaceID, captureID),
+			nil)
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		if resp.StatusCode != fiber.StatusOK {
+			t.Errorf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		// Verify note is gone
+		getReq := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes", spaceID), nil)
+		getResp, _ := ctx.app.Test(getReq)
+
+		var result map[string]interface{}
+		json.NewDecoder(getResp.Body).Decode(&result)
+
+		notes := result["notes"].([]interface{})
+		if len(notes) != 0 {
+			t.Errorf("Expected 0 notes after unlink, got %d", len(notes))
+		}
+	})
+
+	t.Run("ErrorNoteNotFound", func(t *testing.T) {
+		nonExistentID := uuid.New().String()
+		req := httptest.NewRequest("DELETE",
+			fmt.Sprintf("/api/spaces/%s/notes/%s", spaceID, nonExistentID),
+			nil)
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		if resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("ContentOfUnlinkedNoteIsGone", func(t *testing.T) {
+		req := httptest.NewRequest("GET",
+			fmt.Sprintf("/api/spaces/%s/notes/%s/content", spaceID, captureID),
+			nil)
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		if resp.StatusCode != fiber.StatusGone {
+			t.Errorf("Expected status 410, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestGetNoteContentEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	captureContent := "# Test Capture\n\nThis is the content of the capture."
+	captureID, notePath := createTestCapture(t, ctx.tmpDir, captureContent)
+
+	// Link note
+	contextText := "Space-specific context"
+	tags := []string{"test", "content"}
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, contextText, tags)
+
+	t.Run("SuccessfulGetContent", func(t *testing.T) {
+		req := httptest.NewRequest("GET",
+			fmt.Sprintf("/api/spaces/%s/notes/%s/content", spaceID, captureID),
+			nil)
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		if resp.StatusCode != fiber.StatusOK {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			t.Errorf("Expected status 200, got %d. Body: %s", resp.StatusCode, string(bodyBytes))
+		}
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+
+		if result["content"] != captureContent {
+			t.Errorf("Expected content %s, got %v", captureContent, result["content"])
+		}
+
+		if result["encoding"] != "utf-8" {
+			t.Errorf("Expected utf-8 encoding, got %v", result["encoding"])
+		}
+
+		if result["title"] != "Test Capture" {
+			t.Errorf("Expected title from H1, got %v", result["title"])
+		}
+
+		if result["space_context"] != contextText {
+			t.Errorf("Expected space_context %s, got %v", contextText, result["space_context"])
+		}
+
+		if result["capture_id"] != captureID {
+			t.Errorf("Expected capture_id %s, got %v", captureID, result["capture_id"])
+		}
+
+		// Verify tags
+		resultTags := result["tags"].([]interface{})
+		if len(resultTags) != 2 {
+			t.Errorf("Expected 2 tags, got %d", len(resultTags))
+		}
+	})
+
+	t.Run("ErrorNoteNotFound", func(t *testing.T) {
+		nonExistentID := uuid.New().String()
+		req := httptest.NewRequest("GET",
+			fmt.Sprintf("/api/spaces/%s/notes/%s/content", spaceID, nonExistentID),
+			nil)
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		if resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("InvalidUTF8Content", func(t *testing.T) {
+		binaryContent := []byte{'a', 0xff, 0xfe, 'b'}
+		binaryPath := filepath.Join("captures", "binary.md")
+		if err := os.WriteFile(filepath.Join(ctx.tmpDir, binaryPath), binaryContent, 0644); err != nil {
+			t.Fatalf("Failed to write capture: %v", err)
+		}
+		binaryID := uuid.New().String()
+		ctx.spaceDBService.LinkNote(spaceID, spacePath, binaryID, binaryPath, "", nil)
+
+		req := httptest.NewRequest("GET",
+			fmt.Sprintf("/api/spaces/%s/notes/%s/content", spaceID, binaryID),
+			nil)
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var result map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("Response is not valid JSON: %v", err)
+		}
+		if result["encoding"] != "base64" || result["warning"] == nil {
+			t.Errorf("Expected base64 encoding with a warning, got %v and %v", result["encoding"], result["warning"])
+		}
+		decoded, err := base64.StdEncoding.DecodeString(result["content"].(string))
+		if err != nil || !bytes.Equal(decoded, binaryContent) {
+			t.Errorf("Expected content to round-trip through base64, got %v", result["content"])
+		}
+	})
+
+	t.Run("RenderHTML", func(t *testing.T) {
+		req := httptest.NewRequest("GET",
+			fmt.Sprintf("/api/spaces/%s/notes/%s/content?render=html", spaceID, captureID),
+			nil)
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		want := "<h1>Test Capture</h1>\n<p>This is the content of the capture.</p>\n"
+		if result["html"] != want || result["content"] != captureContent {
+			t.Errorf("Expected rendered html alongside content, got %v", result)
+		}
+
+		req = httptest.NewRequest("GET",
+			fmt.Sprintf("/api/spaces/%s/notes/%s/content?render=pdf", spaceID, captureID),
+			nil)
+		resp, _ = ctx.app.Test(req)
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400 for an unknown render, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("LastReferencedTracking", func(t *testing.T) {
+		// Get the note (which should track the reference)
+		req := httptest.NewRequest("GET",
+			fmt.Sprintf("/api/spaces/%s/notes/%s/content", spaceID, captureID),
+			nil)
+		ctx.app.Test(req)
+
+		// Get the note metadata to check last_referenced was set
+		note, _ := ctx.spaceDBService.GetNoteByID(spacePath, captureID)
+		if note.LastReferenced == nil {
+			t.Error("Expected last_referenced to be set after getting content")
+		}
+	})
+}
+
+func TestGetNoteStructureEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	captureContent := "---\ntitle: Walk\n---\n# Walk\n\nNotes on #compost with [[Maria]].\n"
+	captureID, notePath := createTestCapture(t, ctx.tmpDir, captureContent)
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, "Context", nil)
+
+	t.Run("ReturnsStructure", func(t *testing.T) {
+		req := httptest.NewRequest("GET",
+			fmt.Sprintf("/api/spaces/%s/notes/%s/structure", spaceID, captureID),
+			nil)
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			t.Fatalf("Expected status 200, got %d. Body: %s", resp.StatusCode, string(bodyBytes))
+		}
+
+		var result struct {
+			Structure struct {
+				Frontmatter map[string]string `json:"frontmatter"`
+				Headings    []struct {
+					Level int    `json:"level"`
+					Text  string `json:"text"`
+				} `json:"headings"`
+				Tags      []string `json:"tags"`
+				Wikilinks []string `json:"wikilinks"`
+			} `json:"structure"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+
+		structure := result.Structure
+		if structure.Frontmatter["title"] != "Walk" {
+			t.Errorf("Expected frontmatter title Walk, got %v", structure.Frontmatter)
+		}
+		if len(structure.Headings) != 1 || structure.Headings[0].Level != 1 || structure.Headings[0].Text != "Walk" {
+			t.Errorf("Expected a single level 1 heading, got %+v", structure.Headings)
+		}
+		if len(structure.Tags) != 1 || structure.Tags[0] != "compost" {
+			t.Errorf("Expected tags [compost], got %v", structure.Tags)
+		}
+		if len(structure.Wikilinks) != 1 || structure.Wikilinks[0] != "Maria" {
+			t.Errorf("Expected wikilinks [Maria], got %v", structure.Wikilinks)
+		}
+	})
+
+	t.Run("ErrorNoteNotFound", func(t *testing.T) {
+		req := httptest.NewRequest("GET",
+			fmt.Sprintf("/api/spaces/%s/notes/%s/structure", spaceID, uuid.New().String()),
+			nil)
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestGetNoteChunksEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	captureContent := strings.Repeat("We walked the beds and talked about compost. ", 40)
+	captureID, notePath := createTestCapture(t, ctx.tmpDir, captureContent)
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, "Context", nil)
+
+	getChunks := func(t *testing.T, query string) *http.Response {
+		req := httptest.NewRequest("GET",
+			fmt.Sprintf("/api/spaces/%s/notes/%s/chunks%s", spaceID, captureID, query),
+			nil)
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("ReturnsChunks", func(t *testing.T) {
+		resp := getChunks(t, "?size=500&overlap=50")
+		if resp.StatusCode != fiber.StatusOK {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			t.Fatalf("Expected status 200, got %d. Body: %s", resp.StatusCode, string(bodyBytes))
+		}
+
+		var result struct {
+			Total  int          `json:"total"`
+			Chunks []file.Chunk `json:"chunks"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+
+		if result.Total != len(result.Chunks) || result.Total < 4 {
+			t.Fatalf("Expected several chunks, got %d", result.Total)
+		}
+		for i, chunk := range result.Chunks {
+			if len(chunk.Text) > 500 || chunk.Text != captureContent[chunk.Start:chunk.End] {
+				t.Errorf("Chunk %d does not match the capture: %+v", i, chunk)
+			}
+			if !strings.HasPrefix(chunk.Text, "We walked") {
+				t.Errorf("Chunk %d starts mid-sentence: %q", i, chunk.Text[:20])
+			}
+		}
+		if result.Chunks[len(result.Chunks)-1].End != len(captureContent) {
+			t.Error("Expected chunks to reach the end of the capture")
+		}
+	})
+
+	t.Run("ErrorInvalidParams", func(t *testing.T) {
+		for _, query := range []string{"?size=0", "?size=abc", "?size=100&overlap=80", "?overlap=-1"} {
+			if resp := getChunks(t, query); resp.StatusCode != fiber.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", query, resp.StatusCode)
+			}
+		}
+	})
+}
+
+func TestGetDatabaseStatsEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+
+	// Link some notes
+	for i := 0; i < 3; i++ {
+		captureID, notePath := createTestCapture(t, ctx.tmpDir, fmt.Sprintf("Capture %d", i))
+		ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, "Context", []string{"tag1", "tag2"})
+	}
+
+	t.Run("GetStats", func(t *testing.T) {
+		req := httptest.NewRequest("GET",
+			fmt.Sprintf("/api/spaces/%s/database/stats", spaceID),
+			nil)
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		if resp.StatusCode != fiber.StatusOK {
+			t.Errorf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+
+		if result["total_notes"] != float64(3) {
+			t.Errorf("Expected total_notes 3, got %v", result["total_notes"])
+		}
+
+		if result["space_id"] != spaceID {
+			t.Errorf("Expected space_id %s, got %v", spaceID, result["space_id"])
+		}
+
+		expectedVersion := fmt.Sprintf("%d", space.LatestSchemaVersion())
+		if result["schema_version"] != expectedVersion {
+			t.Errorf("Expected schema_version %s, got %v", expectedVersion, result["schema_version"])
+		}
+
+		// Check tables array
+		tables := result["tables"].([]interface{})
+		if len(tables) < 2 {
+			t.Errorf("Expected at least 2 tables, got %d", len(tables))
+		}
+
+		if _, ok := result["content_stats"]; ok {
+			t.Error("Expected content_stats to be omitted without the flag")
+		}
+	})
+
+	t.Run("IncludeContentStats", func(t *testing.T) {
+		req := httptest.NewRequest("GET",
+			fmt.Sprintf("/api/spaces/%s/database/stats?include_content_stats=true", spaceID),
+			nil)
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var result struct {
+			ContentStats *space.NoteContentStats `json:"content_stats"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+
+		if result.ContentStats == nil {
+			t.Fatal("Expected content_stats with the flag")
+		}
+		if result.ContentStats.TotalWords == 0 {
+			t.Error("Expected total_words to be populated")
+		}
+		if result.ContentStats.FilesScanned+result.ContentStats.FilesMissing != 3 {
+			t.Errorf("Expected 3 notes accounted for, got %+v", result.ContentStats)
+		}
+	})
+}
+
+func TestGetTableDataEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+
+	// Link a note
+	captureID, notePath := createTestCapture(t, ctx.tmpDir, "Test")
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, "Context", []string{"tag1"})
+
+	t.Run("QueryRelevantNotesTable", func(t *testing.T) {
+		req := httptest.NewRequest("GET",
+			fmt.Sprintf("/api/spaces/%s/database/tables/relevant_notes", spaceID),
+			nil)
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		if resp.StatusCode != fiber.StatusOK {
+			t.Errorf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+
+		if result["table_name"] != "relevant_notes" {
+			t.Errorf("Expected table_name 'relevant_notes', got %v", result["table_name"])
+		}
+
+		if result["row_count"] != float64(1) {
+			t.Errorf("Expected row_count 1, got %v", result["row_count"])
+		}
+
+		columnTypes, _ := result["column_types"].([]interface{})
+		if len(columnTypes) == 0 {
+			t.Fatal("Expected column_types in result")
+		}
+		for _, c := range columnTypes {
+			col := c.(map[string]interface{})
+			if col["name"] == "tags" && (col["type"] != "TEXT" || col["json"] != true) {
+				t.Errorf("Expected tags to be JSON TEXT, got %v", col)
+			}
+		}
+	})
+
+	t.Run("QueryInvalidTable", func(t *testing.T) {
+		// Use a simple invalid table name (the actual SQL injection protection is tested in unit tests)
+		req := httptest.NewRequest("GET",
+			fmt.Sprintf("/api/spaces/%s/database/tables/invalid_table_name", spaceID),
+			nil)
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		if resp.StatusCode != fiber.StatusInternalServerError {
+			t.Errorf("Expected status 500 for invalid table, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("QueryNonExistentTable", func(t *testing.T) {
+		req := httptest.NewRequest("GET",
+			fmt.Sprintf("/api/spaces/%s/database/tables/non_existent", spaceID),
+			nil)
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		if resp.StatusCode != fiber.StatusInternalServerError {
+			t.Errorf("Expected status 500 for non-existent table, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestEstimateContextEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, _ := createTestSpace(t, ctx)
+
+	t.Run("KnownModel", func(t *testing.T) {
+		req := httptest.NewRequest("GET",
+			fmt.Sprintf("/api/spaces/%s/context/estimate?model=gpt-4o", spaceID),
+			nil)
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		if resp.StatusCode != fiber.StatusOK {
+			t.Errorf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+
+		if result["fits"] != true {
+			t.Errorf("Expected default SPACE.md to fit, got %v", result["fits"])
+		}
+		if result["estimated_tokens"].(float64) <= 0 {
+			t.Errorf("Expected a positive token estimate, got %v", result["estimated_tokens"])
+		}
+	})
+
+	t.Run("UnknownModel", func(t *testing.T) {
+		req := httptest.NewRequest("GET",
+			fmt.Sprintf("/api/spaces/%s/context/estimate?model=unknown", spaceID),
+			nil)
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestContextVersionEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+
+	getVersion := func(t *testing.T) string {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/context/version", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		return resp.Header.Get(handlers.ContextVersionHeader)
+	}
+
+	if v := getVersion(t); v != "0" {
+		t.Errorf("Expected initial version 0, got %q", v)
+	}
+
+	captureID, notePath := createTestCapture(t, ctx.tmpDir, "Content")
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, "Context", nil)
+
+	if v := getVersion(t); v != "1" {
+		t.Errorf("Expected version 1 after linking, got %q", v)
+	}
+
+	t.Run("NotesListCarriesVersion", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if v := resp.Header.Get(handlers.ContextVersionHeader); v != "1" {
+			t.Errorf("Expected notes list to report version 1, got %q", v)
+		}
+	})
+}
+
+func TestReadOnlySpaceEndpoints(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	captureID, notePath := createTestCapture(t, ctx.tmpDir, "Content")
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, "Context", nil)
+
+	readOnly := true
+	if _, err := ctx.spaceService.Update(context.Background(), spaceID, space.UpdateSpaceParams{ReadOnly: &readOnly}); err != nil {
+		t.Fatalf("Failed to mark space read-only: %v", err)
+	}
+
+	t.Run("LinkRejected", func(t *testing.T) {
+		otherID, otherPath := createTestCapture(t, ctx.tmpDir, "Other")
+		body, _ := json.Marshal(map[string]interface{}{
+			"capture_id": otherID,
+			"note_path":  otherPath,
+		})
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/notes", spaceID), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusForbidden {
+			t.Errorf("Expected status 403, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("UnlinkRejected", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", fmt.Sprintf("/api/spaces/%s/notes/%s", spaceID, captureID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusForbidden {
+			t.Errorf("Expected status 403, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("ListStillWorks", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Errorf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		if notes := result["notes"].([]interface{}); len(notes) != 1 {
+			t.Errorf("Expected 1 note, got %d", len(notes))
+		}
+	})
+}
+
+func TestSpaceSettingsEndpoints(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, _ := createTestSpace(t, ctx)
+
+	putSetting := func(t *testing.T, key, value string) *http.Response {
+		body, _ := json.Marshal(handlers.SetSpaceSettingRequest{Value: value})
+		req := httptest.NewRequest("PUT", fmt.Sprintf("/api/spaces/%s/settings/%s", spaceID, key), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("CapturesDirOverrideResolvesContent", func(t *testing.T) {
+		resp := putSetting(t, "captures_dir", "research/captures")
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		researchDir := filepath.Join(ctx.tmpDir, "research", "captures")
+		os.MkdirAll(researchDir, 0755)
+		os.WriteFile(filepath.Join(researchDir, "2024-07-01_09-00-00.md"), []byte("Research capture"), 0644)
+
+		captureID := uuid.New().String()
+		body, _ := json.Marshal(handlers.LinkNoteRequest{CaptureID: captureID, NotePath: "2024-07-01_09-00-00.md"})
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/notes", spaceID), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if resp, err := ctx.app.Test(req); err != nil || resp.StatusCode != fiber.StatusCreated {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+
+		req = httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes/%s/content", spaceID, captureID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		if result["content"] != "Research capture" {
+			t.Errorf("Expected content from research/captures, got %v", result["content"])
+		}
+	})
+
+	t.Run("TraversalRejected", func(t *testing.T) {
+		resp := putSetting(t, "captures_dir", "../../etc")
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("UnknownSettingRejected", func(t *testing.T) {
+		resp := putSetting(t, "not_a_setting", "x")
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("ListSettings", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/settings", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		var result struct {
+			Settings map[string]string `json:"settings"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+		if result.Settings["captures_dir"] != "research/captures" {
+			t.Errorf("Expected captures_dir research/captures, got %q", result.Settings["captures_dir"])
+		}
+	})
+
+	t.Run("BulkCopyToAnotherSpace", func(t *testing.T) {
+		putSetting(t, "recent_notes_order", "linked")
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/settings/bulk", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		exported, _ := io.ReadAll(resp.Body)
+
+		targetID, _ := createTestSpace(t, ctx)
+		req = httptest.NewRequest("PUT", fmt.Sprintf("/api/spaces/%s/settings/bulk", targetID), bytes.NewReader(exported))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err = ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var result struct {
+			Settings map[string]string `json:"settings"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+		if result.Settings["captures_dir"] != "research/captures" || result.Settings["recent_notes_order"] != "linked" {
+			t.Errorf("Expected the source space's settings, got %v", result.Settings)
+		}
+	})
+
+	t.Run("BulkRejectsReservedKey", func(t *testing.T) {
+		body, _ := json.Marshal(handlers.ImportSpaceSettingsRequest{Settings: map[string]string{"space_id": "other"}})
+		req := httptest.NewRequest("PUT", fmt.Sprintf("/api/spaces/%s/settings/bulk", spaceID), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("BulkMalformedJSON", func(t *testing.T) {
+		req := httptest.NewRequest("PUT", fmt.Sprintf("/api/spaces/%s/settings/bulk", spaceID), strings.NewReader(`{"settings": [`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		if resp.StatusCode != fiber.StatusBadRequest || result["error"] != "invalid JSON body" {
+			t.Errorf("Expected an invalid JSON body error, got %d %v", resp.StatusCode, result)
+		}
+	})
+}
+
+func TestPinnedContextEndpoints(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+
+	type pinnedResponse struct {
+		PinnedContext string `json:"pinned_context"`
+		Position      string `json:"position"`
+	}
+	do := func(t *testing.T, method, body string) (*http.Response, pinnedResponse) {
+		req := httptest.NewRequest(method, fmt.Sprintf("/api/spaces/%s/pinned-context", spaceID), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		var result pinnedResponse
+		json.NewDecoder(resp.Body).Decode(&result)
+		return resp, result
+	}
+
+	t.Run("SetAndRender", func(t *testing.T) {
+		resp, result := do(t, "PUT", `{"pinned_context": "Cite note filenames.", "position": "bottom"}`)
+		if resp.StatusCode != fiber.StatusOK || result.PinnedContext != "Cite note filenames." || result.Position != "bottom" {
+			t.Fatalf("Expected pinned context to be set, got %d %+v", resp.StatusCode, result)
+		}
+
+		if _, result := do(t, "GET", ""); result.PinnedContext != "Cite note filenames." {
+			t.Errorf("Expected pinned context to persist, got %+v", result)
+		}
+
+		rendered, err := space.NewContextService(ctx.spaceDBService).RenderSpaceMD(&space.Space{ID: spaceID, Path: spacePath})
+		if err != nil {
+			t.Fatalf("Failed to render: %v", err)
+		}
+		if !strings.HasSuffix(rendered, "<!-- pinned context -->\nCite note filenames.\n<!-- /pinned context -->\n") {
+			t.Errorf("Expected the pinned block after SPACE.md, got %q", rendered)
+		}
+	})
+
+	t.Run("InvalidPosition", func(t *testing.T) {
+		if resp, _ := do(t, "PUT", `{"pinned_context": "x", "position": "middle"}`); resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("MalformedJSON", func(t *testing.T) {
+		req := httptest.NewRequest("PUT", fmt.Sprintf("/api/spaces/%s/pinned-context", spaceID), strings.NewReader(`{"pinned_context": 42}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		if resp.StatusCode != fiber.StatusBadRequest || result["error"] != "invalid JSON body" {
+			t.Errorf("Expected an invalid JSON body error, got %d %v", resp.StatusCode, result)
+		}
+	})
+}
+
+func TestSavedSearchEndpoints(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+
+	gardenID := uuid.New().String()
+	os.WriteFile(filepath.Join(ctx.tmpDir, "captures", "garden.md"), []byte("Garden"), 0644)
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, gardenID, filepath.Join("captures", "garden.md"), "", []string{"garden"})
+	kitchenID := uuid.New().String()
+	os.WriteFile(filepath.Join(ctx.tmpDir, "captures", "kitchen.md"), []byte("Kitchen"), 0644)
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, kitchenID, filepath.Join("captures", "kitchen.md"), "", []string{"kitchen"})
+
+	t.Run("SaveAndRun", func(t *testing.T) {
+		body := []byte(`{"filters": {"tags": ["garden"], "start_date": "2020-01-01T00:00:00Z"}}`)
+		req := httptest.NewRequest("PUT", fmt.Sprintf("/api/spaces/%s/saved-searches/Garden%%20notes", spaceID), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			t.Fatalf("Expected status 200, got %d. Body: %s", resp.StatusCode, string(bodyBytes))
+		}
+
+		var saved space.SavedSearch
+		json.NewDecoder(resp.Body).Decode(&saved)
+		if saved.Name != "Garden notes" || saved.Filters.StartDate == nil {
+			t.Errorf("Expected saved search with a start date, got %+v", saved)
+		}
+
+		req = httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/saved-searches/Garden%%20notes/notes", spaceID), nil)
+		resp, err = ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		var result handlers.GetNotesResponse
+		json.NewDecoder(resp.Body).Decode(&result)
+		if result.Total != 1 || result.Notes[0].CaptureID != gardenID {
+			t.Errorf("Expected only the garden note, got %+v", result)
+		}
+	})
+
+	t.Run("ListSavedSearches", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/saved-searches", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		var result struct {
+			SavedSearches []space.SavedSearch `json:"saved_searches"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+		if len(result.SavedSearches) != 1 || result.SavedSearches[0].Name != "Garden notes" {
+			t.Errorf("Expected one saved search, got %+v", result.SavedSearches)
+		}
+	})
+
+	t.Run("InvalidStatusRejected", func(t *testing.T) {
+		body := []byte(`{"filters": {"status": "someday"}}`)
+		req := httptest.NewRequest("PUT", fmt.Sprintf("/api/spaces/%s/saved-searches/bad", spaceID), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("ExportMarkdown", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/saved-searches/Garden%%20notes/export?format=markdown", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/markdown") {
+			t.Errorf("Expected markdown content type, got %q", ct)
+		}
+		if cd := resp.Header.Get("Content-Disposition"); !strings.Contains(cd, `filename="Garden-notes.md"`) {
+			t.Errorf("Expected a sanitized download filename, got %q", cd)
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		doc := string(body)
+		if !strings.Contains(doc, "## garden.md") || !strings.Contains(doc, "Garden notes") {
+			t.Errorf("Expected the garden note under the search name, got %q", doc)
+		}
+		if strings.Contains(doc, "kitchen.md") {
+			t.Errorf("Expected the kitchen note left out, got %q", doc)
+		}
+	})
+
+	t.Run("ExportJSON", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/saved-searches/Garden%%20notes/export?format=json", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var export handlers.SavedSearchExport
+		json.NewDecoder(resp.Body).Decode(&export)
+		if export.Name != "Garden notes" || export.Total != 1 || export.Notes[0].CaptureID != gardenID {
+			t.Errorf("Expected only the garden note, got %+v", export)
+		}
+	})
+
+	t.Run("ExportUnknownFormat", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/saved-searches/Garden%%20notes/export?format=pdf", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("DeleteSavedSearch", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", fmt.Sprintf("/api/spaces/%s/saved-searches/Garden%%20notes", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		req = httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/saved-searches/Garden%%20notes/notes", spaceID), nil)
+		resp, err = ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("Expected status 404 after delete, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestCollectionEndpoints(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	for _, id := range []string{"first", "second"} {
+		ctx.spaceDBService.LinkNote(spaceID, spacePath, id, "captures/"+id+".md", "", nil)
+	}
+
+	send := func(method, path, body string) *http.Response {
+		req := httptest.NewRequest(method, fmt.Sprintf("/api/spaces/%s/collections%s", spaceID, path), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp
+	}
+
+	resp := send("POST", "", `{"name": "Reading list"}`)
+	if resp.StatusCode != fiber.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+	}
+	var collection space.Collection
+	json.NewDecoder(resp.Body).Decode(&collection)
+
+	if resp := send("POST", "/"+collection.ID+"/notes", `{"capture_ids": ["second", "first"]}`); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status 200 adding notes, got %d", resp.StatusCode)
+	}
+	if resp := send("PUT", "/"+collection.ID+"/notes", `{"capture_ids": ["first", "second"]}`); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status 200 reordering, got %d", resp.StatusCode)
+	}
+
+	resp = send("GET", "/"+collection.ID+"/notes", "")
+	var result handlers.GetNotesResponse
+	json.NewDecoder(resp.Body).Decode(&result)
+	if result.Total != 2 || result.Notes[0].CaptureID != "first" || result.Notes[1].CaptureID != "second" {
+		t.Errorf("Expected first then second, got %+v", result.Notes)
+	}
+
+	t.Run("Errors", func(t *testing.T) {
+		if resp := send("POST", "/"+collection.ID+"/notes", `{"capture_ids": ["nope"]}`); resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400 for an unlinked note, got %d", resp.StatusCode)
+		}
+		if resp := send("POST", "", `{"name": "Reading list"}`); resp.StatusCode != fiber.StatusConflict {
+			t.Errorf("Expected status 409 for a duplicate name, got %d", resp.StatusCode)
+		}
+		if resp := send("GET", "/missing/notes", ""); resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("Expected status 404 for an unknown collection, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if resp := send("DELETE", "/"+collection.ID, ""); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		if resp := send("GET", "/"+collection.ID, ""); resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("Expected status 404 after delete, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestMoveFileEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	sourceID, sourcePath := createTestSpace(t, ctx)
+	targetID, targetPath := createTestSpace(t, ctx)
+	os.WriteFile(filepath.Join(sourcePath, "files", "notes.txt"), []byte("hello"), 0644)
+
+	move := func(t *testing.T, body string) *http.Response {
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/files/move", sourceID), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("MovesFile", func(t *testing.T) {
+		resp := move(t, fmt.Sprintf(`{"to_space_id": %q, "path": "notes.txt"}`, targetID))
+		if resp.StatusCode != fiber.StatusOK {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			t.Fatalf("Expected status 200, got %d. Body: %s", resp.StatusCode, string(bodyBytes))
+		}
+
+		var result map[string]string
+		json.NewDecoder(resp.Body).Decode(&result)
+		if result["path"] != "notes.txt" || result["space_id"] != targetID {
+			t.Errorf("Expected notes.txt in the target space, got %v", result)
+		}
+		if _, err := os.Stat(filepath.Join(targetPath, "files", "notes.txt")); err != nil {
+			t.Errorf("Expected file in target space: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(sourcePath, "files", "notes.txt")); !os.IsNotExist(err) {
+			t.Error("Expected file to be gone from the source space")
+		}
+	})
+
+	t.Run("TraversalRejected", func(t *testing.T) {
+		resp := move(t, fmt.Sprintf(`{"to_space_id": %q, "path": "../space.sqlite"}`, targetID))
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("MissingFile", func(t *testing.T) {
+		resp := move(t, fmt.Sprintf(`{"to_space_id": %q, "path": "notes.txt"}`, targetID))
+		if resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestContextHistoryEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	spaceObj, _ := ctx.spaceService.GetByID(context.Background(), spaceID)
+
+	ctx.spaceDBService.SetSetting(spacePath, space.SettingContextAudit, "true")
+	if _, err := ctx.contextService.RenderSpaceMD(spaceObj); err != nil {
+		t.Fatalf("Failed to render context: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/context/history", spaceID), nil)
+	resp, err := ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var result struct {
+		History []space.ContextHistoryEntry `json:"history"`
+		Total   int                         `json:"total"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	if result.Total != 1 {
+		t.Fatalf("Expected 1 history entry, got %d", result.Total)
+	}
+	if result.History[0].Content == "" {
+		t.Error("Expected rendered content in history entry")
+	}
+}
+
+func TestFreezeContextEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	spaceObj, _ := ctx.spaceService.GetByID(context.Background(), spaceID)
+	os.WriteFile(filepath.Join(spacePath, "SPACE.md"), []byte("Notes: {{note_count}}"), 0644)
+
+	req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/context/freeze", spaceID), nil)
+	resp, err := ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var frozen space.FrozenContext
+	json.NewDecoder(resp.Body).Decode(&frozen)
+	if frozen.Content != "Notes: 0" {
+		t.Errorf("Expected frozen content %q, got %q", "Notes: 0", frozen.Content)
+	}
+
+	captureID, notePath := createTestCapture(t, ctx.tmpDir, "Later note")
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, "", nil)
+
+	rendered, err := ctx.contextService.RenderSpaceMD(spaceObj)
+	if err != nil {
+		t.Fatalf("Failed to render context: %v", err)
+	}
+	if rendered != "Notes: 0" {
+		t.Errorf("Expected frozen render %q, got %q", "Notes: 0", rendered)
+	}
+
+	req = httptest.NewRequest("DELETE", fmt.Sprintf("/api/spaces/%s/context/freeze", spaceID), nil)
+	resp, err = ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", resp.StatusCode)
+	}
+
+	rendered, err = ctx.contextService.RenderSpaceMD(spaceObj)
+	if err != nil {
+		t.Fatalf("Failed to render context: %v", err)
+	}
+	if rendered != "Notes: 1" {
+		t.Errorf("Expected live render %q, got %q", "Notes: 1", rendered)
+	}
+}
+
+func TestPreviewVariablesEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	os.WriteFile(filepath.Join(spacePath, "SPACE.md"), []byte("{{note_count}} notes\n{{recent_notes}}"), 0644)
+
+	if err := ctx.contextService.SetAllowedVariables([]string{"note_count"}); err != nil {
+		t.Fatalf("Failed to set allowlist: %v", err)
+	}
+	defer ctx.contextService.SetAllowedVariables(nil)
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/context/variables", spaceID), nil)
+	resp, err := ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Variables []space.VariablePreview `json:"variables"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	if len(result.Variables) != 2 {
+		t.Fatalf("Expected 2 variables, got %+v", result.Variables)
+	}
+	if v := result.Variables[0]; v.Name != "note_count" || !v.Allowed || v.Value != "0" {
+		t.Errorf("Expected note_count allowed with value 0, got %+v", v)
+	}
+	if v := result.Variables[1]; v.Name != "recent_notes" || v.Allowed {
+		t.Errorf("Expected recent_notes disallowed, got %+v", v)
+	}
+}
+
+func TestSuggestContextEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, _ := createTestSpace(t, ctx)
+	captureID, notePath := createTestCapture(t, ctx.tmpDir, "# Harvest\n\nPicked the first tomatoes today.\n")
+
+	suggest := func(query string) *http.Response {
+		req := httptest.NewRequest("GET",
+			fmt.Sprintf("/api/spaces/%s/notes/%s/suggest-context%s", spaceID, captureID, query), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("UnlinkedCaptureWithPath", func(t *testing.T) {
+		resp := suggest("?note_path=" + notePath)
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var result map[string]string
+		json.NewDecoder(resp.Body).Decode(&result)
+		if result["suggestion"] != "Picked the first tomatoes today." {
+			t.Errorf("Expected opening text as suggestion, got %q", result["suggestion"])
+		}
+	})
+
+	t.Run("UnlinkedCaptureWithoutPath", func(t *testing.T) {
+		if resp := suggest(""); resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestStructuredContextEndpoints(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	captureID, notePath := createTestCapture(t, ctx.tmpDir, "Structured capture")
+
+	t.Run("LinkWithStructuredContext", func(t *testing.T) {
+		reqBody := map[string]interface{}{
+			"capture_id": captureID,
+			"note_path":  notePath,
+			"context":    "Plain context",
+			"context_structured": map[string]interface{}{
+				"why_relevant": "Sets project direction",
+				"key_points":   []string{"one", "two"},
+				"source":       "meeting",
+			},
+		}
+
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/notes", spaceID), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusCreated {
+			t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+		}
+
+		note, err := ctx.spaceDBService.GetNoteByID(spacePath, captureID)
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if note.ContextStructured["why_relevant"] != "Sets project direction" {
+			t.Errorf("Expected structured context to be stored, got %v", note.ContextStructured)
+		}
+		if note.Context != "Plain context" {
+			t.Errorf("Expected plain context to be stored, got %s", note.Context)
+		}
+	})
+
+	t.Run("UpdateStructuredContextOnly", func(t *testing.T) {
+		reqBody := map[string]interface{}{
+			"context_structured": map[string]interface{}{"summary": "Updated summary"},
+		}
+
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest("PUT",
+			fmt.Sprintf("/api/spaces/%s/notes/%s", spaceID, captureID),
+			bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		getReq := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes", spaceID), nil)
+		getResp, _ := ctx.app.Test(getReq)
+
+		var result map[string]interface{}
+		json.NewDecoder(getResp.Body).Decode(&result)
+
+		notes := result["notes"].([]interface{})
+		structured := notes[0].(map[string]interface{})["context_structured"].(map[string]interface{})
+		if structured["summary"] != "Updated summary" {
+			t.Errorf("Expected updated summary in listing, got %v", structured)
+		}
+	})
+
+	t.Run("RejectInvalidStructuredContext", func(t *testing.T) {
+		reqBody := map[string]interface{}{
+			"context_structured": map[string]interface{}{"summary": 42},
+		}
+
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest("PUT",
+			fmt.Sprintf("/api/spaces/%s/notes/%s", spaceID, captureID),
+			bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+}
+
+// newUploadRequest builds a multipart capture upload request
+func newUploadRequest(t *testing.T, timestamp time.Time, idempotencyKey string) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreateFormFile("audio", "capture.wav")
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	part.Write([]byte("RIFF fake audio"))
+	writer.WriteField("timestamp", timestamp.Format(time.RFC3339))
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/api/captures/upload", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if idempotencyKey != "" {
+		req.Header.Set(handlers.IdempotencyKeyHeader, idempotencyKey)
+	}
+	return req
+}
+
+func TestIdempotencyKeys(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	t.Run("ReplayedUploadDoesNotCreateSecondCapture", func(t *testing.T) {
+		timestamp := time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)
+		key := uuid.New().String()
+
+		var ids []string
+		for i := 0; i < 2; i++ {
+			resp, err := ctx.app.Test(newUploadRequest(t, timestamp, key))
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != fiber.StatusCreated {
+				t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+			}
+
+			replayed := resp.Header.Get(handlers.IdempotentReplayedHeader) == "true"
+			if replayed != (i == 1) {
+				t.Errorf("Request %d: expected replayed=%v, got %v", i, i == 1, replayed)
+			}
+
+			var result map[string]interface{}
+			json.NewDecoder(resp.Body).Decode(&result)
+			ids = append(ids, result["id"].(string))
+		}
+
+		if ids[0] != ids[1] {
+			t.Errorf("Expected replay to return the original capture %s, got %s", ids[0], ids[1])
+		}
+
+		req := httptest.NewRequest("GET", "/api/captures/", nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		var list struct {
+			Captures []struct {
+				ID string `json:"id"`
+			} `json:"captures"`
+			Total int `json:"total"`
+		}
+		json.NewDecoder(resp.Body).Decode(&list)
+		if list.Total != 1 {
+			t.Fatalf("Expected 1 capture after replay, got %d", list.Total)
+		}
+		// Re-executing the upload would have rewritten the metadata with a new ID
+		if list.Captures[0].ID != ids[0] {
+			t.Errorf("Expected stored capture %s to be untouched, got %s", ids[0], list.Captures[0].ID)
+		}
+	})
+
+	t.Run("KeysAreScopedToPath", func(t *testing.T) {
+		spaceID, _ := createTestSpace(t, ctx)
+		otherSpaceID, _ := createTestSpace(t, ctx)
+		captureID, notePath := createTestCapture(t, ctx.tmpDir, "Content")
+		key := uuid.New().String()
+
+		for _, id := range []string{spaceID, otherSpaceID} {
+			body, _ := json.Marshal(map[string]interface{}{
+				"capture_id": captureID,
+				"note_path":  notePath,
+				"context":    "Context",
+			})
+			req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/notes", id), bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(handlers.IdempotencyKeyHeader, key)
+
+			resp, err := ctx.app.Test(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.Header.Get(handlers.IdempotentReplayedHeader) != "" {
+				t.Errorf("Expected same key on a different space not to be replayed")
+			}
+		}
+	})
+
+	t.Run("NoKeyPassesThrough", func(t *testing.T) {
+		timestamp := time.Date(2024, 5, 2, 8, 30, 0, 0, time.UTC)
+
+		resp, err := ctx.app.Test(newUploadRequest(t, timestamp, ""))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.Header.Get(handlers.IdempotentReplayedHeader) != "" {
+			t.Error("Expected request without key not to be replayed")
+		}
+	})
+}
+
+func TestLinkNoteFrontmatterTags(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	captureID, notePath := createTestCapture(t, ctx.tmpDir, "---\ntags: [soil, garden]\n---\nPH was 6.2.\n")
+
+	link := func(t *testing.T, spaceID, body string) {
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/notes", spaceID), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusCreated {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			t.Fatalf("Expected status 201, got %d. Body: %s", resp.StatusCode, string(bodyBytes))
+		}
+	}
+
+	t.Run("Inherited", func(t *testing.T) {
+		spaceID, spacePath := createTestSpace(t, ctx)
+		link(t, spaceID, fmt.Sprintf(`{"capture_id": %q, "note_path": %q, "tags": ["garden", "compost"]}`, captureID, notePath))
+
+		note, err := ctx.spaceDBService.GetNoteByID(spacePath, captureID)
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if got := strings.Join(note.Tags, ","); got != "garden,compost,soil" {
+			t.Errorf("Expected garden,compost,soil, got %s", got)
+		}
+	})
+
+	t.Run("OptOut", func(t *testing.T) {
+		spaceID, spacePath := createTestSpace(t, ctx)
+		link(t, spaceID, fmt.Sprintf(`{"capture_id": %q, "note_path": %q, "tags": ["compost"], "inherit_frontmatter_tags": false}`, captureID, notePath))
+
+		note, err := ctx.spaceDBService.GetNoteByID(spacePath, captureID)
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if got := strings.Join(note.Tags, ","); got != "compost" {
+			t.Errorf("Expected only the provided tags, got %s", got)
+		}
+	})
+}
+
+func TestFeaturedNotesEndpoints(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	var captureIDs []string
+	for _, name := range []string{"first", "second"} {
+		captureID := uuid.New().String()
+		if err := ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, "captures/"+name+".md", "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		captureIDs = append(captureIDs, captureID)
+	}
+
+	put := func(t *testing.T, body string) *http.Response {
+		req := httptest.NewRequest("PUT", fmt.Sprintf("/api/spaces/%s/featured-notes", spaceID), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("SetsInOrder", func(t *testing.T) {
+		resp := put(t, fmt.Sprintf(`{"capture_ids": [%q, %q]}`, captureIDs[1], captureIDs[0]))
+		if resp.StatusCode != fiber.StatusOK {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			t.Fatalf("Expected status 200, got %d. Body: %s", resp.StatusCode, string(bodyBytes))
+		}
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/featured-notes", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		var result handlers.GetNotesResponse
+		json.NewDecoder(resp.Body).Decode(&result)
+		if result.Total != 2 || result.Notes[0].CaptureID != captureIDs[1] || result.Notes[1].CaptureID != captureIDs[0] {
+			t.Errorf("Expected second then first, got %+v", result.Notes)
+		}
+	})
+
+	t.Run("UnlinkedNoteRejected", func(t *testing.T) {
+		resp := put(t, `{"capture_ids": ["not-linked"]}`)
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestVaultTagStatsEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	// The endpoint reads the "default" user's spaces
+	for name, tags := range map[string][]string{"Vault A": {"shared", "only-a"}, "Vault B": {"shared"}} {
+		sp, err := ctx.spaceService.Create(context.Background(), "default", space.CreateSpaceParams{Name: name})
+		if err != nil {
+			t.Fatalf("Failed to create space: %v", err)
+		}
+		if err := ctx.spaceDBService.InitializeSpaceDatabase(sp.ID, sp.Path); err != nil {
+			t.Fatalf("Failed to initialize space database: %v", err)
+		}
+		if err := ctx.spaceDBService.LinkNote(sp.ID, sp.Path, uuid.New().String(), "captures/note.md", "", tags); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/tags/vault", nil)
+	resp, err := ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var stats space.VaultTagStats
+	json.NewDecoder(resp.Body).Decode(&stats)
+	if len(stats.Tags) != 2 || stats.Tags[0].Tag != "shared" || stats.Tags[0].Count != 2 || len(stats.Tags[0].Spaces) != 2 {
+		t.Errorf("Expected shared in both spaces first, got %+v", stats.Tags)
+	}
+}
+
+func TestVaultReportEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	// The endpoint checks the "default" user's spaces
+	var paths []string
+	for _, name := range []string{"Report A", "Report B"} {
+		sp, err := ctx.spaceService.Create(context.Background(), "default", space.CreateSpaceParams{Name: name})
+		if err != nil {
+			t.Fatalf("Failed to create space: %v", err)
+		}
+		if err := ctx.spaceDBService.InitializeSpaceDatabase(sp.ID, sp.Path); err != nil {
+			t.Fatalf("Failed to initialize space database: %v", err)
+		}
+		paths = append(paths, sp.Path)
+	}
+	if err := os.WriteFile(filepath.Join(paths[1], "space.sqlite"), []byte("garbage"), 0644); err != nil {
+		t.Fatalf("Failed to corrupt database: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/admin/vault-report", nil)
+	resp, err := ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var report space.VaultReport
+	json.NewDecoder(resp.Body).Decode(&report)
+	if len(report.Spaces) != 2 || report.Healthy != 1 || report.Unhealthy != 1 {
+		t.Errorf("Expected one healthy and one unhealthy space, got %+v", report)
+	}
+}
+
+func TestPurgeTrashEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	captureID := uuid.New().String()
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, "captures/trash.md", "", nil)
+	ctx.spaceDBService.UnlinkNote(spacePath, captureID)
+
+	purge := func(t *testing.T, query string) (*http.Response, map[string]int) {
+		req := httptest.NewRequest("POST", "/api/admin/purge-trash"+query, nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		var result map[string]int
+		json.NewDecoder(resp.Body).Decode(&result)
+		return resp, result
+	}
+
+	t.Run("DefaultRetentionKeepsRecent", func(t *testing.T) {
+		resp, result := purge(t, "")
+		if resp.StatusCode != fiber.StatusOK || result["purged"] != 0 || result["retention_days"] != 30 {
+			t.Errorf("Expected nothing purged with 30 days retention, got %d %v", resp.StatusCode, result)
+		}
+	})
+
+	t.Run("ZeroDaysPurgesAll", func(t *testing.T) {
+		time.Sleep(1100 * time.Millisecond) // deleted_at has second precision
+		resp, result := purge(t, "?days=0")
+		if resp.StatusCode != fiber.StatusOK || result["purged"] != 1 {
+			t.Errorf("Expected 1 purged, got %d %v", resp.StatusCode, result)
+		}
+	})
+
+	t.Run("InvalidDays", func(t *testing.T) {
+		if resp, _ := purge(t, "?days=-1"); resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestLinkNoteCapturedAt(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	if err := ctx.spaceDBService.LinkNote(spaceID, spacePath, "recent", "captures/2024-04-01_10-00-00.md", "", nil); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	body := `{"capture_id": "migrated", "note_path": "captures/2024-05-01_10-00-00.md", "captured_at": "2019-06-01T08:00:00Z"}`
+	req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/notes", spaceID), strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusCreated {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		t.Fatalf("Expected status 201, got %d. Body: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	t.Run("SortByCapturedAt", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes?sort=captured_at", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+
+		var result handlers.GetNotesResponse
+		json.NewDecoder(resp.Body).Decode(&result)
+		if len(result.Notes) != 2 || result.Notes[0].CaptureID != "recent" || result.Notes[1].CaptureID != "migrated" {
+			t.Fatalf("Expected recent before migrated, got %+v", result.Notes)
+		}
+		want := time.Date(2019, 6, 1, 8, 0, 0, 0, time.UTC)
+		if got := result.Notes[1].CapturedAt; got == nil || !got.Equal(want) {
+			t.Errorf("Expected captured_at %v, got %v", want, got)
+		}
+	})
+
+	t.Run("InvalidSort", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes?sort=title", spaceID), nil)
+		resp, _ := ctx.app.Test(req)
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestListSupportedVariablesEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	req := httptest.NewRequest("GET", "/api/context/variables", nil)
+	resp, err := ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Variables []space.VariableSpec `json:"variables"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	found := false
+	for _, spec := range result.Variables {
+		if spec.Name == "notes_tagged" {
+			found = spec.Parameterized
+		}
+	}
+	if len(result.Variables) != len(space.ContextVariables) || !found {
+		t.Errorf("Expected every variable with notes_tagged parameterized, got %+v", result.Variables)
+	}
+}
+
+func TestRenameCaptureEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	// The endpoint renames across the "default" user's spaces
+	var spaces []*space.Space
+	for _, name := range []string{"Rename A", "Rename B"} {
+		sp, err := ctx.spaceService.Create(context.Background(), "default", space.CreateSpaceParams{Name: name})
+		if err != nil {
+			t.Fatalf("Failed to create space: %v", err)
+		}
+		if err := ctx.spaceDBService.InitializeSpaceDatabase(sp.ID, sp.Path); err != nil {
+			t.Fatalf("Failed to initialize space database: %v", err)
+		}
+		if err := ctx.spaceDBService.LinkNote(sp.ID, sp.Path, "walk", "captures/walk.md", "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+		spaces = append(spaces, sp)
+	}
+	if err := ctx.spaceDBService.LinkNote(spaces[0].ID, spaces[0].Path, "other", "captures/other.md", "", nil); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	rename := func(t *testing.T, body string) (*http.Response, map[string]interface{}) {
+		req := httptest.NewRequest("POST", "/api/captures/walk/rename", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		return resp, result
+	}
+
+	t.Run("UpdatesEverySpace", func(t *testing.T) {
+		resp, result := rename(t, `{"old_path": "captures/walk.md", "new_path": "captures/garden-walk.md"}`)
+		if resp.StatusCode != fiber.StatusOK || result["spaces_updated"] != float64(2) {
+			t.Fatalf("Expected 2 spaces updated, got %d %v", resp.StatusCode, result)
+		}
+
+		for _, sp := range spaces {
+			note, err := ctx.spaceDBService.GetNoteByID(sp.Path, "walk")
+			if err != nil || note.NotePath != "captures/garden-walk.md" {
+				t.Errorf("Expected %s to link the new path, got %v %v", sp.Name, note, err)
+			}
+		}
+		if note, _ := ctx.spaceDBService.GetNoteByID(spaces[0].Path, "other"); note == nil || note.NotePath != "captures/other.md" {
+			t.Errorf("Expected unrelated link untouched, got %v", note)
+		}
+	})
+
+	t.Run("PathTraversal", func(t *testing.T) {
+		resp, _ := rename(t, `{"old_path": "captures/garden-walk.md", "new_path": "../outside.md"}`)
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestWriteRateLimitResponses(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, _ := createTestSpace(t, ctx)
+	ctx.spaceDBService.SetWriteRateLimit(space.WriteRateLimit{PerSecond: 10, Burst: 2})
+
+	link := func(t *testing.T, i int) *http.Response {
+		body := fmt.Sprintf(`{"capture_id": "note-%d", "note_path": "captures/note-%d.md"}`, i, i)
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/notes", spaceID), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("RapidWritesLimited", func(t *testing.T) {
+		limited := 0
+		for i := 0; i < 5; i++ {
+			resp := link(t, i)
+			switch resp.StatusCode {
+			case fiber.StatusCreated:
+			case fiber.StatusTooManyRequests:
+				limited++
+				if resp.Header.Get("Retry-After") != "1" {
+					t.Errorf("Expected Retry-After 1, got %q", resp.Header.Get("Retry-After"))
+				}
+			default:
+				t.Fatalf("Unexpected status %d", resp.StatusCode)
+			}
+		}
+		if limited != 3 {
+			t.Errorf("Expected 3 writes beyond the burst to get 429, got %d", limited)
+		}
+	})
+
+	t.Run("ResumesAfterRefill", func(t *testing.T) {
+		time.Sleep(150 * time.Millisecond)
+		if resp := link(t, 5); resp.StatusCode != fiber.StatusCreated {
+			t.Errorf("Expected status 201 after the bucket refilled, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestUntaggedNotesEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	for captureID, tags := range map[string][]string{"bare-1": {}, "bare-2": {}, "tagged": {"soil"}} {
+		if err := ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, "captures/"+captureID+".md", "", tags); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes/untagged?limit=1", spaceID), nil)
+	resp, err := ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var result handlers.GetUntaggedNotesResponse
+	json.NewDecoder(resp.Body).Decode(&result)
+	if result.UntaggedCount != 2 || len(result.Notes) != 1 || result.Notes[0].CaptureID == "tagged" {
+		t.Errorf("Expected one page of 2 untagged notes, got %+v", result)
+	}
+}
+
+func TestExportManifestEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	if err := ctx.spaceDBService.LinkNote(spaceID, spacePath, "walk", "captures/walk.md", "Garden walk", nil); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/manifest", spaceID), nil)
+	resp, err := ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	data, err := os.ReadFile(filepath.Join(spacePath, space.NotesManifestFile))
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+	var manifest space.NotesManifest
+	json.Unmarshal(data, &manifest)
+	if len(manifest.Notes) != 1 || manifest.Notes[0].Context != "Garden walk" {
+		t.Errorf("Expected the linked note in the manifest, got %+v", manifest)
+	}
+}
+
+func TestSearchCapturesEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	captureID, notePath := createTestCapture(t, ctx.tmpDir, "Soil pH was 6.2 this morning.\n")
+
+	// The endpoint searches the "default" user's spaces
+	for _, name := range []string{"Search A", "Search B"} {
+		sp, err := ctx.spaceService.Create(context.Background(), "default", space.CreateSpaceParams{Name: name})
+		if err != nil {
+			t.Fatalf("Failed to create space: %v", err)
+		}
+		if err := ctx.spaceDBService.InitializeSpaceDatabase(sp.ID, sp.Path); err != nil {
+			t.Fatalf("Failed to initialize space database: %v", err)
+		}
+		if err := ctx.spaceDBService.LinkNote(sp.ID, sp.Path, captureID, notePath, "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	t.Run("Deduplicated", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/captures/search?q=soil+ph", nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var result struct {
+			Results []space.CaptureSearchResult `json:"results"`
+			Total   int                         `json:"total"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+		if result.Total != 1 || len(result.Results) != 1 || len(result.Results[0].Spaces) != 2 {
+			t.Errorf("Expected one result linked in two spaces, got %+v", result)
+		}
+	})
+
+	t.Run("MissingQuery", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/captures/search", nil)
+		resp, _ := ctx.app.Test(req)
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestListUnlinkedCapturesEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	for _, name := range []string{"filed.md", "inbox.md", "later/nested.md"} {
+		fullPath := filepath.Join(ctx.tmpDir, "captures", name)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create captures directory: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte("# "+name+"\n"), 0644); err != nil {
+			t.Fatalf("Failed to write capture: %v", err)
+		}
+	}
+
+	sp, err := ctx.spaceService.Create(context.Background(), "default", space.CreateSpaceParams{Name: "Unlinked"})
+	if err != nil {
+		t.Fatalf("Failed to create space: %v", err)
+	}
+	if err := ctx.spaceDBService.InitializeSpaceDatabase(sp.ID, sp.Path); err != nil {
+		t.Fatalf("Failed to initialize space database: %v", err)
+	}
+	if err := ctx.spaceDBService.LinkNote(sp.ID, sp.Path, "filed", "captures/filed.md", "", nil); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/captures/unlinked", nil)
+	resp, err := ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Captures []space.CaptureFile `json:"captures"`
+		Total    int                 `json:"total"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if result.Total != 2 || result.Captures[0].NotePath != "captures/inbox.md" || result.Captures[1].NotePath != "captures/later/nested.md" {
+		t.Errorf("Expected the two unlinked captures, got %+v", result)
+	}
+}
+
+func TestExportAllUserDataEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	captureID, notePath := createTestCapture(t, ctx.tmpDir, "Exported note.\n")
+
+	// The endpoint exports the "default" user's spaces
+	sp, err := ctx.spaceService.Create(context.Background(), "default", space.CreateSpaceParams{Name: "Export Me"})
+	if err != nil {
+		t.Fatalf("Failed to create space: %v", err)
+	}
+	if err := ctx.spaceDBService.InitializeSpaceDatabase(sp.ID, sp.Path); err != nil {
+		t.Fatalf("Failed to initialize space database: %v", err)
+	}
+	if err := ctx.spaceDBService.LinkNote(sp.ID, sp.Path, captureID, notePath, "", nil); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/export/all", nil)
+	resp, err := ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK || resp.Header.Get("Content-Type") != "application/zip" {
+		t.Fatalf("Expected a 200 zip, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("Failed to read archive: %v", err)
+	}
+
+	names := make(map[string]bool)
+	for _, f := range zr.File {
+		names[f.Name] = true
+	}
+	if !names["manifest.json"] || !names[filepath.ToSlash(notePath)] {
+		t.Errorf("Expected the manifest and the linked capture, got %v", names)
+	}
+	if !names["spaces/"+filepath.Base(sp.Path)+"/space.sqlite"] {
+		t.Errorf("Expected the space's database, got %v", names)
+	}
+}
+
+func TestExplainLinkEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	if err := ctx.spaceDBService.LinkNote(spaceID, spacePath, "walk", "captures/walk.md", "", []string{"garden"}); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	t.Run("Manual", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes/walk/explain", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var explanation space.LinkExplanation
+		json.NewDecoder(resp.Body).Decode(&explanation)
+		if explanation.Source != space.LinkSourceManual || len(explanation.Tags) != 1 {
+			t.Errorf("Expected a manual link with its tag, got %+v", explanation)
+		}
+	})
+
+	t.Run("NotLinked", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes/missing/explain", spaceID), nil)
+		resp, _ := ctx.app.Test(req)
+		if resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestNoteAttachmentsEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+
+	imagesDir := filepath.Join(ctx.tmpDir, "captures", "images")
+	os.MkdirAll(imagesDir, 0755)
+	os.WriteFile(filepath.Join(imagesDir, "beds.jpg"), []byte("data"), 0644)
+
+	captureID, notePath := createTestCapture(t, ctx.tmpDir, "![Beds](images/beds.jpg)\n![Gone](images/gone.png)\n")
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, "", nil)
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes/%s/attachments", spaceID, captureID), nil)
+	resp, err := ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Attachments []space.NoteAttachment `json:"attachments"`
+		Total       int                    `json:"total"`
+		Missing     int                    `json:"missing"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	if result.Total != 2 || result.Missing != 1 {
+		t.Fatalf("Expected 2 attachments with 1 missing, got %+v", result)
+	}
+	if !result.Attachments[0].Exists || result.Attachments[0].ResolvedPath != "captures/images/beds.jpg" {
+		t.Errorf("Expected beds.jpg to resolve and exist, got %+v", result.Attachments[0])
+	}
+	if result.Attachments[1].Exists {
+		t.Errorf("Expected gone.png to be flagged missing, got %+v", result.Attachments[1])
+	}
+}
+
+func TestSpaceTemplateEndpoints(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	os.WriteFile(filepath.Join(spacePath, "SPACE.md"), []byte("Notes: {{note_count}}"), 0644)
+	ctx.spaceDBService.SetSetting(spacePath, space.SettingContextAudit, "true")
+	captureID, notePath := createTestCapture(t, ctx.tmpDir, "Linked note")
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, "", nil)
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/export/template", spaceID), nil)
+	resp, err := ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var template space.SpaceTemplate
+	json.NewDecoder(resp.Body).Decode(&template)
+	if template.SpaceMD != "Notes: {{note_count}}" {
+		t.Errorf("Expected unresolved SPACE.md, got %q", template.SpaceMD)
+	}
+	if template.Settings[space.SettingContextAudit] != "true" {
+		t.Errorf("Expected context_audit in template settings, got %v", template.Settings)
+	}
+
+	body, _ := json.Marshal(space.CreateSpaceParams{Name: "From Template", Template: &template})
+	req = httptest.NewRequest("POST", "/api/spaces/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err = ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+	}
+
+	var created space.Space
+	json.NewDecoder(resp.Body).Decode(&created)
+
+	if value, _ := ctx.spaceDBService.GetSetting(created.Path, space.SettingContextAudit); value != "true" {
+		t.Errorf("Expected context_audit applied to the new space, got %q", value)
+	}
+	notes, _ := ctx.spaceDBService.GetRelevantNotes(created.Path, space.NoteFilters{})
+	if len(notes) != 0 {
+		t.Errorf("Expected no linked notes in the new space, got %d", len(notes))
+	}
+}
+
+func TestGetDatabaseStatusEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	getStatus := func(t *testing.T, spaceID string) space.DBStatus {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/database/status", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var status space.DBStatus
+		json.NewDecoder(resp.Body).Decode(&status)
+		return status
+	}
+
+	t.Run("Uninitialized", func(t *testing.T) {
+		sp, err := ctx.spaceService.Create(context.Background(), "test-user", space.CreateSpaceParams{Name: "No Database"})
+		if err != nil {
+			t.Fatalf("Failed to create space: %v", err)
+		}
+
+		status := getStatus(t, sp.ID)
+		if status.Initialized || status.LatestVersion != space.LatestSchemaVersion() {
+			t.Errorf("Expected an uninitialized database, got %+v", status)
+		}
+	})
+
+	t.Run("UpToDate", func(t *testing.T) {
+		spaceID, _ := createTestSpace(t, ctx)
+
+		status := getStatus(t, spaceID)
+		if !status.Initialized || status.NeedsMigration || status.SchemaVersion != space.LatestSchemaVersion() {
+			t.Errorf("Expected an up-to-date database, got %+v", status)
+		}
+	})
+}
+
+func TestNoteReminderEndpoints(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	ctx.spaceDBService.LinkNote(spaceID, spacePath, "seeds", "captures/seeds.md", "", nil)
+
+	reminderRequest := func(method, captureID, body string) *http.Response {
+		req := httptest.NewRequest(method,
+			fmt.Sprintf("/api/spaces/%s/notes/%s/reminder", spaceID, captureID),
+			bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp
+	}
+	activeReminders := func(t *testing.T) string {
+		result, err := ctx.contextService.ResolveVariables("{{active_reminders}}", spacePath)
+		if err != nil {
+			t.Fatalf("Failed to resolve variables: %v", err)
+		}
+		return result
+	}
+
+	past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+	t.Run("Set", func(t *testing.T) {
+		resp := reminderRequest("PUT", "seeds", fmt.Sprintf(`{"text": "Order seeds", "at": %q}`, past))
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		if got := activeReminders(t); !strings.Contains(got, "Order seeds") {
+			t.Errorf("Expected the reminder to be active, got %q", got)
+		}
+	})
+
+	t.Run("Dismiss", func(t *testing.T) {
+		if resp := reminderRequest("DELETE", "seeds", ""); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		if got := activeReminders(t); got != "none" {
+			t.Errorf("Expected no active reminders, got %q", got)
+		}
+	})
+
+	t.Run("MissingText", func(t *testing.T) {
+		resp := reminderRequest("PUT", "seeds", fmt.Sprintf(`{"at": %q}`, past))
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("NotLinked", func(t *testing.T) {
+		resp := reminderRequest("PUT", "missing", fmt.Sprintf(`{"text": "Hi", "at": %q}`, past))
+		if resp.StatusCode != fiber.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestNoteSourceURLEndpoints(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	if err := ctx.spaceDBService.LinkNote(spaceID, spacePath, "typed", "captures/typed.md", "", nil); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	link := func(captureID, notePath, sourceURL string) *http.Response {
+		body, _ := json.Marshal(map[string]interface{}{
+			"capture_id": captureID,
+			"note_path":  notePath,
+			"source_url": sourceURL,
+		})
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/notes", spaceID), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("LinkWithSourceURL", func(t *testing.T) {
+		captureID, notePath := createTestCapture(t, ctx.tmpDir, "Clipped article")
+		if resp := link(captureID, notePath, "https://example.com/article"); resp.StatusCode != fiber.StatusCreated {
+			t.Fatalf("Expected status 201, got %d", resp.StatusCode)
+		}
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes?has_source=true", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var result handlers.GetNotesResponse
+		json.NewDecoder(resp.Body).Decode(&result)
+		if len(result.Notes) != 1 || result.Notes[0].CaptureID != captureID || result.Notes[0].SourceURL != "https://example.com/article" {
+			t.Errorf("Expected only the clipped note with its source URL, got %+v", result.Notes)
+		}
+	})
+
+	t.Run("RejectsInvalidURL", func(t *testing.T) {
+		captureID, notePath := createTestCapture(t, ctx.tmpDir, "Bad source")
+		if resp := link(captureID, notePath, "not a url"); resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+
+		req := httptest.NewRequest("PUT", fmt.Sprintf("/api/spaces/%s/notes/typed", spaceID),
+			bytes.NewReader([]byte(`{"source_url": "ftp://example.com/file"}`)))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("SetViaUpdate", func(t *testing.T) {
+		req := httptest.NewRequest("PUT", fmt.Sprintf("/api/spaces/%s/notes/typed", spaceID),
+			bytes.NewReader([]byte(`{"source_url": "https://example.org/post"}`)))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		note, err := ctx.spaceDBService.GetNoteByID(spacePath, "typed")
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if note.SourceURL != "https://example.org/post" {
+			t.Errorf("Expected the source URL to be set, got %q", note.SourceURL)
+		}
+	})
+}
+
+func TestPreviewContextEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	for _, captureID := range []string{"walk", "soil"} {
+		if err := ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, "captures/"+captureID+".md", "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	spaceMDPath := filepath.Join(spacePath, "SPACE.md")
+	before, err := os.ReadFile(spaceMDPath)
+	if err != nil {
+		t.Fatalf("Failed to read SPACE.md: %v", err)
+	}
+
+	preview := func(body string) *http.Response {
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/spaces/%s/context/preview", spaceID), bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("ResolvesDraft", func(t *testing.T) {
+		resp := preview(`{"template": "# Draft\nNotes: {{note_count}}"}`)
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		var result map[string]string
+		json.NewDecoder(resp.Body).Decode(&result)
+		if result["output"] != "# Draft\nNotes: 2" {
+			t.Errorf("Expected the draft resolved against 2 notes, got %q", result["output"])
+		}
+
+		after, err := os.ReadFile(spaceMDPath)
+		if err != nil {
+			t.Fatalf("Failed to read SPACE.md: %v", err)
+		}
+		if !bytes.Equal(before, after) {
+			t.Errorf("Expected SPACE.md to be unchanged, got %q", after)
+		}
+	})
+
+	t.Run("MissingTemplate", func(t *testing.T) {
+		if resp := preview(`{}`); resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestStaleBackupsEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	// The endpoint lists the "default" user's spaces
+	var ids []string
+	for _, name := range []string{"Exported", "Stale"} {
+		sp, err := ctx.spaceService.Create(context.Background(), "default", space.CreateSpaceParams{Name: name})
+		if err != nil {
+			t.Fatalf("Failed to create space: %v", err)
+		}
+		if err := ctx.spaceDBService.InitializeSpaceDatabase(sp.ID, sp.Path); err != nil {
+			t.Fatalf("Failed to initialize space database: %v", err)
+		}
+		ids = append(ids, sp.ID)
+	}
+	exportedID, staleID := ids[0], ids[1]
+
+	listStale := func() []space.StaleBackup {
+		req := httptest.NewRequest("GET", "/api/spaces/stale-backups?days=30", nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		var result struct {
+			Spaces []space.StaleBackup `json:"spaces"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+		return result.Spaces
+	}
+
+	if stale := listStale(); len(stale) != 2 {
+		t.Fatalf("Expected both spaces before any export, got %d", len(stale))
+	}
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/export/markdown", exportedID), nil)
+	resp, err := ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	req = httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/database/stats", exportedID), nil)
+	resp, err = ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	var stats space.SpaceDatabaseStats
+	json.NewDecoder(resp.Body).Decode(&stats)
+	if stats.LastExportedAt == nil || time.Since(*stats.LastExportedAt) > time.Minute || stats.DaysSinceBackup == nil || *stats.DaysSinceBackup != 0 {
+		t.Errorf("Expected the export to be recorded just now, got %+v", stats.BackupStatus)
+	}
+
+	stale := listStale()
+	if len(stale) != 1 || stale[0].Space.ID != staleID || stale[0].LastExportedAt != nil {
+		t.Errorf("Expected only the unexported space, got %+v", stale)
+	}
+
+	req = httptest.NewRequest("GET", "/api/spaces/stale-backups?days=0", nil)
+	resp, err = ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestMetadataKeyFilterEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	for _, captureID := range []string{"rated", "unrated"} {
+		if err := ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, "captures/"+captureID+".md", "", nil); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(spacePath, "space.sqlite"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE relevant_notes SET metadata = '{"rating": 5}' WHERE capture_id = 'rated'`); err != nil {
+		t.Fatalf("Failed to update metadata: %v", err)
+	}
+	db.Close()
+
+	for query, want := range map[string]string{"has_meta=rating": "rated", "missing_meta=rating": "unrated"} {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes?%s", spaceID, query), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d", query, resp.StatusCode)
+		}
+
+		var result handlers.GetNotesResponse
+		json.NewDecoder(resp.Body).Decode(&result)
+		if len(result.Notes) != 1 || result.Notes[0].CaptureID != want {
+			t.Errorf("Expected only %s for %s, got %+v", want, query, result.Notes)
+		}
+	}
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes?has_meta=%%22", spaceID), nil)
+	resp, err := ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestGetNoteContentSizeLimit(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	captureID, notePath := createTestCapture(t, ctx.tmpDir, strings.Repeat("a", 2048))
+	if err := ctx.spaceDBService.LinkNote(spaceID, spacePath, captureID, notePath, "", nil); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+
+	getContent := func() int {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/notes/%s/content", spaceID, captureID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		return resp.StatusCode
+	}
+
+	if status := getContent(); status != fiber.StatusOK {
+		t.Fatalf("Expected status 200 under the default limit, got %d", status)
+	}
+
+	ctx.spaceDBService.SetMaxCaptureSize(1024)
+	if status := getContent(); status != fiber.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413 over the limit, got %d", status)
+	}
+}
+
+func TestSuggestTagsEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	// The endpoint reads the "default" user's spaces
+	for name, tags := range map[string][]string{"Suggest A": {"shared", "shelf"}, "Suggest B": {"shared", "other"}} {
+		sp, err := ctx.spaceService.Create(context.Background(), "default", space.CreateSpaceParams{Name: name})
+		if err != nil {
+			t.Fatalf("Failed to create space: %v", err)
+		}
+		if err := ctx.spaceDBService.InitializeSpaceDatabase(sp.ID, sp.Path); err != nil {
+			t.Fatalf("Failed to initialize space database: %v", err)
+		}
+		if err := ctx.spaceDBService.LinkNote(sp.ID, sp.Path, uuid.New().String(), "captures/note.md", "", tags); err != nil {
+			t.Fatalf("Failed to link note: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/tags/suggest?q=sh", nil)
+	resp, err := ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Suggestions []space.TagSuggestion `json:"suggestions"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	want := []space.TagSuggestion{{Tag: "shared", Count: 2, SpaceCount: 2}, {Tag: "shelf", Count: 1, SpaceCount: 1}}
+	if len(result.Suggestions) != 2 || result.Suggestions[0] != want[0] || result.Suggestions[1] != want[1] {
+		t.Errorf("Expected %+v, got %+v", want, result.Suggestions)
+	}
+
+	req = httptest.NewRequest("GET", "/api/tags/suggest?q=sh&limit=0", nil)
+	resp, err = ctx.app.Test(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestContextNoticesEndpoint(t *testing.T) {
+	ctx := setupTestApp(t)
+	defer ctx.cleanup()
+
+	notices := func(spaceID string) []space.ContextNotice {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%s/context/variables", spaceID), nil)
+		resp, err := ctx.app.Test(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		var result struct {
+			Notices []space.ContextNotice `json:"notices"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+		return result.Notices
+	}
+
+	spaceID, spacePath := createTestSpace(t, ctx)
+	if got := notices(spaceID); len(got) != 1 || got[0].Code != space.NoticeNoNotes {
+		t.Errorf("Expected a no notes notice for an empty space, got %+v", got)
+	}
+
+	if err := ctx.spaceDBService.LinkNote(spaceID, spacePath, "walk", "captures/walk.md", "", nil); err != nil {
+		t.Fatalf("Failed to link note: %v", err)
+	}
+	if got := notices(spaceID); len(got) != 0 {
+		t.Errorf("Expected no notices for a populated space, got %+v", got)
+	}
+
+	if err := os.Remove(filepath.Join(spacePath, "SPACE.md")); err != nil {
+		t.Fatalf("Failed to remove SPACE.md: %v", err)
+	}
+	if got := notices(spaceID); len(got) != 1 || got[0].Code != space.NoticeNoContextFile {
+		t.Errorf("Expected a no context file notice, got %+v", got)
+	}
+}